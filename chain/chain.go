@@ -186,6 +186,10 @@ func (c *singleChain) ConcurrencyLevel() int {
 	}
 }
 
+func (c *singleChain) OptimisticExecution() bool {
+	return c.cfg.OptimisticExec
+}
+
 func (c *singleChain) NormalTxPoolSize() int {
 	if c.cfg.NormalTxPoolSize > 0 {
 		return c.cfg.NormalTxPoolSize
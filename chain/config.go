@@ -39,6 +39,7 @@ type Config struct {
 	SeedAddr         string `json:"seed_addr"`
 	Role             uint   `json:"role"`
 	ConcurrencyLevel int    `json:"concurrency_level,omitempty"`
+	OptimisticExec   bool   `json:"optimistic_exec,omitempty"`
 	NormalTxPoolSize int    `json:"normal_tx_pool,omitempty"`
 	PatchTxPoolSize  int    `json:"patch_tx_pool,omitempty"`
 	MaxBlockTxBytes  int    `json:"max_block_tx_bytes,omitempty"`
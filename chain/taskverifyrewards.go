@@ -0,0 +1,137 @@
+/*
+ * Copyright 2021 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chain
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+
+	"github.com/icon-project/goloop/chain/base"
+	"github.com/icon-project/goloop/common/errors"
+)
+
+const (
+	VerifyRewardsTask = "verify_rewards"
+)
+
+// rewardVerifier is implemented by platforms which can verify the reward
+// calculation of a term. It returns the report of the verification, and an
+// error if the verification fails.
+type rewardVerifier interface {
+	VerifyRewards(c base.Chain, term int64) (interface{}, error)
+}
+
+type verifyRewardsParams struct {
+	Term int64 `json:"term"`
+}
+
+var verifyRewardsStates = map[State]string{
+	Starting: "verify rewards starting",
+	Started:  "verify rewards started",
+	Stopping: "verify rewards stopping",
+	Failed:   "verify rewards failed",
+	Finished: "verify rewards done",
+}
+
+type taskVerifyRewards struct {
+	chain  *singleChain
+	term   int64
+	result resultStore
+}
+
+func (t *taskVerifyRewards) String() string {
+	return fmt.Sprintf("VerifyRewards(term=%d)", t.term)
+}
+
+func (t *taskVerifyRewards) DetailOf(s State) string {
+	if name, ok := verifyRewardsStates[s]; ok {
+		return name
+	} else {
+		return s.String()
+	}
+}
+
+func (t *taskVerifyRewards) Start() error {
+	rv, ok := t.chain.plt.(rewardVerifier)
+	if !ok {
+		return errors.UnsupportedError.New("UnsupportedFeatureVerifyRewards")
+	}
+	if err := t.chain.prepareManagers(); err != nil {
+		return err
+	}
+	go t.doVerify(rv)
+	return nil
+}
+
+func (t *taskVerifyRewards) doVerify(rv rewardVerifier) {
+	err := t._verify(rv)
+	t.result.SetValue(err)
+}
+
+func (t *taskVerifyRewards) _verify(rv rewardVerifier) error {
+	c := t.chain
+	defer c.releaseManagers()
+
+	report, err := rv.VerifyRewards(c, t.term)
+	if report != nil {
+		if werr := t._writeReport(report); werr != nil && err == nil {
+			err = werr
+		}
+	}
+	return err
+}
+
+func (t *taskVerifyRewards) _writeReport(report interface{}) error {
+	bs, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	file := path.Join(t.chain.cfg.AbsBaseDir(), fmt.Sprintf("verify_rewards_%d.json", t.term))
+	if err := ioutil.WriteFile(file, bs, 0644); err != nil {
+		return errors.UnknownError.Wrapf(err, "fail to write report=%s", file)
+	}
+	t.chain.logger.Infof("Write report of reward verification to %s", file)
+	return nil
+}
+
+func (t *taskVerifyRewards) Stop() {
+	// do nothing (it's hard to stop)
+}
+
+func (t *taskVerifyRewards) Wait() error {
+	return t.result.Wait()
+}
+
+func taskVerifyRewardsFactory(c *singleChain, params json.RawMessage) (chainTask, error) {
+	p := new(verifyRewardsParams)
+	if err := json.Unmarshal(params, p); err != nil {
+		return nil, err
+	}
+	if p.Term < 0 {
+		return nil, errors.IllegalArgumentError.Errorf("InvalidTerm(term=%d)", p.Term)
+	}
+	return &taskVerifyRewards{
+		chain: c,
+		term:  p.Term,
+	}, nil
+}
+
+func init() {
+	registerTaskFactory(VerifyRewardsTask, taskVerifyRewardsFactory)
+}
@@ -4,9 +4,11 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"net/http"
 	"net/url"
 	"reflect"
+	"sort"
 	"strings"
 	"time"
 
@@ -20,6 +22,7 @@ import (
 	"github.com/icon-project/goloop/server"
 	"github.com/icon-project/goloop/server/jsonrpc"
 	v3 "github.com/icon-project/goloop/server/v3"
+	"github.com/icon-project/goloop/service/state"
 	"github.com/icon-project/goloop/service/transaction"
 )
 
@@ -450,3 +453,126 @@ func (c *ClientV3) EstimateStep(param *v3.TransactionParamForEstimate) (*common.
 	}
 	return &result, nil
 }
+
+func (c *ClientV3) EstimateStepDetails(param *v3.TransactionParamForEstimate) (*v3.StepEstimationResult, error) {
+	if len(c.DebugEndPoint) == 0 {
+		return nil, errors.InvalidStateError.New("UnavailableDebugEndPoint")
+	}
+	param.Timestamp = jsonrpc.HexInt(intconv.FormatInt(time.Now().UnixNano() / int64(time.Microsecond)))
+	result := &v3.StepEstimationResult{}
+	if _, err := c.DoURL(c.DebugEndPoint,
+		"debug_estimateStepDetails", param, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetIScoreHistory queries rewards of the account for each term recorded
+// by the node. Zero size means the default size of the node.
+func (c *ClientV3) GetIScoreHistory(addr jsonrpc.Address, start, size int64) (map[string]interface{}, error) {
+	params := map[string]interface{}{
+		"address": addr,
+	}
+	if start != 0 {
+		params["start"] = jsonrpc.HexInt(intconv.FormatInt(start))
+	}
+	if size != 0 {
+		params["size"] = jsonrpc.HexInt(intconv.FormatInt(size))
+	}
+	param := &v3.CallParam{
+		ToAddress: jsonrpc.Address(state.SystemAddress.String()),
+		DataType:  "call",
+		Data: map[string]interface{}{
+			"method": "getIScoreHistory",
+			"params": params,
+		},
+	}
+	var result map[string]interface{}
+	if _, err := c.Do("icx_call", param, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetUnlockSchedule queries the amount of unstaked and unbonded ICX unlocked
+// at each height from startHeight to endHeight. Zero heights mean the
+// defaults of the node. If detail is true, the amount of each account is
+// queried from the index, start.
+func (c *ClientV3) GetUnlockSchedule(startHeight, endHeight int64, detail bool, start, size int64) (map[string]interface{}, error) {
+	params := map[string]interface{}{}
+	if startHeight != 0 {
+		params["startHeight"] = jsonrpc.HexInt(intconv.FormatInt(startHeight))
+	}
+	if endHeight != 0 {
+		params["endHeight"] = jsonrpc.HexInt(intconv.FormatInt(endHeight))
+	}
+	if detail {
+		params["detail"] = "0x1"
+		if start != 0 {
+			params["start"] = jsonrpc.HexInt(intconv.FormatInt(start))
+		}
+		if size != 0 {
+			params["size"] = jsonrpc.HexInt(intconv.FormatInt(size))
+		}
+	}
+	param := &v3.CallParam{
+		ToAddress: jsonrpc.Address(state.SystemAddress.String()),
+		DataType:  "call",
+		Data: map[string]interface{}{
+			"method": "getUnlockSchedule",
+			"params": params,
+		},
+	}
+	var result map[string]interface{}
+	if _, err := c.Do("icx_call", param, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func votesParam(votes map[string]*big.Int) []interface{} {
+	addrs := make([]string, 0, len(votes))
+	for addr := range votes {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	list := make([]interface{}, 0, len(addrs))
+	for _, addr := range addrs {
+		list = append(list, map[string]interface{}{
+			"address": addr,
+			"value":   jsonrpc.HexInt(intconv.FormatBigInt(votes[addr])),
+		})
+	}
+	return list
+}
+
+// EstimateIScore queries the reward of the account expected for a term
+// with the given stake, delegations and bonds. Nil means the current one
+// of the account.
+func (c *ClientV3) EstimateIScore(addr jsonrpc.Address, stake *big.Int, delegations, bonds map[string]*big.Int) (map[string]interface{}, error) {
+	params := map[string]interface{}{
+		"address": addr,
+	}
+	if stake != nil {
+		params["stake"] = jsonrpc.HexInt(intconv.FormatBigInt(stake))
+	}
+	if delegations != nil {
+		params["delegations"] = votesParam(delegations)
+	}
+	if bonds != nil {
+		params["bonds"] = votesParam(bonds)
+	}
+	param := &v3.CallParam{
+		ToAddress: jsonrpc.Address(state.SystemAddress.String()),
+		DataType:  "call",
+		Data: map[string]interface{}{
+			"method": "estimateIScore",
+			"params": params,
+		},
+	}
+	var result map[string]interface{}
+	if _, err := c.Do("icx_call", param, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
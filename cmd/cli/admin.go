@@ -180,7 +180,7 @@ func NewChainCmd(parentCmd *cobra.Command, parentVc *viper.Viper) (*cobra.Comman
 	joinFlags := joinCmd.Flags()
 	joinFlags.String("genesis", "", "Genesis storage path")
 	joinFlags.String("genesis_template", "", "Genesis template directory or file")
-	joinFlags.String("seed", "", "List of trust-seed ip-port, Comma separated string")
+	joinFlags.String("seed", "", "List of trust-seed ip-port or DNS name-port, Comma separated string")
 	joinFlags.Uint("role", 3, "[0:None, 1:Seed, 2:Validator, 3:Both]")
 	joinFlags.String("db_type", "goleveldb", "Name of database system("+strings.Join(db.RegisteredBackendTypes(),", ")+")")
 	joinFlags.String("platform", "", "Name of service platform")
@@ -363,6 +363,30 @@ func NewChainCmd(parentCmd *cobra.Command, parentVc *viper.Viper) (*cobra.Comman
 	}
 	rootCmd.AddCommand(backupCmd)
 
+	verifyRewardsCmd := &cobra.Command{
+		Use:   "verify-rewards CID",
+		Short: "Start to verify the reward calculation of the term",
+		Args:  ArgsWithDefaultErrorFunc(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fs := cmd.Flags()
+			param := &node.ChainVerifyRewardsParam{}
+			param.Term, _ = fs.GetInt64("term")
+
+			var v string
+			reqUrl := node.UrlChain + "/" + args[0] + "/verify_rewards"
+			_, err := adminClient.PostWithJson(reqUrl, param, &v)
+			if err != nil {
+				return err
+			}
+			fmt.Println(v)
+			return nil
+		},
+	}
+	rootCmd.AddCommand(verifyRewardsCmd)
+	verifyRewardsFlags := verifyRewardsCmd.Flags()
+	verifyRewardsFlags.Int64("term", 0, "Sequence of the term")
+	MarkAnnotationRequired(verifyRewardsFlags, "term")
+
 	genesisCmd := &cobra.Command{
 		Use:   "genesis CID FILE",
 		Short: "Download chain genesis file",
@@ -526,6 +550,7 @@ func NewSystemCmd(parentCmd *cobra.Command, parentVc *viper.Viper) (*cobra.Comma
 
 	NewBackupCmd(rootCmd, &adminClient)
 	NewRestoreCmd(rootCmd, &adminClient)
+	NewBanCmd(rootCmd, &adminClient)
 
 	return rootCmd, vc
 }
@@ -611,6 +636,65 @@ func NewRestoreCmd(parent *cobra.Command, client *node.UnixDomainSockHttpClient)
 	rootCmd.AddCommand(stopCmd)
 }
 
+func NewBanCmd(parent *cobra.Command, client *node.UnixDomainSockHttpClient) {
+	rootCmd := &cobra.Command{
+		Use:   "ban",
+		Short: "Manage banned peers",
+	}
+	parent.AddCommand(rootCmd)
+
+	listCmd := &cobra.Command{
+		Use:   "ls",
+		Short: "List banned peers",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resp, err := client.Get(node.UrlSystem+"/ban", nil)
+			if err != nil {
+				return err
+			}
+			return JsonPrettyCopyAndClose(os.Stdout, resp.Body)
+		},
+	}
+	rootCmd.AddCommand(listCmd)
+
+	addCmd := &cobra.Command{
+		Use:   "add ID",
+		Short: "Ban the peer",
+		Args:  ArgsWithDefaultErrorFunc(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fs := cmd.Flags()
+			param := &node.BanParam{ID: args[0]}
+			param.Duration, _ = fs.GetString("duration")
+			param.Reason, _ = fs.GetString("reason")
+			var v string
+			if _, err := client.PostWithJson(node.UrlSystem+"/ban", param, &v); err != nil {
+				return err
+			}
+			fmt.Println(v)
+			return nil
+		},
+	}
+	addFlags := addCmd.Flags()
+	addFlags.String("duration", "", "Duration of ban, ex) 30m, 2h (default: p2pBanDuration of system config)")
+	addFlags.String("reason", "manual", "Reason of ban")
+	rootCmd.AddCommand(addCmd)
+
+	rmCmd := &cobra.Command{
+		Use:   "rm ID",
+		Short: "Unban the peer",
+		Args:  ArgsWithDefaultErrorFunc(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var v string
+			if _, err := client.Delete(node.UrlSystem+"/ban/"+args[0], &v); err != nil {
+				return err
+			}
+			fmt.Println(v)
+			return nil
+		},
+	}
+	rootCmd.AddCommand(rmCmd)
+}
+
 func NewUserCmd(parentCmd *cobra.Command, parentVc *viper.Viper) (*cobra.Command, *viper.Viper) {
 	var adminClient node.UnixDomainSockHttpClient
 	rootCmd, vc := NewCommand(parentCmd, parentVc, "user", "User management")
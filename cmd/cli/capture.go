@@ -0,0 +1,202 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/network"
+
+	//register payload decoders
+	_ "github.com/icon-project/goloop/consensus"
+	_ "github.com/icon-project/goloop/consensus/fastsync"
+	_ "github.com/icon-project/goloop/service"
+	_ "github.com/icon-project/goloop/service/sync"
+)
+
+type capturedPacketJSON struct {
+	Time        string           `json:"time"`
+	Direction   string           `json:"direction"`
+	Peer        string           `json:"peer,omitempty"`
+	Protocol    string           `json:"protocol"`
+	SubProtocol common.HexUint16 `json:"subProtocol"`
+	Src         string           `json:"src,omitempty"`
+	Dest        common.HexUint16 `json:"dest"`
+	TTL         common.HexUint16 `json:"ttl"`
+	Message     interface{}      `json:"message,omitempty"`
+	Payload     common.HexBytes  `json:"payload,omitempty"`
+	Error       string           `json:"error,omitempty"`
+}
+
+func newCaptureDecodeCmd(c string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   fmt.Sprintf("%s FILE...", c),
+		Short: "Decode captured packets",
+		Args:  cobra.MinimumNArgs(1),
+	}
+	flags := cmd.Flags()
+	protocol := flags.String("protocol", "", "Filter by protocol, ex) 0x0300")
+	direction := flags.String("direction", "", "Filter by direction, in or out")
+	showPayload := flags.Bool("payload", false, "Show raw payload")
+	asJSON := flags.Bool("json", false, "Print each packet as JSON")
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		var pi int64 = -1
+		if *protocol != "" {
+			v, err := strconv.ParseUint(*protocol, 0, 16)
+			if err != nil {
+				return fmt.Errorf("invalid protocol %s err=%+v", *protocol, err)
+			}
+			pi = int64(v)
+		}
+		for _, arg := range args {
+			f, err := os.Open(arg)
+			if err != nil {
+				return err
+			}
+			r := network.NewCaptureReader(f)
+			for {
+				cp, err := r.Read()
+				if err != nil {
+					f.Close()
+					if err == io.EOF {
+						break
+					}
+					return fmt.Errorf("fail to read file=%s err=%+v", arg, err)
+				}
+				if pi >= 0 && int64(cp.Protocol.Uint16()) != pi {
+					continue
+				}
+				if *direction != "" && cp.Direction.String() != *direction {
+					continue
+				}
+				if err = printCapturedPacket(cmd.OutOrStdout(), cp, *showPayload, *asJSON); err != nil {
+					f.Close()
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return cmd
+}
+
+func printCapturedPacket(w io.Writer, cp *network.CapturedPacket, showPayload, asJSON bool) error {
+	name, msg, err := network.DecodeCapturedPacket(cp)
+	if !asJSON {
+		fmt.Fprintf(w, "%s %s", cp, name)
+		if err != nil {
+			fmt.Fprintf(w, " error:%v", err)
+		} else if _, ok := msg.([]byte); !ok && msg != nil {
+			fmt.Fprintf(w, " %T%+v", msg, msg)
+		}
+		if showPayload {
+			fmt.Fprintf(w, " payload:%#x", cp.Payload)
+		}
+		_, err = fmt.Fprintln(w)
+		return err
+	}
+	j := &capturedPacketJSON{
+		Time:        cp.Time().Format("2006-01-02T15:04:05.000000000Z07:00"),
+		Direction:   cp.Direction.String(),
+		Protocol:    name,
+		SubProtocol: common.HexUint16{Value: cp.SubProtocol.Uint16()},
+		Dest:        common.HexUint16{Value: uint16(cp.Dest)},
+		TTL:         common.HexUint16{Value: uint16(cp.TTL)},
+	}
+	if id := cp.PeerID(); id != nil {
+		j.Peer = id.String()
+	}
+	if len(cp.Src) > 0 {
+		j.Src = network.NewPeerID(cp.Src).String()
+	}
+	if err != nil {
+		j.Error = err.Error()
+	} else if _, ok := msg.([]byte); !ok {
+		j.Message = msg
+	}
+	if showPayload {
+		j.Payload = cp.Payload
+	}
+	bs, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(bs))
+	return err
+}
+
+// replayReactor receives replayed packets like a reactor of the protocol and
+// prints the messages decoded by the registered decoder of the protocol.
+type replayReactor struct {
+	w        io.Writer
+	pi       module.ProtocolInfo
+	received int
+	rejected int
+}
+
+func (r *replayReactor) OnReceive(pi module.ProtocolInfo, b []byte, id module.PeerID) (bool, error) {
+	r.received++
+	name, msg, err := network.DecodePayload(r.pi, pi, b)
+	if err != nil {
+		r.rejected++
+		fmt.Fprintf(r.w, "%s %#04x from:%v error:%v\n", name, pi.Uint16(), id, err)
+		return false, err
+	}
+	if _, ok := msg.([]byte); ok {
+		fmt.Fprintf(r.w, "%s %#04x from:%v payload:%#x\n", name, pi.Uint16(), id, msg)
+	} else {
+		fmt.Fprintf(r.w, "%s %#04x from:%v %T%+v\n", name, pi.Uint16(), id, msg, msg)
+	}
+	return false, nil
+}
+
+func (r *replayReactor) OnFailure(err error, pi module.ProtocolInfo, b []byte) {}
+
+func (r *replayReactor) OnJoin(id module.PeerID) {}
+
+func (r *replayReactor) OnLeave(id module.PeerID) {}
+
+func newCaptureReplayCmd(c string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   fmt.Sprintf("%s FILE...", c),
+		Short: "Replay received packets of the protocol into a reactor",
+		Args:  cobra.MinimumNArgs(1),
+	}
+	flags := cmd.Flags()
+	protocol := flags.String("protocol", "", "Protocol to replay, ex) 0x0100")
+	MarkAnnotationRequired(flags, "protocol")
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		v, err := strconv.ParseUint(*protocol, 0, 16)
+		if err != nil {
+			return fmt.Errorf("invalid protocol %s err=%+v", *protocol, err)
+		}
+		r := &replayReactor{w: cmd.OutOrStdout(), pi: module.ProtocolInfo(v)}
+		for _, arg := range args {
+			f, err := os.Open(arg)
+			if err != nil {
+				return err
+			}
+			_, err = network.ReplayCapture(f, r.pi, r)
+			f.Close()
+			if err != nil {
+				return fmt.Errorf("fail to replay file=%s err=%+v", arg, err)
+			}
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "replayed %d packets, %d rejected\n", r.received, r.rejected)
+		return nil
+	}
+	return cmd
+}
+
+func NewCaptureCmd(c string) *cobra.Command {
+	cmd := &cobra.Command{Use: c, Short: "Captured packet manipulation"}
+	cmd.AddCommand(newCaptureDecodeCmd("decode"))
+	cmd.AddCommand(newCaptureReplayCmd("replay"))
+	return cmd
+}
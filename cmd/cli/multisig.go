@@ -0,0 +1,279 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/icon-project/goloop/client"
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/crypto"
+	"github.com/icon-project/goloop/common/intconv"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/server/jsonrpc"
+	v3 "github.com/icon-project/goloop/server/v3"
+	"github.com/icon-project/goloop/service/transaction"
+)
+
+var multiSigTxHashExcludes = map[string]bool{
+	"signature": true,
+	"multiSig":  true,
+	"txHash":    true,
+}
+
+// multiSigTx is the partially signed transaction of the multi-signature
+// account, which is kept in a file while key holders sign it.
+type multiSigTx struct {
+	param    map[string]interface{}
+	multiSig transaction.MultiSig
+	key      *crypto.MultiSigKey
+}
+
+func readMultiSigTx(file string) (*multiSigTx, error) {
+	b, err := readFile(file)
+	if err != nil {
+		return nil, err
+	}
+	tx := &multiSigTx{}
+	if err := json.Unmarshal(b, &tx.param); err != nil {
+		return nil, err
+	}
+	ms, ok := tx.param["multiSig"]
+	if !ok {
+		return nil, fmt.Errorf("no multiSig in %s", file)
+	}
+	if b, err = json.Marshal(ms); err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(b, &tx.multiSig); err != nil {
+		return nil, err
+	}
+	if tx.key, err = tx.multiSig.Key(); err != nil {
+		return nil, fmt.Errorf("invalid multiSig key in %s err=%+v", file, err)
+	}
+	return tx, nil
+}
+
+func (tx *multiSigTx) hash() ([]byte, error) {
+	bs, err := transaction.SerializeMap(tx.param, nil, multiSigTxHashExcludes)
+	if err != nil {
+		return nil, err
+	}
+	bs = append([]byte("icx_sendTransaction."), bs...)
+	return crypto.SHA3Sum256(bs), nil
+}
+
+// fill sets fields not specified in the file. It's allowed only before
+// signing, because it changes the hash of the transaction.
+func (tx *multiSigTx) fill(vc *viper.Viper) error {
+	values := map[string]func() (string, error){
+		"version": func() (string, error) {
+			return string(v3.VersionValue), nil
+		},
+		"from": func() (string, error) {
+			return common.NewAccountAddressFromMultiSigKey(tx.key).String(), nil
+		},
+		"stepLimit": func() (string, error) {
+			return intconv.FormatInt(vc.GetInt64("step_limit")), nil
+		},
+		"nid": func() (string, error) {
+			nid, err := intconv.ParseInt(vc.GetString("nid"), 64)
+			if err != nil {
+				return "", err
+			}
+			return intconv.FormatInt(nid), nil
+		},
+		"timestamp": func() (string, error) {
+			return intconv.FormatInt(time.Now().UnixNano() / int64(time.Microsecond)), nil
+		},
+	}
+	for k, f := range values {
+		if _, ok := tx.param[k]; ok {
+			continue
+		}
+		if len(tx.multiSig.Signatures) > 0 {
+			return fmt.Errorf("no %s in the transaction already signed", k)
+		}
+		v, err := f()
+		if err != nil {
+			return err
+		}
+		tx.param[k] = v
+	}
+	from := common.NewAccountAddressFromMultiSigKey(tx.key)
+	if s, ok := tx.param["from"].(string); !ok || s != from.String() {
+		return fmt.Errorf("from(%v) is not the address of the key(%s)", tx.param["from"], from)
+	}
+	return nil
+}
+
+// signer returns the public key which made the signature.
+func (tx *multiSigTx) signer(hash []byte, sig common.Signature) (*crypto.PublicKey, error) {
+	pk, err := sig.RecoverPublicKey(hash)
+	if err != nil {
+		return nil, err
+	}
+	if tx.key.IndexOf(pk) < 0 {
+		return nil, fmt.Errorf("signature of unknown key %s", pk)
+	}
+	return pk, nil
+}
+
+func (tx *multiSigTx) hasSignatureOf(hash []byte, pk *crypto.PublicKey) bool {
+	for _, sig := range tx.multiSig.Signatures {
+		if signer, err := tx.signer(hash, sig); err == nil && signer.Equal(pk) {
+			return true
+		}
+	}
+	return false
+}
+
+// merge takes signatures of the other file for the same transaction.
+func (tx *multiSigTx) merge(hash []byte, other *multiSigTx) error {
+	h, err := other.hash()
+	if err != nil {
+		return err
+	}
+	if string(h) != string(hash) {
+		return fmt.Errorf("different transaction(hash=%#x)", h)
+	}
+	for _, sig := range other.multiSig.Signatures {
+		pk, err := other.signer(hash, sig)
+		if err != nil {
+			return err
+		}
+		if !tx.hasSignatureOf(hash, pk) {
+			tx.multiSig.Signatures = append(tx.multiSig.Signatures, sig)
+		}
+	}
+	return nil
+}
+
+// sign adds the signature of the wallet if it's a member of the key and
+// it didn't sign yet. It returns whether the signature is added.
+func (tx *multiSigTx) sign(hash []byte, w module.Wallet) (bool, error) {
+	pk, err := crypto.ParsePublicKey(w.PublicKey())
+	if err != nil {
+		return false, err
+	}
+	if tx.key.IndexOf(pk) < 0 || tx.hasSignatureOf(hash, pk) {
+		return false, nil
+	}
+	bs, err := w.Sign(hash)
+	if err != nil {
+		return false, err
+	}
+	sig, err := crypto.ParseSignature(bs)
+	if err != nil {
+		return false, err
+	}
+	tx.multiSig.Signatures = append(tx.multiSig.Signatures, common.Signature{Signature: sig})
+	return true, nil
+}
+
+func (tx *multiSigTx) complete() bool {
+	return len(tx.multiSig.Signatures) >= tx.key.Threshold()
+}
+
+func (tx *multiSigTx) write(file string) error {
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return JsonPrettyPrintln(f, tx.param)
+}
+
+// loadMultiSigTx reads the transaction from files, merges signatures of
+// them and signs it with the wallet.
+func loadMultiSigTx(vc *viper.Viper, w module.Wallet, files []string) (*multiSigTx, []byte, error) {
+	tx, err := readMultiSigTx(files[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = tx.fill(vc); err != nil {
+		return nil, nil, err
+	}
+	hash, err := tx.hash()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, file := range files[1:] {
+		other, err := readMultiSigTx(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := tx.merge(hash, other); err != nil {
+			return nil, nil, fmt.Errorf("fail to merge %s err=%+v", file, err)
+		}
+	}
+	if _, err := tx.sign(hash, w); err != nil {
+		return nil, nil, err
+	}
+	tx.param["multiSig"] = &tx.multiSig
+	return tx, hash, nil
+}
+
+func NewMultiSigTxCmd(vc *viper.Viper, rpcClient *client.ClientV3, rpcWallet *module.Wallet) *cobra.Command {
+	rootCmd := &cobra.Command{
+		Use:   "multisig",
+		Short: "Transaction of multi-signature account",
+		Long: "Transaction of multi-signature account. A transaction file has " +
+			"'multiSig' with 'threshold' and 'publicKeys'(hex), then each key " +
+			"holder signs it with 'sign', and the last one sends it with 'send'.",
+	}
+
+	signCmd := &cobra.Command{
+		Use:   "sign FILE [FILE...]",
+		Short: "Sign partially signed transaction files, and merge signatures of them",
+		Args:  ArgsWithDefaultErrorFunc(cobra.MinimumNArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tx, hash, err := loadMultiSigTx(vc, *rpcWallet, args)
+			if err != nil {
+				return err
+			}
+			out := cmd.Flag("out").Value.String()
+			if out == "" {
+				out = args[0]
+			}
+			if err := tx.write(out); err != nil {
+				return err
+			}
+			return JsonPrettyPrintln(os.Stdout, map[string]interface{}{
+				"txHash":     common.HexBytes(hash),
+				"signatures": len(tx.multiSig.Signatures),
+				"threshold":  tx.key.Threshold(),
+			})
+		},
+	}
+	signCmd.Flags().String("out", "", "Output file, the first FILE if it's not specified")
+	rootCmd.AddCommand(signCmd)
+
+	sendCmd := &cobra.Command{
+		Use:   "send FILE [FILE...]",
+		Short: "Sign and merge partially signed transaction files, then send it",
+		Args:  ArgsWithDefaultErrorFunc(cobra.MinimumNArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tx, _, err := loadMultiSigTx(vc, *rpcWallet, args)
+			if err != nil {
+				return err
+			}
+			if !tx.complete() {
+				return fmt.Errorf("not enough signatures(%d<%d)",
+					len(tx.multiSig.Signatures), tx.key.Threshold())
+			}
+			var result jsonrpc.HexBytes
+			if _, err = rpcClient.Do("icx_sendTransaction", tx.param, &result); err != nil {
+				return err
+			}
+			vc.Set("txhash", &result)
+			return JsonPrettyPrintln(os.Stdout, &result)
+		},
+	}
+	rootCmd.AddCommand(sendCmd)
+	return rootCmd
+}
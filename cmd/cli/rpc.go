@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"net/http"
 	"os"
 	"strings"
@@ -218,6 +219,20 @@ func NewRpcCmd(parentCmd *cobra.Command, parentVc *viper.Viper) (*cobra.Command,
 			if len(dataM) > 0 {
 				param.Data = dataM
 			}
+			if overrides := cmd.Flag("overrides").Value.String(); overrides != "" {
+				var bs []byte
+				if strings.HasPrefix(strings.TrimSpace(overrides), "{") {
+					bs = []byte(overrides)
+				} else {
+					var err error
+					if bs, err = readFile(overrides); err != nil {
+						return err
+					}
+				}
+				if err := json.Unmarshal(bs, &param.Overrides); err != nil {
+					return err
+				}
+			}
 			blk, err := rpcClient.Call(param)
 			if err != nil {
 				return err
@@ -237,6 +252,7 @@ func NewRpcCmd(parentCmd *cobra.Command, parentVc *viper.Viper) (*cobra.Command,
 	callFlags.StringToString("param", nil,
 		"key=value, Function parameters, if '--raw' used, will overwrite")
 	callFlags.String("raw", "", "call with 'data' using raw json file or json-string")
+	callFlags.String("overrides", "", "call on the state overridden with json file or json-string")
 	MarkAnnotationRequired(callFlags, "to")
 
 	rawCmd := &cobra.Command{
@@ -257,6 +273,125 @@ func NewRpcCmd(parentCmd *cobra.Command, parentVc *viper.Viper) (*cobra.Command,
 	}
 	rootCmd.AddCommand(rawCmd)
 
+	iscoreHistoryCmd := &cobra.Command{
+		Use:   "iscorehistory ADDRESS",
+		Short: "Query I-Score rewards for each term",
+		Args:  ArgsWithDefaultErrorFunc(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			start, err := cmd.Flags().GetInt64("start")
+			if err != nil {
+				return err
+			}
+			size, err := cmd.Flags().GetInt64("size")
+			if err != nil {
+				return err
+			}
+			history, err := rpcClient.GetIScoreHistory(jsonrpc.Address(args[0]), start, size)
+			if err != nil {
+				return err
+			}
+			return JsonPrettyPrintln(os.Stdout, history)
+		},
+	}
+	rootCmd.AddCommand(iscoreHistoryCmd)
+	iscoreHistoryFlags := iscoreHistoryCmd.Flags()
+	iscoreHistoryFlags.Int64("start", 0, "Index of the first record")
+	iscoreHistoryFlags.Int64("size", 0, "Number of records, zero for the default of the node")
+
+	unlockScheduleCmd := &cobra.Command{
+		Use:   "unlockschedule",
+		Short: "Query unstaked and unbonded ICX unlocked in a range of block heights",
+		Args:  ArgsWithDefaultErrorFunc(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fs := cmd.Flags()
+			startHeight, err := fs.GetInt64("start_height")
+			if err != nil {
+				return err
+			}
+			endHeight, err := fs.GetInt64("end_height")
+			if err != nil {
+				return err
+			}
+			detail, err := fs.GetBool("detail")
+			if err != nil {
+				return err
+			}
+			start, err := fs.GetInt64("start")
+			if err != nil {
+				return err
+			}
+			size, err := fs.GetInt64("size")
+			if err != nil {
+				return err
+			}
+			schedule, err := rpcClient.GetUnlockSchedule(startHeight, endHeight, detail, start, size)
+			if err != nil {
+				return err
+			}
+			return JsonPrettyPrintln(os.Stdout, schedule)
+		},
+	}
+	rootCmd.AddCommand(unlockScheduleCmd)
+	unlockScheduleFlags := unlockScheduleCmd.Flags()
+	unlockScheduleFlags.Int64("start_height", 0, "First block height, zero for the next block")
+	unlockScheduleFlags.Int64("end_height", 0, "Last block height, zero for the default range of the node")
+	unlockScheduleFlags.Bool("detail", false, "Query the amount of each account")
+	unlockScheduleFlags.Int64("start", 0, "Index of the first account with detail")
+	unlockScheduleFlags.Int64("size", 0, "Number of accounts with detail, zero for the default of the node")
+
+	iscoreEstimateCmd := &cobra.Command{
+		Use:   "iscoreestimate ADDRESS",
+		Short: "Estimate I-Score rewards for a term with given stake, delegations and bonds",
+		Args:  ArgsWithDefaultErrorFunc(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var stake *big.Int
+			if cmd.Flags().Changed("stake") {
+				stake = new(big.Int)
+				if _, ok := stake.SetString(cmd.Flag("stake").Value.String(), 0); !ok {
+					return fmt.Errorf("fail to parse stake:%s", cmd.Flag("stake").Value.String())
+				}
+			}
+			votesOf := func(name string) (map[string]*big.Int, error) {
+				if !cmd.Flags().Changed(name) {
+					return nil, nil
+				}
+				params, err := cmd.Flags().GetStringToString(name)
+				if err != nil {
+					return nil, err
+				}
+				votes := make(map[string]*big.Int)
+				for k, v := range params {
+					value := new(big.Int)
+					if _, ok := value.SetString(v, 0); !ok {
+						return nil, fmt.Errorf("fail to parse %s value:%s", name, v)
+					}
+					votes[k] = value
+				}
+				return votes, nil
+			}
+			delegations, err := votesOf("delegation")
+			if err != nil {
+				return err
+			}
+			bonds, err := votesOf("bond")
+			if err != nil {
+				return err
+			}
+			estimate, err := rpcClient.EstimateIScore(jsonrpc.Address(args[0]), stake, delegations, bonds)
+			if err != nil {
+				return err
+			}
+			return JsonPrettyPrintln(os.Stdout, estimate)
+		},
+	}
+	rootCmd.AddCommand(iscoreEstimateCmd)
+	iscoreEstimateFlags := iscoreEstimateCmd.Flags()
+	iscoreEstimateFlags.String("stake", "", "Amount of stake in loop, the current stake if not specified")
+	iscoreEstimateFlags.StringToString("delegation", nil,
+		"Delegations in loop (ADDRESS=VALUE,...), the current delegations if not specified")
+	iscoreEstimateFlags.StringToString("bond", nil,
+		"Bonds in loop (ADDRESS=VALUE,...), the current bonds if not specified")
+
 	rootCmd.AddCommand(
 		&cobra.Command{
 			Use:   "databyhash HASH",
@@ -358,6 +493,20 @@ func NewRpcCmd(parentCmd *cobra.Command, parentVc *viper.Viper) (*cobra.Command,
 	return rootCmd, vc
 }
 
+func paramForEstimate(p *v3.TransactionParam) *v3.TransactionParamForEstimate {
+	return &v3.TransactionParamForEstimate{
+		Version:     p.Version,
+		FromAddress: p.FromAddress,
+		ToAddress:   p.ToAddress,
+		Value:       p.Value,
+		Timestamp:   p.Timestamp,
+		NetworkID:   p.NetworkID,
+		Nonce:       p.Nonce,
+		DataType:    p.DataType,
+		Data:        p.Data,
+	}
+}
+
 func NewSendTxCmd(parentCmd *cobra.Command, parentVc *viper.Viper) *cobra.Command {
 	var rpcClient client.ClientV3
 	var rpcClientSendTx func(w module.Wallet, params *v3.TransactionParam) (interface{}, error)
@@ -373,18 +522,10 @@ func NewSendTxCmd(parentCmd *cobra.Command, parentVc *viper.Viper) *cobra.Comman
 
 		if estimate := vc.GetBool("estimate"); estimate {
 			rpcClientSendTx = func(w module.Wallet, p *v3.TransactionParam) (interface{}, error) {
-				params := &v3.TransactionParamForEstimate{
-					Version:     p.Version,
-					FromAddress: p.FromAddress,
-					ToAddress:   p.ToAddress,
-					Value:       p.Value,
-					Timestamp:   p.Timestamp,
-					NetworkID:   p.NetworkID,
-					Nonce:       p.Nonce,
-					DataType:    p.DataType,
-					Data:        p.Data,
-				}
-				step, err := rpcClient.EstimateStep(params)
+				if vc.GetBool("estimate_details") {
+					return rpcClient.EstimateStepDetails(paramForEstimate(p))
+				}
+				step, err := rpcClient.EstimateStep(paramForEstimate(p))
 				if err != nil {
 					return nil, err
 				}
@@ -392,6 +533,14 @@ func NewSendTxCmd(parentCmd *cobra.Command, parentVc *viper.Viper) *cobra.Comman
 			}
 		} else {
 			rpcClientSendTx = func(w module.Wallet, p *v3.TransactionParam) (interface{}, error) {
+				if p.StepLimit == "" || p.StepLimit.Value() == 0 {
+					// fill step limit with the estimation including margin
+					est, err := rpcClient.EstimateStepDetails(paramForEstimate(p))
+					if err != nil {
+						return nil, fmt.Errorf("fail to estimate step limit err=%+v", err)
+					}
+					p.StepLimit = jsonrpc.HexInt(est.StepLimit.String())
+				}
 				txId, err := rpcClient.SendTransaction(w, p)
 				if err != nil {
 					return nil, err
@@ -511,11 +660,12 @@ func NewSendTxCmd(parentCmd *cobra.Command, parentVc *viper.Viper) *cobra.Comman
 	rootPFlags.String("key_secret", "", "Secret(password) file for KeyStore")
 	rootPFlags.String("key_password", "", "Password for the KeyStore file")
 	rootPFlags.String("nid", "", "Network ID")
-	rootPFlags.Int64("step_limit", 0, "StepLimit")
+	rootPFlags.Int64("step_limit", 0, "StepLimit (estimated with margin if it's zero)")
 	rootPFlags.Bool("wait", false, "Wait transaction result")
 	rootPFlags.Int("wait_interval", 1000, "Polling interval(msec) for wait transaction result")
 	rootPFlags.Int("wait_timeout", 10, "Timeout(sec) for wait transaction result")
 	rootPFlags.Bool("estimate", false, "Just estimate steps for the tx")
+	rootPFlags.Bool("estimate_details", false, "Show steps for each type and accessed states on estimation")
 	MarkAnnotationCustom(rootPFlags, "key_store", "nid")
 	BindPFlags(vc, rootCmd.PersistentFlags())
 	MarkAnnotationHidden(rootPFlags, "wait", "wait_interval", "wait_timeout")
@@ -617,6 +767,8 @@ func NewSendTxCmd(parentCmd *cobra.Command, parentVc *viper.Viper) *cobra.Comman
 	}
 	rootCmd.AddCommand(raw3Cmd)
 
+	rootCmd.AddCommand(NewMultiSigTxCmd(vc, &rpcClient, &rpcWallet))
+
 	transferCmd := &cobra.Command{
 		Use:   "transfer",
 		Short: "Coin Transfer Transaction",
@@ -736,6 +888,47 @@ func NewSendTxCmd(parentCmd *cobra.Command, parentVc *viper.Viper) *cobra.Comman
 	callFlags.String("raw", "", "call with 'data' using raw json file or json-string")
 	MarkAnnotationRequired(callFlags, "to", "method")
 
+	batchCmd := &cobra.Command{
+		Use:   "batch FILE",
+		Short: "Batch Transaction with json file of sub operations(to,value,dataType,data)",
+		Args:  ArgsWithDefaultErrorFunc(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			b, err := readFile(args[0])
+			if err != nil {
+				return err
+			}
+			var items []map[string]interface{}
+			if err := json.Unmarshal(b, &items); err != nil {
+				return err
+			}
+			if len(items) == 0 {
+				return fmt.Errorf("no sub operations in %s", args[0])
+			}
+			stepLimit := vc.GetInt64("step_limit")
+			nid, err := intconv.ParseInt(vc.GetString("nid"), 64)
+			if err != nil {
+				return err
+			}
+			param := &v3.TransactionParam{
+				Version:     v3.VersionValue,
+				FromAddress: jsonrpc.Address(rpcWallet.Address().String()),
+				ToAddress:   jsonrpc.Address(rpcWallet.Address().String()),
+				StepLimit:   jsonrpc.HexInt(intconv.FormatInt(stepLimit)),
+				NetworkID:   jsonrpc.HexInt(intconv.FormatInt(nid)),
+				DataType:    "batch",
+				Data:        items,
+			}
+
+			txHash, err := rpcClientSendTx(rpcWallet, param)
+			if err != nil {
+				return err
+			}
+			vc.Set("txhash", txHash)
+			return JsonPrettyPrintln(os.Stdout, txHash)
+		},
+	}
+	rootCmd.AddCommand(batchCmd)
+
 	deployCmd := &cobra.Command{
 		Use:   "deploy SCORE_ZIP_FILE",
 		Short: "Deploy Transaction",
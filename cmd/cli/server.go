@@ -138,6 +138,8 @@ func NewServerCmd(parentCmd *cobra.Command, parentVc *viper.Viper, version, buil
 	rootPFlags := rootCmd.PersistentFlags()
 	rootPFlags.String("p2p", "127.0.0.1:8080", "Advertise ip-port of P2P")
 	rootPFlags.String("p2p_listen", "", "Listen ip-port of P2P")
+	rootPFlags.String("p2p_nat", "", "Open P2P listen port via the gateway (none,upnp,pmp,any)")
+	rootPFlags.Bool("p2p_auto_address", false, "Advertise P2P address observed by the majority of peers")
 	rootPFlags.String("rpc_addr", ":9080", "Listen ip-port of JSON-RPC")
 	rootPFlags.Bool("rpc_dump", false, "JSON-RPC Request, Response Dump flag")
 	rootPFlags.String("ee_socket", "", "Execution engine socket path")
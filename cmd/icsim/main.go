@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bufio"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/icon/icsim"
+)
+
+func newRunCmd(name string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   name + " SCENARIO",
+		Short: "Run a scenario and write the state after each term",
+		Args:  cobra.ExactArgs(1),
+	}
+	flags := cmd.Flags()
+	report := flags.StringP("report", "o", "", "Output file for the report (default: stdout)")
+	format := flags.StringP("format", "f", "json", "Format of the report (json, csv)")
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		s, err := icsim.LoadScenario(args[0])
+		if err != nil {
+			return err
+		}
+		r, err := icsim.NewScenarioRunner(s)
+		if err != nil {
+			return err
+		}
+		runErr := r.Run()
+
+		out := os.Stdout
+		if len(*report) > 0 {
+			if out, err = os.Create(*report); err != nil {
+				return err
+			}
+			defer out.Close()
+		}
+		w := bufio.NewWriter(out)
+		if err = icsim.WriteReport(w, *format, r.Records()); err != nil {
+			return err
+		}
+		if err = w.Flush(); err != nil {
+			return err
+		}
+		return runErr
+	}
+	return cmd
+}
+
+func main() {
+	cmd := &cobra.Command{
+		Use:   os.Args[0],
+		Short: "IISS simulator",
+	}
+	logLevel := cmd.PersistentFlags().String("log_level", "warn", "Log level (trace, debug, info, warn, error)")
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		lv, err := log.ParseLevel(*logLevel)
+		if err != nil {
+			return err
+		}
+		log.GlobalLogger().SetLevel(lv)
+		return nil
+	}
+	cmd.AddCommand(newRunCmd("run"))
+	cmd.SilenceUsage = true
+	if err := cmd.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
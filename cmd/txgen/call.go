@@ -34,9 +34,11 @@ type CallMaker struct {
 	CallTemplates map[string]*template.Template
 	Index, Last   int64
 	GOD           module.Wallet
+	EstimateStep  bool
 
-	owner    module.Wallet
-	contract module.Address
+	owner     module.Wallet
+	contract  module.Address
+	stepLimit *big.Int
 }
 
 var (
@@ -95,6 +97,17 @@ func (m *CallMaker) Prepare(client *Client) error {
 		m.contract = addr
 	}
 
+	m.stepLimit = big.NewInt(stepLimitForCoinTransfer)
+	if m.EstimateStep {
+		tx, err := m.makeCallTx(m.Index)
+		if err != nil {
+			return err
+		}
+		if m.stepLimit, err = client.EstimateStepLimit(tx); err != nil {
+			return err
+		}
+		log.Printf("Estimated step limit for the call : %s", m.stepLimit)
+	}
 	return nil
 }
 
@@ -103,6 +116,10 @@ func (m *CallMaker) MakeOne() (interface{}, error) {
 	if m.Last != 0 && index >= m.Last {
 		return nil, ErrEndOfTransaction
 	}
+	return m.makeCallTx(index)
+}
+
+func (m *CallMaker) makeCallTx(index int64) (map[string]interface{}, error) {
 	context := map[string]interface{}{
 		"owner": m.owner.Address(),
 		"index": index,
@@ -116,18 +133,19 @@ func (m *CallMaker) MakeOne() (interface{}, error) {
 		}
 		params[n] = buffer.String()
 	}
-	return makeCallTx(m.NID, m.owner, m.contract, m.Method, params)
+	return makeCallTx(m.NID, m.owner, m.contract, m.Method, params, m.stepLimit)
 }
 
 func makeCallTx(nid int64, from module.Wallet,
 	contract module.Address, method string, params map[string]string,
-) (interface{}, error) {
+	stepLimit *big.Int,
+) (map[string]interface{}, error) {
 	tx := map[string]interface{}{
 		"version":   "0x3",
 		"from":      from.Address(),
 		"to":        contract,
 		"nid":       fmt.Sprintf("0x%x", nid),
-		"stepLimit": fmt.Sprintf("0x%x", stepLimitForCoinTransfer),
+		"stepLimit": fmt.Sprintf("0x%x", stepLimit),
 		"timestamp": TimeStampNow(),
 		"dataType":  "call",
 		"data": map[string]interface{}{
@@ -23,6 +23,7 @@ func main() {
 	var installParams map[string]string
 	var index, last int64
 	var waitTimeout int64
+	var estimate bool
 
 	cmd := &cobra.Command{
 		Use: fmt.Sprintf("%s [urls]", os.Args[0]),
@@ -41,6 +42,7 @@ func main() {
 	flags.Int64VarP(&index, "index", "i", 0, "Initial index value to be used for generating transaction")
 	flags.Int64VarP(&last, "last", "l", 0, "Last index value to be used for generating transaction")
 	flags.Int64Var(&waitTimeout, "wait", 0, "Wait for specified time (in ms) for each TX (enable to use sendAndWait)")
+	flags.BoolVar(&estimate, "estimate", false, "Estimate step limit of calls with debug API of the node")
 
 	cmd.Run = func(cmd *cobra.Command, urls []string) {
 		if len(urls) == 0 {
@@ -72,15 +74,17 @@ func main() {
 				GOD:           godWallet,
 				Index:         index,
 				Last:          last,
+				EstimateStep:  estimate,
 			}
 		} else if len(scorePath) > 0 {
 			maker = &TokenTransferMaker{
-				NID:         nid,
-				WalletCount: walletCount,
-				SourcePath:  scorePath,
-				Method:      methodName,
-				GOD:         godWallet,
-				Last:        last,
+				NID:          nid,
+				WalletCount:  walletCount,
+				SourcePath:   scorePath,
+				Method:       methodName,
+				GOD:          godWallet,
+				Last:         last,
+				EstimateStep: estimate,
 			}
 		} else {
 			maker = &CoinTransferMaker{
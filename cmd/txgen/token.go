@@ -26,17 +26,19 @@ const (
 )
 
 type TokenTransferMaker struct {
-	NID         int64
-	WalletCount int
-	SourcePath  string
-	Method      string
-	GOD         module.Wallet
-	Last        int64
-
-	owner    module.Wallet
-	wallets  []module.Wallet
-	contract module.Address
-	index    int64
+	NID          int64
+	WalletCount  int
+	SourcePath   string
+	Method       string
+	GOD          module.Wallet
+	Last         int64
+	EstimateStep bool
+
+	owner     module.Wallet
+	wallets   []module.Wallet
+	contract  module.Address
+	index     int64
+	stepLimit *big.Int
 }
 
 var (
@@ -86,7 +88,8 @@ func (m *TokenTransferMaker) Prepare(client *Client) error {
 	for i := 0; i < m.WalletCount; i++ {
 		m.wallets[i] = wallet.New()
 		tx, err := makeTokenTransfer(m.NID, m.contract,
-			m.Method, m.owner, m.wallets[i].Address(), tokenInitialBalance)
+			m.Method, m.owner, m.wallets[i].Address(), tokenInitialBalance,
+			big.NewInt(stepLimitForTokenTransfer))
 		if err != nil {
 			return err
 		}
@@ -106,6 +109,19 @@ func (m *TokenTransferMaker) Prepare(client *Client) error {
 			return errors.Errorf("Fail to transfer initial balance %+v", r.Failure)
 		}
 	}
+
+	m.stepLimit = big.NewInt(stepLimitForTokenTransfer)
+	if m.EstimateStep {
+		tx, err := makeTokenTransfer(m.NID, m.contract, m.Method,
+			m.wallets[0], m.owner.Address(), tokenValueForTransfer, m.stepLimit)
+		if err != nil {
+			return err
+		}
+		if m.stepLimit, err = client.EstimateStepLimit(tx); err != nil {
+			return err
+		}
+		log.Printf("Estimated step limit for the token transfer : %s", m.stepLimit)
+	}
 	return nil
 }
 
@@ -119,16 +135,16 @@ func (m *TokenTransferMaker) MakeOne() (interface{}, error) {
 	from := m.wallets[fromIndex]
 	to := m.wallets[toIndex]
 
-	return makeTokenTransfer(m.NID, m.contract, m.Method, from, to.Address(), tokenValueForTransfer)
+	return makeTokenTransfer(m.NID, m.contract, m.Method, from, to.Address(), tokenValueForTransfer, m.stepLimit)
 }
 
-func makeTokenTransfer(nid int64, contract module.Address, method string, from module.Wallet, to module.Address, value *big.Int) (interface{}, error) {
+func makeTokenTransfer(nid int64, contract module.Address, method string, from module.Wallet, to module.Address, value *big.Int, stepLimit *big.Int) (map[string]interface{}, error) {
 	tx := map[string]interface{}{
 		"version":   "0x3",
 		"from":      from.Address(),
 		"to":        contract,
 		"nid":       fmt.Sprintf("0x%x", nid),
-		"stepLimit": fmt.Sprintf("0x%x", stepLimitForTokenTransfer),
+		"stepLimit": fmt.Sprintf("0x%x", stepLimit),
 		"timestamp": TimeStampNow(),
 		"dataType":  "call",
 		"data": map[string]interface{}{
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math/big"
 	"net"
 	"net/http"
 	"net/url"
@@ -21,6 +22,7 @@ import (
 	"github.com/icon-project/goloop/common/crypto"
 	"github.com/icon-project/goloop/module"
 	"github.com/icon-project/goloop/server/jsonrpc"
+	"github.com/icon-project/goloop/server/v3"
 	"github.com/icon-project/goloop/service/transaction"
 	"github.com/icon-project/goloop/service/txresult"
 )
@@ -120,6 +122,24 @@ func SignTx(from module.Wallet, tx map[string]interface{}) error {
 	return nil
 }
 
+// EstimateStepLimit returns the step limit for the transaction suggested by
+// the debug API of the node.
+func (c *Client) EstimateStepLimit(tx map[string]interface{}) (*big.Int, error) {
+	js, err := json.Marshal(tx)
+	if err != nil {
+		return nil, err
+	}
+	param := new(v3.TransactionParamForEstimate)
+	if err := json.Unmarshal(js, param); err != nil {
+		return nil, err
+	}
+	r, err := client.NewClientV3(c.Endpoint).EstimateStepDetails(param)
+	if err != nil {
+		return nil, err
+	}
+	return &r.StepLimit.Int, nil
+}
+
 func (c *Client) SendTxAndGetResult(tx interface{}, wait time.Duration) (*TransactionResult, error) {
 	tid, err := c.SendTx(tx)
 	if err != nil {
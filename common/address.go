@@ -223,6 +223,13 @@ func NewAccountAddressFromPublicKey(pubKey *crypto.PublicKey) *Address {
 	return NewAccountAddress(digest[len(digest)-AddressIDBytes:])
 }
 
+// NewAccountAddressFromMultiSigKey returns the address of the account
+// controlled by the multi-signature key.
+func NewAccountAddressFromMultiSigKey(key *crypto.MultiSigKey) *Address {
+	digest := key.Hash()
+	return NewAccountAddress(digest[len(digest)-AddressIDBytes:])
+}
+
 func (a *Address) Equal(a2 module.Address) bool {
 	a2IsNil := a2 == nil || reflect.ValueOf(a2).IsNil()
 	if a2IsNil && a == nil {
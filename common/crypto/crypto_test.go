@@ -84,3 +84,49 @@ func TestPrintSignature(t *testing.T) {
 		t.Errorf("fail to print signaure(no V)")
 	}
 }
+
+func TestMultiSigKey(t *testing.T) {
+	var privs []*PrivateKey
+	var pubs []*PublicKey
+	for i := 0; i < 3; i++ {
+		priv, pub := GenerateKeyPair()
+		privs = append(privs, priv)
+		pubs = append(pubs, pub)
+	}
+	key, err := NewMultiSigKey(2, pubs)
+	if err != nil {
+		t.Fatalf("fail to make key err=%+v", err)
+	}
+	key2, err := ParseMultiSigKey(key.Bytes())
+	if err != nil {
+		t.Fatalf("fail to parse key err=%+v", err)
+	}
+	key3, _ := NewMultiSigKey(2, []*PublicKey{pubs[2], pubs[0], pubs[1]})
+	if string(key.Hash()) != string(key2.Hash()) || string(key.Hash()) != string(key3.Hash()) {
+		t.Errorf("key hash depends on the order of keys")
+	}
+	if _, err := NewMultiSigKey(4, pubs); err == nil {
+		t.Errorf("threshold over the number of keys is accepted")
+	}
+	if _, err := NewMultiSigKey(1, []*PublicKey{pubs[0], pubs[0]}); err == nil {
+		t.Errorf("duplicate keys are accepted")
+	}
+
+	sign := func(priv *PrivateKey) *Signature {
+		sig, _ := NewSignature(testHash, priv)
+		return sig
+	}
+	if err := key.Verify(testHash, []*Signature{sign(privs[0]), sign(privs[2])}); err != nil {
+		t.Errorf("fail to verify err=%+v", err)
+	}
+	if err := key.Verify(testHash, []*Signature{sign(privs[1])}); err == nil {
+		t.Errorf("verified with not enough signatures")
+	}
+	if err := key.Verify(testHash, []*Signature{sign(privs[1]), sign(privs[1])}); err == nil {
+		t.Errorf("verified with duplicate signatures")
+	}
+	other, _ := GenerateKeyPair()
+	if err := key.Verify(testHash, []*Signature{sign(privs[1]), sign(other)}); err == nil {
+		t.Errorf("verified with signature of unknown key")
+	}
+}
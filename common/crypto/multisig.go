@@ -0,0 +1,133 @@
+package crypto
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+const (
+	// MultiSigMaxKeys is the maximum number of public keys in a multi-signature key
+	MultiSigMaxKeys   = 16
+	multiSigKeyPrefix = "multisig"
+)
+
+// MultiSigKey is a type representing the set of public keys and the number
+// of signatures required among them (M-of-N).
+type MultiSigKey struct {
+	threshold int
+	keys      []*PublicKey // sorted by compressed format
+}
+
+// NewMultiSigKey returns a multi-signature key requiring threshold
+// signatures among the public keys. Order of public keys doesn't matter.
+func NewMultiSigKey(threshold int, keys []*PublicKey) (*MultiSigKey, error) {
+	if len(keys) == 0 || len(keys) > MultiSigMaxKeys {
+		return nil, fmt.Errorf("invalid number of public keys(%d)", len(keys))
+	}
+	if threshold < 1 || threshold > len(keys) {
+		return nil, fmt.Errorf("invalid threshold(%d) for %d keys", threshold, len(keys))
+	}
+	sorted := make([]*PublicKey, len(keys))
+	copy(sorted, keys)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].bytes, sorted[j].bytes) < 0
+	})
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i-1].Equal(sorted[i]) {
+			return nil, fmt.Errorf("duplicate public key %s", sorted[i])
+		}
+	}
+	return &MultiSigKey{threshold: threshold, keys: sorted}, nil
+}
+
+// ParseMultiSigKey parses the multi-signature key serialized by Bytes.
+func ParseMultiSigKey(bs []byte) (*MultiSigKey, error) {
+	if len(bs) < 1+PublicKeyLenCompressed ||
+		(len(bs)-1)%PublicKeyLenCompressed != 0 {
+		return nil, errors.New("wrong multi-signature key format")
+	}
+	var keys []*PublicKey
+	for i := 1; i < len(bs); i += PublicKeyLenCompressed {
+		key, err := ParsePublicKey(bs[i : i+PublicKeyLenCompressed])
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return NewMultiSigKey(int(bs[0]), keys)
+}
+
+// Threshold returns the number of signatures required.
+func (key *MultiSigKey) Threshold() int {
+	return key.threshold
+}
+
+// PublicKeys returns public keys in the order of compressed format.
+func (key *MultiSigKey) PublicKeys() []*PublicKey {
+	keys := make([]*PublicKey, len(key.keys))
+	copy(keys, key.keys)
+	return keys
+}
+
+// Bytes returns the threshold followed by compressed public keys.
+func (key *MultiSigKey) Bytes() []byte {
+	bs := make([]byte, 0, 1+len(key.keys)*PublicKeyLenCompressed)
+	bs = append(bs, byte(key.threshold))
+	for _, k := range key.keys {
+		bs = append(bs, k.bytes...)
+	}
+	return bs
+}
+
+// Hash returns the digest identifying the multi-signature key, and it's
+// used to derive the address of the key.
+func (key *MultiSigKey) Hash() []byte {
+	return SHA3Sum256(append([]byte(multiSigKeyPrefix), key.Bytes()...))
+}
+
+// IndexOf returns the index of the public key, or -1 if it's not a member.
+func (key *MultiSigKey) IndexOf(pubKey *PublicKey) int {
+	for i, k := range key.keys {
+		if k.Equal(pubKey) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Verify verifies that the signatures of hash are made by at least
+// threshold members of the key. Each member may sign only once.
+func (key *MultiSigKey) Verify(hash []byte, sigs []*Signature) error {
+	if len(sigs) > len(key.keys) {
+		return fmt.Errorf("too many signatures(%d)", len(sigs))
+	}
+	signed := make([]bool, len(key.keys))
+	for _, sig := range sigs {
+		if sig == nil {
+			return errors.New("empty signature")
+		}
+		pk, err := sig.RecoverPublicKey(hash)
+		if err != nil {
+			return err
+		}
+		idx := key.IndexOf(pk)
+		if idx < 0 {
+			return fmt.Errorf("signature of unknown key %s", pk)
+		}
+		if signed[idx] {
+			return fmt.Errorf("duplicate signature of key %s", pk)
+		}
+		signed[idx] = true
+	}
+	if len(sigs) < key.threshold {
+		return fmt.Errorf("not enough signatures(%d<%d)", len(sigs), key.threshold)
+	}
+	return nil
+}
+
+// String returns the string representation.
+func (key *MultiSigKey) String() string {
+	return fmt.Sprintf("%d-of-%d%v", key.threshold, len(key.keys), key.keys)
+}
@@ -4,7 +4,9 @@ import (
 	"io"
 
 	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/errors"
 	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/network"
 )
 
 // TODO: close message
@@ -74,3 +76,27 @@ type BlockData struct {
 
 type CancelAllBlockRequests struct {
 }
+
+func init() {
+	network.RegisterPayloadDecoder(module.ProtoFastSync, "fastsync", true, decodeMessage)
+}
+
+func decodeMessage(spi module.ProtocolInfo, b []byte) (interface{}, error) {
+	var msg interface{}
+	switch spi {
+	case ProtoBlockRequest:
+		msg = new(BlockRequest)
+	case ProtoBlockMetadata:
+		msg = new(BlockMetadata)
+	case ProtoBlockData:
+		msg = new(BlockData)
+	case ProtoCancelAllBlockRequests:
+		msg = new(CancelAllBlockRequests)
+	default:
+		return nil, errors.NotFoundError.Errorf("UnknownProtocol(%s)", spi)
+	}
+	if _, err := codec.UnmarshalFromBytes(b, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/icon-project/goloop/common/codec"
 	"github.com/icon-project/goloop/common/errors"
 	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/network"
 )
 
 var msgCodec = codec.BC
@@ -34,6 +35,14 @@ var protocolConstructors = [...]protocolConstructor{
 	{ProtoVoteList, func() Message { return newVoteListMessage() }},
 }
 
+func init() {
+	decoder := func(spi module.ProtocolInfo, b []byte) (interface{}, error) {
+		return UnmarshalMessage(spi.Uint16(), b)
+	}
+	network.RegisterPayloadDecoder(module.ProtoConsensus, "consensus", false, decoder)
+	network.RegisterPayloadDecoder(module.ProtoConsensusSync, "consensus.sync", false, decoder)
+}
+
 func UnmarshalMessage(sp uint16, bs []byte) (Message, error) {
 	for _, pc := range protocolConstructors {
 		if sp == uint16(pc.proto) {
@@ -52,6 +61,15 @@ type Message interface {
 	subprotocol() uint16
 }
 
+// verifyError returns the error of Message.Verify with the code for
+// reporting misbehavior of the sender to the network.
+func verifyError(err error) error {
+	if network.InvalidSignatureError.Equals(err) {
+		return err
+	}
+	return network.InvalidMessageError.Wrap(err, "invalid consensus message")
+}
+
 type _HR struct {
 	Height int64
 	Round  int32
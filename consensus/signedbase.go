@@ -5,6 +5,7 @@ import (
 	"github.com/icon-project/goloop/common/crypto"
 	"github.com/icon-project/goloop/common/errors"
 	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/network"
 )
 
 type byteser interface {
@@ -49,7 +50,7 @@ func (s *signedBase) address() *common.Address {
 
 func (s *signedBase) verify() error {
 	if s.publicKey() == nil {
-		return errors.New("bad signature")
+		return network.InvalidSignatureError.New("bad signature")
 	}
 	return nil
 }
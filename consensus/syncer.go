@@ -7,6 +7,7 @@ import (
 	"github.com/icon-project/goloop/common/log"
 	"github.com/icon-project/goloop/consensus/fastsync"
 	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/network"
 )
 
 const (
@@ -312,11 +313,11 @@ func (s *syncer) OnReceive(sp module.ProtocolInfo, bs []byte,
 	msg, err := UnmarshalMessage(sp.Uint16(), bs)
 	if err != nil {
 		s.log.Warnf("OnReceive: error=%+v\n", err)
-		return false, err
+		return false, network.DecodeMessageError.Wrap(err, "malformed consensus message")
 	}
 	s.log.Debugf("OnReceive %v From:%v\n", msg, common.HexPre(id.Bytes()))
 	if err := msg.Verify(); err != nil {
-		return false, err
+		return false, verifyError(err)
 	}
 	var idx int
 	switch m := msg.(type) {
@@ -360,6 +360,55 @@ var chainMethods = []*chainMethod{
 			scoreapi.Dict,
 		},
 	}, icmodule.RevisionIISS, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "getIScoreHistory",
+		scoreapi.FlagReadOnly | scoreapi.FlagExternal, 1,
+		[]scoreapi.Parameter{
+			{"address", scoreapi.Address, nil, nil},
+			{"start", scoreapi.Integer, nil, nil},
+			{"size", scoreapi.Integer, nil, nil},
+		},
+		[]scoreapi.DataType{
+			scoreapi.Dict,
+		},
+	}, icmodule.RevisionIScoreHistory, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "estimateIScore",
+		scoreapi.FlagReadOnly | scoreapi.FlagExternal, 1,
+		[]scoreapi.Parameter{
+			{"address", scoreapi.Address, nil, nil},
+			{"stake", scoreapi.Integer, nil, nil},
+			{"delegations", scoreapi.ListTypeOf(1, scoreapi.Struct), nil,
+				[]scoreapi.Field{
+					{"address", scoreapi.Address, nil},
+					{"value", scoreapi.Integer, nil},
+				},
+			},
+			{"bonds", scoreapi.ListTypeOf(1, scoreapi.Struct), nil,
+				[]scoreapi.Field{
+					{"address", scoreapi.Address, nil},
+					{"value", scoreapi.Integer, nil},
+				},
+			},
+		},
+		[]scoreapi.DataType{
+			scoreapi.Dict,
+		},
+	}, icmodule.RevisionEstimateIScore, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "getUnlockSchedule",
+		scoreapi.FlagReadOnly | scoreapi.FlagExternal, 0,
+		[]scoreapi.Parameter{
+			{"startHeight", scoreapi.Integer, nil, nil},
+			{"endHeight", scoreapi.Integer, nil, nil},
+			{"detail", scoreapi.Bool, nil, nil},
+			{"start", scoreapi.Integer, nil, nil},
+			{"size", scoreapi.Integer, nil, nil},
+		},
+		[]scoreapi.DataType{
+			scoreapi.Dict,
+		},
+	}, icmodule.RevisionUnlockSchedule, 0},
 	{scoreapi.Method{
 		scoreapi.Function, "registerPRep",
 		scoreapi.FlagExternal | scoreapi.FlagPayable, 7,
@@ -578,6 +627,204 @@ var chainMethods = []*chainMethod{
 		},
 		nil,
 	}, icmodule.RevisionEnableSetScoreOwner, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "setAutoCompound",
+		scoreapi.FlagExternal, 1,
+		[]scoreapi.Parameter{
+			{"enable", scoreapi.Bool, nil, nil},
+		},
+		nil,
+	}, icmodule.RevisionAutoCompound, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "getAutoCompound",
+		scoreapi.FlagReadOnly | scoreapi.FlagExternal, 1,
+		[]scoreapi.Parameter{
+			{"address", scoreapi.Address, nil, nil},
+		},
+		[]scoreapi.DataType{
+			scoreapi.Dict,
+		},
+	}, icmodule.RevisionAutoCompound, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "registerProposal",
+		scoreapi.FlagExternal, 4,
+		[]scoreapi.Parameter{
+			{"title", scoreapi.String, nil, nil},
+			{"description", scoreapi.String, nil, nil},
+			{"type", scoreapi.String, nil, nil},
+			{"value", scoreapi.Integer, nil, nil},
+			{"applyHeight", scoreapi.Integer, nil, nil},
+		},
+		[]scoreapi.DataType{
+			scoreapi.Integer,
+		},
+	}, icmodule.RevisionNetworkProposal, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "voteProposal",
+		scoreapi.FlagExternal, 2,
+		[]scoreapi.Parameter{
+			{"id", scoreapi.Integer, nil, nil},
+			{"approve", scoreapi.Bool, nil, nil},
+		},
+		nil,
+	}, icmodule.RevisionNetworkProposal, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "cancelProposal",
+		scoreapi.FlagExternal, 1,
+		[]scoreapi.Parameter{
+			{"id", scoreapi.Integer, nil, nil},
+		},
+		nil,
+	}, icmodule.RevisionNetworkProposal, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "getProposal",
+		scoreapi.FlagReadOnly | scoreapi.FlagExternal, 1,
+		[]scoreapi.Parameter{
+			{"id", scoreapi.Integer, nil, nil},
+		},
+		[]scoreapi.DataType{
+			scoreapi.Dict,
+		},
+	}, icmodule.RevisionNetworkProposal, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "getProposals",
+		scoreapi.FlagReadOnly | scoreapi.FlagExternal, 0,
+		[]scoreapi.Parameter{
+			{"start", scoreapi.Integer, nil, nil},
+			{"size", scoreapi.Integer, nil, nil},
+		},
+		[]scoreapi.DataType{
+			scoreapi.Dict,
+		},
+	}, icmodule.RevisionNetworkProposal, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "getPenaltyHistory",
+		scoreapi.FlagReadOnly | scoreapi.FlagExternal, 1,
+		[]scoreapi.Parameter{
+			{"address", scoreapi.Address, nil, nil},
+			{"start", scoreapi.Integer, nil, nil},
+			{"size", scoreapi.Integer, nil, nil},
+		},
+		[]scoreapi.DataType{
+			scoreapi.Dict,
+		},
+	}, icmodule.RevisionPenaltyHistory, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "getSlashingPreview",
+		scoreapi.FlagReadOnly | scoreapi.FlagExternal, 1,
+		[]scoreapi.Parameter{
+			{"address", scoreapi.Address, nil, nil},
+		},
+		[]scoreapi.DataType{
+			scoreapi.Dict,
+		},
+	}, icmodule.RevisionPenaltyHistory, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "createStakePosition",
+		scoreapi.FlagExternal, 2,
+		[]scoreapi.Parameter{
+			{"prep", scoreapi.Address, nil, nil},
+			{"value", scoreapi.Integer, nil, nil},
+		},
+		nil,
+	}, icmodule.RevisionStakePosition, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "transferStakePosition",
+		scoreapi.FlagExternal, 2,
+		[]scoreapi.Parameter{
+			{"id", scoreapi.Integer, nil, nil},
+			{"to", scoreapi.Address, nil, nil},
+		},
+		nil,
+	}, icmodule.RevisionStakePosition, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "redeemStakePosition",
+		scoreapi.FlagExternal, 1,
+		[]scoreapi.Parameter{
+			{"id", scoreapi.Integer, nil, nil},
+		},
+		nil,
+	}, icmodule.RevisionStakePosition, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "getStakePosition",
+		scoreapi.FlagReadOnly | scoreapi.FlagExternal, 1,
+		[]scoreapi.Parameter{
+			{"id", scoreapi.Integer, nil, nil},
+		},
+		[]scoreapi.DataType{
+			scoreapi.Dict,
+		},
+	}, icmodule.RevisionStakePosition, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "getStakePositions",
+		scoreapi.FlagReadOnly | scoreapi.FlagExternal, 1,
+		[]scoreapi.Parameter{
+			{"address", scoreapi.Address, nil, nil},
+		},
+		[]scoreapi.DataType{
+			scoreapi.Dict,
+		},
+	}, icmodule.RevisionStakePosition, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "setDeployValidatorEnabled",
+		scoreapi.FlagExternal, 2,
+		[]scoreapi.Parameter{
+			{"name", scoreapi.String, nil, nil},
+			{"yn", scoreapi.Bool, nil, nil},
+		},
+		nil,
+	}, icmodule.RevisionDeployValidator, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "getDeployValidators",
+		scoreapi.FlagReadOnly | scoreapi.FlagExternal, 0,
+		nil,
+		[]scoreapi.DataType{
+			scoreapi.List,
+		},
+	}, icmodule.RevisionDeployValidator, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "setMaxCodeSize",
+		scoreapi.FlagExternal, 2,
+		[]scoreapi.Parameter{
+			{"type", scoreapi.String, nil, nil},
+			{"size", scoreapi.Integer, nil, nil},
+		},
+		nil,
+	}, icmodule.RevisionDeployValidator, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "getMaxCodeSize",
+		scoreapi.FlagReadOnly | scoreapi.FlagExternal, 1,
+		[]scoreapi.Parameter{
+			{"type", scoreapi.String, nil, nil},
+		},
+		[]scoreapi.DataType{
+			scoreapi.Integer,
+		},
+	}, icmodule.RevisionDeployValidator, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "addForbiddenAPI",
+		scoreapi.FlagExternal, 1,
+		[]scoreapi.Parameter{
+			{"api", scoreapi.String, nil, nil},
+		},
+		nil,
+	}, icmodule.RevisionDeployValidator, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "removeForbiddenAPI",
+		scoreapi.FlagExternal, 1,
+		[]scoreapi.Parameter{
+			{"api", scoreapi.String, nil, nil},
+		},
+		nil,
+	}, icmodule.RevisionDeployValidator, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "getForbiddenAPIs",
+		scoreapi.FlagReadOnly | scoreapi.FlagExternal, 0,
+		nil,
+		[]scoreapi.DataType{
+			scoreapi.List,
+		},
+	}, icmodule.RevisionDeployValidator, 0},
 }
 
 func applyStepLimits(fee *FeeConfig, as state.AccountState) error {
@@ -725,3 +725,68 @@ func (s *chainScore) Ex_getFeeSharingConfig() (map[string]interface{}, error) {
 	fsConfig["depositIssueRate"] = s.cc.DepositIssueRate()
 	return fsConfig, nil
 }
+
+func (s *chainScore) Ex_setDeployValidatorEnabled(name string, yn bool) error {
+	if err := s.checkGovernance(true); err != nil {
+		return err
+	}
+	if !contract.IsDeployValidator(name) {
+		return scoreresult.New(StatusIllegalArgument, "UnknownDeployValidator")
+	}
+	as := s.cc.GetAccountState(state.SystemID)
+	return contract.SetDeployValidatorEnabled(as, name, yn)
+}
+
+func (s *chainScore) Ex_getDeployValidators() ([]interface{}, error) {
+	if err := s.tryChargeCall(false); err != nil {
+		return nil, err
+	}
+	as := s.cc.GetAccountState(state.SystemID)
+	return contract.GetDeployValidators(as), nil
+}
+
+func (s *chainScore) Ex_setMaxCodeSize(eeType string, size *common.HexInt) error {
+	if err := s.checkGovernance(true); err != nil {
+		return err
+	}
+	if !state.ValidateEEType(state.EEType(eeType)) || size.Sign() < 0 {
+		return scoreresult.New(StatusIllegalArgument, "IllegalArgument")
+	}
+	as := s.cc.GetAccountState(state.SystemID)
+	return contract.SetMaxCodeSize(as, eeType, size.Value())
+}
+
+func (s *chainScore) Ex_getMaxCodeSize(eeType string) (int64, error) {
+	if err := s.tryChargeCall(false); err != nil {
+		return 0, err
+	}
+	as := s.cc.GetAccountState(state.SystemID)
+	return contract.GetMaxCodeSize(as, eeType), nil
+}
+
+func (s *chainScore) Ex_addForbiddenAPI(api string) error {
+	if err := s.checkGovernance(true); err != nil {
+		return err
+	}
+	if len(api) == 0 {
+		return scoreresult.New(StatusIllegalArgument, "IllegalArgument")
+	}
+	as := s.cc.GetAccountState(state.SystemID)
+	return contract.AddForbiddenAPI(as, api)
+}
+
+func (s *chainScore) Ex_removeForbiddenAPI(api string) error {
+	if err := s.checkGovernance(true); err != nil {
+		return err
+	}
+	as := s.cc.GetAccountState(state.SystemID)
+	return contract.RemoveForbiddenAPI(as, api)
+}
+
+func (s *chainScore) Ex_getForbiddenAPIs() ([]interface{}, error) {
+	if err := s.tryChargeCall(false); err != nil {
+		return nil, err
+	}
+	as := s.cc.GetAccountState(state.SystemID)
+	return contract.GetForbiddenAPIs(as), nil
+}
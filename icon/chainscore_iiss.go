@@ -420,6 +420,213 @@ func (s *chainScore) Ex_claimIScore() error {
 	return es.ClaimIScore(cc)
 }
 
+func (s *chainScore) Ex_setAutoCompound(enable bool) error {
+	if err := s.tryChargeCall(true); err != nil {
+		return err
+	}
+	es, err := s.getExtensionState()
+	if err != nil {
+		return err
+	}
+	cc := s.newCallContext(s.cc)
+	return es.SetAutoCompound(cc, enable)
+}
+
+func (s *chainScore) Ex_getAutoCompound(address module.Address) (map[string]interface{}, error) {
+	if err := s.tryChargeCall(true); err != nil {
+		return nil, err
+	}
+	es, err := s.getExtensionState()
+	if err != nil {
+		return nil, err
+	}
+	ia := es.State.GetAccountSnapshot(address)
+	if ia == nil {
+		ia = icstate.GetEmptyAccountSnapshot()
+	}
+	return ia.GetAutoCompoundInJSON(), nil
+}
+
+func (s *chainScore) Ex_registerProposal(
+	title, description, typ string, value *common.HexInt, applyHeight *common.HexInt,
+) (int64, error) {
+	if err := s.tryChargeCall(true); err != nil {
+		return 0, err
+	}
+	es, err := s.getExtensionState()
+	if err != nil {
+		return 0, err
+	}
+	var height int64
+	if applyHeight != nil {
+		height = applyHeight.Int64()
+	}
+	cc := s.newCallContext(s.cc)
+	return es.RegisterNetworkProposal(cc, title, description, typ, value.Value(), height)
+}
+
+func (s *chainScore) Ex_voteProposal(id *common.HexInt, approve bool) error {
+	if err := s.tryChargeCall(true); err != nil {
+		return err
+	}
+	es, err := s.getExtensionState()
+	if err != nil {
+		return err
+	}
+	cc := s.newCallContext(s.cc)
+	return es.VoteNetworkProposal(cc, id.Int64(), approve)
+}
+
+func (s *chainScore) Ex_cancelProposal(id *common.HexInt) error {
+	if err := s.tryChargeCall(true); err != nil {
+		return err
+	}
+	es, err := s.getExtensionState()
+	if err != nil {
+		return err
+	}
+	cc := s.newCallContext(s.cc)
+	return es.CancelNetworkProposal(cc, id.Int64())
+}
+
+func (s *chainScore) Ex_getProposal(id *common.HexInt) (map[string]interface{}, error) {
+	if err := s.tryChargeCall(true); err != nil {
+		return nil, err
+	}
+	es, err := s.getExtensionState()
+	if err != nil {
+		return nil, err
+	}
+	return es.GetNetworkProposalInJSON(id.Int64())
+}
+
+func (s *chainScore) Ex_getProposals(start, size *common.HexInt) (map[string]interface{}, error) {
+	if err := s.tryChargeCall(true); err != nil {
+		return nil, err
+	}
+	es, err := s.getExtensionState()
+	if err != nil {
+		return nil, err
+	}
+	var from, n int64
+	if start != nil {
+		from = start.Int64()
+	}
+	if size != nil {
+		n = size.Int64()
+	}
+	return es.GetNetworkProposalsInJSON(from, n)
+}
+
+func (s *chainScore) Ex_getPenaltyHistory(
+	address module.Address, start, size *common.HexInt,
+) (map[string]interface{}, error) {
+	if err := s.tryChargeCall(true); err != nil {
+		return nil, err
+	}
+	es, err := s.getExtensionState()
+	if err != nil {
+		return nil, err
+	}
+	var from, n int
+	if start != nil {
+		from = int(start.Int64())
+	}
+	if size != nil {
+		n = int(size.Int64())
+	}
+	return es.GetPenaltyHistoryInJSON(address, from, n)
+}
+
+func (s *chainScore) Ex_getSlashingPreview(address module.Address) (map[string]interface{}, error) {
+	if err := s.tryChargeCall(true); err != nil {
+		return nil, err
+	}
+	es, err := s.getExtensionState()
+	if err != nil {
+		return nil, err
+	}
+	return es.GetSlashingPreview(address)
+}
+
+func (s *chainScore) Ex_createStakePosition(prep module.Address, value *common.HexInt) error {
+	if err := s.tryChargeCall(true); err != nil {
+		return err
+	}
+	es, err := s.getExtensionState()
+	if err != nil {
+		return err
+	}
+	cc := s.newCallContext(s.cc)
+	_, err = es.CreateStakePosition(cc, prep, value.Value())
+	return err
+}
+
+func (s *chainScore) Ex_transferStakePosition(id *common.HexInt, to module.Address) error {
+	if err := s.tryChargeCall(true); err != nil {
+		return err
+	}
+	es, err := s.getExtensionState()
+	if err != nil {
+		return err
+	}
+	cc := s.newCallContext(s.cc)
+	return es.TransferStakePosition(cc, id.Int64(), to)
+}
+
+func (s *chainScore) Ex_redeemStakePosition(id *common.HexInt) error {
+	if err := s.tryChargeCall(true); err != nil {
+		return err
+	}
+	es, err := s.getExtensionState()
+	if err != nil {
+		return err
+	}
+	cc := s.newCallContext(s.cc)
+	return es.RedeemStakePosition(cc, id.Int64())
+}
+
+func (s *chainScore) Ex_getStakePosition(id *common.HexInt) (map[string]interface{}, error) {
+	if err := s.tryChargeCall(true); err != nil {
+		return nil, err
+	}
+	es, err := s.getExtensionState()
+	if err != nil {
+		return nil, err
+	}
+	return es.GetStakePositionInJSON(id.Int64())
+}
+
+func (s *chainScore) Ex_getStakePositions(address module.Address) (map[string]interface{}, error) {
+	if err := s.tryChargeCall(true); err != nil {
+		return nil, err
+	}
+	es, err := s.getExtensionState()
+	if err != nil {
+		return nil, err
+	}
+	return es.GetStakePositionsInJSON(address)
+}
+
+// applyNetworkProposal calls the setter for the approved network proposal
+// as the governance SCORE.
+func (s *chainScore) applyNetworkProposal(p *icstate.NetworkProposal) error {
+	value := new(common.HexInt)
+	value.Set(p.Value())
+	switch p.Type() {
+	case iiss.NetworkProposalSetRevision:
+		return s.Ex_setRevision(value)
+	case iiss.NetworkProposalSetStepPrice:
+		return s.Ex_setStepPrice(value)
+	case iiss.NetworkProposalSetRewardFund:
+		return s.Ex_setRewardFund(value)
+	case iiss.NetworkProposalSetIRep:
+		return s.Ex_setIRep(value)
+	default:
+		return scoreresult.InvalidParameterError.Errorf("InvalidProposalType(%s)", p.Type())
+	}
+}
+
 func (s *chainScore) Ex_queryIScore(address module.Address) (map[string]interface{}, error) {
 	var err error
 	if err = s.tryChargeCall(true); err != nil {
@@ -445,6 +652,117 @@ func (s *chainScore) Ex_queryIScore(address module.Address) (map[string]interfac
 	return jso, nil
 }
 
+// Ex_getIScoreHistory returns rewards of the account for each term. The
+// history is written only by the nodes enabling it, so it's not allowed
+// in transactions.
+func (s *chainScore) Ex_getIScoreHistory(address module.Address, start, size *common.HexInt) (map[string]interface{}, error) {
+	if s.cc.TransactionInfo() != nil {
+		return nil, scoreresult.AccessDeniedError.New("QueryOnly")
+	}
+	if err := s.tryChargeCall(true); err != nil {
+		return nil, err
+	}
+	var from, n int
+	if start != nil {
+		from = int(start.Int64())
+	}
+	if size != nil {
+		n = int(size.Int64())
+	}
+	records, total, err := iiss.GetRewardHistory(s.cc.Database(), address, from, n)
+	if err != nil {
+		return nil, err
+	}
+	history := make([]interface{}, 0, len(records))
+	for _, r := range records {
+		history = append(history, r.ToJSON())
+	}
+	return map[string]interface{}{
+		"start":   from,
+		"total":   total,
+		"history": history,
+	}, nil
+}
+
+// Ex_estimateIScore returns the reward of the account expected for the
+// next term with the stake, delegations and bonds changed to the given ones.
+// It replays events of the terms, so it's not allowed in transactions.
+func (s *chainScore) Ex_estimateIScore(
+	address module.Address, stake *common.HexInt, delegations []interface{}, bonds []interface{},
+) (map[string]interface{}, error) {
+	if s.cc.TransactionInfo() != nil {
+		return nil, scoreresult.AccessDeniedError.New("QueryOnly")
+	}
+	if err := s.tryChargeCall(true); err != nil {
+		return nil, err
+	}
+	es, err := s.getExtensionState()
+	if err != nil {
+		return nil, err
+	}
+	var value *big.Int
+	if stake != nil {
+		if stake.Sign() < 0 {
+			return nil, scoreresult.InvalidParameterError.Errorf("NegativeStake(stake=%s)", stake)
+		}
+		value = &stake.Int
+	}
+	var ds icstate.Delegations
+	if delegations != nil {
+		if ds, err = icstate.NewDelegations(delegations, es.State.GetDelegationSlotMax()); err != nil {
+			return nil, err
+		}
+	}
+	var bl icstate.Bonds
+	if bonds != nil {
+		if bl, err = icstate.NewBonds(bonds); err != nil {
+			return nil, scoreresult.InvalidParameterError.Wrap(err, "InvalidBonds")
+		}
+	}
+	p, err := es.ProjectReward(address, value, ds, bl)
+	if err != nil {
+		return nil, err
+	}
+	jso := p.ToJSON()
+	jso["blockHeight"] = s.cc.BlockHeight()
+	return jso, nil
+}
+
+func (s *chainScore) Ex_getUnlockSchedule(
+	startHeight, endHeight *common.HexInt, detail bool, start, size *common.HexInt,
+) (map[string]interface{}, error) {
+	if s.cc.TransactionInfo() != nil {
+		return nil, scoreresult.AccessDeniedError.New("QueryOnly")
+	}
+	if err := s.tryChargeCall(true); err != nil {
+		return nil, err
+	}
+	es, err := s.getExtensionState()
+	if err != nil {
+		return nil, err
+	}
+	var sh, eh int64
+	if startHeight != nil {
+		sh = startHeight.Int64()
+	}
+	if endHeight != nil {
+		eh = endHeight.Int64()
+	}
+	var from, n int
+	if start != nil {
+		from = int(start.Int64())
+	}
+	if size != nil {
+		n = int(size.Int64())
+	}
+	jso, err := es.GetUnlockScheduleInJSON(s.cc.BlockHeight(), sh, eh, detail, from, n)
+	if err != nil {
+		return nil, err
+	}
+	jso["blockHeight"] = s.cc.BlockHeight()
+	return jso, nil
+}
+
 func (s *chainScore) Ex_estimateUnstakeLockPeriod() (map[string]interface{}, error) {
 	if err := s.tryChargeCall(true); err != nil {
 		return nil, err
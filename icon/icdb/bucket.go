@@ -26,4 +26,12 @@ const (
 	// BlockMerkle basically maps node hash to block merkle node for v1 block.
 	// In addition, it also has merkleTreeData.
 	BlockMerkle db.BucketID = "H"
+
+	// RewardHistory maps address to rewards of the account for each term.
+	// It's written only if the history is enabled for the node.
+	RewardHistory db.BucketID = "R"
+
+	// CalculatorCheckpoint has the last checkpoint of the reward calculation
+	// to resume it after restart.
+	CalculatorCheckpoint db.BucketID = "K"
 )
@@ -73,6 +73,10 @@ const (
 	DefaultConsistentValidationPenaltySlashRatio = 10
 	DefaultDelegationSlotMax                     = 100
 	DefaultExtraMainPRepCount                    = 3
+
+	// NetworkProposalVotingTerms is the number of terms including the term
+	// of the registration, during which a network proposal can be voted.
+	NetworkProposalVotingTerms = 14
 )
 
 // The following variables are read-only
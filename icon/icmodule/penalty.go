@@ -22,4 +22,5 @@ const (
 	PenaltyPRepDisqualification
 	PenaltyLowProductivity
 	PenaltyBlockValidation
+	PenaltyConsistentValidation
 )
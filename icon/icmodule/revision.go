@@ -35,6 +35,7 @@ const (
 	Revision14
 	Revision15
 	Revision16
+	Revision17
 	RevisionReserved
 )
 
@@ -86,6 +87,16 @@ const (
 	RevisionICON2R3 = Revision16
 	RevisionEnableSetScoreOwner = RevisionICON2R3
 
+	RevisionICON2R4         = Revision17
+	RevisionAutoCompound    = RevisionICON2R4
+	RevisionNetworkProposal = RevisionICON2R4
+	RevisionPenaltyHistory  = RevisionICON2R4
+	RevisionStakePosition   = RevisionICON2R4
+	RevisionDeployValidator = RevisionICON2R4
+	RevisionIScoreHistory   = RevisionICON2R4
+	RevisionEstimateIScore  = RevisionICON2R4
+	RevisionUnlockSchedule  = RevisionICON2R4
+
 	// TODO: Fix a revision for enabling extra main preps
 	RevisionExtraMainPReps = 100
 )
@@ -0,0 +1,228 @@
+/*
+ * Copyright 2021 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package icsim
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/icon/icmodule"
+	"github.com/icon-project/goloop/icon/iiss"
+	"github.com/icon-project/goloop/icon/iiss/icreward"
+	"github.com/icon-project/goloop/icon/iiss/icstate"
+	"github.com/icon-project/goloop/icon/iiss/icutils"
+	"github.com/icon-project/goloop/module"
+)
+
+// addIScore adds I-Score of the account to the reward state directly,
+// and funds the treasury to pay for the claim.
+func addIScore(t *testing.T, sim *simulatorImpl, address module.Address, iScore *big.Int) {
+	ws := newWorldState(sim.wss, false)
+	es := ws.GetExtensionState().(*iiss.ExtensionStateImpl)
+	is, err := es.Reward.GetIScore(address)
+	assert.NoError(t, err)
+	value := new(big.Int).Set(iScore)
+	if is != nil {
+		value.Add(value, is.Value())
+	}
+	assert.NoError(t, es.Reward.SetIScore(address, icreward.NewIScore(value)))
+
+	as := ws.GetAccountState(treasury.ID())
+	balance := new(big.Int).Add(as.GetBalance(), icutils.IScoreToICX(iScore))
+	assert.NoError(t, setBalance(treasury, as, balance))
+
+	sim.wss = ws.GetSnapshot()
+	assert.NoError(t, sim.wss.Flush())
+}
+
+func TestSimulator_AutoCompound(t *testing.T) {
+	c := NewConfig()
+	c.MainPRepCount = 22
+	c.TermPeriod = 100
+
+	env := initEnv(t, c, icmodule.Revision13)
+	sim := env.sim
+	user := env.users[0]
+
+	receipts, err := sim.GoByTransaction(sim.SetRevision(icmodule.RevisionICON2R1), nil)
+	assert.NoError(t, err)
+	assert.True(t, checkReceipts(receipts))
+
+	// split delegation of the user into two P-Reps
+	stake := sim.GetStake(user)["stake"].(*big.Int)
+	half := new(big.Int).Div(stake, big.NewInt(2))
+	ds := icstate.Delegations{
+		icstate.NewDelegation(common.AddressToPtr(env.preps[0]), half),
+		icstate.NewDelegation(common.AddressToPtr(env.preps[1]), new(big.Int).Sub(stake, half)),
+	}
+	receipts, err = sim.GoByTransaction(sim.SetDelegation(user, ds), nil)
+	assert.NoError(t, err)
+	assert.True(t, checkReceipts(receipts))
+
+	receipts, err = sim.GoByTransaction(sim.SetAutoCompound(user, true), nil)
+	assert.NoError(t, err)
+	assert.True(t, checkReceipts(receipts))
+	assert.Equal(t, true, sim.GetAutoCompound(user)["enabled"])
+	assert.Equal(t, false, sim.GetAutoCompound(env.users[1])["enabled"])
+
+	// claimed ICX is not compounded before the revision
+	iScore := big.NewInt(3001000)
+	icx := icutils.IScoreToICX(iScore)
+	balance := sim.GetBalance(user)
+	addIScore(t, sim.(*simulatorImpl), user, iScore)
+	receipts, err = sim.GoByTransaction(sim.ClaimIScore(user), nil)
+	assert.NoError(t, err)
+	assert.True(t, checkReceipts(receipts))
+	assert.Equal(t, new(big.Int).Add(balance, icx), sim.GetBalance(user))
+	assert.Equal(t, stake, sim.GetStake(user)["stake"])
+
+	receipts, err = sim.GoByTransaction(sim.SetRevision(icmodule.RevisionAutoCompound), nil)
+	assert.NoError(t, err)
+	assert.True(t, checkReceipts(receipts))
+
+	// claimed ICX is staked and delegated again
+	addIScore(t, sim.(*simulatorImpl), user, iScore)
+	balance = sim.GetBalance(user)
+	delegated0 := sim.GetPRep(env.preps[0]).Delegated()
+
+	receipts, err = sim.GoByTransaction(sim.ClaimIScore(user), nil)
+	assert.NoError(t, err)
+	assert.True(t, checkReceipts(receipts))
+	assert.Zero(t, sim.QueryIScore(user).Sign())
+	assert.Equal(t, balance, sim.GetBalance(user))
+	assert.Equal(t, new(big.Int).Add(stake, icx), sim.GetStake(user)["stake"])
+
+	jso := sim.GetDelegation(user)
+	assert.Equal(t, new(big.Int).Add(stake, icx), jso["totalDelegated"])
+	delegations := jso["delegations"].([]interface{})
+	if assert.Len(t, delegations, 2) {
+		assert.Equal(t, 0, new(big.Int).Add(half, big.NewInt(1501)).Cmp(
+			delegations[0].(map[string]interface{})["value"].(*common.HexInt).Value()))
+	}
+	assert.Equal(t, new(big.Int).Add(delegated0, big.NewInt(1501)), sim.GetPRep(env.preps[0]).Delegated())
+
+	// claimed ICX is kept in the balance after disabling it
+	receipts, err = sim.GoByTransaction(sim.SetAutoCompound(user, false), nil)
+	assert.NoError(t, err)
+	assert.True(t, checkReceipts(receipts))
+	assert.Equal(t, false, sim.GetAutoCompound(user)["enabled"])
+
+	stake = sim.GetStake(user)["stake"].(*big.Int)
+	addIScore(t, sim.(*simulatorImpl), user, iScore)
+	receipts, err = sim.GoByTransaction(sim.ClaimIScore(user), nil)
+	assert.NoError(t, err)
+	assert.True(t, checkReceipts(receipts))
+	assert.Equal(t, new(big.Int).Add(balance, icx), sim.GetBalance(user))
+	assert.Equal(t, stake, sim.GetStake(user)["stake"])
+}
+
+// setDelegationSlotMax changes the delegation slot max in the state directly.
+func setDelegationSlotMax(t *testing.T, sim *simulatorImpl, slotMax int64) {
+	ws := newWorldState(sim.wss, false)
+	es := ws.GetExtensionState().(*iiss.ExtensionStateImpl)
+	assert.NoError(t, es.State.SetDelegationSlotMax(slotMax))
+
+	sim.wss = ws.GetSnapshot()
+	assert.NoError(t, sim.wss.Flush())
+}
+
+func initAutoCompoundEnv(t *testing.T) (*Env, module.Address) {
+	c := NewConfig()
+	c.MainPRepCount = 22
+	c.TermPeriod = 100
+
+	env := initEnv(t, c, icmodule.Revision13)
+	sim := env.sim
+	user := env.users[0]
+
+	receipts, err := sim.GoByTransaction(sim.SetRevision(icmodule.RevisionAutoCompound), nil)
+	assert.NoError(t, err)
+	assert.True(t, checkReceipts(receipts))
+
+	receipts, err = sim.GoByTransaction(sim.SetAutoCompound(user, true), nil)
+	assert.NoError(t, err)
+	assert.True(t, checkReceipts(receipts))
+	return env, user
+}
+
+func TestSimulator_AutoCompoundWithUnstakes(t *testing.T) {
+	env, user := initAutoCompoundEnv(t)
+	sim := env.sim
+
+	stake := sim.GetStake(user)["stake"].(*big.Int)
+	unstake := new(big.Int).Div(stake, big.NewInt(2))
+	ds := icstate.Delegations{
+		icstate.NewDelegation(common.AddressToPtr(env.preps[0]), new(big.Int).Sub(stake, unstake)),
+	}
+	receipts, err := sim.GoByTransaction(sim.SetDelegation(user, ds), nil)
+	assert.NoError(t, err)
+	assert.True(t, checkReceipts(receipts))
+	addUnstake(t, sim.(*simulatorImpl), user, unstake, sim.BlockHeight()+1000)
+	stake = sim.GetStake(user)["stake"].(*big.Int)
+	balance := sim.GetBalance(user)
+
+	// claimed ICX is staked without cancelling unstaking
+	iScore := big.NewInt(3001000)
+	icx := icutils.IScoreToICX(iScore)
+	addIScore(t, sim.(*simulatorImpl), user, iScore)
+	receipts, err = sim.GoByTransaction(sim.ClaimIScore(user), nil)
+	assert.NoError(t, err)
+	assert.True(t, checkReceipts(receipts))
+	assert.Equal(t, balance, sim.GetBalance(user))
+	assert.Equal(t, new(big.Int).Add(stake, icx), sim.GetDelegation(user)["totalDelegated"])
+
+	jso := sim.GetStake(user)
+	assert.Equal(t, new(big.Int).Add(stake, icx), jso["stake"])
+	unstakes := jso["unstakes"].([]interface{})
+	if assert.Len(t, unstakes, 1) {
+		assert.Equal(t, unstake, unstakes[0].(map[string]interface{})["unstake"])
+	}
+}
+
+func TestSimulator_AutoCompoundFailure(t *testing.T) {
+	env, user := initAutoCompoundEnv(t)
+	sim := env.sim
+
+	stake := sim.GetStake(user)["stake"].(*big.Int)
+	half := new(big.Int).Div(stake, big.NewInt(2))
+	ds := icstate.Delegations{
+		icstate.NewDelegation(common.AddressToPtr(env.preps[0]), half),
+		icstate.NewDelegation(common.AddressToPtr(env.preps[1]), new(big.Int).Sub(stake, half)),
+	}
+	receipts, err := sim.GoByTransaction(sim.SetDelegation(user, ds), nil)
+	assert.NoError(t, err)
+	assert.True(t, checkReceipts(receipts))
+
+	// delegations can't be updated, so claimed ICX is kept in the balance
+	setDelegationSlotMax(t, sim.(*simulatorImpl), 1)
+	balance := sim.GetBalance(user)
+	delegation := sim.GetDelegation(user)
+
+	iScore := big.NewInt(3001000)
+	icx := icutils.IScoreToICX(iScore)
+	addIScore(t, sim.(*simulatorImpl), user, iScore)
+	receipts, err = sim.GoByTransaction(sim.ClaimIScore(user), nil)
+	assert.NoError(t, err)
+	assert.True(t, checkReceipts(receipts))
+	assert.Zero(t, sim.QueryIScore(user).Sign())
+	assert.Equal(t, new(big.Int).Add(balance, icx), sim.GetBalance(user))
+	assert.Equal(t, stake, sim.GetStake(user)["stake"])
+	assert.Equal(t, delegation, sim.GetDelegation(user))
+}
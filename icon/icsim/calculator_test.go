@@ -0,0 +1,170 @@
+/*
+ * Copyright 2021 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package icsim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/db"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/icon/icdb"
+	"github.com/icon-project/goloop/icon/icmodule"
+	"github.com/icon-project/goloop/icon/iiss"
+	"github.com/icon-project/goloop/icon/iiss/icstate"
+	"github.com/icon-project/goloop/icon/iiss/icutils"
+)
+
+// calculatorDB keeps the checkpoint and the reward history of a calculator
+// apart from the database of the simulator. It blocks on storing the n-th
+// checkpoint until the test lets it go.
+type calculatorDB struct {
+	db.Database
+	local db.Database
+
+	stopAt  int
+	stored  int
+	reached chan struct{}
+	release chan struct{}
+}
+
+func (d *calculatorDB) GetBucket(id db.BucketID) (db.Bucket, error) {
+	switch id {
+	case icdb.CalculatorCheckpoint:
+		bk, err := d.local.GetBucket(id)
+		if err != nil {
+			return nil, err
+		}
+		return &checkpointBucket{bk, d}, nil
+	case icdb.RewardHistory:
+		return d.local.GetBucket(id)
+	default:
+		return d.Database.GetBucket(id)
+	}
+}
+
+type checkpointBucket struct {
+	db.Bucket
+	d *calculatorDB
+}
+
+func (b *checkpointBucket) Set(key, value []byte) error {
+	if err := b.Bucket.Set(key, value); err != nil {
+		return err
+	}
+	b.d.stored += 1
+	if b.d.stored == b.d.stopAt {
+		b.d.reached <- struct{}{}
+		<-b.d.release
+	}
+	return nil
+}
+
+func newCalculatorDB(database db.Database, stopAt int) *calculatorDB {
+	return &calculatorDB{
+		Database: database,
+		local:    db.NewMapDB(),
+		stopAt:   stopAt,
+		reached:  make(chan struct{}),
+		release:  make(chan struct{}),
+	}
+}
+
+func TestCalculator_ResumeWithRewardHistory(t *testing.T) {
+	c := NewConfig()
+	c.MainPRepCount = 22
+	c.TermPeriod = 100
+
+	env := initEnv(t, c, icmodule.Revision13)
+	sim := env.sim
+
+	// a new P-Rep and new accounts delegating to it make voting rewards
+	prep := newDummyAddress(5000)
+	users := newDummyAddresses(6000, 35)
+	amount := icutils.ToLoop(10000)
+	ws := newWorldState(sim.(*simulatorImpl).wss, false)
+	assert.NoError(t, setBalance(prep, ws.GetAccountState(prep.ID()), icutils.ToLoop(3000)))
+	for _, user := range users {
+		assert.NoError(t, setBalance(user, ws.GetAccountState(user.ID()), amount))
+	}
+	sim.(*simulatorImpl).wss = ws.GetSnapshot()
+	assert.NoError(t, sim.(*simulatorImpl).wss.Flush())
+
+	receipts, err := sim.GoByTransaction(sim.RegisterPRep(prep, newDummyPRepInfo(5000)), nil)
+	assert.NoError(t, err)
+	assert.True(t, checkReceipts(receipts))
+	block := NewBlock()
+	for _, user := range users {
+		block.AddTransaction(sim.SetStake(user, amount))
+	}
+	receipts, err = sim.GoByBlock(block, nil)
+	assert.NoError(t, err)
+	assert.True(t, checkReceipts(receipts))
+	block = NewBlock()
+	for _, user := range users {
+		block.AddTransaction(sim.SetDelegation(user, icstate.Delegations{
+			icstate.NewDelegation(common.AddressToPtr(prep), amount),
+		}))
+	}
+	receipts, err = sim.GoByBlock(block, nil)
+	assert.NoError(t, err)
+	assert.True(t, checkReceipts(receipts))
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, sim.Go(1, nil))
+		assert.NoError(t, sim.GoToTermEnd(nil))
+	}
+
+	ess := sim.(*simulatorImpl).wss.GetExtensionSnapshot().(*iiss.ExtensionSnapshotImpl)
+	cfg := &iiss.CalculatorConfig{RewardHistory: true, CheckpointInterval: 10}
+	logger := log.GlobalLogger()
+
+	// uninterrupted calculation
+	database := newCalculatorDB(sim.Database(), 0)
+	calc := iiss.NewCalculator(database, ess.Back2(), ess.Reward(), cfg, logger)
+	assert.NoError(t, calc.WaitResult(calc.StartHeight()))
+	expected := calc.Result()
+	assert.True(t, calc.TotalReward().Sign() > 0)
+
+	// calculation interrupted in the middle of the voting phase, after
+	// checkpoints of claim, blockProduce, voted, delegating and some of
+	// delegating events
+	interrupted := newCalculatorDB(sim.Database(), 6)
+	calc = iiss.NewCalculator(interrupted, ess.Back2(), ess.Reward(), cfg, logger)
+	<-interrupted.reached
+	calc.Stop()
+	interrupted.release <- struct{}{}
+	assert.Error(t, calc.WaitResult(calc.StartHeight()))
+
+	calc = iiss.NewCalculator(interrupted, ess.Back2(), ess.Reward(), cfg, logger)
+	assert.NoError(t, calc.WaitResult(calc.StartHeight()))
+	jso := calc.Progress()
+	assert.Equal(t, true, jso["resumed"])
+	assert.Equal(t, "done", jso["state"])
+	assert.Equal(t, expected.Bytes(), calc.Result().Bytes())
+
+	for _, addr := range users {
+		records, total, err := iiss.GetRewardHistory(database, addr, 0, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, total)
+		records2, total2, err := iiss.GetRewardHistory(interrupted, addr, 0, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, total, total2)
+		assert.Equal(t, records, records2)
+	}
+}
@@ -3,31 +3,31 @@ package icsim
 import "github.com/icon-project/goloop/icon/icmodule"
 
 type RewardFund struct {
-	Iglobal int64
-	Iprep   int64
-	Icps    int64
-	Irelay  int64
-	Ivoter  int64
+	Iglobal int64 `json:"iglobal" yaml:"iglobal"`
+	Iprep   int64 `json:"iprep" yaml:"iprep"`
+	Icps    int64 `json:"icps" yaml:"icps"`
+	Irelay  int64 `json:"irelay" yaml:"irelay"`
+	Ivoter  int64 `json:"ivoter" yaml:"ivoter"`
 }
 
 type config struct {
-	TermPeriod                            int64
-	MainPRepCount                         int64
-	SubPRepCount                          int64
-	Irep                                  int64
-	Rrep                                  int64
-	BondRequirement                       int64
-	UnbondingPeriodMultiplier             int64
-	UnstakeSlotMax                        int64
-	LockMinMultiplier                     int64
-	LockMaxMultiplier                     int64
-	UnbondingMax                          int64
-	ValidationPenaltyCondition            int
-	ConsistentValidationPenaltyCondition  int64
-	ConsistentValidationPenaltyMask       int64
-	ConsistentValidationPenaltySlashRatio int
-	DelegationSlotMax                     int64
-	RewardFund
+	TermPeriod                            int64 `json:"termPeriod" yaml:"termPeriod"`
+	MainPRepCount                         int64 `json:"mainPRepCount" yaml:"mainPRepCount"`
+	SubPRepCount                          int64 `json:"subPRepCount" yaml:"subPRepCount"`
+	Irep                                  int64 `json:"irep" yaml:"irep"`
+	Rrep                                  int64 `json:"rrep" yaml:"rrep"`
+	BondRequirement                       int64 `json:"bondRequirement" yaml:"bondRequirement"`
+	UnbondingPeriodMultiplier             int64 `json:"unbondingPeriodMultiplier" yaml:"unbondingPeriodMultiplier"`
+	UnstakeSlotMax                        int64 `json:"unstakeSlotMax" yaml:"unstakeSlotMax"`
+	LockMinMultiplier                     int64 `json:"lockMinMultiplier" yaml:"lockMinMultiplier"`
+	LockMaxMultiplier                     int64 `json:"lockMaxMultiplier" yaml:"lockMaxMultiplier"`
+	UnbondingMax                          int64 `json:"unbondingMax" yaml:"unbondingMax"`
+	ValidationPenaltyCondition            int   `json:"validationPenaltyCondition" yaml:"validationPenaltyCondition"`
+	ConsistentValidationPenaltyCondition  int64 `json:"consistentValidationPenaltyCondition" yaml:"consistentValidationPenaltyCondition"`
+	ConsistentValidationPenaltyMask       int64 `json:"consistentValidationPenaltyMask" yaml:"consistentValidationPenaltyMask"`
+	ConsistentValidationPenaltySlashRatio int   `json:"consistentValidationPenaltySlashRatio" yaml:"consistentValidationPenaltySlashRatio"`
+	DelegationSlotMax                     int64 `json:"delegationSlotMax" yaml:"delegationSlotMax"`
+	RewardFund                            `json:"rewardFund" yaml:"rewardFund"`
 }
 
 func NewConfig() *config {
@@ -0,0 +1,126 @@
+/*
+ * Copyright 2021 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package icsim
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/icon/icmodule"
+	"github.com/icon-project/goloop/icon/iiss"
+	"github.com/icon-project/goloop/icon/iiss/icutils"
+	"github.com/icon-project/goloop/module"
+)
+
+func goByTransaction(t *testing.T, sim Simulator, tx Transaction, success bool) {
+	receipts, err := sim.GoByTransaction(tx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, success, checkReceipts(receipts))
+}
+
+func voteProposal(t *testing.T, sim Simulator, preps []module.Address, id int64, approve bool) {
+	block := NewBlock()
+	for _, prep := range preps {
+		block.AddTransaction(sim.VoteProposal(prep, id, approve))
+	}
+	receipts, err := sim.GoByBlock(block, nil)
+	assert.NoError(t, err)
+	assert.True(t, checkReceipts(receipts))
+}
+
+func TestSimulator_NetworkProposal(t *testing.T) {
+	c := NewConfig()
+	c.MainPRepCount = 22
+	c.TermPeriod = 100
+
+	env := initEnv(t, c, icmodule.Revision13)
+	sim := env.sim
+	preps := env.preps
+	user := env.users[0]
+
+	goByTransaction(t, sim, sim.SetRevision(icmodule.RevisionNetworkProposal), true)
+
+	irep := icutils.ToLoop(30000)
+	register := func(from module.Address) int64 {
+		goByTransaction(t, sim,
+			sim.RegisterProposal(from, "irep", "change irep", iiss.NetworkProposalSetIRep, irep, 0), true)
+		return sim.GetProposals(0, 0)["total"].(int64)
+	}
+
+	// only main P-Reps can register a proposal with a valid type
+	goByTransaction(t, sim,
+		sim.RegisterProposal(user, "irep", "", iiss.NetworkProposalSetIRep, irep, 0), false)
+	goByTransaction(t, sim,
+		sim.RegisterProposal(preps[22], "irep", "", iiss.NetworkProposalSetIRep, irep, 0), false)
+	goByTransaction(t, sim,
+		sim.RegisterProposal(preps[0], "irep", "", "unknown", irep, 0), false)
+	goByTransaction(t, sim,
+		sim.RegisterProposal(preps[0], "rev", "", iiss.NetworkProposalSetRevision,
+			big.NewInt(icmodule.RevisionNetworkProposal), 0), false)
+
+	// approved with more than 2/3 of the total weight, and applied
+	id := register(preps[0])
+	assert.Equal(t, int64(1), id)
+	jso := sim.GetProposal(id)
+	assert.Equal(t, "voting", jso["status"])
+	assert.Len(t, jso["voters"], 22)
+
+	goByTransaction(t, sim, sim.VoteProposal(user, id, true), false)
+	voteProposal(t, sim, preps[:14], id, true)
+	goByTransaction(t, sim, sim.VoteProposal(preps[0], id, true), false)
+	assert.Equal(t, "voting", sim.GetProposal(id)["status"])
+
+	voteProposal(t, sim, preps[14:15], id, true)
+	assert.Equal(t, "approved", sim.GetProposal(id)["status"])
+	goByTransaction(t, sim, sim.VoteProposal(preps[15], id, false), false)
+
+	assert.NoError(t, sim.Go(1, nil))
+	assert.Equal(t, "applied", sim.GetProposal(id)["status"])
+	assert.Zero(t, irep.Cmp(sim.(*simulatorImpl).getExtensionState(true).State.GetIRep()))
+
+	// canceled by the proposer
+	id = register(preps[1])
+	goByTransaction(t, sim, sim.CancelProposal(preps[0], id), false)
+	goByTransaction(t, sim, sim.CancelProposal(preps[1], id), true)
+	assert.Equal(t, "canceled", sim.GetProposal(id)["status"])
+	goByTransaction(t, sim, sim.VoteProposal(preps[0], id, true), false)
+
+	// rejected with 1/3 or more of the total weight
+	id = register(preps[2])
+	voteProposal(t, sim, preps[:7], id, false)
+	assert.Equal(t, "voting", sim.GetProposal(id)["status"])
+	voteProposal(t, sim, preps[7:8], id, false)
+	assert.Equal(t, "rejected", sim.GetProposal(id)["status"])
+
+	// expired after the voting period
+	id = register(preps[3])
+	expireHeight := sim.GetProposal(id)["expireHeight"].(int64)
+	assert.NoError(t, sim.GoTo(expireHeight, nil))
+	assert.Equal(t, "voting", sim.GetProposal(id)["status"])
+	assert.NoError(t, sim.Go(1, nil))
+	assert.Equal(t, "expired", sim.GetProposal(id)["status"])
+
+	jso = sim.GetProposals(2, 2)
+	assert.Equal(t, int64(4), jso["total"])
+	proposals := jso["proposals"].([]interface{})
+	if assert.Len(t, proposals, 2) {
+		assert.Equal(t, "canceled", proposals[0].(map[string]interface{})["status"])
+		assert.Equal(t, "rejected", proposals[1].(map[string]interface{})["status"])
+	}
+}
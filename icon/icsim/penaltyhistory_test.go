@@ -0,0 +1,121 @@
+/*
+ * Copyright 2021 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package icsim
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/icon/icmodule"
+	"github.com/icon-project/goloop/icon/iiss/icstate"
+)
+
+func TestSimulator_PenaltyHistory(t *testing.T) {
+	const (
+		termPeriod                           = 100
+		mainPRepCount                        = 22
+		validationPenaltyCondition           = 5
+		consistentValidationPenaltyCondition = 3
+	)
+
+	c := NewConfig()
+	c.MainPRepCount = mainPRepCount
+	c.TermPeriod = termPeriod
+	c.ValidationPenaltyCondition = validationPenaltyCondition
+	c.ConsistentValidationPenaltyCondition = consistentValidationPenaltyCondition
+
+	voted := make([]bool, mainPRepCount)
+	for i := 0; i < len(voted); i++ {
+		voted[i] = true
+	}
+
+	env := initEnv(t, c, icmodule.Revision13)
+	sim := env.sim
+	prep0 := env.preps[0]
+
+	goByTransaction(t, sim, sim.SetRevision(icmodule.RevisionPenaltyHistory), true)
+
+	jso := sim.GetPenaltyHistory(prep0, 0, 0)
+	assert.Equal(t, 0, jso["total"])
+	assert.Len(t, jso["penalties"], 0)
+
+	// preview shows the amount slashed from the bonders
+	bonded := sim.GetPRep(prep0).Bonded()
+	preview := sim.GetSlashingPreview(prep0)
+	assert.Equal(t, sim.(*simulatorImpl).getExtensionState(true).State.GetConsistentValidationPenaltySlashRatio(),
+		preview["slashRatio"])
+	expected := new(big.Int).Div(bonded, big.NewInt(10))
+	assert.Zero(t, expected.Cmp(preview["amount"].(*big.Int)))
+	slashes := preview["slashes"].([]interface{})
+	assert.True(t, len(slashes) > 0)
+	assert.Nil(t, sim.GetSlashingPreview(env.users[0]))
+
+	for i := 0; i < consistentValidationPenaltyCondition; i++ {
+		vl := sim.ValidatorList()
+		assert.True(t, prep0.Equal(vl[0].Address()))
+		voted[0] = false
+		csi := newConsensusInfo(sim.Database(), vl, voted)
+		assert.NoError(t, sim.Go(validationPenaltyCondition, csi))
+
+		vl = sim.ValidatorList()
+		voted[0] = true
+		csi = newConsensusInfo(sim.Database(), vl, voted)
+		assert.NoError(t, sim.GoToTermEnd(csi))
+	}
+	assert.Zero(t, sim.GetPRep(prep0).Bonded().Cmp(new(big.Int).Sub(bonded, expected)))
+
+	jso = sim.GetPenaltyHistory(prep0, 0, 0)
+	assert.Equal(t, consistentValidationPenaltyCondition, jso["total"])
+	penalties := jso["penalties"].([]interface{})
+	if assert.Len(t, penalties, consistentValidationPenaltyCondition) {
+		for i, p := range penalties {
+			record := p.(map[string]interface{})
+			if i < consistentValidationPenaltyCondition-1 {
+				assert.Equal(t, int(icmodule.PenaltyBlockValidation), record["type"])
+				assert.Zero(t, record["amount"].(*big.Int).Sign())
+				assert.Len(t, record["slashes"], 0)
+			} else {
+				assert.Equal(t, int(icmodule.PenaltyConsistentValidation), record["type"])
+				assert.Zero(t, expected.Cmp(record["amount"].(*big.Int)))
+				assert.Len(t, record["slashes"], len(slashes))
+			}
+			if i > 0 {
+				prev := penalties[i-1].(map[string]interface{})
+				assert.True(t, prev["blockHeight"].(int64) < record["blockHeight"].(int64))
+			}
+		}
+	}
+	jso = sim.GetPenaltyHistory(prep0, 1, 1)
+	assert.Len(t, jso["penalties"], 1)
+
+	// disqualification
+	prep1 := env.preps[1]
+	goByTransaction(t, sim, sim.DisqualifyPRep(env.users[0], prep1), true)
+	assert.Equal(t, icstate.Disqualified, sim.GetPRep(prep1).Status())
+	jso = sim.GetPenaltyHistory(prep1, 0, 0)
+	penalties = jso["penalties"].([]interface{})
+	if assert.Len(t, penalties, 1) {
+		record := penalties[0].(map[string]interface{})
+		assert.Equal(t, int(icmodule.PenaltyPRepDisqualification), record["type"])
+		assert.Equal(t, sim.BlockHeight(), record["blockHeight"])
+	}
+
+	assert.Nil(t, sim.GetPenaltyHistory(prep1, -1, 0))
+	assert.Nil(t, sim.GetPenaltyHistory(prep1, 0, 101))
+}
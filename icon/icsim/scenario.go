@@ -0,0 +1,741 @@
+/*
+ * Copyright 2021 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package icsim
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/crypto"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/icon/icmodule"
+	"github.com/icon-project/goloop/icon/iiss/icstate"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/state"
+)
+
+// Amount is an integer in a scenario. It's written as a number, a decimal
+// or hexadecimal string, or a string with "icx" suffix like "1.5icx" for
+// the amount in ICX.
+type Amount struct {
+	big.Int
+}
+
+func (a *Amount) set(v interface{}) error {
+	switch value := v.(type) {
+	case int:
+		a.SetInt64(int64(value))
+	case int64:
+		a.SetInt64(value)
+	case uint64:
+		a.SetUint64(value)
+	case float64:
+		if _, accuracy := new(big.Float).SetFloat64(value).Int(&a.Int); accuracy != big.Exact {
+			return errors.IllegalArgumentError.Errorf("InvalidAmount(%v)", value)
+		}
+	case string:
+		return a.setString(value)
+	default:
+		return errors.IllegalArgumentError.Errorf("InvalidAmount(%v)", v)
+	}
+	return nil
+}
+
+func (a *Amount) setString(s string) error {
+	s = strings.TrimSpace(s)
+	if strings.HasSuffix(s, "icx") {
+		r, ok := new(big.Rat).SetString(strings.TrimSpace(strings.TrimSuffix(s, "icx")))
+		if !ok {
+			return errors.IllegalArgumentError.Errorf("InvalidAmount(%s)", s)
+		}
+		r.Mul(r, new(big.Rat).SetInt(icmodule.BigIntICX))
+		if !r.IsInt() {
+			return errors.IllegalArgumentError.Errorf("InvalidAmount(%s)", s)
+		}
+		a.Set(r.Num())
+		return nil
+	}
+	if _, ok := a.SetString(s, 0); !ok {
+		return errors.IllegalArgumentError.Errorf("InvalidAmount(%s)", s)
+	}
+	return nil
+}
+
+func (a *Amount) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	d := json.NewDecoder(strings.NewReader(string(b)))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return err
+	}
+	if n, ok := v.(json.Number); ok {
+		v = n.String()
+	}
+	return a.set(v)
+}
+
+func (a *Amount) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var v interface{}
+	if err := unmarshal(&v); err != nil {
+		return err
+	}
+	return a.set(v)
+}
+
+func (a *Amount) Value() *big.Int {
+	if a == nil {
+		return new(big.Int)
+	}
+	return &a.Int
+}
+
+type AccountSpec struct {
+	Name    string  `json:"name" yaml:"name"`
+	Address string  `json:"address" yaml:"address"`
+	Balance *Amount `json:"balance" yaml:"balance"`
+}
+
+type VoteSpec struct {
+	To     string  `json:"to" yaml:"to"`
+	Amount *Amount `json:"amount" yaml:"amount"`
+}
+
+type PRepSpec struct {
+	Name        *string `json:"name" yaml:"name"`
+	Email       *string `json:"email" yaml:"email"`
+	Website     *string `json:"website" yaml:"website"`
+	Country     *string `json:"country" yaml:"country"`
+	City        *string `json:"city" yaml:"city"`
+	Details     *string `json:"details" yaml:"details"`
+	P2PEndpoint *string `json:"p2pEndpoint" yaml:"p2pEndpoint"`
+	Node        string  `json:"node" yaml:"node"`
+}
+
+type ProposalSpec struct {
+	Title       string  `json:"title" yaml:"title"`
+	Description string  `json:"description" yaml:"description"`
+	Type        string  `json:"type" yaml:"type"`
+	Value       *Amount `json:"value" yaml:"value"`
+	ApplyHeight int64   `json:"applyHeight" yaml:"applyHeight"`
+}
+
+// TxSpec is a transaction of a scenario. Type is the name of the method of
+// the chain SCORE which the transaction calls.
+type TxSpec struct {
+	Type        string        `json:"type" yaml:"type"`
+	From        string        `json:"from" yaml:"from"`
+	Amount      *Amount       `json:"amount" yaml:"amount"`
+	Delegations []*VoteSpec   `json:"delegations" yaml:"delegations"`
+	Bonds       []*VoteSpec   `json:"bonds" yaml:"bonds"`
+	Bonders     []string      `json:"bonders" yaml:"bonders"`
+	Address     string        `json:"address" yaml:"address"`
+	Revision    int           `json:"revision" yaml:"revision"`
+	Enable      bool          `json:"enable" yaml:"enable"`
+	PRep        *PRepSpec     `json:"prep" yaml:"prep"`
+	Proposal    *ProposalSpec `json:"proposal" yaml:"proposal"`
+	ID          int64         `json:"id" yaml:"id"`
+	Approve     bool          `json:"approve" yaml:"approve"`
+
+	// Fail means that the transaction is expected to fail.
+	Fail bool `json:"fail" yaml:"fail"`
+}
+
+// Expectation is an assertion on the state of the simulator. One of the
+// targets, Balance, Stake, IScore, PRepStats, TotalSupply, Revision and
+// Proposal is used. Field is the key of the result of GetPRepStats or
+// GetProposal. Status of a proposal is compared as a string.
+type Expectation struct {
+	Balance     string  `json:"balance" yaml:"balance"`
+	Stake       string  `json:"stake" yaml:"stake"`
+	IScore      string  `json:"iscore" yaml:"iscore"`
+	PRepStats   string  `json:"prepStats" yaml:"prepStats"`
+	TotalSupply bool    `json:"totalSupply" yaml:"totalSupply"`
+	Revision    bool    `json:"revision" yaml:"revision"`
+	Proposal    int64   `json:"proposal" yaml:"proposal"`
+	Status      string  `json:"status" yaml:"status"`
+	Field       string  `json:"field" yaml:"field"`
+	Op          string  `json:"op" yaml:"op"`
+	Value       *Amount `json:"value" yaml:"value"`
+}
+
+// Step is a step of a scenario. Block makes a block with the transactions,
+// Go, GoTo and GoToTermEnd make blocks without transactions, and Expect
+// checks the state after them. Missed is the list of validators which
+// don't vote for the blocks made by the step.
+type Step struct {
+	Block       []*TxSpec      `json:"block" yaml:"block"`
+	Go          int64          `json:"go" yaml:"go"`
+	GoTo        int64          `json:"goTo" yaml:"goTo"`
+	GoToTermEnd int            `json:"goToTermEnd" yaml:"goToTermEnd"`
+	Missed      []string       `json:"missed" yaml:"missed"`
+	Expect      []*Expectation `json:"expect" yaml:"expect"`
+}
+
+type Scenario struct {
+	Revision   int            `json:"revision" yaml:"revision"`
+	Config     *config        `json:"config" yaml:"config"`
+	Accounts   []*AccountSpec `json:"accounts" yaml:"accounts"`
+	Validators []string       `json:"validators" yaml:"validators"`
+	Steps      []*Step        `json:"steps" yaml:"steps"`
+}
+
+// ParseScenario parses the scenario in YAML, which is a superset of JSON.
+// Fields missing in config have default values of NewConfig.
+func ParseScenario(bs []byte) (*Scenario, error) {
+	s := &Scenario{Config: NewConfig()}
+	if err := yaml.UnmarshalStrict(bs, s); err != nil {
+		return nil, errors.IllegalArgumentError.Wrap(err, "InvalidScenario")
+	}
+	return s, nil
+}
+
+// LoadScenario loads the scenario from the file in YAML or JSON.
+func LoadScenario(path string) (*Scenario, error) {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		s := &Scenario{Config: NewConfig()}
+		d := json.NewDecoder(strings.NewReader(string(bs)))
+		d.DisallowUnknownFields()
+		if err := d.Decode(s); err != nil {
+			return nil, errors.IllegalArgumentError.Wrap(err, "InvalidScenario")
+		}
+		return s, nil
+	}
+	return ParseScenario(bs)
+}
+
+// ScenarioRunner runs a scenario on a new simulator, and records the state
+// of accounts at the end of each term.
+type ScenarioRunner struct {
+	scenario *Scenario
+	sim      Simulator
+	names    []string
+	addrs    map[string]module.Address
+	records  []*TermRecord
+	lastTerm int
+}
+
+func (r *ScenarioRunner) resolve(name string) (module.Address, error) {
+	if addr, ok := r.addrs[name]; ok {
+		return addr, nil
+	}
+	if name == "treasury" {
+		return treasury, nil
+	}
+	addr, err := common.NewAddressFromString(name)
+	if err != nil {
+		return nil, errors.IllegalArgumentError.Errorf("UnknownAccount(%s)", name)
+	}
+	return addr, nil
+}
+
+func (r *ScenarioRunner) resolveAll(names []string) ([]module.Address, error) {
+	addrs := make([]module.Address, 0, len(names))
+	for _, name := range names {
+		addr, err := r.resolve(name)
+		if err != nil {
+			return nil, err
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs, nil
+}
+
+func addressOfName(name string) module.Address {
+	return common.NewAccountAddress(crypto.SHA3Sum256([]byte(name))[:common.AddressIDBytes])
+}
+
+func NewScenarioRunner(s *Scenario) (*ScenarioRunner, error) {
+	r := &ScenarioRunner{
+		scenario: s,
+		addrs:    make(map[string]module.Address),
+		lastTerm: -1,
+	}
+	balances := make(map[string]*big.Int)
+	for _, a := range s.Accounts {
+		if a.Name == "" {
+			return nil, errors.IllegalArgumentError.New("NoAccountName")
+		}
+		if _, ok := r.addrs[a.Name]; ok {
+			return nil, errors.IllegalArgumentError.Errorf("DuplicateAccount(%s)", a.Name)
+		}
+		var addr module.Address
+		if a.Address != "" {
+			var err error
+			if addr, err = common.NewAddressFromString(a.Address); err != nil {
+				return nil, errors.IllegalArgumentError.Wrapf(err, "InvalidAddress(%s)", a.Address)
+			}
+		} else {
+			addr = addressOfName(a.Name)
+		}
+		r.addrs[a.Name] = addr
+		r.names = append(r.names, a.Name)
+		balances[string(addr.Bytes())] = a.Balance.Value()
+	}
+
+	addrs, err := r.resolveAll(s.Validators)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, errors.IllegalArgumentError.New("NoValidators")
+	}
+	validators := make([]module.Validator, 0, len(addrs))
+	for _, addr := range addrs {
+		v, err := state.ValidatorFromAddress(addr)
+		if err != nil {
+			return nil, err
+		}
+		validators = append(validators, v)
+	}
+
+	revision := s.Revision
+	if revision == 0 {
+		revision = icmodule.LatestRevision
+	}
+	if revision > icmodule.MaxRevision {
+		return nil, errors.IllegalArgumentError.Errorf("InvalidRevision(%d)", revision)
+	}
+	config := s.Config
+	if config == nil {
+		config = NewConfig()
+	}
+	r.sim = NewSimulator(module.Revision(revision), validators, balances, config)
+	if r.sim == nil {
+		return nil, errors.InvalidStateError.New("FailToCreateSimulator")
+	}
+	return r, nil
+}
+
+func (r *ScenarioRunner) Simulator() Simulator {
+	return r.sim
+}
+
+func (r *ScenarioRunner) Records() []*TermRecord {
+	return r.records
+}
+
+func (r *ScenarioRunner) Run() error {
+	for i, step := range r.scenario.Steps {
+		if err := r.runStep(step); err != nil {
+			return errors.Wrapf(err, "step[%d]: %v", i, err)
+		}
+	}
+	return nil
+}
+
+func (r *ScenarioRunner) runStep(step *Step) error {
+	csi, err := r.consensusInfo(step.Missed)
+	if err != nil {
+		return err
+	}
+	if len(step.Block) > 0 {
+		if err := r.runBlock(step.Block, csi); err != nil {
+			return err
+		}
+	}
+	if step.Go > 0 {
+		if err := r.goTo(r.sim.BlockHeight()+step.Go, step.Missed); err != nil {
+			return err
+		}
+	}
+	if step.GoTo > 0 {
+		if err := r.goTo(step.GoTo, step.Missed); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < step.GoToTermEnd; i++ {
+		term := r.sim.TermSnapshot()
+		if term == nil {
+			return errors.InvalidStateError.New("NoTerm")
+		}
+		if err := r.goTo(term.GetEndHeight(), step.Missed); err != nil {
+			return err
+		}
+	}
+	for i, e := range step.Expect {
+		if err := r.check(e); err != nil {
+			return errors.Wrapf(err, "expect[%d]: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// consensusInfo returns the consensus information where the validators
+// in missed didn't vote. It returns nil if all validators voted.
+func (r *ScenarioRunner) consensusInfo(missed []string) (module.ConsensusInfo, error) {
+	if len(missed) == 0 {
+		return nil, nil
+	}
+	addrs, err := r.resolveAll(missed)
+	if err != nil {
+		return nil, err
+	}
+	vl := r.sim.ValidatorList()
+	voted := make([]bool, len(vl))
+	for i, v := range vl {
+		voted[i] = true
+		for _, addr := range addrs {
+			if v.Address().Equal(addr) {
+				voted[i] = false
+			}
+		}
+	}
+	vss, err := state.ValidatorSnapshotFromSlice(r.sim.Database(), vl)
+	if err != nil {
+		return nil, err
+	}
+	proposer, _ := vss.Get(vss.Len() - 1)
+	return common.NewConsensusInfo(proposer.Address(), vss, voted), nil
+}
+
+// goTo makes blocks to the height. Blocks are made term by term to record
+// the state at the end of each term. Blocks are made one by one if some
+// validators miss votes, because the validators may be changed by penalties.
+func (r *ScenarioRunner) goTo(height int64, missed []string) error {
+	for r.sim.BlockHeight() < height {
+		next := height
+		if len(missed) > 0 {
+			next = r.sim.BlockHeight() + 1
+		} else if term := r.sim.TermSnapshot(); term != nil && term.GetEndHeight() < next {
+			if end := term.GetEndHeight(); end > r.sim.BlockHeight() {
+				next = end
+			}
+		}
+		csi, err := r.consensusInfo(missed)
+		if err != nil {
+			return err
+		}
+		if err := r.sim.GoTo(next, csi); err != nil {
+			return err
+		}
+		r.onBlocks()
+	}
+	return nil
+}
+
+func (r *ScenarioRunner) runBlock(txs []*TxSpec, csi module.ConsensusInfo) error {
+	block := NewBlock()
+	for i, spec := range txs {
+		tx, err := r.transaction(spec)
+		if err != nil {
+			return errors.Wrapf(err, "tx[%d]: %v", i, err)
+		}
+		block.AddTransaction(tx)
+	}
+	receipts, err := r.sim.GoByBlock(block, csi)
+	if err != nil {
+		return err
+	}
+	r.onBlocks()
+	for i, rct := range receipts {
+		if txs[i].Fail && rct.Status() == Success {
+			return errors.InvalidStateError.Errorf("tx[%d]: UnexpectedSuccess(type=%s)", i, txs[i].Type)
+		}
+		if !txs[i].Fail && rct.Status() != Success {
+			return errors.Wrapf(rct.Error(), "tx[%d]: UnexpectedFailure(type=%s): %v", i, txs[i].Type, rct.Error())
+		}
+	}
+	return nil
+}
+
+func (r *ScenarioRunner) votes(specs []*VoteSpec) ([]*common.Address, []*big.Int, error) {
+	addrs := make([]*common.Address, 0, len(specs))
+	values := make([]*big.Int, 0, len(specs))
+	for _, v := range specs {
+		addr, err := r.resolve(v.To)
+		if err != nil {
+			return nil, nil, err
+		}
+		addrs = append(addrs, common.AddressToPtr(addr))
+		values = append(values, v.Amount.Value())
+	}
+	return addrs, values, nil
+}
+
+// prepInfo returns P-Rep information in the spec. Missing fields are
+// generated from the account name for registration.
+func (r *ScenarioRunner) prepInfo(spec *PRepSpec, name string, register bool) (*icstate.PRepInfo, error) {
+	info := new(icstate.PRepInfo)
+	if register {
+		city := "Seoul"
+		country := "KOR"
+		email := fmt.Sprintf("%s@email.com", name)
+		website := fmt.Sprintf("https://%s.example.com/", name)
+		details := fmt.Sprintf("%sdetails/", website)
+		endpoint := fmt.Sprintf("%s.example.com:9080", name)
+		info = &icstate.PRepInfo{
+			City:        &city,
+			Country:     &country,
+			Name:        &name,
+			Email:       &email,
+			WebSite:     &website,
+			Details:     &details,
+			P2PEndpoint: &endpoint,
+		}
+	}
+	if spec == nil {
+		return info, nil
+	}
+	for _, f := range []struct {
+		dst **string
+		src *string
+	}{
+		{&info.Name, spec.Name},
+		{&info.Email, spec.Email},
+		{&info.WebSite, spec.Website},
+		{&info.Country, spec.Country},
+		{&info.City, spec.City},
+		{&info.Details, spec.Details},
+		{&info.P2PEndpoint, spec.P2PEndpoint},
+	} {
+		if f.src != nil {
+			*f.dst = f.src
+		}
+	}
+	if spec.Node != "" {
+		node, err := r.resolve(spec.Node)
+		if err != nil {
+			return nil, err
+		}
+		info.Node = node
+	}
+	return info, nil
+}
+
+func (r *ScenarioRunner) transaction(spec *TxSpec) (Transaction, error) {
+	var from module.Address
+	if spec.Type != "setRevision" {
+		var err error
+		if from, err = r.resolve(spec.From); err != nil {
+			return nil, err
+		}
+	}
+	switch spec.Type {
+	case "setStake":
+		return r.sim.SetStake(from, spec.Amount.Value()), nil
+	case "setDelegation":
+		addrs, values, err := r.votes(spec.Delegations)
+		if err != nil {
+			return nil, err
+		}
+		ds := make(icstate.Delegations, 0, len(addrs))
+		for i, addr := range addrs {
+			ds = append(ds, icstate.NewDelegation(addr, values[i]))
+		}
+		return r.sim.SetDelegation(from, ds), nil
+	case "setBond":
+		addrs, values, err := r.votes(spec.Bonds)
+		if err != nil {
+			return nil, err
+		}
+		bonds := make(icstate.Bonds, 0, len(addrs))
+		for i, addr := range addrs {
+			bonds = append(bonds, icstate.NewBond(addr, values[i]))
+		}
+		return r.sim.SetBond(from, bonds), nil
+	case "setBonderList":
+		addrs, err := r.resolveAll(spec.Bonders)
+		if err != nil {
+			return nil, err
+		}
+		bl := make(icstate.BonderList, 0, len(addrs))
+		for _, addr := range addrs {
+			bl = append(bl, common.AddressToPtr(addr))
+		}
+		return r.sim.SetBonderList(from, bl), nil
+	case "registerPRep", "setPRep":
+		register := spec.Type == "registerPRep"
+		info, err := r.prepInfo(spec.PRep, spec.From, register)
+		if err != nil {
+			return nil, err
+		}
+		if register {
+			return r.sim.RegisterPRep(from, info), nil
+		}
+		return r.sim.SetPRep(from, info), nil
+	case "unregisterPRep":
+		return r.sim.UnregisterPRep(from), nil
+	case "disqualifyPRep":
+		addr, err := r.resolve(spec.Address)
+		if err != nil {
+			return nil, err
+		}
+		return r.sim.DisqualifyPRep(from, addr), nil
+	case "setRevision":
+		return r.sim.SetRevision(module.Revision(spec.Revision)), nil
+	case "claimIScore":
+		return r.sim.ClaimIScore(from), nil
+	case "setAutoCompound":
+		return r.sim.SetAutoCompound(from, spec.Enable), nil
+	case "registerProposal":
+		p := spec.Proposal
+		if p == nil {
+			return nil, errors.IllegalArgumentError.New("NoProposal")
+		}
+		return r.sim.RegisterProposal(
+			from, p.Title, p.Description, p.Type, p.Value.Value(), p.ApplyHeight), nil
+	case "voteProposal":
+		return r.sim.VoteProposal(from, spec.ID, spec.Approve), nil
+	case "cancelProposal":
+		return r.sim.CancelProposal(from, spec.ID), nil
+	case "createStakePosition":
+		prep, err := r.resolve(spec.Address)
+		if err != nil {
+			return nil, err
+		}
+		return r.sim.CreateStakePosition(from, prep, spec.Amount.Value()), nil
+	case "transferStakePosition":
+		to, err := r.resolve(spec.Address)
+		if err != nil {
+			return nil, err
+		}
+		return r.sim.TransferStakePosition(from, spec.ID, to), nil
+	case "redeemStakePosition":
+		return r.sim.RedeemStakePosition(from, spec.ID), nil
+	default:
+		return nil, errors.IllegalArgumentError.Errorf("UnknownTxType(%s)", spec.Type)
+	}
+}
+
+func toBigInt(v interface{}) (*big.Int, bool) {
+	switch value := v.(type) {
+	case *big.Int:
+		return value, true
+	case int:
+		return big.NewInt(int64(value)), true
+	case int64:
+		return big.NewInt(value), true
+	case *common.HexInt:
+		return value.Value(), true
+	}
+	return nil, false
+}
+
+func (r *ScenarioRunner) actual(e *Expectation) (string, *big.Int, error) {
+	switch {
+	case e.Balance != "":
+		addr, err := r.resolve(e.Balance)
+		if err != nil {
+			return "", nil, err
+		}
+		return "balance(" + e.Balance + ")", r.sim.GetBalance(addr), nil
+	case e.Stake != "":
+		addr, err := r.resolve(e.Stake)
+		if err != nil {
+			return "", nil, err
+		}
+		stake, _ := toBigInt(r.sim.GetStake(addr)["stake"])
+		return "stake(" + e.Stake + ")", stake, nil
+	case e.IScore != "":
+		addr, err := r.resolve(e.IScore)
+		if err != nil {
+			return "", nil, err
+		}
+		return "iscore(" + e.IScore + ")", r.sim.QueryIScore(addr), nil
+	case e.PRepStats != "":
+		addr, err := r.resolve(e.PRepStats)
+		if err != nil {
+			return "", nil, err
+		}
+		name := fmt.Sprintf("prepStats(%s).%s", e.PRepStats, e.Field)
+		if r.sim.GetPRep(addr) == nil {
+			return "", nil, errors.NotFoundError.Errorf("PRepNotFound(%s)", e.PRepStats)
+		}
+		value, ok := toBigInt(r.sim.GetPRepStats(addr)[e.Field])
+		if !ok {
+			return "", nil, errors.IllegalArgumentError.Errorf("UnknownField(%s)", name)
+		}
+		return name, value, nil
+	case e.TotalSupply:
+		return "totalSupply", r.sim.TotalSupply(), nil
+	case e.Revision:
+		return "revision", big.NewInt(int64(r.sim.Revision().Value())), nil
+	case e.Proposal != 0:
+		name := fmt.Sprintf("proposal(%d).%s", e.Proposal, e.Field)
+		p := r.sim.GetProposal(e.Proposal)
+		if p == nil {
+			return "", nil, errors.NotFoundError.Errorf("ProposalNotFound(%d)", e.Proposal)
+		}
+		value, ok := toBigInt(p[e.Field])
+		if !ok {
+			return "", nil, errors.IllegalArgumentError.Errorf("UnknownField(%s)", name)
+		}
+		return name, value, nil
+	}
+	return "", nil, errors.IllegalArgumentError.New("NoTarget")
+}
+
+func (r *ScenarioRunner) checkProposalStatus(e *Expectation) error {
+	p := r.sim.GetProposal(e.Proposal)
+	if p == nil {
+		return errors.NotFoundError.Errorf("ProposalNotFound(%d)", e.Proposal)
+	}
+	if status := p["status"]; status != e.Status {
+		return errors.InvalidStateError.Errorf(
+			"ExpectationFailed(proposal(%d).status=%s, eq %s)", e.Proposal, status, e.Status)
+	}
+	return nil
+}
+
+func (r *ScenarioRunner) check(e *Expectation) error {
+	if e.Proposal != 0 && e.Status != "" {
+		return r.checkProposalStatus(e)
+	}
+	name, actual, err := r.actual(e)
+	if err != nil {
+		return err
+	}
+	expected := e.Value.Value()
+	cmp := actual.Cmp(expected)
+	var ok bool
+	switch e.Op {
+	case "", "eq":
+		ok = cmp == 0
+	case "ne":
+		ok = cmp != 0
+	case "lt":
+		ok = cmp < 0
+	case "le":
+		ok = cmp <= 0
+	case "gt":
+		ok = cmp > 0
+	case "ge":
+		ok = cmp >= 0
+	default:
+		return errors.IllegalArgumentError.Errorf("UnknownOp(%s)", e.Op)
+	}
+	if !ok {
+		op := e.Op
+		if op == "" {
+			op = "eq"
+		}
+		return errors.InvalidStateError.Errorf(
+			"ExpectationFailed(%s=%s, %s %s)", name, actual, op, expected)
+	}
+	return nil
+}
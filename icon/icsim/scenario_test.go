@@ -0,0 +1,286 @@
+/*
+ * Copyright 2021 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package icsim
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/icon/icmodule"
+	"github.com/icon-project/goloop/icon/iiss/icutils"
+)
+
+const testScenario = `
+revision: 13
+config:
+  termPeriod: 10
+  mainPRepCount: 4
+  subPRepCount: 2
+  validationPenaltyCondition: 3
+accounts:
+  - { name: prep1, balance: 2000icx }
+  - { name: prep2, balance: 2000icx }
+  - { name: prep3, balance: 2000icx }
+  - { name: prep4, balance: 2000icx }
+  - { name: prep5, balance: 2000icx }
+  - { name: user, balance: 10000icx }
+  - { name: node1 }
+  - { name: node2 }
+  - { name: node3 }
+  - { name: node4 }
+validators: [ node1, node2, node3, node4 ]
+steps:
+  - block:
+      - { type: registerPRep, from: prep1 }
+      - { type: registerPRep, from: prep2 }
+      - { type: registerPRep, from: prep3 }
+      - { type: registerPRep, from: prep4 }
+      - { type: registerPRep, from: prep5 }
+      - { type: registerPRep, from: prep5, fail: true }
+  - block:
+      - { type: setStake, from: user, amount: 10000icx }
+  - block:
+      - type: setDelegation
+        from: user
+        delegations:
+          - { to: prep1, amount: 5000icx }
+          - { to: prep2, amount: 2000icx }
+          - { to: prep3, amount: 1500icx }
+          - { to: prep4, amount: 1000icx }
+          - { to: prep5, amount: "0x1b1ae4d6e2ef500000" }
+  - goToTermEnd: 2
+    expect:
+      - { prepStats: prep1, field: grade, value: 0 }
+      - { prepStats: prep5, field: grade, value: 1 }
+      - { stake: user, value: 10000icx }
+      - { balance: prep1, value: 0 }
+  - go: 3
+    missed: [ prep1 ]
+    expect:
+      - { prepStats: prep1, field: grade, value: 2 }
+      - { prepStats: prep1, field: penalties, value: 1 }
+      - { prepStats: prep5, field: grade, value: 0 }
+  - goToTermEnd: 1
+    expect:
+      - { totalSupply: true, value: 10000icx }
+`
+
+func TestScenarioRunner_Run(t *testing.T) {
+	s, err := ParseScenario([]byte(testScenario))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), s.Config.TermPeriod)
+	assert.Equal(t, int64(icmodule.DefaultIglobal), s.Config.Iglobal)
+
+	r, err := NewScenarioRunner(s)
+	assert.NoError(t, err)
+	assert.NoError(t, r.Run())
+
+	records := r.Records()
+	if assert.True(t, len(records) >= 2) {
+		last := records[len(records)-1]
+		assert.Equal(t, r.Simulator().BlockHeight(), last.BlockHeight)
+		assert.Len(t, last.Accounts, len(s.Accounts))
+		assert.Equal(t, "prep1", last.Accounts[0].Name)
+		if assert.NotNil(t, last.Accounts[0].Grade) {
+			assert.Equal(t, 0, *last.Accounts[0].Grade)
+		}
+		assert.Equal(t, icutils.ToLoop(5000), last.Accounts[0].Delegated)
+		assert.Nil(t, last.Accounts[5].Grade)
+		assert.Equal(t, icutils.ToLoop(10000), last.Accounts[5].Stake)
+		for i := 1; i < len(records); i++ {
+			assert.Equal(t, records[i-1].Term+1, records[i].Term)
+		}
+	}
+
+	buf := bytes.NewBuffer(nil)
+	assert.NoError(t, WriteReport(buf, "csv", records))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, 1+len(records)*len(s.Accounts), len(lines))
+	assert.True(t, strings.HasPrefix(lines[0], "term,blockHeight,totalSupply,name"))
+
+	buf.Reset()
+	assert.NoError(t, WriteReport(buf, "json", records))
+	var jso []map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &jso))
+	assert.Len(t, jso, len(records))
+}
+
+func TestScenarioRunner_Failure(t *testing.T) {
+	s, err := ParseScenario([]byte(testScenario + `
+  - expect:
+      - { balance: user, value: 1icx }
+`))
+	assert.NoError(t, err)
+	r, err := NewScenarioRunner(s)
+	assert.NoError(t, err)
+	err = r.Run()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "balance(user)")
+
+	for _, scenario := range []string{
+		"unknown: 1",
+		"validators: [ nobody ]",
+		"accounts: [ { name: a, balance: 1.5 } ]\nvalidators: [ a ]",
+		"accounts: [ { name: a }, { name: a } ]\nvalidators: [ a ]",
+	} {
+		s, err := ParseScenario([]byte(scenario))
+		if err == nil {
+			_, err = NewScenarioRunner(s)
+		}
+		assert.Error(t, err, scenario)
+	}
+}
+
+const testProposalScenario = `
+revision: 17
+config:
+  termPeriod: 10
+  mainPRepCount: 4
+  subPRepCount: 2
+accounts:
+  - { name: prep1, balance: 2000icx }
+  - { name: prep2, balance: 2000icx }
+  - { name: prep3, balance: 2000icx }
+  - { name: prep4, balance: 2000icx }
+  - { name: user, balance: 10000icx }
+  - { name: node1 }
+  - { name: node2 }
+  - { name: node3 }
+  - { name: node4 }
+validators: [ node1, node2, node3, node4 ]
+steps:
+  - block:
+      - { type: registerPRep, from: prep1 }
+      - { type: registerPRep, from: prep2 }
+      - { type: registerPRep, from: prep3 }
+      - { type: registerPRep, from: prep4 }
+      - { type: setBonderList, from: prep1, bonders: [ user ] }
+      - { type: setBonderList, from: prep2, bonders: [ user ] }
+      - { type: setBonderList, from: prep3, bonders: [ user ] }
+      - { type: setBonderList, from: prep4, bonders: [ user ] }
+  - block:
+      - { type: setStake, from: user, amount: 10000icx }
+  - block:
+      - type: setDelegation
+        from: user
+        delegations:
+          - { to: prep1, amount: 2400icx }
+          - { to: prep2, amount: 2400icx }
+          - { to: prep3, amount: 2400icx }
+          - { to: prep4, amount: 2400icx }
+  - block:
+      - type: setBond
+        from: user
+        bonds:
+          - { to: prep1, amount: 100icx }
+          - { to: prep2, amount: 100icx }
+          - { to: prep3, amount: 100icx }
+          - { to: prep4, amount: 100icx }
+  - goToTermEnd: 2
+    expect:
+      - { prepStats: prep1, field: grade, value: 0 }
+  - block:
+      - type: registerProposal
+        from: prep1
+        proposal: { title: irep, type: setIRep, value: 30000icx }
+  - block:
+      - { type: voteProposal, from: prep1, id: 1, approve: true }
+      - { type: voteProposal, from: prep2, id: 1, approve: true }
+      - { type: voteProposal, from: prep3, id: 1, approve: true }
+    expect:
+      - { proposal: 1, status: approved }
+      - { proposal: 1, field: approveWeight, value: 7200icx }
+  - go: 1
+    expect:
+      - { proposal: 1, status: applied }
+      - { revision: true, value: 17 }
+`
+
+func TestScenarioRunner_NetworkProposal(t *testing.T) {
+	s, err := ParseScenario([]byte(testProposalScenario))
+	assert.NoError(t, err)
+	r, err := NewScenarioRunner(s)
+	assert.NoError(t, err)
+	assert.NoError(t, r.Run())
+
+	jso := r.Simulator().GetProposals(0, 0)
+	assert.Equal(t, int64(1), jso["total"])
+	assert.Equal(t, icutils.ToLoop(30000), r.Simulator().GetProposal(1)["value"])
+}
+
+const testStakePositionScenario = `
+revision: 17
+config:
+  termPeriod: 10
+  mainPRepCount: 4
+  subPRepCount: 2
+accounts:
+  - { name: prep1, balance: 2000icx }
+  - { name: prep2, balance: 2000icx }
+  - { name: prep3, balance: 2000icx }
+  - { name: prep4, balance: 2000icx }
+  - { name: user, balance: 10000icx }
+  - { name: holder }
+  - { name: node1 }
+  - { name: node2 }
+  - { name: node3 }
+  - { name: node4 }
+validators: [ node1, node2, node3, node4 ]
+steps:
+  - block:
+      - { type: registerPRep, from: prep1 }
+      - { type: registerPRep, from: prep2 }
+      - { type: registerPRep, from: prep3 }
+      - { type: registerPRep, from: prep4 }
+  - block:
+      - { type: setStake, from: user, amount: 10000icx }
+  - block:
+      - type: setDelegation
+        from: user
+        delegations:
+          - { to: prep1, amount: 4000icx }
+  - block:
+      - { type: createStakePosition, from: user, address: prep1, amount: 1000icx }
+      - { type: createStakePosition, from: user, address: prep2, amount: 1000icx, fail: true }
+  - block:
+      - { type: transferStakePosition, from: user, id: 1, address: holder }
+      - { type: redeemStakePosition, from: user, id: 1, fail: true }
+    expect:
+      - { stake: user, value: 9000icx }
+      - { stake: holder, value: 1000icx }
+  - block:
+      - { type: redeemStakePosition, from: holder, id: 1 }
+    expect:
+      - { stake: holder, value: 0 }
+`
+
+func TestScenarioRunner_StakePosition(t *testing.T) {
+	s, err := ParseScenario([]byte(testStakePositionScenario))
+	assert.NoError(t, err)
+	r, err := NewScenarioRunner(s)
+	assert.NoError(t, err)
+	assert.NoError(t, r.Run())
+
+	holder, err := r.resolve("holder")
+	assert.NoError(t, err)
+	jso := r.Simulator().GetStakePositions(holder)
+	assert.Len(t, jso["positions"], 0)
+}
@@ -0,0 +1,152 @@
+/*
+ * Copyright 2021 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package icsim
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math/big"
+	"strconv"
+
+	"github.com/icon-project/goloop/common/errors"
+)
+
+// AccountRecord is the state of an account at the end of a term.
+// P-Rep fields are nil for the account which is not a P-Rep.
+type AccountRecord struct {
+	Name      string   `json:"name"`
+	Address   string   `json:"address"`
+	Balance   *big.Int `json:"balance"`
+	Stake     *big.Int `json:"stake"`
+	IScore    *big.Int `json:"iscore"`
+	Grade     *int     `json:"grade,omitempty"`
+	Delegated *big.Int `json:"delegated,omitempty"`
+	Bonded    *big.Int `json:"bonded,omitempty"`
+	Penalties *int     `json:"penalties,omitempty"`
+}
+
+// TermRecord is the state at the end of a term.
+type TermRecord struct {
+	Term        int              `json:"term"`
+	BlockHeight int64            `json:"blockHeight"`
+	TotalSupply *big.Int         `json:"totalSupply"`
+	Accounts    []*AccountRecord `json:"accounts"`
+}
+
+// onBlocks records the state if a term is finished by the blocks.
+func (r *ScenarioRunner) onBlocks() {
+	term := r.sim.TermSnapshot()
+	if term == nil {
+		return
+	}
+	if r.lastTerm < 0 {
+		r.lastTerm = term.Sequence()
+		return
+	}
+	if term.Sequence() == r.lastTerm {
+		return
+	}
+	record := &TermRecord{
+		Term:        r.lastTerm,
+		BlockHeight: r.sim.BlockHeight(),
+		TotalSupply: r.sim.TotalSupply(),
+	}
+	for _, name := range r.names {
+		addr := r.addrs[name]
+		ar := &AccountRecord{
+			Name:    name,
+			Address: addr.String(),
+			Balance: r.sim.GetBalance(addr),
+			IScore:  r.sim.QueryIScore(addr),
+		}
+		ar.Stake, _ = toBigInt(r.sim.GetStake(addr)["stake"])
+		if prep := r.sim.GetPRep(addr); prep != nil {
+			grade := int(prep.Grade())
+			penalties := prep.GetVPenaltyCount()
+			ar.Grade = &grade
+			ar.Delegated = prep.Delegated()
+			ar.Bonded = prep.Bonded()
+			ar.Penalties = &penalties
+		}
+		record.Accounts = append(record.Accounts, ar)
+	}
+	r.records = append(r.records, record)
+	r.lastTerm = term.Sequence()
+}
+
+func WriteReportJSON(w io.Writer, records []*TermRecord) error {
+	if records == nil {
+		records = []*TermRecord{}
+	}
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(records)
+}
+
+// WriteReportCSV writes a row for each account of each term.
+func WriteReportCSV(w io.Writer, records []*TermRecord) error {
+	cw := csv.NewWriter(w)
+	header := []string{
+		"term", "blockHeight", "totalSupply",
+		"name", "address", "balance", "stake", "iscore",
+		"grade", "delegated", "bonded", "penalties",
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	str := func(v *big.Int) string {
+		if v == nil {
+			return ""
+		}
+		return v.String()
+	}
+	itoa := func(v *int) string {
+		if v == nil {
+			return ""
+		}
+		return strconv.Itoa(*v)
+	}
+	for _, tr := range records {
+		for _, ar := range tr.Accounts {
+			if err := cw.Write([]string{
+				strconv.Itoa(tr.Term),
+				strconv.FormatInt(tr.BlockHeight, 10),
+				str(tr.TotalSupply),
+				ar.Name, ar.Address,
+				str(ar.Balance), str(ar.Stake), str(ar.IScore),
+				itoa(ar.Grade), str(ar.Delegated), str(ar.Bonded), itoa(ar.Penalties),
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteReport writes records in the format, "json" or "csv".
+func WriteReport(w io.Writer, format string, records []*TermRecord) error {
+	switch format {
+	case "", "json":
+		return WriteReportJSON(w, records)
+	case "csv":
+		return WriteReportCSV(w, records)
+	default:
+		return errors.IllegalArgumentError.Errorf("UnknownFormat(%s)", format)
+	}
+}
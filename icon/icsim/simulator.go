@@ -36,6 +36,13 @@ const (
 	TypeSetPRep
 	TypeSetRevision
 	TypeClaimIScore
+	TypeSetAutoCompound
+	TypeRegisterProposal
+	TypeVoteProposal
+	TypeCancelProposal
+	TypeCreateStakePosition
+	TypeTransferStakePosition
+	TypeRedeemStakePosition
 )
 
 type Transaction interface {
@@ -94,7 +101,26 @@ type Simulator interface {
 	QueryIScore(address module.Address) *big.Int
 	ClaimIScore(from module.Address) Transaction
 
+	GetAutoCompound(address module.Address) map[string]interface{}
+	SetAutoCompound(from module.Address, enable bool) Transaction
+
+	GetProposal(id int64) map[string]interface{}
+	GetProposals(start, size int64) map[string]interface{}
+	RegisterProposal(
+		from module.Address, title, description, typ string, value *big.Int, applyHeight int64) Transaction
+	VoteProposal(from module.Address, id int64, approve bool) Transaction
+	CancelProposal(from module.Address, id int64) Transaction
+
+	GetStakePosition(id int64) map[string]interface{}
+	GetStakePositions(address module.Address) map[string]interface{}
+	CreateStakePosition(from module.Address, prep module.Address, amount *big.Int) Transaction
+	TransferStakePosition(from module.Address, id int64, to module.Address) Transaction
+	RedeemStakePosition(from module.Address, id int64) Transaction
+
 	GetPRepStats(address module.Address) map[string]interface{}
+	GetPenaltyHistory(address module.Address, start, size int) map[string]interface{}
+	GetSlashingPreview(address module.Address) map[string]interface{}
+	GetUnlockSchedule(startHeight, endHeight int64, detail bool, start, size int) map[string]interface{}
 	GetPRep(address module.Address) *icstate.PRep
 	SetPRep(from module.Address, info *icstate.PRepInfo) Transaction
 
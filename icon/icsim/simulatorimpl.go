@@ -232,6 +232,9 @@ func (sim *simulatorImpl) Go(blocks int64, csi module.ConsensusInfo) error {
 		if err = sim.onExecutionBegin(wc); err != nil {
 			return err
 		}
+		if err = sim.handleNetworkProposals(wc); err != nil {
+			return err
+		}
 		if err = sim.onBaseTx(wc); err != nil {
 			return err
 		}
@@ -351,6 +354,20 @@ func (sim *simulatorImpl) executeTx(csi module.ConsensusInfo, ws state.WorldStat
 		err = sim.setRevision(wc, tx)
 	case TypeClaimIScore:
 		err = sim.claimIScore(es, wc, tx)
+	case TypeSetAutoCompound:
+		err = sim.setAutoCompound(es, wc, tx)
+	case TypeRegisterProposal:
+		err = sim.registerProposal(es, wc, tx)
+	case TypeVoteProposal:
+		err = sim.voteProposal(es, wc, tx)
+	case TypeCancelProposal:
+		err = sim.cancelProposal(es, wc, tx)
+	case TypeCreateStakePosition:
+		err = sim.createStakePosition(es, wc, tx)
+	case TypeTransferStakePosition:
+		err = sim.transferStakePosition(es, wc, tx)
+	case TypeRedeemStakePosition:
+		err = sim.redeemStakePosition(es, wc, tx)
 	default:
 		return errors.Errorf("Unexpected transaction: %v", tx.Type())
 	}
@@ -470,6 +487,153 @@ func (sim *simulatorImpl) claimIScore(es *iiss.ExtensionStateImpl, wc WorldConte
 	return es.ClaimIScore(cc)
 }
 
+func (sim *simulatorImpl) GetAutoCompound(address module.Address) map[string]interface{} {
+	es := sim.getExtensionState(true)
+	ia := es.State.GetAccountSnapshot(address)
+	if ia == nil {
+		ia = icstate.GetEmptyAccountSnapshot()
+	}
+	return ia.GetAutoCompoundInJSON()
+}
+
+func (sim *simulatorImpl) SetAutoCompound(from module.Address, enable bool) Transaction {
+	return NewTransaction(TypeSetAutoCompound, []interface{}{from, enable})
+}
+
+func (sim *simulatorImpl) setAutoCompound(es *iiss.ExtensionStateImpl, wc WorldContext, tx Transaction) error {
+	args := tx.Args()
+	from := args[0].(module.Address)
+	enable := args[1].(bool)
+	cc := NewCallContext(wc, from)
+	return es.SetAutoCompound(cc, enable)
+}
+
+func (sim *simulatorImpl) GetProposal(id int64) map[string]interface{} {
+	es := sim.getExtensionState(true)
+	jso, _ := es.GetNetworkProposalInJSON(id)
+	return jso
+}
+
+func (sim *simulatorImpl) GetProposals(start, size int64) map[string]interface{} {
+	es := sim.getExtensionState(true)
+	jso, _ := es.GetNetworkProposalsInJSON(start, size)
+	return jso
+}
+
+func (sim *simulatorImpl) RegisterProposal(
+	from module.Address, title, description, typ string, value *big.Int, applyHeight int64) Transaction {
+	return NewTransaction(TypeRegisterProposal, []interface{}{from, title, description, typ, value, applyHeight})
+}
+
+func (sim *simulatorImpl) registerProposal(es *iiss.ExtensionStateImpl, wc WorldContext, tx Transaction) error {
+	args := tx.Args()
+	from := args[0].(module.Address)
+	cc := NewCallContext(wc, from)
+	_, err := es.RegisterNetworkProposal(
+		cc, args[1].(string), args[2].(string), args[3].(string), args[4].(*big.Int), args[5].(int64))
+	return err
+}
+
+func (sim *simulatorImpl) VoteProposal(from module.Address, id int64, approve bool) Transaction {
+	return NewTransaction(TypeVoteProposal, []interface{}{from, id, approve})
+}
+
+func (sim *simulatorImpl) voteProposal(es *iiss.ExtensionStateImpl, wc WorldContext, tx Transaction) error {
+	args := tx.Args()
+	from := args[0].(module.Address)
+	cc := NewCallContext(wc, from)
+	return es.VoteNetworkProposal(cc, args[1].(int64), args[2].(bool))
+}
+
+func (sim *simulatorImpl) CancelProposal(from module.Address, id int64) Transaction {
+	return NewTransaction(TypeCancelProposal, []interface{}{from, id})
+}
+
+func (sim *simulatorImpl) cancelProposal(es *iiss.ExtensionStateImpl, wc WorldContext, tx Transaction) error {
+	args := tx.Args()
+	from := args[0].(module.Address)
+	cc := NewCallContext(wc, from)
+	return es.CancelNetworkProposal(cc, args[1].(int64))
+}
+
+func (sim *simulatorImpl) GetStakePosition(id int64) map[string]interface{} {
+	es := sim.getExtensionState(true)
+	jso, _ := es.GetStakePositionInJSON(id)
+	return jso
+}
+
+func (sim *simulatorImpl) GetStakePositions(address module.Address) map[string]interface{} {
+	es := sim.getExtensionState(true)
+	jso, _ := es.GetStakePositionsInJSON(address)
+	return jso
+}
+
+func (sim *simulatorImpl) CreateStakePosition(from module.Address, prep module.Address, amount *big.Int) Transaction {
+	return NewTransaction(TypeCreateStakePosition, []interface{}{from, prep, amount})
+}
+
+func (sim *simulatorImpl) createStakePosition(es *iiss.ExtensionStateImpl, wc WorldContext, tx Transaction) error {
+	args := tx.Args()
+	from := args[0].(module.Address)
+	cc := NewCallContext(wc, from)
+	_, err := es.CreateStakePosition(cc, args[1].(module.Address), args[2].(*big.Int))
+	return err
+}
+
+func (sim *simulatorImpl) TransferStakePosition(from module.Address, id int64, to module.Address) Transaction {
+	return NewTransaction(TypeTransferStakePosition, []interface{}{from, id, to})
+}
+
+func (sim *simulatorImpl) transferStakePosition(es *iiss.ExtensionStateImpl, wc WorldContext, tx Transaction) error {
+	args := tx.Args()
+	from := args[0].(module.Address)
+	cc := NewCallContext(wc, from)
+	return es.TransferStakePosition(cc, args[1].(int64), args[2].(module.Address))
+}
+
+func (sim *simulatorImpl) RedeemStakePosition(from module.Address, id int64) Transaction {
+	return NewTransaction(TypeRedeemStakePosition, []interface{}{from, id})
+}
+
+func (sim *simulatorImpl) redeemStakePosition(es *iiss.ExtensionStateImpl, wc WorldContext, tx Transaction) error {
+	args := tx.Args()
+	from := args[0].(module.Address)
+	cc := NewCallContext(wc, from)
+	return es.RedeemStakePosition(cc, args[1].(int64))
+}
+
+func (sim *simulatorImpl) handleNetworkProposals(wc WorldContext) error {
+	if wc.Revision().Value() < icmodule.RevisionNetworkProposal {
+		return nil
+	}
+	es := wc.GetExtensionState().(*iiss.ExtensionStateImpl)
+	return es.HandleNetworkProposals(wc.BlockHeight(), func(p *icstate.NetworkProposal) error {
+		return sim.applyNetworkProposal(wc, es, p)
+	})
+}
+
+// applyNetworkProposal changes the value of the simulator for the approved
+// network proposal as the chain SCORE does.
+func (sim *simulatorImpl) applyNetworkProposal(
+	wc WorldContext, es *iiss.ExtensionStateImpl, p *icstate.NetworkProposal) error {
+	value := p.Value()
+	switch p.Type() {
+	case iiss.NetworkProposalSetRevision:
+		return sim.setRevision(wc, sim.SetRevision(icmodule.ValueToRevision(int(value.Int64()))))
+	case iiss.NetworkProposalSetStepPrice:
+		sim.stepPrice = new(big.Int).Set(value)
+		return nil
+	case iiss.NetworkProposalSetRewardFund:
+		rf := es.State.GetRewardFund()
+		rf.Iglobal = new(big.Int).Set(value)
+		return es.State.SetRewardFund(rf)
+	case iiss.NetworkProposalSetIRep:
+		return es.State.SetIRep(new(big.Int).Set(value))
+	default:
+		return errors.Errorf("InvalidProposalType(%s)", p.Type())
+	}
+}
+
 func (sim *simulatorImpl) QueryIScore(address module.Address) *big.Int {
 	es := sim.getExtensionState(true)
 	iscore, _ := es.GetIScore(address, sim.revision.Value(), nil)
@@ -511,6 +675,25 @@ func (sim *simulatorImpl) GetPRepStats(address module.Address) map[string]interf
 	return ps.GetStatsInJSON(sim.BlockHeight())
 }
 
+func (sim *simulatorImpl) GetPenaltyHistory(address module.Address, start, size int) map[string]interface{} {
+	es := sim.getExtensionState(true)
+	jso, _ := es.GetPenaltyHistoryInJSON(address, start, size)
+	return jso
+}
+
+func (sim *simulatorImpl) GetSlashingPreview(address module.Address) map[string]interface{} {
+	es := sim.getExtensionState(true)
+	jso, _ := es.GetSlashingPreview(address)
+	return jso
+}
+
+func (sim *simulatorImpl) GetUnlockSchedule(
+	startHeight, endHeight int64, detail bool, start, size int) map[string]interface{} {
+	es := sim.getExtensionState(true)
+	jso, _ := es.GetUnlockScheduleInJSON(sim.BlockHeight(), startHeight, endHeight, detail, start, size)
+	return jso
+}
+
 func (sim *simulatorImpl) TermSnapshot() *icstate.TermSnapshot {
 	es := sim.getExtensionState(true)
 	return es.State.GetTermSnapshot()
@@ -0,0 +1,101 @@
+/*
+ * Copyright 2021 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package icsim
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/icon/icmodule"
+	"github.com/icon-project/goloop/icon/iiss/icstate"
+	"github.com/icon-project/goloop/icon/iiss/icutils"
+	"github.com/icon-project/goloop/module"
+)
+
+func TestSimulator_StakePosition(t *testing.T) {
+	c := NewConfig()
+	c.MainPRepCount = 22
+	c.TermPeriod = 100
+
+	env := initEnv(t, c, icmodule.Revision13)
+	sim := env.sim
+	prep := env.preps[0]
+	user0 := env.users[0]
+	user1 := env.users[1]
+
+	// the env makes user0 delegate 10000 ICX to prep
+	assert.NoError(t, sim.GoToTermEnd(nil))
+	goByTransaction(t, sim, sim.SetRevision(icmodule.RevisionStakePosition), true)
+
+	stake := func(address module.Address) *big.Int {
+		return sim.GetStake(address)["stake"].(*big.Int)
+	}
+	delegation := func(address module.Address) *big.Int {
+		for _, d := range sim.GetDelegation(address)["delegations"].([]interface{}) {
+			jso := d.(map[string]interface{})
+			if jso["address"].(module.Address).Equal(prep) {
+				return jso["value"].(*common.HexInt).Value()
+			}
+		}
+		return new(big.Int)
+	}
+	stake0 := stake(user0)
+	stake1 := stake(user1)
+	delegated := sim.GetPRep(prep).Delegated()
+
+	// positions can't exceed the delegation to the P-Rep
+	goByTransaction(t, sim, sim.CreateStakePosition(user0, prep, icutils.ToLoop(20000)), false)
+	goByTransaction(t, sim, sim.CreateStakePosition(user0, prep, icutils.ToLoop(4000)), true)
+	goByTransaction(t, sim, sim.CreateStakePosition(user0, prep, icutils.ToLoop(6000)), true)
+	goByTransaction(t, sim, sim.CreateStakePosition(user0, prep, big.NewInt(1)), false)
+	jso := sim.GetStakePositions(user0)
+	assert.Len(t, jso["positions"], 2)
+	assert.Zero(t, icutils.ToLoop(10000).Cmp(jso["total"].(*big.Int)))
+
+	// the delegation of positions is locked
+	goByTransaction(t, sim, sim.SetDelegation(user0, icstate.Delegations{
+		icstate.NewDelegation(common.AddressToPtr(prep), icutils.ToLoop(5000)),
+	}), false)
+
+	// only the holder can transfer it to an EOA
+	goByTransaction(t, sim, sim.TransferStakePosition(user1, 1, user1), false)
+	goByTransaction(t, sim, sim.TransferStakePosition(user0, 1, user0), false)
+	goByTransaction(t, sim, sim.TransferStakePosition(user0, 1, common.MustNewAddressFromString("cx1")), false)
+	goByTransaction(t, sim, sim.TransferStakePosition(user0, 1, user1), true)
+
+	assert.True(t, user1.Equal(sim.GetStakePosition(1)["holder"].(module.Address)))
+	assert.Zero(t, new(big.Int).Sub(stake0, icutils.ToLoop(4000)).Cmp(stake(user0)))
+	assert.Zero(t, new(big.Int).Add(stake1, icutils.ToLoop(4000)).Cmp(stake(user1)))
+	assert.Zero(t, icutils.ToLoop(6000).Cmp(delegation(user0)))
+	assert.Zero(t, icutils.ToLoop(4000).Cmp(delegation(user1)))
+	assert.Zero(t, delegated.Cmp(sim.GetPRep(prep).Delegated()))
+
+	// redemption goes through the unstake lock
+	goByTransaction(t, sim, sim.RedeemStakePosition(user0, 1), false)
+	goByTransaction(t, sim, sim.RedeemStakePosition(user1, 1), true)
+	assert.Nil(t, sim.GetStakePosition(1))
+	assert.Len(t, sim.GetStakePositions(user1)["positions"], 0)
+	assert.Zero(t, stake1.Cmp(stake(user1)))
+	assert.Zero(t, delegation(user1).Sign())
+	assert.Zero(t, new(big.Int).Sub(delegated, icutils.ToLoop(4000)).Cmp(sim.GetPRep(prep).Delegated()))
+	unstakes := sim.GetStake(user1)["unstakes"].([]interface{})
+	assert.Len(t, unstakes, 1)
+	assert.Zero(t, icutils.ToLoop(4000).Cmp(unstakes[0].(map[string]interface{})["unstake"].(*big.Int)))
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright 2021 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package icsim
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/icon/icmodule"
+	"github.com/icon-project/goloop/icon/iiss"
+	"github.com/icon-project/goloop/icon/iiss/icstate"
+	"github.com/icon-project/goloop/icon/iiss/icutils"
+	"github.com/icon-project/goloop/module"
+)
+
+// addUnstake moves the amount of stake of the account to its unstakes
+// directly, because the simulator doesn't make unstakes on setStake.
+func addUnstake(t *testing.T, sim *simulatorImpl, address module.Address, amount *big.Int, expire int64) {
+	ws := newWorldState(sim.wss, false)
+	es := ws.GetExtensionState().(*iiss.ExtensionStateImpl)
+	as := es.State.GetAccountState(address)
+	tl, err := as.IncreaseUnstake(amount, expire, int(es.State.GetUnstakeSlotMax()), sim.Revision().Value())
+	assert.NoError(t, err)
+	for _, job := range tl {
+		icstate.ScheduleTimerJob(es.State.GetUnstakingTimerState(job.Height), job, address)
+	}
+	assert.NoError(t, as.SetStake(new(big.Int).Sub(as.Stake(), amount)))
+
+	sim.wss = ws.GetSnapshot()
+	assert.NoError(t, sim.wss.Flush())
+}
+
+func TestSimulator_UnlockSchedule(t *testing.T) {
+	c := NewConfig()
+	c.MainPRepCount = 22
+	c.TermPeriod = 100
+
+	env := initEnv(t, c, icmodule.Revision13)
+	sim := env.sim
+	users := env.users[:2]
+	bonder := env.bonders[1]
+
+	jso := sim.GetUnlockSchedule(0, 0, false, 0, 0)
+	assert.Equal(t, sim.BlockHeight()+1, jso["startHeight"])
+	assert.Zero(t, jso["total"].(*big.Int).Sign())
+	assert.Len(t, jso["heights"], 0)
+	assert.Nil(t, jso["accounts"])
+
+	// unstake 4000 and 3000 ICX in this term, and unbond 100 ICX
+	assert.NoError(t, sim.GoToTermEnd(nil))
+	expire := sim.BlockHeight() + 10
+	addUnstake(t, sim.(*simulatorImpl), users[0], icutils.ToLoop(4000), expire)
+	addUnstake(t, sim.(*simulatorImpl), users[1], icutils.ToLoop(3000), expire+10)
+
+	block := NewBlock()
+	bond := sim.GetBond(bonder)["bonds"].([]interface{})[0].(map[string]interface{})["value"].(*common.HexInt).Value()
+	block.AddTransaction(sim.SetBond(bonder, icstate.Bonds{
+		icstate.NewBond(common.AddressToPtr(env.preps[1]), new(big.Int).Sub(bond, icutils.ToLoop(100))),
+	}))
+	receipts, err := sim.GoByBlock(block, nil)
+	assert.NoError(t, err)
+	assert.True(t, checkReceipts(receipts))
+
+	endHeight := sim.BlockHeight()
+	for _, user := range users {
+		unstakes := sim.GetStake(user)["unstakes"].([]interface{})
+		if assert.Len(t, unstakes, 1) {
+			h := unstakes[0].(map[string]interface{})["unstakeBlockHeight"].(int64)
+			if h > endHeight {
+				endHeight = h
+			}
+		}
+	}
+	unbonds := sim.GetBond(bonder)["unbonds"].([]interface{})
+	if assert.Len(t, unbonds, 1) {
+		h := unbonds[0].(map[string]interface{})["expireBlockHeight"].(int64)
+		if h > endHeight {
+			endHeight = h
+		}
+	}
+
+	jso = sim.GetUnlockSchedule(0, endHeight, true, 0, 0)
+	assert.Equal(t, icutils.ToLoop(7000), jso["unstake"])
+	assert.Equal(t, icutils.ToLoop(100), jso["unbond"])
+	assert.Equal(t, icutils.ToLoop(7100), jso["total"])
+	assert.Equal(t, 3, jso["totalAccounts"])
+	accounts := jso["accounts"].([]interface{})
+	assert.Len(t, accounts, 3)
+	heights := jso["heights"].([]interface{})
+	count := 0
+	for _, h := range heights {
+		count += h.(map[string]interface{})["accounts"].(int)
+	}
+	assert.Equal(t, 3, count)
+
+	// accounts unlocked at the height
+	first := accounts[0].(map[string]interface{})
+	height := first["height"].(int64)
+	jso = sim.GetUnlockSchedule(height, height, true, 0, 0)
+	assert.Len(t, jso["heights"], 1)
+	assert.Equal(t, first, jso["accounts"].([]interface{})[0])
+
+	// pagination
+	jso = sim.GetUnlockSchedule(0, endHeight, true, 2, 1)
+	assert.Equal(t, accounts[2], jso["accounts"].([]interface{})[0])
+
+	// unlocked ones are removed from the schedule
+	assert.NoError(t, sim.GoTo(expire+10, nil))
+	jso = sim.GetUnlockSchedule(0, endHeight, false, 0, 0)
+	assert.Zero(t, jso["unstake"].(*big.Int).Sign())
+	assert.Equal(t, icutils.ToLoop(100), jso["total"])
+
+	assert.Nil(t, sim.GetUnlockSchedule(10, 9, false, 0, 0))
+	assert.NotNil(t, sim.GetUnlockSchedule(1, iiss.UnlockScheduleMaxBlocks, false, 0, 0))
+	assert.Nil(t, sim.GetUnlockSchedule(1, 1+iiss.UnlockScheduleMaxBlocks, false, 0, 0))
+	assert.Nil(t, sim.GetUnlockSchedule(0, 0, true, 0, 101))
+}
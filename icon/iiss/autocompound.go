@@ -0,0 +1,139 @@
+/*
+ * Copyright 2021 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package iiss
+
+import (
+	"math/big"
+
+	"github.com/icon-project/goloop/common/intconv"
+	"github.com/icon-project/goloop/icon/icmodule"
+	"github.com/icon-project/goloop/icon/iiss/icstate"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/scoreresult"
+	"github.com/icon-project/goloop/service/state"
+)
+
+func (es *ExtensionStateImpl) SetAutoCompound(cc icmodule.CallContext, enable bool) error {
+	from := cc.From()
+	account := es.State.GetAccountState(from)
+	if account.AutoCompound() != enable {
+		account.SetAutoCompound(enable)
+	}
+	AutoCompoundSetEventLog(cc, from, enable)
+	return nil
+}
+
+// compoundIScore stakes claimed ICX again and delegates it to the P-Reps
+// which the account delegates to, in proportion to the current delegations.
+// It doesn't touch unstaking of the account. If it fails, then the claimed
+// ICX is kept in the balance without failing the claim.
+func (es *ExtensionStateImpl) compoundIScore(cc icmodule.CallContext, icx *big.Int) error {
+	from := cc.From()
+	account := es.State.GetAccountState(from)
+	if !account.AutoCompound() || icx.Sign() == 0 {
+		return nil
+	}
+
+	snapshot := es.GetSnapshot()
+	delegated, err := es.stakeAndDelegate(cc, icx)
+	if err != nil {
+		es.Reset(snapshot)
+		es.Logger().Debugf("Failed to compound IScore: from=%v icx=%v err=%v", from, icx, err)
+		return nil
+	}
+	if err = cc.Withdraw(from, icx); err != nil {
+		es.Reset(snapshot)
+		return err
+	}
+	AutoCompoundedEventLog(cc, from, icx, delegated)
+	return nil
+}
+
+// stakeAndDelegate increases the stake of the account by the amount, and
+// distributes it to the current delegations. It returns the amount
+// delegated. The balance of the account is not changed.
+func (es *ExtensionStateImpl) stakeAndDelegate(cc icmodule.CallContext, amount *big.Int) (*big.Int, error) {
+	from := cc.From()
+	account := es.State.GetAccountState(from)
+	if err := account.SetStake(new(big.Int).Add(account.Stake(), amount)); err != nil {
+		return nil, scoreresult.InvalidParameterError.Wrapf(err, "Failed to set stake: from=%v", from)
+	}
+	totalStake := new(big.Int).Add(es.State.GetTotalStake(), amount)
+	if err := es.State.SetTotalStake(totalStake); err != nil {
+		return nil, scoreresult.UnknownFailureError.Wrapf(err, "Failed to set totalStake: from=%v", from)
+	}
+
+	ds := account.Delegations()
+	if !ds.Has() {
+		return new(big.Int), nil
+	}
+	if len(ds) > es.State.GetDelegationSlotMax() {
+		return nil, scoreresult.InvalidParameterError.Errorf(
+			"TooManyDelegations(count=%d,max=%d)", len(ds), es.State.GetDelegationSlotMax())
+	}
+	if err := es.SetDelegation(cc, distributeDelegation(ds, amount)); err != nil {
+		return nil, err
+	}
+	return amount, nil
+}
+
+// distributeDelegation returns delegations increased by the amount in
+// proportion to each delegation. The remainder is added to the first one.
+func distributeDelegation(ds icstate.Delegations, amount *big.Int) icstate.Delegations {
+	total := ds.GetDelegationAmount()
+	nds := make(icstate.Delegations, 0, len(ds))
+	remains := new(big.Int).Set(amount)
+	for _, d := range ds {
+		share := new(big.Int).Mul(amount, d.Amount())
+		share.Div(share, total)
+		remains.Sub(remains, share)
+		nds = append(nds, icstate.NewDelegation(d.Address, new(big.Int).Add(d.Amount(), share)))
+	}
+	if remains.Sign() > 0 {
+		nds[0] = icstate.NewDelegation(nds[0].Address, new(big.Int).Add(nds[0].Amount(), remains))
+	}
+	return nds
+}
+
+func AutoCompoundSetEventLog(cc icmodule.CallContext, address module.Address, enable bool) {
+	value := []byte{0}
+	if enable {
+		value = []byte{1}
+	}
+	cc.OnEvent(state.SystemAddress,
+		[][]byte{
+			[]byte("AutoCompoundSet(Address,bool)"),
+			address.Bytes(),
+		},
+		[][]byte{
+			value,
+		},
+	)
+}
+
+func AutoCompoundedEventLog(cc icmodule.CallContext, address module.Address, staked *big.Int, delegated *big.Int) {
+	cc.OnEvent(state.SystemAddress,
+		[][]byte{
+			[]byte("AutoCompounded(Address,int,int)"),
+			address.Bytes(),
+		},
+		[][]byte{
+			intconv.BigIntToBytes(staked),
+			intconv.BigIntToBytes(delegated),
+		},
+	)
+}
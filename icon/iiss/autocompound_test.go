@@ -0,0 +1,51 @@
+/*
+ * Copyright 2021 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package iiss
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/icon/iiss/icstate"
+)
+
+func Test_distributeDelegation(t *testing.T) {
+	addr1 := common.MustNewAddressFromString("hx1")
+	addr2 := common.MustNewAddressFromString("hx2")
+	addr3 := common.MustNewAddressFromString("hx3")
+	ds := icstate.Delegations{
+		icstate.NewDelegation(addr1, big.NewInt(100)),
+		icstate.NewDelegation(addr2, big.NewInt(200)),
+		icstate.NewDelegation(addr3, big.NewInt(300)),
+	}
+
+	nds := distributeDelegation(ds, big.NewInt(61))
+	assert.Len(t, nds, 3)
+	assert.Equal(t, int64(111), nds[0].Amount().Int64())
+	assert.Equal(t, int64(220), nds[1].Amount().Int64())
+	assert.Equal(t, int64(330), nds[2].Amount().Int64())
+	assert.Equal(t, int64(661), nds.GetDelegationAmount().Int64())
+
+	// the original ones are not changed
+	assert.Equal(t, int64(600), ds.GetDelegationAmount().Int64())
+	for i, d := range nds {
+		assert.True(t, d.To().Equal(ds[i].To()))
+	}
+}
@@ -75,6 +75,11 @@ type Calculator struct {
 	global      icstage.Global
 	temp        *icreward.State
 	stats       *statistics
+	history     *rewardHistory
+
+	checkpoint         *checkpoint
+	checkpointInterval int
+	resumed            bool
 
 	lock        sync.Mutex
 	waiters     []*sync.Cond
@@ -162,7 +167,18 @@ func (c *Calculator) Stop() {
 	}
 }
 
-func UpdateCalculator(c *Calculator, ess state.ExtensionSnapshot, logger log.Logger) *Calculator {
+// CalculatorConfig is the configuration of the calculator for the node.
+// It doesn't change results of the calculation.
+type CalculatorConfig struct {
+	// RewardHistory enables records of rewards for each account and term.
+	RewardHistory bool `json:"rewardHistory,omitempty"`
+
+	// CheckpointInterval is the number of voting entries processed between
+	// checkpoints. DefaultCheckpointInterval is used if it's not positive.
+	CheckpointInterval int `json:"checkpointInterval,omitempty"`
+}
+
+func UpdateCalculator(c *Calculator, ess state.ExtensionSnapshot, cfg *CalculatorConfig, logger log.Logger) *Calculator {
 	essi := ess.(*ExtensionSnapshotImpl)
 	back := essi.Back2()
 	reward := essi.Reward()
@@ -174,7 +190,7 @@ func UpdateCalculator(c *Calculator, ess state.ExtensionSnapshot, logger log.Log
 		}
 		c.Stop()
 	}
-	return NewCalculator(essi.database, back, reward, logger)
+	return NewCalculator(essi.database, back, reward, cfg, logger)
 }
 
 func (c *Calculator) run() (err error) {
@@ -184,28 +200,52 @@ func (c *Calculator) run() (err error) {
 		}
 	}()
 
+	// phases done before the checkpoint are skipped
+	phase := c.checkpoint.Phase
 	startTS := time.Now()
-	if err = c.prepare(); err != nil {
-		err = icmodule.CalculationFailedError.Wrapf(err, "Failed to prepare calculator")
-		return
+	if phase < phaseClaim {
+		if err = c.prepare(); err != nil {
+			err = icmodule.CalculationFailedError.Wrapf(err, "Failed to prepare calculator")
+			return
+		}
+		if err = c.saveCheckpoint(phaseClaim, 0, 0); err != nil {
+			return
+		}
 	}
 	prepareTS := time.Now()
 
-	if err = c.calculateBlockProduce(); err != nil {
-		err = icmodule.CalculationFailedError.Wrapf(err, "Failed to calculate block produce reward")
-		return
+	if phase < phaseBlockProduce {
+		if err = c.calculateBlockProduce(); err != nil {
+			err = icmodule.CalculationFailedError.Wrapf(err, "Failed to calculate block produce reward")
+			return
+		}
+		if err = c.saveCheckpoint(phaseBlockProduce, 0, 0); err != nil {
+			return
+		}
 	}
 	bpTS := time.Now()
 
-	if err = c.calculateVotedReward(); err != nil {
-		err = icmodule.CalculationFailedError.Wrapf(err, "Failed to calculate P-Rep voted reward")
-		return
+	if phase < phaseVoted {
+		if err = c.calculateVotedReward(); err != nil {
+			err = icmodule.CalculationFailedError.Wrapf(err, "Failed to calculate P-Rep voted reward")
+			return
+		}
+		if err = c.saveCheckpoint(phaseVoted, 0, 0); err != nil {
+			return
+		}
 	}
 	votedTS := time.Now()
 
-	if err = c.calculateVotingReward(); err != nil {
-		err = icmodule.CalculationFailedError.Wrapf(err, "Failed to calculate ICONist voting reward")
-		return
+	if phase < phaseVoting {
+		if err = c.calculateVotingReward(); err != nil {
+			if err != errors.ErrInterrupted {
+				err = icmodule.CalculationFailedError.Wrapf(err, "Failed to calculate ICONist voting reward")
+			}
+			return
+		}
+		if err = c.saveCheckpoint(phaseVoting, 0, 0); err != nil {
+			return
+		}
 	}
 	votingTS := time.Now()
 
@@ -222,6 +262,14 @@ func (c *Calculator) run() (err error) {
 	c.log.Infof("Calculation statistics: Total=%d BlockProduce=%s Voted=%s Voting=%s",
 		c.stats.TotalReward(), c.stats.BlockProduce(), c.stats.Voted(), c.stats.Voting())
 
+	if c.history != nil {
+		// history is an optional data for queries, so it doesn't make
+		// the calculation fail.
+		if err := c.history.flush(c.database); err != nil {
+			c.log.Warnf("Failed to write reward history. %+v", err)
+		}
+	}
+
 	c.setResult(c.temp.GetSnapshot(), nil)
 	return nil
 }
@@ -326,6 +374,9 @@ func (c *Calculator) updateIScore(addr module.Address, reward *big.Int, t Reward
 	case TypeVoting:
 		c.stats.IncreaseVoting(reward)
 	}
+	if c.history != nil {
+		c.history.add(addr, reward, t)
+	}
 	return nil
 }
 
@@ -743,23 +794,33 @@ func (c *Calculator) calculateVotingReward() error {
 
 	// calculate voting reward
 	for _, i := range inputs {
-		if err = c.processVoting(
-			i._type,
-			multiplier,
-			divider,
-			prepInfo,
-			i.eventMap,
-		); err != nil {
-			return err
+		if step := votingStep(i._type, false); !c.checkpoint.isVotingStepDone(step) {
+			if err = c.processVoting(
+				i._type,
+				multiplier,
+				divider,
+				prepInfo,
+				i.eventMap,
+			); err != nil {
+				return err
+			}
+			if err = c.saveCheckpoint(phaseVoted, step+1, 0); err != nil {
+				return err
+			}
 		}
-		if err = c.processVotingEvent(
-			i._type,
-			multiplier,
-			divider,
-			prepInfo,
-			i.eventMap,
-		); err != nil {
-			return err
+		if step := votingStep(i._type, true); !c.checkpoint.isVotingStepDone(step) {
+			if err = c.processVotingEvent(
+				i._type,
+				multiplier,
+				divider,
+				prepInfo,
+				i.eventMap,
+			); err != nil {
+				return err
+			}
+			if err = c.saveCheckpoint(phaseVoted, step+1, 0); err != nil {
+				return err
+			}
 		}
 	}
 	// add preprocessed data for BugDisabledPRep
@@ -832,7 +893,15 @@ func (c *Calculator) processVoting(
 	} else {
 		prefix = icreward.BondingKey.Build()
 	}
+	// entries done before the checkpoint are skipped
+	step := votingStep(_type, false)
+	skip := c.checkpoint.doneInVotingStep(step)
+	done := 0
 	for iter := c.base.Filter(prefix); iter.Has(); iter.Next() {
+		done += 1
+		if done <= skip {
+			continue
+		}
 		o, key, err := iter.Get()
 		if err != nil {
 			return err
@@ -861,6 +930,9 @@ func (c *Calculator) processVoting(
 		if err = c.updateIScore(addr, reward, TypeVoting); err != nil {
 			return err
 		}
+		if err = c.onVotingEntry(step, done); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -926,7 +998,19 @@ func (c *Calculator) processVotingEvent(
 	prepInfo map[string]*pRepEnable,
 	eventMap map[string]map[int]icstage.VoteList,
 ) error {
-	for key, events := range eventMap { // each account
+	// accounts are processed in the order of the key to resume it
+	keys := make([]string, 0, len(eventMap))
+	for key := range eventMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	step := votingStep(_type, true)
+	skip := c.checkpoint.doneInVotingStep(step)
+	for idx, key := range keys { // each account
+		if idx < skip {
+			continue
+		}
+		events := eventMap[key]
 		addr, _ := common.NewAddress([]byte(key))
 		reward := new(big.Int)
 		offsets := make([]int, 0, len(events))
@@ -983,6 +1067,9 @@ func (c *Calculator) processVotingEvent(
 		if err = c.updateIScore(addr, reward, TypeVoting); err != nil {
 			return err
 		}
+		if err = c.onVotingEntry(step, idx+1); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -1060,7 +1147,7 @@ func (c *Calculator) postWork() (err error) {
 
 const InitBlockHeight = -1
 
-func NewCalculator(database db.Database, back *icstage.Snapshot, reward *icreward.Snapshot, logger log.Logger) *Calculator {
+func NewCalculator(database db.Database, back *icstage.Snapshot, reward *icreward.Snapshot, cfg *CalculatorConfig, logger log.Logger) *Calculator {
 	var err error
 	var global icstage.Global
 	var startHeight int64
@@ -1086,7 +1173,14 @@ func NewCalculator(database db.Database, back *icstage.Snapshot, reward *icrewar
 		startHeight: startHeight,
 		stats:       newStatistics(),
 	}
+	if cfg != nil && cfg.RewardHistory {
+		c.history = newRewardHistory(startHeight)
+	}
+	if cfg != nil {
+		c.checkpointInterval = cfg.CheckpointInterval
+	}
 	if startHeight != InitBlockHeight {
+		c.resume()
 		go c.run()
 	}
 	return c
@@ -1095,6 +1189,14 @@ func NewCalculator(database db.Database, back *icstage.Snapshot, reward *icrewar
 type CalculatorHolder struct {
 	lock   sync.Mutex
 	runner *Calculator
+	config CalculatorConfig
+}
+
+func (h *CalculatorHolder) SetConfig(cfg CalculatorConfig) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.config = cfg
 }
 
 func (h *CalculatorHolder) Start(ess state.ExtensionSnapshot, logger log.Logger) {
@@ -1102,7 +1204,7 @@ func (h *CalculatorHolder) Start(ess state.ExtensionSnapshot, logger log.Logger)
 	defer h.lock.Unlock()
 
 	if ess != nil {
-		h.runner = UpdateCalculator(h.runner, ess, logger)
+		h.runner = UpdateCalculator(h.runner, ess, &h.config, logger)
 	} else {
 		if h.runner != nil {
 			h.runner.Stop()
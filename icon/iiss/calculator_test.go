@@ -32,6 +32,7 @@ import (
 	"github.com/icon-project/goloop/icon/iiss/icreward"
 	"github.com/icon-project/goloop/icon/iiss/icstage"
 	"github.com/icon-project/goloop/icon/iiss/icstate"
+	"github.com/icon-project/goloop/icon/iiss/icutils"
 )
 
 func MakeCalculator(database db.Database, back *icstage.Snapshot) *Calculator {
@@ -657,12 +658,17 @@ func TestCalculator_varForVotingReward(t *testing.T) {
 type testGlobal struct {
 	icstage.Global
 	iissVersion int
+	offsetLimit int
 }
 
 func (tg *testGlobal) GetIISSVersion() int {
 	return tg.iissVersion
 }
 
+func (tg *testGlobal) GetOffsetLimit() int {
+	return tg.offsetLimit
+}
+
 func TestCalculator_VotingReward(t *testing.T) {
 	addr1 := common.MustNewAddressFromString("hx1")
 	addr2 := common.MustNewAddressFromString("hx2")
@@ -800,6 +806,122 @@ func TestCalculator_VotingReward(t *testing.T) {
 	}
 }
 
+func TestCalculator_StakePosition(t *testing.T) {
+	prep := common.MustNewAddressFromString("hx1")
+	holder1 := common.MustNewAddressFromString("hx11")
+	holder2 := common.MustNewAddressFromString("hx12")
+	prepInfo := map[string]*pRepEnable{
+		icutils.ToKey(prep): {0, 0},
+	}
+	amount := big.NewInt(MinDelegation * 10)
+	multiplier := big.NewInt(100)
+	divider := big.NewInt(10)
+	offsetLimit := 100
+
+	reward := func(period int) *big.Int {
+		r := new(big.Int).Mul(multiplier, amount)
+		r.Mul(r, big.NewInt(int64(period)))
+		return r.Div(r, divider)
+	}
+	vote := func(v *big.Int) icstage.VoteList {
+		return icstage.VoteList{icstage.NewVote(prep, v)}
+	}
+
+	tests := []struct {
+		name     string
+		eventMap map[string]map[int]icstage.VoteList
+		want1    *big.Int
+		want2    *big.Int
+		has1     bool
+		has2     bool
+	}{
+		{
+			// the position is held by holder1 for the whole term
+			name:     "Hold",
+			eventMap: map[string]map[int]icstage.VoteList{},
+			want1:    reward(offsetLimit + 1),
+			want2:    new(big.Int),
+			has1:     true,
+			has2:     false,
+		},
+		{
+			// transfer moves the delegation of the position at offset 40
+			name: "Transfer",
+			eventMap: map[string]map[int]icstage.VoteList{
+				icutils.ToKey(holder1): {40: vote(new(big.Int).Neg(amount))},
+				icutils.ToKey(holder2): {40: vote(amount)},
+			},
+			want1: reward(40 + 1),
+			want2: reward(offsetLimit - 40),
+			has1:  false,
+			has2:  true,
+		},
+		{
+			// holder2 redeems the position transferred at offset 40
+			name: "TransferAndRedeem",
+			eventMap: map[string]map[int]icstage.VoteList{
+				icutils.ToKey(holder1): {40: vote(new(big.Int).Neg(amount))},
+				icutils.ToKey(holder2): {
+					40: vote(amount),
+					70: vote(new(big.Int).Neg(amount)),
+				},
+			},
+			want1: reward(40 + 1),
+			want2: reward(70 - 40),
+			has1:  false,
+			has2:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := MakeCalculator(db.NewMapDB(), nil)
+			c.global = &testGlobal{iissVersion: icstate.IISSVersion3, offsetLimit: offsetLimit}
+			c.stats = newStatistics()
+
+			// holder1 created the position in the previous term
+			d := icreward.NewDelegating()
+			d.Delegations = icstate.Delegations{icstate.NewDelegation(prep, amount)}
+			assert.NoError(t, c.temp.SetDelegating(holder1, d))
+			c.base = c.temp.GetSnapshot()
+			c.temp = c.base.NewState()
+
+			err := c.processVoting(icreward.TypeDelegating, multiplier, divider, prepInfo, tt.eventMap)
+			assert.NoError(t, err)
+			err = c.processVotingEvent(icreward.TypeDelegating, multiplier, divider, prepInfo, tt.eventMap)
+			assert.NoError(t, err)
+
+			for i, h := range []struct {
+				addr *common.Address
+				want *big.Int
+				has  bool
+			}{
+				{holder1, tt.want1, tt.has1},
+				{holder2, tt.want2, tt.has2},
+			} {
+				iScore, err := c.temp.GetIScore(h.addr)
+				assert.NoError(t, err)
+				value := new(big.Int)
+				if iScore != nil {
+					value = iScore.Value()
+				}
+				assert.Zero(t, h.want.Cmp(value), "holder%d want=%s got=%s", i+1, h.want, value)
+
+				delegating, err := c.temp.GetDelegating(h.addr)
+				assert.NoError(t, err)
+				has := delegating != nil && !delegating.IsEmpty()
+				assert.Equal(t, h.has, has, "holder%d", i+1)
+			}
+
+			// reward of the position is shared by holders without loss
+			if tt.has1 || tt.has2 {
+				total := new(big.Int).Add(tt.want1, tt.want2)
+				assert.Zero(t, reward(offsetLimit+1).Cmp(total))
+			}
+		})
+	}
+}
+
 func TestCalculator_WaitResult(t *testing.T) {
 	c := &Calculator{
 		startHeight: InitBlockHeight,
@@ -0,0 +1,264 @@
+/*
+ * Copyright 2021 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package iiss
+
+import (
+	"bytes"
+	"math/big"
+
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/db"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/icon/icdb"
+	"github.com/icon-project/goloop/icon/iiss/icreward"
+)
+
+// DefaultCheckpointInterval is the number of voting entries processed
+// between checkpoints if it's not configured.
+const DefaultCheckpointInterval = 100000
+
+// Phases of the calculation. A checkpoint records the last phase done.
+const (
+	phaseNone = iota
+	phaseClaim
+	phaseBlockProduce
+	phaseVoted
+	phaseVoting
+)
+
+var phaseNames = []string{"none", "claim", "blockProduce", "voted", "voting"}
+
+// Steps of the voting phase.
+const (
+	stepDelegating = iota
+	stepDelegatingEvent
+	stepBonding
+	stepBondingEvent
+)
+
+func votingStep(_type int, event bool) int {
+	step := stepDelegating
+	if _type == icreward.TypeBonding {
+		step = stepBonding
+	}
+	if event {
+		step += 1
+	}
+	return step
+}
+
+var checkpointKey = []byte("calculator")
+
+// checkpoint is the progress of the calculation for a term. The calculation
+// is identified by the start height and the hashes of back and base.
+// Step and Index are the progress in the voting phase, the step in progress
+// and the number of entries done in it. History has the reward history
+// collected before the checkpoint if it's enabled.
+type checkpoint struct {
+	StartHeight  int64
+	Back         []byte
+	Base         []byte
+	Phase        int
+	Step         int
+	Index        int
+	Temp         []byte
+	History      []byte
+	BlockProduce *big.Int
+	Voted        *big.Int
+	Voting       *big.Int
+}
+
+func (cp *checkpoint) isFor(c *Calculator) bool {
+	return cp.StartHeight == c.startHeight &&
+		bytes.Equal(cp.Back, c.back.Bytes()) &&
+		bytes.Equal(cp.Base, c.base.Bytes())
+}
+
+// isVotingStepDone returns whether the step of the voting phase is done.
+func (cp *checkpoint) isVotingStepDone(step int) bool {
+	if cp == nil {
+		return false
+	}
+	return cp.Phase >= phaseVoting || (cp.Phase == phaseVoted && cp.Step > step)
+}
+
+// doneInVotingStep returns the number of entries done in the step of the
+// voting phase.
+func (cp *checkpoint) doneInVotingStep(step int) int {
+	if cp != nil && cp.Phase == phaseVoted && cp.Step == step {
+		return cp.Index
+	}
+	return 0
+}
+
+func (cp *checkpoint) ToJSON() map[string]interface{} {
+	jso := map[string]interface{}{
+		"startHeight": cp.StartHeight,
+		"checkpoint":  phaseNames[cp.Phase],
+	}
+	if cp.Phase == phaseVoted {
+		jso["votingStep"] = cp.Step
+		jso["votingIndex"] = cp.Index
+	}
+	return jso
+}
+
+func loadCheckpoint(database db.Database) (*checkpoint, error) {
+	bk, err := database.GetBucket(icdb.CalculatorCheckpoint)
+	if err != nil {
+		return nil, err
+	}
+	bs, err := bk.Get(checkpointKey)
+	if err != nil || bs == nil {
+		return nil, err
+	}
+	cp := new(checkpoint)
+	if _, err = codec.BC.UnmarshalFromBytes(bs, cp); err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
+
+func storeCheckpoint(database db.Database, cp *checkpoint) error {
+	bk, err := database.GetBucket(icdb.CalculatorCheckpoint)
+	if err != nil {
+		return err
+	}
+	bs, err := codec.BC.MarshalToBytes(cp)
+	if err != nil {
+		return err
+	}
+	return bk.Set(checkpointKey, bs)
+}
+
+// resume restores the temporary state and the statistics from the checkpoint
+// of the same calculation stored in the database.
+func (c *Calculator) resume() {
+	c.checkpoint = &checkpoint{
+		StartHeight: c.startHeight,
+		Back:        c.back.Bytes(),
+		Base:        c.base.Bytes(),
+	}
+	if c.database == nil {
+		return
+	}
+	cp, err := loadCheckpoint(c.database)
+	if err != nil {
+		c.log.Warnf("Failed to load checkpoint of calculator. %+v", err)
+		return
+	}
+	if cp == nil || !cp.isFor(c) || cp.Phase == phaseNone {
+		return
+	}
+	if c.history != nil {
+		if len(cp.History) == 0 {
+			// rewards before the checkpoint are unknown
+			c.log.Warnf("Reward history of %d is not recorded on resumed calculation", c.startHeight)
+			c.history = nil
+		} else if h, err := loadRewardHistory(c.startHeight, cp.History); err != nil {
+			c.log.Warnf("Failed to load reward history from checkpoint. %+v", err)
+			return
+		} else {
+			c.history = h
+		}
+	}
+	c.temp = icreward.NewSnapshot(c.database, cp.Temp).NewState()
+	c.stats = &statistics{cp.BlockProduce, cp.Voted, cp.Voting}
+	c.checkpoint = cp
+	c.resumed = true
+	c.log.Infof("Resume calculation %d from %s", c.startHeight, phaseNames[cp.Phase])
+}
+
+// saveCheckpoint flushes the temporary state and stores the progress.
+// It returns errors.ErrInterrupted if the calculator is stopped, so that
+// the calculation can be resumed from the point later. Failure of storing
+// the checkpoint doesn't make the calculation fail.
+func (c *Calculator) saveCheckpoint(phase, step, index int) error {
+	if c.Error() != nil {
+		return errors.ErrInterrupted
+	}
+	if c.database == nil {
+		return nil
+	}
+	ss := c.temp.GetSnapshot()
+	if err := ss.Flush(); err != nil {
+		c.log.Warnf("Failed to flush temporary state for checkpoint. %+v", err)
+		return nil
+	}
+	var history []byte
+	if c.history != nil {
+		history = c.history.Bytes()
+	}
+	cp := &checkpoint{
+		StartHeight:  c.startHeight,
+		Back:         c.back.Bytes(),
+		Base:         c.base.Bytes(),
+		Phase:        phase,
+		Step:         step,
+		Index:        index,
+		Temp:         ss.Bytes(),
+		History:      history,
+		BlockProduce: new(big.Int).Set(c.stats.blockProduce),
+		Voted:        new(big.Int).Set(c.stats.voted),
+		Voting:       new(big.Int).Set(c.stats.voting),
+	}
+	if err := storeCheckpoint(c.database, cp); err != nil {
+		c.log.Warnf("Failed to store checkpoint of calculator. %+v", err)
+		return nil
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.checkpoint = cp
+	return nil
+}
+
+// onVotingEntry is called after each entry of the voting phase is done.
+func (c *Calculator) onVotingEntry(step, done int) error {
+	interval := c.checkpointInterval
+	if interval <= 0 {
+		interval = DefaultCheckpointInterval
+	}
+	if done%interval != 0 {
+		return nil
+	}
+	return c.saveCheckpoint(phaseVoted, step, done)
+}
+
+// Progress returns the progress of the calculation for inspection.
+func (c *Calculator) Progress() map[string]interface{} {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var jso map[string]interface{}
+	if c.checkpoint != nil {
+		jso = c.checkpoint.ToJSON()
+	} else {
+		jso = map[string]interface{}{"checkpoint": phaseNames[phaseNone]}
+	}
+	jso["startHeight"] = c.startHeight
+	jso["resumed"] = c.resumed
+	switch {
+	case c.err != nil:
+		jso["state"] = "failed"
+		jso["error"] = c.err.Error()
+	case c.result != nil:
+		jso["state"] = "done"
+	default:
+		jso["state"] = "running"
+	}
+	return jso
+}
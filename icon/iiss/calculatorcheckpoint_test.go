@@ -0,0 +1,121 @@
+/*
+ * Copyright 2021 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package iiss
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/db"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/icon/iiss/icstage"
+)
+
+func makeCheckpointCalculator(database db.Database, startHeight int64) *Calculator {
+	c := MakeCalculator(database, icstage.NewSnapshot(database, nil))
+	c.database = database
+	c.startHeight = startHeight
+	c.stats = newStatistics()
+	return c
+}
+
+func TestCheckpoint_VotingStep(t *testing.T) {
+	var cp *checkpoint
+	assert.False(t, cp.isVotingStepDone(stepDelegating))
+	assert.Zero(t, cp.doneInVotingStep(stepDelegating))
+
+	cp = &checkpoint{Phase: phaseVoted, Step: stepBonding, Index: 10}
+	assert.True(t, cp.isVotingStepDone(stepDelegating))
+	assert.True(t, cp.isVotingStepDone(stepDelegatingEvent))
+	assert.False(t, cp.isVotingStepDone(stepBonding))
+	assert.False(t, cp.isVotingStepDone(stepBondingEvent))
+	assert.Zero(t, cp.doneInVotingStep(stepDelegating))
+	assert.Equal(t, 10, cp.doneInVotingStep(stepBonding))
+
+	cp = &checkpoint{Phase: phaseVoting}
+	assert.True(t, cp.isVotingStepDone(stepBondingEvent))
+	assert.Zero(t, cp.doneInVotingStep(stepBondingEvent))
+}
+
+func TestCalculator_Checkpoint(t *testing.T) {
+	database := db.NewMapDB()
+	addr := common.MustNewAddressFromString("hx1")
+
+	c := makeCheckpointCalculator(database, 100)
+	c.resume()
+	assert.False(t, c.resumed)
+	assert.Equal(t, phaseNone, c.checkpoint.Phase)
+
+	c.history = newRewardHistory(100)
+	assert.NoError(t, c.updateIScore(addr, big.NewInt(1000), TypeVoting))
+	assert.NoError(t, c.saveCheckpoint(phaseVoted, stepBonding, 5))
+
+	// a calculator for another term doesn't use the checkpoint
+	c2 := makeCheckpointCalculator(database, 200)
+	c2.resume()
+	assert.False(t, c2.resumed)
+
+	c2 = makeCheckpointCalculator(database, 100)
+	c2.history = newRewardHistory(100)
+	c2.resume()
+	assert.True(t, c2.resumed)
+	if assert.NotNil(t, c2.history) {
+		r := c2.history.records[string(addr.Bytes())]
+		if assert.NotNil(t, r) {
+			assert.Equal(t, int64(100), r.StartHeight)
+			assert.Equal(t, int64(1000), r.Voting.Int64())
+		}
+	}
+	assert.Equal(t, phaseVoted, c2.checkpoint.Phase)
+	assert.Equal(t, stepBonding, c2.checkpoint.Step)
+	assert.Equal(t, 5, c2.checkpoint.Index)
+	assert.Equal(t, int64(1000), c2.stats.voting.Int64())
+	is, err := c2.temp.GetIScore(addr)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), is.Value().Int64())
+
+	jso := c2.Progress()
+	assert.Equal(t, "voted", jso["checkpoint"])
+	assert.Equal(t, stepBonding, jso["votingStep"])
+	assert.Equal(t, 5, jso["votingIndex"])
+	assert.Equal(t, true, jso["resumed"])
+	assert.Equal(t, "running", jso["state"])
+
+	// stopped calculation keeps the last checkpoint
+	c2.Stop()
+	assert.Equal(t, errors.ErrInterrupted, c2.saveCheckpoint(phaseVoting, 0, 0))
+	cp, err := loadCheckpoint(database)
+	assert.NoError(t, err)
+	assert.Equal(t, phaseVoted, cp.Phase)
+}
+
+func TestCalculator_onVotingEntry(t *testing.T) {
+	database := db.NewMapDB()
+	c := makeCheckpointCalculator(database, 100)
+	c.checkpointInterval = 3
+	c.resume()
+
+	for i := 1; i <= 4; i++ {
+		assert.NoError(t, c.onVotingEntry(stepDelegatingEvent, i))
+	}
+	assert.Equal(t, phaseVoted, c.checkpoint.Phase)
+	assert.Equal(t, stepDelegatingEvent, c.checkpoint.Step)
+	assert.Equal(t, 3, c.checkpoint.Index)
+}
@@ -360,6 +360,11 @@ func (es *ExtensionStateImpl) SetDelegation(
 	if account.Stake().Cmp(using) < 0 {
 		return icmodule.IllegalArgumentError.Errorf("Not enough voting power")
 	}
+	if revision >= icmodule.RevisionStakePosition {
+		if err := es.checkStakePositionLock(from, ds); err != nil {
+			return err
+		}
+	}
 
 	delta := account.Delegations().Delta(ds)
 
@@ -577,7 +582,7 @@ func (es *ExtensionStateImpl) DisqualifyPRep(cc icmodule.CallContext, address mo
 			intconv.Int64ToBytes(int64(icmodule.PenaltyPRepDisqualification)),
 		},
 	)
-	return nil
+	return es.addPenaltyRecord(cc, address, icmodule.PenaltyPRepDisqualification, nil)
 }
 
 func (es *ExtensionStateImpl) SetBond(blockHeight int64, from module.Address, bonds icstate.Bonds) error {
@@ -1436,6 +1441,9 @@ func (es *ExtensionStateImpl) ClaimIScore(cc icmodule.CallContext) error {
 		es.claimed[icutils.ToKey(from)] = newClaimed(cc.TransactionID(), claim)
 	}
 	ClaimEventLog(cc, from, claim, icx)
+	if revision >= icmodule.RevisionAutoCompound {
+		return es.compoundIScore(cc, icx)
+	}
 	return nil
 }
 
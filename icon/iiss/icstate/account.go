@@ -18,6 +18,7 @@ package icstate
 
 import (
 	"fmt"
+	"io"
 	"math/big"
 	"sort"
 
@@ -32,7 +33,8 @@ import (
 
 const (
 	accountVersion1 = iota + 1
-	accountVersion  = accountVersion1
+	accountVersion2
+	accountVersion = accountVersion1
 )
 
 var AccountDictPrefix = containerdb.ToKey(
@@ -52,6 +54,8 @@ type accountData struct {
 	totalDelegation *big.Int
 	totalBond       *big.Int
 	totalUnbond     *big.Int
+
+	autoCompound bool
 }
 
 func (a *accountData) equal(other *accountData) bool {
@@ -66,7 +70,8 @@ func (a *accountData) equal(other *accountData) bool {
 		a.totalBond.Cmp(other.totalBond) == 0 &&
 		a.totalUnbond.Cmp(other.totalUnbond) == 0 &&
 		a.bonds.Equal(other.bonds) &&
-		a.unbonds.Equal(other.unbonds)
+		a.unbonds.Equal(other.unbonds) &&
+		a.autoCompound == other.autoCompound
 }
 
 func (a accountData) clone() accountData {
@@ -81,11 +86,13 @@ func (a accountData) clone() accountData {
 		totalDelegation: a.totalDelegation,
 		totalBond:       a.totalBond,
 		totalUnbond:     a.totalUnbond,
+
+		autoCompound: a.autoCompound,
 	}
 }
 
 func (a accountData) IsEmpty() bool {
-	return (a.stake == nil || a.stake.Sign() == 0) && len(a.unstakes) == 0
+	return (a.stake == nil || a.stake.Sign() == 0) && len(a.unstakes) == 0 && !a.autoCompound
 }
 
 func (a accountData) Stake() *big.Int {
@@ -156,6 +163,18 @@ func (a *accountData) Unbond() *big.Int {
 	return a.totalUnbond
 }
 
+// AutoCompound returns whether claimed I-Score of the account is staked
+// and delegated again automatically.
+func (a *accountData) AutoCompound() bool {
+	return a.autoCompound
+}
+
+func (a *accountData) GetAutoCompoundInJSON() map[string]interface{} {
+	jso := make(map[string]interface{})
+	jso["enabled"] = a.autoCompound
+	return jso
+}
+
 func (a *accountData) GetBondsInJSON() []interface{} {
 	return a.bonds.ToJSON(module.JSONVersion3)
 }
@@ -202,12 +221,17 @@ func (a *AccountSnapshot) Equal(object icobject.Impl) bool {
 	return a.equal(&other.accountData)
 }
 
+// Version returns accountVersion2 only for the account enabling
+// auto-compounding, so encoding of other accounts is kept as it was.
 func (a *AccountSnapshot) Version() int {
+	if a.autoCompound {
+		return accountVersion2
+	}
 	return accountVersion
 }
 
 func (a *AccountSnapshot) RLPDecodeFields(decoder codec.Decoder) error {
-	return decoder.DecodeAll(
+	_, err := decoder.DecodeMulti(
 		&a.stake,
 		&a.unstakes,
 		&a.totalDelegation,
@@ -216,11 +240,19 @@ func (a *AccountSnapshot) RLPDecodeFields(decoder codec.Decoder) error {
 		&a.totalUnbond,
 		&a.bonds,
 		&a.unbonds,
+		&a.autoCompound,
 	)
+	if err == io.EOF {
+		err = nil
+	}
+	if err != nil {
+		return codec.ErrInvalidFormat
+	}
+	return nil
 }
 
 func (a *AccountSnapshot) RLPEncodeFields(encoder codec.Encoder) error {
-	return encoder.EncodeMulti(
+	if err := encoder.EncodeMulti(
 		a.stake,
 		a.unstakes,
 		a.totalDelegation,
@@ -229,7 +261,13 @@ func (a *AccountSnapshot) RLPEncodeFields(encoder codec.Encoder) error {
 		a.totalUnbond,
 		a.bonds,
 		a.unbonds,
-	)
+	); err != nil {
+		return err
+	}
+	if a.autoCompound {
+		return encoder.Encode(a.autoCompound)
+	}
+	return nil
 }
 
 var emptyAccountData = accountData{
@@ -314,6 +352,11 @@ func (a *AccountState) SetDelegation(ds Delegations) {
 	a.setDirty()
 }
 
+func (a *AccountState) SetAutoCompound(enable bool) {
+	a.autoCompound = enable
+	a.setDirty()
+}
+
 func (a *AccountState) SetBonds(bonds Bonds) {
 	a.bonds = bonds
 	a.totalBond = a.bonds.GetBondAmount()
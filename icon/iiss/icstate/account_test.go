@@ -78,6 +78,33 @@ func TestAccount_Bytes(t *testing.T) {
 	assert.Equal(t, true, assTest.GetSnapshot().Equal(ass2))
 }
 
+func TestAccount_AutoCompound(t *testing.T) {
+	database := icobject.AttachObjectFactory(db.NewMapDB(), NewObjectImpl)
+	account := getTestAccount()
+	serialized := icobject.New(TypeAccount, account.GetSnapshot()).Bytes()
+
+	account.SetAutoCompound(true)
+	assert.True(t, account.AutoCompound())
+	o1 := icobject.New(TypeAccount, account.GetSnapshot())
+	assert.Equal(t, accountVersion2, o1.Tag().Version())
+	assert.NotEqual(t, serialized, o1.Bytes())
+
+	o2 := new(icobject.Object)
+	assert.NoError(t, o2.Reset(database, o1.Bytes()))
+	assert.True(t, ToAccount(o2).AutoCompound())
+	assert.True(t, account.GetSnapshot().Equal(ToAccount(o2)))
+
+	// encoding is not changed for the account disabling it
+	account.SetAutoCompound(false)
+	o1 = icobject.New(TypeAccount, account.GetSnapshot())
+	assert.Equal(t, accountVersion1, o1.Tag().Version())
+	assert.Equal(t, serialized, o1.Bytes())
+
+	empty := newAccountStateWithSnapshot(nil)
+	empty.SetAutoCompound(true)
+	assert.False(t, empty.IsEmpty())
+}
+
 func TestAccount_SetStake(t *testing.T) {
 	account := newAccountStateWithSnapshot(nil)
 
@@ -0,0 +1,396 @@
+/*
+ * Copyright 2021 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package icstate
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/containerdb"
+	"github.com/icon-project/goloop/common/trie"
+	"github.com/icon-project/goloop/icon/iiss/icobject"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/scoredb"
+)
+
+var (
+	networkProposalPrefix = containerdb.ToKey(
+		containerdb.HashBuilder, scoredb.DictDBPrefix, "network_proposal",
+	)
+	networkProposalCountKey = containerdb.ToKey(
+		containerdb.HashBuilder, scoredb.VarDBPrefix, "network_proposal_count",
+	)
+	activeNetworkProposalsKey = containerdb.ToKey(
+		containerdb.HashBuilder, scoredb.ArrayDBPrefix, "active_network_proposals",
+	)
+)
+
+type NetworkProposalStatus int
+
+const (
+	NetworkProposalVoting NetworkProposalStatus = iota
+	NetworkProposalApproved
+	NetworkProposalRejected
+	NetworkProposalCanceled
+	NetworkProposalExpired
+	NetworkProposalApplied
+	NetworkProposalFailed
+)
+
+func (s NetworkProposalStatus) String() string {
+	switch s {
+	case NetworkProposalVoting:
+		return "voting"
+	case NetworkProposalApproved:
+		return "approved"
+	case NetworkProposalRejected:
+		return "rejected"
+	case NetworkProposalCanceled:
+		return "canceled"
+	case NetworkProposalExpired:
+		return "expired"
+	case NetworkProposalApplied:
+		return "applied"
+	case NetworkProposalFailed:
+		return "failed"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// IsActive returns true if the proposal may be voted or applied later.
+func (s NetworkProposalStatus) IsActive() bool {
+	return s == NetworkProposalVoting || s == NetworkProposalApproved
+}
+
+const (
+	NetworkProposalVoteNone = iota
+	NetworkProposalVoteApprove
+	NetworkProposalVoteReject
+)
+
+// NetworkProposalVoter is a main P-Rep at the registration of the proposal
+// with its delegation as the weight of its vote.
+type NetworkProposalVoter struct {
+	Address *common.Address
+	Weight  *big.Int
+	Vote    int
+}
+
+func (v *NetworkProposalVoter) Equal(v2 *NetworkProposalVoter) bool {
+	return v.Address.Equal(v2.Address) &&
+		v.Weight.Cmp(v2.Weight) == 0 &&
+		v.Vote == v2.Vote
+}
+
+func (v *NetworkProposalVoter) ToJSON() map[string]interface{} {
+	jso := map[string]interface{}{
+		"address": v.Address,
+		"weight":  v.Weight,
+	}
+	switch v.Vote {
+	case NetworkProposalVoteApprove:
+		jso["vote"] = "approve"
+	case NetworkProposalVoteReject:
+		jso["vote"] = "reject"
+	}
+	return jso
+}
+
+type NetworkProposal struct {
+	icobject.NoDatabase
+
+	id           int64
+	proposer     *common.Address
+	title        string
+	description  string
+	typ          string
+	value        *big.Int
+	startHeight  int64
+	expireHeight int64
+	applyHeight  int64
+	status       int
+	voters       []*NetworkProposalVoter
+}
+
+func NewNetworkProposalWithTag(_ icobject.Tag) *NetworkProposal {
+	return new(NetworkProposal)
+}
+
+func NewNetworkProposal(
+	proposer module.Address, title, description, typ string, value *big.Int,
+	startHeight, expireHeight, applyHeight int64, voters []*NetworkProposalVoter,
+) *NetworkProposal {
+	return &NetworkProposal{
+		proposer:     common.AddressToPtr(proposer),
+		title:        title,
+		description:  description,
+		typ:          typ,
+		value:        value,
+		startHeight:  startHeight,
+		expireHeight: expireHeight,
+		applyHeight:  applyHeight,
+		voters:       voters,
+	}
+}
+
+func (p *NetworkProposal) Version() int {
+	return 0
+}
+
+func (p *NetworkProposal) ID() int64 {
+	return p.id
+}
+
+func (p *NetworkProposal) Proposer() module.Address {
+	return p.proposer
+}
+
+func (p *NetworkProposal) Type() string {
+	return p.typ
+}
+
+// Value returns the parameter of the method called on apply.
+func (p *NetworkProposal) Value() *big.Int {
+	return p.value
+}
+
+func (p *NetworkProposal) StartHeight() int64 {
+	return p.startHeight
+}
+
+func (p *NetworkProposal) ExpireHeight() int64 {
+	return p.expireHeight
+}
+
+// ApplyHeight returns the height from which the proposal is applied.
+// Zero means that it's applied right after the approval.
+func (p *NetworkProposal) ApplyHeight() int64 {
+	return p.applyHeight
+}
+
+func (p *NetworkProposal) Status() NetworkProposalStatus {
+	return NetworkProposalStatus(p.status)
+}
+
+func (p *NetworkProposal) SetStatus(status NetworkProposalStatus) {
+	p.status = int(status)
+}
+
+func (p *NetworkProposal) GetVoter(address module.Address) *NetworkProposalVoter {
+	for _, v := range p.voters {
+		if v.Address.Equal(address) {
+			return v
+		}
+	}
+	return nil
+}
+
+// Tally returns the total weight of the voters and the weights of approving
+// and rejecting votes.
+func (p *NetworkProposal) Tally() (total, approve, reject *big.Int) {
+	total, approve, reject = new(big.Int), new(big.Int), new(big.Int)
+	for _, v := range p.voters {
+		total.Add(total, v.Weight)
+		switch v.Vote {
+		case NetworkProposalVoteApprove:
+			approve.Add(approve, v.Weight)
+		case NetworkProposalVoteReject:
+			reject.Add(reject, v.Weight)
+		}
+	}
+	return
+}
+
+func (p *NetworkProposal) Clone() *NetworkProposal {
+	voters := make([]*NetworkProposalVoter, len(p.voters))
+	for i, v := range p.voters {
+		nv := *v
+		voters[i] = &nv
+	}
+	np := *p
+	np.voters = voters
+	return &np
+}
+
+func (p *NetworkProposal) RLPDecodeFields(decoder codec.Decoder) error {
+	return decoder.DecodeAll(
+		&p.id,
+		&p.proposer,
+		&p.title,
+		&p.description,
+		&p.typ,
+		&p.value,
+		&p.startHeight,
+		&p.expireHeight,
+		&p.applyHeight,
+		&p.status,
+		&p.voters,
+	)
+}
+
+func (p *NetworkProposal) RLPEncodeFields(encoder codec.Encoder) error {
+	return encoder.EncodeMulti(
+		p.id,
+		p.proposer,
+		p.title,
+		p.description,
+		p.typ,
+		p.value,
+		p.startHeight,
+		p.expireHeight,
+		p.applyHeight,
+		p.status,
+		p.voters,
+	)
+}
+
+func (p *NetworkProposal) Equal(o icobject.Impl) bool {
+	p2, ok := o.(*NetworkProposal)
+	if !ok {
+		return false
+	}
+	if p.id != p2.id ||
+		!p.proposer.Equal(p2.proposer) ||
+		p.title != p2.title ||
+		p.description != p2.description ||
+		p.typ != p2.typ ||
+		p.value.Cmp(p2.value) != 0 ||
+		p.startHeight != p2.startHeight ||
+		p.expireHeight != p2.expireHeight ||
+		p.applyHeight != p2.applyHeight ||
+		p.status != p2.status ||
+		len(p.voters) != len(p2.voters) {
+		return false
+	}
+	for i, v := range p.voters {
+		if !v.Equal(p2.voters[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *NetworkProposal) ToJSON() map[string]interface{} {
+	total, approve, reject := p.Tally()
+	voters := make([]interface{}, len(p.voters))
+	for i, v := range p.voters {
+		voters[i] = v.ToJSON()
+	}
+	jso := map[string]interface{}{
+		"id":            p.id,
+		"proposer":      p.proposer,
+		"title":         p.title,
+		"description":   p.description,
+		"type":          p.typ,
+		"value":         p.value,
+		"startHeight":   p.startHeight,
+		"expireHeight":  p.expireHeight,
+		"status":        p.Status().String(),
+		"voters":        voters,
+		"totalWeight":   total,
+		"approveWeight": approve,
+		"rejectWeight":  reject,
+	}
+	if p.applyHeight > 0 {
+		jso["applyHeight"] = p.applyHeight
+	}
+	return jso
+}
+
+func (p *NetworkProposal) Format(f fmt.State, c rune) {
+	switch c {
+	case 'v':
+		if f.Flag('+') {
+			fmt.Fprintf(f, "NetworkProposal{id=%d proposer=%s type=%s value=%s status=%s voters=%d}",
+				p.id, p.proposer, p.typ, p.value, p.Status(), len(p.voters))
+		} else {
+			fmt.Fprintf(f, "NetworkProposal{%d %s %s %s %s %d}",
+				p.id, p.proposer, p.typ, p.value, p.Status(), len(p.voters))
+		}
+	}
+}
+
+func ToNetworkProposal(object trie.Object) *NetworkProposal {
+	if object == nil {
+		return nil
+	}
+	return object.(*icobject.Object).Real().(*NetworkProposal)
+}
+
+// AddNetworkProposal stores a new proposal with the next ID and returns the ID.
+func (s *State) AddNetworkProposal(p *NetworkProposal) (int64, error) {
+	count := containerdb.NewVarDB(s.store, networkProposalCountKey)
+	p.id = count.Int64() + 1
+	if err := count.Set(p.id); err != nil {
+		return 0, err
+	}
+	if err := s.SetNetworkProposal(p); err != nil {
+		return 0, err
+	}
+	return p.id, containerdb.NewArrayDB(s.store, activeNetworkProposalsKey).Put(p.id)
+}
+
+// SetNetworkProposal updates the proposal. The proposal which is not active
+// any more is removed from the list of active proposals.
+func (s *State) SetNetworkProposal(p *NetworkProposal) error {
+	dict := containerdb.NewDictDB(s.store, 1, networkProposalPrefix)
+	if err := dict.Set(p.id, icobject.New(TypeNetworkProposal, p)); err != nil {
+		return err
+	}
+	if p.Status().IsActive() {
+		return nil
+	}
+	array := containerdb.NewArrayDB(s.store, activeNetworkProposalsKey)
+	for i := 0; i < array.Size(); i++ {
+		if array.Get(i).Int64() == p.id {
+			last := array.Get(array.Size() - 1).Int64()
+			array.Pop()
+			if i < array.Size() {
+				return array.Set(i, last)
+			}
+			break
+		}
+	}
+	return nil
+}
+
+func (s *State) GetNetworkProposal(id int64) *NetworkProposal {
+	dict := containerdb.NewDictDB(s.store, 1, networkProposalPrefix)
+	obj := dict.Get(id)
+	if obj == nil {
+		return nil
+	}
+	return ToNetworkProposal(obj.Object()).Clone()
+}
+
+func (s *State) GetNetworkProposalCount() int64 {
+	return containerdb.NewVarDB(s.store, networkProposalCountKey).Int64()
+}
+
+// GetActiveNetworkProposalIDs returns IDs of the proposals under voting or
+// waiting for the apply height.
+func (s *State) GetActiveNetworkProposalIDs() []int64 {
+	array := containerdb.NewArrayDB(s.store, activeNetworkProposalsKey)
+	ids := make([]int64, array.Size())
+	for i := range ids {
+		ids[i] = array.Get(i).Int64()
+	}
+	return ids
+}
@@ -0,0 +1,120 @@
+/*
+ * Copyright 2021 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package icstate
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/db"
+	"github.com/icon-project/goloop/icon/iiss/icobject"
+)
+
+func newDummyNetworkProposal(proposer int) *NetworkProposal {
+	voters := make([]*NetworkProposalVoter, 3)
+	for i := range voters {
+		voters[i] = &NetworkProposalVoter{
+			Address: common.AddressToPtr(newDummyAddress(i + 1)),
+			Weight:  big.NewInt(int64(100 * (i + 1))),
+		}
+	}
+	return NewNetworkProposal(
+		newDummyAddress(proposer), "title", "description", "setIRep", big.NewInt(1000),
+		10, 110, 0, voters,
+	)
+}
+
+func TestNetworkProposal_RLP(t *testing.T) {
+	p := newDummyNetworkProposal(1)
+	p.GetVoter(newDummyAddress(2)).Vote = NetworkProposalVoteApprove
+	p.SetStatus(NetworkProposalApproved)
+
+	database := icobject.AttachObjectFactory(db.NewMapDB(), NewObjectImpl)
+	o1 := icobject.New(TypeNetworkProposal, p)
+	o2 := new(icobject.Object)
+	assert.NoError(t, o2.Reset(database, o1.Bytes()))
+	assert.True(t, o1.Equal(o2))
+
+	total, approve, reject := ToNetworkProposal(o2).Tally()
+	assert.Equal(t, int64(600), total.Int64())
+	assert.Equal(t, int64(200), approve.Int64())
+	assert.Zero(t, reject.Sign())
+}
+
+func TestState_NetworkProposal(t *testing.T) {
+	s := newDummyState(false)
+	assert.Zero(t, s.GetNetworkProposalCount())
+	assert.Nil(t, s.GetNetworkProposal(1))
+
+	for i := 1; i <= 3; i++ {
+		id, err := s.AddNetworkProposal(newDummyNetworkProposal(i))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(i), id)
+	}
+	assert.Equal(t, int64(3), s.GetNetworkProposalCount())
+	assert.Equal(t, []int64{1, 2, 3}, s.GetActiveNetworkProposalIDs())
+
+	// changes of the returned proposal are not stored without SetNetworkProposal
+	p := s.GetNetworkProposal(1)
+	p.GetVoter(newDummyAddress(1)).Vote = NetworkProposalVoteReject
+	assert.Equal(t, NetworkProposalVoteNone, s.GetNetworkProposal(1).GetVoter(newDummyAddress(1)).Vote)
+
+	p.SetStatus(NetworkProposalApproved)
+	assert.NoError(t, s.SetNetworkProposal(p))
+	assert.Equal(t, []int64{1, 2, 3}, s.GetActiveNetworkProposalIDs())
+
+	p.SetStatus(NetworkProposalApplied)
+	assert.NoError(t, s.SetNetworkProposal(p))
+	assert.Equal(t, []int64{3, 2}, s.GetActiveNetworkProposalIDs())
+	assert.Equal(t, NetworkProposalApplied, s.GetNetworkProposal(1).Status())
+
+	p = s.GetNetworkProposal(2)
+	p.SetStatus(NetworkProposalCanceled)
+	assert.NoError(t, s.SetNetworkProposal(p))
+	assert.Equal(t, []int64{3}, s.GetActiveNetworkProposalIDs())
+
+	s = flushAndNewState(s, false)
+	assert.Equal(t, int64(3), s.GetNetworkProposalCount())
+	assert.Equal(t, []int64{3}, s.GetActiveNetworkProposalIDs())
+	assert.Equal(t, "canceled", s.GetNetworkProposal(2).ToJSON()["status"])
+}
+
+func TestState_SetNetworkProposal_RemoveActive(t *testing.T) {
+	s := newDummyState(false)
+	for i := 1; i <= 4; i++ {
+		_, err := s.AddNetworkProposal(newDummyNetworkProposal(i))
+		assert.NoError(t, err)
+	}
+	s = flushAndNewState(s, false)
+
+	// the last one takes the place of the removed one in the middle
+	p := s.GetNetworkProposal(2)
+	p.SetStatus(NetworkProposalApplied)
+	assert.NoError(t, s.SetNetworkProposal(p))
+	assert.Equal(t, []int64{1, 4, 3}, s.GetActiveNetworkProposalIDs())
+
+	s = flushAndNewState(s, false)
+	assert.Equal(t, []int64{1, 4, 3}, s.GetActiveNetworkProposalIDs())
+
+	p = s.GetNetworkProposal(3)
+	p.SetStatus(NetworkProposalRejected)
+	assert.NoError(t, s.SetNetworkProposal(p))
+	assert.Equal(t, []int64{1, 4}, s.GetActiveNetworkProposalIDs())
+}
@@ -33,6 +33,9 @@ const (
 	TypeValidators
 	TypeBlockVoters
 	TypeIllegalDelegation
+	TypeNetworkProposal
+	TypePenaltyRecord
+	TypeStakePosition
 )
 
 type StateAndSnapshot struct {
@@ -75,6 +78,12 @@ func NewObjectImpl(tag icobject.Tag) (icobject.Impl, error) {
 		return NewBlockVotersWithTag(tag), nil
 	case TypeIllegalDelegation:
 		return NewIllegalDelegationWithTag(tag), nil
+	case TypeNetworkProposal:
+		return NewNetworkProposalWithTag(tag), nil
+	case TypePenaltyRecord:
+		return NewPenaltyRecordWithTag(tag), nil
+	case TypeStakePosition:
+		return NewStakePositionWithTag(tag), nil
 	default:
 		return nil, errors.IllegalArgumentError.Errorf(
 			"UnknownTypeTag(tag=%#x)", tag)
@@ -0,0 +1,183 @@
+/*
+ * Copyright 2021 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package icstate
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/containerdb"
+	"github.com/icon-project/goloop/common/trie"
+	"github.com/icon-project/goloop/icon/icmodule"
+	"github.com/icon-project/goloop/icon/iiss/icobject"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/scoredb"
+)
+
+var penaltyHistoryPrefix = containerdb.ToKey(
+	containerdb.HashBuilder, scoredb.ArrayDBPrefix, "penalty_history",
+)
+
+// Slash is the amount slashed from the bond and the unbonding of a bonder.
+type Slash struct {
+	Bonder *common.Address
+	Amount *big.Int
+}
+
+func (s *Slash) Equal(s2 *Slash) bool {
+	return s.Bonder.Equal(s2.Bonder) && s.Amount.Cmp(s2.Amount) == 0
+}
+
+func (s *Slash) ToJSON() map[string]interface{} {
+	return map[string]interface{}{
+		"address": s.Bonder,
+		"amount":  s.Amount,
+	}
+}
+
+// PenaltyRecord is a penalty imposed on a P-Rep with the slashes of its
+// bonders.
+type PenaltyRecord struct {
+	icobject.NoDatabase
+
+	blockHeight int64
+	typ         int
+	slashes     []*Slash
+}
+
+func NewPenaltyRecordWithTag(_ icobject.Tag) *PenaltyRecord {
+	return new(PenaltyRecord)
+}
+
+func NewPenaltyRecord(blockHeight int64, typ icmodule.PenaltyType, slashes []*Slash) *PenaltyRecord {
+	return &PenaltyRecord{
+		blockHeight: blockHeight,
+		typ:         int(typ),
+		slashes:     slashes,
+	}
+}
+
+func (r *PenaltyRecord) Version() int {
+	return 0
+}
+
+func (r *PenaltyRecord) BlockHeight() int64 {
+	return r.blockHeight
+}
+
+func (r *PenaltyRecord) Type() icmodule.PenaltyType {
+	return icmodule.PenaltyType(r.typ)
+}
+
+func (r *PenaltyRecord) Slashes() []*Slash {
+	return r.slashes
+}
+
+// Amount returns the total amount slashed by the penalty.
+func (r *PenaltyRecord) Amount() *big.Int {
+	amount := new(big.Int)
+	for _, s := range r.slashes {
+		amount.Add(amount, s.Amount)
+	}
+	return amount
+}
+
+func (r *PenaltyRecord) RLPDecodeFields(decoder codec.Decoder) error {
+	return decoder.DecodeAll(&r.blockHeight, &r.typ, &r.slashes)
+}
+
+func (r *PenaltyRecord) RLPEncodeFields(encoder codec.Encoder) error {
+	return encoder.EncodeMulti(r.blockHeight, r.typ, r.slashes)
+}
+
+func (r *PenaltyRecord) Equal(o icobject.Impl) bool {
+	r2, ok := o.(*PenaltyRecord)
+	if !ok {
+		return false
+	}
+	if r.blockHeight != r2.blockHeight || r.typ != r2.typ || len(r.slashes) != len(r2.slashes) {
+		return false
+	}
+	for i, s := range r.slashes {
+		if !s.Equal(r2.slashes[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *PenaltyRecord) ToJSON() map[string]interface{} {
+	slashes := make([]interface{}, len(r.slashes))
+	for i, s := range r.slashes {
+		slashes[i] = s.ToJSON()
+	}
+	return map[string]interface{}{
+		"blockHeight": r.blockHeight,
+		"type":        r.typ,
+		"amount":      r.Amount(),
+		"slashes":     slashes,
+	}
+}
+
+func (r *PenaltyRecord) Format(f fmt.State, c rune) {
+	switch c {
+	case 'v':
+		if f.Flag('+') {
+			fmt.Fprintf(f, "PenaltyRecord{blockHeight=%d type=%d amount=%s slashes=%d}",
+				r.blockHeight, r.typ, r.Amount(), len(r.slashes))
+		} else {
+			fmt.Fprintf(f, "PenaltyRecord{%d %d %s %d}",
+				r.blockHeight, r.typ, r.Amount(), len(r.slashes))
+		}
+	}
+}
+
+func ToPenaltyRecord(object trie.Object) *PenaltyRecord {
+	if object == nil {
+		return nil
+	}
+	return object.(*icobject.Object).Real().(*PenaltyRecord)
+}
+
+func (s *State) getPenaltyHistoryDB(owner module.Address) *containerdb.ArrayDB {
+	return containerdb.NewArrayDB(s.store, penaltyHistoryPrefix.Append(owner))
+}
+
+// AddPenaltyRecord appends the record to the penalty history of the P-Rep.
+func (s *State) AddPenaltyRecord(owner module.Address, r *PenaltyRecord) error {
+	return s.getPenaltyHistoryDB(owner).Put(icobject.New(TypePenaltyRecord, r))
+}
+
+func (s *State) GetPenaltyRecordCount(owner module.Address) int {
+	return s.getPenaltyHistoryDB(owner).Size()
+}
+
+// GetPenaltyRecord returns the record of the P-Rep at the index in the order
+// of the imposition.
+func (s *State) GetPenaltyRecord(owner module.Address, idx int) *PenaltyRecord {
+	array := s.getPenaltyHistoryDB(owner)
+	if idx < 0 || idx >= array.Size() {
+		return nil
+	}
+	v := array.Get(idx)
+	if v == nil {
+		return nil
+	}
+	return ToPenaltyRecord(v.Object())
+}
@@ -0,0 +1,71 @@
+/*
+ * Copyright 2021 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package icstate
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/db"
+	"github.com/icon-project/goloop/icon/icmodule"
+	"github.com/icon-project/goloop/icon/iiss/icobject"
+)
+
+func TestPenaltyRecord(t *testing.T) {
+	slashes := []*Slash{
+		{common.AddressToPtr(newDummyAddress(1)), big.NewInt(100)},
+		{common.AddressToPtr(newDummyAddress(2)), big.NewInt(200)},
+	}
+	r := NewPenaltyRecord(100, icmodule.PenaltyConsistentValidation, slashes)
+	assert.Equal(t, int64(300), r.Amount().Int64())
+
+	database := icobject.AttachObjectFactory(db.NewMapDB(), NewObjectImpl)
+	o1 := icobject.New(TypePenaltyRecord, r)
+	o2 := new(icobject.Object)
+	assert.NoError(t, o2.Reset(database, o1.Bytes()))
+	assert.True(t, o1.Equal(o2))
+
+	r2 := ToPenaltyRecord(o2)
+	assert.Equal(t, int64(100), r2.BlockHeight())
+	assert.Equal(t, icmodule.PenaltyConsistentValidation, r2.Type())
+	assert.Len(t, r2.Slashes(), 2)
+}
+
+func TestState_PenaltyHistory(t *testing.T) {
+	s := newDummyState(false)
+	owner1 := newDummyAddress(1)
+	owner2 := newDummyAddress(2)
+
+	assert.Zero(t, s.GetPenaltyRecordCount(owner1))
+	assert.Nil(t, s.GetPenaltyRecord(owner1, 0))
+
+	assert.NoError(t, s.AddPenaltyRecord(owner1, NewPenaltyRecord(10, icmodule.PenaltyBlockValidation, nil)))
+	assert.NoError(t, s.AddPenaltyRecord(owner1, NewPenaltyRecord(20, icmodule.PenaltyPRepDisqualification, nil)))
+	assert.NoError(t, s.AddPenaltyRecord(owner2, NewPenaltyRecord(30, icmodule.PenaltyBlockValidation, nil)))
+
+	s = flushAndNewState(s, false)
+	assert.Equal(t, 2, s.GetPenaltyRecordCount(owner1))
+	assert.Equal(t, 1, s.GetPenaltyRecordCount(owner2))
+	assert.Equal(t, int64(20), s.GetPenaltyRecord(owner1, 1).BlockHeight())
+	assert.Equal(t, icmodule.PenaltyPRepDisqualification, s.GetPenaltyRecord(owner1, 1).Type())
+	assert.Equal(t, int64(30), s.GetPenaltyRecord(owner2, 0).BlockHeight())
+	assert.Nil(t, s.GetPenaltyRecord(owner1, 2))
+	assert.Nil(t, s.GetPenaltyRecord(owner1, -1))
+}
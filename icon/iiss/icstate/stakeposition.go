@@ -0,0 +1,254 @@
+/*
+ * Copyright 2021 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package icstate
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/containerdb"
+	"github.com/icon-project/goloop/common/trie"
+	"github.com/icon-project/goloop/icon/iiss/icobject"
+	"github.com/icon-project/goloop/icon/iiss/icutils"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/scoredb"
+)
+
+var (
+	stakePositionPrefix = containerdb.ToKey(
+		containerdb.HashBuilder, scoredb.DictDBPrefix, "stake_position",
+	)
+	stakePositionCountKey = containerdb.ToKey(
+		containerdb.HashBuilder, scoredb.VarDBPrefix, "stake_position_count",
+	)
+	stakePositionsOfPrefix = containerdb.ToKey(
+		containerdb.HashBuilder, scoredb.ArrayDBPrefix, "stake_positions_of",
+	)
+)
+
+// StakePosition is a transferable position on a part of the delegation of
+// its holder. The stake and the delegation of the position belong to the
+// holder, so the holder gets the voting reward of it.
+type StakePosition struct {
+	icobject.NoDatabase
+
+	id          int64
+	holder      *common.Address
+	prep        *common.Address
+	amount      *big.Int
+	blockHeight int64
+}
+
+func NewStakePositionWithTag(_ icobject.Tag) *StakePosition {
+	return new(StakePosition)
+}
+
+func NewStakePosition(holder, prep module.Address, amount *big.Int, blockHeight int64) *StakePosition {
+	return &StakePosition{
+		holder:      common.AddressToPtr(holder),
+		prep:        common.AddressToPtr(prep),
+		amount:      amount,
+		blockHeight: blockHeight,
+	}
+}
+
+func (p *StakePosition) Version() int {
+	return 0
+}
+
+func (p *StakePosition) ID() int64 {
+	return p.id
+}
+
+func (p *StakePosition) Holder() module.Address {
+	return p.holder
+}
+
+func (p *StakePosition) PRep() module.Address {
+	return p.prep
+}
+
+func (p *StakePosition) Amount() *big.Int {
+	return p.amount
+}
+
+// BlockHeight returns the height of the block where the position was created.
+func (p *StakePosition) BlockHeight() int64 {
+	return p.blockHeight
+}
+
+func (p *StakePosition) Clone() *StakePosition {
+	n := *p
+	return &n
+}
+
+func (p *StakePosition) RLPDecodeFields(decoder codec.Decoder) error {
+	return decoder.DecodeAll(&p.id, &p.holder, &p.prep, &p.amount, &p.blockHeight)
+}
+
+func (p *StakePosition) RLPEncodeFields(encoder codec.Encoder) error {
+	return encoder.EncodeMulti(p.id, p.holder, p.prep, p.amount, p.blockHeight)
+}
+
+func (p *StakePosition) Equal(o icobject.Impl) bool {
+	p2, ok := o.(*StakePosition)
+	if !ok {
+		return false
+	}
+	return p.id == p2.id &&
+		p.holder.Equal(p2.holder) &&
+		p.prep.Equal(p2.prep) &&
+		p.amount.Cmp(p2.amount) == 0 &&
+		p.blockHeight == p2.blockHeight
+}
+
+func (p *StakePosition) ToJSON() map[string]interface{} {
+	return map[string]interface{}{
+		"id":          p.id,
+		"holder":      p.holder,
+		"prep":        p.prep,
+		"amount":      p.amount,
+		"blockHeight": p.blockHeight,
+	}
+}
+
+func (p *StakePosition) Format(f fmt.State, c rune) {
+	switch c {
+	case 'v':
+		if f.Flag('+') {
+			fmt.Fprintf(f, "StakePosition{id=%d holder=%s prep=%s amount=%s blockHeight=%d}",
+				p.id, p.holder, p.prep, p.amount, p.blockHeight)
+		} else {
+			fmt.Fprintf(f, "StakePosition{%d %s %s %s %d}",
+				p.id, p.holder, p.prep, p.amount, p.blockHeight)
+		}
+	}
+}
+
+func ToStakePosition(object trie.Object) *StakePosition {
+	if object == nil {
+		return nil
+	}
+	return object.(*icobject.Object).Real().(*StakePosition)
+}
+
+func (s *State) getStakePositionsOfDB(holder module.Address) *containerdb.ArrayDB {
+	return containerdb.NewArrayDB(s.store, stakePositionsOfPrefix.Append(holder))
+}
+
+func (s *State) setStakePosition(p *StakePosition) error {
+	dict := containerdb.NewDictDB(s.store, 1, stakePositionPrefix)
+	return dict.Set(p.id, icobject.New(TypeStakePosition, p))
+}
+
+func (s *State) removeStakePositionID(holder module.Address, id int64) error {
+	array := s.getStakePositionsOfDB(holder)
+	for i := 0; i < array.Size(); i++ {
+		if array.Get(i).Int64() == id {
+			last := array.Get(array.Size() - 1).Int64()
+			array.Pop()
+			if i < array.Size() {
+				return array.Set(i, last)
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// AddStakePosition stores a new position and returns the ID assigned to it.
+func (s *State) AddStakePosition(p *StakePosition) (int64, error) {
+	count := containerdb.NewVarDB(s.store, stakePositionCountKey)
+	p.id = count.Int64() + 1
+	if err := count.Set(p.id); err != nil {
+		return 0, err
+	}
+	if err := s.setStakePosition(p); err != nil {
+		return 0, err
+	}
+	return p.id, s.getStakePositionsOfDB(p.holder).Put(p.id)
+}
+
+func (s *State) GetStakePosition(id int64) *StakePosition {
+	dict := containerdb.NewDictDB(s.store, 1, stakePositionPrefix)
+	obj := dict.Get(id)
+	if obj == nil {
+		return nil
+	}
+	return ToStakePosition(obj.Object()).Clone()
+}
+
+// TransferStakePosition changes the holder of the position.
+func (s *State) TransferStakePosition(id int64, to module.Address) error {
+	p := s.GetStakePosition(id)
+	if p == nil {
+		return nil
+	}
+	if err := s.removeStakePositionID(p.holder, id); err != nil {
+		return err
+	}
+	p.holder = common.AddressToPtr(to)
+	if err := s.setStakePosition(p); err != nil {
+		return err
+	}
+	return s.getStakePositionsOfDB(to).Put(id)
+}
+
+// RemoveStakePosition deletes the position.
+func (s *State) RemoveStakePosition(id int64) error {
+	p := s.GetStakePosition(id)
+	if p == nil {
+		return nil
+	}
+	if err := s.removeStakePositionID(p.holder, id); err != nil {
+		return err
+	}
+	dict := containerdb.NewDictDB(s.store, 1, stakePositionPrefix)
+	return dict.Delete(id)
+}
+
+// GetStakePositionIDs returns IDs of the positions held by the account.
+func (s *State) GetStakePositionIDs(holder module.Address) []int64 {
+	array := s.getStakePositionsOfDB(holder)
+	ids := make([]int64, array.Size())
+	for i := range ids {
+		ids[i] = array.Get(i).Int64()
+	}
+	return ids
+}
+
+// GetStakePositionAmounts returns the sum of the positions held by the
+// account for each P-Rep. The delegation of the account to the P-Rep can't
+// be less than it.
+func (s *State) GetStakePositionAmounts(holder module.Address) map[string]*big.Int {
+	amounts := make(map[string]*big.Int)
+	for _, id := range s.GetStakePositionIDs(holder) {
+		p := s.GetStakePosition(id)
+		if p == nil {
+			continue
+		}
+		key := icutils.ToKey(p.prep)
+		if amount, ok := amounts[key]; ok {
+			amount.Add(amount, p.amount)
+		} else {
+			amounts[key] = new(big.Int).Set(p.amount)
+		}
+	}
+	return amounts
+}
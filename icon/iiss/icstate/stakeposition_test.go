@@ -0,0 +1,83 @@
+/*
+ * Copyright 2021 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package icstate
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common/db"
+	"github.com/icon-project/goloop/icon/iiss/icobject"
+	"github.com/icon-project/goloop/icon/iiss/icutils"
+)
+
+func TestStakePosition(t *testing.T) {
+	p := NewStakePosition(newDummyAddress(1), newDummyAddress(2), big.NewInt(100), 10)
+
+	database := icobject.AttachObjectFactory(db.NewMapDB(), NewObjectImpl)
+	o1 := icobject.New(TypeStakePosition, p)
+	o2 := new(icobject.Object)
+	assert.NoError(t, o2.Reset(database, o1.Bytes()))
+	assert.True(t, o1.Equal(o2))
+
+	p2 := ToStakePosition(o2)
+	assert.True(t, p2.Holder().Equal(newDummyAddress(1)))
+	assert.True(t, p2.PRep().Equal(newDummyAddress(2)))
+	assert.Equal(t, int64(100), p2.Amount().Int64())
+	assert.Equal(t, int64(10), p2.BlockHeight())
+}
+
+func TestState_StakePosition(t *testing.T) {
+	s := newDummyState(false)
+	holder1 := newDummyAddress(1)
+	holder2 := newDummyAddress(2)
+	prep1 := newDummyAddress(11)
+	prep2 := newDummyAddress(12)
+
+	id1, err := s.AddStakePosition(NewStakePosition(holder1, prep1, big.NewInt(100), 10))
+	assert.NoError(t, err)
+	id2, err := s.AddStakePosition(NewStakePosition(holder1, prep1, big.NewInt(200), 11))
+	assert.NoError(t, err)
+	id3, err := s.AddStakePosition(NewStakePosition(holder1, prep2, big.NewInt(300), 12))
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, 3}, []int64{id1, id2, id3})
+
+	s = flushAndNewState(s, false)
+	assert.Equal(t, []int64{id1, id2, id3}, s.GetStakePositionIDs(holder1))
+	amounts := s.GetStakePositionAmounts(holder1)
+	assert.Len(t, amounts, 2)
+	assert.Equal(t, int64(300), amounts[icutils.ToKey(prep1)].Int64())
+	assert.Equal(t, int64(300), amounts[icutils.ToKey(prep2)].Int64())
+
+	assert.NoError(t, s.TransferStakePosition(id1, holder2))
+	assert.True(t, s.GetStakePosition(id1).Holder().Equal(holder2))
+	assert.Equal(t, []int64{id3, id2}, s.GetStakePositionIDs(holder1))
+	assert.Equal(t, []int64{id1}, s.GetStakePositionIDs(holder2))
+	assert.Equal(t, int64(200), s.GetStakePositionAmounts(holder1)[icutils.ToKey(prep1)].Int64())
+
+	assert.NoError(t, s.RemoveStakePosition(id3))
+	assert.Nil(t, s.GetStakePosition(id3))
+	assert.Equal(t, []int64{id2}, s.GetStakePositionIDs(holder1))
+	assert.Len(t, s.GetStakePositionAmounts(holder1), 1)
+
+	// IDs are not reused
+	id4, err := s.AddStakePosition(NewStakePosition(holder2, prep2, big.NewInt(400), 13))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), id4)
+}
@@ -22,9 +22,11 @@ import (
 	"github.com/icon-project/goloop/common"
 	"github.com/icon-project/goloop/common/codec"
 	"github.com/icon-project/goloop/common/containerdb"
+	"github.com/icon-project/goloop/common/log"
 	"github.com/icon-project/goloop/icon/iiss/icobject"
 	"github.com/icon-project/goloop/module"
 	"github.com/icon-project/goloop/service/scoredb"
+	"github.com/icon-project/goloop/service/state"
 )
 
 var unstakingTimerDictPrefix = containerdb.ToKey(
@@ -81,13 +83,16 @@ func (t timerData) IsEmpty() bool {
 	return len(t.addresses) == 0
 }
 
+func (t timerData) Size() int {
+	return len(t.addresses)
+}
+
+func (t timerData) Get(i int) []byte {
+	return t.addresses[i].Bytes()
+}
+
 func (t timerData) IndexOf(addr module.Address) int {
-	for i, a := range t.addresses {
-		if a.Equal(addr) {
-			return i
-		}
-	}
-	return -1
+	return state.IndexOfTimerEntry(t, addr.Bytes())
 }
 
 func (t timerData) Contains(addr module.Address) bool {
@@ -199,26 +204,47 @@ func (t *TimerState) GetSnapshot() *TimerSnapshot {
 	return t.snapshot
 }
 
+func (t *TimerState) Set(i int, bs []byte) error {
+	addr, err := common.NewAddress(bs)
+	if err != nil {
+		return err
+	}
+	t.addresses[i] = addr
+	t.setDirty()
+	return nil
+}
+
+func (t *TimerState) Put(bs []byte) error {
+	addr, err := common.NewAddress(bs)
+	if err != nil {
+		return err
+	}
+	t.addresses = append(t.addresses, addr)
+	t.setDirty()
+	return nil
+}
+
+func (t *TimerState) Pop() error {
+	l := len(t.addresses)
+	if l == 0 {
+		return nil
+	}
+	t.addresses[l-1] = nil
+	t.addresses = t.addresses[0 : l-1]
+	t.setDirty()
+	return nil
+}
+
 func (t *TimerState) Delete(address module.Address) {
-	idx := t.IndexOf(address)
-	if idx >= 0 {
-		l := len(t.addresses)
-		if idx+1 < l {
-			copy(t.addresses[idx:], t.addresses[idx+1:])
-		} else {
-			t.addresses[idx] = nil
-		}
-		t.addresses = t.addresses[0 : l-1]
-		t.setDirty()
+	if _, err := state.RemoveTimerEntry(t, address.Bytes()); err != nil {
+		log.Errorf("Failed to delete %s from timer, err=%+v", address, err)
 	}
 }
 
 func (t *TimerState) Add(address module.Address) {
-	if t.Contains(address) {
-		return
+	if _, err := state.AddTimerEntry(t, address.Bytes()); err != nil {
+		log.Errorf("Failed to add %s to timer, err=%+v", address, err)
 	}
-	t.addresses = append(t.addresses, common.AddressToPtr(address))
-	t.setDirty()
 }
 
 var emptyTimerSnapshot = &TimerSnapshot{}
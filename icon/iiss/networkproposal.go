@@ -0,0 +1,280 @@
+/*
+ * Copyright 2021 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package iiss
+
+import (
+	"math/big"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/intconv"
+	"github.com/icon-project/goloop/icon/icmodule"
+	"github.com/icon-project/goloop/icon/iiss/icstate"
+	"github.com/icon-project/goloop/service/scoreresult"
+	"github.com/icon-project/goloop/service/state"
+)
+
+// Types of network proposals. Each is the name of the method of the chain
+// SCORE which is called with the value of the proposal on apply.
+const (
+	NetworkProposalSetRevision   = "setRevision"
+	NetworkProposalSetStepPrice  = "setStepPrice"
+	NetworkProposalSetRewardFund = "setRewardFund"
+	NetworkProposalSetIRep       = "setIRep"
+)
+
+const (
+	NetworkProposalsDefaultSize = 10
+	NetworkProposalsMaxSize     = 100
+)
+
+func isNetworkProposalType(typ string) bool {
+	switch typ {
+	case NetworkProposalSetRevision, NetworkProposalSetStepPrice,
+		NetworkProposalSetRewardFund, NetworkProposalSetIRep:
+		return true
+	}
+	return false
+}
+
+// NetworkProposalApplier calls the method of the chain SCORE for the
+// approved proposal.
+type NetworkProposalApplier func(p *icstate.NetworkProposal) error
+
+// RegisterNetworkProposal registers a proposal of a main P-Rep. Main P-Reps
+// of the current term can vote for it with their delegation as the weight.
+func (es *ExtensionStateImpl) RegisterNetworkProposal(
+	cc icmodule.CallContext, title, description, typ string, value *big.Int, applyHeight int64,
+) (int64, error) {
+	from := cc.From()
+	blockHeight := cc.BlockHeight()
+	if !isNetworkProposalType(typ) {
+		return 0, scoreresult.InvalidParameterError.Errorf("InvalidProposalType(%s)", typ)
+	}
+	if value.Sign() < 0 {
+		return 0, scoreresult.InvalidParameterError.Errorf("InvalidProposalValue(%s)", value)
+	}
+	if typ == NetworkProposalSetRevision {
+		if value.Cmp(big.NewInt(icmodule.MaxRevision)) > 0 ||
+			value.Cmp(big.NewInt(int64(cc.Revision().Value()))) <= 0 {
+			return 0, scoreresult.InvalidParameterError.Errorf("InvalidRevision(%s)", value)
+		}
+	}
+	if applyHeight != 0 && applyHeight <= blockHeight {
+		return 0, scoreresult.InvalidParameterError.Errorf("InvalidApplyHeight(%d)", applyHeight)
+	}
+
+	term := es.State.GetTermSnapshot()
+	if term == nil || !term.IsDecentralized() {
+		return 0, scoreresult.AccessDeniedError.New("NotDecentralized")
+	}
+	var voters []*icstate.NetworkProposalVoter
+	var isMainPRep bool
+	for i := 0; i < term.MainPRepCount() && i < term.GetPRepSnapshotCount(); i++ {
+		owner := term.GetPRepSnapshotByIndex(i).Owner()
+		weight := new(big.Int)
+		if ps := es.State.GetPRepStatusByOwner(owner, false); ps != nil {
+			weight.Set(ps.Delegated())
+		}
+		voters = append(voters, &icstate.NetworkProposalVoter{
+			Address: common.AddressToPtr(owner),
+			Weight:  weight,
+		})
+		isMainPRep = isMainPRep || owner.Equal(from)
+	}
+	if !isMainPRep {
+		return 0, scoreresult.AccessDeniedError.Errorf("NotMainPRep(%s)", from)
+	}
+
+	expireHeight := term.GetEndHeight() + int64(icmodule.NetworkProposalVotingTerms-1)*term.Period()
+	p := icstate.NewNetworkProposal(
+		from, title, description, typ, new(big.Int).Set(value),
+		blockHeight, expireHeight, applyHeight, voters,
+	)
+	id, err := es.State.AddNetworkProposal(p)
+	if err != nil {
+		return 0, err
+	}
+	cc.OnEvent(state.SystemAddress,
+		[][]byte{
+			[]byte("NetworkProposalRegistered(int,Address,str,int)"),
+			intconv.Int64ToBytes(id),
+		},
+		[][]byte{
+			from.Bytes(),
+			[]byte(typ),
+			intconv.BigIntToBytes(value),
+		},
+	)
+	return id, nil
+}
+
+func (es *ExtensionStateImpl) getNetworkProposal(id int64) (*icstate.NetworkProposal, error) {
+	p := es.State.GetNetworkProposal(id)
+	if p == nil {
+		return nil, scoreresult.InvalidParameterError.Errorf("ProposalNotFound(%d)", id)
+	}
+	return p, nil
+}
+
+// VoteNetworkProposal records the vote of a main P-Rep. The proposal is
+// approved if more than 2/3 of the total weight approves it, and rejected
+// if 1/3 or more of the total weight rejects it.
+func (es *ExtensionStateImpl) VoteNetworkProposal(cc icmodule.CallContext, id int64, approve bool) error {
+	from := cc.From()
+	p, err := es.getNetworkProposal(id)
+	if err != nil {
+		return err
+	}
+	if p.Status() != icstate.NetworkProposalVoting {
+		return scoreresult.InvalidParameterError.Errorf("ProposalNotInVoting(id=%d,status=%s)", id, p.Status())
+	}
+	voter := p.GetVoter(from)
+	if voter == nil {
+		return scoreresult.AccessDeniedError.Errorf("NotVoter(%s)", from)
+	}
+	if voter.Vote != icstate.NetworkProposalVoteNone {
+		return scoreresult.InvalidParameterError.Errorf("AlreadyVoted(%s)", from)
+	}
+	if approve {
+		voter.Vote = icstate.NetworkProposalVoteApprove
+	} else {
+		voter.Vote = icstate.NetworkProposalVoteReject
+	}
+	cc.OnEvent(state.SystemAddress,
+		[][]byte{
+			[]byte("NetworkProposalVoted(int,Address,bool)"),
+			intconv.Int64ToBytes(id),
+		},
+		[][]byte{
+			from.Bytes(),
+			boolToBytes(approve),
+		},
+	)
+
+	total, approved, rejected := p.Tally()
+	if approved.Mul(approved, big.NewInt(3)).Cmp(new(big.Int).Mul(total, big.NewInt(2))) > 0 {
+		p.SetStatus(icstate.NetworkProposalApproved)
+		networkProposalEventLog(cc, "NetworkProposalApproved(int)", id)
+	} else if total.Sign() > 0 && rejected.Mul(rejected, big.NewInt(3)).Cmp(total) >= 0 {
+		p.SetStatus(icstate.NetworkProposalRejected)
+		networkProposalEventLog(cc, "NetworkProposalRejected(int)", id)
+	}
+	return es.State.SetNetworkProposal(p)
+}
+
+// CancelNetworkProposal cancels the proposal under voting by the proposer.
+func (es *ExtensionStateImpl) CancelNetworkProposal(cc icmodule.CallContext, id int64) error {
+	from := cc.From()
+	p, err := es.getNetworkProposal(id)
+	if err != nil {
+		return err
+	}
+	if !p.Proposer().Equal(from) {
+		return scoreresult.AccessDeniedError.Errorf("NotProposer(%s)", from)
+	}
+	if p.Status() != icstate.NetworkProposalVoting {
+		return scoreresult.InvalidParameterError.Errorf("ProposalNotInVoting(id=%d,status=%s)", id, p.Status())
+	}
+	p.SetStatus(icstate.NetworkProposalCanceled)
+	networkProposalEventLog(cc, "NetworkProposalCanceled(int)", id)
+	return es.State.SetNetworkProposal(p)
+}
+
+// HandleNetworkProposals expires the proposals whose voting period is over,
+// and applies the approved proposals from their apply height. It's called
+// at the beginning of each block. A proposal failed to apply is marked as
+// failed without affecting the block.
+func (es *ExtensionStateImpl) HandleNetworkProposals(blockHeight int64, apply NetworkProposalApplier) error {
+	for _, id := range es.State.GetActiveNetworkProposalIDs() {
+		p := es.State.GetNetworkProposal(id)
+		if p == nil {
+			continue
+		}
+		switch p.Status() {
+		case icstate.NetworkProposalVoting:
+			if blockHeight <= p.ExpireHeight() {
+				continue
+			}
+			p.SetStatus(icstate.NetworkProposalExpired)
+		case icstate.NetworkProposalApproved:
+			if blockHeight < p.ApplyHeight() {
+				continue
+			}
+			if err := apply(p); err != nil {
+				es.logger.Warnf("Failed to apply network proposal %v: %+v", p, err)
+				p.SetStatus(icstate.NetworkProposalFailed)
+			} else {
+				p.SetStatus(icstate.NetworkProposalApplied)
+			}
+		}
+		es.logger.Debugf("Network proposal %d is %s at %d", id, p.Status(), blockHeight)
+		if err := es.State.SetNetworkProposal(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (es *ExtensionStateImpl) GetNetworkProposalInJSON(id int64) (map[string]interface{}, error) {
+	p, err := es.getNetworkProposal(id)
+	if err != nil {
+		return nil, err
+	}
+	return p.ToJSON(), nil
+}
+
+// GetNetworkProposalsInJSON returns proposals from the ID, start, in the
+// order of registration. Zero start means the first one.
+func (es *ExtensionStateImpl) GetNetworkProposalsInJSON(start, size int64) (map[string]interface{}, error) {
+	count := es.State.GetNetworkProposalCount()
+	if start == 0 {
+		start = 1
+	}
+	if size == 0 {
+		size = NetworkProposalsDefaultSize
+	}
+	if start < 0 || size < 0 || size > NetworkProposalsMaxSize {
+		return nil, scoreresult.InvalidParameterError.Errorf("InvalidRange(start=%d,size=%d)", start, size)
+	}
+	proposals := make([]interface{}, 0, size)
+	for id := start; id <= count && id < start+size; id++ {
+		if p := es.State.GetNetworkProposal(id); p != nil {
+			proposals = append(proposals, p.ToJSON())
+		}
+	}
+	return map[string]interface{}{
+		"total":     count,
+		"proposals": proposals,
+	}, nil
+}
+
+func boolToBytes(v bool) []byte {
+	if v {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+func networkProposalEventLog(cc icmodule.CallContext, signature string, id int64) {
+	cc.OnEvent(state.SystemAddress,
+		[][]byte{
+			[]byte(signature),
+			intconv.Int64ToBytes(id),
+		},
+		nil,
+	)
+}
@@ -19,15 +19,23 @@ package iiss
 import (
 	"math/big"
 
+	"github.com/icon-project/goloop/common"
 	"github.com/icon-project/goloop/common/errors"
 	"github.com/icon-project/goloop/common/intconv"
 	"github.com/icon-project/goloop/icon/icmodule"
 	"github.com/icon-project/goloop/icon/iiss/icstage"
+	"github.com/icon-project/goloop/icon/iiss/icstate"
 	"github.com/icon-project/goloop/icon/iiss/icutils"
 	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/scoreresult"
 	"github.com/icon-project/goloop/service/state"
 )
 
+const (
+	PenaltyHistoryDefaultSize = 20
+	PenaltyHistoryMaxSize     = 100
+)
+
 func (es *ExtensionStateImpl) handlePenalty(cc icmodule.CallContext, owner module.Address) error {
 	var err error
 
@@ -59,23 +67,41 @@ func (es *ExtensionStateImpl) handlePenalty(cc icmodule.CallContext, owner modul
 
 	// Slashing
 	revision := cc.Revision().Value()
+	penaltyType := icmodule.PenaltyBlockValidation
+	var slashes []*icstate.Slash
 	if es.State.CheckConsistentValidationPenalty(revision, ps) {
 		slashRatio := es.State.GetConsistentValidationPenaltySlashRatio()
-		if err = es.slash(cc, owner, slashRatio); err != nil {
+		if slashes, err = es.slash(cc, owner, slashRatio); err != nil {
 			return err
 		}
+		penaltyType = icmodule.PenaltyConsistentValidation
+	}
+	if err = es.addPenaltyRecord(cc, owner, penaltyType, slashes); err != nil {
+		return err
 	}
 
 	// Record event for reward calculation
 	return es.addEventEnable(blockHeight, owner, icstage.ESDisableTemp)
 }
 
-func (es *ExtensionStateImpl) slash(cc icmodule.CallContext, owner module.Address, ratio int) error {
-	if ratio == 0 {
+// addPenaltyRecord records the penalty in the penalty history of the P-Rep.
+func (es *ExtensionStateImpl) addPenaltyRecord(
+	cc icmodule.CallContext, owner module.Address, typ icmodule.PenaltyType, slashes []*icstate.Slash,
+) error {
+	if cc.Revision().Value() < icmodule.RevisionPenaltyHistory {
 		return nil
 	}
+	return es.State.AddPenaltyRecord(owner, icstate.NewPenaltyRecord(cc.BlockHeight(), typ, slashes))
+}
+
+// slash slashes bonds and unbondings of bonders of the P-Rep by the ratio,
+// and returns the amount slashed from each bonder.
+func (es *ExtensionStateImpl) slash(cc icmodule.CallContext, owner module.Address, ratio int) ([]*icstate.Slash, error) {
+	if ratio == 0 {
+		return nil, nil
+	}
 	if ratio < 0 || 100 < ratio {
-		return errors.Errorf("Invalid slash ratio %d", ratio)
+		return nil, errors.Errorf("Invalid slash ratio %d", ratio)
 	}
 
 	logger := es.Logger()
@@ -83,9 +109,10 @@ func (es *ExtensionStateImpl) slash(cc icmodule.CallContext, owner module.Addres
 
 	pb := es.State.GetPRepBaseByOwner(owner, false)
 	if pb == nil {
-		return errors.Errorf("PRep not found: %s", owner)
+		return nil, errors.Errorf("PRep not found: %s", owner)
 	}
 	bonders := pb.BonderList()
+	slashes := make([]*icstate.Slash, 0, len(bonders))
 	totalSlashBond := new(big.Int)
 	totalStake := new(big.Int).Set(es.State.GetTotalStake())
 
@@ -110,13 +137,13 @@ func (es *ExtensionStateImpl) slash(cc icmodule.CallContext, owner module.Addres
 			if timer != nil {
 				timer.Delete(owner)
 			} else {
-				return errors.Errorf("timer doesn't exist for height %d", expire)
+				return nil, errors.Errorf("timer doesn't exist for height %d", expire)
 			}
 		}
 
 		// from stake
 		if err := account.SlashStake(totalSlash); err != nil {
-			return err
+			return nil, err
 		}
 		totalStake.Sub(totalStake, totalSlash)
 
@@ -125,7 +152,7 @@ func (es *ExtensionStateImpl) slash(cc icmodule.CallContext, owner module.Addres
 			icutils.ToKey(owner): new(big.Int).Neg(slashBond),
 		}
 		if err := es.AddEventBond(cc.BlockHeight(), bonder, delta); err != nil {
-			return err
+			return nil, err
 		}
 
 		// event log
@@ -135,17 +162,84 @@ func (es *ExtensionStateImpl) slash(cc icmodule.CallContext, owner module.Addres
 			[][]byte{bonder.Bytes(), intconv.BigIntToBytes(totalSlash)},
 		)
 
+		if totalSlash.Sign() > 0 {
+			slashes = append(slashes, &icstate.Slash{
+				Bonder: common.AddressToPtr(bonder),
+				Amount: totalSlash,
+			})
+		}
+
 		logger.Debugf("After slashing: %s", account)
 	}
 
 	if err := es.State.SetTotalStake(totalStake); err != nil {
-		return err
+		return nil, err
 	}
 	if err := es.State.Slash(owner, totalSlashBond); err != nil {
-		return err
+		return nil, err
 	}
 	err := cc.Burn(state.SystemAddress, totalSlashBond)
 
 	logger.Tracef("slash() end: totalSlashBond=%s", totalSlashBond)
-	return err
+	return slashes, err
+}
+
+// GetSlashingPreview returns the amount which would be slashed from each
+// bonder of the P-Rep if the consistent validation penalty were imposed now.
+func (es *ExtensionStateImpl) GetSlashingPreview(owner module.Address) (map[string]interface{}, error) {
+	pb := es.State.GetPRepBaseByOwner(owner, false)
+	if pb == nil {
+		return nil, scoreresult.InvalidParameterError.Errorf("PRepNotFound(%s)", owner)
+	}
+	ratio := es.State.GetConsistentValidationPenaltySlashRatio()
+	total := new(big.Int)
+	slashes := make([]interface{}, 0)
+	for _, bonder := range pb.BonderList() {
+		account := es.State.GetAccountSnapshot(bonder)
+		if account == nil {
+			continue
+		}
+		bonds := account.Bonds()
+		_, slashBond := bonds.Slash(owner, ratio)
+		unbonds := account.Unbonds()
+		_, slashUnbond, _ := unbonds.Slash(owner, ratio)
+		amount := new(big.Int).Add(slashBond, slashUnbond)
+		if amount.Sign() == 0 {
+			continue
+		}
+		total.Add(total, amount)
+		slashes = append(slashes, map[string]interface{}{
+			"address": bonder,
+			"bond":    slashBond,
+			"unbond":  slashUnbond,
+			"amount":  amount,
+		})
+	}
+	return map[string]interface{}{
+		"slashRatio": ratio,
+		"amount":     total,
+		"slashes":    slashes,
+	}, nil
+}
+
+// GetPenaltyHistoryInJSON returns the penalties imposed on the P-Rep from
+// the index, start, in the order of the imposition.
+func (es *ExtensionStateImpl) GetPenaltyHistoryInJSON(owner module.Address, start, size int) (map[string]interface{}, error) {
+	if size == 0 {
+		size = PenaltyHistoryDefaultSize
+	}
+	if start < 0 || size < 0 || size > PenaltyHistoryMaxSize {
+		return nil, scoreresult.InvalidParameterError.Errorf("InvalidRange(start=%d,size=%d)", start, size)
+	}
+	count := es.State.GetPenaltyRecordCount(owner)
+	penalties := make([]interface{}, 0, size)
+	for i := start; i < count && i < start+size; i++ {
+		if r := es.State.GetPenaltyRecord(owner, i); r != nil {
+			penalties = append(penalties, r.ToJSON())
+		}
+	}
+	return map[string]interface{}{
+		"total":     count,
+		"penalties": penalties,
+	}, nil
 }
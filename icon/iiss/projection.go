@@ -0,0 +1,234 @@
+/*
+ * Copyright 2021 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package iiss
+
+import (
+	"math/big"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/icon/icmodule"
+	"github.com/icon-project/goloop/icon/iiss/icobject"
+	"github.com/icon-project/goloop/icon/iiss/icreward"
+	"github.com/icon-project/goloop/icon/iiss/icstage"
+	"github.com/icon-project/goloop/icon/iiss/icstate"
+	"github.com/icon-project/goloop/icon/iiss/icutils"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/scoreresult"
+)
+
+// RewardProjection is the reward of an account expected for a term.
+type RewardProjection struct {
+	Period int
+	Voted  *big.Int
+	Voting *big.Int
+}
+
+func (p *RewardProjection) IScore() *big.Int {
+	return new(big.Int).Add(p.Voted, p.Voting)
+}
+
+func (p *RewardProjection) ToJSON() map[string]interface{} {
+	iScore := p.IScore()
+	return map[string]interface{}{
+		"period":       p.Period,
+		"voted":        p.Voted,
+		"voting":       p.Voting,
+		"iscore":       iScore,
+		"estimatedICX": icutils.IScoreToICX(iScore),
+	}
+}
+
+// loadProjectedVotedInfo returns voted information at the end of the
+// current term. Events in the stages are applied to the voted data of
+// the reward snapshot in order.
+func (c *Calculator) loadProjectedVotedInfo(stages []*icstage.Snapshot) (*votedInfo, error) {
+	vInfo, err := c.loadVotedInfo()
+	if err != nil {
+		return nil, err
+	}
+	for _, stage := range stages {
+		for iter := stage.Filter(icstage.EventKey.Build()); iter.Has(); iter.Next() {
+			o, _, err := iter.Get()
+			if err != nil {
+				return nil, err
+			}
+			switch o.(*icobject.Object).Tag().Type() {
+			case icstage.TypeEventEnable:
+				obj := icstage.ToEventEnable(o)
+				vInfo.SetEnable(obj.Target(), obj.Status())
+			case icstage.TypeEventDelegation, icstage.TypeEventDelegated:
+				vInfo.UpdateDelegated(icstage.ToEventVote(o).Votes())
+			case icstage.TypeEventDelegationV2:
+				vInfo.UpdateDelegated(icstage.ToEventDelegationV2(o).Delegated())
+			case icstage.TypeEventBond:
+				vInfo.UpdateBonded(icstage.ToEventVote(o).Votes())
+			}
+		}
+	}
+	return vInfo, nil
+}
+
+// nextVotedInfo returns voted information for the next term, where
+// temporarily disabled P-Reps are enabled again and P-Reps are ranked
+// with their bonded delegations.
+func (c *Calculator) nextVotedInfo(vInfo *votedInfo) (*votedInfo, map[string]*pRepEnable, error) {
+	bondRequirement := c.global.GetBondRequirement()
+	next := newVotedInfo(c.global.GetElectedPRepCount())
+	prepInfo := make(map[string]*pRepEnable)
+	for key, data := range vInfo.PReps() {
+		addr, err := common.NewAddress([]byte(key))
+		if err != nil {
+			return nil, nil, err
+		}
+		data.UpdateToWrite()
+		voted := data.Voted().Clone()
+		voted.UpdateBondedDelegation(bondRequirement)
+		next.AddVotedData(addr, newVotedData(voted))
+		if voted.Enable() {
+			prepInfo[key] = new(pRepEnable)
+		}
+	}
+	next.Sort()
+	next.UpdateTotalBondedDelegation()
+	return next, prepInfo, nil
+}
+
+func votesOf(delta map[string]*big.Int) (icstage.VoteList, error) {
+	votes, err := deltaToVotes(delta)
+	if err != nil {
+		return nil, scoreresult.UnknownFailureError.Wrap(err, "InvalidVotes")
+	}
+	return votes, nil
+}
+
+// ProjectReward returns the reward of the account expected for a term
+// following the current one, assuming that stake, delegations and bonds of
+// the account are changed to the given ones. Nil means the current one.
+// Rewards are calculated with the configuration of the current term, and
+// nothing is written to the database.
+func (es *ExtensionStateImpl) ProjectReward(
+	addr module.Address, stake *big.Int, ds icstate.Delegations, bonds icstate.Bonds,
+) (*RewardProjection, error) {
+	global, err := es.Front.GetGlobal()
+	if err != nil {
+		return nil, err
+	}
+	if global == nil {
+		return nil, errors.InvalidStateError.New("NoTermForReward")
+	}
+
+	account := es.State.GetAccountSnapshot(addr)
+	if account == nil {
+		account = icstate.GetEmptyAccountSnapshot()
+	}
+	if stake == nil {
+		stake = account.Stake()
+	}
+	if ds == nil {
+		ds = account.Delegations()
+	}
+	if bonds == nil {
+		bonds = account.Bonds()
+	}
+	using := new(big.Int).Add(ds.GetDelegationAmount(), account.Unbond())
+	for _, bond := range bonds {
+		using.Add(using, bond.Amount())
+		pb := es.State.GetPRepBaseByOwner(bond.To(), false)
+		if pb == nil {
+			return nil, scoreresult.InvalidParameterError.Errorf("PRep not found: %v", bond.To())
+		}
+		if !pb.BonderList().Contains(addr) {
+			return nil, scoreresult.InvalidParameterError.Errorf("%s is not in bonder List of %s", addr, bond.To())
+		}
+	}
+	if stake.Cmp(using) < 0 {
+		return nil, icmodule.IllegalArgumentError.Errorf("Not enough voting power")
+	}
+
+	c := &Calculator{
+		log:         es.Logger(),
+		startHeight: global.GetStartHeight(),
+		global:      global,
+		base:        es.Reward.GetSnapshot(),
+		stats:       newStatistics(),
+	}
+	vInfo, err := c.loadProjectedVotedInfo([]*icstage.Snapshot{
+		es.Back2.GetSnapshot(),
+		es.Back1.GetSnapshot(),
+		es.Front.GetSnapshot(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// apply the changes of the account
+	delegated, err := votesOf(account.Delegations().Delta(ds))
+	if err != nil {
+		return nil, err
+	}
+	vInfo.UpdateDelegated(delegated)
+	bonded, err := votesOf(account.Bonds().Delta(bonds))
+	if err != nil {
+		return nil, err
+	}
+	vInfo.UpdateBonded(bonded)
+
+	vInfo, prepInfo, err := c.nextVotedInfo(vInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	// a term from the offset -1 to the offset limit, like the calculator
+	// does for votes took place in the previous term.
+	from, to := -1, global.GetOffsetLimit()
+	p := &RewardProjection{
+		Period: to - from,
+		Voted:  new(big.Int),
+		Voting: new(big.Int),
+	}
+
+	multiplier, divider := varForVotedReward(global)
+	vInfo.CalculateReward(multiplier, divider, p.Period)
+	if prep := vInfo.GetPRepByAddress(addr); prep != nil {
+		p.Voted.Set(prep.IScore())
+	}
+
+	multiplier, divider = varForVotingReward(global, vInfo.TotalVoted())
+	if multiplier.Sign() == 0 || divider.Sign() == 0 {
+		return p, nil
+	}
+	delegating := icreward.NewDelegating()
+	votes, err := votesOf(icstate.Delegations(nil).Delta(ds))
+	if err != nil {
+		return nil, err
+	}
+	if err = delegating.ApplyVotes(votes); err != nil {
+		return nil, err
+	}
+	p.Voting.Add(p.Voting, c.votingReward(multiplier, divider, from, to, prepInfo, delegating.Iterator()))
+
+	bonding := icreward.NewBonding()
+	if votes, err = votesOf(icstate.Bonds(nil).Delta(bonds)); err != nil {
+		return nil, err
+	}
+	if err = bonding.ApplyVotes(votes); err != nil {
+		return nil, err
+	}
+	p.Voting.Add(p.Voting, c.votingReward(multiplier, divider, from, to, prepInfo, bonding.Iterator()))
+	return p, nil
+}
@@ -0,0 +1,129 @@
+/*
+ * Copyright 2021 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package iiss
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/db"
+	"github.com/icon-project/goloop/icon/icmodule"
+	"github.com/icon-project/goloop/icon/iiss/icreward"
+	"github.com/icon-project/goloop/icon/iiss/icstage"
+	"github.com/icon-project/goloop/icon/iiss/icstate"
+	"github.com/icon-project/goloop/module"
+)
+
+func TestExtensionStateImpl_ProjectReward(t *testing.T) {
+	prep1 := common.MustNewAddressFromString("hx1")
+	prep2 := common.MustNewAddressFromString("hx2")
+	user := common.MustNewAddressFromString("hx3")
+	other := common.MustNewAddressFromString("hx4")
+
+	es := NewExtensionSnapshot(db.NewMapDB(), nil).NewState(false).(*ExtensionStateImpl)
+	assert.NoError(t, es.Front.AddGlobalV2(icmodule.RevisionICON2R1, 100, 99,
+		big.NewInt(15552000000), big.NewInt(50), big.NewInt(50), big.NewInt(0), big.NewInt(0),
+		100, 0))
+
+	// P-Reps and votes of accounts at the last calculation
+	for _, v := range []struct {
+		addr      module.Address
+		delegated int64
+	}{{prep1, 3000}, {prep2, 1000}} {
+		voted := icreward.NewVoted()
+		voted.SetEnable(true)
+		voted.SetDelegated(big.NewInt(v.delegated))
+		assert.NoError(t, es.Reward.SetVoted(v.addr, voted))
+	}
+	for _, v := range []struct {
+		addr  module.Address
+		value int64
+	}{{user, 1000}, {other, 2000}} {
+		delegating := icreward.NewDelegating()
+		assert.NoError(t, delegating.ApplyVotes(icstage.VoteList{
+			icstage.NewVote(common.AddressToPtr(prep1), big.NewInt(v.value)),
+		}))
+		assert.NoError(t, es.Reward.SetDelegating(v.addr, delegating))
+
+		account := es.State.GetAccountState(v.addr)
+		assert.NoError(t, account.SetStake(big.NewInt(v.value)))
+		account.SetDelegation(icstate.Delegations{
+			icstate.NewDelegation(common.AddressToPtr(prep1), big.NewInt(v.value)),
+		})
+	}
+
+	// rewards calculated for a term without changes
+	global, err := es.Front.GetGlobal()
+	assert.NoError(t, err)
+	c := MakeCalculator(db.NewMapDB(), es.Front.GetSnapshot())
+	c.global = global
+	c.base = es.Reward.GetSnapshot()
+	c.temp = icreward.NewStateFromSnapshot(c.base)
+	c.stats = newStatistics()
+	assert.NoError(t, c.calculateVotedReward())
+	assert.NoError(t, c.calculateVotingReward())
+
+	p, err := es.ProjectReward(user, nil, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, p.Period)
+	assert.Zero(t, p.Voted.Sign())
+	iScore, err := c.temp.GetIScore(user)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, p.Voting.Sign())
+	assert.Equal(t, iScore.Value(), p.Voting)
+
+	p, err = es.ProjectReward(prep1, nil, nil, nil)
+	assert.NoError(t, err)
+	iScore, err = c.temp.GetIScore(prep1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, p.Voted.Sign())
+	assert.Equal(t, iScore.Value(), p.Voted)
+	assert.Zero(t, p.Voting.Sign())
+
+	// hypothetical changes of the account
+	p1, err := es.ProjectReward(user, nil, nil, nil)
+	assert.NoError(t, err)
+	p2, err := es.ProjectReward(user, nil, icstate.Delegations{
+		icstate.NewDelegation(common.AddressToPtr(prep2), big.NewInt(500)),
+	}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, p2.Voting.Sign())
+	assert.Equal(t, -1, p2.Voting.Cmp(p1.Voting))
+
+	p2, err = es.ProjectReward(user, big.NewInt(2000), icstate.Delegations{
+		icstate.NewDelegation(common.AddressToPtr(prep1), big.NewInt(2000)),
+	}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, p2.Voting.Cmp(p1.Voting))
+
+	_, err = es.ProjectReward(user, nil, icstate.Delegations{
+		icstate.NewDelegation(common.AddressToPtr(prep1), big.NewInt(2000)),
+	}, nil)
+	assert.Error(t, err)
+
+	// events of the current term are applied
+	_, _, err = es.Front.AddEventDelegation(10, other, icstage.VoteList{
+		icstage.NewVote(common.AddressToPtr(prep2), big.NewInt(5000)),
+	})
+	assert.NoError(t, err)
+	p2, err = es.ProjectReward(user, nil, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, -1, p2.Voting.Cmp(p1.Voting))
+}
@@ -0,0 +1,259 @@
+/*
+ * Copyright 2021 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package iiss
+
+import (
+	"encoding/binary"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/db"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/icon/icdb"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/scoreresult"
+)
+
+const (
+	RewardHistoryDefaultSize = 20
+	RewardHistoryMaxSize     = 100
+)
+
+// RewardRecord is the reward of an account calculated for a term which is
+// identified by the start height of the calculation.
+type RewardRecord struct {
+	StartHeight  int64
+	BlockProduce *big.Int
+	Voted        *big.Int
+	Voting       *big.Int
+}
+
+func (r *RewardRecord) Total() *big.Int {
+	total := new(big.Int).Add(r.BlockProduce, r.Voted)
+	return total.Add(total, r.Voting)
+}
+
+func (r *RewardRecord) add(reward *big.Int, t RewardType) {
+	switch t {
+	case TypeBlockProduce:
+		r.BlockProduce.Add(r.BlockProduce, reward)
+	case TypeVoted:
+		r.Voted.Add(r.Voted, reward)
+	case TypeVoting:
+		r.Voting.Add(r.Voting, reward)
+	}
+}
+
+func (r *RewardRecord) ToJSON() map[string]interface{} {
+	return map[string]interface{}{
+		"startHeight":  r.StartHeight,
+		"blockProduce": r.BlockProduce,
+		"voted":        r.Voted,
+		"voting":       r.Voting,
+		"iscore":       r.Total(),
+	}
+}
+
+func newRewardRecord(startHeight int64) *RewardRecord {
+	return &RewardRecord{
+		StartHeight:  startHeight,
+		BlockProduce: new(big.Int),
+		Voted:        new(big.Int),
+		Voting:       new(big.Int),
+	}
+}
+
+// rewardHistory collects rewards of accounts during a calculation.
+type rewardHistory struct {
+	startHeight int64
+	records     map[string]*RewardRecord
+}
+
+func (h *rewardHistory) add(addr module.Address, reward *big.Int, t RewardType) {
+	key := string(addr.Bytes())
+	r, ok := h.records[key]
+	if !ok {
+		r = newRewardRecord(h.startHeight)
+		h.records[key] = r
+	}
+	r.add(reward, t)
+}
+
+func newRewardHistory(startHeight int64) *rewardHistory {
+	return &rewardHistory{
+		startHeight: startHeight,
+		records:     make(map[string]*RewardRecord),
+	}
+}
+
+// rewardHistoryEntry is the record of an account kept in a checkpoint.
+type rewardHistoryEntry struct {
+	Address []byte
+	Record  *RewardRecord
+}
+
+// Bytes returns records collected so far, so that they can be kept in
+// a checkpoint. Records are ordered by the address.
+func (h *rewardHistory) Bytes() []byte {
+	keys := make([]string, 0, len(h.records))
+	for key := range h.records {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	entries := make([]rewardHistoryEntry, len(keys))
+	for i, key := range keys {
+		entries[i] = rewardHistoryEntry{[]byte(key), h.records[key]}
+	}
+	return codec.BC.MustMarshalToBytes(entries)
+}
+
+// loadRewardHistory returns the history with records kept in a checkpoint.
+func loadRewardHistory(startHeight int64, bs []byte) (*rewardHistory, error) {
+	var entries []rewardHistoryEntry
+	if _, err := codec.BC.UnmarshalFromBytes(bs, &entries); err != nil {
+		return nil, err
+	}
+	h := newRewardHistory(startHeight)
+	for _, e := range entries {
+		if e.Record == nil || e.Record.StartHeight != startHeight {
+			return nil, errors.InvalidStateError.Errorf(
+				"InvalidRewardRecord(addr=%x,height=%d)", e.Address, startHeight)
+		}
+		h.records[string(e.Address)] = e.Record
+	}
+	return h, nil
+}
+
+// rewardHistoryLock serializes updates of the history, because a calculator
+// may be replaced by another one for the same term while it's writing.
+var rewardHistoryLock sync.Mutex
+
+// Records of an account are stored in icdb.RewardHistory bucket with
+// the key composed of the address and the index of the record.
+// The number of records is stored with the address.
+func rewardHistoryKey(addr []byte, idx int) []byte {
+	key := make([]byte, len(addr)+4)
+	copy(key, addr)
+	binary.BigEndian.PutUint32(key[len(addr):], uint32(idx))
+	return key
+}
+
+func getRewardHistoryCount(bk db.Bucket, addr []byte) (int, error) {
+	bs, err := bk.Get(addr)
+	if err != nil || bs == nil {
+		return 0, err
+	}
+	var count int
+	if _, err := codec.BC.UnmarshalFromBytes(bs, &count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func getRewardRecord(bk db.Bucket, addr []byte, idx int) (*RewardRecord, error) {
+	bs, err := bk.Get(rewardHistoryKey(addr, idx))
+	if err != nil {
+		return nil, err
+	}
+	if bs == nil {
+		return nil, errors.NotFoundError.Errorf("NoRewardRecord(idx=%d)", idx)
+	}
+	r := new(RewardRecord)
+	if _, err := codec.BC.UnmarshalFromBytes(bs, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// appendRewardRecord appends the record to the history of the account.
+// Records for the same or later terms are replaced, so the history is kept
+// in order even if the calculation for the term is done again.
+func appendRewardRecord(bk db.Bucket, addr []byte, r *RewardRecord) error {
+	count, err := getRewardHistoryCount(bk, addr)
+	if err != nil {
+		return err
+	}
+	for count > 0 {
+		last, err := getRewardRecord(bk, addr, count-1)
+		if err != nil {
+			return err
+		}
+		if last.StartHeight < r.StartHeight {
+			break
+		}
+		count -= 1
+	}
+	bs, err := codec.BC.MarshalToBytes(r)
+	if err != nil {
+		return err
+	}
+	if err := bk.Set(rewardHistoryKey(addr, count), bs); err != nil {
+		return err
+	}
+	return bk.Set(addr, codec.BC.MustMarshalToBytes(count+1))
+}
+
+func (h *rewardHistory) flush(database db.Database) error {
+	bk, err := database.GetBucket(icdb.RewardHistory)
+	if err != nil {
+		return err
+	}
+	rewardHistoryLock.Lock()
+	defer rewardHistoryLock.Unlock()
+
+	for addr, r := range h.records {
+		if r.Total().Sign() == 0 {
+			continue
+		}
+		if err := appendRewardRecord(bk, []byte(addr), r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetRewardHistory returns records of the account from start index with
+// the total number of records. Records are ordered by the start height.
+func GetRewardHistory(database db.Database, addr module.Address, start, size int) ([]*RewardRecord, int, error) {
+	if start < 0 || size < 0 || size > RewardHistoryMaxSize {
+		return nil, 0, scoreresult.InvalidParameterError.Errorf(
+			"InvalidRange(start=%d,size=%d)", start, size)
+	}
+	if size == 0 {
+		size = RewardHistoryDefaultSize
+	}
+	bk, err := database.GetBucket(icdb.RewardHistory)
+	if err != nil {
+		return nil, 0, err
+	}
+	key := addr.Bytes()
+	count, err := getRewardHistoryCount(bk, key)
+	if err != nil {
+		return nil, 0, err
+	}
+	records := make([]*RewardRecord, 0, size)
+	for idx := start; idx < count && len(records) < size; idx++ {
+		r, err := getRewardRecord(bk, key, idx)
+		if err != nil {
+			return nil, 0, err
+		}
+		records = append(records, r)
+	}
+	return records, count, nil
+}
@@ -0,0 +1,112 @@
+/*
+ * Copyright 2021 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package iiss
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/db"
+)
+
+func TestRewardHistory(t *testing.T) {
+	database := db.NewMapDB()
+	addr1 := common.MustNewAddressFromString("hx1")
+	addr2 := common.MustNewAddressFromString("hx2")
+
+	for _, height := range []int64{100, 200, 300} {
+		h := newRewardHistory(height)
+		h.add(addr1, big.NewInt(10), TypeBlockProduce)
+		h.add(addr1, big.NewInt(20), TypeVoted)
+		h.add(addr1, big.NewInt(height), TypeVoting)
+		h.add(addr2, big.NewInt(0), TypeVoting)
+		assert.NoError(t, h.flush(database))
+	}
+
+	records, total, err := GetRewardHistory(database, addr1, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, total)
+	if assert.Len(t, records, 3) {
+		r := records[2]
+		assert.Equal(t, int64(300), r.StartHeight)
+		assert.Equal(t, int64(10), r.BlockProduce.Int64())
+		assert.Equal(t, int64(20), r.Voted.Int64())
+		assert.Equal(t, int64(300), r.Voting.Int64())
+		assert.Equal(t, int64(330), r.Total().Int64())
+	}
+
+	// no record for zero reward
+	records, total, err = GetRewardHistory(database, addr2, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, total)
+	assert.Len(t, records, 0)
+
+	// pagination
+	records, total, err = GetRewardHistory(database, addr1, 1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, total)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, int64(200), records[0].StartHeight)
+	}
+	records, _, err = GetRewardHistory(database, addr1, 5, 1)
+	assert.NoError(t, err)
+	assert.Len(t, records, 0)
+
+	_, _, err = GetRewardHistory(database, addr1, -1, 1)
+	assert.Error(t, err)
+	_, _, err = GetRewardHistory(database, addr1, 0, RewardHistoryMaxSize+1)
+	assert.Error(t, err)
+
+	// calculation for the same term replaces later records
+	h := newRewardHistory(200)
+	h.add(addr1, big.NewInt(5), TypeVoting)
+	assert.NoError(t, h.flush(database))
+
+	records, total, err = GetRewardHistory(database, addr1, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, total)
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, int64(100), records[0].StartHeight)
+		assert.Equal(t, int64(200), records[1].StartHeight)
+		assert.Equal(t, int64(5), records[1].Total().Int64())
+	}
+}
+
+func TestCalculator_updateIScoreWithHistory(t *testing.T) {
+	database := db.NewMapDB()
+	addr := common.MustNewAddressFromString("hx1")
+
+	c := MakeCalculator(database, nil)
+	c.stats = newStatistics()
+	c.history = newRewardHistory(100)
+
+	assert.NoError(t, c.updateIScore(addr, big.NewInt(10), TypeBlockProduce))
+	assert.NoError(t, c.updateIScore(addr, big.NewInt(20), TypeVoting))
+	assert.NoError(t, c.updateIScore(addr, big.NewInt(30), TypeVoting))
+	assert.NoError(t, c.history.flush(database))
+
+	records, _, err := GetRewardHistory(database, addr, 0, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, int64(10), records[0].BlockProduce.Int64())
+		assert.Equal(t, int64(0), records[0].Voted.Int64())
+		assert.Equal(t, int64(50), records[0].Voting.Int64())
+	}
+}
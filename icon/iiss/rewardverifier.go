@@ -0,0 +1,711 @@
+/*
+ * Copyright 2021 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package iiss
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/containerdb"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/common/intconv"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/icon/icmodule"
+	"github.com/icon-project/goloop/icon/iiss/icobject"
+	"github.com/icon-project/goloop/icon/iiss/icreward"
+	"github.com/icon-project/goloop/icon/iiss/icstage"
+	"github.com/icon-project/goloop/icon/iiss/icstate"
+	"github.com/icon-project/goloop/icon/iiss/icutils"
+	"github.com/icon-project/goloop/module"
+)
+
+// RewardDiscrepancy is an account whose I-Score in the calculation result
+// differs from the recomputed one.
+type RewardDiscrepancy struct {
+	Address  module.Address
+	Expected *big.Int
+	Actual   *big.Int
+}
+
+func (d *RewardDiscrepancy) ToJSON() map[string]interface{} {
+	return map[string]interface{}{
+		"address":  d.Address,
+		"expected": d.Expected,
+		"actual":   d.Actual,
+		"diff":     new(big.Int).Sub(d.Actual, d.Expected),
+	}
+}
+
+// RewardVerification is the result of VerifyRewards.
+type RewardVerification struct {
+	StartHeight   int64
+	Accounts      int
+	BlockProduce  *big.Int
+	Voted         *big.Int
+	Voting        *big.Int
+	Discrepancies []*RewardDiscrepancy
+}
+
+func (r *RewardVerification) ToJSON() map[string]interface{} {
+	ds := make([]interface{}, len(r.Discrepancies))
+	for i, d := range r.Discrepancies {
+		ds[i] = d.ToJSON()
+	}
+	return map[string]interface{}{
+		"startHeight":   r.StartHeight,
+		"accounts":      r.Accounts,
+		"blockProduce":  r.BlockProduce,
+		"voted":         r.Voted,
+		"voting":        r.Voting,
+		"discrepancies": ds,
+	}
+}
+
+type rewardEvent struct {
+	offset int
+	obj    *icobject.Object
+}
+
+// rewardPRep is the status of a P-Rep during the term.
+type rewardPRep struct {
+	enable           bool
+	voted            *big.Int
+	delegated        *big.Int
+	bondedDelegation *big.Int
+	inBase           bool
+}
+
+// votingChange is a change of the votes of an account at the offset.
+type votingChange struct {
+	offset int
+	votes  icstage.VoteList
+}
+
+// rewardVerifier recomputes the rewards of a term from the raw events in the
+// back snapshot. It doesn't share the temporary state of Calculator and
+// replays the events of each account on its own.
+type rewardVerifier struct {
+	log     log.Logger
+	back    *icstage.Snapshot
+	base    *icreward.Snapshot
+	global  icstage.Global
+	events  []*rewardEvent
+	rewards map[string]*big.Int
+	stats   *statistics
+}
+
+func (v *rewardVerifier) addReward(key string, amount *big.Int, t RewardType) {
+	if amount.Sign() == 0 {
+		return
+	}
+	if reward, ok := v.rewards[key]; ok {
+		reward.Add(reward, amount)
+	} else {
+		v.rewards[key] = new(big.Int).Set(amount)
+	}
+	switch t {
+	case TypeBlockProduce:
+		v.stats.IncreaseBlockProduce(amount)
+	case TypeVoted:
+		v.stats.IncreaseVoted(amount)
+	case TypeVoting:
+		v.stats.IncreaseVoting(amount)
+	}
+}
+
+func (v *rewardVerifier) loadEvents() error {
+	for iter := v.back.Filter(icstage.EventKey.Build()); iter.Has(); iter.Next() {
+		o, key, err := iter.Get()
+		if err != nil {
+			return err
+		}
+		keySplit, err := containerdb.SplitKeys(key)
+		if err != nil {
+			return err
+		}
+		v.events = append(v.events, &rewardEvent{
+			offset: int(intconv.BytesToInt64(keySplit[1])),
+			obj:    o.(*icobject.Object),
+		})
+	}
+	return nil
+}
+
+// loadPReps returns the status of P-Reps at the beginning of the term.
+func (v *rewardVerifier) loadPReps() (map[string]*rewardPRep, error) {
+	bondRequirement := v.global.GetBondRequirement()
+	preps := make(map[string]*rewardPRep)
+	for iter := v.base.Filter(icreward.VotedKey.Build()); iter.Has(); iter.Next() {
+		o, key, err := iter.Get()
+		if err != nil {
+			return nil, err
+		}
+		keySplit, err := containerdb.SplitKeys(key)
+		if err != nil {
+			return nil, err
+		}
+		voted := icreward.ToVoted(o).Clone()
+		voted.UpdateBondedDelegation(bondRequirement)
+		preps[string(keySplit[1])] = &rewardPRep{
+			enable:           voted.Enable(),
+			voted:            voted.GetVotedAmount(),
+			delegated:        voted.Delegated(),
+			bondedDelegation: voted.BondedDelegation(),
+			inBase:           true,
+		}
+	}
+	return preps, nil
+}
+
+func getRewardPRep(preps map[string]*rewardPRep, key string) *rewardPRep {
+	p, ok := preps[key]
+	if !ok {
+		p = &rewardPRep{
+			voted:            new(big.Int),
+			delegated:        new(big.Int),
+			bondedDelegation: new(big.Int),
+		}
+		preps[key] = p
+	}
+	return p
+}
+
+// electedPReps returns P-Reps getting voted reward in the term. They are
+// ranked by bonded delegation, delegation and address at the beginning of
+// the term.
+func electedPReps(preps map[string]*rewardPRep, count int) map[string]bool {
+	keys := make([]string, 0, len(preps))
+	for key, p := range preps {
+		if p.inBase {
+			keys = append(keys, key)
+		}
+	}
+	value := func(p *rewardPRep, v *big.Int) *big.Int {
+		if p.enable {
+			return v
+		}
+		return new(big.Int)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		pi, pj := preps[keys[i]], preps[keys[j]]
+		if c := value(pi, pi.bondedDelegation).Cmp(value(pj, pj.bondedDelegation)); c != 0 {
+			return c > 0
+		}
+		if c := value(pi, pi.delegated).Cmp(value(pj, pj.delegated)); c != 0 {
+			return c > 0
+		}
+		return bytes.Compare([]byte(keys[i]), []byte(keys[j])) > 0
+	})
+	if len(keys) > count {
+		keys = keys[:count]
+	}
+	elected := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		elected[key] = true
+	}
+	return elected
+}
+
+func (v *rewardVerifier) computeBlockProduce() error {
+	if v.global.GetIISSVersion() != icstate.IISSVersion2 {
+		return nil
+	}
+	g := v.global.GetV1()
+	variable := varForBlockProduceReward(g.GetIRep(), g.GetMainRepCount())
+	if variable.Sign() == 0 {
+		return nil
+	}
+	validators, err := v.back.GetValidators()
+	if err != nil {
+		return err
+	}
+	for iter := v.back.Filter(icstage.BlockProduceKey.Build()); iter.Has(); iter.Next() {
+		o, _, err := iter.Get()
+		if err != nil {
+			return err
+		}
+		bp := icstage.ToBlockProduce(o)
+		proposer := bp.ProposerIndex()
+		mask := bp.VoteMask()
+		if proposer >= len(validators) || mask.BitLen() > len(validators) {
+			return errors.InvalidStateError.Errorf("InvalidBlockProduce(%+v)", bp)
+		}
+		v.addReward(icutils.ToKey(validators[proposer]), variable, TypeBlockProduce)
+
+		// the proposer doesn't get the reward for validation
+		voters := make([]module.Address, 0, mask.BitLen())
+		for i := 0; i < mask.BitLen(); i++ {
+			if i != proposer && mask.Bit(i) == 1 {
+				voters = append(voters, validators[i])
+			}
+		}
+		if len(voters) > 0 {
+			share := new(big.Int).Div(variable, big.NewInt(int64(len(voters))))
+			for _, voter := range voters {
+				v.addReward(icutils.ToKey(voter), share, TypeBlockProduce)
+			}
+		}
+	}
+	return nil
+}
+
+// computeVoted computes P-Rep voted reward. The reward is shared by elected
+// P-Reps in proportion to bonded delegation at the beginning of the term for
+// each period split by reward configuration changes and disabled P-Reps.
+func (v *rewardVerifier) computeVoted() error {
+	multiplier, divider := varForVotedReward(v.global)
+	preps, err := v.loadPReps()
+	if err != nil {
+		return err
+	}
+	elected := electedPReps(preps, v.global.GetElectedPRepCount())
+	totalBondedDelegation := func() *big.Int {
+		total := new(big.Int)
+		for key := range elected {
+			if p := preps[key]; p.enable {
+				total.Add(total, p.bondedDelegation)
+			}
+		}
+		return total
+	}
+	total := totalBondedDelegation()
+	rewards := make(map[string]*big.Int)
+	distribute := func(period int) {
+		if multiplier.Sign() == 0 || divider.Sign() == 0 || total.Sign() == 0 || period == 0 {
+			return
+		}
+		for key := range elected {
+			p := preps[key]
+			if !p.enable {
+				continue
+			}
+			reward := new(big.Int).Mul(multiplier, big.NewInt(int64(period)))
+			reward.Mul(reward, p.bondedDelegation)
+			reward.Div(reward, divider)
+			reward.Div(reward, total)
+			if r, ok := rewards[key]; ok {
+				r.Add(r, reward)
+			} else {
+				rewards[key] = reward
+			}
+		}
+	}
+
+	from := -1
+	for _, e := range v.events {
+		switch e.obj.Tag().Type() {
+		case icstage.TypeEventEnable:
+			event := icstage.ToEventEnable(e.obj)
+			key := icutils.ToKey(event.Target())
+			status := event.Status()
+			if !status.IsEnabled() && elected[key] {
+				distribute(e.offset - from)
+				from = e.offset
+				getRewardPRep(preps, key).enable = false
+				if v.global.GetRevision() >= icmodule.RevisionFixTotalDelegated || !status.IsDisabledTemporarily() {
+					total = totalBondedDelegation()
+				}
+			} else {
+				getRewardPRep(preps, key).enable = status.IsEnabled()
+			}
+		case icstage.TypeEventVotedReward:
+			distribute(e.offset - from)
+			from = e.offset
+		}
+	}
+	if limit := v.global.GetOffsetLimit(); from < limit {
+		distribute(limit - from)
+	}
+	for key, reward := range rewards {
+		v.addReward(key, reward, TypeVoted)
+	}
+	return nil
+}
+
+// votingPeriod is the range of offsets where a P-Rep gives voting reward.
+// Zero means no limit.
+type votingPeriod struct {
+	start int
+	end   int
+}
+
+// computeVoting computes ICONist voting reward. The votes of each account are
+// replayed with its events, and each vote gets the reward for the blocks
+// while it's held and the P-Rep is active.
+func (v *rewardVerifier) computeVoting() error {
+	preps, err := v.loadPReps()
+	if err != nil {
+		return err
+	}
+	periods := make(map[string]*votingPeriod)
+	total := new(big.Int)
+	for key, p := range preps {
+		if p.enable {
+			periods[key] = new(votingPeriod)
+			total.Add(total, p.voted)
+		}
+	}
+	maxTotal := new(big.Int).Set(total)
+	updateVoted := func(votes icstage.VoteList) {
+		for _, vote := range votes {
+			p := getRewardPRep(preps, icutils.ToKey(vote.To()))
+			p.voted = new(big.Int).Add(p.voted, vote.Amount())
+			if p.enable {
+				total.Add(total, vote.Amount())
+			}
+		}
+	}
+	changes := map[int]map[string][]*votingChange{
+		icreward.TypeDelegating: make(map[string][]*votingChange),
+		icreward.TypeBonding:    make(map[string][]*votingChange),
+	}
+	addChange := func(_type int, from module.Address, offset int, votes icstage.VoteList) {
+		key := icutils.ToKey(from)
+		changes[_type][key] = append(changes[_type][key], &votingChange{offset, votes})
+	}
+
+	for _, e := range v.events {
+		switch e.obj.Tag().Type() {
+		case icstage.TypeEventEnable:
+			event := icstage.ToEventEnable(e.obj)
+			key := icutils.ToKey(event.Target())
+			status := event.Status()
+			period, ok := periods[key]
+			if !ok {
+				period = new(votingPeriod)
+				periods[key] = period
+			}
+			if status.IsEnabled() {
+				period.start = e.offset
+			} else if status.IsDisabledPermanently() {
+				period.end = e.offset
+			}
+			p := getRewardPRep(preps, key)
+			if p.enable != status.IsEnabled() {
+				if status.IsEnabled() {
+					total.Add(total, p.voted)
+				} else {
+					total.Sub(total, p.voted)
+				}
+			}
+			p.enable = status.IsEnabled()
+		case icstage.TypeEventDelegation:
+			event := icstage.ToEventVote(e.obj)
+			addChange(icreward.TypeDelegating, event.From(), e.offset, event.Votes())
+			updateVoted(event.Votes())
+		case icstage.TypeEventBond:
+			event := icstage.ToEventVote(e.obj)
+			addChange(icreward.TypeBonding, event.From(), e.offset, event.Votes())
+			updateVoted(event.Votes())
+		case icstage.TypeEventDelegated:
+			updateVoted(icstage.ToEventVote(e.obj).Votes())
+		case icstage.TypeEventDelegationV2:
+			event := icstage.ToEventDelegationV2(e.obj)
+			addChange(icreward.TypeDelegating, event.From(), e.offset, event.Delegating())
+			updateVoted(event.Delegated())
+		}
+		if total.Cmp(maxTotal) > 0 {
+			maxTotal.Set(total)
+		}
+	}
+
+	multiplier, divider := varForVotingReward(v.global, maxTotal)
+	if multiplier.Sign() == 0 || divider.Sign() == 0 {
+		return nil
+	}
+	for _, _type := range []int{icreward.TypeDelegating, icreward.TypeBonding} {
+		prefix := icreward.DelegatingKey.Build()
+		if _type == icreward.TypeBonding {
+			prefix = icreward.BondingKey.Build()
+		}
+		accounts := changes[_type]
+		for iter := v.base.Filter(prefix); iter.Has(); iter.Next() {
+			o, key, err := iter.Get()
+			if err != nil {
+				return err
+			}
+			keySplit, err := containerdb.SplitKeys(key)
+			if err != nil {
+				return err
+			}
+			addr := string(keySplit[1])
+			reward, err := v.votingRewardOf(multiplier, divider, periods, toVoting(_type, o), accounts[addr])
+			if err != nil {
+				return err
+			}
+			v.addReward(addr, reward, TypeVoting)
+			delete(accounts, addr)
+		}
+		// accounts voting first in the term
+		for addr, cs := range accounts {
+			reward, err := v.votingRewardOf(multiplier, divider, periods, nil, cs)
+			if err != nil {
+				return err
+			}
+			v.addReward(addr, reward, TypeVoting)
+		}
+	}
+	return v.addBugDisabledPRep()
+}
+
+// votingRewardOf returns the voting reward of an account with the votes at
+// the beginning of the term and the changes in the term.
+func (v *rewardVerifier) votingRewardOf(
+	multiplier, divider *big.Int, periods map[string]*votingPeriod,
+	initial icreward.Voting, changes []*votingChange,
+) (*big.Int, error) {
+	votes := make(map[string]*big.Int)
+	if initial != nil {
+		for iter := initial.Iterator(); iter.Has(); iter.Next() {
+			voting, err := iter.Get()
+			if err != nil {
+				return nil, err
+			}
+			votes[icutils.ToKey(voting.To())] = voting.Amount()
+		}
+	}
+	limit := v.global.GetOffsetLimit()
+	iissVersion := v.global.GetIISSVersion()
+
+	// new votes take effect from the next block
+	reward := new(big.Int)
+	from := -1
+	for _, c := range changes {
+		if iissVersion == icstate.IISSVersion2 {
+			// ICON1 adds the reward until the end of the term and subtracts
+			// the reward after the change
+			reward.Add(reward, v.votesReward(multiplier, divider, periods, votes, from, limit))
+			reward.Sub(reward, v.votesReward(multiplier, divider, periods, votes, c.offset, limit))
+		} else {
+			reward.Add(reward, v.votesReward(multiplier, divider, periods, votes, from, c.offset))
+		}
+		for _, vote := range c.votes {
+			key := icutils.ToKey(vote.To())
+			amount := new(big.Int).Set(vote.Amount())
+			if prev, ok := votes[key]; ok {
+				amount.Add(amount, prev)
+			}
+			switch amount.Sign() {
+			case -1:
+				return nil, errors.InvalidStateError.Errorf(
+					"NegativeVote(to=%s,amount=%s,offset=%d)", vote.To(), amount, c.offset)
+			case 0:
+				delete(votes, key)
+			default:
+				votes[key] = amount
+			}
+		}
+		from = c.offset
+	}
+	reward.Add(reward, v.votesReward(multiplier, divider, periods, votes, from, limit))
+	return reward, nil
+}
+
+// votesReward returns the reward of the votes for the blocks in (from, to].
+func (v *rewardVerifier) votesReward(
+	multiplier, divider *big.Int, periods map[string]*votingPeriod,
+	votes map[string]*big.Int, from, to int,
+) *big.Int {
+	total := new(big.Int)
+	checkMinVoting := v.global.GetIISSVersion() == icstate.IISSVersion2
+	for key, amount := range votes {
+		if checkMinVoting && amount.Cmp(BigIntMinDelegation) < 0 {
+			continue
+		}
+		p, ok := periods[key]
+		if !ok {
+			continue
+		}
+		s, e := from, to
+		if p.start != 0 && p.start > s {
+			s = p.start
+		}
+		if p.end != 0 && p.end < e {
+			e = p.end
+		}
+		if e <= s {
+			continue
+		}
+		reward := new(big.Int).Mul(multiplier, amount)
+		reward.Mul(reward, big.NewInt(int64(e-s)))
+		reward.Div(reward, divider)
+		total.Add(total, reward)
+	}
+	return total
+}
+
+// addBugDisabledPRep adds voting reward which ICON1 gave for the delegation
+// to disabled P-Reps in the previous term.
+func (v *rewardVerifier) addBugDisabledPRep() error {
+	revision := v.global.GetRevision()
+	if v.global.GetIISSVersion() != icstate.IISSVersion2 ||
+		revision < icmodule.RevisionDecentralize || revision >= icmodule.RevisionFixBugDisabledPRep {
+		return nil
+	}
+	for iter := v.base.Filter(icreward.BugDisabledPRepKey.Build()); iter.Has(); iter.Next() {
+		o, key, err := iter.Get()
+		if err != nil {
+			return err
+		}
+		keySplit, err := containerdb.SplitKeys(key)
+		if err != nil {
+			return err
+		}
+		v.addReward(string(keySplit[1]), icreward.ToBugDisabledPRep(o).Value(), TypeVoting)
+	}
+	return nil
+}
+
+// expectedIScores returns I-Score of accounts after the calculation.
+func (v *rewardVerifier) expectedIScores() (map[string]*big.Int, error) {
+	iScores := make(map[string]*big.Int)
+	for iter := v.base.Filter(icreward.IScoreKey.Build()); iter.Has(); iter.Next() {
+		o, key, err := iter.Get()
+		if err != nil {
+			return nil, err
+		}
+		keySplit, err := containerdb.SplitKeys(key)
+		if err != nil {
+			return nil, err
+		}
+		iScores[string(keySplit[1])] = new(big.Int).Set(icreward.ToIScore(o).Value())
+	}
+	get := func(key string) *big.Int {
+		value, ok := iScores[key]
+		if !ok {
+			value = new(big.Int)
+			iScores[key] = value
+		}
+		return value
+	}
+	for iter := v.back.Filter(icstage.IScoreClaimKey.Build()); iter.Has(); iter.Next() {
+		o, key, err := iter.Get()
+		if err != nil {
+			return nil, err
+		}
+		keySplit, err := containerdb.SplitKeys(key)
+		if err != nil {
+			return nil, err
+		}
+		value := get(string(keySplit[1]))
+		value.Sub(value, icstage.ToIScoreClaim(o).Value())
+	}
+	for key, reward := range v.rewards {
+		value := get(key)
+		value.Add(value, reward)
+	}
+	return iScores, nil
+}
+
+func (v *rewardVerifier) compare(result *icreward.Snapshot) (*RewardVerification, error) {
+	expected, err := v.expectedIScores()
+	if err != nil {
+		return nil, err
+	}
+	rv := &RewardVerification{
+		StartHeight:  v.global.GetStartHeight(),
+		BlockProduce: v.stats.BlockProduce(),
+		Voted:        v.stats.Voted(),
+		Voting:       v.stats.Voting(),
+	}
+	check := func(key string, exp, actual *big.Int) error {
+		rv.Accounts += 1
+		if exp.Cmp(actual) == 0 {
+			return nil
+		}
+		addr, err := common.NewAddress([]byte(key))
+		if err != nil {
+			return err
+		}
+		rv.Discrepancies = append(rv.Discrepancies, &RewardDiscrepancy{addr, exp, actual})
+		return nil
+	}
+	for iter := result.Filter(icreward.IScoreKey.Build()); iter.Has(); iter.Next() {
+		o, key, err := iter.Get()
+		if err != nil {
+			return nil, err
+		}
+		keySplit, err := containerdb.SplitKeys(key)
+		if err != nil {
+			return nil, err
+		}
+		addr := string(keySplit[1])
+		exp, ok := expected[addr]
+		if !ok {
+			exp = new(big.Int)
+		}
+		delete(expected, addr)
+		if err = check(addr, exp, icreward.ToIScore(o).Value()); err != nil {
+			return nil, err
+		}
+	}
+	for addr, exp := range expected {
+		if exp.Sign() == 0 {
+			continue
+		}
+		if err = check(addr, exp, new(big.Int)); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(rv.Discrepancies, func(i, j int) bool {
+		return bytes.Compare(rv.Discrepancies[i].Address.Bytes(), rv.Discrepancies[j].Address.Bytes()) < 0
+	})
+	return rv, nil
+}
+
+// VerifyRewards recomputes the rewards of the term with the events in back and
+// the reward state in base, and compares I-Score of each account with result,
+// the reward state calculated for the term.
+func VerifyRewards(back *icstage.Snapshot, base, result *icreward.Snapshot, logger log.Logger) (*RewardVerification, error) {
+	global, err := back.GetGlobal()
+	if err != nil {
+		return nil, err
+	}
+	if global == nil {
+		return nil, errors.InvalidStateError.New("NoGlobalForCalculation")
+	}
+	v := &rewardVerifier{
+		log:     logger,
+		back:    back,
+		base:    base,
+		global:  global,
+		rewards: make(map[string]*big.Int),
+		stats:   newStatistics(),
+	}
+	v.log.Infof("Verify rewards of %d", global.GetStartHeight())
+	if err = v.loadEvents(); err != nil {
+		return nil, err
+	}
+	if err = v.computeBlockProduce(); err != nil {
+		return nil, err
+	}
+	if err = v.computeVoted(); err != nil {
+		return nil, err
+	}
+	if err = v.computeVoting(); err != nil {
+		return nil, err
+	}
+	rv, err := v.compare(result)
+	if err != nil {
+		return nil, err
+	}
+	v.log.Infof("Verified rewards of %d: accounts=%d discrepancies=%d",
+		rv.StartHeight, rv.Accounts, len(rv.Discrepancies))
+	return rv, nil
+}
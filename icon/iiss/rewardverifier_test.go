@@ -0,0 +1,133 @@
+/*
+ * Copyright 2021 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package iiss
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/db"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/icon/icmodule"
+	"github.com/icon-project/goloop/icon/iiss/icreward"
+	"github.com/icon-project/goloop/icon/iiss/icstage"
+	"github.com/icon-project/goloop/icon/iiss/icstate"
+	"github.com/icon-project/goloop/icon/iiss/icutils"
+)
+
+func TestVerifyRewards(t *testing.T) {
+	database := db.NewMapDB()
+	startHeight := int64(1000)
+	preps := []*common.Address{
+		common.MustNewAddressFromString("hx1"),
+		common.MustNewAddressFromString("hx2"),
+		common.MustNewAddressFromString("hx3"),
+		common.MustNewAddressFromString("hx4"),
+	}
+	users := []*common.Address{
+		common.MustNewAddressFromString("hx11"),
+		common.MustNewAddressFromString("hx12"),
+		common.MustNewAddressFromString("hx13"),
+		common.MustNewAddressFromString("hx14"),
+	}
+	vote := func(to *common.Address, amount int) *icstage.Vote {
+		return icstage.NewVote(to, icutils.ToLoop(amount))
+	}
+
+	// reward state at the beginning of the term
+	rs := icreward.NewState(database, nil)
+	delegations := map[*common.Address]icstate.Delegations{
+		users[0]: {
+			icstate.NewDelegation(preps[0], icutils.ToLoop(2000)),
+			icstate.NewDelegation(preps[1], icutils.ToLoop(2000)),
+		},
+		users[1]: {
+			icstate.NewDelegation(preps[0], icutils.ToLoop(1000)),
+			icstate.NewDelegation(preps[2], icutils.ToLoop(1000)),
+		},
+		users[2]: {
+			icstate.NewDelegation(preps[3], icutils.ToLoop(500)),
+		},
+	}
+	for addr, ds := range delegations {
+		delegating := icreward.NewDelegating()
+		delegating.Delegations = ds
+		assert.NoError(t, rs.SetDelegating(addr, delegating))
+	}
+	for i, amount := range []int{3000, 2000, 1000, 500} {
+		voted := icreward.NewVoted()
+		voted.SetEnable(true)
+		voted.SetDelegated(icutils.ToLoop(amount))
+		assert.NoError(t, rs.SetVoted(preps[i], voted))
+	}
+	assert.NoError(t, rs.SetIScore(users[0], icreward.NewIScore(big.NewInt(1000))))
+	base := rs.GetSnapshot()
+
+	// events of the term
+	ss := icstage.NewState(database)
+	assert.NoError(t, ss.AddGlobalV2(icmodule.RevisionICON2R1, startHeight, 99,
+		big.NewInt(15552000000), big.NewInt(50), big.NewInt(50), big.NewInt(0), big.NewInt(0), 3, 0))
+	changes := icstage.VoteList{vote(preps[1], -1000), vote(preps[2], 1000)}
+	_, _, err := ss.AddEventDelegationV2(10, users[0], changes, changes)
+	assert.NoError(t, err)
+	changes = icstage.VoteList{vote(preps[0], 700)}
+	_, _, err = ss.AddEventDelegationV2(40, users[3], changes, changes)
+	assert.NoError(t, err)
+	_, err = ss.AddEventEnable(50, preps[2], icstage.ESDisablePermanent)
+	assert.NoError(t, err)
+	_, err = ss.AddIScoreClaim(users[0], big.NewInt(500))
+	assert.NoError(t, err)
+	back := ss.GetSnapshot()
+
+	c := NewCalculator(database, back, base, nil, log.New())
+	assert.NoError(t, c.WaitResult(startHeight))
+	result := c.Result()
+
+	rv, err := VerifyRewards(back, base, result, log.New())
+	assert.NoError(t, err)
+	assert.Equal(t, startHeight, rv.StartHeight)
+	assert.Equal(t, 0, c.stats.Voted().Cmp(rv.Voted))
+	assert.Equal(t, 0, c.stats.Voting().Cmp(rv.Voting))
+	assert.True(t, rv.Voted.Sign() > 0)
+	assert.True(t, rv.Voting.Sign() > 0)
+	// preps[3] is not elected, so it has no reward
+	assert.Equal(t, len(preps)-1+len(users), rv.Accounts)
+	assert.Empty(t, rv.Discrepancies)
+
+	// changed I-Score in the result is reported
+	tampered := result.NewState()
+	is, err := tampered.GetIScore(users[1])
+	assert.NoError(t, err)
+	value := new(big.Int).Add(is.Value(), big.NewInt(1))
+	assert.NoError(t, tampered.SetIScore(users[1], icreward.NewIScore(value)))
+
+	rv, err = VerifyRewards(back, base, tampered.GetSnapshot(), log.New())
+	assert.NoError(t, err)
+	if assert.Len(t, rv.Discrepancies, 1) {
+		d := rv.Discrepancies[0]
+		assert.True(t, users[1].Equal(d.Address))
+		assert.Equal(t, 0, is.Value().Cmp(d.Expected))
+		assert.Equal(t, 0, value.Cmp(d.Actual))
+	}
+
+	// back without global can't be verified
+	_, err = VerifyRewards(icstage.NewSnapshot(database, nil), base, result, log.New())
+	assert.Error(t, err)
+}
@@ -0,0 +1,259 @@
+/*
+ * Copyright 2021 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package iiss
+
+import (
+	"math/big"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/intconv"
+	"github.com/icon-project/goloop/icon/icmodule"
+	"github.com/icon-project/goloop/icon/iiss/icstate"
+	"github.com/icon-project/goloop/icon/iiss/icutils"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/scoreresult"
+	"github.com/icon-project/goloop/service/state"
+)
+
+// addDelegationAmount returns a copy of the delegations with the amount added
+// to the delegation to the P-Rep. A delegation of zero amount is removed.
+func addDelegationAmount(ds icstate.Delegations, prep module.Address, amount *big.Int) icstate.Delegations {
+	nds := make(icstate.Delegations, 0, len(ds)+1)
+	found := false
+	for _, d := range ds {
+		if d.To().Equal(prep) {
+			found = true
+			value := new(big.Int).Add(d.Amount(), amount)
+			if value.Sign() > 0 {
+				nds = append(nds, icstate.NewDelegation(d.Address, value))
+			}
+		} else {
+			nds = append(nds, d.Clone())
+		}
+	}
+	if !found && amount.Sign() > 0 {
+		nds = append(nds, icstate.NewDelegation(common.AddressToPtr(prep), amount))
+	}
+	return nds
+}
+
+func getDelegationAmount(ds icstate.Delegations, prep module.Address) *big.Int {
+	for _, d := range ds {
+		if d.To().Equal(prep) {
+			return d.Amount()
+		}
+	}
+	return new(big.Int)
+}
+
+// checkStakePositionLock returns an error if the delegations don't cover the
+// positions held by the account.
+func (es *ExtensionStateImpl) checkStakePositionLock(from module.Address, ds icstate.Delegations) error {
+	locked := es.State.GetStakePositionAmounts(from)
+	if len(locked) == 0 {
+		return nil
+	}
+	dm := ds.ToMap()
+	for key, amount := range locked {
+		value := new(big.Int)
+		if d, ok := dm[key]; ok {
+			value = d.Amount()
+		}
+		if value.Cmp(amount) < 0 {
+			prep, _ := common.NewAddress([]byte(key))
+			return scoreresult.InvalidParameterError.Errorf(
+				"DelegationLockedByStakePosition(prep=%s,locked=%s,delegation=%s)", prep, amount, value)
+		}
+	}
+	return nil
+}
+
+// moveDelegation replaces the delegations of the account without changing
+// the delegated amount of P-Reps. It's used only when the change of one
+// account is canceled out by the change of another.
+func (es *ExtensionStateImpl) moveDelegation(
+	blockHeight int64, owner module.Address, account *icstate.AccountState, ds icstate.Delegations,
+) error {
+	delta := account.Delegations().Delta(ds)
+	if _, _, _, err := es.addEventDelegation(blockHeight, owner, delta); err != nil {
+		return scoreresult.UnknownFailureError.Wrapf(err, "Failed to add EventDelegation")
+	}
+	account.SetDelegation(ds)
+	return nil
+}
+
+func (es *ExtensionStateImpl) getStakePositionOf(holder module.Address, id int64) (*icstate.StakePosition, error) {
+	p := es.State.GetStakePosition(id)
+	if p == nil {
+		return nil, scoreresult.InvalidParameterError.Errorf("StakePositionNotFound(%d)", id)
+	}
+	if !p.Holder().Equal(holder) {
+		return nil, scoreresult.AccessDeniedError.Errorf("NotHolder(id=%d,address=%s)", id, holder)
+	}
+	return p, nil
+}
+
+// CreateStakePosition makes a transferable position on a part of the
+// delegation of the account to the P-Rep. The delegation of the position
+// can't be changed by setDelegation until it's redeemed.
+func (es *ExtensionStateImpl) CreateStakePosition(
+	cc icmodule.CallContext, prep module.Address, amount *big.Int,
+) (int64, error) {
+	from := cc.From()
+	if amount.Sign() <= 0 {
+		return 0, scoreresult.InvalidParameterError.Errorf("InvalidAmount(%s)", amount)
+	}
+	account := es.State.GetAccountState(from)
+	available := new(big.Int).Set(getDelegationAmount(account.Delegations(), prep))
+	if locked, ok := es.State.GetStakePositionAmounts(from)[icutils.ToKey(prep)]; ok {
+		available.Sub(available, locked)
+	}
+	if available.Cmp(amount) < 0 {
+		return 0, scoreresult.InvalidParameterError.Errorf(
+			"NotEnoughDelegation(prep=%s,available=%s,amount=%s)", prep, available, amount)
+	}
+
+	p := icstate.NewStakePosition(from, prep, new(big.Int).Set(amount), cc.BlockHeight())
+	id, err := es.State.AddStakePosition(p)
+	if err != nil {
+		return 0, err
+	}
+	cc.OnEvent(state.SystemAddress,
+		[][]byte{
+			[]byte("StakePositionCreated(int,Address,Address,int)"),
+			intconv.Int64ToBytes(id),
+		},
+		[][]byte{
+			from.Bytes(),
+			prep.Bytes(),
+			intconv.BigIntToBytes(amount),
+		},
+	)
+	return id, nil
+}
+
+// TransferStakePosition moves the position to another account together with
+// its stake and delegation. The delegated amount of the P-Rep is not changed,
+// and the voting reward of the position goes to the new holder from the next
+// block.
+func (es *ExtensionStateImpl) TransferStakePosition(cc icmodule.CallContext, id int64, to module.Address) error {
+	from := cc.From()
+	blockHeight := cc.BlockHeight()
+	p, err := es.getStakePositionOf(from, id)
+	if err != nil {
+		return err
+	}
+	if to.IsContract() || to.Equal(from) {
+		return scoreresult.InvalidParameterError.Errorf("InvalidReceiver(%s)", to)
+	}
+	amount := p.Amount()
+
+	toAccount := es.State.GetAccountState(to)
+	toDs := addDelegationAmount(toAccount.Delegations(), p.PRep(), amount)
+	if len(toDs) > es.State.GetDelegationSlotMax() {
+		return scoreresult.InvalidParameterError.Errorf("TooManyDelegations(%s)", to)
+	}
+	fromAccount := es.State.GetAccountState(from)
+	fromDs := addDelegationAmount(fromAccount.Delegations(), p.PRep(), new(big.Int).Neg(amount))
+
+	if err = es.moveDelegation(blockHeight, from, fromAccount, fromDs); err != nil {
+		return err
+	}
+	if err = es.moveDelegation(blockHeight, to, toAccount, toDs); err != nil {
+		return err
+	}
+	if err = fromAccount.SetStake(new(big.Int).Sub(fromAccount.Stake(), amount)); err != nil {
+		return scoreresult.UnknownFailureError.Wrapf(err, "Failed to set stake: from=%s", from)
+	}
+	if err = toAccount.SetStake(new(big.Int).Add(toAccount.Stake(), amount)); err != nil {
+		return scoreresult.UnknownFailureError.Wrapf(err, "Failed to set stake: to=%s", to)
+	}
+	if err = es.State.TransferStakePosition(id, to); err != nil {
+		return err
+	}
+	cc.OnEvent(state.SystemAddress,
+		[][]byte{
+			[]byte("StakePositionTransferred(int,Address,Address)"),
+			intconv.Int64ToBytes(id),
+		},
+		[][]byte{
+			from.Bytes(),
+			to.Bytes(),
+		},
+	)
+	return nil
+}
+
+// RedeemStakePosition removes the position and unstakes its amount. The
+// amount is locked for the unstake lock period like other unstakes.
+func (es *ExtensionStateImpl) RedeemStakePosition(cc icmodule.CallContext, id int64) error {
+	from := cc.From()
+	p, err := es.getStakePositionOf(from, id)
+	if err != nil {
+		return err
+	}
+	if err = es.State.RemoveStakePosition(id); err != nil {
+		return err
+	}
+	account := es.State.GetAccountState(from)
+	ds := addDelegationAmount(account.Delegations(), p.PRep(), new(big.Int).Neg(p.Amount()))
+	if err = es.SetDelegation(cc, ds); err != nil {
+		return err
+	}
+	if err = es.SetStake(cc, new(big.Int).Sub(account.Stake(), p.Amount())); err != nil {
+		return err
+	}
+	cc.OnEvent(state.SystemAddress,
+		[][]byte{
+			[]byte("StakePositionRedeemed(int,Address,int)"),
+			intconv.Int64ToBytes(id),
+		},
+		[][]byte{
+			from.Bytes(),
+			intconv.BigIntToBytes(p.Amount()),
+		},
+	)
+	return nil
+}
+
+func (es *ExtensionStateImpl) GetStakePositionInJSON(id int64) (map[string]interface{}, error) {
+	p := es.State.GetStakePosition(id)
+	if p == nil {
+		return nil, scoreresult.InvalidParameterError.Errorf("StakePositionNotFound(%d)", id)
+	}
+	return p.ToJSON(), nil
+}
+
+// GetStakePositionsInJSON returns the positions held by the account.
+func (es *ExtensionStateImpl) GetStakePositionsInJSON(holder module.Address) (map[string]interface{}, error) {
+	total := new(big.Int)
+	ids := es.State.GetStakePositionIDs(holder)
+	positions := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		p := es.State.GetStakePosition(id)
+		if p == nil {
+			continue
+		}
+		total.Add(total, p.Amount())
+		positions = append(positions, p.ToJSON())
+	}
+	return map[string]interface{}{
+		"holder":    holder,
+		"total":     total,
+		"positions": positions,
+	}, nil
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright 2021 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package iiss
+
+import (
+	"math/big"
+
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/scoreresult"
+)
+
+const (
+	UnlockScheduleDefaultBlocks = 1800
+	UnlockScheduleMaxBlocks     = 7200
+	UnlockScheduleDefaultSize   = 20
+	UnlockScheduleMaxSize       = 100
+)
+
+// UnlockEntry is the amount of ICX of an account unlocked at a block height.
+type UnlockEntry struct {
+	Height  int64
+	Address module.Address
+	Unstake *big.Int
+	Unbond  *big.Int
+}
+
+func (e *UnlockEntry) ToJSON() map[string]interface{} {
+	return map[string]interface{}{
+		"height":  e.Height,
+		"address": e.Address,
+		"unstake": e.Unstake,
+		"unbond":  e.Unbond,
+	}
+}
+
+// getUnlockEntries returns the accounts whose unstaking or unbonding expires
+// at the height in the order of the timers.
+func (es *ExtensionStateImpl) getUnlockEntries(height int64) []*UnlockEntry {
+	var entries []*UnlockEntry
+	index := make(map[string]*UnlockEntry)
+	entryOf := func(addr module.Address) *UnlockEntry {
+		key := string(addr.Bytes())
+		e, ok := index[key]
+		if !ok {
+			e = &UnlockEntry{
+				Height:  height,
+				Address: addr,
+				Unstake: new(big.Int),
+				Unbond:  new(big.Int),
+			}
+			index[key] = e
+			entries = append(entries, e)
+		}
+		return e
+	}
+
+	if ts := es.State.GetUnstakingTimerSnapshot(height); ts != nil {
+		for itr := ts.Iterator(); itr.Has(); itr.Next() {
+			addr, _ := itr.Get()
+			account := es.State.GetAccountSnapshot(addr)
+			if account == nil {
+				continue
+			}
+			e := entryOf(addr)
+			for _, u := range account.UnStakes() {
+				if u.GetExpire() == height {
+					e.Unstake.Add(e.Unstake, u.GetValue())
+				}
+			}
+		}
+	}
+	if ts := es.State.GetUnbondingTimerSnapshot(height); ts != nil {
+		for itr := ts.Iterator(); itr.Has(); itr.Next() {
+			addr, _ := itr.Get()
+			account := es.State.GetAccountSnapshot(addr)
+			if account == nil {
+				continue
+			}
+			e := entryOf(addr)
+			for _, u := range account.Unbonds() {
+				if u.Expire() == height {
+					e.Unbond.Add(e.Unbond, u.Value())
+				}
+			}
+		}
+	}
+	return entries
+}
+
+// GetUnlockScheduleInJSON returns the amount of unstaked and unbonded ICX
+// unlocked at each height from startHeight to endHeight. If detail is true,
+// the unlocked amount of each account is returned from the index, start.
+// Zero startHeight means the next block, and zero endHeight means the
+// default number of blocks from startHeight.
+func (es *ExtensionStateImpl) GetUnlockScheduleInJSON(
+	blockHeight, startHeight, endHeight int64, detail bool, start, size int,
+) (map[string]interface{}, error) {
+	if startHeight == 0 {
+		startHeight = blockHeight + 1
+	}
+	if endHeight == 0 {
+		endHeight = startHeight + UnlockScheduleDefaultBlocks - 1
+	}
+	if startHeight < 0 || endHeight < startHeight || endHeight-startHeight >= UnlockScheduleMaxBlocks {
+		return nil, scoreresult.InvalidParameterError.Errorf(
+			"InvalidHeightRange(startHeight=%d,endHeight=%d)", startHeight, endHeight)
+	}
+	if size == 0 {
+		size = UnlockScheduleDefaultSize
+	}
+	if start < 0 || size < 0 || size > UnlockScheduleMaxSize {
+		return nil, scoreresult.InvalidParameterError.Errorf("InvalidRange(start=%d,size=%d)", start, size)
+	}
+
+	totalUnstake := new(big.Int)
+	totalUnbond := new(big.Int)
+	heights := make([]interface{}, 0)
+	accounts := make([]interface{}, 0)
+	count := 0
+	for h := startHeight; h <= endHeight; h++ {
+		entries := es.getUnlockEntries(h)
+		if len(entries) == 0 {
+			continue
+		}
+		unstake := new(big.Int)
+		unbond := new(big.Int)
+		for _, e := range entries {
+			unstake.Add(unstake, e.Unstake)
+			unbond.Add(unbond, e.Unbond)
+			if detail && count >= start && count < start+size {
+				accounts = append(accounts, e.ToJSON())
+			}
+			count++
+		}
+		totalUnstake.Add(totalUnstake, unstake)
+		totalUnbond.Add(totalUnbond, unbond)
+		heights = append(heights, map[string]interface{}{
+			"height":   h,
+			"unstake":  unstake,
+			"unbond":   unbond,
+			"accounts": len(entries),
+		})
+	}
+
+	jso := map[string]interface{}{
+		"startHeight": startHeight,
+		"endHeight":   endHeight,
+		"unstake":     totalUnstake,
+		"unbond":      totalUnbond,
+		"total":       new(big.Int).Add(totalUnstake, totalUnbond),
+		"heights":     heights,
+	}
+	if detail {
+		jso["totalAccounts"] = count
+		jso["accounts"] = accounts
+	}
+	return jso, nil
+}
@@ -280,6 +280,10 @@ func (sm *ServiceManager) ExecuteTransaction(result []byte, vh []byte, js []byte
 	return nil, errors.ErrInvalidState
 }
 
+func (sm *ServiceManager) EstimateTransaction(result []byte, vh []byte, js []byte, bi module.BlockInfo) (module.StepEstimation, error) {
+	return nil, errors.ErrInvalidState
+}
+
 func newValidatorListFromSlice(dbase db.Database, addrs []*common.Address) (module.ValidatorList, error) {
 	vls := make([]module.Validator, len(addrs))
 	for i, addr := range addrs {
@@ -28,6 +28,7 @@ import (
 	"github.com/icon-project/goloop/common"
 	"github.com/icon-project/goloop/common/codec"
 	"github.com/icon-project/goloop/common/db"
+	"github.com/icon-project/goloop/common/errors"
 	"github.com/icon-project/goloop/common/log"
 	"github.com/icon-project/goloop/common/merkle"
 	"github.com/icon-project/goloop/consensus"
@@ -37,6 +38,7 @@ import (
 	"github.com/icon-project/goloop/icon/icmodule"
 	"github.com/icon-project/goloop/icon/iiss"
 	"github.com/icon-project/goloop/icon/iiss/iccache"
+	"github.com/icon-project/goloop/icon/iiss/icstate"
 	"github.com/icon-project/goloop/icon/iiss/icutils"
 	"github.com/icon-project/goloop/icon/merkle/hexary"
 	"github.com/icon-project/goloop/module"
@@ -127,7 +129,39 @@ func (p *platform) OnExecutionBegin(wc state.WorldContext, logger log.Logger) er
 	if es == nil {
 		return nil
 	}
-	return es.OnExecutionBegin(iiss.NewWorldContext(wc))
+	if err := es.OnExecutionBegin(iiss.NewWorldContext(wc)); err != nil {
+		return err
+	}
+	if revision >= icmodule.RevisionNetworkProposal {
+		return es.HandleNetworkProposals(wc.BlockHeight(), func(np *icstate.NetworkProposal) error {
+			return applyNetworkProposal(wc, np)
+		})
+	}
+	return nil
+}
+
+// applyNetworkProposal calls the setter of the chain SCORE for the approved
+// network proposal with the privilege of the governance SCORE. Changes made
+// by the setter are reverted if it fails.
+func applyNetworkProposal(wc state.WorldContext, np *icstate.NetworkProposal) error {
+	ctx, ok := wc.(contract.Context)
+	if !ok {
+		return errors.InvalidStateError.Errorf("InvalidWorldContext(%T)", wc)
+	}
+	snapshot := ctx.GetSnapshot()
+	cc := contract.NewCallContext(ctx, ctx.GetStepLimit(state.StepLimitTypeInvoke), false)
+	defer cc.Dispose()
+	score, err := newChainScore(cc, cc.Governance(), nil)
+	if err == nil {
+		err = score.(*chainScore).applyNetworkProposal(np)
+	}
+	if err != nil {
+		if rerr := ctx.Reset(snapshot); rerr != nil {
+			return rerr
+		}
+		return err
+	}
+	return nil
 }
 
 func (p *platform) OnExecutionEnd(wc state.WorldContext, er base.ExecutionResult, logger log.Logger) error {
@@ -163,6 +197,17 @@ func (p *platform) OnTransactionEnd(wc state.WorldContext, logger log.Logger, rc
 	return es.OnTransactionEnd(wc.BlockHeight(), success)
 }
 
+// Inspect returns the progress of the reward calculation.
+func (p *platform) Inspect(informal bool) map[string]interface{} {
+	c := p.calculator.Get()
+	if c == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"calculator": c.Progress(),
+	}
+}
+
 func (p *platform) Term() {
 	// Terminate
 }
@@ -212,8 +257,31 @@ func (t *platform) CommitVoteSetDecoder() module.CommitVoteSetDecoder {
 
 const (
 	BlockV1ProofFile = "block_v1_proof.bin"
+	ConfigFile       = "platform.json"
 )
 
+// PlatformConfig is the configuration of the platform for the node, which
+// is loaded from ConfigFile in the base directory of the chain.
+type PlatformConfig struct {
+	Calculator iiss.CalculatorConfig `json:"calculator"`
+}
+
+func loadPlatformConfig(base string) (*PlatformConfig, error) {
+	cfg := new(PlatformConfig)
+	bs, err := ioutil.ReadFile(path.Join(base, ConfigFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(bs, cfg); err != nil {
+		return nil, errors.IllegalArgumentError.Wrapf(err,
+			"InvalidPlatformConfig(file=%s)", ConfigFile)
+	}
+	return cfg, nil
+}
+
 type BlockV1Proof struct {
 	MerkleHeader *hexary.MerkleHeader
 	LastVotes    *blockv0.BlockVoteList
@@ -250,9 +318,15 @@ func (p *platform) SetBlockV1Proof(root []byte, size int64, votes *blockv0.Block
 }
 
 func NewPlatform(base string, cid int) (base.Platform, error) {
-	return &platform{
+	cfg, err := loadPlatformConfig(base)
+	if err != nil {
+		return nil, err
+	}
+	p := &platform{
 		base: base,
-	}, nil
+	}
+	p.calculator.SetConfig(cfg.Calculator)
+	return p, nil
 }
 
 func init() {
@@ -18,15 +18,25 @@ package icon
 
 import (
 	"io/ioutil"
+	"math/big"
 	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/icon-project/goloop/common"
 	"github.com/icon-project/goloop/common/crypto"
+	"github.com/icon-project/goloop/common/db"
+	"github.com/icon-project/goloop/common/log"
 	"github.com/icon-project/goloop/common/wallet"
 	"github.com/icon-project/goloop/icon/blockv0"
+	"github.com/icon-project/goloop/icon/icmodule"
+	"github.com/icon-project/goloop/icon/iiss"
+	"github.com/icon-project/goloop/icon/iiss/icstate"
 	"github.com/icon-project/goloop/icon/lcimporter"
+	"github.com/icon-project/goloop/service/contract"
+	"github.com/icon-project/goloop/service/scoredb"
+	"github.com/icon-project/goloop/service/state"
 )
 
 func TestPlatform_BlockV1Proof(t *testing.T) {
@@ -52,4 +62,50 @@ func TestPlatform_BlockV1Proof(t *testing.T) {
 	assert.Equal(t, votes.Hash(), votes2.Hash())
 	assert.Equal(t, root, mh2.RootHash)
 	assert.Equal(t, height, mh2.Leaves)
-}
\ No newline at end of file
+}
+func TestPlatform_ApplyNetworkProposal(t *testing.T) {
+	base, err := ioutil.TempDir("", "platform*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(base)
+
+	plt, err := NewPlatform(base, 1)
+	assert.NoError(t, err)
+
+	dbase := db.NewMapDB()
+	ws := state.NewWorldState(dbase, nil, nil, iiss.NewExtensionSnapshot(dbase, nil))
+	as := ws.GetAccountState(state.SystemID)
+	assert.NoError(t, scoredb.NewVarDB(as, state.VarRevision).Set(icmodule.RevisionNetworkProposal))
+	wc := state.NewWorldContext(ws, common.NewBlockInfo(10, 0), nil, plt)
+	ctx := contract.NewContext(wc, nil, nil, nil, log.New(), nil)
+	es := ctx.GetExtensionState().(*iiss.ExtensionStateImpl)
+
+	proposer := common.MustNewAddressFromString("hx1")
+	newProposal := func(typ string, value int64) *icstate.NetworkProposal {
+		return icstate.NewNetworkProposal(proposer, "title", "description", typ,
+			big.NewInt(value), 1, 100, 0, nil)
+	}
+
+	// applied through the setter of the chain SCORE
+	err = applyNetworkProposal(ctx, newProposal(iiss.NetworkProposalSetIRep, 30000))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(30000), es.State.GetIRep().Int64())
+
+	err = applyNetworkProposal(ctx, newProposal(iiss.NetworkProposalSetStepPrice, 12500))
+	assert.NoError(t, err)
+	as = ctx.GetAccountState(state.SystemID)
+	assert.Equal(t, int64(12500), scoredb.NewVarDB(as, state.VarStepPrice).Int64())
+
+	// failed proposal leaves the state as it is
+	err = applyNetworkProposal(ctx, newProposal(iiss.NetworkProposalSetRevision, icmodule.MaxRevision+1))
+	assert.Error(t, err)
+	as = ctx.GetAccountState(state.SystemID)
+	assert.Equal(t, int64(icmodule.RevisionNetworkProposal), scoredb.NewVarDB(as, state.VarRevision).Int64())
+
+	err = applyNetworkProposal(ctx, newProposal("setUnknown", 1))
+	assert.Error(t, err)
+	assert.Equal(t, int64(30000), es.State.GetIRep().Int64())
+
+	// only contract context can apply it
+	err = applyNetworkProposal(wc, newProposal(iiss.NetworkProposalSetIRep, 1))
+	assert.Error(t, err)
+}
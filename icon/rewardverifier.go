@@ -0,0 +1,156 @@
+/*
+ * Copyright 2021 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package icon
+
+import (
+	"github.com/icon-project/goloop/chain/base"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/icon/iiss"
+	"github.com/icon-project/goloop/icon/iiss/icutils"
+	"github.com/icon-project/goloop/service"
+)
+
+// rewardLocator finds the extension snapshots of the calculation for a term
+// in the blocks of the chain.
+type rewardLocator struct {
+	chain base.Chain
+	plt   *platform
+	last  int64
+}
+
+func (l *rewardLocator) extensionAt(height int64) (*iiss.ExtensionSnapshotImpl, error) {
+	blk, err := l.chain.BlockManager().GetBlockByHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	wss, err := service.NewWorldSnapshot(l.chain.Database(), l.plt, blk.Result(), nil)
+	if err != nil {
+		return nil, err
+	}
+	ess, _ := wss.GetExtensionSnapshot().(*iiss.ExtensionSnapshotImpl)
+	return ess, nil
+}
+
+// search returns the lowest height where the value of the extension is not
+// less than the target. The value should be non-decreasing with the height.
+// It returns -1 if there is no such height.
+func (l *rewardLocator) search(
+	value func(ess *iiss.ExtensionSnapshotImpl) (int64, error), target int64,
+) (int64, *iiss.ExtensionSnapshotImpl, error) {
+	var found *iiss.ExtensionSnapshotImpl
+	height := int64(-1)
+	low, high := int64(0), l.last
+	for low <= high {
+		mid := low + (high-low)/2
+		ess, err := l.extensionAt(mid)
+		if err != nil {
+			return -1, nil, err
+		}
+		v := int64(-1)
+		if ess != nil {
+			if v, err = value(ess); err != nil {
+				return -1, nil, err
+			}
+		}
+		if v >= target {
+			height, found = mid, ess
+			high = mid - 1
+		} else {
+			low = mid + 1
+		}
+	}
+	return height, found, nil
+}
+
+func termSequenceOf(ess *iiss.ExtensionSnapshotImpl) (int64, error) {
+	es := ess.NewState(true).(*iiss.ExtensionStateImpl)
+	term := es.State.GetTermSnapshot()
+	if term == nil {
+		return -1, nil
+	}
+	return int64(term.Sequence()), nil
+}
+
+// calculationOf returns the start height of the term calculated with the
+// extension.
+func calculationOf(ess *iiss.ExtensionSnapshotImpl) (int64, error) {
+	global, err := ess.Back2().GetGlobal()
+	if err != nil || global == nil {
+		return -1, err
+	}
+	return global.GetStartHeight(), nil
+}
+
+// VerifyRewards recomputes the rewards of the term with the sequence and
+// compares them with the calculation result applied to the chain. It returns
+// the report of the verification, and an error if there are discrepancies.
+func (p *platform) VerifyRewards(c base.Chain, term int64) (interface{}, error) {
+	last, err := c.BlockManager().GetLastBlock()
+	if err != nil {
+		return nil, err
+	}
+	l := &rewardLocator{chain: c, plt: p, last: last.Height()}
+
+	_, ess, err := l.search(termSequenceOf, term)
+	if err != nil {
+		return nil, err
+	}
+	if ess == nil {
+		return nil, errors.NotFoundError.Errorf("TermNotFound(term=%d)", term)
+	}
+	es := ess.NewState(true).(*iiss.ExtensionStateImpl)
+	ts := es.State.GetTermSnapshot()
+	if int64(ts.Sequence()) != term {
+		return nil, errors.NotFoundError.Errorf("TermNotFound(term=%d)", term)
+	}
+	startHeight := ts.StartHeight()
+
+	calcHeight, ess, err := l.search(calculationOf, startHeight)
+	if err != nil {
+		return nil, err
+	}
+	if ess == nil {
+		return nil, errors.InvalidStateError.Errorf("CalculationNotStarted(term=%d)", term)
+	}
+	if h, err := calculationOf(ess); err != nil || h != startHeight {
+		return nil, errors.InvalidStateError.Errorf("CalculationNotFound(term=%d,start=%d)", term, startHeight)
+	}
+	back, base := ess.Back2(), ess.Reward()
+
+	resultHeight, ess, err := l.search(calculationOf, startHeight+1)
+	if err != nil {
+		return nil, err
+	}
+	if ess == nil {
+		return nil, errors.InvalidStateError.Errorf("CalculationNotApplied(term=%d)", term)
+	}
+
+	logger := icutils.NewIconLogger(c.Logger())
+	rv, err := iiss.VerifyRewards(back, base, ess.Reward(), logger)
+	if err != nil {
+		return nil, err
+	}
+	report := rv.ToJSON()
+	report["term"] = term
+	report["calculationHeight"] = calcHeight
+	report["resultHeight"] = resultHeight
+	if len(rv.Discrepancies) > 0 {
+		return report, errors.InvalidStateError.Errorf(
+			"RewardMismatch(term=%d,count=%d)", term, len(rv.Discrepancies))
+	}
+	return report, nil
+}
@@ -31,6 +31,11 @@ const (
 	LegacyBalanceCheck
 	LegacyInputJSON
 	LegacyNoTimeout
+	UseWasmEE
+	UseScheduledCall
+	UseAccountTransaction
+	UseMultiSigTransaction
+	UseBatchTransaction
 	LastRevisionBit
 )
 
@@ -85,6 +90,26 @@ func (r Revision) LegacyBalanceCheck() bool {
 	return (r & LegacyBalanceCheck) != 0
 }
 
+func (r Revision) UseWasmEE() bool {
+	return (r & UseWasmEE) != 0
+}
+
+func (r Revision) UseScheduledCall() bool {
+	return (r & UseScheduledCall) != 0
+}
+
+func (r Revision) UseAccountTransaction() bool {
+	return (r & UseAccountTransaction) != 0
+}
+
+func (r Revision) UseMultiSigTransaction() bool {
+	return (r & UseMultiSigTransaction) != 0
+}
+
+func (r Revision) UseBatchTransaction() bool {
+	return (r & UseBatchTransaction) != 0
+}
+
 func (r Revision) Has(flag Revision) bool {
 	return (r & flag) != 0
 }
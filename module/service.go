@@ -374,6 +374,31 @@ type ServiceManager interface {
 	// Then it returns the expected result of the transaction.
 	// It ignores supplied step limit.
 	ExecuteTransaction(result []byte, vh []byte, js []byte, bi BlockInfo) (Receipt, error)
+
+	// EstimateTransaction executes the transaction like ExecuteTransaction.
+	// Then it returns the estimation including the expected result, steps
+	// for each type and states accessed by the transaction.
+	EstimateTransaction(result []byte, vh []byte, js []byte, bi BlockInfo) (StepEstimation, error)
+}
+
+// StepEstimation is the result of the estimation for a transaction.
+type StepEstimation interface {
+	// Receipt returns the expected result of the transaction.
+	Receipt() Receipt
+
+	// StepsByType returns steps used for each step type. Sum of them
+	// is same as the steps used by the transaction.
+	StepsByType() map[string]*big.Int
+
+	// AccessList returns accounts accessed by the transaction with
+	// storage keys of them.
+	AccessList() []AccountAccess
+}
+
+// AccountAccess is accesses to an account.
+type AccountAccess struct {
+	Address Address
+	Storage [][]byte
 }
 
 type TraceInfo struct {
@@ -0,0 +1,396 @@
+package network
+
+import (
+	"context"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/db"
+	"github.com/icon-project/goloop/module"
+)
+
+const (
+	DefaultAddressBookSize       = 1000
+	DefaultAddressBookBootstrap  = 10
+	DefaultAddressBookSavePeriod = 1 * time.Minute
+	DefaultDNSSeedResolvePeriod  = 10 * time.Minute
+	DefaultDNSSeedResolveTimeout = 5 * time.Second
+	addressBookFailurePenalty    = 2
+	addressBookRoleBonus         = 5
+	addressBookKey               = "network.addressBook"
+)
+
+type AddressBookEntry struct {
+	Address  NetAddress   `json:"addr"`
+	ID       string       `json:"id,omitempty"`
+	Role     PeerRoleFlag `json:"role"`
+	LastSeen int64        `json:"lastSeen"`
+	Success  int          `json:"success"`
+	Failure  int          `json:"failure"`
+	Verified bool         `json:"verified,omitempty"`
+}
+
+func (e *AddressBookEntry) hasVerifiedRole() bool {
+	return e.Verified && (e.Role.Has(p2pRoleSeed) || e.Role.Has(p2pRoleRoot))
+}
+
+//Score prefers the address which has been connected successfully and serves
+//as seed or root. The role is counted only if it's verified locally, so that
+//peers can't promote the addresses given by them.
+func (e *AddressBookEntry) Score() int {
+	s := e.Success - e.Failure*addressBookFailurePenalty
+	if e.hasVerifiedRole() {
+		s += addressBookRoleBonus
+	}
+	return s
+}
+
+func addressBookLess(a, b *AddressBookEntry) bool {
+	if sa, sb := a.Score(), b.Score(); sa != sb {
+		return sa > sb
+	}
+	if a.LastSeen != b.LastSeen {
+		return a.LastSeen > b.LastSeen
+	}
+	return a.Address < b.Address
+}
+
+//AddressBook keeps the addresses of peers which is known to the channel with
+//the connection history. It's stored in the chain database to bootstrap
+//the connections on startup.
+type AddressBook struct {
+	entries map[NetAddress]*AddressBookEntry
+	size    int
+	bucket  db.Bucket
+	dirty   bool
+	mtx     sync.Mutex
+}
+
+func newAddressBook() *AddressBook {
+	return &AddressBook{
+		entries: make(map[NetAddress]*AddressBookEntry),
+		size:    DefaultAddressBookSize,
+	}
+}
+
+//Load reads the stored entries from the bucket, and later Save writes to it.
+func (ab *AddressBook) Load(bk db.Bucket) error {
+	ab.mtx.Lock()
+	defer ab.mtx.Unlock()
+
+	ab.bucket = bk
+	bs, err := bk.Get([]byte(addressBookKey))
+	if err != nil || len(bs) == 0 {
+		return err
+	}
+	var l []*AddressBookEntry
+	if _, err = codec.BC.UnmarshalFromBytes(bs, &l); err != nil {
+		return err
+	}
+	for _, e := range l {
+		if len(e.Address) != 0 {
+			ab.entries[e.Address] = e
+		}
+	}
+	ab._evict()
+	return nil
+}
+
+func (ab *AddressBook) Save() error {
+	ab.mtx.Lock()
+	defer ab.mtx.Unlock()
+
+	if ab.bucket == nil || !ab.dirty {
+		return nil
+	}
+	bs, err := codec.BC.MarshalToBytes(ab._entries())
+	if err != nil {
+		return err
+	}
+	if err = ab.bucket.Set([]byte(addressBookKey), bs); err != nil {
+		return err
+	}
+	ab.dirty = false
+	return nil
+}
+
+func (ab *AddressBook) _get(na NetAddress, create bool) *AddressBookEntry {
+	e, ok := ab.entries[na]
+	if !ok && create {
+		e = &AddressBookEntry{Address: na}
+		ab.entries[na] = e
+	}
+	return e
+}
+
+//_evict removes the entries of the lowest score until it fits the size
+func (ab *AddressBook) _evict() {
+	if len(ab.entries) <= ab.size {
+		return
+	}
+	l := ab._entries()
+	for _, e := range l[ab.size:] {
+		delete(ab.entries, e.Address)
+	}
+}
+
+func (ab *AddressBook) _entries() []*AddressBookEntry {
+	l := make([]*AddressBookEntry, 0, len(ab.entries))
+	for _, e := range ab.entries {
+		l = append(l, e)
+	}
+	sort.Slice(l, func(i, j int) bool {
+		return addressBookLess(l[i], l[j])
+	})
+	return l
+}
+
+//add registers the address learned from other peers, the role given by
+//the peers is not verified.
+func (ab *AddressBook) add(na NetAddress, r PeerRoleFlag) {
+	if len(na) == 0 {
+		return
+	}
+	ab.mtx.Lock()
+	defer ab.mtx.Unlock()
+
+	e := ab._get(na, true)
+	if !e.Role.Has(r) {
+		e.Role.SetFlag(r)
+		ab.dirty = true
+	}
+	ab._evict()
+}
+
+//connected is called when the connection is established, only the outbound
+//connection is counted as success.
+func (ab *AddressBook) connected(na NetAddress, id module.PeerID, outbound bool) {
+	if len(na) == 0 {
+		return
+	}
+	ab.mtx.Lock()
+	defer ab.mtx.Unlock()
+
+	e := ab._get(na, true)
+	if id != nil {
+		e.ID = id.String()
+	}
+	e.LastSeen = time.Now().Unix()
+	if outbound {
+		e.Success++
+		e.Failure = 0
+	}
+	ab.dirty = true
+	ab._evict()
+}
+
+//failed is called when it fails to dial to the known address
+func (ab *AddressBook) failed(na NetAddress) {
+	ab.mtx.Lock()
+	defer ab.mtx.Unlock()
+
+	if e := ab._get(na, false); e != nil {
+		e.Failure++
+		ab.dirty = true
+	}
+}
+
+//trust registers the address of the trust seed configured locally
+func (ab *AddressBook) trust(na NetAddress) {
+	if len(na) == 0 {
+		return
+	}
+	ab.mtx.Lock()
+	defer ab.mtx.Unlock()
+
+	e := ab._get(na, true)
+	if !e.Role.Has(p2pRoleSeed) || !e.Verified {
+		e.Role.SetFlag(p2pRoleSeed)
+		e.Verified = true
+		ab.dirty = true
+	}
+	ab._evict()
+}
+
+//setRole updates the role of the connected peer, verified should be true if
+//the role is allowed by the local configuration.
+func (ab *AddressBook) setRole(na NetAddress, r PeerRoleFlag, verified bool) {
+	ab.mtx.Lock()
+	defer ab.mtx.Unlock()
+
+	if e := ab._get(na, false); e != nil && (e.Role != r || e.Verified != verified) {
+		e.Role = r
+		e.Verified = verified
+		ab.dirty = true
+	}
+}
+
+//Best returns at most n addresses ordered by score, which are verified seeds
+//or roots or have been dialed successfully, and not failed more than succeeded.
+func (ab *AddressBook) Best(n int) []NetAddress {
+	ab.mtx.Lock()
+	defer ab.mtx.Unlock()
+
+	l := make([]NetAddress, 0, n)
+	for _, e := range ab._entries() {
+		if len(l) >= n || e.Score() < 0 {
+			break
+		}
+		if e.Success > 0 || e.hasVerifiedRole() {
+			l = append(l, e.Address)
+		}
+	}
+	return l
+}
+
+//Entries returns the entries ordered by score
+func (ab *AddressBook) Entries() []AddressBookEntry {
+	ab.mtx.Lock()
+	defer ab.mtx.Unlock()
+
+	es := ab._entries()
+	l := make([]AddressBookEntry, len(es))
+	for i, e := range es {
+		l[i] = *e
+	}
+	return l
+}
+
+func (ab *AddressBook) Len() int {
+	ab.mtx.Lock()
+	defer ab.mtx.Unlock()
+
+	return len(ab.entries)
+}
+
+type ipResolver func(ctx context.Context, host string) ([]net.IPAddr, error)
+
+//dnsSeeds keeps the trust seeds given as DNS name, each name could be
+//resolved to multiple addresses which are used as trust seeds.
+type dnsSeeds struct {
+	//host:port => resolved addresses
+	names    map[NetAddress]bool
+	statics  map[NetAddress]bool
+	resolved map[NetAddress][]NetAddress
+	resolver ipResolver
+	mtx      sync.Mutex
+}
+
+func newDNSSeeds() *dnsSeeds {
+	return &dnsSeeds{
+		names:    make(map[NetAddress]bool),
+		statics:  make(map[NetAddress]bool),
+		resolved: make(map[NetAddress][]NetAddress),
+		resolver: net.DefaultResolver.LookupIPAddr,
+	}
+}
+
+//isDNSSeed returns host and port if the host of the address is not an IP
+func isDNSSeed(na NetAddress) (string, string, bool) {
+	host, port, err := net.SplitHostPort(string(na))
+	if err != nil || len(host) == 0 || net.ParseIP(host) != nil {
+		return "", "", false
+	}
+	return host, port, true
+}
+
+//set updates the trust seeds, the addresses given as IP are kept as trust
+//seeds even though some name isn't resolved to them any more.
+func (d *dnsSeeds) set(seeds []NetAddress) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	d.names = make(map[NetAddress]bool)
+	d.statics = make(map[NetAddress]bool)
+	d.resolved = make(map[NetAddress][]NetAddress)
+	for _, na := range seeds {
+		if _, _, ok := isDNSSeed(na); ok {
+			d.names[na] = true
+		} else {
+			d.statics[na] = true
+		}
+	}
+}
+
+func (d *dnsSeeds) Len() int {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	return len(d.names)
+}
+
+//resolve looks up all names, then returns the added and removed addresses.
+//If lookup fails, previously resolved addresses of the name are kept.
+func (d *dnsSeeds) resolve(ctx context.Context) (added, removed []NetAddress, err error) {
+	d.mtx.Lock()
+	names := make([]NetAddress, 0, len(d.names))
+	for na := range d.names {
+		names = append(names, na)
+	}
+	resolver := d.resolver
+	d.mtx.Unlock()
+
+	result := make(map[NetAddress][]NetAddress)
+	for _, name := range names {
+		host, port, _ := isDNSSeed(name)
+		ips, lerr := resolver(ctx, host)
+		if lerr != nil {
+			err = lerr
+			continue
+		}
+		l := make([]NetAddress, 0, len(ips))
+		for _, ip := range ips {
+			if DefaultTransportNet == "tcp4" && ip.IP.To4() == nil {
+				continue
+			}
+			l = append(l, NetAddress(net.JoinHostPort(ip.IP.String(), port)))
+		}
+		result[name] = l
+	}
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	old := make(map[NetAddress]bool)
+	for _, l := range d.resolved {
+		for _, na := range l {
+			old[na] = true
+		}
+	}
+	for name, l := range result {
+		if d.names[name] {
+			d.resolved[name] = l
+		}
+	}
+	now := make(map[NetAddress]bool)
+	for _, l := range d.resolved {
+		for _, na := range l {
+			now[na] = true
+		}
+	}
+	for na := range now {
+		if !old[na] && !d.statics[na] {
+			added = append(added, na)
+		}
+	}
+	for na := range old {
+		if !now[na] && !d.statics[na] {
+			removed = append(removed, na)
+		}
+	}
+	return added, removed, err
+}
+
+func (d *dnsSeeds) Map() map[string]interface{} {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	m := make(map[string]interface{})
+	for name := range d.names {
+		m[string(name)] = d.resolved[name]
+	}
+	return m
+}
@@ -0,0 +1,116 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common/db"
+)
+
+func Test_addressbook_score(t *testing.T) {
+	ab := newAddressBook()
+	id := newTestPeerID()
+
+	ab.connected("127.0.0.1:8080", id, true)
+	ab.connected("127.0.0.1:8081", newTestPeerID(), false)
+	ab.add("127.0.0.1:8082", p2pRoleSeed)
+	ab.add("127.0.0.1:8083", p2pRoleNone)
+	ab.failed("127.0.0.1:8083")
+	ab.failed("127.0.0.1:9999")
+	ab.trust("127.0.0.1:8085")
+	assert.Equal(t, 5, ab.Len())
+
+	l := ab.Entries()
+	assert.Equal(t, NetAddress("127.0.0.1:8085"), l[0].Address)
+	assert.True(t, l[0].Verified)
+	assert.Equal(t, NetAddress("127.0.0.1:8080"), l[1].Address)
+	assert.Equal(t, id.String(), l[1].ID)
+	assert.Equal(t, 1, l[1].Success)
+	assert.NotZero(t, l[1].LastSeen)
+	//role learned from other peers has no bonus
+	assert.Equal(t, NetAddress("127.0.0.1:8082"), l[3].Address)
+	assert.False(t, l[3].Verified)
+	assert.Equal(t, NetAddress("127.0.0.1:8083"), l[4].Address)
+	assert.Equal(t, 1, l[4].Failure)
+
+	//inbound only, failed and unverified addresses are not used for bootstrap
+	assert.Equal(t, []NetAddress{"127.0.0.1:8085", "127.0.0.1:8080"}, ab.Best(10))
+	assert.Equal(t, []NetAddress{"127.0.0.1:8085"}, ab.Best(1))
+
+	ab.setRole("127.0.0.1:8085", p2pRoleNone, false)
+	ab.failed("127.0.0.1:8080")
+	assert.Len(t, ab.Best(10), 0)
+
+	ab.setRole("127.0.0.1:8082", p2pRoleSeed, true)
+	assert.Equal(t, []NetAddress{"127.0.0.1:8082"}, ab.Best(10))
+
+	ab.size = 2
+	ab.add("127.0.0.1:8084", p2pRoleRoot)
+	assert.Equal(t, 2, ab.Len())
+	l = ab.Entries()
+	assert.Equal(t, NetAddress("127.0.0.1:8082"), l[0].Address)
+	assert.Equal(t, NetAddress("127.0.0.1:8081"), l[1].Address)
+}
+
+func Test_addressbook_persist(t *testing.T) {
+	mdb := db.NewMapDB()
+	bk, err := mdb.GetBucket(db.ChainProperty)
+	assert.NoError(t, err)
+
+	ab := newAddressBook()
+	assert.NoError(t, ab.Load(bk))
+	assert.Equal(t, 0, ab.Len())
+	ab.connected("127.0.0.1:8080", newTestPeerID(), true)
+	ab.add("127.0.0.1:8081", p2pRoleRoot|p2pRoleSeed)
+	assert.NoError(t, ab.Save())
+
+	ab2 := newAddressBook()
+	assert.NoError(t, ab2.Load(bk))
+	assert.Equal(t, ab.Entries(), ab2.Entries())
+}
+
+func Test_addressbook_dnsSeeds(t *testing.T) {
+	records := map[string][]string{
+		"seed.example.com":  {"10.0.0.1", "10.0.0.2", "::1"},
+		"seed2.example.com": {"10.0.0.3"},
+	}
+	d := newDNSSeeds()
+	d.resolver = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		ips, ok := records[host]
+		if !ok {
+			return nil, fmt.Errorf("no such host %s", host)
+		}
+		l := make([]net.IPAddr, len(ips))
+		for i, ip := range ips {
+			l[i] = net.IPAddr{IP: net.ParseIP(ip)}
+		}
+		return l, nil
+	}
+
+	_, _, ok := isDNSSeed("127.0.0.1:8080")
+	assert.False(t, ok)
+	d.set([]NetAddress{"seed.example.com:8080", "seed2.example.com:7100"})
+	assert.Equal(t, 2, d.Len())
+
+	added, removed, err := d.resolve(context.Background())
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []NetAddress{"10.0.0.1:8080", "10.0.0.2:8080", "10.0.0.3:7100"}, added)
+	assert.Len(t, removed, 0)
+
+	//configured address is kept even if it's not resolved any more
+	d.set([]NetAddress{"seed.example.com:8080", "seed2.example.com:7100", "10.0.0.2:8080"})
+	_, _, err = d.resolve(context.Background())
+	assert.NoError(t, err)
+
+	records["seed.example.com"] = []string{"10.0.0.4"}
+	delete(records, "seed2.example.com")
+	added, removed, err = d.resolve(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, []NetAddress{"10.0.0.4:8080"}, added)
+	assert.Equal(t, []NetAddress{"10.0.0.1:8080"}, removed)
+	assert.ElementsMatch(t, []NetAddress{"10.0.0.3:7100"}, d.resolved["seed2.example.com:7100"])
+}
@@ -0,0 +1,389 @@
+package network
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/module"
+)
+
+const (
+	DefaultCaptureFileName  = "packet.cap"
+	DefaultCaptureFileSize  = 64 * 1024 * 1024
+	DefaultCaptureFileCount = 10
+	captureRecordMax        = DefaultPacketPayloadMax + 1024
+)
+
+type CaptureDirection byte
+
+const (
+	CaptureIn CaptureDirection = iota
+	CaptureOut
+)
+
+func (d CaptureDirection) String() string {
+	switch d {
+	case CaptureIn:
+		return "in"
+	case CaptureOut:
+		return "out"
+	default:
+		return "unknown"
+	}
+}
+
+//CapturedPacket is a record of the capture file
+type CapturedPacket struct {
+	Timestamp   int64 //unix nano
+	Direction   CaptureDirection
+	Peer        []byte
+	Protocol    module.ProtocolInfo
+	SubProtocol module.ProtocolInfo
+	Src         []byte
+	Dest        byte
+	TTL         byte
+	Payload     []byte
+}
+
+func (cp *CapturedPacket) Time() time.Time {
+	return time.Unix(0, cp.Timestamp)
+}
+
+func (cp *CapturedPacket) PeerID() module.PeerID {
+	if len(cp.Peer) == 0 {
+		return nil
+	}
+	return NewPeerID(cp.Peer)
+}
+
+func (cp *CapturedPacket) String() string {
+	return fmt.Sprintf("%s %-3s peer:%v pi:%#04x spi:%#04x dest:%#02x ttl:%d len:%d",
+		cp.Time().Format(time.RFC3339Nano), cp.Direction, cp.PeerID(),
+		cp.Protocol.Uint16(), cp.SubProtocol.Uint16(), cp.Dest, cp.TTL, len(cp.Payload))
+}
+
+//Capturer writes packets of all peers of the transport to the rotating files,
+//dir/packet.cap is the current file and dir/packet.cap.N are rotated files.
+type Capturer struct {
+	dir       string
+	fileSize  int64
+	fileCount int
+	f         *os.File
+	size      int64
+	running   int32
+	mtx       sync.Mutex
+}
+
+func newCapturer() *Capturer {
+	return &Capturer{
+		fileSize:  DefaultCaptureFileSize,
+		fileCount: DefaultCaptureFileCount,
+	}
+}
+
+func (c *Capturer) SetLimit(fileSize int64, fileCount int) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if fileSize > 0 {
+		c.fileSize = fileSize
+	}
+	if fileCount > 0 {
+		c.fileCount = fileCount
+	}
+}
+
+func (c *Capturer) Start(dir string) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.f != nil {
+		return errors.InvalidStateError.New("AlreadyStarted")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	c.dir = dir
+	if err := c._open(); err != nil {
+		return err
+	}
+	atomic.StoreInt32(&c.running, 1)
+	return nil
+}
+
+func (c *Capturer) Stop() error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.f == nil {
+		return nil
+	}
+	atomic.StoreInt32(&c.running, 0)
+	err := c.f.Close()
+	c.f = nil
+	return err
+}
+
+func (c *Capturer) IsRunning() bool {
+	return atomic.LoadInt32(&c.running) == 1
+}
+
+func (c *Capturer) _filePath(idx int) string {
+	fp := path.Join(c.dir, DefaultCaptureFileName)
+	if idx > 0 {
+		fp = fmt.Sprintf("%s.%d", fp, idx)
+	}
+	return fp
+}
+
+func (c *Capturer) _open() error {
+	f, err := os.OpenFile(c._filePath(0), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	c.f = f
+	c.size = fi.Size()
+	return nil
+}
+
+func (c *Capturer) _rotate() error {
+	if err := c.f.Close(); err != nil {
+		return err
+	}
+	c.f = nil
+	for i := c.fileCount - 1; i >= 0; i-- {
+		fp := c._filePath(i)
+		if _, err := os.Stat(fp); err != nil {
+			continue
+		}
+		if i == c.fileCount-1 {
+			if err := os.Remove(fp); err != nil {
+				return err
+			}
+		} else if err := os.Rename(fp, c._filePath(i+1)); err != nil {
+			return err
+		}
+	}
+	return c._open()
+}
+
+func (c *Capturer) capture(d CaptureDirection, p *Peer, pkt *Packet) {
+	if !c.IsRunning() {
+		return
+	}
+	cp := &CapturedPacket{
+		Timestamp:   time.Now().UnixNano(),
+		Direction:   d,
+		Protocol:    pkt.protocol,
+		SubProtocol: pkt.subProtocol,
+		Dest:        pkt.dest,
+		TTL:         pkt.ttl,
+		Payload:     pkt.payload,
+	}
+	if id := p.ID(); id != nil {
+		cp.Peer = id.Bytes()
+	}
+	if pkt.src != nil {
+		cp.Src = pkt.src.Bytes()
+	}
+	bs, err := codec.BC.MarshalToBytes(cp)
+	if err != nil {
+		p.logger.Infoln("capture", "fail to marshal", err)
+		return
+	}
+	b := make([]byte, 4+len(bs))
+	binary.BigEndian.PutUint32(b, uint32(len(bs)))
+	copy(b[4:], bs)
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.f == nil {
+		return
+	}
+	if c.size > 0 && c.size+int64(len(b)) > c.fileSize {
+		if err = c._rotate(); err != nil {
+			p.logger.Warnln("capture", "fail to rotate", err)
+			return
+		}
+	}
+	n, err := c.f.Write(b)
+	c.size += int64(n)
+	if err != nil {
+		p.logger.Warnln("capture", "fail to write", err)
+	}
+}
+
+//CaptureReader reads CapturedPacket from the capture file
+type CaptureReader struct {
+	r *bufio.Reader
+}
+
+func NewCaptureReader(r io.Reader) *CaptureReader {
+	return &CaptureReader{r: bufio.NewReader(r)}
+}
+
+//Read returns io.EOF at the end of the file
+func (r *CaptureReader) Read() (*CapturedPacket, error) {
+	hb := make([]byte, 4)
+	if _, err := io.ReadFull(r.r, hb); err != nil {
+		return nil, err
+	}
+	l := binary.BigEndian.Uint32(hb)
+	if l > captureRecordMax {
+		return nil, errors.CriticalFormatError.Errorf("InvalidRecordLength(len=%d)", l)
+	}
+	b := make([]byte, l)
+	if _, err := io.ReadFull(r.r, b); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	cp := new(CapturedPacket)
+	if _, err := codec.BC.UnmarshalFromBytes(b, cp); err != nil {
+		return nil, errors.CriticalFormatError.Wrap(err, "InvalidRecord")
+	}
+	return cp, nil
+}
+
+//PayloadDecoder decodes payload of the packet to the message of the sub-protocol
+type PayloadDecoder func(spi module.ProtocolInfo, b []byte) (interface{}, error)
+
+type payloadDecoderEntry struct {
+	name     string
+	streamed bool
+	decoder  PayloadDecoder
+}
+
+var (
+	payloadDecoders   = make(map[uint16]*payloadDecoderEntry)
+	payloadDecoderMtx sync.RWMutex
+)
+
+//RegisterPayloadDecoder registers the decoder of the protocol for the capture tools,
+//streamed should be true if the protocol is registered by RegisterReactorForStreams.
+func RegisterPayloadDecoder(pi module.ProtocolInfo, name string, streamed bool, d PayloadDecoder) {
+	payloadDecoderMtx.Lock()
+	defer payloadDecoderMtx.Unlock()
+
+	payloadDecoders[pi.Uint16()] = &payloadDecoderEntry{
+		name:     name,
+		streamed: streamed,
+		decoder:  d,
+	}
+}
+
+func getPayloadDecoder(pi module.ProtocolInfo) (*payloadDecoderEntry, bool) {
+	payloadDecoderMtx.RLock()
+	defer payloadDecoderMtx.RUnlock()
+
+	e, ok := payloadDecoders[pi.Uint16()]
+	return e, ok
+}
+
+var p2pProtocolNames = map[uint16]string{
+	p2pProtoQueryReq.Uint16():  "p2p.query",
+	p2pProtoQueryResp.Uint16(): "p2p.queryResult",
+	p2pProtoConnReq.Uint16():   "p2p.connRequest",
+	p2pProtoConnResp.Uint16():  "p2p.connResponse",
+	p2pProtoRttReq.Uint16():    "p2p.rttRequest",
+	p2pProtoRttResp.Uint16():   "p2p.rttResponse",
+}
+
+//unwrapPayload returns the payload for the reactor registered by RegisterReactorForStreams,
+//returns nil if the packet has no payload (ex: ack only)
+func unwrapPayload(cp *CapturedPacket) ([]byte, *payloadDecoderEntry, error) {
+	e, ok := getPayloadDecoder(cp.Protocol)
+	if !ok || !e.streamed {
+		return cp.Payload, e, nil
+	}
+	sm := &streamMessage{}
+	if _, err := codec.UnmarshalFromBytes(cp.Payload, sm); err != nil {
+		return nil, e, err
+	}
+	return sm.Payload, e, nil
+}
+
+//DecodeCapturedPacket returns the name of the protocol and the decoded message.
+//If there's no registered decoder, it returns the payload.
+func DecodeCapturedPacket(cp *CapturedPacket) (string, interface{}, error) {
+	if cp.Protocol == p2pProtoControl {
+		name, ok := p2pProtocolNames[cp.SubProtocol.Uint16()]
+		if !ok {
+			name = "p2p"
+		}
+		return name, cp.Payload, nil
+	}
+	b, e, err := unwrapPayload(cp)
+	if e == nil {
+		return fmt.Sprintf("%#04x", cp.Protocol.Uint16()), cp.Payload, nil
+	}
+	if err != nil {
+		return e.name, nil, err
+	}
+	if b == nil {
+		return e.name, nil, nil
+	}
+	v, err := e.decoder(cp.SubProtocol, b)
+	return e.name, v, err
+}
+
+//DecodePayload returns the name of the protocol and the message decoded from the
+//payload given to the reactor. It returns the payload if there's no registered decoder.
+func DecodePayload(pi module.ProtocolInfo, spi module.ProtocolInfo, b []byte) (string, interface{}, error) {
+	e, ok := getPayloadDecoder(pi)
+	if !ok {
+		return fmt.Sprintf("%#04x", pi.Uint16()), b, nil
+	}
+	v, err := e.decoder(spi, b)
+	return e.name, v, err
+}
+
+//ReplayCapture calls OnReceive of the reactor with the received packets of the protocol
+//in the capture, payload of streamed protocol is unwrapped. it returns the number of
+//delivered packets, errors returned by the reactor are ignored.
+func ReplayCapture(r io.Reader, pi module.ProtocolInfo, reactor module.Reactor) (int, error) {
+	cr := NewCaptureReader(r)
+	cnt := 0
+	for {
+		cp, err := cr.Read()
+		if err != nil {
+			if err == io.EOF {
+				return cnt, nil
+			}
+			return cnt, err
+		}
+		if cp.Direction != CaptureIn || cp.Protocol != pi {
+			continue
+		}
+		b, _, err := unwrapPayload(cp)
+		if err != nil || b == nil {
+			continue
+		}
+		//error of the reactor is a result of the replay, not a failure of the replay
+		_, _ = reactor.OnReceive(cp.SubProtocol, b, cp.PeerID())
+		cnt++
+	}
+}
+
+func GetCapturer(nt module.NetworkTransport) *Capturer {
+	if t, ok := nt.(*transport); ok {
+		return t.pd.capturer
+	}
+	return nil
+}
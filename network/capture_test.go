@@ -0,0 +1,223 @@
+package network
+
+import (
+	"encoding/binary"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/module"
+)
+
+func newCaptureTestPeer() *Peer {
+	return &Peer{id: generatePeerID(), logger: log.GlobalLogger()}
+}
+
+func readCaptureFile(t *testing.T, fp string) []*CapturedPacket {
+	f, err := os.Open(fp)
+	assert.NoError(t, err)
+	defer f.Close()
+	r := NewCaptureReader(f)
+	cps := make([]*CapturedPacket, 0)
+	for {
+		cp, err := r.Read()
+		if err != nil {
+			return cps
+		}
+		cps = append(cps, cp)
+	}
+}
+
+func Test_capture_Capturer(t *testing.T) {
+	dir := t.TempDir()
+	c := newCapturer()
+	p := newCaptureTestPeer()
+	pi := module.ProtocolInfo(0x0100)
+	spi := module.ProtocolInfo(0x0101)
+
+	pkt := NewPacket(pi, spi, []byte("before"))
+	c.capture(CaptureIn, p, pkt)
+
+	assert.NoError(t, c.Start(dir))
+	assert.True(t, c.IsRunning())
+	assert.Error(t, c.Start(dir))
+
+	pkt = NewPacket(pi, spi, []byte("in"))
+	pkt.src = generatePeerID()
+	pkt.dest = p2pDestPeer
+	pkt.ttl = 1
+	c.capture(CaptureIn, p, pkt)
+	c.capture(CaptureOut, p, NewPacket(pi, spi, []byte("out")))
+	assert.NoError(t, c.Stop())
+	assert.False(t, c.IsRunning())
+	c.capture(CaptureIn, p, NewPacket(pi, spi, []byte("after")))
+
+	cps := readCaptureFile(t, path.Join(dir, DefaultCaptureFileName))
+	assert.Len(t, cps, 2)
+	assert.Equal(t, CaptureIn, cps[0].Direction)
+	assert.Equal(t, p.ID(), cps[0].PeerID())
+	assert.Equal(t, pi, cps[0].Protocol)
+	assert.Equal(t, spi, cps[0].SubProtocol)
+	assert.Equal(t, pkt.src.Bytes(), cps[0].Src)
+	assert.Equal(t, byte(p2pDestPeer), cps[0].Dest)
+	assert.Equal(t, byte(1), cps[0].TTL)
+	assert.Equal(t, []byte("in"), cps[0].Payload)
+	assert.Equal(t, CaptureOut, cps[1].Direction)
+	assert.Equal(t, []byte("out"), cps[1].Payload)
+
+	//appended to the existing file
+	assert.NoError(t, c.Start(dir))
+	c.capture(CaptureOut, p, NewPacket(pi, spi, []byte("restart")))
+	assert.NoError(t, c.Stop())
+	cps = readCaptureFile(t, path.Join(dir, DefaultCaptureFileName))
+	assert.Len(t, cps, 3)
+	assert.Equal(t, []byte("restart"), cps[2].Payload)
+}
+
+func Test_capture_rotate(t *testing.T) {
+	dir := t.TempDir()
+	c := newCapturer()
+	c.SetLimit(200, 3)
+	p := newCaptureTestPeer()
+	pi := module.ProtocolInfo(0x0100)
+	payload := make([]byte, 100)
+
+	assert.NoError(t, c.Start(dir))
+	for i := 0; i < 5; i++ {
+		payload[0] = byte(i)
+		c.capture(CaptureOut, p, NewPacket(pi, pi, payload))
+	}
+	assert.NoError(t, c.Stop())
+
+	var last []byte
+	for i := 2; i >= 0; i-- {
+		fp := c._filePath(i)
+		cps := readCaptureFile(t, fp)
+		assert.Len(t, cps, 1, fp)
+		last = append(last, cps[0].Payload[0])
+	}
+	assert.Equal(t, []byte{2, 3, 4}, last)
+	_, err := os.Stat(c._filePath(3))
+	assert.True(t, os.IsNotExist(err))
+}
+
+type captureTestMessage struct {
+	Value string
+}
+
+type captureTestReactor struct {
+	received []*captureTestMessage
+}
+
+func (r *captureTestReactor) OnReceive(spi module.ProtocolInfo, b []byte, id module.PeerID) (bool, error) {
+	m := &captureTestMessage{}
+	if _, err := codec.UnmarshalFromBytes(b, m); err != nil {
+		return false, err
+	}
+	r.received = append(r.received, m)
+	return true, nil
+}
+
+func (r *captureTestReactor) OnFailure(err error, pi module.ProtocolInfo, b []byte) {}
+
+func (r *captureTestReactor) OnJoin(id module.PeerID) {}
+
+func (r *captureTestReactor) OnLeave(id module.PeerID) {}
+
+func Test_capture_DecodeAndReplay(t *testing.T) {
+	pi := module.ProtocolInfo(0xF100)
+	streamedPi := module.ProtocolInfo(0xF200)
+	unknownPi := module.ProtocolInfo(0xF300)
+	decoder := func(spi module.ProtocolInfo, b []byte) (interface{}, error) {
+		m := &captureTestMessage{}
+		_, err := codec.UnmarshalFromBytes(b, m)
+		return m, err
+	}
+	RegisterPayloadDecoder(pi, "test", false, decoder)
+	RegisterPayloadDecoder(streamedPi, "test.streamed", true, decoder)
+
+	m1 := codec.MustMarshalToBytes(&captureTestMessage{"m1"})
+	m2 := codec.MustMarshalToBytes(&captureTestMessage{"m2"})
+	sm := codec.MustMarshalToBytes(&streamMessage{Seq: 1, Payload: m2})
+	ack := codec.MustMarshalToBytes(&streamMessage{Ack: 1})
+
+	cps := []*CapturedPacket{
+		{Direction: CaptureIn, Protocol: pi, Payload: m1},
+		{Direction: CaptureOut, Protocol: pi, Payload: m1},
+		{Direction: CaptureIn, Protocol: streamedPi, Payload: sm},
+		{Direction: CaptureIn, Protocol: streamedPi, Payload: ack},
+		{Direction: CaptureIn, Protocol: unknownPi, Payload: m1},
+		{Direction: CaptureIn, Protocol: p2pProtoControl, SubProtocol: p2pProtoQueryReq, Payload: m1},
+	}
+
+	name, v, err := DecodeCapturedPacket(cps[0])
+	assert.NoError(t, err)
+	assert.Equal(t, "test", name)
+	assert.Equal(t, &captureTestMessage{"m1"}, v)
+
+	name, v, err = DecodeCapturedPacket(cps[2])
+	assert.NoError(t, err)
+	assert.Equal(t, "test.streamed", name)
+	assert.Equal(t, &captureTestMessage{"m2"}, v)
+
+	name, v, err = DecodeCapturedPacket(cps[3])
+	assert.NoError(t, err)
+	assert.Equal(t, "test.streamed", name)
+	assert.Nil(t, v)
+
+	name, v, err = DecodeCapturedPacket(cps[4])
+	assert.NoError(t, err)
+	assert.Equal(t, "0xf300", name)
+	assert.Equal(t, m1, v)
+
+	name, _, err = DecodeCapturedPacket(cps[5])
+	assert.NoError(t, err)
+	assert.Equal(t, "p2p.query", name)
+
+	name, v, err = DecodePayload(streamedPi, 0, m2)
+	assert.NoError(t, err)
+	assert.Equal(t, "test.streamed", name)
+	assert.Equal(t, &captureTestMessage{"m2"}, v)
+
+	name, v, err = DecodePayload(unknownPi, 0, m1)
+	assert.NoError(t, err)
+	assert.Equal(t, "0xf300", name)
+	assert.Equal(t, m1, v)
+
+	fp := path.Join(t.TempDir(), DefaultCaptureFileName)
+	f, err := os.Create(fp)
+	assert.NoError(t, err)
+	for _, cp := range cps {
+		bs := codec.BC.MustMarshalToBytes(cp)
+		b := make([]byte, 4, 4+len(bs))
+		binary.BigEndian.PutUint32(b, uint32(len(bs)))
+		_, err = f.Write(append(b, bs...))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, f.Close())
+
+	for _, tc := range []struct {
+		pi       module.ProtocolInfo
+		expected []string
+	}{
+		{pi, []string{"m1"}},
+		{streamedPi, []string{"m2"}},
+	} {
+		f, err = os.Open(fp)
+		assert.NoError(t, err)
+		r := &captureTestReactor{}
+		n, err := ReplayCapture(f, tc.pi, r)
+		assert.NoError(t, err)
+		assert.Equal(t, len(tc.expected), n)
+		values := make([]string, len(r.received))
+		for i, m := range r.received {
+			values[i] = m.Value
+		}
+		assert.Equal(t, tc.expected, values)
+		f.Close()
+	}
+}
@@ -21,6 +21,10 @@ const (
 	DuplicatedPeerError
 	InvalidMessageSequenceError
 	InvalidSignatureError
+	InvalidMessageError
+	DecodeMessageError
+	BannedPeerError
+	PortMappingError
 )
 
 var (
@@ -40,5 +44,8 @@ var (
 	ErrDuplicatedPeer            = errors.NewBase(DuplicatedPeerError, "DuplicatedPeer")
 	ErrInvalidMessageSequence    = errors.NewBase(InvalidMessageSequenceError, "InvalidMessageSequence")
 	ErrInvalidSignature          = errors.NewBase(InvalidSignatureError, "InvalidSignatureError")
+	ErrInvalidMessage            = errors.NewBase(InvalidMessageError, "InvalidMessage")
+	ErrDecodeMessage             = errors.NewBase(DecodeMessageError, "DecodeMessage")
+	ErrBannedPeer                = errors.NewBase(BannedPeerError, "BannedPeer")
 	ErrIllegalArgument           = errors.ErrIllegalArgument
 )
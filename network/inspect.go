@@ -20,6 +20,11 @@ func Inspect(c module.Chain, informal bool) map[string]interface{} {
 	m["p2p"] = inspectP2P(mgr, informal)
 	if informal {
 		m["protocol"] = inspectProtocol(mgr)
+		m["bandwidth"] = map[string]interface{}{
+			"limit":      mgr.pd.rateLimiter.Map(),
+			"throughput": mgr.mtr.Throughput(),
+		}
+		m["nat"] = mgr.pd.nat.Map()
 	}
 	return m
 }
@@ -41,6 +46,14 @@ func inspectP2P(mgr *manager, informal bool) map[string]interface{} {
 		m["reject"] = peerSetToMapArray(mgr.p2p.reject, informal)
 	}
 	m["trustSeeds"] = mgr.p2p.trustSeeds.Map()
+	if informal {
+		m["dnsSeeds"] = mgr.p2p.dnsSeeds.Map()
+		m["addressBook"] = mgr.p2p.addressBook.Entries()
+	}
+	m["bans"] = mgr.p2p.banList.Entries()
+	if informal {
+		m["scores"] = mgr.p2p.reputation.Map()
+	}
 	return m
 }
 
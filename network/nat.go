@@ -0,0 +1,403 @@
+package network
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/module"
+)
+
+const (
+	DefaultObservedAddressMin    = 2
+	DefaultObservedAddressMax    = 100
+	DefaultPortMappingLifetime   = 20 * time.Minute
+	DefaultPortMappingRetryDelay = 1 * time.Minute
+	DefaultPortMappingDesc       = "goloop"
+	DefaultNATDiscoveryTimeout   = 3 * time.Second
+	DefaultNATRequestTimeout     = 3 * time.Second
+)
+
+const (
+	NATNone  = "none"
+	NATUPnP  = "upnp"
+	NATPMP   = "pmp"
+	NATAny   = "any"
+	natProto = "TCP"
+)
+
+//PortMapper opens the TCP port of the gateway for the inbound connections
+type PortMapper interface {
+	Name() string
+	ExternalIP() (net.IP, error)
+	//AddPortMapping returns the mapped external port which could be different
+	//from the requested one
+	AddPortMapping(internalPort, externalPort int, desc string, lifetime time.Duration) (int, error)
+	DeletePortMapping(internalPort, externalPort int) error
+}
+
+func ValidateNAT(nat string) error {
+	switch nat {
+	case "", NATNone, NATUPnP, NATPMP, NATAny:
+		return nil
+	default:
+		return errors.IllegalArgumentError.Errorf("InvalidNAT(nat=%s)", nat)
+	}
+}
+
+//DiscoverPortMapper finds the gateway which supports the protocol,
+//NATAny tries UPnP first and then NAT-PMP
+func DiscoverPortMapper(nat string) (PortMapper, error) {
+	switch nat {
+	case NATUPnP:
+		g, err := discoverUPnP(ssdpMulticastAddress, DefaultNATDiscoveryTimeout)
+		if err != nil {
+			return nil, err
+		}
+		return g, nil
+	case NATPMP:
+		gw, err := defaultGateway()
+		if err != nil {
+			return nil, err
+		}
+		pm := newNATPMP(net.JoinHostPort(gw.String(), strconv.Itoa(natPMPPort)))
+		if _, err = pm.ExternalIP(); err != nil {
+			return nil, err
+		}
+		return pm, nil
+	case NATAny:
+		pm, err := DiscoverPortMapper(NATUPnP)
+		if err == nil {
+			return pm, nil
+		}
+		if pm, perr := DiscoverPortMapper(NATPMP); perr == nil {
+			return pm, nil
+		}
+		return nil, err
+	default:
+		return nil, errors.IllegalArgumentError.Errorf("InvalidNAT(nat=%s)", nat)
+	}
+}
+
+//defaultGateway parses the routing table of linux
+func defaultGateway() (net.IP, error) {
+	f, err := os.Open("/proc/net/route")
+	if err != nil {
+		return nil, PortMappingError.Wrap(err, "fail to read routing table")
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 3 || fields[1] != "00000000" {
+			continue
+		}
+		b, err := hex.DecodeString(fields[2])
+		if err != nil || len(b) != net.IPv4len {
+			continue
+		}
+		ip := make(net.IP, net.IPv4len)
+		binary.LittleEndian.PutUint32(ip, binary.BigEndian.Uint32(b))
+		return ip, nil
+	}
+	return nil, PortMappingError.New("NoDefaultGateway")
+}
+
+//localIPFor returns the local address used to reach the host
+func localIPFor(host string) (net.IP, error) {
+	conn, err := net.Dial("udp4", net.JoinHostPort(host, "1"))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).IP, nil
+}
+
+func hostOf(na NetAddress) string {
+	host, _, err := net.SplitHostPort(string(na))
+	if err != nil {
+		return ""
+	}
+	return host
+}
+
+func portOf(na NetAddress) int {
+	_, port, err := net.SplitHostPort(string(na))
+	if err != nil {
+		return 0
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return 0
+	}
+	return p
+}
+
+//NATTraversal resolves the external address of the transport with the observed
+//addresses reported by peers and the port mapping of the gateway.
+type NATTraversal struct {
+	base   NetAddress
+	listen func() string
+	auto   bool
+
+	//reporter => observed ip
+	observed   map[string]string
+	observedIP string
+
+	nat        string
+	mapper     PortMapper
+	mappedIP   net.IP
+	mappedPort int
+	discover   func(nat string) (PortMapper, error)
+	stopCh     chan bool
+	wg         sync.WaitGroup
+
+	address  NetAddress
+	onChange func(na NetAddress)
+	logger   log.Logger
+	mtx      sync.Mutex
+}
+
+func newNATTraversal(base NetAddress, listen func() string, l log.Logger) *NATTraversal {
+	return &NATTraversal{
+		base:     base,
+		listen:   listen,
+		observed: make(map[string]string),
+		discover: DiscoverPortMapper,
+		address:  base,
+		logger:   l.WithFields(log.Fields{LoggerFieldKeySubModule: "nat"}),
+	}
+}
+
+//SetAutoAddress enables to advertise the address agreed by majority of peers
+func (n *NATTraversal) SetAutoAddress(auto bool) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	n.auto = auto
+	n._update()
+}
+
+func (n *NATTraversal) Address() NetAddress {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	return n.address
+}
+
+//observe is called with the address of this node which is reported by the peer
+func (n *NATTraversal) observe(reporter module.PeerID, na NetAddress) {
+	if reporter == nil || na == "" {
+		return
+	}
+	ip := net.ParseIP(hostOf(na))
+	if ip == nil || ip.IsUnspecified() || ip.IsMulticast() {
+		return
+	}
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	k := reporter.String()
+	if _, ok := n.observed[k]; !ok && len(n.observed) >= DefaultObservedAddressMax {
+		return
+	}
+	n.observed[k] = ip.String()
+	n._resolveObserved()
+}
+
+func (n *NATTraversal) forget(reporter module.PeerID) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	k := reporter.String()
+	if _, ok := n.observed[k]; !ok {
+		return
+	}
+	delete(n.observed, k)
+	n._resolveObserved()
+}
+
+//_resolveObserved keeps the previous one if there's no majority
+func (n *NATTraversal) _resolveObserved() {
+	cnt := make(map[string]int)
+	max, ip := 0, ""
+	for _, v := range n.observed {
+		cnt[v]++
+		if cnt[v] > max {
+			max, ip = cnt[v], v
+		}
+	}
+	if max < DefaultObservedAddressMin || max*2 <= len(n.observed) {
+		return
+	}
+	if n.observedIP != ip {
+		n.logger.Infoln("observed address", ip, "agreed:", max, "reporters:", len(n.observed))
+		n.observedIP = ip
+		n._update()
+	}
+}
+
+func (n *NATTraversal) _resolve() NetAddress {
+	host, port := hostOf(n.base), portOf(n.base)
+	if n.mappedPort > 0 {
+		port = n.mappedPort
+		if n.mappedIP != nil && !n.mappedIP.IsPrivate() && !n.mappedIP.IsUnspecified() {
+			host = n.mappedIP.String()
+		}
+	}
+	if n.auto && n.observedIP != "" {
+		host = n.observedIP
+	}
+	return NetAddress(net.JoinHostPort(host, strconv.Itoa(port)))
+}
+
+func (n *NATTraversal) _update() {
+	na := n._resolve()
+	if na == n.address {
+		return
+	}
+	n.logger.Infoln("update address", n.address, "->", na)
+	n.address = na
+	if n.onChange != nil {
+		n.onChange(na)
+	}
+}
+
+//Start opens the listen port via the gateway in background, it keeps the mapping
+//alive until Stop is called.
+func (n *NATTraversal) Start(nat string) error {
+	if err := ValidateNAT(nat); err != nil {
+		return err
+	}
+	if nat == "" || nat == NATNone {
+		return nil
+	}
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	if n.stopCh != nil {
+		return errors.InvalidStateError.Errorf("AlreadyStarted(nat=%s)", n.nat)
+	}
+	n.nat = nat
+	n.stopCh = make(chan bool)
+	n.wg.Add(1)
+	go n.mappingRoutine(n.stopCh)
+	return nil
+}
+
+func (n *NATTraversal) Stop() {
+	n.mtx.Lock()
+	if n.stopCh == nil {
+		n.mtx.Unlock()
+		return
+	}
+	close(n.stopCh)
+	n.stopCh = nil
+	n.mtx.Unlock()
+
+	n.wg.Wait()
+}
+
+func (n *NATTraversal) internalPort() int {
+	if port := portOf(NetAddress(n.listen())); port > 0 {
+		return port
+	}
+	return portOf(n.base)
+}
+
+func (n *NATTraversal) mappingRoutine(stopCh chan bool) {
+	defer n.wg.Done()
+
+	var pm PortMapper
+	extPort := portOf(n.base)
+	for {
+		delay := DefaultPortMappingRetryDelay
+		if pm == nil {
+			var err error
+			if pm, err = n.discover(n.nat); err != nil {
+				n.logger.Infoln("fail to discover gateway", n.nat, err)
+			}
+		}
+		if pm != nil {
+			if port, ip, err := n.addPortMapping(pm, extPort); err != nil {
+				n.logger.Infoln("fail to map port", pm.Name(), err)
+				pm = nil
+			} else {
+				extPort = port
+				n.setMapping(pm, ip, port)
+				delay = DefaultPortMappingLifetime / 2
+			}
+		}
+		select {
+		case <-stopCh:
+			if pm != nil {
+				if err := pm.DeletePortMapping(n.internalPort(), extPort); err != nil {
+					n.logger.Infoln("fail to delete port mapping", pm.Name(), err)
+				}
+			}
+			n.setMapping(nil, nil, 0)
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (n *NATTraversal) addPortMapping(pm PortMapper, extPort int) (int, net.IP, error) {
+	port, err := pm.AddPortMapping(n.internalPort(), extPort, DefaultPortMappingDesc, DefaultPortMappingLifetime)
+	if err != nil {
+		return 0, nil, err
+	}
+	ip, err := pm.ExternalIP()
+	if err != nil {
+		n.logger.Infoln("fail to get external ip", pm.Name(), err)
+	}
+	return port, ip, nil
+}
+
+func (n *NATTraversal) setMapping(pm PortMapper, ip net.IP, port int) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	if n.mappedPort != port && port > 0 {
+		n.logger.Infoln("port mapped", pm.Name(), ip, port)
+	}
+	n.mapper = pm
+	n.mappedIP = ip
+	n.mappedPort = port
+	n._update()
+}
+
+func (n *NATTraversal) Map() map[string]interface{} {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	m := make(map[string]interface{})
+	m["address"] = string(n.address)
+	m["auto"] = n.auto
+	m["observed"] = n.observedIP
+	m["reporters"] = len(n.observed)
+	if n.nat != "" {
+		m["nat"] = n.nat
+	}
+	if n.mapper != nil {
+		m["gateway"] = n.mapper.Name()
+		m["mapped"] = fmt.Sprintf("%s:%d", n.mappedIP, n.mappedPort)
+	}
+	return m
+}
+
+func GetNATTraversal(nt module.NetworkTransport) *NATTraversal {
+	if t, ok := nt.(*transport); ok {
+		return t.pd.nat
+	}
+	return nil
+}
@@ -0,0 +1,382 @@
+package network
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common/log"
+)
+
+const testExternalIP = "203.0.113.7"
+
+func Test_nat_observe(t *testing.T) {
+	n := newNATTraversal("10.0.0.1:8080", func() string { return ":8080" }, log.GlobalLogger())
+	var changed []NetAddress
+	n.onChange = func(na NetAddress) {
+		changed = append(changed, na)
+	}
+
+	id1, id2, id3 := generatePeerID(), generatePeerID(), generatePeerID()
+	n.observe(id1, "1.2.3.4:50000")
+	n.observe(id2, "5.6.7.8:50001")
+	assert.Equal(t, NetAddress("10.0.0.1:8080"), n.Address())
+	assert.Equal(t, "", n.observedIP)
+
+	n.observe(id3, "1.2.3.4:50002")
+	assert.Equal(t, "1.2.3.4", n.observedIP)
+	//not applied until auto address is enabled
+	assert.Equal(t, NetAddress("10.0.0.1:8080"), n.Address())
+	assert.Len(t, changed, 0)
+
+	n.SetAutoAddress(true)
+	assert.Equal(t, NetAddress("1.2.3.4:8080"), n.Address())
+	assert.Equal(t, []NetAddress{"1.2.3.4:8080"}, changed)
+
+	//invalid reports are ignored
+	n.observe(id2, "invalid")
+	n.observe(id2, "0.0.0.0:1")
+	n.observe(nil, "5.6.7.8:1")
+	assert.Len(t, n.observed, 3)
+
+	//no majority, keep the previous one
+	n.forget(id3)
+	assert.Equal(t, NetAddress("1.2.3.4:8080"), n.Address())
+
+	n.observe(id1, "5.6.7.8:50000")
+	assert.Equal(t, NetAddress("5.6.7.8:8080"), n.Address())
+	assert.Equal(t, []NetAddress{"1.2.3.4:8080", "5.6.7.8:8080"}, changed)
+
+	n.SetAutoAddress(false)
+	assert.Equal(t, NetAddress("10.0.0.1:8080"), n.Address())
+}
+
+type fakeGatewayMapping struct {
+	internal int
+	external int
+	lifetime int
+}
+
+//fakeGateway works as UPnP IGD and NAT-PMP gateway on the loopback interface
+type fakeGateway struct {
+	t          *testing.T
+	ssdp       *net.UDPConn
+	pmp        *net.UDPConn
+	http       *httptest.Server
+	portOffset int
+	permanent  bool
+	mappings   map[int]fakeGatewayMapping
+	mtx        sync.Mutex
+}
+
+func newFakeGateway(t *testing.T) *fakeGateway {
+	g := &fakeGateway{
+		t:        t,
+		mappings: make(map[int]fakeGatewayMapping),
+	}
+	var err error
+	g.ssdp, err = net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.NoError(t, err)
+	g.pmp, err = net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.NoError(t, err)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/desc.xml", g.serveDescription)
+	mux.HandleFunc("/ctl/IPConn", g.serveControl)
+	g.http = httptest.NewServer(mux)
+	go g.serveSSDP()
+	go g.servePMP()
+	return g
+}
+
+func (g *fakeGateway) Close() {
+	g.ssdp.Close()
+	g.pmp.Close()
+	g.http.Close()
+}
+
+func (g *fakeGateway) Set(portOffset int, permanent bool) {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	g.portOffset = portOffset
+	g.permanent = permanent
+}
+
+func (g *fakeGateway) Mappings() map[int]fakeGatewayMapping {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	m := make(map[int]fakeGatewayMapping)
+	for k, v := range g.mappings {
+		m[k] = v
+	}
+	return m
+}
+
+func (g *fakeGateway) serveSSDP() {
+	b := make([]byte, 2048)
+	for {
+		n, addr, err := g.ssdp.ReadFrom(b)
+		if err != nil {
+			return
+		}
+		req := string(b[:n])
+		if !strings.HasPrefix(req, "M-SEARCH") || !strings.Contains(req, ssdpSearchTarget) {
+			continue
+		}
+		resp := fmt.Sprintf("HTTP/1.1 200 OK\r\n"+
+			"CACHE-CONTROL: max-age=120\r\n"+
+			"ST: %s\r\n"+
+			"LOCATION: %s/desc.xml\r\n\r\n", ssdpSearchTarget, g.http.URL)
+		_, _ = g.ssdp.WriteTo([]byte(resp), addr)
+	}
+}
+
+func (g *fakeGateway) serveDescription(w http.ResponseWriter, r *http.Request) {
+	_, _ = fmt.Fprint(w, `<?xml version="1.0"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+ <device>
+  <deviceType>urn:schemas-upnp-org:device:InternetGatewayDevice:1</deviceType>
+  <deviceList>
+   <device>
+    <deviceType>urn:schemas-upnp-org:device:WANDevice:1</deviceType>
+    <deviceList>
+     <device>
+      <deviceType>urn:schemas-upnp-org:device:WANConnectionDevice:1</deviceType>
+      <serviceList>
+       <service>
+        <serviceType>urn:schemas-upnp-org:service:WANIPConnection:1</serviceType>
+        <controlURL>/ctl/IPConn</controlURL>
+       </service>
+      </serviceList>
+     </device>
+    </deviceList>
+   </device>
+  </deviceList>
+ </device>
+</root>`)
+}
+
+func (g *fakeGateway) soapResponse(w http.ResponseWriter, action string, body string) {
+	_, _ = fmt.Fprintf(w, `<?xml version="1.0"?>`+
+		`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body>`+
+		`<u:%sResponse xmlns:u="urn:schemas-upnp-org:service:WANIPConnection:1">%s</u:%sResponse>`+
+		`</s:Body></s:Envelope>`, action, body, action)
+}
+
+func (g *fakeGateway) soapError(w http.ResponseWriter, code int, desc string) {
+	w.WriteHeader(http.StatusInternalServerError)
+	_, _ = fmt.Fprintf(w, `<?xml version="1.0"?>`+
+		`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><s:Fault>`+
+		`<faultcode>s:Client</faultcode><faultstring>UPnPError</faultstring><detail>`+
+		`<UPnPError xmlns="urn:schemas-upnp-org:control-1-0">`+
+		`<errorCode>%d</errorCode><errorDescription>%s</errorDescription>`+
+		`</UPnPError></detail></s:Fault></s:Body></s:Envelope>`, code, desc)
+}
+
+func (g *fakeGateway) serveControl(w http.ResponseWriter, r *http.Request) {
+	action := r.Header.Get("SOAPAction")
+	action = strings.Trim(action[strings.Index(action, "#")+1:], `"`)
+	b, err := ioutil.ReadAll(r.Body)
+	assert.NoError(g.t, err)
+	values, err := upnpValues(b)
+	assert.NoError(g.t, err)
+
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	switch action {
+	case "GetExternalIPAddress":
+		g.soapResponse(w, action, "<NewExternalIPAddress>"+testExternalIP+"</NewExternalIPAddress>")
+	case "AddPortMapping":
+		var m fakeGatewayMapping
+		var ext int
+		_, _ = fmt.Sscan(values["NewInternalPort"], &m.internal)
+		_, _ = fmt.Sscan(values["NewExternalPort"], &ext)
+		_, _ = fmt.Sscan(values["NewLeaseDuration"], &m.lifetime)
+		if g.permanent && m.lifetime != 0 {
+			g.soapError(w, upnpErrorOnlyPermanentLeases, "OnlyPermanentLeasesSupported")
+			return
+		}
+		if values["NewProtocol"] != natProto || values["NewInternalClient"] == "" {
+			g.soapError(w, 402, "Invalid Args")
+			return
+		}
+		m.external = ext
+		g.mappings[ext] = m
+		g.soapResponse(w, action, "")
+	case "DeletePortMapping":
+		var ext int
+		_, _ = fmt.Sscan(values["NewExternalPort"], &ext)
+		if _, ok := g.mappings[ext]; !ok {
+			g.soapError(w, 714, "NoSuchEntryInArray")
+			return
+		}
+		delete(g.mappings, ext)
+		g.soapResponse(w, action, "")
+	default:
+		g.soapError(w, 401, "Invalid Action")
+	}
+}
+
+func (g *fakeGateway) servePMP() {
+	b := make([]byte, 16)
+	for {
+		n, addr, err := g.pmp.ReadFrom(b)
+		if err != nil {
+			return
+		}
+		if n < 2 || b[0] != natPMPVersion {
+			continue
+		}
+		resp := make([]byte, 16)
+		resp[1] = natPMPOpResponse + b[1]
+		binary.BigEndian.PutUint32(resp[4:8], uint32(time.Now().Unix()))
+		switch b[1] {
+		case natPMPOpAddress:
+			copy(resp[8:12], net.ParseIP(testExternalIP).To4())
+			resp = resp[:12]
+		case natPMPOpMapTCP:
+			m := fakeGatewayMapping{
+				internal: int(binary.BigEndian.Uint16(b[4:6])),
+				external: int(binary.BigEndian.Uint16(b[6:8])),
+				lifetime: int(binary.BigEndian.Uint32(b[8:12])),
+			}
+			g.mtx.Lock()
+			if m.lifetime == 0 {
+				for k, v := range g.mappings {
+					if v.internal == m.internal {
+						delete(g.mappings, k)
+					}
+				}
+			} else {
+				m.external += g.portOffset
+				g.mappings[m.external] = m
+			}
+			g.mtx.Unlock()
+			copy(resp[8:10], b[4:6])
+			binary.BigEndian.PutUint16(resp[10:12], uint16(m.external))
+			binary.BigEndian.PutUint32(resp[12:16], uint32(m.lifetime))
+		default:
+			binary.BigEndian.PutUint16(resp[2:4], 5)
+			resp = resp[:8]
+		}
+		_, _ = g.pmp.WriteTo(resp, addr)
+	}
+}
+
+func Test_nat_UPnP(t *testing.T) {
+	fg := newFakeGateway(t)
+	defer fg.Close()
+
+	g, err := discoverUPnP(fg.ssdp.LocalAddr().String(), time.Second)
+	assert.NoError(t, err)
+	if g == nil {
+		return
+	}
+	assert.Equal(t, fg.http.URL+"/ctl/IPConn", g.controlURL)
+
+	ip, err := g.ExternalIP()
+	assert.NoError(t, err)
+	assert.Equal(t, testExternalIP, ip.String())
+
+	port, err := g.AddPortMapping(8080, 18080, DefaultPortMappingDesc, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 18080, port)
+	assert.Equal(t, fakeGatewayMapping{8080, 18080, 60}, fg.Mappings()[18080])
+
+	//retry with permanent lease
+	fg.Set(0, true)
+	port, err = g.AddPortMapping(8081, 18081, DefaultPortMappingDesc, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 18081, port)
+	assert.Equal(t, fakeGatewayMapping{8081, 18081, 0}, fg.Mappings()[18081])
+
+	assert.NoError(t, g.DeletePortMapping(8080, 18080))
+	assert.Len(t, fg.Mappings(), 1)
+	err = g.DeletePortMapping(8080, 18080)
+	assert.True(t, PortMappingError.Equals(err))
+	assert.Contains(t, err.Error(), "714")
+
+	//no gateway
+	closed, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.NoError(t, err)
+	_, err = discoverUPnP(closed.LocalAddr().String(), 100*time.Millisecond)
+	assert.True(t, PortMappingError.Equals(err))
+	closed.Close()
+}
+
+func Test_nat_PMP(t *testing.T) {
+	fg := newFakeGateway(t)
+	defer fg.Close()
+	fg.Set(1, false)
+
+	g := newNATPMP(fg.pmp.LocalAddr().String())
+	ip, err := g.ExternalIP()
+	assert.NoError(t, err)
+	assert.Equal(t, testExternalIP, ip.String())
+
+	port, err := g.AddPortMapping(8080, 18080, DefaultPortMappingDesc, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 18081, port)
+	assert.Equal(t, fakeGatewayMapping{8080, 18081, 60}, fg.Mappings()[18081])
+
+	assert.NoError(t, g.DeletePortMapping(8080, port))
+	assert.Len(t, fg.Mappings(), 0)
+
+	//no gateway
+	closed, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.NoError(t, err)
+	g = newNATPMP(closed.LocalAddr().String())
+	g.timeout = 300 * time.Millisecond
+	_, err = g.ExternalIP()
+	assert.True(t, PortMappingError.Equals(err))
+	closed.Close()
+}
+
+func Test_nat_Start(t *testing.T) {
+	fg := newFakeGateway(t)
+	defer fg.Close()
+	fg.Set(10, false)
+
+	n := newNATTraversal("192.168.0.2:8080", func() string { return "0.0.0.0:9080" }, log.GlobalLogger())
+	n.discover = func(nat string) (PortMapper, error) {
+		assert.Equal(t, NATPMP, nat)
+		return newNATPMP(fg.pmp.LocalAddr().String()), nil
+	}
+	changed := make(chan NetAddress, 2)
+	n.onChange = func(na NetAddress) {
+		changed <- na
+	}
+
+	assert.Error(t, n.Start("invalid"))
+	assert.NoError(t, n.Start(NATNone))
+	assert.NoError(t, n.Start(NATPMP))
+	assert.Error(t, n.Start(NATPMP))
+
+	select {
+	case na := <-changed:
+		assert.Equal(t, NetAddress(testExternalIP+":8090"), na)
+	case <-time.After(3 * time.Second):
+		assert.Fail(t, "timeout")
+	}
+	assert.Equal(t, fakeGatewayMapping{9080, 8090, int(DefaultPortMappingLifetime / time.Second)},
+		fg.Mappings()[8090])
+	assert.Equal(t, NATPMP, n.Map()["nat"])
+
+	//observed address has priority
+	n.SetAutoAddress(true)
+	n.observe(generatePeerID(), "198.51.100.1:1000")
+	n.observe(generatePeerID(), "198.51.100.1:1001")
+	assert.Equal(t, NetAddress("198.51.100.1:8090"), <-changed)
+
+	n.Stop()
+	assert.Len(t, fg.Mappings(), 0)
+	assert.Equal(t, NetAddress("198.51.100.1:8080"), n.Address())
+}
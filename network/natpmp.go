@@ -0,0 +1,117 @@
+package network
+
+import (
+	"encoding/binary"
+	"net"
+	"time"
+)
+
+const (
+	natPMPPort          = 5351
+	natPMPVersion       = 0
+	natPMPOpAddress     = 0
+	natPMPOpMapTCP      = 2
+	natPMPOpResponse    = 128
+	natPMPRetryInterval = 250 * time.Millisecond
+)
+
+var natPMPResults = []string{
+	"Success",
+	"UnsupportedVersion",
+	"NotAuthorized",
+	"NetworkFailure",
+	"OutOfResources",
+	"UnsupportedOpcode",
+}
+
+//natPMPGateway is a client of NAT Port Mapping Protocol (RFC 6886)
+type natPMPGateway struct {
+	gateway string
+	timeout time.Duration
+}
+
+func newNATPMP(gateway string) *natPMPGateway {
+	return &natPMPGateway{gateway: gateway, timeout: DefaultNATRequestTimeout}
+}
+
+func (g *natPMPGateway) Name() string {
+	return "pmp(" + g.gateway + ")"
+}
+
+//request sends the request until it receives the response of the opcode or timeout
+func (g *natPMPGateway) request(req []byte, size int) ([]byte, error) {
+	conn, err := net.Dial("udp4", g.gateway)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(g.timeout)
+	b := make([]byte, 16)
+	for time.Now().Before(deadline) {
+		if _, err = conn.Write(req); err != nil {
+			return nil, err
+		}
+		rd := time.Now().Add(natPMPRetryInterval)
+		if rd.After(deadline) {
+			rd = deadline
+		}
+		if err = conn.SetReadDeadline(rd); err != nil {
+			return nil, err
+		}
+		for {
+			n, err := conn.Read(b)
+			if err != nil {
+				if ne, ok := err.(net.Error); ok && ne.Timeout() {
+					break
+				}
+				return nil, err
+			}
+			if n < size || b[0] != natPMPVersion || b[1] != natPMPOpResponse+req[1] {
+				continue
+			}
+			if rc := binary.BigEndian.Uint16(b[2:4]); rc != 0 {
+				msg := "Unknown"
+				if int(rc) < len(natPMPResults) {
+					msg = natPMPResults[rc]
+				}
+				return nil, PortMappingError.Errorf("NATPMPError(op=%d,result=%d,msg=%s)", req[1], rc, msg)
+			}
+			return b[:n], nil
+		}
+	}
+	return nil, PortMappingError.Errorf("NATPMPTimeout(gateway=%s)", g.gateway)
+}
+
+func (g *natPMPGateway) ExternalIP() (net.IP, error) {
+	b, err := g.request([]byte{natPMPVersion, natPMPOpAddress}, 12)
+	if err != nil {
+		return nil, err
+	}
+	ip := make(net.IP, net.IPv4len)
+	copy(ip, b[8:12])
+	return ip, nil
+}
+
+func (g *natPMPGateway) mapPort(internalPort, externalPort int, lifetime time.Duration) (int, error) {
+	req := make([]byte, 12)
+	req[0] = natPMPVersion
+	req[1] = natPMPOpMapTCP
+	binary.BigEndian.PutUint16(req[4:6], uint16(internalPort))
+	binary.BigEndian.PutUint16(req[6:8], uint16(externalPort))
+	binary.BigEndian.PutUint32(req[8:12], uint32(lifetime/time.Second))
+	b, err := g.request(req, 16)
+	if err != nil {
+		return 0, err
+	}
+	return int(binary.BigEndian.Uint16(b[10:12])), nil
+}
+
+func (g *natPMPGateway) AddPortMapping(internalPort, externalPort int, desc string, lifetime time.Duration) (int, error) {
+	return g.mapPort(internalPort, externalPort, lifetime)
+}
+
+func (g *natPMPGateway) DeletePortMapping(internalPort, externalPort int) error {
+	_, err := g.mapPort(internalPort, 0, 0)
+	return err
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/icon-project/goloop/common/db"
 	"github.com/icon-project/goloop/common/errors"
 	"github.com/icon-project/goloop/common/log"
 	"github.com/icon-project/goloop/module"
@@ -42,7 +43,7 @@ func NewManager(c module.Chain, nt module.NetworkTransport, trustSeeds string, r
 	networkLogger.Infof("NetworkManager use channel=%s for cid=%#x nid=%#x", channel, c.CID(), c.NID())
 	m := &manager{
 		channel:          channel,
-		p2p:              newPeerToPeer(channel, self, t.GetDialer(channel), mtr, networkLogger),
+		p2p:              newPeerToPeer(channel, self, t.GetDialer(channel), t.pd.banList, t.pd.nat, mtr, networkLogger),
 		roles:            make(map[module.Role]*PeerIDSet),
 		destByRole:       make(map[module.Role]byte),
 		roleByDest:       make(map[byte]module.Role),
@@ -62,6 +63,13 @@ func NewManager(c module.Chain, nt module.NetworkTransport, trustSeeds string, r
 
 	m.SetInitialRoles(roles...)
 	m.SetTrustSeeds(trustSeeds)
+	if dbase := c.Database(); dbase != nil {
+		if bk, err := dbase.GetBucket(db.ChainProperty); err != nil {
+			m.logger.Warnf("fail to get bucket for addressBook err=%+v", err)
+		} else if err = m.p2p.addressBook.Load(bk); err != nil {
+			m.logger.Warnf("fail to load addressBook err=%+v", err)
+		}
+	}
 
 	m.p2p.setConnectionLimit(p2pConnTypeChildren, c.ChildrenLimit())
 	m.p2p.setConnectionLimit(p2pConnTypeNephew, c.NephewsLimit())
@@ -167,6 +175,9 @@ func (m *manager) RegisterReactor(name string, pi module.ProtocolInfo, reactor m
 		}
 
 		ph = newProtocolHandler(m, pi, piList, reactor, name, priority, m.logger)
+		if m.pd != nil {
+			m.pd.rateLimiter.setProtocolPriority(pi, priority)
+		}
 		m.p2p.setCbFunc(pi, ph.onPacket, ph.onFailure, ph.onEvent, p2pEventJoin, p2pEventLeave, p2pEventDuplicate)
 		m.protocolHandlers[k] = ph
 	}
@@ -329,14 +340,25 @@ func (m *manager) getRoleByDest(dest byte) module.Role {
 	return m.roleByDest[dest]
 }
 
+//SetTrustSeeds sets comma separated addresses of trust seeds, the address
+//which has DNS name as host is resolved to multiple addresses periodically.
 func (m *manager) SetTrustSeeds(seeds string) {
 	m.p2p.trustSeeds.Clear()
 	ss := strings.Split(seeds, ",")
+	nas := make([]NetAddress, 0)
 	for _, s := range ss {
 		if na := NetAddress(s); len(na) != 0 && na != m.p2p.NetAddress() {
-			m.p2p.trustSeeds.Add(NetAddress(s))
+			if _, _, ok := isDNSSeed(na); !ok {
+				m.p2p.trustSeeds.Add(na)
+				m.p2p.addressBook.trust(na)
+			}
+			nas = append(nas, na)
 		}
 	}
+	m.p2p.dnsSeeds.set(nas)
+	if m.p2p.IsStarted() {
+		go m.p2p.resolveDNSSeeds()
+	}
 }
 
 func (m *manager) SetInitialRoles(roles ...module.Role) {
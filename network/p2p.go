@@ -114,6 +114,17 @@ type PeerToPeer struct {
 	cLimit    map[PeerConnectionType]int
 	cLimitMtx sync.RWMutex
 
+	//misbehavior
+	reputation *reputation
+	banList    *BanList
+
+	//external address discovery
+	nat *NATTraversal
+
+	//known addresses and trust seeds given as DNS name
+	addressBook *AddressBook
+	dnsSeeds    *dnsSeeds
+
 	//log
 	logger log.Logger
 
@@ -136,7 +147,7 @@ const (
 	p2pEventNotAllowed = "not allowed"
 )
 
-func newPeerToPeer(channel string, self *Peer, d *Dialer, mtr *metric.NetworkMetric, l log.Logger) *PeerToPeer {
+func newPeerToPeer(channel string, self *Peer, d *Dialer, bl *BanList, nat *NATTraversal, mtr *metric.NetworkMetric, l log.Logger) *PeerToPeer {
 	p2pLogger := l.WithFields(log.Fields{LoggerFieldKeySubModule: "p2p"})
 	p2p := &PeerToPeer{
 		channel:          channel,
@@ -173,6 +184,13 @@ func newPeerToPeer(channel string, self *Peer, d *Dialer, mtr *metric.NetworkMet
 		//
 		cLimit: make(map[PeerConnectionType]int),
 		//
+		reputation: newReputation(),
+		banList:    bl,
+		nat:        nat,
+		//
+		addressBook: newAddressBook(),
+		dnsSeeds:    newDNSSeeds(),
+		//
 		logger: p2pLogger,
 		//
 		mtr: mtr,
@@ -209,6 +227,8 @@ func (p2p *PeerToPeer) Start() {
 	go p2p.sendRoutine()
 	go p2p.alternateSendRoutine()
 	go p2p.discoverRoutine()
+	go p2p.throughputRoutine()
+	go p2p.addressBookRoutine()
 }
 
 func (p2p *PeerToPeer) Stop() {
@@ -242,18 +262,26 @@ func (p2p *PeerToPeer) Stop() {
 	}()
 	p2p.logger.Debugln("Stop", "wait peer Closing")
 	wg.Wait()
+	if err := p2p.addressBook.Save(); err != nil {
+		p2p.logger.Infoln("Stop", "fail to save addressBook", err)
+	}
 
 	p2p.run = false
 	p2p.logger.Debugln("Stop", "Done")
 }
 
 func (p2p *PeerToPeer) dial(na NetAddress) error {
+	if p2p.banList.IsBannedAddress(na) {
+		p2p.logger.Debugln("Dial ignore, banned", na)
+		return ErrBannedPeer
+	}
 	if err := p2p.dialer.Dial(string(na)); err != nil {
 		if err == ErrAlreadyDialing {
 			p2p.logger.Infoln("Dial ignore", na, err)
 			return nil
 		}
 		p2p.logger.Infoln("Dial fail", na, err)
+		p2p.addressBook.failed(na)
 		return err
 	}
 	return nil
@@ -327,6 +355,7 @@ func (p2p *PeerToPeer) onPeer(p *Peer) {
 		p2p.logger.Infoln("Already exists connected Peer, close old", dp, diff)
 	}
 	p2p.orphanages.AddWithPredicate(p, func(p *Peer) bool { return !p.IsClosed() })
+	p2p.addressBook.connected(p.NetAddress(), p.ID(), !p.In())
 	if !p.In() {
 		p2p.sendQuery(p)
 	}
@@ -371,6 +400,7 @@ func (p2p *PeerToPeer) onClose(p *Peer) {
 	if p2p.isTrustSeed(p) {
 		p2p.trustSeeds.RemoveData(p.DialNetAddress())
 	}
+	p2p.nat.forget(p.ID())
 }
 
 func (p2p *PeerToPeer) onEvent(evt string, p *Peer) {
@@ -395,6 +425,23 @@ func (p2p *PeerToPeer) onFailure(err error, pkt *Packet, c *Counter) {
 	}
 }
 
+//callback from protocolHandler.receiveRoutine and handlers of control message
+func (p2p *PeerToPeer) onMisbehavior(m Misbehavior, p *Peer, reason string) {
+	if m == MisbehaviorNone {
+		return
+	}
+	score, ban := p2p.reputation.penalize(p.ID(), m)
+	p2p.logger.Debugln("onMisbehavior", m, "score:", score, reason, p)
+	if ban {
+		p2p.logger.Infoln("onMisbehavior", "ban", m, "score:", score, p)
+		p2p.reputation.remove(p.ID())
+		if err := p2p.banList.Ban(p.ID().String(), p.NetAddress(), 0, m.String()); err != nil {
+			p2p.logger.Warnln("onMisbehavior", "fail to ban", err, p)
+			p.CloseByError(ErrBannedPeer)
+		}
+	}
+}
+
 func (p2p *PeerToPeer) removePeer(p *Peer) (isLeave bool) {
 	isLeave = false
 	p2p.seeds.RemoveData(p.NetAddress())
@@ -477,6 +524,9 @@ func (p2p *PeerToPeer) onPacket(pkt *Packet, p *Peer) {
 				cbFunc(pkt, p)
 			} else {
 				p2p.logger.Traceln("onPacket", "Drop, Duplicated by footer", pkt.protocol, pkt.subProtocol, pkt.hashOfPacket, p.ID())
+				if pkt.duplicated {
+					p2p.onMisbehavior(MisbehaviorDuplicateFlood, p, "duplicated by peer")
+				}
 			}
 		} else {
 			p.CloseByError(ErrNotRegisteredProtocol)
@@ -500,7 +550,8 @@ func (p2p *PeerToPeer) decodeMsgpack(b []byte, v interface{}) error {
 
 //TODO timestamp or sequencenumber for validation (query,result pair)
 type QueryMessage struct {
-	Role PeerRoleFlag
+	Role     PeerRoleFlag
+	Observed NetAddress //remote address of the receiver which is observed by the sender
 }
 
 type QueryResultMessage struct {
@@ -510,6 +561,7 @@ type QueryResultMessage struct {
 	Children []NetAddress
 	Nephews  []NetAddress
 	Message  string
+	Observed NetAddress //remote address of the receiver which is observed by the sender
 }
 
 type RttMessage struct {
@@ -541,6 +593,11 @@ func (p2p *PeerToPeer) applyPeerRole(p *Peer) {
 	} else {
 		p2p.roots.Remove(p.NetAddress())
 	}
+	if p != p2p.self {
+		verified := (r.Has(p2pRoleRoot) && p2p.allowedRoots.Contains(p.ID())) ||
+			(r.Has(p2pRoleSeed) && p2p.allowedSeeds.Contains(p.ID()))
+		p2p.addressBook.setRole(p.NetAddress(), r, verified)
+	}
 }
 
 func (p2p *PeerToPeer) setRole(r PeerRoleFlag) {
@@ -624,11 +681,12 @@ func (p2p *PeerToPeer) stopRtt(p *Peer) {
 	p.rtt.Stop()
 	if p.rtt.last >= DefaultRttLogThreshold {
 		p2p.logger.Warnln("RTT Threshold", DefaultRttLogThreshold, p)
+		p2p.onMisbehavior(MisbehaviorHighRTT, p, p.rtt.String())
 	}
 }
 
 func (p2p *PeerToPeer) sendQuery(p *Peer) {
-	m := &QueryMessage{Role: p2p.Role()}
+	m := &QueryMessage{Role: p2p.Role(), Observed: p.remoteNetAddress()}
 	pkt := newPacket(p2pProtoQueryReq, p2p.encodeMsgpack(m), p2p.ID())
 	pkt.destPeer = p.ID()
 	err := p.sendPacket(pkt)
@@ -645,15 +703,18 @@ func (p2p *PeerToPeer) handleQuery(pkt *Packet, p *Peer) {
 	err := p2p.decodeMsgpack(pkt.payload, qm)
 	if err != nil {
 		p2p.logger.Infoln("handleQuery", err, p)
+		p2p.onMisbehavior(MisbehaviorUndecodable, p, err.Error())
 		return
 	}
 	p2p.logger.Traceln("handleQuery", qm, p)
+	p2p.nat.observe(p.ID(), qm.Observed)
 
 	r := p2p.Role()
 	m := &QueryResultMessage{
 		Role:     r,
 		Children: p2p.children.NetAddresses(),
 		Nephews:  p2p.nephews.NetAddresses(),
+		Observed: p.remoteNetAddress(),
 	}
 	rr := p2p.resolveRole(qm.Role, p.ID(), true)
 	if rr != qm.Role {
@@ -711,6 +772,7 @@ func (p2p *PeerToPeer) handleQueryResult(pkt *Packet, p *Peer) {
 	err := p2p.decodeMsgpack(pkt.payload, qrm)
 	if err != nil {
 		p2p.logger.Infoln("handleQueryResult", err, p)
+		p2p.onMisbehavior(MisbehaviorUndecodable, p, err.Error())
 		return
 	}
 	p2p.stopRtt(p)
@@ -731,6 +793,7 @@ func (p2p *PeerToPeer) handleQueryResult(pkt *Packet, p *Peer) {
 		qrm.Nephews = qrm.Nephews[:DefaultQueryElementLength]
 	}
 	p2p.logger.Traceln("handleQueryResult", qrm, p)
+	p2p.nat.observe(p.ID(), qrm.Observed)
 
 	p.children.ClearAndAdd(qrm.Children...)
 	p.nephews.ClearAndAdd(qrm.Nephews...)
@@ -762,6 +825,7 @@ func (p2p *PeerToPeer) handleQueryResult(pkt *Packet, p *Peer) {
 			}
 		}
 		p2p.roots.Merge(roots...)
+		p2p.learnAddresses(roots, p2pRoleRoot)
 	}
 	seeds := make([]NetAddress, 0)
 	for _, na := range qrm.Seeds {
@@ -770,6 +834,7 @@ func (p2p *PeerToPeer) handleQueryResult(pkt *Packet, p *Peer) {
 		}
 	}
 	p2p.seeds.Merge(seeds...)
+	p2p.learnAddresses(seeds, p2pRoleSeed)
 
 	m := &RttMessage{Last: p.rtt.last, Average: p.rtt.avg}
 	rpkt := newPacket(p2pProtoRttReq, p2p.encodeMsgpack(m), p2p.ID())
@@ -787,6 +852,7 @@ func (p2p *PeerToPeer) handleRttRequest(pkt *Packet, p *Peer) {
 	err := p2p.decodeMsgpack(pkt.payload, rm)
 	if err != nil {
 		p2p.logger.Infoln("handleRttRequest", err, p)
+		p2p.onMisbehavior(MisbehaviorUndecodable, p, err.Error())
 		return
 	}
 	p2p.logger.Traceln("handleRttRequest", rm, p)
@@ -813,6 +879,7 @@ func (p2p *PeerToPeer) handleRttResponse(pkt *Packet, p *Peer) {
 	err := p2p.decodeMsgpack(pkt.payload, rm)
 	if err != nil {
 		p2p.logger.Infoln("handleRttResponse", err, p)
+		p2p.onMisbehavior(MisbehaviorUndecodable, p, err.Error())
 		return
 	}
 	p2p.logger.Traceln("handleRttResponse", rm, p)
@@ -1028,6 +1095,19 @@ Loop:
 	}
 }
 
+func (p2p *PeerToPeer) throughputRoutine() {
+	ticker := time.NewTicker(DefaultThroughputPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p2p.stopCh:
+			return
+		case <-ticker.C:
+			p2p.mtr.UpdateThroughput()
+		}
+	}
+}
+
 func (p2p *PeerToPeer) alternateSendRoutine() {
 	var m = make(map[uint64]context.Context)
 Loop:
@@ -1335,6 +1415,7 @@ func (p2p *PeerToPeer) resolveRole(r PeerRoleFlag, id module.PeerID, onlyUnSet b
 
 //Dial to seeds, roots, nodes and create p2p connection
 func (p2p *PeerToPeer) discoverRoutine() {
+	p2p.resolveDNSSeeds()
 	for na, _ := range p2p.trustSeeds.Map() {
 		p2p.logger.Debugln("discoverRoutine", "initialize", "dial to trustSeed", na)
 		p2p.dial(na)
@@ -1424,6 +1505,70 @@ Loop:
 	}
 }
 
+//Dial to the best addresses of the address book, then save it and resolve
+//trust seeds given as DNS name periodically
+func (p2p *PeerToPeer) addressBookRoutine() {
+	for _, na := range p2p.addressBook.Best(DefaultAddressBookBootstrap) {
+		select {
+		case <-p2p.stopCh:
+			return
+		default:
+		}
+		if na != p2p.NetAddress() && !p2p.hasNetAddress(na) {
+			p2p.logger.Debugln("addressBookRoutine", "bootstrap", "dial to", na)
+			p2p.dial(na)
+		}
+	}
+	saveTicker := time.NewTicker(DefaultAddressBookSavePeriod)
+	defer saveTicker.Stop()
+	resolveTicker := time.NewTicker(DefaultDNSSeedResolvePeriod)
+	defer resolveTicker.Stop()
+	for {
+		select {
+		case <-p2p.stopCh:
+			return
+		case <-saveTicker.C:
+			if err := p2p.addressBook.Save(); err != nil {
+				p2p.logger.Infoln("addressBookRoutine", "fail to save addressBook", err)
+			}
+		case <-resolveTicker.C:
+			p2p.resolveDNSSeeds()
+		}
+	}
+}
+
+func (p2p *PeerToPeer) learnAddresses(l []NetAddress, r PeerRoleFlag) {
+	for _, na := range l {
+		if na != p2p.NetAddress() {
+			p2p.addressBook.add(na, r)
+		}
+	}
+}
+
+//resolveDNSSeeds updates trust seeds with the addresses of DNS seeds
+func (p2p *PeerToPeer) resolveDNSSeeds() {
+	if p2p.dnsSeeds.Len() == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultDNSSeedResolveTimeout)
+	defer cancel()
+	added, removed, err := p2p.dnsSeeds.resolve(ctx)
+	if err != nil {
+		p2p.logger.Infoln("resolveDNSSeeds", err)
+	}
+	for _, na := range removed {
+		p2p.logger.Debugln("resolveDNSSeeds", "remove trustSeed", na)
+		p2p.trustSeeds.Remove(na)
+	}
+	for _, na := range added {
+		if na != p2p.NetAddress() {
+			p2p.logger.Debugln("resolveDNSSeeds", "add trustSeed", na)
+			p2p.trustSeeds.Add(na)
+			p2p.addressBook.trust(na)
+		}
+	}
+}
+
 func (p2p *PeerToPeer) query(r PeerRoleFlag) (needMoreSeeds bool) {
 	ps := make([]*Peer, 0)
 	if r.Has(p2pRoleRoot) {
@@ -1783,6 +1928,7 @@ func (p2p *PeerToPeer) handleP2PConnectionRequest(pkt *Packet, p *Peer) {
 	err := p2p.decodeMsgpack(pkt.payload, req)
 	if err != nil {
 		p2p.logger.Infoln("handleP2PConnectionRequest", err, p)
+		p2p.onMisbehavior(MisbehaviorUndecodable, p, err.Error())
 		return
 	}
 	p2p.logger.Debugln("handleP2PConnectionRequest", req, p)
@@ -1921,6 +2067,7 @@ func (p2p *PeerToPeer) handleP2PConnectionResponse(pkt *Packet, p *Peer) {
 	err := p2p.decodeMsgpack(pkt.payload, resp)
 	if err != nil {
 		p2p.logger.Infoln("handleP2PConnectionResponse", err, p)
+		p2p.onMisbehavior(MisbehaviorUndecodable, p, err.Error())
 		return
 	}
 	p2p.logger.Debugln("handleP2PConnectionResponse", resp, p)
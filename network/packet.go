@@ -37,12 +37,13 @@ type Packet struct {
 	footer  []byte
 	ext     []byte
 	//Transient fields
-	sender    module.PeerID //20byte
-	destPeer  module.PeerID //20byte
-	priority  uint8
-	timestamp time.Time
-	forceSend bool
-	mtx       sync.RWMutex
+	sender     module.PeerID //20byte
+	destPeer   module.PeerID //20byte
+	priority   uint8
+	timestamp  time.Time
+	forceSend  bool
+	duplicated bool
+	mtx        sync.RWMutex
 }
 
 type packetDestInfo uint16
@@ -31,6 +31,7 @@ type Peer struct {
 	cbMtx        sync.RWMutex
 	timestamp    time.Time
 	pool         *TimestampPool
+	recvPool     *TimestampPool
 	close        chan error
 	closed       int32
 	closeReason  []string
@@ -63,6 +64,15 @@ type Peer struct {
 	secureKey *secureKey
 	rtt       PeerRTT
 
+	//bandwidth
+	limiter    *RateLimiter
+	limiterMtx sync.RWMutex
+	rateLimit  peerRateLimit
+
+	//capture
+	capturer    *Capturer
+	capturerMtx sync.RWMutex
+
 	//log
 	logger log.Logger
 
@@ -84,6 +94,7 @@ func newPeer(conn net.Conn, cbFunc packetCbFunc, in bool, dial NetAddress, l log
 		in:          in,
 		timestamp:   time.Now(),
 		pool:        NewTimestampPool(DefaultPeerPoolExpireSecond + 1),
+		recvPool:    NewTimestampPool(DefaultPeerPoolExpireSecond + 1),
 		close:       make(chan error),
 		closeReason: make([]string, 0),
 		closeErr:    make([]error, 0),
@@ -124,6 +135,15 @@ func (p *Peer) ConnString() string {
 	}
 }
 
+//remoteNetAddress returns the address of the connection which could be
+//different from NetAddress of the peer
+func (p *Peer) remoteNetAddress() NetAddress {
+	if p.conn == nil {
+		return ""
+	}
+	return NetAddress(p.conn.RemoteAddr().String())
+}
+
 func (p *Peer) In() bool {
 	return p.in
 }
@@ -415,8 +435,14 @@ func (p *Peer) receiveRoutine() {
 			continue
 		}
 
+		if !p.waitDownloadLimit(pkt) {
+			return
+		}
 		pkt.sender = p.ID()
-		p.pool.Put(pkt.hashOfPacket)
+		if c := p.getCapturer(); c != nil {
+			c.capture(CaptureIn, p, pkt)
+		}
+		p.markReceived(pkt)
 		p.getMetric().OnRecv(pkt.dest, pkt.ttl, pkt.extendInfo.hint(), pkt.protocol.Uint16(), pkt.lengthOfPayload)
 		//TODO peer.packet_dump
 		if isLoggingPacket {
@@ -430,6 +456,15 @@ func (p *Peer) receiveRoutine() {
 	}
 }
 
+//markReceived marks the packet duplicated if it's received from the peer before.
+//packets sent to the peer are not counted, so that echoes of them are not
+//regarded as a flood by the peer.
+func (p *Peer) markReceived(pkt *Packet) {
+	pkt.duplicated = p.recvPool.Contains(pkt.hashOfPacket)
+	p.recvPool.Put(pkt.hashOfPacket)
+	p.pool.Put(pkt.hashOfPacket)
+}
+
 func (p *Peer) sendDirect(pkt *Packet) error {
 	defer p.sendMtx.Unlock()
 	p.sendMtx.Lock()
@@ -441,6 +476,9 @@ func (p *Peer) sendDirect(pkt *Packet) error {
 	} else if err := p.writer.Flush(); err != nil {
 		return err
 	}
+	if c := p.getCapturer(); c != nil {
+		c.capture(CaptureOut, p, pkt)
+	}
 	return nil
 }
 
@@ -461,25 +499,87 @@ Loop:
 					break
 				}
 				pkt := ctx.Value(p2pContextKeyPacket).(*Packet)
-				if err := p.sendDirect(pkt); err != nil {
-					r := p.isTemporaryError(err)
-					p.logger.Tracef("Peer.sendRoutine Error isTemporary:{%v} error:{%+v} peer:%s", r, err, p.String())
-					p.CloseByError(err)
+				if !p.waitUploadLimit(pkt) || !p.sendFromQueue(pkt) {
 					return
 				}
-				//TODO peer.packet_dump
-				if isLoggingPacket {
-					log.Println(p.ID(), "Peer", "sendRoutine", p.ConnType(), p.ConnString(), pkt)
-				}
-				p.pool.Put(pkt.hashOfPacket)
-				p.getMetric().OnSend(pkt.dest, pkt.ttl, pkt.extendInfo.hint(), pkt.protocol.Uint16(), pkt.lengthOfPayload)
 			}
 		case <-secondTick.C:
 			p.pool.RemoveBefore(DefaultPeerPoolExpireSecond)
+			p.recvPool.RemoveBefore(DefaultPeerPoolExpireSecond)
 		}
 	}
 }
 
+func (p *Peer) sendFromQueue(pkt *Packet) bool {
+	if err := p.sendDirect(pkt); err != nil {
+		r := p.isTemporaryError(err)
+		p.logger.Tracef("Peer.sendRoutine Error isTemporary:{%v} error:{%+v} peer:%s", r, err, p.String())
+		p.CloseByError(err)
+		return false
+	}
+	//TODO peer.packet_dump
+	if isLoggingPacket {
+		log.Println(p.ID(), "Peer", "sendRoutine", p.ConnType(), p.ConnString(), pkt)
+	}
+	p.pool.Put(pkt.hashOfPacket)
+	p.getMetric().OnSend(pkt.dest, pkt.ttl, pkt.extendInfo.hint(), pkt.protocol.Uint16(), pkt.lengthOfPayload)
+	return true
+}
+
+//waitUploadLimit waits until the packet is allowed by the rate limiter,
+//packets of exempt priority are sent while waiting. returns false if the peer is closed.
+func (p *Peer) waitUploadLimit(pkt *Packet) bool {
+	l := p.getRateLimiter()
+	if l == nil {
+		return true
+	}
+	d := l.reserveUpload(&p.rateLimit, pkt)
+	if d <= 0 || pkt.priority <= DefaultRateLimitExemptPriority {
+		return true
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	for {
+		select {
+		case <-p.close:
+			return false
+		case <-t.C:
+			return true
+		case <-p.q.Wait():
+			for {
+				ctx := p.q.PopUntil(DefaultRateLimitExemptPriority)
+				if ctx == nil {
+					break
+				}
+				hpkt := ctx.Value(p2pContextKeyPacket).(*Packet)
+				l.reserveUpload(&p.rateLimit, hpkt)
+				if !p.sendFromQueue(hpkt) {
+					return false
+				}
+			}
+		}
+	}
+}
+
+//waitDownloadLimit delays reading of the connection, packets of exempt protocols
+//consume tokens without waiting. returns false if the peer is closed.
+func (p *Peer) waitDownloadLimit(pkt *Packet) bool {
+	l := p.getRateLimiter()
+	if l == nil {
+		return true
+	}
+	if d := l.reserveDownload(&p.rateLimit, pkt); d > 0 && !l.isExempt(pkt.protocol) {
+		t := time.NewTimer(d)
+		defer t.Stop()
+		select {
+		case <-p.close:
+			return false
+		case <-t.C:
+		}
+	}
+	return true
+}
+
 func (p *Peer) isDuplicatedToSend(pkt *Packet) bool {
 	if p.ID().Equal(pkt.src) {
 		return true
@@ -536,6 +636,30 @@ func (p *Peer) getMetric() *metric.NetworkMetric {
 	return p.mtr
 }
 
+func (p *Peer) setRateLimiter(l *RateLimiter) {
+	p.limiterMtx.Lock()
+	defer p.limiterMtx.Unlock()
+	p.limiter = l
+}
+
+func (p *Peer) getRateLimiter() *RateLimiter {
+	p.limiterMtx.RLock()
+	defer p.limiterMtx.RUnlock()
+	return p.limiter
+}
+
+func (p *Peer) setCapturer(c *Capturer) {
+	p.capturerMtx.Lock()
+	defer p.capturerMtx.Unlock()
+	p.capturer = c
+}
+
+func (p *Peer) getCapturer() *Capturer {
+	p.capturerMtx.RLock()
+	defer p.capturerMtx.RUnlock()
+	return p.capturer
+}
+
 func (p *Peer) HasCloseError(err error) bool {
 	p.closeInfoMtx.RLock()
 	defer p.closeInfoMtx.RUnlock()
@@ -22,7 +22,8 @@ var (
 //Negotiation map<channel, map<protocolHandler.name, {protocol, []subProtocol}>>
 type ChannelNegotiator struct {
 	*peerHandler
-	netAddress NetAddress
+	netAddress    NetAddress
+	netAddressMtx sync.RWMutex
 }
 
 func newChannelNegotiator(netAddress NetAddress, l log.Logger) *ChannelNegotiator {
@@ -33,6 +34,18 @@ func newChannelNegotiator(netAddress NetAddress, l log.Logger) *ChannelNegotiato
 	return cn
 }
 
+func (cn *ChannelNegotiator) setNetAddress(na NetAddress) {
+	cn.netAddressMtx.Lock()
+	defer cn.netAddressMtx.Unlock()
+	cn.netAddress = na
+}
+
+func (cn *ChannelNegotiator) NetAddress() NetAddress {
+	cn.netAddressMtx.RLock()
+	defer cn.netAddressMtx.RUnlock()
+	return cn.netAddress
+}
+
 func (cn *ChannelNegotiator) onPeer(p *Peer) {
 	cn.logger.Traceln("onPeer", p)
 	if !p.In() {
@@ -73,7 +86,7 @@ type JoinResponse struct {
 }
 
 func (cn *ChannelNegotiator) sendJoinRequest(p *Peer) {
-	m := &JoinRequest{Channel: p.Channel(), Addr: cn.netAddress}
+	m := &JoinRequest{Channel: p.Channel(), Addr: cn.NetAddress()}
 	cn.sendMessage(p2pProtoChanJoinReq, m, p)
 	cn.logger.Traceln("sendJoinRequest", m, p)
 }
@@ -97,7 +110,7 @@ func (cn *ChannelNegotiator) handleJoinRequest(pkt *Packet, p *Peer) {
 
 	p.setNetAddress(rm.Addr)
 
-	m := &JoinResponse{Channel: p.Channel(), Addr: cn.netAddress}
+	m := &JoinResponse{Channel: p.Channel(), Addr: cn.NetAddress()}
 	cn.sendMessage(p2pProtoChanJoinResp, m, p)
 
 	cn.nextOnPeer(p)
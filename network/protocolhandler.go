@@ -146,7 +146,9 @@ Loop:
 				r := ph.getReactor()
 				isRelay, err := r.OnReceive(pkt.subProtocol, pkt.payload, p.ID())
 				if err != nil {
-					//ph.logger.Debugln("receiveRoutine", err)
+					if m := misbehaviorOf(err); m != MisbehaviorNone {
+						ph.m.p2p.onMisbehavior(m, p, err.Error())
+					}
 				}
 
 				if isRelay && pkt.ttl == byte(module.BROADCAST_ALL) && pkt.dest != p2pDestPeer {
@@ -230,6 +230,23 @@ func (q *PriorityQueue) fetch() (context.Context, bool) {
 	return nil, false
 }
 
+//PopUntil returns the context of which priority is not lower than the given priority
+func (q *PriorityQueue) PopUntil(priority int) context.Context {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	for i := 0; i <= priority && i < len(q.queues); i++ {
+		if ctx, ok := q.queues[i].pop(); ok {
+			q.len -= 1
+			if q.len > 0 {
+				q.notify()
+			}
+			return ctx
+		}
+	}
+	return nil
+}
+
 func (q *PriorityQueue) Close() {
 	q.term()
 }
@@ -107,3 +107,27 @@ func TestWeightQueue_Pop(t *testing.T) {
 	q.Close()
 	exit.Wait()
 }
+
+func TestPriorityQueue_PopUntil(t *testing.T) {
+	q := NewPriorityQueue(10, 3)
+	for p := 3; p >= 0; p-- {
+		ctx := context.WithValue(context.Background(), "priority", p)
+		q.Push(ctx, p)
+	}
+	for p := 0; p < 2; p++ {
+		ctx := q.PopUntil(1)
+		if ctx == nil || ctx.Value("priority").(int) != p {
+			t.Fatalf("expected priority %d, got %v", p, ctx)
+		}
+	}
+	if ctx := q.PopUntil(1); ctx != nil {
+		t.Fatalf("expected nil, got priority %v", ctx.Value("priority"))
+	}
+	for p := 2; p < 4; p++ {
+		ctx := q.Pop()
+		if ctx == nil || ctx.Value("priority").(int) != p {
+			t.Fatalf("expected priority %d, got %v", p, ctx)
+		}
+	}
+	q.Close()
+}
@@ -0,0 +1,246 @@
+package network
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/module"
+)
+
+const (
+	DefaultRateLimitBurst          = 1 * time.Second //burst size as duration of the rate
+	DefaultRateLimitExemptPriority = 2               //consensus.ConfigEnginePriority
+	DefaultThroughputPeriod        = 1 * time.Second
+)
+
+//tokenBucket keeps tokens only, rate is given by RateLimiter on every call
+//so that changing of configuration is applied to all buckets immediately.
+type tokenBucket struct {
+	tokens int64
+	last   time.Time
+	mtx    sync.Mutex
+}
+
+//reserve consumes n tokens and returns the duration to wait until the tokens are
+//filled, tokens may become negative then following reservations would wait longer.
+func (b *tokenBucket) reserve(rate int64, n int64, now time.Time) time.Duration {
+	if rate <= 0 {
+		return 0
+	}
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	burst := rate * int64(DefaultRateLimitBurst) / int64(time.Second)
+	if b.last.IsZero() {
+		b.tokens = burst
+		b.last = now
+	} else if elapsed := now.Sub(b.last); elapsed > 0 {
+		if fill := int64(elapsed.Seconds() * float64(rate)); fill > 0 {
+			b.tokens += fill
+			b.last = now
+		}
+		if b.tokens > burst {
+			b.tokens = burst
+		}
+	}
+	b.tokens -= n
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens * int64(time.Second) / rate)
+}
+
+type peerRateLimit struct {
+	upload   tokenBucket
+	download tokenBucket
+}
+
+//RateLimiter limits bandwidth of the transport by token-bucket in bytes per second.
+//Global limits are shared by all peers, peer limits are applied to each peer and
+//protocol limits are applied to upload of the protocol over all peers.
+//Packets of which priority is not lower than DefaultRateLimitExemptPriority
+//consume tokens without waiting, so that lower priority packets are delayed instead.
+//Received packets don't have priority, so the protocols registered with such
+//priority are exempted on download.
+//Not positive value means unlimited.
+type RateLimiter struct {
+	upload       int64
+	download     int64
+	peerUpload   int64
+	peerDownload int64
+	protocols    map[uint16]int64
+	exempts      map[uint16]bool
+
+	uploadBucket    tokenBucket
+	downloadBucket  tokenBucket
+	protocolBuckets map[uint16]*tokenBucket
+	mtx             sync.RWMutex
+}
+
+func newRateLimiter() *RateLimiter {
+	return &RateLimiter{
+		protocols:       make(map[uint16]int64),
+		exempts:         make(map[uint16]bool),
+		protocolBuckets: make(map[uint16]*tokenBucket),
+	}
+}
+
+func (l *RateLimiter) SetGlobalLimit(upload, download int64) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	l.upload = upload
+	l.download = download
+}
+
+func (l *RateLimiter) SetPeerLimit(upload, download int64) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	l.peerUpload = upload
+	l.peerDownload = download
+}
+
+//SetProtocolLimit sets upload limit of the protocol, not positive rate removes the limit.
+func (l *RateLimiter) SetProtocolLimit(pi module.ProtocolInfo, rate int64) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	k := pi.Uint16()
+	if rate <= 0 {
+		delete(l.protocols, k)
+		delete(l.protocolBuckets, k)
+		return
+	}
+	l.protocols[k] = rate
+	if _, ok := l.protocolBuckets[k]; !ok {
+		l.protocolBuckets[k] = &tokenBucket{}
+	}
+}
+
+//SetProtocolLimits replaces all of protocol limits.
+func (l *RateLimiter) SetProtocolLimits(limits map[module.ProtocolInfo]int64) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	l.protocols = make(map[uint16]int64)
+	buckets := make(map[uint16]*tokenBucket)
+	for pi, rate := range limits {
+		if rate <= 0 {
+			continue
+		}
+		k := pi.Uint16()
+		l.protocols[k] = rate
+		if b, ok := l.protocolBuckets[k]; ok {
+			buckets[k] = b
+		} else {
+			buckets[k] = &tokenBucket{}
+		}
+	}
+	l.protocolBuckets = buckets
+}
+
+//setProtocolPriority exempts the protocol from waiting for download if the
+//priority is not lower than DefaultRateLimitExemptPriority.
+func (l *RateLimiter) setProtocolPriority(pi module.ProtocolInfo, priority uint8) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if priority <= DefaultRateLimitExemptPriority {
+		l.exempts[pi.Uint16()] = true
+	} else {
+		delete(l.exempts, pi.Uint16())
+	}
+}
+
+func (l *RateLimiter) isExempt(pi module.ProtocolInfo) bool {
+	l.mtx.RLock()
+	defer l.mtx.RUnlock()
+
+	return l.exempts[pi.Uint16()]
+}
+
+func (l *RateLimiter) reserveUpload(pl *peerRateLimit, pkt *Packet) time.Duration {
+	l.mtx.RLock()
+	defer l.mtx.RUnlock()
+
+	now := time.Now()
+	n := int64(packetHeaderSize + pkt.lengthOfPayload + packetFooterSize)
+	d := l.uploadBucket.reserve(l.upload, n, now)
+	if pd := pl.upload.reserve(l.peerUpload, n, now); pd > d {
+		d = pd
+	}
+	k := pkt.protocol.Uint16()
+	if b, ok := l.protocolBuckets[k]; ok {
+		if pd := b.reserve(l.protocols[k], n, now); pd > d {
+			d = pd
+		}
+	}
+	return d
+}
+
+func (l *RateLimiter) reserveDownload(pl *peerRateLimit, pkt *Packet) time.Duration {
+	l.mtx.RLock()
+	defer l.mtx.RUnlock()
+
+	now := time.Now()
+	n := int64(packetHeaderSize + pkt.lengthOfPayload + packetFooterSize)
+	d := l.downloadBucket.reserve(l.download, n, now)
+	if pd := pl.download.reserve(l.peerDownload, n, now); pd > d {
+		d = pd
+	}
+	return d
+}
+
+func (l *RateLimiter) Map() map[string]interface{} {
+	l.mtx.RLock()
+	defer l.mtx.RUnlock()
+
+	ps := make(map[string]interface{})
+	for k, v := range l.protocols {
+		ps[fmt.Sprintf("%#04x", k)] = v
+	}
+	return map[string]interface{}{
+		"upload":       l.upload,
+		"download":     l.download,
+		"peerUpload":   l.peerUpload,
+		"peerDownload": l.peerDownload,
+		"protocols":    ps,
+	}
+}
+
+//ParseProtocolLimits parses comma separated list of PROTOCOL=RATE,
+//ex) "0x0300=1048576,0x0500=524288"
+func ParseProtocolLimits(s string) (map[module.ProtocolInfo]int64, error) {
+	m := make(map[module.ProtocolInfo]int64)
+	if len(s) == 0 {
+		return m, nil
+	}
+	for _, e := range strings.Split(s, ",") {
+		kv := strings.SplitN(strings.TrimSpace(e), "=", 2)
+		if len(kv) != 2 {
+			return nil, errors.IllegalArgumentError.Errorf("InvalidProtocolLimit(%s)", e)
+		}
+		pi, err := strconv.ParseUint(kv[0], 0, 16)
+		if err != nil {
+			return nil, errors.IllegalArgumentError.Wrapf(err, "InvalidProtocol(%s)", kv[0])
+		}
+		rate, err := strconv.ParseInt(kv[1], 0, 64)
+		if err != nil {
+			return nil, errors.IllegalArgumentError.Wrapf(err, "InvalidRate(%s)", kv[1])
+		}
+		m[module.ProtocolInfo(pi)] = rate
+	}
+	return m, nil
+}
+
+func GetRateLimiter(nt module.NetworkTransport) *RateLimiter {
+	if t, ok := nt.(*transport); ok {
+		return t.pd.rateLimiter
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/module"
+)
+
+func Test_ratelimit_tokenBucket(t *testing.T) {
+	var b tokenBucket
+	now := time.Now()
+	assert.Equal(t, time.Duration(0), b.reserve(0, 1000, now), "unlimited")
+
+	rate := int64(1000)
+	assert.Equal(t, time.Duration(0), b.reserve(rate, 1000, now), "burst")
+	assert.Equal(t, 500*time.Millisecond, b.reserve(rate, 500, now))
+	assert.Equal(t, time.Second, b.reserve(rate, 500, now))
+
+	now = now.Add(time.Second)
+	assert.Equal(t, 500*time.Millisecond, b.reserve(rate, 500, now))
+
+	now = now.Add(time.Hour)
+	assert.Equal(t, time.Duration(0), b.reserve(rate, 1000, now), "refilled up to burst")
+	assert.Equal(t, time.Millisecond, b.reserve(rate, 1, now))
+}
+
+func Test_ratelimit_RateLimiter(t *testing.T) {
+	l := newRateLimiter()
+	var pl peerRateLimit
+	pi := module.ProtocolInfo(0x0500)
+	pkt := NewPacket(pi, pi, make([]byte, 1000-packetHeaderSize-packetFooterSize))
+
+	assert.Equal(t, time.Duration(0), l.reserveUpload(&pl, pkt))
+	assert.Equal(t, time.Duration(0), l.reserveDownload(&pl, pkt))
+
+	l.SetProtocolLimit(pi, 1000)
+	assert.Equal(t, time.Duration(0), l.reserveUpload(&pl, pkt))
+	assert.True(t, l.reserveUpload(&pl, pkt) > 0)
+	assert.Equal(t, time.Duration(0), l.reserveDownload(&pl, pkt), "protocol limit is only for upload")
+
+	other := NewPacket(module.ProtocolInfo(0x0100), pi, pkt.payload)
+	assert.Equal(t, time.Duration(0), l.reserveUpload(&pl, other))
+
+	l.SetProtocolLimit(pi, 0)
+	assert.Equal(t, time.Duration(0), l.reserveUpload(&pl, pkt))
+
+	l.SetPeerLimit(0, 1000)
+	assert.Equal(t, time.Duration(0), l.reserveDownload(&pl, pkt))
+	assert.True(t, l.reserveDownload(&pl, pkt) > 0)
+	var pl2 peerRateLimit
+	assert.Equal(t, time.Duration(0), l.reserveDownload(&pl2, pkt), "peer limit is for each peer")
+
+	l.SetPeerLimit(0, 0)
+	l.SetGlobalLimit(1000, 0)
+	assert.Equal(t, time.Duration(0), l.reserveUpload(&pl, pkt))
+	assert.True(t, l.reserveUpload(&pl2, pkt) > 0, "global limit is shared")
+}
+
+func Test_ratelimit_ParseProtocolLimits(t *testing.T) {
+	m, err := ParseProtocolLimits("")
+	assert.NoError(t, err)
+	assert.Len(t, m, 0)
+
+	m, err = ParseProtocolLimits("0x0500=1048576, 0x0300=1024")
+	assert.NoError(t, err)
+	assert.Equal(t, map[module.ProtocolInfo]int64{
+		module.ProtocolInfo(0x0500): 1048576,
+		module.ProtocolInfo(0x0300): 1024,
+	}, m)
+
+	for _, s := range []string{"0x0500", "0x10000=1", "0x0500=a"} {
+		_, err = ParseProtocolLimits(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func Test_ratelimit_waitDownloadLimit(t *testing.T) {
+	l := newRateLimiter()
+	l.SetPeerLimit(0, 1000)
+	exempt := module.ProtocolInfo(0x0100)
+	l.setProtocolPriority(exempt, DefaultRateLimitExemptPriority)
+	normal := module.ProtocolInfo(0x0500)
+	l.setProtocolPriority(normal, DefaultRateLimitExemptPriority+1)
+
+	p := &Peer{close: make(chan error)}
+	p.setRateLimiter(l)
+	size := 1000 - packetHeaderSize - packetFooterSize
+
+	// exempt packets are not delayed even after the tokens are used up
+	for i := 0; i < 3; i++ {
+		start := time.Now()
+		assert.True(t, p.waitDownloadLimit(NewPacket(exempt, exempt, make([]byte, size))))
+		assert.True(t, time.Since(start) < 100*time.Millisecond)
+	}
+	assert.True(t, l.reserveDownload(&p.rateLimit, NewPacket(normal, normal, nil)) > 0,
+		"exempt packets consume tokens")
+
+	// other packets wait for the tokens
+	done := make(chan bool)
+	go func() {
+		done <- p.waitDownloadLimit(NewPacket(normal, normal, make([]byte, size)))
+	}()
+	select {
+	case <-done:
+		assert.Fail(t, "not delayed")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(p.close)
+	assert.False(t, <-done)
+}
@@ -0,0 +1,358 @@
+package network
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/module"
+)
+
+const (
+	DefaultPeerScore         = 100
+	DefaultPeerBanThreshold  = 0
+	DefaultPeerScoreRecovery = 1 //per second
+	DefaultPeerBanDuration   = 1 * time.Hour
+)
+
+//Misbehavior is a kind of bad behaviour of a peer which lowers its score.
+type Misbehavior byte
+
+const (
+	MisbehaviorNone Misbehavior = iota
+	MisbehaviorInvalidSignature
+	MisbehaviorUndecodable
+	MisbehaviorInvalidMessage
+	MisbehaviorDuplicateFlood
+	MisbehaviorHighRTT
+)
+
+var (
+	strMisbehavior = []string{
+		"none",
+		"invalidSignature",
+		"undecodable",
+		"invalidMessage",
+		"duplicateFlood",
+		"highRTT",
+	}
+	misbehaviorPenalty = []int{
+		0,
+		40,
+		20,
+		10,
+		1,
+		5,
+	}
+)
+
+func (m Misbehavior) String() string {
+	if int(m) < len(strMisbehavior) {
+		return strMisbehavior[m]
+	}
+	return "unknown"
+}
+
+func (m Misbehavior) Penalty() int {
+	if int(m) < len(misbehaviorPenalty) {
+		return misbehaviorPenalty[m]
+	}
+	return 0
+}
+
+//misbehaviorOf returns Misbehavior for the error returned by Reactor.OnReceive.
+//Reactor reports misbehavior of the sender by the error code,
+//ex) network.DecodeMessageError.Wrap(err, "message")
+func misbehaviorOf(err error) Misbehavior {
+	switch errors.CodeOf(err) {
+	case InvalidSignatureError:
+		return MisbehaviorInvalidSignature
+	case DecodeMessageError:
+		return MisbehaviorUndecodable
+	case InvalidMessageError:
+		return MisbehaviorInvalidMessage
+	case DuplicatedPacketError:
+		return MisbehaviorDuplicateFlood
+	default:
+		return MisbehaviorNone
+	}
+}
+
+type peerScore struct {
+	value   int
+	updated time.Time
+	last    Misbehavior
+}
+
+func (s *peerScore) recover(now time.Time) {
+	if s.value < DefaultPeerScore {
+		r := int(now.Sub(s.updated)/time.Second) * DefaultPeerScoreRecovery
+		if r < 1 {
+			return
+		}
+		s.value += r
+		if s.value > DefaultPeerScore {
+			s.value = DefaultPeerScore
+		}
+	}
+	s.updated = now
+}
+
+//reputation keeps scores of peers, score recovers as time goes by.
+type reputation struct {
+	scores map[string]*peerScore
+	mtx    sync.Mutex
+}
+
+func newReputation() *reputation {
+	return &reputation{scores: make(map[string]*peerScore)}
+}
+
+//penalize lowers score of the peer, returns true if the score reaches the ban threshold
+func (r *reputation) penalize(id module.PeerID, m Misbehavior) (int, bool) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	now := time.Now()
+	k := id.String()
+	s, ok := r.scores[k]
+	if !ok {
+		s = &peerScore{value: DefaultPeerScore, updated: now}
+		r.scores[k] = s
+	} else {
+		s.recover(now)
+	}
+	s.value -= m.Penalty()
+	s.last = m
+	return s.value, s.value <= DefaultPeerBanThreshold
+}
+
+func (r *reputation) score(id module.PeerID) int {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if s, ok := r.scores[id.String()]; ok {
+		s.recover(time.Now())
+		if s.value >= DefaultPeerScore {
+			delete(r.scores, id.String())
+		}
+		return s.value
+	}
+	return DefaultPeerScore
+}
+
+//Map returns scores which are lower than DefaultPeerScore
+func (r *reputation) Map() map[string]interface{} {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	now := time.Now()
+	m := make(map[string]interface{})
+	for k, s := range r.scores {
+		s.recover(now)
+		if s.value >= DefaultPeerScore {
+			delete(r.scores, k)
+			continue
+		}
+		m[k] = map[string]interface{}{
+			"score": s.value,
+			"last":  s.last.String(),
+		}
+	}
+	return m
+}
+
+func (r *reputation) remove(id module.PeerID) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	delete(r.scores, id.String())
+}
+
+type BanEntry struct {
+	ID     string     `json:"id"`
+	Addr   NetAddress `json:"addr,omitempty"`
+	Reason string     `json:"reason"`
+	Until  time.Time  `json:"until"`
+}
+
+func (e *BanEntry) expired(now time.Time) bool {
+	return !e.Until.After(now)
+}
+
+//BanList is list of banned peers which is shared by all channels of the transport.
+//If the file path is set, it's persisted on every change.
+type BanList struct {
+	entries  map[string]*BanEntry
+	duration time.Duration
+	filePath string
+	onBan    func(id string)
+	mtx      sync.RWMutex
+}
+
+func newBanList() *BanList {
+	return &BanList{
+		entries:  make(map[string]*BanEntry),
+		duration: DefaultPeerBanDuration,
+	}
+}
+
+func (b *BanList) Load(filePath string) error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	b.filePath = filePath
+	bs, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var l []*BanEntry
+	if err = json.Unmarshal(bs, &l); err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, e := range l {
+		if !e.expired(now) {
+			b.entries[e.ID] = e
+		}
+	}
+	return nil
+}
+
+func (b *BanList) _save() error {
+	if len(b.filePath) == 0 {
+		return nil
+	}
+	bs, err := json.Marshal(b._entries())
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(b.filePath, bs, 0644)
+}
+
+func (b *BanList) SetDuration(d time.Duration) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if d <= 0 {
+		d = DefaultPeerBanDuration
+	}
+	b.duration = d
+}
+
+func (b *BanList) Duration() time.Duration {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	return b.duration
+}
+
+//Ban adds the peer to the list and closes connections of the peer,
+//if d is not positive then uses duration of the list.
+func (b *BanList) Ban(id string, addr NetAddress, d time.Duration, reason string) error {
+	if len(id) == 0 {
+		return errors.WithStack(ErrIllegalArgument)
+	}
+	err := b.ban(id, addr, d, reason)
+	if b.onBan != nil {
+		b.onBan(id)
+	}
+	return err
+}
+
+func (b *BanList) ban(id string, addr NetAddress, d time.Duration, reason string) error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if d <= 0 {
+		d = b.duration
+	}
+	b.entries[id] = &BanEntry{
+		ID:     id,
+		Addr:   addr,
+		Reason: reason,
+		Until:  time.Now().Add(d),
+	}
+	return b._save()
+}
+
+func (b *BanList) Unban(id string) error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if _, ok := b.entries[id]; !ok {
+		return errors.NotFoundError.Errorf("NotBanned(id=%s)", id)
+	}
+	delete(b.entries, id)
+	return b._save()
+}
+
+func (b *BanList) IsBanned(id module.PeerID) bool {
+	if id == nil {
+		return false
+	}
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	e, ok := b.entries[id.String()]
+	return ok && !e.expired(time.Now())
+}
+
+func (b *BanList) IsBannedAddress(na NetAddress) bool {
+	if len(na) == 0 {
+		return false
+	}
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	now := time.Now()
+	for _, e := range b.entries {
+		if e.Addr == na && !e.expired(now) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *BanList) _entries() []*BanEntry {
+	now := time.Now()
+	l := make([]*BanEntry, 0, len(b.entries))
+	for k, e := range b.entries {
+		if e.expired(now) {
+			delete(b.entries, k)
+			continue
+		}
+		l = append(l, e)
+	}
+	sort.Slice(l, func(i, j int) bool {
+		return l[i].ID < l[j].ID
+	})
+	return l
+}
+
+//Entries returns not expired entries ordered by ID
+func (b *BanList) Entries() []BanEntry {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	es := b._entries()
+	l := make([]BanEntry, len(es))
+	for i, e := range es {
+		l[i] = *e
+	}
+	return l
+}
+
+func GetBanList(nt module.NetworkTransport) *BanList {
+	if t, ok := nt.(*transport); ok {
+		return t.pd.banList
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package network
+
+import (
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/common/wallet"
+	"github.com/icon-project/goloop/module"
+)
+
+func newTestPeerID() module.PeerID {
+	return NewPeerIDFromAddress(wallet.New().Address())
+}
+
+func Test_reputation_misbehaviorOf(t *testing.T) {
+	assert.Equal(t, MisbehaviorNone, misbehaviorOf(nil))
+	assert.Equal(t, MisbehaviorNone, misbehaviorOf(errors.New("test")))
+	assert.Equal(t, MisbehaviorInvalidSignature, misbehaviorOf(InvalidSignatureError.New("test")))
+	assert.Equal(t, MisbehaviorUndecodable, misbehaviorOf(DecodeMessageError.Wrap(errors.New("cause"), "test")))
+	assert.Equal(t, MisbehaviorInvalidMessage, misbehaviorOf(InvalidMessageError.New("test")))
+	assert.Equal(t, MisbehaviorDuplicateFlood, misbehaviorOf(ErrDuplicatedPacket))
+}
+
+func Test_reputation_penalize(t *testing.T) {
+	r := newReputation()
+	id := newTestPeerID()
+	assert.Equal(t, DefaultPeerScore, r.score(id))
+
+	s, ban := r.penalize(id, MisbehaviorInvalidMessage)
+	assert.Equal(t, DefaultPeerScore-MisbehaviorInvalidMessage.Penalty(), s)
+	assert.False(t, ban)
+	assert.Len(t, r.Map(), 1)
+
+	for !ban {
+		_, ban = r.penalize(id, MisbehaviorInvalidSignature)
+	}
+	assert.True(t, r.score(id) <= DefaultPeerBanThreshold)
+
+	r.remove(id)
+	assert.Equal(t, DefaultPeerScore, r.score(id))
+	assert.Len(t, r.Map(), 0)
+}
+
+func Test_reputation_recover(t *testing.T) {
+	now := time.Now()
+	s := &peerScore{value: 50, updated: now.Add(-10 * time.Second)}
+	s.recover(now)
+	assert.Equal(t, 50+10*DefaultPeerScoreRecovery, s.value)
+
+	s.updated = now.Add(-1 * time.Hour)
+	s.recover(now)
+	assert.Equal(t, DefaultPeerScore, s.value)
+}
+
+func Test_reputation_BanList(t *testing.T) {
+	fp := path.Join(t.TempDir(), "ban.json")
+	bl := newBanList()
+	assert.NoError(t, bl.Load(fp))
+
+	var banned []string
+	bl.onBan = func(id string) {
+		banned = append(banned, id)
+	}
+
+	id := newTestPeerID()
+	na := NetAddress("127.0.0.1:8080")
+	assert.False(t, bl.IsBanned(id))
+	assert.Error(t, bl.Ban("", na, 0, "test"))
+
+	assert.NoError(t, bl.Ban(id.String(), na, 0, "test"))
+	assert.Equal(t, []string{id.String()}, banned)
+	assert.True(t, bl.IsBanned(id))
+	assert.True(t, bl.IsBannedAddress(na))
+	assert.False(t, bl.IsBannedAddress(NetAddress("127.0.0.1:8081")))
+
+	es := bl.Entries()
+	assert.Len(t, es, 1)
+	assert.Equal(t, "test", es[0].Reason)
+	assert.True(t, es[0].Until.After(time.Now().Add(DefaultPeerBanDuration-time.Minute)))
+
+	loaded := newBanList()
+	assert.NoError(t, loaded.Load(fp))
+	assert.True(t, loaded.IsBanned(id))
+
+	assert.NoError(t, bl.Unban(id.String()))
+	assert.False(t, bl.IsBanned(id))
+	assert.True(t, errors.NotFoundError.Equals(bl.Unban(id.String())))
+
+	loaded = newBanList()
+	assert.NoError(t, loaded.Load(fp))
+	assert.Len(t, loaded.Entries(), 0)
+}
+
+func Test_reputation_BanListExpire(t *testing.T) {
+	bl := newBanList()
+	id := newTestPeerID()
+	assert.NoError(t, bl.Ban(id.String(), "", 10*time.Millisecond, "test"))
+	assert.True(t, bl.IsBanned(id))
+	time.Sleep(20 * time.Millisecond)
+	assert.False(t, bl.IsBanned(id))
+	assert.Len(t, bl.Entries(), 0)
+}
+
+func Test_reputation_markReceived(t *testing.T) {
+	p := &Peer{
+		id:       newTestPeerID(),
+		pool:     NewTimestampPool(DefaultPeerPoolExpireSecond + 1),
+		recvPool: NewTimestampPool(DefaultPeerPoolExpireSecond + 1),
+	}
+	pkt := newPacket(module.ProtocolInfo(0x0100), []byte("test"), p.ID())
+	assert.NoError(t, pkt.updateHash(false))
+
+	// echo of the packet sent to the peer is not a duplicate
+	p.pool.Put(pkt.hashOfPacket)
+	p.markReceived(pkt)
+	assert.False(t, pkt.duplicated)
+
+	p.markReceived(pkt)
+	assert.True(t, pkt.duplicated)
+}
@@ -22,6 +22,7 @@ type transport struct {
 	pd      *PeerDispatcher
 	dMap    map[string]*Dialer
 	logger  log.Logger
+	mtx     sync.RWMutex
 }
 
 func NewTransport(address string, w module.Wallet, l log.Logger) module.NetworkTransport {
@@ -43,6 +44,8 @@ func NewTransport(address string, w module.Wallet, l log.Logger) module.NetworkT
 		dMap:    make(map[string]*Dialer),
 		logger:  transportLogger,
 	}
+	pd.nat = newNATTraversal(na, listener.Address, transportLogger)
+	pd.nat.onChange = t.onAddressChange
 	return t
 }
 
@@ -63,9 +66,23 @@ func (t *transport) PeerID() module.PeerID {
 }
 
 func (t *transport) Address() string {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
 	return string(t.address)
 }
 
+//callback from NATTraversal
+func (t *transport) onAddressChange(na NetAddress) {
+	t.mtx.Lock()
+	t.address = na
+	t.mtx.Unlock()
+
+	t.cn.setNetAddress(na)
+	for _, p2p := range t.pd.getPeerToPeers() {
+		p2p.self.setNetAddress(na)
+	}
+}
+
 func (t *transport) SetListenAddress(address string) error {
 	return t.l.SetAddress(address)
 }
@@ -361,6 +378,10 @@ type PeerDispatcher struct {
 	*peerHandler
 	peerHandlers *list.List
 	p2pMap       map[string]*PeerToPeer
+	banList      *BanList
+	rateLimiter  *RateLimiter
+	capturer     *Capturer
+	nat          *NATTraversal
 	mtx          sync.RWMutex
 
 	mtr *metric.NetworkMetric
@@ -370,11 +391,15 @@ func newPeerDispatcher(id module.PeerID, l log.Logger, peerHandlers ...PeerHandl
 	pd := &PeerDispatcher{
 		peerHandlers: list.New(),
 		p2pMap:       make(map[string]*PeerToPeer),
+		banList:      newBanList(),
+		rateLimiter:  newRateLimiter(),
+		capturer:     newCapturer(),
 		peerHandler:  newPeerHandler(l),
 		mtr:          metric.NewNetworkMetric(metric.DefaultMetricContext()),
 	}
 	// pd.peerHandler.codecHandle.MapType = reflect.TypeOf(map[string]interface{}(nil))
 	pd.setSelfPeerID(id)
+	pd.banList.onBan = pd.onBan
 
 	pd.registerPeerHandler(pd, true)
 	for _, ph := range peerHandlers {
@@ -411,6 +436,29 @@ func (pd *PeerDispatcher) getPeerToPeer(channel string) *PeerToPeer {
 	return pd.p2pMap[channel]
 }
 
+func (pd *PeerDispatcher) getPeerToPeers() []*PeerToPeer {
+	defer pd.mtx.RUnlock()
+	pd.mtx.RLock()
+
+	l := make([]*PeerToPeer, 0, len(pd.p2pMap))
+	for _, p2p := range pd.p2pMap {
+		l = append(l, p2p)
+	}
+	return l
+}
+
+//callback from BanList.Ban
+func (pd *PeerDispatcher) onBan(id string) {
+	for _, p2p := range pd.getPeerToPeers() {
+		ps := p2p.findPeers(func(p *Peer) bool {
+			return p.ID().String() == id
+		})
+		for _, p := range ps {
+			p.CloseByError(ErrBannedPeer)
+		}
+	}
+}
+
 func (pd *PeerDispatcher) registerPeerHandler(ph PeerHandler, pushBack bool) {
 	pd.logger.Traceln("registerPeerHandler", ph, pushBack)
 	if pushBack {
@@ -450,6 +498,8 @@ func (pd *PeerDispatcher) dispatchPeer(p *Peer) {
 	elm := pd.peerHandlers.Back()
 	ph := elm.Value.(PeerHandler)
 	p.setMetric(pd.mtr)
+	p.setRateLimiter(pd.rateLimiter)
+	p.setCapturer(pd.capturer)
 	p.setPacketCbFunc(ph.onPacket)
 	p.setErrorCbFunc(ph.onError)
 	p.setCloseCbFunc(ph.onClose)
@@ -459,6 +509,11 @@ func (pd *PeerDispatcher) dispatchPeer(p *Peer) {
 //callback from PeerHandler.nextOnPeer
 func (pd *PeerDispatcher) onPeer(p *Peer) {
 	pd.logger.Traceln("onPeer", p)
+	if pd.banList.IsBanned(p.ID()) {
+		pd.logger.Infoln("onPeer", "banned peer", p)
+		p.CloseByError(ErrBannedPeer)
+		return
+	}
 	if p2p := pd.getPeerToPeer(p.Channel()); p2p != nil {
 		p.setMetric(p2p.mtr)
 		p.setPacketCbFunc(p2p.onPacket)
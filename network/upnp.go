@@ -0,0 +1,313 @@
+package network
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/icon-project/goloop/common/errors"
+)
+
+const (
+	ssdpMulticastAddress         = "239.255.255.250:1900"
+	ssdpSearchTarget             = "urn:schemas-upnp-org:device:InternetGatewayDevice:1"
+	upnpErrorOnlyPermanentLeases = 725
+)
+
+var upnpServiceTypes = []string{
+	"urn:schemas-upnp-org:service:WANIPConnection:2",
+	"urn:schemas-upnp-org:service:WANIPConnection:1",
+	"urn:schemas-upnp-org:service:WANPPPConnection:1",
+}
+
+type upnpService struct {
+	ServiceType string `xml:"serviceType"`
+	ControlURL  string `xml:"controlURL"`
+}
+
+type upnpDevice struct {
+	DeviceType string        `xml:"deviceType"`
+	Services   []upnpService `xml:"serviceList>service"`
+	Devices    []upnpDevice  `xml:"deviceList>device"`
+}
+
+func (d *upnpDevice) findService(st string) *upnpService {
+	for i := range d.Services {
+		if d.Services[i].ServiceType == st {
+			return &d.Services[i]
+		}
+	}
+	for i := range d.Devices {
+		if s := d.Devices[i].findService(st); s != nil {
+			return s
+		}
+	}
+	return nil
+}
+
+type upnpRoot struct {
+	URLBase string     `xml:"URLBase"`
+	Device  upnpDevice `xml:"device"`
+}
+
+//upnpGateway is a client of WANIPConnection or WANPPPConnection service of
+//the Internet Gateway Device
+type upnpGateway struct {
+	location    string
+	serviceType string
+	controlURL  string
+	client      *http.Client
+}
+
+//discoverUPnP sends M-SEARCH to ssdpAddr and returns the first gateway
+//which has the connection service
+func discoverUPnP(ssdpAddr string, timeout time.Duration) (*upnpGateway, error) {
+	raddr, err := net.ResolveUDPAddr("udp4", ssdpAddr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	req := fmt.Sprintf("M-SEARCH * HTTP/1.1\r\n"+
+		"HOST: %s\r\n"+
+		"ST: %s\r\n"+
+		"MAN: \"ssdp:discover\"\r\n"+
+		"MX: %d\r\n\r\n", ssdpMulticastAddress, ssdpSearchTarget, int(timeout/time.Second))
+	if _, err = conn.WriteTo([]byte(req), raddr); err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(timeout)
+	if err = conn.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+	b := make([]byte, 2048)
+	for {
+		n, _, err := conn.ReadFrom(b)
+		if err != nil {
+			return nil, PortMappingError.Wrap(err, "no UPnP gateway")
+		}
+		resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(b[:n])), nil)
+		if err != nil {
+			continue
+		}
+		resp.Body.Close()
+		location := resp.Header.Get("Location")
+		if resp.StatusCode != http.StatusOK || location == "" {
+			continue
+		}
+		g, err := newUPnPGateway(location, time.Until(deadline))
+		if err != nil {
+			continue
+		}
+		return g, nil
+	}
+}
+
+func newUPnPGateway(location string, timeout time.Duration) (*upnpGateway, error) {
+	client := &http.Client{Timeout: DefaultNATRequestTimeout}
+	if timeout > 0 && timeout < DefaultNATRequestTimeout {
+		client.Timeout = timeout
+	}
+	resp, err := client.Get(location)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, PortMappingError.Errorf("fail to get description status=%d", resp.StatusCode)
+	}
+	root := &upnpRoot{}
+	if err = xml.NewDecoder(resp.Body).Decode(root); err != nil {
+		return nil, PortMappingError.Wrap(err, "invalid description")
+	}
+	base, err := url.Parse(location)
+	if err != nil {
+		return nil, err
+	}
+	if root.URLBase != "" {
+		if u, err := url.Parse(root.URLBase); err == nil {
+			base = u
+		}
+	}
+	for _, st := range upnpServiceTypes {
+		if s := root.Device.findService(st); s != nil {
+			u, err := base.Parse(s.ControlURL)
+			if err != nil {
+				return nil, err
+			}
+			return &upnpGateway{
+				location:    location,
+				serviceType: st,
+				controlURL:  u.String(),
+				client:      &http.Client{Timeout: DefaultNATRequestTimeout},
+			}, nil
+		}
+	}
+	return nil, PortMappingError.New("no connection service")
+}
+
+func (g *upnpGateway) Name() string {
+	return "upnp(" + g.location + ")"
+}
+
+type upnpArg struct {
+	name  string
+	value string
+}
+
+//soap calls the action and returns the value of the elements in the response
+func (g *upnpGateway) soap(action string, args ...upnpArg) (map[string]string, error) {
+	body := &bytes.Buffer{}
+	body.WriteString(`<?xml version="1.0"?>` +
+		`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" ` +
+		`s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body>`)
+	fmt.Fprintf(body, `<u:%s xmlns:u="%s">`, action, g.serviceType)
+	for _, arg := range args {
+		fmt.Fprintf(body, "<%s>", arg.name)
+		if err := xml.EscapeText(body, []byte(arg.value)); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(body, "</%s>", arg.name)
+	}
+	fmt.Fprintf(body, `</u:%s></s:Body></s:Envelope>`, action)
+
+	req, err := http.NewRequest(http.MethodPost, g.controlURL, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", `text/xml; charset="utf-8"`)
+	req.Header.Set("SOAPAction", fmt.Sprintf(`"%s#%s"`, g.serviceType, action))
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, err
+	}
+	values, err := upnpValues(b)
+	if err != nil {
+		return nil, PortMappingError.Wrapf(err, "invalid response of %s", action)
+	}
+	if resp.StatusCode != http.StatusOK {
+		code, _ := strconv.Atoi(values["errorCode"])
+		return nil, &upnpError{
+			action: action,
+			status: resp.StatusCode,
+			code:   code,
+			desc:   values["errorDescription"],
+		}
+	}
+	return values, nil
+}
+
+//upnpValues returns the values of all leaf elements
+func upnpValues(b []byte) (map[string]string, error) {
+	values := make(map[string]string)
+	dec := xml.NewDecoder(bytes.NewReader(b))
+	var name string
+	var text strings.Builder
+	for {
+		t, err := dec.Token()
+		if err == io.EOF {
+			return values, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch e := t.(type) {
+		case xml.StartElement:
+			name = e.Name.Local
+			text.Reset()
+		case xml.CharData:
+			text.Write(e)
+		case xml.EndElement:
+			if name == e.Name.Local {
+				values[name] = strings.TrimSpace(text.String())
+			}
+			name = ""
+		}
+	}
+}
+
+type upnpError struct {
+	action string
+	status int
+	code   int
+	desc   string
+}
+
+func (e *upnpError) Error() string {
+	return fmt.Sprintf("UPnPError(action=%s,status=%d,code=%d,desc=%s)", e.action, e.status, e.code, e.desc)
+}
+
+func (e *upnpError) ErrorCode() errors.Code {
+	return PortMappingError
+}
+
+func (g *upnpGateway) ExternalIP() (net.IP, error) {
+	values, err := g.soap("GetExternalIPAddress")
+	if err != nil {
+		return nil, err
+	}
+	ip := net.ParseIP(values["NewExternalIPAddress"])
+	if ip == nil {
+		return nil, PortMappingError.Errorf("invalid external ip %s", values["NewExternalIPAddress"])
+	}
+	return ip, nil
+}
+
+func (g *upnpGateway) AddPortMapping(internalPort, externalPort int, desc string, lifetime time.Duration) (int, error) {
+	u, err := url.Parse(g.controlURL)
+	if err != nil {
+		return 0, err
+	}
+	ip, err := localIPFor(u.Hostname())
+	if err != nil {
+		return 0, err
+	}
+	add := func(lifetime time.Duration) error {
+		_, err := g.soap("AddPortMapping",
+			upnpArg{"NewRemoteHost", ""},
+			upnpArg{"NewExternalPort", strconv.Itoa(externalPort)},
+			upnpArg{"NewProtocol", natProto},
+			upnpArg{"NewInternalPort", strconv.Itoa(internalPort)},
+			upnpArg{"NewInternalClient", ip.String()},
+			upnpArg{"NewEnabled", "1"},
+			upnpArg{"NewPortMappingDescription", desc},
+			upnpArg{"NewLeaseDuration", strconv.Itoa(int(lifetime / time.Second))},
+		)
+		return err
+	}
+	err = add(lifetime)
+	if ue, ok := err.(*upnpError); ok && ue.code == upnpErrorOnlyPermanentLeases {
+		err = add(0)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return externalPort, nil
+}
+
+func (g *upnpGateway) DeletePortMapping(internalPort, externalPort int) error {
+	_, err := g.soap("DeletePortMapping",
+		upnpArg{"NewRemoteHost", ""},
+		upnpArg{"NewExternalPort", strconv.Itoa(externalPort)},
+		upnpArg{"NewProtocol", natProto},
+	)
+	return err
+}
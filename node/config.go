@@ -14,6 +14,8 @@ import (
 const (
 	ChainConfigFileName     = "config.json"
 	ChainGenesisZipFileName = "genesis.zip"
+	BanListFileName         = "ban.json"
+	CaptureDirectoryName    = "capture"
 )
 
 type StaticConfig struct {
@@ -30,6 +32,9 @@ type StaticConfig struct {
 	AuthSkipIfEmptyUsers bool `json:"auth_skip_if_empty_users,omitempty"`
 	NIDForP2P            bool `json:"nid_for_p2p,omitempty"`
 
+	P2PNAT         string `json:"p2p_nat,omitempty"`
+	P2PAutoAddress bool   `json:"p2p_auto_address,omitempty"`
+
 	BaseDir  string `json:"node_dir"`
 	FilePath string `json:"-"` // absolute path
 
@@ -108,6 +113,14 @@ type RuntimeConfig struct {
 	EEInstances       int    `json:"eeInstances"`
 	RPCDefaultChannel string `json:"rpcDefaultChannel"`
 	RPCIncludeDebug   bool   `json:"rpcIncludeDebug"`
+	P2PBanDuration    string `json:"p2pBanDuration,omitempty"`
+
+	P2PUploadLimit       int64  `json:"p2pUploadLimit,omitempty"`
+	P2PDownloadLimit     int64  `json:"p2pDownloadLimit,omitempty"`
+	P2PPeerUploadLimit   int64  `json:"p2pPeerUploadLimit,omitempty"`
+	P2PPeerDownloadLimit int64  `json:"p2pPeerDownloadLimit,omitempty"`
+	P2PProtocolLimits    string `json:"p2pProtocolLimits,omitempty"`
+	P2PCapture           bool   `json:"p2pCapture,omitempty"`
 
 	FilePath string `json:"-"` // absolute path
 }
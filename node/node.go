@@ -15,6 +15,7 @@ import (
 
 	"github.com/icon-project/goloop/chain"
 	"github.com/icon-project/goloop/chain/gs"
+	"github.com/icon-project/goloop/common"
 	"github.com/icon-project/goloop/common/errors"
 	"github.com/icon-project/goloop/common/log"
 	"github.com/icon-project/goloop/module"
@@ -334,6 +335,9 @@ func (n *Node) Start() {
 	if err != nil {
 		log.Panicf("fail to P2P listen err=%+v", err)
 	}
+	if err = network.GetNATTraversal(n.nt).Start(n.cfg.P2PNAT); err != nil {
+		log.Panicf("fail to start NAT traversal err=%+v", err)
+	}
 
 	go func() {
 		for channel, chain := range n.chains {
@@ -359,6 +363,7 @@ func (n *Node) Start() {
 }
 
 func (n *Node) Stop() {
+	network.GetNATTraversal(n.nt).Stop()
 	if err := n.nt.Close(); err != nil {
 		log.Panicf("fail to P2P close err=%+v", err)
 	}
@@ -875,6 +880,49 @@ func (n *Node) Configure(key string, value string) error {
 			n.rcfg.RPCIncludeDebug = boolVal
 		}
 		n.srv.SetIncludeDebug(n.rcfg.RPCIncludeDebug)
+	case "p2pBanDuration":
+		if d, err := time.ParseDuration(value); err != nil {
+			return errors.Wrapf(err, "invalid value type")
+		} else if d <= 0 {
+			return errors.Errorf("invalid value %s", value)
+		} else {
+			n.rcfg.P2PBanDuration = value
+			network.GetBanList(n.nt).SetDuration(d)
+		}
+	case "p2pUploadLimit", "p2pDownloadLimit", "p2pPeerUploadLimit", "p2pPeerDownloadLimit":
+		int64Val, err := strconv.ParseInt(value, 0, 64)
+		if err != nil {
+			return errors.Wrapf(err, "invalid value type")
+		}
+		switch key {
+		case "p2pUploadLimit":
+			n.rcfg.P2PUploadLimit = int64Val
+		case "p2pDownloadLimit":
+			n.rcfg.P2PDownloadLimit = int64Val
+		case "p2pPeerUploadLimit":
+			n.rcfg.P2PPeerUploadLimit = int64Val
+		case "p2pPeerDownloadLimit":
+			n.rcfg.P2PPeerDownloadLimit = int64Val
+		}
+		if err = applyRateLimit(network.GetRateLimiter(n.nt), n.rcfg); err != nil {
+			return err
+		}
+	case "p2pProtocolLimits":
+		if _, err := network.ParseProtocolLimits(value); err != nil {
+			return err
+		}
+		n.rcfg.P2PProtocolLimits = value
+		if err := applyRateLimit(network.GetRateLimiter(n.nt), n.rcfg); err != nil {
+			return err
+		}
+	case "p2pCapture":
+		if boolVal, err := strconv.ParseBool(value); err != nil {
+			return errors.Wrapf(err, "invalid value type")
+		} else if err = n.applyCapture(boolVal); err != nil {
+			return err
+		} else {
+			n.rcfg.P2PCapture = boolVal
+		}
 	default:
 		return errors.Errorf("not found key")
 	}
@@ -884,6 +932,44 @@ func (n *Node) Configure(key string, value string) error {
 	return nil
 }
 
+func applyRateLimit(l *network.RateLimiter, rcfg *RuntimeConfig) error {
+	limits, err := network.ParseProtocolLimits(rcfg.P2PProtocolLimits)
+	if err != nil {
+		return err
+	}
+	l.SetGlobalLimit(rcfg.P2PUploadLimit, rcfg.P2PDownloadLimit)
+	l.SetPeerLimit(rcfg.P2PPeerUploadLimit, rcfg.P2PPeerDownloadLimit)
+	l.SetProtocolLimits(limits)
+	return nil
+}
+
+func (n *Node) applyCapture(enable bool) error {
+	c := network.GetCapturer(n.nt)
+	if !enable {
+		return c.Stop()
+	}
+	if c.IsRunning() {
+		return nil
+	}
+	return c.Start(path.Join(n.cfg.AbsBaseDir(), CaptureDirectoryName))
+}
+
+func (n *Node) GetBans() []network.BanEntry {
+	return network.GetBanList(n.nt).Entries()
+}
+
+func (n *Node) Ban(id string, d time.Duration, reason string) error {
+	addr := new(common.Address)
+	if err := addr.SetString(id); err != nil || addr.IsContract() {
+		return errors.IllegalArgumentError.Errorf("InvalidPeerID(id=%s)", id)
+	}
+	return network.GetBanList(n.nt).Ban(addr.String(), "", d, reason)
+}
+
+func (n *Node) Unban(id string) error {
+	return network.GetBanList(n.nt).Unban(id)
+}
+
 func NewNode(
 	w module.Wallet,
 	cfg *StaticConfig,
@@ -906,6 +992,29 @@ func NewNode(
 	if cfg.P2PListenAddr != "" {
 		_ = nt.SetListenAddress(cfg.P2PListenAddr)
 	}
+	if err := network.ValidateNAT(cfg.P2PNAT); err != nil {
+		log.Panicf("invalid p2p_nat %s err=%+v", cfg.P2PNAT, err)
+	}
+	network.GetNATTraversal(nt).SetAutoAddress(cfg.P2PAutoAddress)
+	banList := network.GetBanList(nt)
+	if err := banList.Load(path.Join(nodeDir, BanListFileName)); err != nil {
+		log.Panicf("fail to load ban list err=%+v", err)
+	}
+	if rcfg.P2PBanDuration != "" {
+		if d, err := time.ParseDuration(rcfg.P2PBanDuration); err != nil {
+			log.Panicf("invalid p2pBanDuration %s err=%+v", rcfg.P2PBanDuration, err)
+		} else {
+			banList.SetDuration(d)
+		}
+	}
+	if err := applyRateLimit(network.GetRateLimiter(nt), rcfg); err != nil {
+		log.Panicf("invalid rate limit config err=%+v", err)
+	}
+	if rcfg.P2PCapture {
+		if err := network.GetCapturer(nt).Start(path.Join(nodeDir, CaptureDirectoryName)); err != nil {
+			log.Panicf("fail to start packet capture err=%+v", err)
+		}
+	}
 	srv := server.NewManager(cfg.RPCAddr, cfg.RPCDump, rcfg.RPCIncludeDebug, rcfg.RPCDefaultChannel, w, l)
 
 	ee, err := eeproxy.AllocEngines(l, strings.Split(cfg.Engines, ",")...)
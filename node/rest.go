@@ -108,11 +108,21 @@ type ChainPruneParam struct {
 	Height int64  `json:"height"`
 }
 
+type ChainVerifyRewardsParam struct {
+	Term int64 `json:"term"`
+}
+
 type ConfigureParam struct {
 	Key   string `json:"key"`
 	Value string `json:"value"`
 }
 
+type BanParam struct {
+	ID       string `json:"id"`
+	Duration string `json:"duration,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
 type RestoreBackupParam struct {
 	Name      string `json:"name"`
 	Overwrite bool   `json:"overwrite"`
@@ -445,6 +455,7 @@ func (r *Rest) RegisterSystemHandlers(g *echo.Group) {
 	g.POST("/configure", r.ConfigureSystem)
 	r.RegistryBackupHandlers(g.Group("/backup"))
 	r.RegistryRestoreHandlers(g.Group("/restore"))
+	r.RegistryBanHandlers(g.Group("/ban"))
 }
 
 func (r *Rest) GetSystem(ctx echo.Context) error {
@@ -523,6 +534,48 @@ func (r *Rest) StopRestore(ctx echo.Context) error {
 	return ctx.String(http.StatusOK, "OK")
 }
 
+func (r *Rest) RegistryBanHandlers(g *echo.Group) {
+	g.GET("", r.GetBans)
+	g.POST("", r.Ban)
+	g.DELETE(UrlUserRes, r.Unban)
+}
+
+func (r *Rest) GetBans(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, r.n.GetBans())
+}
+
+func (r *Rest) Ban(ctx echo.Context) error {
+	param := new(BanParam)
+	if err := ctx.Bind(param); err != nil {
+		return echo.ErrBadRequest
+	}
+	var d time.Duration
+	if param.Duration != "" {
+		var err error
+		if d, err = time.ParseDuration(param.Duration); err != nil {
+			return ctx.String(http.StatusBadRequest, err.Error())
+		}
+	}
+	if err := r.n.Ban(param.ID, d, param.Reason); err != nil {
+		if errors.IllegalArgumentError.Equals(err) {
+			return ctx.String(http.StatusBadRequest, err.Error())
+		}
+		return err
+	}
+	return ctx.String(http.StatusOK, "OK")
+}
+
+func (r *Rest) Unban(ctx echo.Context) error {
+	p := ctx.Param(ParamID)
+	if err := r.n.Unban(p); err != nil {
+		if errors.NotFoundError.Equals(err) {
+			return ctx.String(http.StatusNotFound, err.Error())
+		}
+		return err
+	}
+	return ctx.String(http.StatusOK, "OK")
+}
+
 func (r *Rest) RegisterUserHandlers(g *echo.Group) {
 	g.GET("", r.Users)
 	g.POST("", r.AddUser)
@@ -12,6 +12,7 @@ var (
 	scoreAddressRegex = regexp.MustCompile("^cx[0-9a-f]{40}$")
 	hexInt            = regexp.MustCompile("^0x(0|[1-9a-f][0-9a-f]*)$")
 	hashRegex         = regexp.MustCompile("^0x[0-9a-f]{64}$")
+	hexBytesRegex     = regexp.MustCompile("^0x([0-9a-f][0-9a-f])*$")
 )
 
 type Validator struct {
@@ -32,6 +33,7 @@ func NewValidator() *Validator {
 	v.RegisterValidation("t_addr_score", isScoreAddress)
 	v.RegisterValidation("t_int", isHexInt)
 	v.RegisterValidation("t_hash", isHash)
+	v.RegisterValidation("t_bytes", isHexBytes)
 
 	v.RegisterAlias("t_sig", "base64")
 	v.RegisterAlias("t_addr", "t_addr_eoa|t_addr_score")
@@ -79,3 +81,7 @@ func isHexInt(fl validator.FieldLevel) bool {
 func isHash(fl validator.FieldLevel) bool {
 	return hashRegex.MatchString(fl.Field().String())
 }
+
+func isHexBytes(fl validator.FieldLevel) bool {
+	return hexBytesRegex.MatchString(fl.Field().String())
+}
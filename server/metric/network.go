@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"go.opencensus.io/stats"
 	"go.opencensus.io/stats/view"
@@ -16,6 +17,10 @@ var (
 	mkDest     = NewMetricKey("dest")
 	mkProtocol = NewMetricKey("protocol")
 	networkMks = []tag.Key{mkDest, mkProtocol}
+
+	msSendThroughput = stats.Int64("network_send_throughput", "send bytes per second", stats.UnitBytes)
+	msRecvThroughput = stats.Int64("network_recv_throughput", "recv bytes per second", stats.UnitBytes)
+	throughputMks    = []tag.Key{mkProtocol}
 )
 
 func RegisterNetwork() {
@@ -23,12 +28,24 @@ func RegisterNetwork() {
 	RegisterMetricView(msSend, view.Sum(), networkMks)
 	RegisterMetricView(msRecv, view.Count(), networkMks)
 	RegisterMetricView(msRecv, view.Sum(), networkMks)
+	RegisterMetricView(msSendThroughput, view.LastValue(), throughputMks)
+	RegisterMetricView(msRecvThroughput, view.LastValue(), throughputMks)
+}
+
+type throughput struct {
+	send, recv int64
+	//bytes per second of the last period
+	sendRate, recvRate int64
 }
 
 type NetworkMetric struct {
 	ctx    context.Context
 	ctxMap map[string]context.Context
 	ctxMtx sync.RWMutex
+
+	tps     map[uint16]*throughput
+	tpsLast time.Time
+	tpsMtx  sync.Mutex
 }
 
 func (m *NetworkMetric) get(key string) (context.Context, bool) {
@@ -62,16 +79,72 @@ func (m *NetworkMetric) getMetricContext(dest byte, ttl byte, hint byte, protoco
 func (m *NetworkMetric) OnSend(dest byte, ttl byte, hint byte, protocol uint16, pktLen uint32) {
 	ctx := m.getMetricContext(dest, ttl, hint, protocol)
 	stats.Record(ctx, msSend.M(int64(pktLen)))
+	m.getThroughput(protocol, func(tp *throughput) {
+		tp.send += int64(pktLen)
+	})
 }
 
 func (m *NetworkMetric) OnRecv(dest byte, ttl byte, hint byte, protocol uint16, pktLen uint32) {
 	ctx := m.getMetricContext(dest, ttl, hint, protocol)
 	stats.Record(ctx, msRecv.M(int64(pktLen)))
+	m.getThroughput(protocol, func(tp *throughput) {
+		tp.recv += int64(pktLen)
+	})
+}
+
+func (m *NetworkMetric) getThroughput(protocol uint16, f func(tp *throughput)) {
+	m.tpsMtx.Lock()
+	defer m.tpsMtx.Unlock()
+
+	tp, ok := m.tps[protocol]
+	if !ok {
+		tp = &throughput{}
+		m.tps[protocol] = tp
+	}
+	f(tp)
+}
+
+//UpdateThroughput records bytes per second of each protocol since the last update,
+//it should be called periodically.
+func (m *NetworkMetric) UpdateThroughput() {
+	m.tpsMtx.Lock()
+	defer m.tpsMtx.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(m.tpsLast).Seconds()
+	m.tpsLast = now
+	if elapsed <= 0 {
+		return
+	}
+	for protocol, tp := range m.tps {
+		tp.sendRate = int64(float64(tp.send) / elapsed)
+		tp.recvRate = int64(float64(tp.recv) / elapsed)
+		tp.send, tp.recv = 0, 0
+		ctx := GetMetricContext(m.ctx, &mkProtocol, fmt.Sprintf("%#04x", protocol))
+		stats.Record(ctx, msSendThroughput.M(tp.sendRate), msRecvThroughput.M(tp.recvRate))
+	}
+}
+
+//Throughput returns bytes per second of each protocol at the last update
+func (m *NetworkMetric) Throughput() map[string]interface{} {
+	m.tpsMtx.Lock()
+	defer m.tpsMtx.Unlock()
+
+	r := make(map[string]interface{})
+	for protocol, tp := range m.tps {
+		r[fmt.Sprintf("%#04x", protocol)] = map[string]interface{}{
+			"send": tp.sendRate,
+			"recv": tp.recvRate,
+		}
+	}
+	return r
 }
 
 func NewNetworkMetric(ctx context.Context) *NetworkMetric {
 	return &NetworkMetric{
-		ctx: ctx,
-		ctxMap: make(map[string]context.Context),
+		ctx:     ctx,
+		ctxMap:  make(map[string]context.Context),
+		tps:     make(map[uint16]*throughput),
+		tpsLast: time.Now(),
 	}
 }
\ No newline at end of file
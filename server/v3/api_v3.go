@@ -3,7 +3,9 @@ package v3
 import (
 	"bytes"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"math/big"
 	"strconv"
 	"sync"
 	"time"
@@ -397,11 +399,32 @@ func getTransactionByHash(ctx *jsonrpc.Context, params *jsonrpc.Params) (interfa
 	return result, nil
 }
 
+// convertTransactionParam validates parameters for a transaction. A
+// transaction from a contract account has authData, and a transaction from
+// a multi-signature account has multiSig instead of signature.
+func convertTransactionParam(params *jsonrpc.Params) error {
+	var auth struct {
+		AuthData *string         `json:"authData"`
+		MultiSig json.RawMessage `json:"multiSig"`
+	}
+	if err := json.Unmarshal(params.RawMessage(), &auth); err == nil {
+		if auth.AuthData != nil {
+			var param AccountTransactionParam
+			return params.Convert(&param)
+		}
+		if auth.MultiSig != nil {
+			var param MultiSigTransactionParam
+			return params.Convert(&param)
+		}
+	}
+	var param TransactionParam
+	return params.Convert(&param)
+}
+
 func sendTransaction(ctx *jsonrpc.Context, params *jsonrpc.Params) (interface{}, error) {
 	debug := ctx.IncludeDebug()
 
-	var param TransactionParam
-	if err := params.Convert(&param); err != nil {
+	if err := convertTransactionParam(params); err != nil {
 		return nil, jsonrpc.ErrorCodeInvalidParams.Wrap(err, debug)
 	}
 
@@ -684,8 +707,7 @@ func sendTransactionAndWait(ctx *jsonrpc.Context, params *jsonrpc.Params) (inter
 		maxLimit = true
 	}
 
-	var param TransactionParam
-	if err := params.Convert(&param); err != nil {
+	if err := convertTransactionParam(params); err != nil {
 		return nil, jsonrpc.ErrorCodeInvalidParams.Wrap(err, debug)
 	}
 
@@ -812,6 +834,7 @@ func DebugMethodRepository(cfg *jsonrpc.Config) *jsonrpc.MethodRepository {
 
 	mr.RegisterMethod("debug_getTrace", getTrace)
 	mr.RegisterMethod("debug_estimateStep", estimateStep)
+	mr.RegisterMethod("debug_estimateStepDetails", estimateStepDetails)
 
 	return mr
 }
@@ -957,29 +980,32 @@ func getTrace(ctx *jsonrpc.Context, params *jsonrpc.Params) (interface{}, error)
 	return nil, jsonrpc.ErrorCodeSystem.New("Unknown error on channel")
 }
 
-func estimateStep(ctx *jsonrpc.Context, params *jsonrpc.Params) (interface{}, error) {
+func executeForEstimate(
+	ctx *jsonrpc.Context, params *jsonrpc.Params,
+	exec func(sm module.ServiceManager, result, vh []byte, bi module.BlockInfo) (module.Receipt, error),
+) error {
 	debug := ctx.IncludeDebug()
 
 	chain, err := ctx.Chain()
 	if err != nil {
-		return nil, jsonrpc.ErrorCodeServer.Wrap(err, debug)
+		return jsonrpc.ErrorCodeServer.Wrap(err, debug)
 	}
 
 	var param TransactionParamForEstimate
 	if err := params.Convert(&param); err != nil {
-		return nil, jsonrpc.ErrorCodeInvalidParams.Wrap(err, debug)
+		return jsonrpc.ErrorCodeInvalidParams.Wrap(err, debug)
 	}
 
 	bm := chain.BlockManager()
 	sm := chain.ServiceManager()
 	if bm == nil || sm == nil {
-		return nil, jsonrpc.ErrorCodeServer.New("ChannelStopped")
+		return jsonrpc.ErrorCodeServer.New("ChannelStopped")
 	}
 
 	// get last block
 	blk, err := bm.GetLastBlock()
 	if err != nil {
-		return nil, jsonrpc.ErrorCodeServer.Wrap(err, debug)
+		return jsonrpc.ErrorCodeServer.Wrap(err, debug)
 	}
 
 	// new block information based on the last
@@ -991,24 +1017,77 @@ func estimateStep(ctx *jsonrpc.Context, params *jsonrpc.Params) (interface{}, er
 	bi := common.NewBlockInfo(blk.Height()+1, newTS)
 
 	// execute transaction
-	rct, err := sm.ExecuteTransaction(
-		blk.Result(),
-		blk.NextValidators().Hash(),
-		params.RawMessage(),
-		bi,
-	)
+	rct, err := exec(sm, blk.Result(), blk.NextValidators().Hash(), bi)
 	if err != nil {
-		return nil, jsonrpc.ErrorCodeServer.Wrap(err, debug)
+		return jsonrpc.ErrorCodeServer.Wrap(err, debug)
 	}
 	if status := rct.Status(); status != module.StatusSuccess {
 		if rctex, ok := rct.(txresult.Receipt); ok {
 			if err := rctex.Reason(); err != nil {
-				return nil, jsonrpc.ErrScore(rctex.Reason(), debug)
+				return jsonrpc.ErrScore(rctex.Reason(), debug)
 			}
 		}
-		return nil, jsonrpc.ErrScoreWithStatus(status)
+		return jsonrpc.ErrScoreWithStatus(status)
+	}
+	return nil
+}
+
+func estimateStep(ctx *jsonrpc.Context, params *jsonrpc.Params) (interface{}, error) {
+	var rct module.Receipt
+	err := executeForEstimate(ctx, params,
+		func(sm module.ServiceManager, result, vh []byte, bi module.BlockInfo) (module.Receipt, error) {
+			var err error
+			rct, err = sm.ExecuteTransaction(result, vh, params.RawMessage(), bi)
+			return rct, err
+		})
+	if err != nil {
+		return nil, err
 	}
 	steps := new(common.HexInt)
 	steps.Set(rct.StepUsed())
 	return steps, nil
 }
+
+// StepMarginPercent is the margin added to the steps used for the
+// suggested step limit in percentage.
+const StepMarginPercent = 10
+
+func estimateStepDetails(ctx *jsonrpc.Context, params *jsonrpc.Params) (interface{}, error) {
+	var est module.StepEstimation
+	err := executeForEstimate(ctx, params,
+		func(sm module.ServiceManager, result, vh []byte, bi module.BlockInfo) (module.Receipt, error) {
+			var err error
+			est, err = sm.EstimateTransaction(result, vh, params.RawMessage(), bi)
+			if err != nil {
+				return nil, err
+			}
+			return est.Receipt(), nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	used := est.Receipt().StepUsed()
+	res := &StepEstimationResult{
+		StepsByType: make(map[string]*common.HexInt),
+		AccessList:  []*AccountAccessResult{},
+	}
+	res.Steps.Set(used)
+	for st, steps := range est.StepsByType() {
+		res.StepsByType[st] = common.NewHexInt(0).SetValue(steps)
+	}
+	limit := new(big.Int).Mul(used, big.NewInt(100+StepMarginPercent))
+	limit.Div(limit, big.NewInt(100))
+	res.StepLimit.Set(limit)
+	for _, a := range est.AccessList() {
+		item := &AccountAccessResult{
+			Address: common.AddressToPtr(a.Address),
+			Storage: []common.HexBytes{},
+		}
+		for _, k := range a.Storage {
+			item.Storage = append(item.Storage, k)
+		}
+		res.AccessList = append(res.AccessList, item)
+	}
+	return res, nil
+}
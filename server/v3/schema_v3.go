@@ -1,6 +1,7 @@
 package v3
 
 import (
+	"github.com/icon-project/goloop/common"
 	"github.com/icon-project/goloop/common/intconv"
 	"github.com/icon-project/goloop/server/jsonrpc"
 )
@@ -26,6 +27,7 @@ type CallParam struct {
 	ToAddress   jsonrpc.Address `json:"to" validate:"required,t_addr_score"`
 	DataType    string          `json:"dataType" validate:"required,call"`
 	Data        interface{}     `json:"data"`
+	Overrides   interface{}     `json:"overrides,omitempty"`
 }
 
 type AddressParam struct {
@@ -48,8 +50,22 @@ type TransactionParamForEstimate struct {
 	Timestamp   jsonrpc.HexInt  `json:"timestamp" validate:"required,t_int"`
 	NetworkID   jsonrpc.HexInt  `json:"nid" validate:"required,t_int"`
 	Nonce       jsonrpc.HexInt  `json:"nonce,omitempty" validate:"optional,t_int"`
-	DataType    string          `json:"dataType,omitempty" validate:"optional,call|deploy|message|deposit"`
+	DataType    string          `json:"dataType,omitempty" validate:"optional,call|deploy|message|deposit|batch"`
 	Data        interface{}     `json:"data,omitempty"`
+	Overrides   interface{}     `json:"overrides,omitempty"`
+}
+
+// StepEstimationResult is the result of debug_estimateStepDetails.
+type StepEstimationResult struct {
+	Steps       common.HexInt             `json:"steps"`
+	StepsByType map[string]*common.HexInt `json:"stepsByType"`
+	AccessList  []*AccountAccessResult    `json:"accessList"`
+	StepLimit   common.HexInt             `json:"stepLimit"`
+}
+
+type AccountAccessResult struct {
+	Address *common.Address   `json:"address"`
+	Storage []common.HexBytes `json:"storage"`
 }
 
 type TransactionParam struct {
@@ -62,7 +78,48 @@ type TransactionParam struct {
 	NetworkID   jsonrpc.HexInt  `json:"nid" validate:"required,t_int"`
 	Nonce       jsonrpc.HexInt  `json:"nonce,omitempty" validate:"optional,t_int"`
 	Signature   string          `json:"signature" validate:"required,t_sig"`
-	DataType    string          `json:"dataType,omitempty" validate:"optional,call|deploy|message|deposit"`
+	DataType    string          `json:"dataType,omitempty" validate:"optional,call|deploy|message|deposit|batch"`
+	Data        interface{}     `json:"data,omitempty"`
+}
+
+// AccountTransactionParam is the transaction sent by a contract account.
+// It has authorization data validated by the account instead of signature.
+type AccountTransactionParam struct {
+	Version     jsonrpc.HexInt   `json:"version" validate:"required,t_int"`
+	FromAddress jsonrpc.Address  `json:"from" validate:"required,t_addr_score"`
+	ToAddress   jsonrpc.Address  `json:"to" validate:"required,t_addr"`
+	Value       jsonrpc.HexInt   `json:"value,omitempty" validate:"optional,t_int"`
+	StepLimit   jsonrpc.HexInt   `json:"stepLimit" validate:"required,t_int"`
+	Timestamp   jsonrpc.HexInt   `json:"timestamp" validate:"required,t_int"`
+	NetworkID   jsonrpc.HexInt   `json:"nid" validate:"required,t_int"`
+	Nonce       jsonrpc.HexInt   `json:"nonce,omitempty" validate:"optional,t_int"`
+	AuthData    jsonrpc.HexBytes `json:"authData" validate:"required,t_bytes"`
+	DataType    string           `json:"dataType,omitempty" validate:"optional,call|deploy|message|deposit|batch"`
+	Data        interface{}      `json:"data,omitempty"`
+}
+
+// MultiSigParam is the envelope of signatures for the account controlled
+// by the multi-signature key.
+type MultiSigParam struct {
+	Threshold  jsonrpc.HexInt     `json:"threshold" validate:"required,t_int"`
+	PublicKeys []jsonrpc.HexBytes `json:"publicKeys" validate:"required,dive,t_bytes"`
+	Signatures []string           `json:"signatures" validate:"required,dive,t_sig"`
+}
+
+// MultiSigTransactionParam is the transaction sent by the account controlled
+// by the multi-signature key. It has the envelope of signatures instead of
+// signature.
+type MultiSigTransactionParam struct {
+	Version     jsonrpc.HexInt  `json:"version" validate:"required,t_int"`
+	FromAddress jsonrpc.Address `json:"from" validate:"required,t_addr_eoa"`
+	ToAddress   jsonrpc.Address `json:"to" validate:"required,t_addr"`
+	Value       jsonrpc.HexInt  `json:"value,omitempty" validate:"optional,t_int"`
+	StepLimit   jsonrpc.HexInt  `json:"stepLimit" validate:"required,t_int"`
+	Timestamp   jsonrpc.HexInt  `json:"timestamp" validate:"required,t_int"`
+	NetworkID   jsonrpc.HexInt  `json:"nid" validate:"required,t_int"`
+	Nonce       jsonrpc.HexInt  `json:"nonce,omitempty" validate:"optional,t_int"`
+	MultiSig    MultiSigParam   `json:"multiSig" validate:"required"`
+	DataType    string          `json:"dataType,omitempty" validate:"optional,call|deploy|message|deposit|batch"`
 	Data        interface{}     `json:"data,omitempty"`
 }
 
@@ -12,7 +12,8 @@ import (
 
 var (
 	hexString          = regexp.MustCompile("^0x[0-9a-f]+$")
-	deployContentTypes = []string{"application/zip", "application/java"}
+	addressString      = regexp.MustCompile("^(hx|cx)[0-9a-f]{40}$")
+	deployContentTypes = []string{"application/zip", "application/java", "application/wasm"}
 )
 
 func RegisterValidationRule(v *jsonrpc.Validator) {
@@ -21,9 +22,12 @@ func RegisterValidationRule(v *jsonrpc.Validator) {
 	v.RegisterValidation("deploy", isDeploy)
 	v.RegisterValidation("message", isMessage)
 	v.RegisterValidation("deposit", isDeposit)
+	v.RegisterValidation("batch", isBatch)
 
-	// validate : CallParam.Data, TransactionParam.Data
-	v.RegisterStructValidation(DataParamValidation, CallParam{}, TransactionParam{})
+	// validate : CallParam.Data, TransactionParam.Data, AccountTransactionParam.Data,
+	// MultiSigTransactionParam.Data
+	v.RegisterStructValidation(DataParamValidation, CallParam{}, TransactionParam{},
+		AccountTransactionParam{}, MultiSigTransactionParam{})
 
 }
 
@@ -43,6 +47,10 @@ func isDeposit(fl validator.FieldLevel) bool {
 	return fl.Field().String() == contract.DataTypeDeposit
 }
 
+func isBatch(fl validator.FieldLevel) bool {
+	return fl.Field().String() == contract.DataTypeBatch
+}
+
 func DataParamValidation(sl validator.StructLevel) {
 	switch sl.Current().Interface().(type) {
 	case CallParam:
@@ -54,35 +62,83 @@ func DataParamValidation(sl validator.StructLevel) {
 		}
 	case TransactionParam:
 		txParam := sl.Current().Interface().(TransactionParam)
-		if txParam.DataType != "" {
-			switch txParam.DataType {
-			case contract.DataTypeCall:
-				if data, ok := txParam.Data.(map[string]interface{}); ok {
-					validateCallDataParam(sl, txParam.Data, data)
-				} else {
-					sl.ReportError(txParam.Data, "Data", "", "data", "")
-				}
-			case contract.DataTypeDeploy:
-				if data, ok := txParam.Data.(map[string]interface{}); ok {
-					validateDeployDataParam(sl, txParam.Data, data)
-				} else {
-					sl.ReportError(txParam.Data, "Data", "", "data", "")
-				}
-			case contract.DataTypeMessage:
-				if data, ok := txParam.Data.(string); ok {
-					if !hexString.MatchString(data) {
-						sl.ReportError(txParam.Data, "Data", "", "data", "")
-					}
-				} else {
-					sl.ReportError(txParam.Data, "Data", "", "data", "")
-				}
-			case contract.DataTypeDeposit:
-				if data, ok := txParam.Data.(map[string]interface{}); ok {
-					validateDepositDataParam(sl, txParam.Data, data)
-				} else {
-					sl.ReportError(txParam.Data, "Data", "", "data", "")
-				}
+		validateTxDataParam(sl, txParam.DataType, txParam.Data)
+	case AccountTransactionParam:
+		txParam := sl.Current().Interface().(AccountTransactionParam)
+		validateTxDataParam(sl, txParam.DataType, txParam.Data)
+	case MultiSigTransactionParam:
+		txParam := sl.Current().Interface().(MultiSigTransactionParam)
+		validateTxDataParam(sl, txParam.DataType, txParam.Data)
+	}
+}
+
+func validateTxDataParam(sl validator.StructLevel, dataType string, field interface{}) {
+	switch dataType {
+	case contract.DataTypeCall:
+		if data, ok := field.(map[string]interface{}); ok {
+			validateCallDataParam(sl, field, data)
+		} else {
+			sl.ReportError(field, "Data", "", "data", "")
+		}
+	case contract.DataTypeDeploy:
+		if data, ok := field.(map[string]interface{}); ok {
+			validateDeployDataParam(sl, field, data)
+		} else {
+			sl.ReportError(field, "Data", "", "data", "")
+		}
+	case contract.DataTypeMessage:
+		if data, ok := field.(string); ok {
+			if !hexString.MatchString(data) {
+				sl.ReportError(field, "Data", "", "data", "")
+			}
+		} else {
+			sl.ReportError(field, "Data", "", "data", "")
+		}
+	case contract.DataTypeDeposit:
+		if data, ok := field.(map[string]interface{}); ok {
+			validateDepositDataParam(sl, field, data)
+		} else {
+			sl.ReportError(field, "Data", "", "data", "")
+		}
+	case contract.DataTypeBatch:
+		if data, ok := field.([]interface{}); ok && len(data) > 0 {
+			validateBatchDataParam(sl, field, data)
+		} else {
+			sl.ReportError(field, "Data", "", "data", "")
+		}
+	}
+}
+
+func validateBatchDataParam(sl validator.StructLevel, field interface{}, data []interface{}) {
+	for i, v := range data {
+		item, ok := v.(map[string]interface{})
+		if !ok {
+			sl.ReportError(field, "Data", "", fmt.Sprintf("data[%d]", i), "")
+			continue
+		}
+		// data[i].to : required
+		if to, ok := item["to"].(string); !ok || !addressString.MatchString(to) {
+			sl.ReportError(field, "Data", "", fmt.Sprintf("data[%d].to", i), "")
+		}
+		// data[i].value : optional
+		if value, ok := item["value"]; ok && !isHexString(value) {
+			sl.ReportError(field, "Data", "", fmt.Sprintf("data[%d].value", i), "")
+		}
+		// data[i].dataType : optional, call or message
+		switch item["dataType"] {
+		case nil:
+		case contract.DataTypeCall:
+			if call, ok := item["data"].(map[string]interface{}); ok {
+				validateCallDataParam(sl, field, call)
+			} else {
+				sl.ReportError(field, "Data", "", fmt.Sprintf("data[%d].data", i), "")
+			}
+		case contract.DataTypeMessage:
+			if !isHexString(item["data"]) {
+				sl.ReportError(field, "Data", "", fmt.Sprintf("data[%d].data", i), "")
 			}
+		default:
+			sl.ReportError(field, "Data", "", fmt.Sprintf("data[%d].dataType", i), "")
 		}
 	}
 }
@@ -0,0 +1,164 @@
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/scoreapi"
+	"github.com/icon-project/goloop/service/scoreresult"
+	"github.com/icon-project/goloop/service/state"
+)
+
+const (
+	// BatchMaxItems is the maximum number of sub operations in a batch
+	BatchMaxItems = 100
+)
+
+// BatchItemJSON is a sub operation of the batch. It's a transfer or a
+// call like a transaction with the same fields.
+type BatchItemJSON struct {
+	To       common.Address  `json:"to"`
+	Value    *common.HexInt  `json:"value,omitempty"`
+	DataType *string         `json:"dataType,omitempty"`
+	Data     json.RawMessage `json:"data,omitempty"`
+}
+
+// ParseBatchData parses data of the transaction with DataTypeBatch, which
+// is the list of sub operations.
+func ParseBatchData(data []byte) ([]*BatchItemJSON, error) {
+	var items []*BatchItemJSON
+	jd := json.NewDecoder(bytes.NewBuffer(data))
+	jd.DisallowUnknownFields()
+	if err := jd.Decode(&items); err != nil {
+		return nil, scoreresult.InvalidParameterError.Wrap(err, "InvalidBatchData")
+	}
+	if len(items) == 0 || len(items) > BatchMaxItems {
+		return nil, scoreresult.InvalidParameterError.Errorf(
+			"InvalidBatchSize(size=%d)", len(items))
+	}
+	for i, item := range items {
+		if item == nil {
+			return nil, scoreresult.InvalidParameterError.Errorf("EmptyBatchItem(index=%d)", i)
+		}
+		if item.Value != nil && item.Value.Sign() < 0 {
+			return nil, scoreresult.InvalidParameterError.Errorf(
+				"InvalidValue(index=%d,value=%s)", i, item.Value)
+		}
+		if item.DataType == nil {
+			continue
+		}
+		switch *item.DataType {
+		case DataTypeMessage:
+		case DataTypeCall:
+			if _, err := ParseCallData(item.Data); err != nil {
+				return nil, scoreresult.InvalidParameterError.Wrapf(err,
+					"InvalidCallData(index=%d)", i)
+			}
+		default:
+			return nil, scoreresult.InvalidParameterError.Errorf(
+				"IllegalDataType(index=%d,type=%s)", i, *item.DataType)
+		}
+	}
+	return items, nil
+}
+
+// BatchItemResult is the result of a sub operation of the batch.
+type BatchItemResult struct {
+	Status   module.Status
+	StepUsed *big.Int
+}
+
+// BatchHandler executes sub operations in order inside its frame. Each
+// operation is executed in its own frame as an inter-call, and if one of
+// them fails, then the batch fails reverting all of them.
+type BatchHandler struct {
+	*CommonHandler
+	items   []*BatchItemJSON
+	results []BatchItemResult
+}
+
+func newBatchHandler(ch *CommonHandler, data []byte) (*BatchHandler, error) {
+	items, err := ParseBatchData(data)
+	if err != nil {
+		return nil, err
+	}
+	return &BatchHandler{CommonHandler: ch, items: items}, nil
+}
+
+func (h *BatchHandler) Prepare(ctx Context) (state.WorldContext, error) {
+	lq := []state.LockRequest{
+		{state.WorldIDStr, state.AccountWriteLock},
+	}
+	return ctx.GetFuture(lq), nil
+}
+
+func (h *BatchHandler) itemHandler(item *BatchItemJSON) (ContractHandler, error) {
+	value := new(big.Int)
+	if item.Value != nil {
+		value = &item.Value.Int
+	}
+	ch := NewCommonHandler(h.From, &item.To, value, true, h.Log)
+	if item.DataType != nil && *item.DataType == DataTypeCall {
+		call, err := newCallHandlerWithData(ch, item.Data)
+		if err != nil {
+			return nil, err
+		}
+		if value.Sign() == 1 {
+			return newTransferAndCallHandler(ch, call), nil
+		}
+		return call, nil
+	}
+	if item.To.IsContract() {
+		call := newCallHandlerWithParams(ch, scoreapi.FallbackMethodName, nil, false)
+		return newTransferAndCallHandler(ch, call), nil
+	}
+	return newTransferHandler(ch), nil
+}
+
+func (h *BatchHandler) ExecuteSync(cc CallContext) (err error, ro *codec.TypedObj, addr module.Address) {
+	h.Log.TSystemf("FRAME[%d] BATCH start from=%s items=%d", h.FID, h.From, len(h.items))
+	defer func() {
+		if err != nil {
+			h.Log.TSystemf("FRAME[%d] BATCH done status=%s msg=%v", h.FID, err.Error(), err)
+		}
+	}()
+
+	if !cc.Revision().UseBatchTransaction() {
+		return scoreresult.InvalidParameterError.Errorf(
+			"UnsupportedDataType(type=%s,rev=%d)", DataTypeBatch, cc.Revision().Value()), nil, nil
+	}
+	if !h.To.Equal(h.From) {
+		return scoreresult.InvalidParameterError.Errorf(
+			"InvalidBatchTarget(to=%s)", h.To), nil, nil
+	}
+	if h.Value != nil && h.Value.Sign() != 0 {
+		return scoreresult.InvalidParameterError.Errorf(
+			"InvalidBatchValue(value=%s)", h.Value), nil, nil
+	}
+	h.results = make([]BatchItemResult, 0, len(h.items))
+	for i, item := range h.items {
+		handler, err := h.itemHandler(item)
+		if err != nil {
+			return err, nil, nil
+		}
+		status, used, _, _ := cc.Call(handler, cc.StepAvailable())
+		cc.DeductSteps(used)
+		s, _ := scoreresult.StatusOf(status)
+		h.results = append(h.results, BatchItemResult{Status: s, StepUsed: used})
+		if status != nil {
+			return errors.Wrapf(status, "BatchFailed(index=%d)", i), nil, nil
+		}
+	}
+	return nil, nil, nil
+}
+
+// Results returns results of sub operations executed. On failure, the
+// last one is the result of the failed operation.
+func (h *BatchHandler) Results() []BatchItemResult {
+	return h.results
+}
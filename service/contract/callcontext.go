@@ -95,7 +95,8 @@ type callContext struct {
 	ioStart *time.Time
 	ioTime  time.Duration
 
-	payers *stepPayers
+	payers  *stepPayers
+	tracker *StepTracker
 
 	log *trace.Logger
 }
@@ -118,8 +119,9 @@ func NewCallContext(ctx Context, limit *big.Int, isQuery bool) CallContext {
 		nextFID: firstFID,
 		frame:   NewFrame(nil, nil, limit, isQuery),
 
-		waiter: make(chan interface{}, 8),
-		log:    logger,
+		waiter:  make(chan interface{}, 8),
+		tracker: StepTrackerOf(ctx),
+		log:     logger,
 	}
 }
 
@@ -491,6 +493,9 @@ func (cc *callContext) ApplySteps(t state.StepType, n int) bool {
 func (cc *callContext) applyStepsInLock(t state.StepType, n int) bool {
 	steps := big.NewInt(cc.StepsFor(t, n))
 	ok := cc.frame.deductSteps(steps)
+	if cc.tracker != nil {
+		cc.tracker.Add(t, steps)
+	}
 	cc.log.TSystemf("FRAME[%d] STEP apply type=%s count=%d cost=%s total=%s", cc.frame.fid, t, n, steps, &cc.frame.stepUsed)
 	return ok
 }
@@ -429,6 +429,9 @@ func (h *CallHandler) GetValue(key []byte) ([]byte, error) {
 			h.Log.TSystemf("FRAME[%d] GETVALUE key=<%x> err=%+v", h.FID, key, err)
 		} else {
 			h.Log.TSystemf("FRAME[%d] GETVALUE key=<%x> value=<%x>", h.FID, key, value)
+			if t := StepTrackerOf(h.cc); t != nil {
+				t.onGet(h.cc, value)
+			}
 		}
 		return value, err
 	} else {
@@ -452,6 +455,9 @@ func (h *CallHandler) SetValue(key []byte, value []byte) ([]byte, error) {
 			h.Log.TSystemf("FRAME[%d] SETVALUE key=<%x> value=<%x> err=%+v", h.FID, key, value, err)
 		} else {
 			h.Log.TSystemf("FRAME[%d] SETVALUE key=<%x> value=<%x> old=<%x>", h.FID, key, value, old)
+			if t := StepTrackerOf(h.cc); t != nil {
+				t.onSet(h.cc, value, old)
+			}
 		}
 		return old, err
 	} else {
@@ -475,6 +481,9 @@ func (h *CallHandler) DeleteValue(key []byte) ([]byte, error) {
 			h.Log.TSystemf("FRAME[%d] DELETE key=<%x> err=%+v", h.FID, key, err)
 		} else {
 			h.Log.TSystemf("FRAME[%d] DELETE key=<%x> old=<%x>", h.FID, key, old)
+			if t := StepTrackerOf(h.cc); t != nil {
+				t.onDelete(h.cc, old)
+			}
 		}
 		return old, err
 	} else {
@@ -552,6 +561,9 @@ func (h *CallHandler) OnEvent(addr module.Address, indexed, data [][]byte) error
 		return nil
 	}
 	h.cc.OnEvent(addr, indexed, data)
+	if t := StepTrackerOf(h.cc); t != nil {
+		t.onEvent(h.cc, indexed, data)
+	}
 	return nil
 }
 
@@ -21,6 +21,7 @@ const (
 	CTypeCall
 	CTypePatch
 	CTypeDeposit
+	CTypeBatch
 )
 
 type (
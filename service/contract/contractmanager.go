@@ -15,6 +15,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/icon-project/goloop/service/eeproxy"
 	"github.com/icon-project/goloop/service/scoreapi"
 	"github.com/icon-project/goloop/service/scoreresult"
 
@@ -74,6 +75,7 @@ const (
 	DataTypeDeploy  = "deploy"
 	DataTypeDeposit = "deposit"
 	DataTypePatch   = "patch"
+	DataTypeBatch   = "batch"
 )
 
 func IsCallableDataType(dt *string) bool {
@@ -188,6 +190,8 @@ func (cm *contractManager) GetHandler(from, to module.Address, value *big.Int, c
 		return newPatchHandler(ch, data)
 	case CTypeDeposit:
 		return newDepositHandler(ch, data)
+	case CTypeBatch:
+		return newBatchHandler(ch, data)
 	}
 	return handler, nil
 }
@@ -335,6 +339,7 @@ func NewContractManager(db db.Database, contractDir string, log log.Logger) (Con
 
 const (
 	javaCode               = "code.jar"
+	wasmCode               = eeproxy.WasmCodeFile
 	tmpRoot                = "tmp"
 	tmpPattern             = "tmp-*"
 	contractPythonRootFile = "package.json"
@@ -420,6 +425,20 @@ func storeJava(path string, code []byte, log log.Logger) error {
 	return nil
 }
 
+func storeWasm(path string, code []byte) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err = os.MkdirAll(path, 0755); err != nil {
+			return errors.WithCode(err, errors.CriticalIOError)
+		}
+	}
+	sPath := filepath.Join(path, wasmCode)
+	if err := ioutil.WriteFile(sPath, code, 0644); err != nil {
+		_ = os.RemoveAll(sPath)
+		return errors.WithCode(err, errors.CriticalIOError)
+	}
+	return nil
+}
+
 func storeByEEType(e state.EEType, path string, code []byte, log log.Logger) error {
 	var err error
 	switch e {
@@ -427,6 +446,8 @@ func storeByEEType(e state.EEType, path string, code []byte, log log.Logger) err
 		err = storePython(path, code, log)
 	case state.JavaEE:
 		err = storeJava(path, code, log)
+	case state.WasmEE:
+		err = storeWasm(path, code)
 	default:
 		err = scoreresult.Errorf(module.StatusInvalidParameter,
 			"UnexpectedEEType(%v)\n", e)
@@ -321,6 +321,11 @@ func (h *DeployHandler) DoExecuteSync(cc CallContext) (error, *codec.TypedObj, m
 			h.contentType), nil, nil
 	}
 
+	if h.eeType == state.WasmEE && !cc.Revision().UseWasmEE() {
+		return scoreresult.InvalidParameterError.Errorf("UnsupportedContentType(ct=%s,rev=%d)",
+			h.contentType, cc.Revision().Value()), nil, nil
+	}
+
 	if !cc.GetEnabledEETypes().Contains(h.eeType) {
 		return scoreresult.InvalidParameterError.Errorf("UnsupportedContentType(ct=%s,enabled=%s)",
 			h.contentType, cc.GetEnabledEETypes().String()), nil, nil
@@ -345,6 +350,21 @@ func (h *DeployHandler) DoExecuteSync(cc CallContext) (error, *codec.TypedObj, m
 		}
 	}
 	scoreAddr := common.NewContractAddress(contractID)
+	if h.preDefinedAddr == nil {
+		dc := &DeployContent{
+			From:        h.From,
+			Address:     scoreAddr,
+			Update:      update,
+			EEType:      h.eeType,
+			ContentType: h.contentType,
+			Code:        h.content.GetBytes(),
+		}
+		if name, err := validateDeploy(cc, dc); err != nil {
+			h.Log.TSystemf("FRAME[%d] DEPLOY rejected validator=%s err=%v", h.FID, name, err)
+			return err, nil, nil
+		}
+	}
+
 	deployID := getIDWithSalt(txInfo.Hash, salt)
 	h2a := scoredb.NewDictDB(sysAs, state.VarTxHashToAddress, 1)
 	for h2a.Get(deployID) != nil {
@@ -424,6 +444,11 @@ func (h *AcceptHandler) ExecuteSync(cc CallContext) (err error, obj *codec.Typed
 		return scoreresult.ContractNotFoundError.New("NoContractToAccept"), nil, nil
 	}
 
+	code, err := next.Code()
+	if err != nil {
+		return err, nil, nil
+	}
+
 	var methodStr string
 	nextEEType := next.EEType()
 	current := scoreAs.Contract()
@@ -477,6 +502,9 @@ func (h *AcceptHandler) ExecuteSync(cc CallContext) (err error, obj *codec.Typed
 	if err = scoreAs.AcceptContract(h.txHash, h.auditTxHash); err != nil {
 		return err, nil, nil
 	}
+	if err = acceptContent(cc, code, scoreAddr); err != nil {
+		return err, nil, nil
+	}
 	return nil, nil, nil
 }
 
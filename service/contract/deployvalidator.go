@@ -0,0 +1,622 @@
+package contract
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/icon-project/goloop/common/containerdb"
+	"github.com/icon-project/goloop/common/crypto"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/eeproxy"
+	"github.com/icon-project/goloop/service/scoredb"
+	"github.com/icon-project/goloop/service/scoreresult"
+	"github.com/icon-project/goloop/service/state"
+	"github.com/icon-project/goloop/service/wasm"
+)
+
+const (
+	DeployValidatorCodeSize     = "codeSize"
+	DeployValidatorForbiddenAPI = "forbiddenAPI"
+	DeployValidatorEntryPoint   = "entryPoint"
+	DeployValidatorDuplicate    = "duplicate"
+)
+
+// maxInflatedContent limits the size of files extracted from the content
+// for the validation.
+const maxInflatedContent = 64 * 1024 * 1024
+
+// DeployContent is the content to be deployed, which is given to
+// DeployValidator.
+type DeployContent struct {
+	From        module.Address
+	Address     module.Address
+	Update      bool
+	EEType      state.EEType
+	ContentType string
+	Code        []byte
+
+	files []*ContentFile
+	err   error
+}
+
+type ContentFile struct {
+	Name string
+	Data []byte
+}
+
+// Files returns files in the content in the order of the archive if it's
+// an archive (python and java).
+func (dc *DeployContent) Files() ([]*ContentFile, error) {
+	if dc.files != nil || dc.err != nil {
+		return dc.files, dc.err
+	}
+	dc.files, dc.err = inflateContent(dc.Code)
+	return dc.files, dc.err
+}
+
+func inflateContent(code []byte) ([]*ContentFile, error) {
+	zr, err := zip.NewReader(bytes.NewReader(code), int64(len(code)))
+	if err != nil {
+		return nil, scoreresult.IllegalFormatError.Wrap(err, "InvalidArchive")
+	}
+	files := make([]*ContentFile, 0, len(zr.File))
+	remain := int64(maxInflatedContent)
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			return nil, scoreresult.IllegalFormatError.Errorf("FailToOpen(f=%s)", f.Name)
+		}
+		bs, err := ioutil.ReadAll(io.LimitReader(r, remain+1))
+		r.Close()
+		if err != nil {
+			return nil, scoreresult.IllegalFormatError.Errorf("FailToRead(f=%s)", f.Name)
+		}
+		remain -= int64(len(bs))
+		if remain < 0 {
+			return nil, scoreresult.IllegalFormatError.New("ContentTooLarge")
+		}
+		files = append(files, &ContentFile{f.Name, bs})
+	}
+	return files, nil
+}
+
+// DeployValidator checks the content before it's deployed. The returned
+// error is the reason of the rejection, and it's recorded in the receipt.
+type DeployValidator interface {
+	Validate(cc CallContext, dc *DeployContent) error
+}
+
+type DeployValidatorFunc func(cc CallContext, dc *DeployContent) error
+
+func (f DeployValidatorFunc) Validate(cc CallContext, dc *DeployContent) error {
+	return f(cc, dc)
+}
+
+type namedDeployValidator struct {
+	name string
+	DeployValidator
+}
+
+var deployValidators struct {
+	lock sync.Mutex
+	list []namedDeployValidator
+}
+
+// RegisterDeployValidator registers the validator with the name. Registered
+// validators are applied in the order of registration if the governance
+// enables them.
+func RegisterDeployValidator(name string, v DeployValidator) {
+	deployValidators.lock.Lock()
+	defer deployValidators.lock.Unlock()
+
+	for i, nv := range deployValidators.list {
+		if nv.name == name {
+			deployValidators.list[i].DeployValidator = v
+			return
+		}
+	}
+	deployValidators.list = append(deployValidators.list, namedDeployValidator{name, v})
+}
+
+// IsDeployValidator returns whether the validator is registered.
+func IsDeployValidator(name string) bool {
+	deployValidators.lock.Lock()
+	defer deployValidators.lock.Unlock()
+
+	for _, nv := range deployValidators.list {
+		if nv.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func enabledDeployValidators(cc CallContext) []namedDeployValidator {
+	as := cc.GetAccountState(state.SystemID)
+	db := scoredb.NewArrayDB(as, state.VarDeployValidators)
+	size := db.Size()
+	if size == 0 {
+		return nil
+	}
+	enabled := make(map[string]bool, size)
+	for i := 0; i < size; i++ {
+		enabled[db.Get(i).String()] = true
+	}
+
+	deployValidators.lock.Lock()
+	defer deployValidators.lock.Unlock()
+	var vs []namedDeployValidator
+	for _, nv := range deployValidators.list {
+		if enabled[nv.name] {
+			vs = append(vs, nv)
+		}
+	}
+	return vs
+}
+
+func isDeployValidatorEnabled(cc CallContext, name string) bool {
+	as := cc.GetAccountState(state.SystemID)
+	db := scoredb.NewArrayDB(as, state.VarDeployValidators)
+	for i := 0; i < db.Size(); i++ {
+		if db.Get(i).String() == name {
+			return true
+		}
+	}
+	return false
+}
+
+func addStringToArrayDB(db *containerdb.ArrayDB, value string) error {
+	for i := 0; i < db.Size(); i++ {
+		if db.Get(i).String() == value {
+			return nil
+		}
+	}
+	return db.Put(value)
+}
+
+func removeStringFromArrayDB(db *containerdb.ArrayDB, value string) error {
+	for i := 0; i < db.Size(); i++ {
+		if db.Get(i).String() == value {
+			last := db.Pop().String()
+			if i < db.Size() { // last is not value
+				return db.Set(i, last)
+			}
+			break
+		}
+	}
+	return nil
+}
+
+func getStringsFromArrayDB(db *containerdb.ArrayDB) []interface{} {
+	values := make([]interface{}, db.Size())
+	for i := 0; i < db.Size(); i++ {
+		values[i] = db.Get(i).String()
+	}
+	return values
+}
+
+// SetDeployValidatorEnabled enables or disables the validator. It's used
+// by chain SCOREs of platforms, which check the name and the permission.
+func SetDeployValidatorEnabled(as state.AccountState, name string, yn bool) error {
+	db := scoredb.NewArrayDB(as, state.VarDeployValidators)
+	if yn {
+		return addStringToArrayDB(db, name)
+	}
+	return removeStringFromArrayDB(db, name)
+}
+
+func GetDeployValidators(as state.AccountState) []interface{} {
+	return getStringsFromArrayDB(scoredb.NewArrayDB(as, state.VarDeployValidators))
+}
+
+// SetMaxCodeSize sets the limit of the code size for the EE type. Zero
+// size removes the limit.
+func SetMaxCodeSize(as state.AccountState, eeType string, size *big.Int) error {
+	db := scoredb.NewDictDB(as, state.VarMaxCodeSizes, 1)
+	if size.Sign() == 0 {
+		return db.Delete(eeType)
+	}
+	return db.Set(eeType, size)
+}
+
+// GetMaxCodeSize returns the limit of the code size for the EE type. It
+// returns zero if there is no limit.
+func GetMaxCodeSize(as state.AccountState, eeType string) int64 {
+	if value := scoredb.NewDictDB(as, state.VarMaxCodeSizes, 1).Get(eeType); value != nil {
+		return value.Int64()
+	}
+	return 0
+}
+
+func AddForbiddenAPI(as state.AccountState, api string) error {
+	return addStringToArrayDB(scoredb.NewArrayDB(as, state.VarForbiddenAPIs), api)
+}
+
+func RemoveForbiddenAPI(as state.AccountState, api string) error {
+	return removeStringFromArrayDB(scoredb.NewArrayDB(as, state.VarForbiddenAPIs), api)
+}
+
+func GetForbiddenAPIs(as state.AccountState) []interface{} {
+	return getStringsFromArrayDB(scoredb.NewArrayDB(as, state.VarForbiddenAPIs))
+}
+
+// validateDeploy applies validators enabled by the governance. It returns
+// the name of the validator rejecting the content with the reason.
+func validateDeploy(cc CallContext, dc *DeployContent) (string, error) {
+	for _, nv := range enabledDeployValidators(cc) {
+		if err := nv.Validate(cc, dc); err != nil {
+			return nv.name, err
+		}
+	}
+	return "", nil
+}
+
+// validateCodeSize checks the size of the code with the limit for the
+// EE type. There is no limit for the EE type without configuration.
+func validateCodeSize(cc CallContext, dc *DeployContent) error {
+	as := cc.GetAccountState(state.SystemID)
+	if max := GetMaxCodeSize(as, string(dc.EEType)); max > 0 && int64(len(dc.Code)) > max {
+		return scoreresult.InvalidParameterError.Errorf(
+			"CodeSizeExceeded(ee=%s,size=%d,max=%d)", dc.EEType, len(dc.Code), max)
+	}
+	return nil
+}
+
+// javaReferences returns names of classes and members referred by the
+// class file. Names are in the internal form of java (ex. java/lang/Thread),
+// and members are appended to the class with a slash
+// (ex. java/lang/System/exit).
+func javaReferences(data []byte) (map[string]bool, error) {
+	type cpEntry struct {
+		tag  byte
+		utf8 string
+		a, b uint16
+	}
+	r := bytes.NewReader(data)
+	var header struct {
+		Magic        uint32
+		Minor, Major uint16
+		Count        uint16
+	}
+	if err := binary.Read(r, binary.BigEndian, &header); err != nil || header.Magic != 0xcafebabe {
+		return nil, scoreresult.IllegalFormatError.New("InvalidClassFile")
+	}
+	pool := make([]cpEntry, header.Count)
+	for i := 1; i < len(pool); i++ {
+		e := &pool[i]
+		var err error
+		if e.tag, err = r.ReadByte(); err != nil {
+			return nil, scoreresult.IllegalFormatError.New("InvalidConstantPool")
+		}
+		switch e.tag {
+		case 1: // Utf8
+			var n uint16
+			if err = binary.Read(r, binary.BigEndian, &n); err == nil {
+				bs := make([]byte, n)
+				_, err = io.ReadFull(r, bs)
+				e.utf8 = string(bs)
+			}
+		case 7, 8, 16, 19, 20: // Class, String, MethodType, Module, Package
+			err = binary.Read(r, binary.BigEndian, &e.a)
+		case 3, 4: // Integer, Float
+			_, err = r.Seek(4, io.SeekCurrent)
+		case 5, 6: // Long, Double take two entries
+			_, err = r.Seek(8, io.SeekCurrent)
+			i++
+		case 9, 10, 11, 12, 17, 18: // Fieldref, Methodref, InterfaceMethodref, NameAndType, Dynamic, InvokeDynamic
+			if err = binary.Read(r, binary.BigEndian, &e.a); err == nil {
+				err = binary.Read(r, binary.BigEndian, &e.b)
+			}
+		case 15: // MethodHandle
+			_, err = r.Seek(3, io.SeekCurrent)
+		default:
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, scoreresult.IllegalFormatError.Errorf("InvalidConstantPool(idx=%d,tag=%d)", i, e.tag)
+		}
+	}
+	entry := func(idx uint16, tag byte) *cpEntry {
+		if int(idx) < len(pool) && pool[idx].tag == tag {
+			return &pool[idx]
+		}
+		return nil
+	}
+	className := func(idx uint16) string {
+		c := entry(idx, 7)
+		if c == nil {
+			return ""
+		}
+		name := ""
+		if u := entry(c.a, 1); u != nil {
+			name = strings.TrimLeft(u.utf8, "[")
+		}
+		if strings.HasPrefix(name, "L") && strings.HasSuffix(name, ";") {
+			name = name[1 : len(name)-1]
+		}
+		return name
+	}
+	refs := make(map[string]bool)
+	for idx, e := range pool {
+		switch e.tag {
+		case 7:
+			if name := className(uint16(idx)); len(name) > 0 {
+				refs[name] = true
+			}
+		case 9, 10, 11:
+			cls := className(e.a)
+			if nt := entry(e.b, 12); len(cls) > 0 && nt != nil {
+				if u := entry(nt.a, 1); u != nil {
+					refs[cls+"/"+u.utf8] = true
+				}
+			}
+		}
+	}
+	return refs, nil
+}
+
+var (
+	pythonNamePattern       = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*`)
+	pythonFromImportPattern = regexp.MustCompile(`(?m)^[ \t]*from[ \t]+([A-Za-z_][A-Za-z0-9_.]*)[ \t]+import[ \t]+\(?([A-Za-z0-9_, \t\r\n]+)`)
+)
+
+// pythonReferences returns dotted names in the source. Names imported with
+// "from" statements are also returned with the module (ex. os.system for
+// "from os import system").
+func pythonReferences(data []byte) map[string]bool {
+	refs := make(map[string]bool)
+	for _, name := range pythonNamePattern.FindAll(data, -1) {
+		refs[string(name)] = true
+	}
+	for _, m := range pythonFromImportPattern.FindAllSubmatch(data, -1) {
+		for _, item := range strings.Split(string(m[2]), ",") {
+			if fields := strings.Fields(item); len(fields) > 0 {
+				refs[string(m[1])+"."+fields[0]] = true
+			}
+		}
+	}
+	return refs
+}
+
+// referred returns true if the name or any name under it (ex. members of
+// the class) is in the references.
+func referred(refs map[string]bool, name, sep string) bool {
+	if refs[name] {
+		return true
+	}
+	prefix := name + sep
+	for ref := range refs {
+		if strings.HasPrefix(ref, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateForbiddenAPI rejects the content referring any of forbidden APIs.
+// A name matches the API if it's same as the API or it's under the API
+// (ex. java.lang.Thread matches java.lang.Thread.start, but it doesn't
+// match java.lang.ThreadLocal). Names for java may be given either with
+// dots or slashes (ex. java.lang.Thread), and they are matched with classes
+// and members referred by class files. Names for python are matched with
+// dotted names in python files. Names for wasm are imported functions in
+// "module.name" form.
+func validateForbiddenAPI(cc CallContext, dc *DeployContent) error {
+	as := cc.GetAccountState(state.SystemID)
+	db := scoredb.NewArrayDB(as, state.VarForbiddenAPIs)
+	size := db.Size()
+	if size == 0 {
+		return nil
+	}
+	apis := make([]string, size)
+	for i := 0; i < size; i++ {
+		apis[i] = db.Get(i).String()
+	}
+
+	switch dc.EEType {
+	case state.PythonEE, state.JavaEE:
+		files, err := dc.Files()
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			var refs map[string]bool
+			sep := "."
+			if dc.EEType == state.JavaEE {
+				if !strings.HasSuffix(f.Name, ".class") {
+					continue
+				}
+				if refs, err = javaReferences(f.Data); err != nil {
+					return err
+				}
+				sep = "/"
+			} else {
+				if !strings.HasSuffix(f.Name, ".py") {
+					continue
+				}
+				refs = pythonReferences(f.Data)
+			}
+			for _, api := range apis {
+				if dc.EEType == state.JavaEE {
+					api = strings.ReplaceAll(api, ".", "/")
+				}
+				if referred(refs, api, sep) {
+					return scoreresult.AccessDeniedError.Errorf(
+						"ForbiddenAPI(api=%s,file=%s)", api, f.Name)
+				}
+			}
+		}
+	case state.WasmEE:
+		m, err := wasm.Compile(dc.Code)
+		if err != nil {
+			return err
+		}
+		imports := make(map[string]bool)
+		for _, im := range m.Imports() {
+			imports[im] = true
+		}
+		for _, api := range apis {
+			if imports[api] {
+				return scoreresult.AccessDeniedError.Errorf("ForbiddenAPI(api=%s)", api)
+			}
+		}
+	}
+	return nil
+}
+
+type pythonPackage struct {
+	MainModule string `json:"main_module"`
+	MainFile   string `json:"main_file"`
+	MainScore  string `json:"main_score"`
+}
+
+func findContentFile(files []*ContentFile, name string) *ContentFile {
+	for _, f := range files {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// validatePythonEntryPoint checks the package file and the main module.
+// The package file is found in the same way as storePython.
+func validatePythonEntryPoint(files []*ContentFile) error {
+	var pkgFile *ContentFile
+	for _, f := range files {
+		if path.Base(f.Name) == contractPythonRootFile {
+			pkgFile = f
+			break
+		}
+	}
+	if pkgFile == nil {
+		return scoreresult.IllegalFormatError.New("NoPackageFile")
+	}
+	var pkg pythonPackage
+	if err := json.Unmarshal(pkgFile.Data, &pkg); err != nil {
+		return scoreresult.IllegalFormatError.Wrap(err, "InvalidPackageFile")
+	}
+	if len(pkg.MainScore) == 0 {
+		return scoreresult.IllegalFormatError.New("NoMainScore")
+	}
+	main := pkg.MainModule
+	if len(main) == 0 {
+		main = pkg.MainFile
+	}
+	if len(main) == 0 {
+		return scoreresult.IllegalFormatError.New("NoMainModule")
+	}
+	mainFile := path.Join(path.Dir(pkgFile.Name), strings.ReplaceAll(main, ".", "/")+".py")
+	if findContentFile(files, mainFile) == nil {
+		return scoreresult.IllegalFormatError.Errorf("NoMainModuleFile(file=%s)", mainFile)
+	}
+	return nil
+}
+
+const javaManifest = "META-INF/MANIFEST.MF"
+
+func validateJavaEntryPoint(files []*ContentFile) error {
+	mf := findContentFile(files, javaManifest)
+	if mf == nil {
+		return scoreresult.IllegalFormatError.New("NoManifest")
+	}
+	var mainClass string
+	s := bufio.NewScanner(bytes.NewReader(mf.Data))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if strings.HasPrefix(line, "Main-Class:") {
+			mainClass = strings.TrimSpace(strings.TrimPrefix(line, "Main-Class:"))
+			break
+		}
+	}
+	if len(mainClass) == 0 {
+		return scoreresult.IllegalFormatError.New("NoMainClass")
+	}
+	classFile := strings.ReplaceAll(mainClass, ".", "/") + ".class"
+	if findContentFile(files, classFile) == nil {
+		return scoreresult.IllegalFormatError.Errorf("NoMainClassFile(file=%s)", classFile)
+	}
+	return nil
+}
+
+// validateEntryPoint checks whether the content has the entry point which
+// is required by the EE.
+func validateEntryPoint(cc CallContext, dc *DeployContent) error {
+	switch dc.EEType {
+	case state.PythonEE:
+		files, err := dc.Files()
+		if err != nil {
+			return err
+		}
+		return validatePythonEntryPoint(files)
+	case state.JavaEE:
+		files, err := dc.Files()
+		if err != nil {
+			return err
+		}
+		return validateJavaEntryPoint(files)
+	case state.WasmEE:
+		m, err := wasm.Compile(dc.Code)
+		if err != nil {
+			return err
+		}
+		if _, ok := m.CustomSection(eeproxy.WasmABISection); !ok {
+			return scoreresult.IllegalFormatError.Errorf("NoABISection(name=%s)", eeproxy.WasmABISection)
+		}
+	}
+	return nil
+}
+
+func checkDuplicate(cc CallContext, hash []byte, addr module.Address) error {
+	as := cc.GetAccountState(state.SystemID)
+	value := scoredb.NewDictDB(as, state.VarContentHashes, 1).Get(hash)
+	if value != nil {
+		if owner := value.Address(); !owner.Equal(addr) {
+			return scoreresult.InvalidParameterError.Errorf(
+				"DuplicateContent(hash=%#x,score=%s)", hash, owner)
+		}
+	}
+	return nil
+}
+
+// validateDuplicate rejects the content deployed to another contract
+// already. The content may be deployed again to the same contract.
+func validateDuplicate(cc CallContext, dc *DeployContent) error {
+	return checkDuplicate(cc, crypto.SHA3Sum256(dc.Code), dc.Address)
+}
+
+// acceptContent records the hash of the content of the contract accepted,
+// so that it's rejected by validateDuplicate for other contracts. Contents
+// of pending contracts are not recorded, and one accepted first is kept if
+// there are more than one contract pending with the same content.
+func acceptContent(cc CallContext, code []byte, addr module.Address) error {
+	if !isDeployValidatorEnabled(cc, DeployValidatorDuplicate) {
+		return nil
+	}
+	hash := crypto.SHA3Sum256(code)
+	if err := checkDuplicate(cc, hash, addr); err != nil {
+		return err
+	}
+	as := cc.GetAccountState(state.SystemID)
+	return scoredb.NewDictDB(as, state.VarContentHashes, 1).Set(hash, addr)
+}
+
+func init() {
+	RegisterDeployValidator(DeployValidatorCodeSize, DeployValidatorFunc(validateCodeSize))
+	RegisterDeployValidator(DeployValidatorForbiddenAPI, DeployValidatorFunc(validateForbiddenAPI))
+	RegisterDeployValidator(DeployValidatorEntryPoint, DeployValidatorFunc(validateEntryPoint))
+	RegisterDeployValidator(DeployValidatorDuplicate, DeployValidatorFunc(validateDuplicate))
+}
@@ -0,0 +1,196 @@
+package contract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestZip(t *testing.T, files map[string]string) []byte {
+	buf := bytes.NewBuffer(nil)
+	zw := zip.NewWriter(buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		assert.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestDeployContent_Files(t *testing.T) {
+	dc := &DeployContent{Code: newTestZip(t, map[string]string{
+		"a/b.txt": "hello",
+	})}
+	files, err := dc.Files()
+	assert.NoError(t, err)
+	if assert.Len(t, files, 1) {
+		assert.Equal(t, "a/b.txt", files[0].Name)
+		assert.Equal(t, []byte("hello"), files[0].Data)
+	}
+
+	dc = &DeployContent{Code: []byte("not a zip")}
+	_, err = dc.Files()
+	assert.Error(t, err)
+}
+
+func TestValidatePythonEntryPoint(t *testing.T) {
+	cases := []struct {
+		name  string
+		files map[string]string
+		ok    bool
+	}{
+		{"valid", map[string]string{
+			"score/package.json":  `{"main_module":"token.main","main_score":"Token"}`,
+			"score/token/main.py": "class Token: pass",
+		}, true},
+		{"main_file", map[string]string{
+			"package.json": `{"main_file":"token","main_score":"Token"}`,
+			"token.py":     "class Token: pass",
+		}, true},
+		{"no_package", map[string]string{
+			"token.py": "class Token: pass",
+		}, false},
+		{"no_main_score", map[string]string{
+			"package.json": `{"main_module":"token"}`,
+			"token.py":     "class Token: pass",
+		}, false},
+		{"no_main_module_file", map[string]string{
+			"package.json": `{"main_module":"token","main_score":"Token"}`,
+			"other.py":     "class Token: pass",
+		}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			files, err := inflateContent(newTestZip(t, c.files))
+			assert.NoError(t, err)
+			err = validatePythonEntryPoint(files)
+			assert.Equal(t, c.ok, err == nil, "err=%v", err)
+		})
+	}
+}
+
+func TestValidateJavaEntryPoint(t *testing.T) {
+	cases := []struct {
+		name  string
+		files map[string]string
+		ok    bool
+	}{
+		{"valid", map[string]string{
+			javaManifest:              "Manifest-Version: 1.0\r\nMain-Class: com.example.Token\r\n",
+			"com/example/Token.class": "class",
+		}, true},
+		{"no_manifest", map[string]string{
+			"com/example/Token.class": "class",
+		}, false},
+		{"no_main_class", map[string]string{
+			javaManifest:              "Manifest-Version: 1.0\r\n",
+			"com/example/Token.class": "class",
+		}, false},
+		{"no_class_file", map[string]string{
+			javaManifest: "Main-Class: com.example.Token\n",
+		}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			files, err := inflateContent(newTestZip(t, c.files))
+			assert.NoError(t, err)
+			err = validateJavaEntryPoint(files)
+			assert.Equal(t, c.ok, err == nil, "err=%v", err)
+		})
+	}
+}
+
+func TestRegisterDeployValidator(t *testing.T) {
+	for _, name := range []string{
+		DeployValidatorCodeSize,
+		DeployValidatorForbiddenAPI,
+		DeployValidatorEntryPoint,
+		DeployValidatorDuplicate,
+	} {
+		assert.True(t, IsDeployValidator(name))
+	}
+	assert.False(t, IsDeployValidator("testValidator"))
+	RegisterDeployValidator("testValidator", DeployValidatorFunc(
+		func(cc CallContext, dc *DeployContent) error {
+			return nil
+		}))
+	assert.True(t, IsDeployValidator("testValidator"))
+}
+
+// newTestClass returns a class file with the constant pool. Each entry is
+// the tag followed by its content.
+func newTestClass(t *testing.T, count int, entries ...[]interface{}) []byte {
+	buf := bytes.NewBuffer(nil)
+	w := func(v interface{}) {
+		assert.NoError(t, binary.Write(buf, binary.BigEndian, v))
+	}
+	w(uint32(0xcafebabe))
+	w(uint16(0))
+	w(uint16(52))
+	w(uint16(count))
+	for _, e := range entries {
+		for _, v := range e {
+			if str, ok := v.(string); ok {
+				w(uint16(len(str)))
+				buf.WriteString(str)
+			} else {
+				w(v)
+			}
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestJavaReferences(t *testing.T) {
+	class := newTestClass(t, 13,
+		[]interface{}{uint8(1), "com/example/Token"},
+		[]interface{}{uint8(7), uint16(1)},
+		[]interface{}{uint8(1), "java/lang/ThreadLocal"},
+		[]interface{}{uint8(7), uint16(3)},
+		[]interface{}{uint8(1), "get"},
+		[]interface{}{uint8(1), "()Ljava/lang/Object;"},
+		[]interface{}{uint8(12), uint16(5), uint16(6)},
+		[]interface{}{uint8(10), uint16(4), uint16(7)},
+		[]interface{}{uint8(5), uint64(1)},
+		[]interface{}{uint8(1), "[Ljava/lang/String;"},
+		[]interface{}{uint8(7), uint16(11)},
+	)
+	refs, err := javaReferences(class)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{
+		"com/example/Token":         true,
+		"java/lang/ThreadLocal":     true,
+		"java/lang/ThreadLocal/get": true,
+		"java/lang/String":          true,
+	}, refs)
+
+	assert.False(t, referred(refs, "java/lang/Thread", "/"))
+	assert.False(t, referred(refs, "java/lang/Object", "/"))
+	assert.True(t, referred(refs, "java/lang/ThreadLocal", "/"))
+	assert.True(t, referred(refs, "java/lang/ThreadLocal/get", "/"))
+	assert.True(t, referred(refs, "java/lang", "/"))
+
+	_, err = javaReferences([]byte("class"))
+	assert.Error(t, err)
+	_, err = javaReferences(class[:len(class)-1])
+	assert.Error(t, err)
+}
+
+func TestPythonReferences(t *testing.T) {
+	refs := pythonReferences([]byte(
+		"from os import (system,\n    path as p)\n" +
+			"import subprocess2\n" +
+			"x = myos.system_call()\n"))
+	assert.True(t, referred(refs, "os.system", "."))
+	assert.True(t, referred(refs, "os.path", "."))
+	assert.True(t, referred(refs, "os", "."))
+	assert.True(t, referred(refs, "myos.system_call", "."))
+	assert.False(t, referred(refs, "os.sys", "."))
+	assert.False(t, referred(refs, "subprocess", "."))
+	assert.False(t, referred(refs, "system_call", "."))
+}
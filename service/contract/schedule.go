@@ -0,0 +1,246 @@
+package contract
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/containerdb"
+	"github.com/icon-project/goloop/common/intconv"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/scoredb"
+	"github.com/icon-project/goloop/service/scoreresult"
+	"github.com/icon-project/goloop/service/state"
+)
+
+// MaxScheduledCallsPerBlock is the maximum number of scheduled calls
+// executed in a block. Calls exceeding it are carried over to following
+// blocks.
+const MaxScheduledCallsPerBlock = 32
+
+// ScheduledCall is a call registered to be executed by the chain at the
+// height. Fee for the step limit is paid on registration, and it's kept
+// by the system until the call is executed or canceled.
+type ScheduledCall struct {
+	ID        int64
+	Height    int64
+	From      *common.Address
+	To        *common.Address
+	Method    string
+	Params    []byte
+	StepLimit *big.Int
+	Deposit   *big.Int
+}
+
+func (sc *ScheduledCall) Bytes() []byte {
+	return codec.BC.MustMarshalToBytes(sc)
+}
+
+// Data returns data of the call transaction for the call.
+func (sc *ScheduledCall) Data() []byte {
+	data := struct {
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params,omitempty"`
+	}{sc.Method, sc.Params}
+	bs, _ := json.Marshal(&data)
+	return bs
+}
+
+// ToMap returns the call as a map for the result of system SCORE.
+func (sc *ScheduledCall) ToMap() map[string]interface{} {
+	m := map[string]interface{}{
+		"id":        sc.ID,
+		"height":    sc.Height,
+		"from":      sc.From,
+		"to":        sc.To,
+		"method":    sc.Method,
+		"stepLimit": sc.StepLimit,
+		"deposit":   sc.Deposit,
+	}
+	if len(sc.Params) > 0 {
+		m["params"] = string(sc.Params)
+	}
+	return m
+}
+
+func scheduledCallDB(store containerdb.BytesStoreState) *containerdb.DictDB {
+	return scoredb.NewDictDB(store, state.VarScheduledCalls, 1)
+}
+
+func scheduleTimer(store containerdb.BytesStoreState) *state.Timer {
+	return state.NewTimer(store, state.VarScheduleTimer)
+}
+
+// GetScheduledCall returns the scheduled call of the id. It returns nil if
+// there is no such call.
+func GetScheduledCall(store containerdb.BytesStoreState, id int64) (*ScheduledCall, error) {
+	value := scheduledCallDB(store).Get(id)
+	if value == nil {
+		return nil, nil
+	}
+	sc := new(ScheduledCall)
+	if _, err := codec.BC.UnmarshalFromBytes(value.Bytes(), sc); err != nil {
+		return nil, scoreresult.InvalidContainerAccessError.Wrap(err, "InvalidScheduledCall")
+	}
+	return sc, nil
+}
+
+func scheduleBacklog(store containerdb.BytesStoreState) *containerdb.VarDB {
+	return scoredb.NewVarDB(store, state.VarScheduleBacklog)
+}
+
+// backlogHeight returns the lowest height having calls to be executed in
+// the block at the height.
+func backlogHeight(store containerdb.BytesStoreState, height int64) int64 {
+	if h := scheduleBacklog(store).Int64(); h > 0 && h < height {
+		return h
+	}
+	return height
+}
+
+// DueScheduledCalls returns IDs of calls to be executed in the block at the
+// height, up to the limit. Calls carried over from previous blocks come
+// first.
+func DueScheduledCalls(store containerdb.BytesStoreState, height int64, limit int) []int64 {
+	timer := scheduleTimer(store)
+	var ids []int64
+	for h := backlogHeight(store, height); h <= height && len(ids) < limit; h++ {
+		for _, v := range timer.Entries(h) {
+			if len(ids) >= limit {
+				break
+			}
+			ids = append(ids, intconv.BytesToInt64(v))
+		}
+	}
+	return ids
+}
+
+// UpdateScheduleBacklog records the lowest height having calls which are
+// not executed yet, so those are carried over to the next block.
+func UpdateScheduleBacklog(store containerdb.BytesStoreState, height int64) error {
+	timer := scheduleTimer(store)
+	backlog := scheduleBacklog(store)
+	for h := backlogHeight(store, height); h <= height; h++ {
+		if timer.Len(h) > 0 {
+			if backlog.Int64() == h {
+				return nil
+			}
+			return backlog.Set(h)
+		}
+	}
+	if backlog.Bytes() != nil {
+		_, err := backlog.Delete()
+		return err
+	}
+	return nil
+}
+
+func moveBalance(cc CallContext, from, to module.Address, amount *big.Int) error {
+	if amount.Sign() == 0 {
+		return nil
+	}
+	as1 := cc.GetAccountState(from.ID())
+	bal1 := as1.GetBalance()
+	if bal1.Cmp(amount) < 0 {
+		return scoreresult.ErrOutOfBalance
+	}
+	as1.SetBalance(new(big.Int).Sub(bal1, amount))
+	as2 := cc.GetAccountState(to.ID())
+	as2.SetBalance(new(big.Int).Add(as2.GetBalance(), amount))
+	return nil
+}
+
+// ScheduleCall registers the call to be executed at the height. It
+// withdraws fee for the step limit of the call from the caller. If more
+// than MaxScheduledCallsPerBlock calls are due at the height, the rest are
+// executed in following blocks in the order of registration, and the
+// receipt of each call has the ScheduledCallExecuted event log showing the
+// scheduled and the executed heights.
+func ScheduleCall(cc CallContext, from module.Address, height int64, to module.Address,
+	method string, params []byte, stepLimit *big.Int,
+) (int64, error) {
+	if height <= cc.BlockHeight() {
+		return 0, scoreresult.InvalidParameterError.Errorf(
+			"InvalidHeight(height=%d,current=%d)", height, cc.BlockHeight())
+	}
+	if !to.IsContract() || len(method) == 0 {
+		return 0, scoreresult.InvalidParameterError.Errorf(
+			"InvalidTarget(to=%s,method=%s)", to, method)
+	}
+	if stepLimit.Sign() <= 0 || stepLimit.Cmp(cc.GetStepLimit(state.StepLimitTypeInvoke)) > 0 {
+		return 0, scoreresult.InvalidParameterError.Errorf("InvalidStepLimit(%d)", stepLimit)
+	}
+	if len(params) > 0 {
+		compact, err := common.CompactJSON(params)
+		if err != nil || len(compact) == 0 || compact[0] != '{' {
+			return 0, scoreresult.InvalidParameterError.New("InvalidParams")
+		}
+		params = compact
+	}
+
+	deposit := new(big.Int).Mul(stepLimit, cc.StepPrice())
+	if err := moveBalance(cc, from, state.SystemAddress, deposit); err != nil {
+		return 0, err
+	}
+
+	sys := cc.GetAccountState(state.SystemID)
+	seq := scoredb.NewVarDB(sys, state.VarScheduleSeq)
+	id := seq.Int64() + 1
+	if err := seq.Set(id); err != nil {
+		return 0, err
+	}
+	sc := &ScheduledCall{
+		ID:        id,
+		Height:    height,
+		From:      common.AddressToPtr(from),
+		To:        common.AddressToPtr(to),
+		Method:    method,
+		Params:    params,
+		StepLimit: stepLimit,
+		Deposit:   deposit,
+	}
+	if err := scheduledCallDB(sys).Set(id, sc.Bytes()); err != nil {
+		return 0, err
+	}
+	if err := scheduleTimer(sys).Add(height, id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// RemoveScheduledCall removes the scheduled call from the state, and
+// returns it.
+func RemoveScheduledCall(store containerdb.BytesStoreState, id int64) (*ScheduledCall, error) {
+	sc, err := GetScheduledCall(store, id)
+	if err != nil || sc == nil {
+		return nil, err
+	}
+	if _, err := scheduleTimer(store).Remove(sc.Height, id); err != nil {
+		return nil, err
+	}
+	if err := scheduledCallDB(store).Delete(id); err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
+
+// CancelScheduledCall cancels the call registered by the caller, and
+// returns the deposit to the caller.
+func CancelScheduledCall(cc CallContext, from module.Address, id int64) error {
+	sys := cc.GetAccountState(state.SystemID)
+	sc, err := GetScheduledCall(sys, id)
+	if err != nil {
+		return err
+	}
+	if sc == nil {
+		return scoreresult.InvalidParameterError.Errorf("NoScheduledCall(id=%d)", id)
+	}
+	if !sc.From.Equal(from) {
+		return scoreresult.AccessDeniedError.Errorf("NotOwner(id=%d,from=%s)", id, from)
+	}
+	if _, err := RemoveScheduledCall(sys, id); err != nil {
+		return err
+	}
+	return moveBalance(cc, state.SystemAddress, sc.From, sc.Deposit)
+}
@@ -0,0 +1,105 @@
+package contract
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/icon-project/goloop/service/state"
+)
+
+const (
+	PropStepTracker = "transition.stepTracker"
+
+	// StepTypeExecution is used for steps charged by the execution
+	// environment which are not related to storage or event logs.
+	StepTypeExecution = "execution"
+)
+
+// StepTracker records steps used for each step type. Steps charged by
+// the execution environment are reported as a sum, so steps for storage
+// and event logs are calculated from the operations requested by it.
+type StepTracker struct {
+	lock  sync.Mutex
+	steps map[string]*big.Int
+}
+
+func NewStepTracker() *StepTracker {
+	return &StepTracker{
+		steps: make(map[string]*big.Int),
+	}
+}
+
+func (t *StepTracker) Add(st state.StepType, steps *big.Int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if v, ok := t.steps[string(st)]; ok {
+		v.Add(v, steps)
+	} else {
+		t.steps[string(st)] = new(big.Int).Set(steps)
+	}
+}
+
+func (t *StepTracker) add(st state.StepType, steps int64) {
+	if steps != 0 {
+		t.Add(st, big.NewInt(steps))
+	}
+}
+
+func (t *StepTracker) onGet(wc state.WorldContext, value []byte) {
+	t.add(state.StepTypeGet, wc.StepsFor(state.StepTypeDefaultGet, 1)+
+		wc.StepsFor(state.StepTypeGet, len(value)))
+}
+
+func (t *StepTracker) onSet(wc state.WorldContext, value, old []byte) {
+	if old != nil {
+		t.add(state.StepTypeReplace, wc.StepsFor(state.StepTypeReplaceBase, 1)+
+			wc.StepsFor(state.StepTypeReplace, len(value)))
+	} else {
+		t.add(state.StepTypeSet, wc.StepsFor(state.StepTypeDefaultSet, 1)+
+			wc.StepsFor(state.StepTypeSet, len(value)))
+	}
+}
+
+func (t *StepTracker) onDelete(wc state.WorldContext, old []byte) {
+	if old != nil {
+		t.add(state.StepTypeDelete, wc.StepsFor(state.StepTypeDefaultDelete, 1)+
+			wc.StepsFor(state.StepTypeDelete, len(old)))
+	}
+}
+
+func (t *StepTracker) onEvent(wc state.WorldContext, indexed, data [][]byte) {
+	var size int
+	for _, v := range indexed {
+		size += len(v)
+	}
+	for _, v := range data {
+		size += len(v)
+	}
+	t.add(state.StepTypeEventLog, wc.StepsFor(state.StepTypeEventLogBase, 1)+
+		wc.StepsFor(state.StepTypeEventLog, size))
+}
+
+// StepsByType returns steps for each type. Steps not recorded in the
+// tracker are regarded as steps for StepTypeExecution.
+func (t *StepTracker) StepsByType(used *big.Int) map[string]*big.Int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	steps := make(map[string]*big.Int, len(t.steps)+1)
+	rest := new(big.Int).Set(used)
+	for st, v := range t.steps {
+		steps[st] = new(big.Int).Set(v)
+		rest.Sub(rest, v)
+	}
+	if rest.Sign() > 0 {
+		steps[StepTypeExecution] = rest
+	}
+	return steps
+}
+
+// StepTrackerOf returns the tracker set in the context. It returns nil
+// if the context doesn't track steps.
+func StepTrackerOf(ctx Context) *StepTracker {
+	t, _ := ctx.GetProperty(PropStepTracker).(*StepTracker)
+	return t
+}
@@ -0,0 +1,141 @@
+package eeproxy
+
+import (
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"sync"
+
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/scoreapi"
+	"github.com/icon-project/goloop/service/scoreresult"
+)
+
+//GoContext is used by GoHandler to access the state and to interact with
+//other contracts through the eeproxy protocol.
+type GoContext interface {
+	From() module.Address
+	Address() module.Address
+	Value() *big.Int
+	IsQuery() bool
+	Info() map[string]interface{}
+	BlockHeight() int64
+	BlockTimestamp() int64
+
+	GetValue(key []byte) ([]byte, error)
+	SetValue(key, value []byte) error
+	DeleteValue(key []byte) error
+	GetBalance(addr module.Address) (*big.Int, error)
+	Event(indexed, data [][]byte) error
+	Call(to module.Address, value *big.Int, method string, params ...interface{}) (interface{}, error)
+	SetFeeProportion(portion int) error
+	Log(lv log.Level, msg string) error
+
+	UseSteps(steps int64) error
+	StepUsed() int64
+	StepLimit() int64
+}
+
+//GoHandler implements a method of GoContract. Parameters are decoded
+//with common.DecodeAny in the order of the declaration, and the result is
+//encoded with common.EncodeAny.
+type GoHandler func(ctx GoContext, params []interface{}) (interface{}, error)
+
+type GoMethod struct {
+	Method  *scoreapi.Method
+	Handler GoHandler
+}
+
+type GoContract struct {
+	info     *scoreapi.Info
+	handlers map[string]GoHandler
+}
+
+func (c *GoContract) Info() *scoreapi.Info {
+	return c.info
+}
+
+//NewGoContract returns a contract with the methods. Every method except
+//event logs should have its handler.
+func NewGoContract(methods ...*GoMethod) (*GoContract, error) {
+	c := &GoContract{
+		handlers: make(map[string]GoHandler),
+	}
+	apis := make([]*scoreapi.Method, 0, len(methods))
+	for _, m := range methods {
+		if m == nil || m.Method == nil {
+			return nil, errors.IllegalArgumentError.New("NilMethod")
+		}
+		if m.Method.IsEvent() {
+			if m.Handler != nil {
+				return nil, errors.IllegalArgumentError.Errorf(
+					"HandlerForEvent(name=%s)", m.Method.Name)
+			}
+		} else {
+			if m.Handler == nil {
+				return nil, errors.IllegalArgumentError.Errorf(
+					"NoHandler(name=%s)", m.Method.Name)
+			}
+			if _, ok := c.handlers[m.Method.Name]; ok {
+				return nil, errors.IllegalArgumentError.Errorf(
+					"DuplicateMethod(name=%s)", m.Method.Name)
+			}
+			c.handlers[m.Method.Name] = m.Handler
+		}
+		apis = append(apis, m.Method)
+	}
+	c.info = scoreapi.NewInfo(apis)
+	return c, nil
+}
+
+//GoContracts is the registry of contracts for the engine created by
+//NewGoEE.
+type GoContracts struct {
+	lock      sync.Mutex
+	contracts map[string]*GoContract
+}
+
+func NewGoContracts() *GoContracts {
+	return &GoContracts{
+		contracts: make(map[string]*GoContract),
+	}
+}
+
+//Register adds the contract with the name. The name is matched with the
+//code given by the service; it's the path of the code or the content of
+//the code stored in the path (code.jar for the java content type).
+func (cs *GoContracts) Register(name string, c *GoContract) {
+	cs.lock.Lock()
+	defer cs.lock.Unlock()
+
+	cs.contracts[name] = c
+}
+
+func (cs *GoContracts) Unregister(name string) {
+	cs.lock.Lock()
+	defer cs.lock.Unlock()
+
+	delete(cs.contracts, name)
+}
+
+func (cs *GoContracts) get(name string) *GoContract {
+	cs.lock.Lock()
+	defer cs.lock.Unlock()
+
+	return cs.contracts[name]
+}
+
+func (cs *GoContracts) lookup(code string) (*GoContract, error) {
+	if c := cs.get(code); c != nil {
+		return c, nil
+	}
+	if bs, err := ioutil.ReadFile(filepath.Join(code, "code.jar")); err == nil {
+		if c := cs.get(string(bs)); c != nil {
+			return c, nil
+		}
+	}
+	return nil, scoreresult.ContractNotFoundError.Errorf(
+		"GoContractNotFound(code=%s)", code)
+}
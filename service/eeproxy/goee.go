@@ -0,0 +1,540 @@
+package eeproxy
+
+import (
+	"io"
+	"math"
+	"math/big"
+	"sync"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/common/ipc"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/scoreresult"
+)
+
+const (
+	GoEE        = "goee"
+	goEEVersion = 1
+)
+
+//goExecutionEngine runs executors in the process. Executors connect to
+//the manager with the same protocol as the external engines, so services
+//can be tested with contracts written in Go.
+type goExecutionEngine struct {
+	lock      sync.Mutex
+	t         string
+	contracts *GoContracts
+	target    int
+	instances map[string]*goExecutor
+	net, addr string
+	logger    log.Logger
+}
+
+func (e *goExecutionEngine) Type() string {
+	return e.t
+}
+
+func (e *goExecutionEngine) Init(net, addr string) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.net = net
+	e.addr = addr
+	return e.runInstancesInLock()
+}
+
+func (e *goExecutionEngine) runInstancesInLock() error {
+	if len(e.addr) == 0 {
+		return nil
+	}
+	for e.target > len(e.instances) {
+		ex := &goExecutor{
+			engine: e,
+			uid:    newUID(),
+			log:    e.logger,
+		}
+		e.instances[ex.uid] = ex
+		go ex.run(e.net, e.addr)
+	}
+	return nil
+}
+
+func (e *goExecutionEngine) SetInstances(n int) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if n < 0 {
+		return errors.ErrIllegalArgument
+	}
+	e.target = n
+	return e.runInstancesInLock()
+}
+
+func (e *goExecutionEngine) OnAttach(uid string) bool {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	_, ok := e.instances[uid]
+	return ok
+}
+
+func (e *goExecutionEngine) OnEnd(uid string) bool {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if _, ok := e.instances[uid]; ok {
+		return true
+	}
+	return false
+}
+
+func (e *goExecutionEngine) Kill(uid string) (bool, error) {
+	e.lock.Lock()
+	ex, ok := e.instances[uid]
+	e.lock.Unlock()
+
+	if !ok {
+		return false, nil
+	}
+	return true, ex.close()
+}
+
+func (e *goExecutionEngine) OnConnect(conn ipc.Connection, version uint16) error {
+	return errors.UnsupportedError.New("NoManagerForGoEE")
+}
+
+func (e *goExecutionEngine) OnClose(conn ipc.Connection) bool {
+	return false
+}
+
+//onEnd is called when the executor is terminated. It starts a new one
+//if the number of executors is less than the target.
+func (e *goExecutionEngine) onEnd(ex *goExecutor) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	delete(e.instances, ex.uid)
+	if err := e.runInstancesInLock(); err != nil {
+		e.logger.Warnf("FailToRunInstances(err=%+v)", err)
+	}
+}
+
+//NewGoEE returns an engine for the type t, which executes the contracts
+//registered in cs. Use "java" or "python" for t to replace the engine.
+func NewGoEE(l log.Logger, t string, cs *GoContracts) Engine {
+	return &goExecutionEngine{
+		t:         t,
+		contracts: cs,
+		instances: make(map[string]*goExecutor),
+		logger:    l.WithFields(log.Fields{log.FieldKeyModule: GoEE}),
+	}
+}
+
+type goExecutor struct {
+	lock   sync.Mutex
+	engine *goExecutionEngine
+	uid    string
+	conn   ipc.Connection
+	log    log.Logger
+
+	result *resultMessage
+	err    error
+}
+
+func (ex *goExecutor) run(net, addr string) {
+	defer ex.engine.onEnd(ex)
+
+	conn, err := ipc.Dial(net, addr)
+	if err != nil {
+		ex.log.Warnf("FailToConnect(net=%s,addr=%s,err=%+v)", net, addr, err)
+		return
+	}
+	ex.lock.Lock()
+	ex.conn = conn
+	ex.lock.Unlock()
+	defer conn.Close()
+
+	conn.SetHandler(msgINVOKE, ex)
+	conn.SetHandler(msgRESULT, ex)
+	conn.SetHandler(msgGETAPI, ex)
+	conn.SetHandler(msgCLOSE, ex)
+
+	err = conn.Send(msgVERSION, &versionMessage{
+		Version: goEEVersion,
+		UID:     ex.uid,
+		Type:    ex.engine.t,
+	})
+	for err == nil {
+		err = conn.HandleMessage()
+	}
+	if err != io.EOF {
+		ex.log.Debugf("GoExecutor[%s] terminated err=%+v", ex.uid, err)
+	}
+}
+
+func (ex *goExecutor) close() error {
+	ex.lock.Lock()
+	defer ex.lock.Unlock()
+
+	if ex.conn == nil {
+		return nil
+	}
+	return ex.conn.Close()
+}
+
+func (ex *goExecutor) HandleMessage(c ipc.Connection, msg uint, data []byte) error {
+	switch msg {
+	case msgINVOKE:
+		var m invokeMessage
+		if _, err := codec.MP.UnmarshalFromBytes(data, &m); err != nil {
+			return err
+		}
+		res := ex.invoke(&m)
+		if ex.err != nil {
+			return ex.err
+		}
+		return c.Send(msgRESULT, res)
+
+	case msgRESULT:
+		var m resultMessage
+		if _, err := codec.MP.UnmarshalFromBytes(data, &m); err != nil {
+			return err
+		}
+		ex.result = &m
+		return nil
+
+	case msgGETAPI:
+		var code string
+		if _, err := codec.MP.UnmarshalFromBytes(data, &code); err != nil {
+			return err
+		}
+		var m getAPIMessage
+		if contract, err := ex.engine.contracts.lookup(code); err != nil {
+			m.Status = errors.CodeOf(err)
+		} else {
+			m.Status = errors.Success
+			m.Info = contract.Info()
+		}
+		return c.Send(msgGETAPI, &m)
+
+	case msgCLOSE:
+		return io.EOF
+
+	default:
+		return errors.InvalidStateError.Errorf("UnknownMessage(msg=%d)", msg)
+	}
+}
+
+func (ex *goExecutor) invoke(m *invokeMessage) *resultMessage {
+	f := &goFrame{
+		ex:      ex,
+		to:      &m.To,
+		value:   &m.Value.Int,
+		isQuery: m.IsQry,
+		limit:   math.MaxInt64,
+	}
+	if m.From != nil {
+		f.from = m.From
+	}
+	if m.Limit.IsInt64() {
+		f.limit = m.Limit.Int64()
+	}
+	if info, ok := common.MustDecodeAny(m.Info).(map[string]interface{}); ok {
+		f.info = info
+	} else {
+		f.info = make(map[string]interface{})
+	}
+
+	result, status := f.execute(m.Code, m.Method, m.Params)
+
+	res := &resultMessage{
+		EID: m.EID,
+	}
+	if m.State != nil {
+		res.PrevEID = m.State.PrevEID
+	}
+	res.StepUsed.SetInt64(f.used)
+	if status == nil {
+		res.Status = errors.Success
+		res.Result = result
+	} else {
+		status = scoreresult.Validate(status)
+		res.Status = errors.CodeOf(status)
+		res.Result = common.MustEncodeAny(status.Error())
+	}
+	return res
+}
+
+//waitResult handles the messages until it gets the result of the call.
+//Calls to the contracts in the executor are handled in the loop.
+func (ex *goExecutor) waitResult() (*resultMessage, error) {
+	for ex.result == nil {
+		if err := ex.conn.HandleMessage(); err != nil {
+			ex.err = err
+			return nil, errors.ExecutionFailError.Wrap(err, "ConnectionFailure")
+		}
+	}
+	res := ex.result
+	ex.result = nil
+	return res, nil
+}
+
+type goFrame struct {
+	ex      *goExecutor
+	from    module.Address
+	to      module.Address
+	value   *big.Int
+	isQuery bool
+	limit   int64
+	used    int64
+	info    map[string]interface{}
+}
+
+func (f *goFrame) execute(code, method string, params *codec.TypedObj) (result *codec.TypedObj, err error) {
+	contract, err := f.ex.engine.contracts.lookup(code)
+	if err != nil {
+		return nil, err
+	}
+	handler, ok := contract.handlers[method]
+	if !ok {
+		return nil, scoreresult.MethodNotFoundError.Errorf(
+			"MethodNotFound(name=%s)", method)
+	}
+	var args []interface{}
+	if params != nil {
+		obj, err := common.DecodeAny(params)
+		if err != nil {
+			return nil, scoreresult.InvalidParameterError.Wrap(err, "InvalidParams")
+		}
+		switch obj := obj.(type) {
+		case nil:
+		case []interface{}:
+			args = obj
+		default:
+			return nil, scoreresult.InvalidParameterError.Errorf(
+				"InvalidParams(type=%T)", obj)
+		}
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			result = nil
+			err = scoreresult.UnknownFailureError.Errorf("Panic(%v)", r)
+		}
+	}()
+	ret, err := handler(f, args)
+	if err != nil {
+		return nil, err
+	}
+	if result, err = common.EncodeAny(ret); err != nil {
+		return nil, scoreresult.UnknownFailureError.Wrap(err, "InvalidResult")
+	}
+	return result, nil
+}
+
+func (f *goFrame) From() module.Address {
+	return f.from
+}
+
+func (f *goFrame) Address() module.Address {
+	return f.to
+}
+
+func (f *goFrame) Value() *big.Int {
+	return f.value
+}
+
+func (f *goFrame) IsQuery() bool {
+	return f.isQuery
+}
+
+func (f *goFrame) Info() map[string]interface{} {
+	return f.info
+}
+
+func (f *goFrame) BlockHeight() int64 {
+	return int64Of(f.info["B.height"])
+}
+
+func (f *goFrame) BlockTimestamp() int64 {
+	return int64Of(f.info["B.timestamp"])
+}
+
+func (f *goFrame) stepsFor(t string, n int) int64 {
+	if costs, ok := f.info["StepCosts"].(map[string]interface{}); ok {
+		return int64Of(costs[t]) * int64(n)
+	}
+	return 0
+}
+
+func (f *goFrame) UseSteps(steps int64) error {
+	f.used += steps
+	if f.used < 0 {
+		f.used = 0
+	}
+	if f.used > f.limit {
+		f.used = f.limit
+		return scoreresult.OutOfStepError.New("OutOfStep")
+	}
+	return nil
+}
+
+func (f *goFrame) StepUsed() int64 {
+	return f.used
+}
+
+func (f *goFrame) StepLimit() int64 {
+	return f.limit
+}
+
+func (f *goFrame) GetValue(key []byte) ([]byte, error) {
+	var m getValueMessage
+	if err := f.ex.conn.SendAndReceive(msgGETVALUE, key, &m); err != nil {
+		return nil, errors.ExecutionFailError.Wrap(err, "ConnectionFailure")
+	}
+	if err := f.UseSteps(f.stepsFor("defaultGet", 1) + f.stepsFor("get", len(m.Value))); err != nil {
+		return nil, err
+	}
+	if !m.Success {
+		return nil, nil
+	}
+	return m.Value, nil
+}
+
+func (f *goFrame) SetValue(key, value []byte) error {
+	if f.isQuery {
+		return scoreresult.AccessDeniedError.New("SetValueInQuery")
+	}
+	m := setValueMessage{
+		Key:   key,
+		Flag:  flagOLDVALUE,
+		Value: value,
+	}
+	var old oldValueMessage
+	if err := f.ex.conn.SendAndReceive(msgSETVALUE, &m, &old); err != nil {
+		return errors.ExecutionFailError.Wrap(err, "ConnectionFailure")
+	}
+	if old.HasOld {
+		return f.UseSteps(f.stepsFor("replaceBase", 1) + f.stepsFor("replace", len(value)))
+	}
+	return f.UseSteps(f.stepsFor("defaultSet", 1) + f.stepsFor("set", len(value)))
+}
+
+func (f *goFrame) DeleteValue(key []byte) error {
+	if f.isQuery {
+		return scoreresult.AccessDeniedError.New("DeleteValueInQuery")
+	}
+	m := setValueMessage{
+		Key:  key,
+		Flag: flagDELETE | flagOLDVALUE,
+	}
+	var old oldValueMessage
+	if err := f.ex.conn.SendAndReceive(msgSETVALUE, &m, &old); err != nil {
+		return errors.ExecutionFailError.Wrap(err, "ConnectionFailure")
+	}
+	if !old.HasOld {
+		return nil
+	}
+	return f.UseSteps(f.stepsFor("defaultDelete", 1) + f.stepsFor("delete", old.OldSize))
+}
+
+func (f *goFrame) GetBalance(addr module.Address) (*big.Int, error) {
+	var balance common.HexInt
+	if err := f.ex.conn.SendAndReceive(msgGETBALANCE, common.AddressToPtr(addr), &balance); err != nil {
+		return nil, errors.ExecutionFailError.Wrap(err, "ConnectionFailure")
+	}
+	return &balance.Int, nil
+}
+
+func (f *goFrame) Event(indexed, data [][]byte) error {
+	if f.isQuery {
+		return scoreresult.AccessDeniedError.New("EventInQuery")
+	}
+	var size int
+	for _, v := range indexed {
+		size += len(v)
+	}
+	for _, v := range data {
+		size += len(v)
+	}
+	if err := f.UseSteps(f.stepsFor("eventLogBase", 1) + f.stepsFor("eventLog", size)); err != nil {
+		return err
+	}
+	m := eventMessage{
+		Indexed: indexed,
+		Data:    data,
+	}
+	if err := f.ex.conn.Send(msgEVENT, &m); err != nil {
+		return errors.ExecutionFailError.Wrap(err, "ConnectionFailure")
+	}
+	return nil
+}
+
+//Call invokes the method of the contract, or transfers the value if the
+//target is not a contract. Steps used by the target are added to the frame.
+func (f *goFrame) Call(to module.Address, value *big.Int, method string, params ...interface{}) (interface{}, error) {
+	if value == nil {
+		value = new(big.Int)
+	}
+	if f.isQuery && value.Sign() != 0 {
+		return nil, scoreresult.AccessDeniedError.New("TransferInQuery")
+	}
+	if params == nil {
+		params = []interface{}{}
+	}
+	data, err := common.EncodeAny(map[string]interface{}{
+		"method": method,
+		"params": params,
+	})
+	if err != nil {
+		return nil, scoreresult.InvalidParameterError.Wrap(err, "InvalidParams")
+	}
+	var m callMessage
+	m.To.Set(to)
+	m.Value.Set(value)
+	m.Limit.SetInt64(f.limit - f.used)
+	m.DataType = "call"
+	m.Data = data
+	if err := f.ex.conn.Send(msgCALL, &m); err != nil {
+		return nil, errors.ExecutionFailError.Wrap(err, "ConnectionFailure")
+	}
+	res, err := f.ex.waitResult()
+	if err != nil {
+		return nil, err
+	}
+	if err := f.UseSteps(res.StepUsed.Int64()); err != nil {
+		return nil, err
+	}
+	if res.Status != errors.Success {
+		return nil, res.Status.New(common.DecodeAsString(res.Result, ""))
+	}
+	if res.Result == nil {
+		return nil, nil
+	}
+	return common.DecodeAny(res.Result)
+}
+
+func (f *goFrame) SetFeeProportion(portion int) error {
+	if portion < 0 || portion > 100 {
+		return scoreresult.InvalidParameterError.Errorf(
+			"InvalidProportion(%d)", portion)
+	}
+	if err := f.ex.conn.Send(msgSETFEEPCT, portion); err != nil {
+		return errors.ExecutionFailError.Wrap(err, "ConnectionFailure")
+	}
+	return nil
+}
+
+func (f *goFrame) Log(lv log.Level, msg string) error {
+	m := logMessage{
+		Level:   lv,
+		Message: msg,
+	}
+	if err := f.ex.conn.Send(msgLOG, &m); err != nil {
+		return errors.ExecutionFailError.Wrap(err, "ConnectionFailure")
+	}
+	return nil
+}
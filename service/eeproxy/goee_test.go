@@ -0,0 +1,314 @@
+package eeproxy
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/intconv"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/scoreapi"
+	"github.com/icon-project/goloop/service/scoreresult"
+)
+
+type goTestResult struct {
+	status error
+	steps  *big.Int
+	result *codec.TypedObj
+}
+
+//goTestContext emulates the service. Calls are executed by the proxy
+//on the same executor, and the results are sent back to the caller.
+type goTestContext struct {
+	proxy  Proxy
+	parent *goTestContext
+	store  map[string][]byte
+	lock   *sync.Mutex
+	events [][][]byte
+	done   chan *goTestResult
+	api    chan *scoreapi.Info
+}
+
+func newGoTestContext(p Proxy) *goTestContext {
+	return &goTestContext{
+		proxy: p,
+		store: make(map[string][]byte),
+		lock:  new(sync.Mutex),
+		done:  make(chan *goTestResult, 1),
+		api:   make(chan *scoreapi.Info, 1),
+	}
+}
+
+func (cc *goTestContext) GetValue(key []byte) ([]byte, error) {
+	cc.lock.Lock()
+	defer cc.lock.Unlock()
+	return cc.store[string(key)], nil
+}
+
+func (cc *goTestContext) SetValue(key []byte, value []byte) ([]byte, error) {
+	cc.lock.Lock()
+	defer cc.lock.Unlock()
+	old := cc.store[string(key)]
+	cc.store[string(key)] = value
+	return old, nil
+}
+
+func (cc *goTestContext) DeleteValue(key []byte) ([]byte, error) {
+	cc.lock.Lock()
+	defer cc.lock.Unlock()
+	old := cc.store[string(key)]
+	delete(cc.store, string(key))
+	return old, nil
+}
+
+func (cc *goTestContext) ArrayDBContains(prefix, value []byte, limit int64) (bool, int, int, error) {
+	return false, 0, 0, nil
+}
+
+func (cc *goTestContext) GetInfo() *codec.TypedObj {
+	return common.MustEncodeAny(map[string]interface{}{
+		"B.height":    10,
+		"B.timestamp": 1000,
+		"StepCosts": map[string]interface{}{
+			"defaultGet": 10,
+			"defaultSet": 100,
+		},
+	})
+}
+
+func (cc *goTestContext) GetBalance(addr module.Address) *big.Int {
+	return big.NewInt(1234)
+}
+
+func (cc *goTestContext) OnEvent(addr module.Address, indexed, data [][]byte) error {
+	cc.lock.Lock()
+	defer cc.lock.Unlock()
+	cc.events = append(cc.events, indexed)
+	return nil
+}
+
+func (cc *goTestContext) OnResult(status error, steps *big.Int, result *codec.TypedObj) {
+	if cc.parent != nil {
+		go cc.proxy.SendResult(cc.parent, status, steps, result, 0, 0)
+		return
+	}
+	cc.done <- &goTestResult{status, steps, result}
+}
+
+func (cc *goTestContext) OnCall(from, to module.Address, value, limit *big.Int, dataType string, dataObj *codec.TypedObj) {
+	data := common.MustDecodeAny(dataObj).(map[string]interface{})
+	params, _ := common.EncodeAny(data["params"])
+	child := &goTestContext{
+		proxy:  cc.proxy,
+		parent: cc,
+		store:  cc.store,
+		lock:   cc.lock,
+	}
+	go cc.proxy.Invoke(child, "counter", false, from, to, value, limit,
+		data["method"].(string), params, nil, 0, nil)
+}
+
+func (cc *goTestContext) OnAPI(status error, info *scoreapi.Info) {
+	cc.api <- info
+}
+
+func (cc *goTestContext) OnSetFeeProportion(owner module.Address, portion int) {
+}
+
+func (cc *goTestContext) SetCode(code []byte) error {
+	return nil
+}
+
+func (cc *goTestContext) GetObjGraph(bool) (int, []byte, []byte, error) {
+	return 0, nil, nil, nil
+}
+
+func (cc *goTestContext) SetObjGraph(flags bool, nextHash int, objGraph []byte) error {
+	return nil
+}
+
+func (cc *goTestContext) Logger() log.Logger {
+	return log.GlobalLogger()
+}
+
+func newCounterContract(t *testing.T) *GoContract {
+	c, err := NewGoContract(
+		&GoMethod{
+			Method: &scoreapi.Method{
+				Type:    scoreapi.Function,
+				Name:    "inc",
+				Flags:   scoreapi.FlagExternal,
+				Indexed: 1,
+				Inputs: []scoreapi.Parameter{
+					{Name: "delta", Type: scoreapi.Integer},
+				},
+			},
+			Handler: func(ctx GoContext, params []interface{}) (interface{}, error) {
+				value, err := ctx.GetValue([]byte("count"))
+				if err != nil {
+					return nil, err
+				}
+				count := new(big.Int).SetBytes(value)
+				count.Add(count, &params[0].(*common.HexInt).Int)
+				if err := ctx.SetValue([]byte("count"), count.Bytes()); err != nil {
+					return nil, err
+				}
+				return nil, ctx.Event([][]byte{[]byte("Inc(int)")}, [][]byte{count.Bytes()})
+			},
+		},
+		&GoMethod{
+			Method: &scoreapi.Method{
+				Type:    scoreapi.Function,
+				Name:    "get",
+				Flags:   scoreapi.FlagExternal | scoreapi.FlagReadOnly,
+				Outputs: []scoreapi.DataType{scoreapi.Integer},
+			},
+			Handler: func(ctx GoContext, params []interface{}) (interface{}, error) {
+				value, err := ctx.GetValue([]byte("count"))
+				if err != nil {
+					return nil, err
+				}
+				if ctx.BlockHeight() != 10 {
+					return nil, scoreresult.UnknownFailureError.New("InvalidHeight")
+				}
+				return new(big.Int).SetBytes(value), nil
+			},
+		},
+		&GoMethod{
+			Method: &scoreapi.Method{
+				Type:    scoreapi.Function,
+				Name:    "relay",
+				Flags:   scoreapi.FlagExternal,
+				Outputs: []scoreapi.DataType{scoreapi.Integer},
+			},
+			Handler: func(ctx GoContext, params []interface{}) (interface{}, error) {
+				if _, err := ctx.Call(ctx.Address(), nil, "inc", 5); err != nil {
+					return nil, err
+				}
+				return ctx.Call(ctx.Address(), nil, "get")
+			},
+		},
+		&GoMethod{
+			Method: &scoreapi.Method{
+				Type:  scoreapi.Function,
+				Name:  "spend",
+				Flags: scoreapi.FlagExternal,
+			},
+			Handler: func(ctx GoContext, params []interface{}) (interface{}, error) {
+				for {
+					if err := ctx.UseSteps(1000); err != nil {
+						return nil, err
+					}
+				}
+			},
+		},
+		&GoMethod{
+			Method: &scoreapi.Method{
+				Type:    scoreapi.Event,
+				Name:    "Inc",
+				Indexed: 0,
+				Inputs: []scoreapi.Parameter{
+					{Name: "count", Type: scoreapi.Integer},
+				},
+			},
+		},
+	)
+	assert.NoError(t, err)
+	return c
+}
+
+func TestGoEE_Invoke(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goee")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	contracts := NewGoContracts()
+	contracts.Register("counter", newCounterContract(t))
+
+	mgr, err := NewManager("unix", filepath.Join(dir, "ee.sock"), log.GlobalLogger(),
+		NewGoEE(log.GlobalLogger(), "java", contracts))
+	assert.NoError(t, err)
+	defer mgr.Close()
+	go mgr.Loop()
+	assert.NoError(t, mgr.SetInstances(1, 1, 1))
+
+	ex := mgr.GetExecutor(ForTransaction)
+	defer ex.Release()
+	p := ex.Get("java")
+	assert.NotNil(t, p)
+
+	from := common.MustNewAddressFromString("hx0000000000000000000000000000000000000001")
+	to := common.MustNewAddressFromString("cx0000000000000000000000000000000000000001")
+	limit := big.NewInt(1000000)
+	cc := newGoTestContext(p)
+
+	wait := func() *goTestResult {
+		select {
+		case r := <-cc.done:
+			return r
+		case <-time.After(5 * time.Second):
+			assert.FailNow(t, "Timeout")
+			return nil
+		}
+	}
+
+	assert.NoError(t, p.GetAPI(cc, "counter"))
+	info := <-cc.api
+	if assert.NotNil(t, info) {
+		assert.NotNil(t, info.GetMethod("inc"))
+	}
+
+	params := common.MustEncodeAny([]interface{}{3})
+	assert.NoError(t, p.Invoke(cc, "counter", false, from, to, big.NewInt(0), limit,
+		"inc", params, nil, 1, nil))
+	r := wait()
+	assert.NoError(t, r.status)
+	assert.Equal(t, int64(110), r.steps.Int64())
+	assert.Equal(t, []byte{3}, cc.store["count"])
+	assert.Len(t, cc.events, 1)
+
+	assert.NoError(t, p.Invoke(cc, "counter", false, from, to, big.NewInt(0), limit,
+		"relay", nil, nil, 2, nil))
+	r = wait()
+	assert.NoError(t, r.status)
+	assert.Equal(t, "0x8", intconv.FormatBigInt(&common.MustDecodeAny(r.result).(*common.HexInt).Int))
+	assert.Equal(t, int64(20), r.steps.Int64())
+
+	assert.NoError(t, p.Invoke(cc, "counter", true, from, to, big.NewInt(0), limit,
+		"inc", params, nil, 3, nil))
+	r = wait()
+	assert.True(t, scoreresult.AccessDeniedError.Equals(r.status))
+
+	assert.NoError(t, p.Invoke(cc, "counter", false, from, to, big.NewInt(0), limit,
+		"spend", nil, nil, 4, nil))
+	r = wait()
+	assert.True(t, scoreresult.OutOfStepError.Equals(r.status))
+	assert.Equal(t, limit, r.steps)
+
+	assert.NoError(t, p.Invoke(cc, "counter", false, from, to, big.NewInt(0), limit,
+		"unknown", nil, nil, 5, nil))
+	r = wait()
+	assert.True(t, scoreresult.MethodNotFoundError.Equals(r.status))
+}
+
+func TestNewGoContract(t *testing.T) {
+	_, err := NewGoContract(&GoMethod{
+		Method: &scoreapi.Method{Type: scoreapi.Function, Name: "f"},
+	})
+	assert.Error(t, err)
+
+	_, err = NewGoContract(&GoMethod{
+		Method:  &scoreapi.Method{Type: scoreapi.Event, Name: "E"},
+		Handler: func(ctx GoContext, params []interface{}) (interface{}, error) { return nil, nil },
+	})
+	assert.Error(t, err)
+}
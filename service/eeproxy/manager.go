@@ -49,9 +49,16 @@ type Executor struct {
 	manager  *executorManager
 	typeMap  map[string]int
 	proxies  []*proxy
+	wasm     *wasmProxy
 }
 
 func (e *Executor) Get(name string) Proxy {
+	if name == WasmEE {
+		if e.wasm == nil {
+			e.wasm = newWasmProxy()
+		}
+		return e.wasm
+	}
 	t, ok := e.typeMap[name]
 	if !ok {
 		return nil
@@ -64,12 +71,18 @@ func (e *Executor) Release() {
 	for _, p := range e.proxies {
 		p.Release()
 	}
+	if e.wasm != nil {
+		e.wasm.Release()
+	}
 }
 
 func (e *Executor) Kill() {
 	for _, p := range e.proxies {
 		p.Kill()
 	}
+	if e.wasm != nil {
+		e.wasm.Kill()
+	}
 	e.Release()
 }
 
@@ -0,0 +1,274 @@
+package eeproxy
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/cache"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/common/intconv"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/scoreapi"
+	"github.com/icon-project/goloop/service/scoreresult"
+	"github.com/icon-project/goloop/service/wasm"
+)
+
+const (
+	WasmCodeFile     = "code.wasm"
+	WasmABISection   = "icon:abi"
+	wasmInstall      = "on_install"
+	wasmUpdate       = "on_update"
+	wasmFallback     = "fallback"
+	wasmMaxEventArgs = 3
+	wasmCacheSize    = 64
+)
+
+type wasmABIParam struct {
+	Name    string          `json:"name"`
+	Type    string          `json:"type"`
+	Default json.RawMessage `json:"default"`
+	Indexed json.RawMessage `json:"indexed"`
+}
+
+type wasmABIMethod struct {
+	Type     string          `json:"type"`
+	Name     string          `json:"name"`
+	Inputs   []wasmABIParam  `json:"inputs"`
+	Outputs  []wasmABIParam  `json:"outputs"`
+	ReadOnly json.RawMessage `json:"readonly"`
+	Payable  json.RawMessage `json:"payable"`
+	Isolated json.RawMessage `json:"isolated"`
+}
+
+//wasmCode is a compiled module with its API
+type wasmCode struct {
+	module *wasm.Module
+	info   *scoreapi.Info
+}
+
+var wasmCodeCache = cache.NewLRUCache(wasmCacheSize, func(path string) (interface{}, error) {
+	bs, err := ioutil.ReadFile(filepath.Join(path, WasmCodeFile))
+	if err != nil {
+		return nil, errors.CriticalIOError.Wrapf(err, "FailToReadCode(path=%s)", path)
+	}
+	return compileWasm(bs)
+})
+
+func loadWasmCode(path string) (*wasmCode, error) {
+	obj, err := wasmCodeCache.Get(path)
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*wasmCode), nil
+}
+
+func compileWasm(bs []byte) (*wasmCode, error) {
+	m, err := wasm.Compile(bs)
+	if err != nil {
+		return nil, err
+	}
+	info, err := parseWasmABI(m)
+	if err != nil {
+		return nil, err
+	}
+	return &wasmCode{module: m, info: info}, nil
+}
+
+//isTrue accepts true, 1 and "0x1" as JSON values for the flags
+func isTrue(raw json.RawMessage) bool {
+	switch string(raw) {
+	case "true", "1", "\"0x1\"":
+		return true
+	default:
+		return false
+	}
+}
+
+func wasmExportOf(name string) string {
+	if name == scoreapi.FallbackMethodName {
+		return wasmFallback
+	}
+	return name
+}
+
+func isWasmInternal(name string) bool {
+	return name == wasmInstall || name == wasmUpdate
+}
+
+//parseWasmABI reads API from the custom section which has the same format
+//as the result of icx_getScoreApi. Every function should be exported as
+//a function without parameters and results, which reads parameters and sets
+//result through the host functions.
+func parseWasmABI(m *wasm.Module) (*scoreapi.Info, error) {
+	bs, ok := m.CustomSection(WasmABISection)
+	if !ok {
+		return nil, scoreresult.IllegalFormatError.Errorf("NoABISection(name=%s)", WasmABISection)
+	}
+	var abi []wasmABIMethod
+	if err := json.Unmarshal(bs, &abi); err != nil {
+		return nil, scoreresult.IllegalFormatError.Wrap(err, "InvalidABI")
+	}
+	names := make(map[string]bool)
+	methods := make([]*scoreapi.Method, 0, len(abi)+2)
+	for _, am := range abi {
+		method, err := am.toMethod()
+		if err != nil {
+			return nil, err
+		}
+		key := method.Type.String() + ":" + method.Name
+		if names[key] {
+			return nil, scoreresult.IllegalFormatError.Errorf("DuplicateMethod(name=%s)", method.Name)
+		}
+		names[key] = true
+		if method.Type != scoreapi.Event {
+			ft, ok := m.ExportedFunction(wasmExportOf(method.Name))
+			if ok && (len(ft.Params) != 0 || len(ft.Results) != 0) {
+				return nil, scoreresult.IllegalFormatError.Errorf(
+					"InvalidExportType(name=%s)", method.Name)
+			}
+			if !ok && !isWasmInternal(method.Name) {
+				return nil, scoreresult.IllegalFormatError.Errorf(
+					"NoExport(name=%s)", method.Name)
+			}
+		}
+		methods = append(methods, method)
+	}
+	//on_install and on_update are optional, but deploy requires them.
+	for _, name := range []string{wasmInstall, wasmUpdate} {
+		if !names[scoreapi.Function.String()+":"+name] {
+			methods = append(methods, &scoreapi.Method{
+				Type: scoreapi.Function,
+				Name: name,
+			})
+		}
+	}
+	return scoreapi.NewInfo(methods), nil
+}
+
+func (am *wasmABIMethod) toMethod() (*scoreapi.Method, error) {
+	method := &scoreapi.Method{Name: am.Name}
+	switch am.Type {
+	case "function":
+		method.Type = scoreapi.Function
+		if !isWasmInternal(am.Name) {
+			method.Flags |= scoreapi.FlagExternal
+		}
+	case "fallback":
+		method.Type = scoreapi.Fallback
+		method.Name = scoreapi.FallbackMethodName
+	case "eventlog":
+		method.Type = scoreapi.Event
+	default:
+		return nil, scoreresult.IllegalFormatError.Errorf(
+			"InvalidMethodType(name=%s,type=%s)", am.Name, am.Type)
+	}
+	if method.Type == scoreapi.Function && len(am.Name) == 0 {
+		return nil, scoreresult.IllegalFormatError.New("EmptyMethodName")
+	}
+	if isTrue(am.ReadOnly) {
+		method.Flags |= scoreapi.FlagReadOnly
+	}
+	if isTrue(am.Payable) {
+		method.Flags |= scoreapi.FlagPayable
+	}
+	if isTrue(am.Isolated) {
+		method.Flags |= scoreapi.FlagIsolated
+	}
+
+	optional := false
+	method.Inputs = make([]scoreapi.Parameter, len(am.Inputs))
+	for i, ap := range am.Inputs {
+		p := &method.Inputs[i]
+		p.Name = ap.Name
+		p.Type = scoreapi.DataTypeOf(ap.Type)
+		if p.Type == scoreapi.Unknown || p.Type.Tag() == scoreapi.TStruct {
+			return nil, scoreresult.IllegalFormatError.Errorf(
+				"InvalidParameterType(method=%s,type=%s)", am.Name, ap.Type)
+		}
+		if method.Type == scoreapi.Event {
+			if isTrue(ap.Indexed) {
+				if method.Indexed != i {
+					return nil, scoreresult.IllegalFormatError.Errorf(
+						"InvalidIndexedOrder(event=%s)", am.Name)
+				}
+				method.Indexed++
+			}
+			continue
+		}
+		if ap.Default != nil {
+			def, err := wasmBytesOf(p.Type, ap.Default)
+			if err != nil {
+				return nil, scoreresult.IllegalFormatError.Wrapf(err,
+					"InvalidDefault(method=%s,param=%s)", am.Name, ap.Name)
+			}
+			p.Default = def
+			optional = true
+		} else if optional {
+			return nil, scoreresult.IllegalFormatError.Errorf(
+				"RequiredAfterOptional(method=%s,param=%s)", am.Name, ap.Name)
+		} else {
+			method.Indexed++
+		}
+	}
+	if method.Type == scoreapi.Event && method.Indexed > wasmMaxEventArgs {
+		return nil, scoreresult.IllegalFormatError.Errorf(
+			"TooManyIndexed(event=%s)", am.Name)
+	}
+	if len(am.Outputs) > 1 {
+		return nil, scoreresult.IllegalFormatError.Errorf(
+			"TooManyOutputs(method=%s)", am.Name)
+	}
+	for _, ao := range am.Outputs {
+		t := scoreapi.DataTypeOf(ao.Type)
+		if t == scoreapi.Unknown || t.Tag() == scoreapi.TStruct {
+			return nil, scoreresult.IllegalFormatError.Errorf(
+				"InvalidOutputType(method=%s,type=%s)", am.Name, ao.Type)
+		}
+		method.Outputs = append(method.Outputs, t)
+	}
+	return method, nil
+}
+
+//wasmBytesOf converts JSON value into bytes used for event logs and default
+//values of parameters.
+func wasmBytesOf(t scoreapi.DataType, raw json.RawMessage) ([]byte, error) {
+	if string(raw) == "null" {
+		return nil, nil
+	}
+	if t.IsList() {
+		return nil, scoreresult.InvalidParameterError.Errorf(
+			"UnsupportedType(type=%s)", t.String())
+	}
+	obj, err := t.ConvertJSONToTypedObj(raw, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	if obj.Type == codec.TypeNil {
+		return nil, nil
+	}
+	v, err := common.DecodeAny(obj)
+	if err != nil {
+		return nil, scoreresult.InvalidParameterError.Wrap(err, "InvalidValue")
+	}
+	switch v := v.(type) {
+	case *common.HexInt:
+		return intconv.BigIntToBytes(&v.Int), nil
+	case string:
+		return []byte(v), nil
+	case []byte:
+		return v, nil
+	case bool:
+		if v {
+			return []byte{1}, nil
+		}
+		return []byte{0}, nil
+	case module.Address:
+		return v.Bytes(), nil
+	default:
+		return nil, scoreresult.InvalidParameterError.Errorf(
+			"UnsupportedValue(type=%T)", v)
+	}
+}
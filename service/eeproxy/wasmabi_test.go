@@ -0,0 +1,120 @@
+package eeproxy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/service/scoreapi"
+	"github.com/icon-project/goloop/service/scoreresult"
+)
+
+func wasmSection(id byte, content []byte) []byte {
+	bs := []byte{id}
+	for n := len(content); ; n >>= 7 {
+		if n < 0x80 {
+			bs = append(bs, byte(n))
+			break
+		}
+		bs = append(bs, byte(n&0x7f)|0x80)
+	}
+	return append(bs, content...)
+}
+
+func wasmName(s string) []byte {
+	return append([]byte{byte(len(s))}, s...)
+}
+
+//wasmModuleWithABI builds a module exporting "hello" and "fallback"
+//as functions of () -> () and "bad" as a function of (i32) -> ().
+func wasmModuleWithABI(abi string) []byte {
+	bs := []byte{0, 'a', 's', 'm', 1, 0, 0, 0}
+	bs = append(bs, wasmSection(1, []byte{2, 0x60, 0, 0, 0x60, 1, 0x7f, 0})...)
+	bs = append(bs, wasmSection(3, []byte{3, 0, 0, 1})...)
+	var exports []byte
+	exports = append(exports, 3)
+	exports = append(append(exports, wasmName("hello")...), 0, 0)
+	exports = append(append(exports, wasmName("fallback")...), 0, 1)
+	exports = append(append(exports, wasmName("bad")...), 0, 2)
+	bs = append(bs, wasmSection(7, exports)...)
+	bs = append(bs, wasmSection(10, []byte{3, 2, 0, 0x0b, 2, 0, 0x0b, 2, 0, 0x0b})...)
+	custom := append(wasmName(WasmABISection), abi...)
+	return append(bs, wasmSection(0, custom)...)
+}
+
+func TestCompileWasm_ABI(t *testing.T) {
+	code, err := compileWasm(wasmModuleWithABI(`[
+		{"type":"function","name":"hello","readonly":"0x1",
+			"inputs":[
+				{"name":"to","type":"Address"},
+				{"name":"amount","type":"int","default":"0x10"},
+				{"name":"memo","type":"str","default":null}
+			],
+			"outputs":[{"type":"str"}]},
+		{"type":"fallback","name":"fallback","payable":true},
+		{"type":"eventlog","name":"Sent",
+			"inputs":[
+				{"name":"to","type":"Address","indexed":"0x1"},
+				{"name":"amount","type":"int"}
+			]}
+	]`))
+	assert.NoError(t, err)
+	info := code.info
+
+	m := info.GetMethod("hello")
+	if assert.NotNil(t, m) {
+		assert.True(t, m.IsReadOnly())
+		assert.True(t, m.IsExternal())
+		assert.Equal(t, 1, m.Indexed)
+		assert.Equal(t, []byte{0x10}, m.Inputs[1].Default)
+		assert.Nil(t, m.Inputs[2].Default)
+		assert.Equal(t, []scoreapi.DataType{scoreapi.String}, m.Outputs)
+	}
+
+	m = info.GetMethod(scoreapi.FallbackMethodName)
+	if assert.NotNil(t, m) {
+		assert.True(t, m.IsPayable())
+	}
+
+	for _, name := range []string{wasmInstall, wasmUpdate} {
+		m = info.GetMethod(name)
+		if assert.NotNil(t, m) {
+			assert.False(t, m.IsExternal())
+		}
+	}
+
+	var event *scoreapi.Method
+	for itr := info.MethodIterator(); itr.Has(); itr.Next() {
+		if m := itr.Get(); m.Type == scoreapi.Event {
+			event = m
+		}
+	}
+	if assert.NotNil(t, event) {
+		assert.Equal(t, "Sent(Address,int)", event.Signature())
+		assert.Equal(t, 1, event.Indexed)
+	}
+}
+
+func TestCompileWasm_InvalidABI(t *testing.T) {
+	cases := map[string]string{
+		"syntax":     `[`,
+		"noExport":   `[{"type":"function","name":"unknown"}]`,
+		"exportType": `[{"type":"function","name":"bad"}]`,
+		"methodType": `[{"type":"struct","name":"hello"}]`,
+		"paramType":  `[{"type":"function","name":"hello","inputs":[{"name":"a","type":"float"}]}]`,
+		"optionalOrder": `[{"type":"function","name":"hello","inputs":[
+			{"name":"a","type":"int","default":"0x1"},{"name":"b","type":"int"}]}]`,
+		"duplicate":  `[{"type":"function","name":"hello"},{"type":"function","name":"hello"}]`,
+		"outputs":    `[{"type":"function","name":"hello","outputs":[{"type":"int"},{"type":"int"}]}]`,
+		"indexOrder": `[{"type":"eventlog","name":"E","inputs":[{"name":"a","type":"int"},{"name":"b","type":"int","indexed":true}]}]`,
+	}
+	for name, abi := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := compileWasm(wasmModuleWithABI(abi))
+			assert.True(t, scoreresult.IllegalFormatError.Equals(err), "err=%+v", err)
+		})
+	}
+
+	_, err := compileWasm(wasmModuleWithABI("")[:0x30])
+	assert.Error(t, err)
+}
@@ -0,0 +1,579 @@
+package eeproxy
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"sync"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/common/intconv"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/scoreapi"
+	"github.com/icon-project/goloop/service/scoreresult"
+	"github.com/icon-project/goloop/service/trace"
+	"github.com/icon-project/goloop/service/wasm"
+)
+
+const (
+	WasmEE         = "wasm"
+	wasmHostModule = "icon"
+	wasmNotFound   = math.MaxUint32
+)
+
+//wasmProxy executes WebAssembly contracts in the process. Each invocation
+//runs on its own goroutine, and the results of inter-contract calls are
+//delivered to the frame on the top.
+type wasmProxy struct {
+	lock   sync.Mutex
+	frames []*wasmFrame
+	killed chan struct{}
+}
+
+type wasmCallResult struct {
+	status error
+	steps  *big.Int
+	result *codec.TypedObj
+}
+
+type wasmFrame struct {
+	proxy   *wasmProxy
+	ctx     CallContext
+	log     *trace.Logger
+	isQuery bool
+	from    module.Address
+	to      module.Address
+	value   *big.Int
+	limit   int64
+	method  *scoreapi.Method
+	code    *wasmCode
+	params  []byte
+	inst    *wasm.Instance
+	info    map[string]interface{}
+	result  *codec.TypedObj
+	ret     []byte
+	waiter  chan *wasmCallResult
+}
+
+func newWasmProxy() *wasmProxy {
+	return &wasmProxy{
+		killed: make(chan struct{}),
+	}
+}
+
+func (p *wasmProxy) Invoke(
+	ctx CallContext, code string, isQuery bool,
+	from, to module.Address, value, limit *big.Int, method string, params *codec.TypedObj,
+	cid []byte, eid int, state *CodeState,
+) error {
+	wc, err := loadWasmCode(code)
+	if err != nil {
+		return err
+	}
+	m := wc.info.GetMethod(method)
+	if m == nil || m.IsEvent() {
+		return scoreresult.MethodNotFoundError.Errorf("MethodNotFound(name=%s)", method)
+	}
+	f := &wasmFrame{
+		proxy:   p,
+		ctx:     ctx,
+		log:     trace.LoggerOf(ctx.Logger()),
+		isQuery: isQuery,
+		from:    from,
+		to:      to,
+		value:   value,
+		limit:   math.MaxInt64,
+		method:  m,
+		code:    wc,
+		waiter:  make(chan *wasmCallResult, 1),
+	}
+	if limit.IsInt64() {
+		f.limit = limit.Int64()
+	}
+	if params != nil {
+		jso, err := common.DecodeAnyForJSON(params)
+		if err != nil {
+			return scoreresult.InvalidParameterError.Wrap(err, "InvalidParams")
+		}
+		if f.params, err = json.Marshal(jso); err != nil {
+			return scoreresult.InvalidParameterError.Wrap(err, "InvalidParams")
+		}
+	}
+	f.log.Tracef("WasmProxy[%p].Invoke code=%s query=%v from=%v to=%v value=%v limit=%v method=%s",
+		p, code, isQuery, from, to, value, limit, method)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	select {
+	case <-p.killed:
+		return errors.ExecutionFailError.New("ProxyIsKilled")
+	default:
+	}
+	p.frames = append(p.frames, f)
+	go p.run(f)
+	return nil
+}
+
+func (p *wasmProxy) run(f *wasmFrame) {
+	status := f.execute()
+	var steps int64
+	if f.inst != nil {
+		steps = f.inst.StepUsed()
+	}
+
+	p.lock.Lock()
+	for i := len(p.frames) - 1; i >= 0; i-- {
+		if p.frames[i] == f {
+			p.frames = append(p.frames[:i], p.frames[i+1:]...)
+			break
+		}
+	}
+	p.lock.Unlock()
+
+	select {
+	case <-p.killed:
+		return
+	default:
+	}
+	if status != nil {
+		f.result = nil
+	}
+	f.ctx.OnResult(status, big.NewInt(steps), f.result)
+}
+
+func (p *wasmProxy) SendResult(ctx CallContext, status error, steps *big.Int, result *codec.TypedObj, eid int, last int) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if len(p.frames) == 0 {
+		return errors.InvalidStateError.New("NoFrameForResult")
+	}
+	f := p.frames[len(p.frames)-1]
+	select {
+	case f.waiter <- &wasmCallResult{status, steps, result}:
+		return nil
+	default:
+		return errors.InvalidStateError.New("UnexpectedResult")
+	}
+}
+
+func (p *wasmProxy) GetAPI(ctx CallContext, code string) error {
+	wc, err := loadWasmCode(code)
+	var info *scoreapi.Info
+	if wc != nil {
+		info = wc.info
+	}
+	go ctx.OnAPI(err, info)
+	return nil
+}
+
+func (p *wasmProxy) Release() {
+	// nothing to release
+}
+
+func (p *wasmProxy) Kill() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	select {
+	case <-p.killed:
+		return nil
+	default:
+	}
+	close(p.killed)
+	for _, f := range p.frames {
+		if f.inst != nil {
+			f.inst.Abort()
+		}
+	}
+	return nil
+}
+
+func (f *wasmFrame) execute() error {
+	inst, err := wasm.NewInstance(f.code.module, f.imports(), f.limit)
+	if err != nil {
+		return err
+	}
+	f.proxy.lock.Lock()
+	f.inst = inst
+	select {
+	case <-f.proxy.killed:
+		inst.Abort()
+	default:
+	}
+	f.proxy.lock.Unlock()
+
+	name := wasmExportOf(f.method.Name)
+	if _, ok := f.code.module.ExportedFunction(name); !ok && isWasmInternal(name) {
+		return nil
+	}
+	_, err = inst.Call(name)
+	return err
+}
+
+func (f *wasmFrame) getInfo() map[string]interface{} {
+	if f.info == nil {
+		if info, ok := common.MustDecodeAny(f.ctx.GetInfo()).(map[string]interface{}); ok {
+			f.info = info
+		} else {
+			f.info = make(map[string]interface{})
+		}
+	}
+	return f.info
+}
+
+func int64Of(v interface{}) int64 {
+	if i, ok := v.(*common.HexInt); ok && i.IsInt64() {
+		return i.Int64()
+	}
+	return 0
+}
+
+func (f *wasmFrame) stepsFor(t string, n int) int64 {
+	if costs, ok := f.getInfo()["StepCosts"].(map[string]interface{}); ok {
+		return int64Of(costs[t]) * int64(n)
+	}
+	return 0
+}
+
+func (f *wasmFrame) useSteps(inst *wasm.Instance, steps ...int64) error {
+	var sum int64
+	for _, s := range steps {
+		sum += s
+	}
+	return inst.UseSteps(sum)
+}
+
+type hostCall func(inst *wasm.Instance, args []uint64) ([]uint64, error)
+
+func hostFunc(params, results []wasm.ValueType, call hostCall) *wasm.HostFunc {
+	return &wasm.HostFunc{
+		Type: wasm.FuncType{Params: params, Results: results},
+		Call: call,
+	}
+}
+
+var (
+	wasmNone  []wasm.ValueType
+	wasmI32   = []wasm.ValueType{wasm.I32}
+	wasmI32x2 = []wasm.ValueType{wasm.I32, wasm.I32}
+	wasmI32x3 = []wasm.ValueType{wasm.I32, wasm.I32, wasm.I32}
+	wasmI32x4 = []wasm.ValueType{wasm.I32, wasm.I32, wasm.I32, wasm.I32}
+	wasmI64   = []wasm.ValueType{wasm.I64}
+)
+
+func (f *wasmFrame) imports() wasm.Imports {
+	return wasm.Imports{
+		wasmHostModule: {
+			"get_params":     hostFunc(wasmI32x2, wasmI32, f.getParams),
+			"set_result":     hostFunc(wasmI32x2, wasmNone, f.setResult),
+			"revert":         hostFunc(wasmI32x3, wasmNone, f.revert),
+			"log":            hostFunc(wasmI32x2, wasmNone, f.logMessage),
+			"storage_get":    hostFunc(wasmI32x4, wasmI32, f.storageGet),
+			"storage_set":    hostFunc(wasmI32x4, wasmNone, f.storageSet),
+			"storage_delete": hostFunc(wasmI32x2, wasmNone, f.storageDelete),
+			"event":          hostFunc(wasmI32x2, wasmNone, f.event),
+			"call":           hostFunc(wasmI32x2, wasmI32, f.call),
+			"get_return":     hostFunc(wasmI32x2, wasmI32, f.getReturn),
+			"get_caller":     hostFunc(wasmI32x2, wasmI32, f.getCaller),
+			"get_address":    hostFunc(wasmI32x2, wasmI32, f.getAddress),
+			"get_value":      hostFunc(wasmI32x2, wasmI32, f.getValue),
+			"get_balance":    hostFunc(wasmI32x4, wasmI32, f.getBalance),
+			"get_height":     hostFunc(wasmNone, wasmI64, f.getHeight),
+			"get_timestamp":  hostFunc(wasmNone, wasmI64, f.getTimestamp),
+		},
+	}
+}
+
+//output writes bs if it fits in the buffer, then returns the size of bs.
+//So the caller can retry with the larger buffer.
+func output(inst *wasm.Instance, ptr, size uint64, bs []byte) ([]uint64, error) {
+	if uint64(len(bs)) <= uint64(uint32(size)) {
+		if err := inst.WriteBytes(uint32(ptr), bs); err != nil {
+			return nil, err
+		}
+	}
+	return []uint64{uint64(len(bs))}, nil
+}
+
+func input(inst *wasm.Instance, ptr, size uint64) ([]byte, error) {
+	return inst.ReadBytes(uint32(ptr), uint32(size))
+}
+
+func (f *wasmFrame) getParams(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	params := f.params
+	if params == nil {
+		params = []byte("[]")
+	}
+	return output(inst, args[0], args[1], params)
+}
+
+func (f *wasmFrame) setResult(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	bs, err := input(inst, args[0], args[1])
+	if err != nil {
+		return nil, err
+	}
+	if len(f.method.Outputs) == 0 {
+		return nil, scoreresult.UnknownFailureError.Errorf(
+			"NoOutput(method=%s)", f.method.Name)
+	}
+	obj, err := f.method.Outputs[0].ConvertJSONToTypedObj(bs, nil, true)
+	if err != nil {
+		return nil, scoreresult.UnknownFailureError.Wrap(err, "InvalidResult")
+	}
+	f.result = obj
+	return nil, nil
+}
+
+func (f *wasmFrame) revert(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	msg, err := input(inst, args[1], args[2])
+	if err != nil {
+		return nil, err
+	}
+	code := uint32(args[0])
+	if code > uint32(module.StatusLimit-module.StatusReverted) {
+		code = uint32(module.StatusLimit - module.StatusReverted)
+	}
+	return nil, scoreresult.New(module.StatusReverted+module.Status(code), string(msg))
+}
+
+func (f *wasmFrame) logMessage(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	msg, err := input(inst, args[0], args[1])
+	if err != nil {
+		return nil, err
+	}
+	f.log.TLog(module.TDebugLevel, string(msg))
+	f.log.Log(log.DebugLevel, WasmEE, "|", common.StrLeft(10, f.to.String()), "|", string(msg))
+	return nil, nil
+}
+
+func (f *wasmFrame) storageGet(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	key, err := input(inst, args[0], args[1])
+	if err != nil {
+		return nil, err
+	}
+	value, err := f.ctx.GetValue(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.useSteps(inst, f.stepsFor("defaultGet", 1), f.stepsFor("get", len(value))); err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return []uint64{wasmNotFound}, nil
+	}
+	return output(inst, args[2], args[3], value)
+}
+
+func (f *wasmFrame) storageSet(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	key, err := input(inst, args[0], args[1])
+	if err != nil {
+		return nil, err
+	}
+	value, err := input(inst, args[2], args[3])
+	if err != nil {
+		return nil, err
+	}
+	old, err := f.ctx.SetValue(key, value)
+	if err != nil {
+		return nil, err
+	}
+	if old == nil {
+		err = f.useSteps(inst, f.stepsFor("defaultSet", 1), f.stepsFor("set", len(value)))
+	} else {
+		err = f.useSteps(inst, f.stepsFor("replaceBase", 1), f.stepsFor("replace", len(value)))
+	}
+	return nil, err
+}
+
+func (f *wasmFrame) storageDelete(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	key, err := input(inst, args[0], args[1])
+	if err != nil {
+		return nil, err
+	}
+	old, err := f.ctx.DeleteValue(key)
+	if err != nil {
+		return nil, err
+	}
+	return nil, f.useSteps(inst, f.stepsFor("defaultDelete", 1), f.stepsFor("delete", len(old)))
+}
+
+type wasmEvent struct {
+	Event  string            `json:"event"`
+	Values []json.RawMessage `json:"values"`
+}
+
+//event emits the event declared in ABI. The values are given in JSON
+//{"event":<name>,"values":[<value>...]}
+func (f *wasmFrame) event(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	if f.isQuery {
+		return nil, scoreresult.AccessDeniedError.New("EventInQuery")
+	}
+	bs, err := input(inst, args[0], args[1])
+	if err != nil {
+		return nil, err
+	}
+	var e wasmEvent
+	if err := json.Unmarshal(bs, &e); err != nil {
+		return nil, scoreresult.InvalidParameterError.Wrap(err, "InvalidEvent")
+	}
+	var em *scoreapi.Method
+	for it := f.code.info.MethodIterator(); it.Has(); it.Next() {
+		if m := it.Get(); m.IsEvent() && m.Name == e.Event {
+			em = m
+			break
+		}
+	}
+	if em == nil {
+		return nil, scoreresult.InvalidParameterError.Errorf("UnknownEvent(name=%s)", e.Event)
+	}
+	if len(e.Values) != len(em.Inputs) {
+		return nil, scoreresult.InvalidParameterError.Errorf(
+			"InvalidEventValues(event=%s,exp=%d,real=%d)", e.Event, len(em.Inputs), len(e.Values))
+	}
+	indexed := [][]byte{[]byte(em.Signature())}
+	var data [][]byte
+	size := len(indexed[0])
+	for i, p := range em.Inputs {
+		v, err := wasmBytesOf(p.Type, e.Values[i])
+		if err != nil {
+			return nil, err
+		}
+		size += len(v)
+		if i < em.Indexed {
+			indexed = append(indexed, v)
+		} else {
+			data = append(data, v)
+		}
+	}
+	if err := f.useSteps(inst, f.stepsFor("eventLogBase", 1), f.stepsFor("eventLog", size)); err != nil {
+		return nil, err
+	}
+	return nil, f.ctx.OnEvent(f.to, indexed, data)
+}
+
+type wasmCallParam struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+type wasmCall struct {
+	To     common.Address  `json:"to"`
+	Value  *common.HexInt  `json:"value"`
+	Method string          `json:"method"`
+	Params []wasmCallParam `json:"params"`
+}
+
+//call invokes the method of other contract or transfers coin, then returns
+//the size of the result in JSON, which can be read by get_return. The call
+//is given in JSON {"to":<address>,"value":<int>,"method":<name>,
+//"params":[{"type":<type>,"value":<value>}...]}
+func (f *wasmFrame) call(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	bs, err := input(inst, args[0], args[1])
+	if err != nil {
+		return nil, err
+	}
+	var c wasmCall
+	if err := json.Unmarshal(bs, &c); err != nil {
+		return nil, scoreresult.InvalidParameterError.Wrap(err, "InvalidCall")
+	}
+	value := new(big.Int)
+	if c.Value != nil {
+		value.Set(&c.Value.Int)
+	}
+	if f.isQuery && value.Sign() != 0 {
+		return nil, scoreresult.AccessDeniedError.New("TransferInQuery")
+	}
+	params := make([]*codec.TypedObj, len(c.Params))
+	for i, p := range c.Params {
+		t := scoreapi.DataTypeOf(p.Type)
+		if t == scoreapi.Unknown {
+			return nil, scoreresult.InvalidParameterError.Errorf("InvalidType(type=%s)", p.Type)
+		}
+		if params[i], err = t.ConvertJSONToTypedObj(p.Value, nil, true); err != nil {
+			return nil, err
+		}
+	}
+	data, err := common.EncodeAny(map[string]interface{}{
+		"method": c.Method,
+		"params": params,
+	})
+	if err != nil {
+		return nil, scoreresult.InvalidParameterError.Wrap(err, "InvalidCall")
+	}
+	limit := big.NewInt(inst.StepLimit() - inst.StepUsed())
+	f.ctx.OnCall(f.to, &c.To, value, limit, "call", data)
+
+	var r *wasmCallResult
+	select {
+	case r = <-f.waiter:
+	case <-f.proxy.killed:
+		return nil, errors.ExecutionFailError.New("ProxyIsKilled")
+	}
+	if r.steps != nil {
+		if err := inst.UseSteps(r.steps.Int64()); err != nil {
+			return nil, err
+		}
+	}
+	if r.status != nil {
+		return nil, r.status
+	}
+	f.ret = nil
+	if r.result != nil && r.result.Type != codec.TypeNil {
+		jso, err := common.DecodeAnyForJSON(r.result)
+		if err != nil {
+			return nil, scoreresult.UnknownFailureError.Wrap(err, "InvalidReturn")
+		}
+		if f.ret, err = json.Marshal(jso); err != nil {
+			return nil, scoreresult.UnknownFailureError.Wrap(err, "InvalidReturn")
+		}
+	}
+	return []uint64{uint64(len(f.ret))}, nil
+}
+
+func (f *wasmFrame) getReturn(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	return output(inst, args[0], args[1], f.ret)
+}
+
+func addressBytes(addr module.Address) []byte {
+	if addr == nil {
+		return nil
+	}
+	return []byte(addr.String())
+}
+
+func (f *wasmFrame) getCaller(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	return output(inst, args[0], args[1], addressBytes(f.from))
+}
+
+func (f *wasmFrame) getAddress(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	return output(inst, args[0], args[1], addressBytes(f.to))
+}
+
+func (f *wasmFrame) getValue(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	return output(inst, args[0], args[1], []byte(intconv.FormatBigInt(f.value)))
+}
+
+func (f *wasmFrame) getBalance(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	bs, err := input(inst, args[0], args[1])
+	if err != nil {
+		return nil, err
+	}
+	addr, err := common.NewAddressFromString(string(bs))
+	if err != nil {
+		return nil, scoreresult.InvalidParameterError.Wrapf(err, "InvalidAddress(%s)", bs)
+	}
+	balance := f.ctx.GetBalance(addr)
+	return output(inst, args[2], args[3], []byte(intconv.FormatBigInt(balance)))
+}
+
+func (f *wasmFrame) getHeight(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	return []uint64{uint64(int64Of(f.getInfo()["B.height"]))}, nil
+}
+
+func (f *wasmFrame) getTimestamp(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+	return []uint64{uint64(int64Of(f.getInfo()["B.timestamp"]))}, nil
+}
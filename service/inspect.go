@@ -19,9 +19,20 @@ func Inspect(c module.Chain, informal bool) map[string]interface{} {
 	m["normalTxPool"] = inspectTxPool(mgr.tm.normalTxPool)
 	m["patchTxPool"] = inspectTxPool(mgr.tm.patchTxPool)
 	m["resultCache"] = inspectResultCache(mgr.trc)
+	if pi, ok := mgr.plt.(platformInspector); ok {
+		if pm := pi.Inspect(informal); pm != nil {
+			m["platform"] = pm
+		}
+	}
 	return m
 }
 
+// platformInspector is implemented by the platform having its own
+// information to inspect.
+type platformInspector interface {
+	Inspect(informal bool) map[string]interface{}
+}
+
 func inspectResultCache(tsc *transitionResultCache) map[string]interface{} {
 	m := make(map[string]interface{})
 	m["used"] = tsc.Count()
@@ -54,6 +54,10 @@ type manager struct {
 	log log.Logger
 
 	skipTxPatch atomic.Value
+
+	// finalized is the last finalized transition, which is used to
+	// validate transactions on admission to the pool.
+	finalized atomic.Value
 }
 
 func NewManager(chain module.Chain, nm module.NetworkManager,
@@ -100,6 +104,7 @@ func NewManager(chain module.Chain, nm module.NetworkManager,
 		log: logger,
 		tsc: tsc,
 	}
+	tm.SetValidator(mgr.validateTransaction)
 	if nm != nil {
 		mgr.txReactor = NewTransactionReactor(nm, tm)
 	}
@@ -141,7 +146,17 @@ func (m *manager) ProposeTransition(parent module.Transition, bi module.BlockInf
 	}
 	maxTxCount := m.chain.Regulator().MaxTxCount()
 	txSizeInBlock := m.chain.MaxBlockTxBytes()
-	normalTxs, _ := m.tm.Candidate(module.TransactionGroupNormal, wc, txSizeInBlock, maxTxCount)
+	scheduledTxs, err := transaction.NewScheduledTransactions(wc)
+	if err != nil {
+		return nil, err
+	}
+	// transactions from contract accounts are validated by the accounts
+	// with the context for execution.
+	ctx := contract.NewContext(wc, m.cm, m.eem, m.chain, m.log, nil)
+	normalTxs, _ := m.tm.Candidate(module.TransactionGroupNormal, ctx, txSizeInBlock, maxTxCount)
+	if len(scheduledTxs) > 0 {
+		normalTxs = append(scheduledTxs, normalTxs...)
+	}
 	if baseTx != nil {
 		normalTxs = append([]module.Transaction{baseTx}, normalTxs...)
 	}
@@ -296,6 +311,7 @@ func (m *manager) Finalize(t module.Transition, opt int) error {
 			if err := tst.finalizeResult(false, keepParent); err != nil {
 				return err
 			}
+			m.finalized.Store(tst)
 			m.tm.NotifyFinalized(tst.patchTransactions, tst.patchReceipts, tst.normalTransactions, tst.normalReceipts)
 			now := time.Now()
 			m.patchMetric.OnFinalize(tst.patchTransactions.Hash(), now)
@@ -390,6 +406,28 @@ func newTransaction(txi interface{}) (transaction.Transaction, error) {
 	}
 }
 
+// validateTransaction validates transactions sent by contract accounts with
+// the state of the last finalized block, so that the accounts authorize them
+// before they are added to the pool. Other transactions are validated on
+// selecting candidates from the pool.
+func (m *manager) validateTransaction(tx transaction.Transaction) error {
+	if !transaction.IsAccountTransaction(tx) {
+		return nil
+	}
+	tr, _ := m.finalized.Load().(*transition)
+	if tr == nil || tr.bi == nil {
+		return nil
+	}
+	ws, err := state.WorldStateFromSnapshot(tr.worldSnapshot)
+	if err != nil {
+		return err
+	}
+	bi := common.NewBlockInfo(tr.bi.Height()+1, tr.bi.Timestamp())
+	wc := state.NewWorldContext(ws, bi, nil, m.plt)
+	ctx := contract.NewContext(wc, m.cm, m.eem, m.chain, m.log, nil)
+	return tx.PreValidate(ctx, false)
+}
+
 func (m *manager) SendTransactionAndWait(txi interface{}) ([]byte, <-chan interface{}, error) {
 	newTx, err := newTransaction(txi)
 	if err != nil {
@@ -435,9 +473,10 @@ func (m *manager) Call(resultHash []byte,
 	vl module.ValidatorList, js []byte, bi module.BlockInfo,
 ) (interface{}, error) {
 	type callJSON struct {
-		To       common.Address  `json:"to"`
-		DataType *string         `json:"dataType"`
-		Data     json.RawMessage `json:"data"`
+		To        common.Address       `json:"to"`
+		DataType  *string              `json:"dataType"`
+		Data      json.RawMessage      `json:"data"`
+		Overrides state.StateOverrides `json:"overrides"`
 	}
 
 	var jso callJSON
@@ -450,6 +489,16 @@ func (m *manager) Call(resultHash []byte,
 
 	var wc state.WorldContext
 	if wss, err := m.trc.GetWorldSnapshot(resultHash, vl.Hash()); err == nil {
+		if len(jso.Overrides) > 0 {
+			ws, err := state.WorldStateFromSnapshot(wss)
+			if err != nil {
+				return nil, err
+			}
+			if err := jso.Overrides.Apply(ws); err != nil {
+				return nil, err
+			}
+			wss = ws.GetSnapshot()
+		}
 		ws := state.NewReadOnlyWorldState(wss)
 		wc = state.NewWorldContext(ws, bi, nil, m.plt)
 	} else {
@@ -641,6 +690,20 @@ func (m *manager) ImportResult(result []byte, vh []byte, src db.Database) error
 }
 
 func (m *manager) ExecuteTransaction(result []byte, vh []byte, js []byte, bi module.BlockInfo) (module.Receipt, error) {
+	return m.executeTransaction(result, vh, js, bi, nil)
+}
+
+func (m *manager) EstimateTransaction(result []byte, vh []byte, js []byte, bi module.BlockInfo) (module.StepEstimation, error) {
+	e := new(stepEstimation)
+	rct, err := m.executeTransaction(result, vh, js, bi, e)
+	if err != nil {
+		return nil, err
+	}
+	e.receipt = rct
+	return e, nil
+}
+
+func (m *manager) executeTransaction(result []byte, vh []byte, js []byte, bi module.BlockInfo, e *stepEstimation) (module.Receipt, error) {
 	tx, err := transaction.NewTransactionFromJSON(js)
 	if err != nil {
 		return nil, err
@@ -648,6 +711,12 @@ func (m *manager) ExecuteTransaction(result []byte, vh []byte, js []byte, bi mod
 	if err := tx.Verify(); err != nil && !transaction.InvalidSignatureError.Equals(err) {
 		return nil, scoreresult.InvalidParameterError.Wrap(err, "InvalidTransaction")
 	}
+	var jso struct {
+		Overrides state.StateOverrides `json:"overrides"`
+	}
+	if err := json.Unmarshal(js, &jso); err != nil {
+		return nil, scoreresult.InvalidParameterError.Wrap(err, "InvalidOverrides")
+	}
 
 	txh, err := tx.GetHandler(m.cm)
 	if err != nil {
@@ -661,11 +730,22 @@ func (m *manager) ExecuteTransaction(result []byte, vh []byte, js []byte, bi mod
 		if err != nil {
 			return nil, err
 		}
+		if err := jso.Overrides.Apply(ws); err != nil {
+			return nil, err
+		}
+		if e != nil {
+			e.ws = state.NewTrackingWorldState(ws)
+			ws = e.ws
+		}
 		wc = state.NewWorldContext(ws, bi, nil, m.plt)
 	} else {
 		return nil, err
 	}
 	ctx := contract.NewContext(wc, m.cm, m.eem, m.chain, m.log, nil)
+	if e != nil {
+		e.tracker = contract.NewStepTracker()
+		ctx.SetProperty(contract.PropStepTracker, e.tracker)
+	}
 	ctx.SetTransactionInfo(&state.TransactionInfo{
 		Group:     module.TransactionGroupNormal,
 		Index:     0,
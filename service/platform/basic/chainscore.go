@@ -489,6 +489,98 @@ var chainMethods = []*chainMethod{
 			scoreapi.Bool,
 		},
 	}, Revision8, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "setDeployValidatorEnabled",
+		scoreapi.FlagExternal, 2,
+		[]scoreapi.Parameter{
+			{"name", scoreapi.String, nil, nil},
+			{"yn", scoreapi.Bool, nil, nil},
+		},
+		nil,
+	}, Revision9, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "getDeployValidators",
+		scoreapi.FlagReadOnly | scoreapi.FlagExternal, 0,
+		nil,
+		[]scoreapi.DataType{
+			scoreapi.List,
+		},
+	}, Revision9, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "setMaxCodeSize",
+		scoreapi.FlagExternal, 2,
+		[]scoreapi.Parameter{
+			{"type", scoreapi.String, nil, nil},
+			{"size", scoreapi.Integer, nil, nil},
+		},
+		nil,
+	}, Revision9, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "getMaxCodeSize",
+		scoreapi.FlagReadOnly | scoreapi.FlagExternal, 1,
+		[]scoreapi.Parameter{
+			{"type", scoreapi.String, nil, nil},
+		},
+		[]scoreapi.DataType{
+			scoreapi.Integer,
+		},
+	}, Revision9, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "addForbiddenAPI",
+		scoreapi.FlagExternal, 1,
+		[]scoreapi.Parameter{
+			{"api", scoreapi.String, nil, nil},
+		},
+		nil,
+	}, Revision9, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "removeForbiddenAPI",
+		scoreapi.FlagExternal, 1,
+		[]scoreapi.Parameter{
+			{"api", scoreapi.String, nil, nil},
+		},
+		nil,
+	}, Revision9, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "getForbiddenAPIs",
+		scoreapi.FlagReadOnly | scoreapi.FlagExternal, 0,
+		nil,
+		[]scoreapi.DataType{
+			scoreapi.List,
+		},
+	}, Revision9, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "scheduleCall",
+		scoreapi.FlagExternal, 4,
+		[]scoreapi.Parameter{
+			{"height", scoreapi.Integer, nil, nil},
+			{"to", scoreapi.Address, nil, nil},
+			{"method", scoreapi.String, nil, nil},
+			{"stepLimit", scoreapi.Integer, nil, nil},
+			{"params", scoreapi.String, nil, nil},
+		},
+		[]scoreapi.DataType{
+			scoreapi.Integer,
+		},
+	}, Revision9, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "cancelScheduledCall",
+		scoreapi.FlagExternal, 1,
+		[]scoreapi.Parameter{
+			{"id", scoreapi.Integer, nil, nil},
+		},
+		nil,
+	}, Revision9, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "getScheduledCall",
+		scoreapi.FlagReadOnly | scoreapi.FlagExternal, 1,
+		[]scoreapi.Parameter{
+			{"id", scoreapi.Integer, nil, nil},
+		},
+		[]scoreapi.DataType{
+			scoreapi.Dict,
+		},
+	}, Revision9, 0},
 }
 
 func (s *ChainScore) GetAPI() *scoreapi.Info {
@@ -1392,3 +1484,120 @@ func (s *ChainScore) Ex_setMinimizeBlockGen(b bool) error {
 	mbg := scoredb.NewVarDB(as, state.VarMinimizeBlockGen)
 	return mbg.Set(b)
 }
+
+func (s *ChainScore) Ex_setDeployValidatorEnabled(name string, yn bool) error {
+	if err := s.checkGovernance(true); err != nil {
+		return err
+	}
+	if !contract.IsDeployValidator(name) {
+		return scoreresult.New(StatusIllegalArgument, "UnknownDeployValidator")
+	}
+	as := s.cc.GetAccountState(state.SystemID)
+	return contract.SetDeployValidatorEnabled(as, name, yn)
+}
+
+func (s *ChainScore) Ex_getDeployValidators() ([]interface{}, error) {
+	if err := s.tryChargeCall(); err != nil {
+		return nil, err
+	}
+	as := s.cc.GetAccountState(state.SystemID)
+	return contract.GetDeployValidators(as), nil
+}
+
+func (s *ChainScore) Ex_setMaxCodeSize(eeType string, size *common.HexInt) error {
+	if err := s.checkGovernance(true); err != nil {
+		return err
+	}
+	if !state.ValidateEEType(state.EEType(eeType)) || size.Sign() < 0 {
+		return scoreresult.New(StatusIllegalArgument, "IllegalArgument")
+	}
+	as := s.cc.GetAccountState(state.SystemID)
+	return contract.SetMaxCodeSize(as, eeType, size.Value())
+}
+
+func (s *ChainScore) Ex_getMaxCodeSize(eeType string) (int64, error) {
+	if err := s.tryChargeCall(); err != nil {
+		return 0, err
+	}
+	as := s.cc.GetAccountState(state.SystemID)
+	return contract.GetMaxCodeSize(as, eeType), nil
+}
+
+func (s *ChainScore) Ex_addForbiddenAPI(api string) error {
+	if err := s.checkGovernance(true); err != nil {
+		return err
+	}
+	if len(api) == 0 {
+		return scoreresult.New(StatusIllegalArgument, "IllegalArgument")
+	}
+	as := s.cc.GetAccountState(state.SystemID)
+	return contract.AddForbiddenAPI(as, api)
+}
+
+func (s *ChainScore) Ex_removeForbiddenAPI(api string) error {
+	if err := s.checkGovernance(true); err != nil {
+		return err
+	}
+	as := s.cc.GetAccountState(state.SystemID)
+	return contract.RemoveForbiddenAPI(as, api)
+}
+
+func (s *ChainScore) Ex_getForbiddenAPIs() ([]interface{}, error) {
+	if err := s.tryChargeCall(); err != nil {
+		return nil, err
+	}
+	as := s.cc.GetAccountState(state.SystemID)
+	return contract.GetForbiddenAPIs(as), nil
+}
+
+// Ex_scheduleCall registers the call to the SCORE to be executed at the
+// start of the block at the height, and returns ID of the call. The fee for
+// the step limit is paid on registration. Up to
+// contract.MaxScheduledCallsPerBlock calls are executed in a block, so the
+// call may be delayed to following blocks if there are more calls at the
+// height. The receipt of the call has the event log,
+// ScheduledCallExecuted(int,int,int) with the ID, the scheduled height and
+// the executed height.
+func (s *ChainScore) Ex_scheduleCall(height *common.HexInt, to module.Address, method string,
+	stepLimit *common.HexInt, params *string,
+) (int64, error) {
+	if err := s.tryChargeCall(); err != nil {
+		return 0, err
+	}
+	if !height.IsInt64() {
+		return 0, scoreresult.New(StatusIllegalArgument, "IllegalArgument")
+	}
+	var data []byte
+	if params != nil {
+		data = []byte(*params)
+	}
+	return contract.ScheduleCall(s.cc, s.from, height.Int64(), to, method, data, &stepLimit.Int)
+}
+
+func (s *ChainScore) Ex_cancelScheduledCall(id *common.HexInt) error {
+	if err := s.tryChargeCall(); err != nil {
+		return err
+	}
+	if !id.IsInt64() {
+		return scoreresult.New(StatusIllegalArgument, "IllegalArgument")
+	}
+	return contract.CancelScheduledCall(s.cc, s.from, id.Int64())
+}
+
+func (s *ChainScore) Ex_getScheduledCall(id *common.HexInt) (map[string]interface{}, error) {
+	if err := s.tryChargeCall(); err != nil {
+		return nil, err
+	}
+	if !id.IsInt64() {
+		return nil, scoreresult.New(StatusIllegalArgument, "IllegalArgument")
+	}
+	as := s.cc.GetAccountState(state.SystemID)
+	sc, err := contract.GetScheduledCall(as, id.Int64())
+	if err != nil {
+		return nil, err
+	}
+	if sc == nil {
+		return nil, scoreresult.New(StatusNotFound, "NotFound")
+	}
+	return sc.ToMap(), nil
+}
@@ -27,13 +27,14 @@ const (
 	Revision6
 	Revision7
 	Revision8
+	Revision9
 	RevisionReserved
 )
 
 const (
 	DefaultRevision = Revision4
 	MaxRevision     = RevisionReserved - 1
-	LatestRevision  = Revision8
+	LatestRevision  = Revision9
 )
 
 var revisionFlags = []module.Revision{
@@ -46,6 +47,8 @@ var revisionFlags = []module.Revision{
 	module.ExpandErrorCode,
 	module.UseChainID | module.UseMPTOnEvents,
 	module.UseCompactAPIInfo,
+	module.UseWasmEE | module.UseScheduledCall | module.UseAccountTransaction |
+		module.UseMultiSigTransaction | module.UseBatchTransaction,
 }
 
 func init() {
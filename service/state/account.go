@@ -803,6 +803,22 @@ func (s *accountStateImpl) Reset(isnapshot AccountSnapshot) error {
 	return nil
 }
 
+// resetMeta resets the account with the snapshot except for the storage.
+func (s *accountStateImpl) resetMeta(snapshot *accountSnapshotImpl) {
+	s.markDirty()
+
+	s.balance = snapshot.balance
+	s.isContract = snapshot.fIsContract
+	s.version = snapshot.version
+	s.apiInfo = snapshot.apiInfo
+	s.state = snapshot.state
+	s.contractOwner = snapshot.contractOwner
+	s.curContract = newContractState(snapshot.curContract, s.markDirty)
+	s.nextContract = newContractState(snapshot.nextContract, s.markDirty)
+	s.objCache = snapshot.objCache.Clone()
+	s.deposits = snapshot.deposits.Clone()
+}
+
 func (s *accountStateImpl) Clear() {
 	*s = accountStateImpl{
 		key:      s.key,
@@ -34,6 +34,10 @@ const (
 const (
 	StepLimitTypeInvoke = "invoke"
 	StepLimitTypeQuery  = "query"
+
+	// StepLimitTypeValidate limits steps for validating transactions by
+	// contract accounts. It falls back to the limit for query if it's not set.
+	StepLimitTypeValidate = "validate"
 )
 
 var AllStepLimitTypes = []string{
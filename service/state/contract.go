@@ -49,6 +49,7 @@ const (
 	CTAppZip    = "application/zip"
 	CTAppJava   = "application/java"
 	CTAppSystem = "application/x.score.system"
+	CTAppWasm   = "application/wasm"
 )
 
 type ContractSnapshot interface {
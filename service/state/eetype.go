@@ -15,6 +15,7 @@ const (
 	PythonEE EEType = "python"
 	JavaEE   EEType = "java"
 	SystemEE EEType = "system"
+	WasmEE   EEType = "wasm"
 )
 
 const (
@@ -26,11 +27,13 @@ var (
 		PythonEE: "on_install",
 		JavaEE:   "<init>",
 		SystemEE: "<Install>",
+		WasmEE:   "on_install",
 	}
 	updateMethods = map[EEType]string{
 		PythonEE: "on_update",
 		JavaEE:   "<init>",
 		SystemEE: "<Update>",
+		WasmEE:   "on_update",
 	}
 	allowUpdateFromTo = map[EEType]map[EEType]bool{
 		PythonEE: {
@@ -40,6 +43,9 @@ var (
 		JavaEE: {
 			JavaEE: true,
 		},
+		WasmEE: {
+			WasmEE: true,
+		},
 	}
 	needAudit = map[EEType]bool{
 		PythonEE: true,
@@ -106,6 +112,8 @@ func EETypeFromContentType(ct string) (EEType, bool) {
 		return JavaEE, true
 	case CTAppSystem:
 		return SystemEE, true
+	case CTAppWasm:
+		return WasmEE, true
 	default:
 		return NullEE, false
 	}
@@ -121,7 +129,7 @@ func MustEETypeFromContentType(ct string) EEType {
 
 func ValidateEEType(et EEType) bool {
 	switch et {
-	case PythonEE, JavaEE, SystemEE:
+	case PythonEE, JavaEE, SystemEE, WasmEE:
 		return true
 	default:
 		return false
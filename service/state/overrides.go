@@ -0,0 +1,191 @@
+package state
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/scoredb"
+	"github.com/icon-project/goloop/service/scoreresult"
+)
+
+const (
+	OverrideMaxAccounts = 32
+	OverrideMaxStorage  = 256
+)
+
+const (
+	StorageTypeVar  = "var"
+	StorageTypeDict = "dict"
+)
+
+// StorageOverride is a change of an item in the storage. The item is
+// specified with the raw key, or with the type and the name of the
+// container (VarDB or DictDB) with keys for DictDB. Keys of DictDB are
+// strings where addresses are converted to the bytes of them and "0x"
+// prefixed ones are decoded as bytes. Empty value removes the item.
+type StorageOverride struct {
+	Key   common.HexBytes `json:"key,omitempty"`
+	Type  string          `json:"type,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Keys  []string        `json:"keys,omitempty"`
+	Value common.HexBytes `json:"value"`
+}
+
+func dictKeyOf(s string) interface{} {
+	if strings.HasPrefix(s, "hx") || strings.HasPrefix(s, "cx") {
+		if addr, err := common.NewAddressFromString(s); err == nil {
+			return addr
+		}
+	}
+	if strings.HasPrefix(s, "0x") {
+		if bs, err := hex.DecodeString(s[2:]); err == nil {
+			return bs
+		}
+	}
+	return s
+}
+
+func (o *StorageOverride) Apply(as AccountState) error {
+	if len(o.Key) > 0 {
+		if len(o.Type) > 0 || len(o.Name) > 0 || len(o.Keys) > 0 {
+			return scoreresult.InvalidParameterError.New("KeyWithContainer")
+		}
+		if len(o.Value) == 0 {
+			_, err := as.DeleteValue(o.Key)
+			return err
+		}
+		_, err := as.SetValue(o.Key, o.Value)
+		return err
+	}
+	if len(o.Name) == 0 {
+		return scoreresult.InvalidParameterError.New("NoKeyOrName")
+	}
+	switch o.Type {
+	case StorageTypeVar:
+		if len(o.Keys) > 0 {
+			return scoreresult.InvalidParameterError.New("KeysForVarDB")
+		}
+		db := scoredb.NewVarDB(as, o.Name)
+		if len(o.Value) == 0 {
+			_, err := db.Delete()
+			return err
+		}
+		return db.Set([]byte(o.Value))
+	case StorageTypeDict:
+		if len(o.Keys) == 0 {
+			return scoreresult.InvalidParameterError.New("NoKeysForDictDB")
+		}
+		keys := make([]interface{}, len(o.Keys), len(o.Keys)+1)
+		for i, k := range o.Keys {
+			keys[i] = dictKeyOf(k)
+		}
+		db := scoredb.NewDictDB(as, o.Name, len(keys))
+		if len(o.Value) == 0 {
+			return db.Delete(keys...)
+		}
+		return db.Set(append(keys, []byte(o.Value))...)
+	default:
+		return scoreresult.InvalidParameterError.Errorf(
+			"InvalidStorageType(type=%s)", o.Type)
+	}
+}
+
+// AccountOverride is changes of an account. CodeFrom makes the account
+// a contract running the code of the specified contract.
+type AccountOverride struct {
+	Balance  *common.HexInt     `json:"balance,omitempty"`
+	Storage  []*StorageOverride `json:"storage,omitempty"`
+	CodeFrom *common.Address    `json:"codeFrom,omitempty"`
+}
+
+func copyContract(ws WorldState, addr, from module.Address) error {
+	if !addr.IsContract() {
+		return scoreresult.InvalidParameterError.Errorf(
+			"CodeForAccount(addr=%s)", addr)
+	}
+	src := ws.GetAccountState(from.ID())
+	c := src.ActiveContract()
+	if c == nil {
+		return scoreresult.ContractNotFoundError.Errorf(
+			"NoActiveContract(addr=%s)", from)
+	}
+	code, err := c.Code()
+	if err != nil {
+		return err
+	}
+	info, err := src.APIInfo()
+	if err != nil {
+		return err
+	}
+
+	as := ws.GetAccountState(addr.ID())
+	as.InitContractAccount(src.ContractOwner())
+	if _, err := as.DeployContract(code, c.EEType(), c.ContentType(),
+		c.Params(), c.DeployTxHash()); err != nil {
+		return err
+	}
+	if err := as.AcceptContract(c.DeployTxHash(), c.AuditTxHash()); err != nil {
+		return err
+	}
+	as.SetAPIInfo(info)
+	if nextHash, _, graph, err := src.GetObjGraph(c.CodeID(), true); err == nil && graph != nil {
+		if err := as.SetObjGraph(c.CodeID(), true, nextHash, graph); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StateOverrides is changes of accounts applied to the world state for
+// simulations. Keys are addresses of the accounts.
+type StateOverrides map[string]*AccountOverride
+
+// Apply applies changes to the world state. The world state should be a
+// temporary one which is not flushed, so the changes are not applied to
+// the real state.
+func (o StateOverrides) Apply(ws WorldState) error {
+	if len(o) > OverrideMaxAccounts {
+		return scoreresult.InvalidParameterError.Errorf(
+			"TooManyAccounts(size=%d)", len(o))
+	}
+	var items int
+	for key, ao := range o {
+		addr, err := common.NewAddressFromString(key)
+		if err != nil {
+			return scoreresult.InvalidParameterError.Wrapf(err,
+				"InvalidAddress(addr=%s)", key)
+		}
+		if ao == nil {
+			continue
+		}
+		if ao.CodeFrom != nil {
+			if err := copyContract(ws, addr, ao.CodeFrom); err != nil {
+				return err
+			}
+		}
+		as := ws.GetAccountState(addr.ID())
+		if ao.Balance != nil {
+			if ao.Balance.Sign() < 0 {
+				return scoreresult.InvalidParameterError.Errorf(
+					"InvalidBalance(addr=%s,balance=%s)", addr, ao.Balance)
+			}
+			as.SetBalance(&ao.Balance.Int)
+		}
+		if items += len(ao.Storage); items > OverrideMaxStorage {
+			return scoreresult.InvalidParameterError.Errorf(
+				"TooManyStorageItems(size=%d)", items)
+		}
+		for i, so := range ao.Storage {
+			if so == nil {
+				continue
+			}
+			if err := so.Apply(as); err != nil {
+				return scoreresult.InvalidParameterError.Wrapf(err,
+					"InvalidStorage(addr=%s,index=%d)", addr, i)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package state
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/db"
+	"github.com/icon-project/goloop/service/scoredb"
+	"github.com/icon-project/goloop/service/scoreresult"
+)
+
+func TestStateOverrides_Apply(t *testing.T) {
+	ws := NewWorldState(db.NewMapDB(), nil, nil, nil)
+	user := common.MustNewAddressFromString("hx0000000000000000000000000000000000000001")
+	score := common.MustNewAddressFromString("cx0000000000000000000000000000000000000002")
+	clone := common.MustNewAddressFromString("cx0000000000000000000000000000000000000003")
+
+	as := ws.GetAccountState(score.ID())
+	as.InitContractAccount(user)
+	_, err := as.DeployContract([]byte("code"), "java", "application/java", nil, []byte("tx"))
+	assert.NoError(t, err)
+	assert.NoError(t, as.AcceptContract([]byte("tx"), nil))
+	assert.NoError(t, scoredb.NewVarDB(as, "name").Set("old"))
+
+	var o StateOverrides
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"hx0000000000000000000000000000000000000001": { "balance": "0x64" },
+		"cx0000000000000000000000000000000000000002": {
+			"storage": [
+				{ "type": "var", "name": "name", "value": "0x6e6577" },
+				{ "type": "dict", "name": "balances", "keys": ["hx0000000000000000000000000000000000000001"], "value": "0x0a" },
+				{ "key": "0x1234", "value": "0x01" }
+			]
+		},
+		"cx0000000000000000000000000000000000000003": {
+			"codeFrom": "cx0000000000000000000000000000000000000002"
+		}
+	}`), &o))
+	assert.NoError(t, o.Apply(ws))
+
+	assert.Equal(t, big.NewInt(100), ws.GetAccountState(user.ID()).GetBalance())
+	as = ws.GetAccountState(score.ID())
+	assert.Equal(t, "new", scoredb.NewVarDB(as, "name").String())
+	assert.Equal(t, int64(10), scoredb.NewDictDB(as, "balances", 1).Get(user).Int64())
+	v, err := as.GetValue([]byte{0x12, 0x34})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1}, v)
+
+	cs := ws.GetAccountState(clone.ID())
+	if assert.NotNil(t, cs.ActiveContract()) {
+		code, err := cs.ActiveContract().Code()
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("code"), code)
+	}
+
+	// invalid overrides
+	for _, js := range []string{
+		`{ "hx0000000000000000000000000000000000000004": { "codeFrom": "cx0000000000000000000000000000000000000002" } }`,
+		`{ "cx0000000000000000000000000000000000000004": { "codeFrom": "cx0000000000000000000000000000000000000005" } }`,
+		`{ "cx0000000000000000000000000000000000000002": { "storage": [ { "type": "list", "name": "a", "value": "0x01" } ] } }`,
+		`{ "cx0000000000000000000000000000000000000002": { "storage": [ { "type": "var", "value": "0x01" } ] } }`,
+		`{ "hx0000000000000000000000000000000000000001": { "balance": "-0x1" } }`,
+	} {
+		var o StateOverrides
+		assert.NoError(t, json.Unmarshal([]byte(js), &o))
+		err := o.Apply(ws)
+		assert.Error(t, err, js)
+		assert.True(t, scoreresult.IsValid(err), js)
+	}
+}
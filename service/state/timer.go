@@ -0,0 +1,140 @@
+package state
+
+import (
+	"bytes"
+
+	"github.com/icon-project/goloop/common/containerdb"
+	"github.com/icon-project/goloop/service/scoredb"
+)
+
+// TimerEntries is a read-only view of entries of a height in a timer.
+type TimerEntries interface {
+	Size() int
+	Get(i int) []byte
+}
+
+// TimerSlot keeps entries of a height in a timer. Timers may keep them in
+// their own form, like the list of addresses for unstaking and unbonding
+// of IISS, while the order and uniqueness of entries are managed here.
+type TimerSlot interface {
+	TimerEntries
+	Set(i int, bs []byte) error
+	Put(bs []byte) error
+	Pop() error
+}
+
+// IndexOfTimerEntry returns the index of the entry, or -1 if it doesn't
+// exist.
+func IndexOfTimerEntry(s TimerEntries, bs []byte) int {
+	for i := 0; i < s.Size(); i++ {
+		if bytes.Equal(s.Get(i), bs) {
+			return i
+		}
+	}
+	return -1
+}
+
+// AddTimerEntry appends the entry if it doesn't exist. It returns true if
+// it's added.
+func AddTimerEntry(s TimerSlot, bs []byte) (bool, error) {
+	if IndexOfTimerEntry(s, bs) >= 0 {
+		return false, nil
+	}
+	if err := s.Put(bs); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RemoveTimerEntry removes the entry keeping the order of following ones.
+// It returns true if it's removed.
+func RemoveTimerEntry(s TimerSlot, bs []byte) (bool, error) {
+	idx := IndexOfTimerEntry(s, bs)
+	if idx < 0 {
+		return false, nil
+	}
+	size := s.Size()
+	for j := idx + 1; j < size; j++ {
+		if err := s.Set(j-1, s.Get(j)); err != nil {
+			return false, err
+		}
+	}
+	if err := s.Pop(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+type arrayTimerSlot struct {
+	db *containerdb.ArrayDB
+}
+
+func (s arrayTimerSlot) Size() int {
+	return s.db.Size()
+}
+
+func (s arrayTimerSlot) Get(i int) []byte {
+	return s.db.Get(i).Bytes()
+}
+
+func (s arrayTimerSlot) Set(i int, bs []byte) error {
+	return s.db.Set(i, bs)
+}
+
+func (s arrayTimerSlot) Put(bs []byte) error {
+	return s.db.Put(bs)
+}
+
+func (s arrayTimerSlot) Pop() error {
+	s.db.Pop()
+	return nil
+}
+
+// Timer keeps entries to be handled at the block height. It's a
+// generalized form of the timer in IISS, which keeps addresses of accounts
+// for unstaking and unbonding. Entries of a height keep the order of
+// registration.
+type Timer struct {
+	slotAt func(height int64) TimerSlot
+}
+
+// Add adds the entry at the height if it doesn't exist.
+func (t *Timer) Add(height int64, v interface{}) error {
+	_, err := AddTimerEntry(t.slotAt(height), containerdb.ToBytes(v))
+	return err
+}
+
+// Remove removes the entry at the height. It returns true if it was removed.
+func (t *Timer) Remove(height int64, v interface{}) (bool, error) {
+	return RemoveTimerEntry(t.slotAt(height), containerdb.ToBytes(v))
+}
+
+// Entries returns entries at the height.
+func (t *Timer) Entries(height int64) [][]byte {
+	s := t.slotAt(height)
+	size := s.Size()
+	values := make([][]byte, size)
+	for i := 0; i < size; i++ {
+		values[i] = s.Get(i)
+	}
+	return values
+}
+
+// Len returns number of entries at the height.
+func (t *Timer) Len(height int64) int {
+	return t.slotAt(height).Size()
+}
+
+// NewTimer returns a timer keeping entries of each height in an array of
+// the store.
+func NewTimer(store containerdb.BytesStoreState, name string) *Timer {
+	return NewTimerWithSlots(func(height int64) TimerSlot {
+		return arrayTimerSlot{scoredb.NewArrayDB(store, name, height)}
+	})
+}
+
+// NewTimerWithSlots returns a timer keeping entries of each height in the
+// slot returned by slotAt.
+func NewTimerWithSlots(slotAt func(height int64) TimerSlot) *Timer {
+	return &Timer{slotAt: slotAt}
+}
@@ -0,0 +1,47 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common/db"
+	"github.com/icon-project/goloop/common/intconv"
+)
+
+func TestTimer_AddRemove(t *testing.T) {
+	ws := NewWorldState(db.NewMapDB(), nil, nil, nil)
+	timer := NewTimer(ws.GetAccountState(SystemID), "timer")
+
+	entries := func(height int64) []int64 {
+		var values []int64
+		for _, bs := range timer.Entries(height) {
+			values = append(values, intconv.BytesToInt64(bs))
+		}
+		return values
+	}
+
+	for _, v := range []int64{3, 1, 2, 1} {
+		assert.NoError(t, timer.Add(10, v))
+	}
+	assert.NoError(t, timer.Add(11, 1))
+	assert.Equal(t, []int64{3, 1, 2}, entries(10))
+	assert.Equal(t, 1, timer.Len(11))
+	assert.Equal(t, 0, timer.Len(12))
+
+	// removing keeps the order of following entries
+	removed, err := timer.Remove(10, 3)
+	assert.NoError(t, err)
+	assert.True(t, removed)
+	assert.Equal(t, []int64{1, 2}, entries(10))
+
+	removed, err = timer.Remove(10, 3)
+	assert.NoError(t, err)
+	assert.False(t, removed)
+
+	removed, err = timer.Remove(10, 2)
+	assert.NoError(t, err)
+	assert.True(t, removed)
+	assert.Equal(t, []int64{1}, entries(10))
+	assert.Equal(t, []int64{1}, entries(11))
+}
@@ -39,6 +39,14 @@ const (
 	VarDepositIssueRate   = "deposit_issue_rate"
 	VarNextBlockVersion   = "next_block_version"
 	VarEnabledEETypes     = "enabled_ee_types"
+	VarDeployValidators   = "deploy_validators"
+	VarMaxCodeSizes       = "max_code_sizes"
+	VarForbiddenAPIs      = "forbidden_apis"
+	VarContentHashes      = "content_hashes"
+	VarScheduledCalls     = "scheduled_calls"
+	VarScheduleSeq        = "schedule_seq"
+	VarScheduleTimer      = "schedule_timer"
+	VarScheduleBacklog    = "schedule_backlog"
 )
 
 const (
@@ -377,6 +385,8 @@ func (c *worldContext) WorldStateChanged(ws WorldState) WorldContext {
 		governance:   c.governance,
 		systemInfo:   c.systemInfo,
 		blockInfo:    c.blockInfo,
+		csInfo:       c.csInfo,
+		platform:     c.platform,
 	}
 	return wc
 }
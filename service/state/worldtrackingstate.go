@@ -0,0 +1,578 @@
+package state
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/scoreapi"
+)
+
+type accountAccess struct {
+	// whole is set if the account is accessed as a whole, with snapshots.
+	whole bool
+	// meta is set for the account except for the storage.
+	meta bool
+	// contract is set if one of the contracts is accessed. It's used to
+	// find changes made through Contract.SetCode.
+	contract bool
+	storage  map[string]bool
+}
+
+func (a *accountAccess) conflicts(w *accountAccess) bool {
+	if a.whole || w.whole {
+		return true
+	}
+	if a.meta && w.meta {
+		return true
+	}
+	for k := range a.storage {
+		if w.storage[k] {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *accountAccess) merge(w *accountAccess) {
+	a.whole = a.whole || w.whole
+	a.meta = a.meta || w.meta
+	a.contract = a.contract || w.contract
+	for k := range w.storage {
+		a.setStorage(k)
+	}
+}
+
+func (a *accountAccess) setStorage(k string) {
+	if a.storage == nil {
+		a.storage = make(map[string]bool)
+	}
+	a.storage[k] = true
+}
+
+// AccessSet is a set of states accessed by a transaction. Storage of
+// accounts is tracked for each key, so accesses to different items of
+// containerdb don't conflict each other.
+type AccessSet struct {
+	accounts   map[string]*accountAccess
+	validators bool
+	extension  bool
+}
+
+func NewAccessSet() *AccessSet {
+	return &AccessSet{
+		accounts: make(map[string]*accountAccess),
+	}
+}
+
+func (s *AccessSet) account(id string) *accountAccess {
+	a, ok := s.accounts[id]
+	if !ok {
+		a = new(accountAccess)
+		s.accounts[id] = a
+	}
+	return a
+}
+
+// Conflicts returns true if the set (as reads) has something changed by
+// the writes.
+func (s *AccessSet) Conflicts(writes *AccessSet) bool {
+	if (s.validators && writes.validators) || (s.extension && writes.extension) {
+		return true
+	}
+	for id, a := range s.accounts {
+		if w, ok := writes.accounts[id]; ok && a.conflicts(w) {
+			return true
+		}
+	}
+	return false
+}
+
+// Extension returns true if the set has the extension.
+func (s *AccessSet) Extension() bool {
+	return s.extension
+}
+
+// Accounts returns IDs of accounts in the set in ascending order.
+func (s *AccessSet) Accounts() [][]byte {
+	ids := make([][]byte, 0, len(s.accounts))
+	for id := range s.accounts {
+		ids = append(ids, []byte(id))
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return bytes.Compare(ids[i], ids[j]) < 0
+	})
+	return ids
+}
+
+// StorageKeys returns keys of the storage of the account in the set in
+// ascending order.
+func (s *AccessSet) StorageKeys(id []byte) [][]byte {
+	a, ok := s.accounts[string(id)]
+	if !ok {
+		return nil
+	}
+	keys := make([][]byte, 0, len(a.storage))
+	for k := range a.storage {
+		keys = append(keys, []byte(k))
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i], keys[j]) < 0
+	})
+	return keys
+}
+
+func (s *AccessSet) Merge(s2 *AccessSet) {
+	s.validators = s.validators || s2.validators
+	s.extension = s.extension || s2.extension
+	for id, a := range s2.accounts {
+		s.account(id).merge(a)
+	}
+}
+
+// TrackingWorldState records accesses to the world state. Every write is
+// also recorded as a read, so reads of a transaction can be validated
+// against writes of preceding transactions.
+type TrackingWorldState interface {
+	WorldState
+	Reads() *AccessSet
+	Writes() *AccessSet
+
+	// Apply applies changes recorded in writes to the world state.
+	Apply(ws WorldState) error
+}
+
+type trackingWorldState struct {
+	WorldState
+
+	mutex  sync.Mutex
+	reads  *AccessSet
+	writes *AccessSet
+
+	accounts   map[string]*trackingAccountState
+	validators ValidatorSnapshot
+	extension  ExtensionSnapshot
+}
+
+func (ws *trackingWorldState) read(id string) *accountAccess {
+	return ws.reads.account(id)
+}
+
+func (ws *trackingWorldState) readMeta(id string) {
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+	ws.read(id).meta = true
+}
+
+func (ws *trackingWorldState) readContract(id string, as AccountState) {
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+	a := ws.read(id)
+	a.meta = true
+	if !a.contract {
+		a.contract = true
+		ws.accounts[id].before = as.GetSnapshot()
+	}
+}
+
+func (ws *trackingWorldState) writeMeta(id string) {
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+	ws.read(id).meta = true
+	ws.writes.account(id).meta = true
+}
+
+func (ws *trackingWorldState) readWhole(id string) {
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+	ws.read(id).whole = true
+}
+
+func (ws *trackingWorldState) writeWhole(id string) {
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+	ws.read(id).whole = true
+	ws.writes.account(id).whole = true
+}
+
+func (ws *trackingWorldState) readStorage(id string, k []byte) {
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+	ws.read(id).setStorage(string(k))
+}
+
+func (ws *trackingWorldState) writeStorage(id string, k []byte) {
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+	ws.read(id).setStorage(string(k))
+	ws.writes.account(id).setStorage(string(k))
+}
+
+func (ws *trackingWorldState) GetAccountState(id []byte) AccountState {
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+
+	ids := string(id)
+	if as, ok := ws.accounts[ids]; ok {
+		return as
+	}
+	as := &trackingAccountState{
+		AccountState: ws.WorldState.GetAccountState(id),
+		ws:           ws,
+		id:           ids,
+	}
+	ws.accounts[ids] = as
+	return as
+}
+
+func (ws *trackingWorldState) GetAccountSnapshot(id []byte) AccountSnapshot {
+	ws.readWhole(string(id))
+	return ws.WorldState.GetAccountSnapshot(id)
+}
+
+func (ws *trackingWorldState) GetValidatorState() ValidatorState {
+	vs := ws.WorldState.GetValidatorState()
+
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+	if !ws.reads.validators {
+		ws.reads.validators = true
+		ws.validators = vs.GetSnapshot()
+	}
+	return vs
+}
+
+func (ws *trackingWorldState) GetExtensionState() ExtensionState {
+	es := ws.WorldState.GetExtensionState()
+
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+	if !ws.reads.extension {
+		ws.reads.extension = true
+		ws.extension = es.GetSnapshot()
+	}
+	return es
+}
+
+func (ws *trackingWorldState) Reset(snapshot WorldSnapshot) error {
+	if err := ws.WorldState.Reset(snapshot); err != nil {
+		return err
+	}
+
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+	for id, as := range ws.accounts {
+		as.AccountState = ws.WorldState.GetAccountState([]byte(id))
+	}
+	return nil
+}
+
+func (ws *trackingWorldState) ClearCache() {
+	ws.WorldState.ClearCache()
+
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+	for id, as := range ws.accounts {
+		as.AccountState = ws.WorldState.GetAccountState([]byte(id))
+	}
+}
+
+func (ws *trackingWorldState) Reads() *AccessSet {
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+	return ws.reads
+}
+
+func extensionBytes(ess ExtensionSnapshot) []byte {
+	if ess == nil {
+		return nil
+	}
+	return ess.Bytes()
+}
+
+func contractChanged(before, after AccountSnapshot) bool {
+	s1, _ := before.(*accountSnapshotImpl)
+	s2, _ := after.(*accountSnapshotImpl)
+	if s1 == nil || s2 == nil {
+		return s1 != s2
+	}
+	return !s1.curContract.Equal(s2.curContract) ||
+		!s1.nextContract.Equal(s2.nextContract)
+}
+
+// Writes returns the writes including changes of validators, extension
+// and contracts, which are found by comparing with the states before the
+// first access.
+func (ws *trackingWorldState) Writes() *AccessSet {
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+
+	if ws.reads.validators && !ws.writes.validators {
+		vss := ws.WorldState.GetValidatorState().GetSnapshot()
+		ws.writes.validators = !bytes.Equal(vss.Hash(), ws.validators.Hash())
+	}
+	if ws.reads.extension && !ws.writes.extension {
+		ess := ws.WorldState.GetExtensionState().GetSnapshot()
+		ws.writes.extension = !bytes.Equal(extensionBytes(ess), extensionBytes(ws.extension))
+	}
+	for id, a := range ws.reads.accounts {
+		if !a.contract {
+			continue
+		}
+		as := ws.accounts[id]
+		if contractChanged(as.before, as.AccountState.GetSnapshot()) {
+			ws.writes.account(id).meta = true
+		}
+	}
+	return ws.writes
+}
+
+func (ws *trackingWorldState) Apply(target WorldState) error {
+	writes := ws.Writes()
+
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+
+	for id, a := range writes.accounts {
+		src := ws.WorldState.GetAccountState([]byte(id))
+		dst := target.GetAccountState([]byte(id))
+		if a.whole {
+			if err := dst.Reset(src.GetSnapshot()); err != nil {
+				return err
+			}
+			continue
+		}
+		if a.meta {
+			if as, ok := dst.(*accountStateImpl); ok {
+				as.resetMeta(src.GetSnapshot().(*accountSnapshotImpl))
+			} else {
+				return errors.InvalidStateError.Errorf(
+					"UnsupportedAccountState(type=%T)", dst)
+			}
+		}
+		for k := range a.storage {
+			v, err := src.GetValue([]byte(k))
+			if err != nil {
+				return err
+			}
+			if _, err := dst.SetValue([]byte(k), v); err != nil {
+				return err
+			}
+		}
+	}
+	if writes.validators {
+		target.GetValidatorState().Reset(ws.WorldState.GetValidatorState().GetSnapshot())
+	}
+	if writes.extension {
+		target.GetExtensionState().Reset(ws.WorldState.GetExtensionState().GetSnapshot())
+	}
+	return nil
+}
+
+// NewTrackingWorldState returns a world state tracking accesses to the
+// world state.
+func NewTrackingWorldState(ws WorldState) TrackingWorldState {
+	return &trackingWorldState{
+		WorldState: ws,
+		reads:      NewAccessSet(),
+		writes:     NewAccessSet(),
+		accounts:   make(map[string]*trackingAccountState),
+	}
+}
+
+type trackingAccountState struct {
+	AccountState
+	ws     *trackingWorldState
+	id     string
+	before AccountSnapshot
+}
+
+func (s *trackingAccountState) Version() int {
+	s.ws.readMeta(s.id)
+	return s.AccountState.Version()
+}
+
+func (s *trackingAccountState) MigrateForRevision(rev module.Revision) error {
+	s.ws.readMeta(s.id)
+	v := s.AccountState.Version()
+	err := s.AccountState.MigrateForRevision(rev)
+	if s.AccountState.Version() != v {
+		s.ws.writeMeta(s.id)
+	}
+	return err
+}
+
+func (s *trackingAccountState) GetBalance() *big.Int {
+	s.ws.readMeta(s.id)
+	return s.AccountState.GetBalance()
+}
+
+func (s *trackingAccountState) IsContract() bool {
+	s.ws.readMeta(s.id)
+	return s.AccountState.IsContract()
+}
+
+func (s *trackingAccountState) GetValue(k []byte) ([]byte, error) {
+	s.ws.readStorage(s.id, k)
+	return s.AccountState.GetValue(k)
+}
+
+func (s *trackingAccountState) SetBalance(v *big.Int) {
+	if s.GetBalance().Cmp(v) != 0 {
+		s.ws.writeMeta(s.id)
+		s.AccountState.SetBalance(v)
+	}
+}
+
+func (s *trackingAccountState) SetValue(k, v []byte) ([]byte, error) {
+	s.ws.writeStorage(s.id, k)
+	return s.AccountState.SetValue(k, v)
+}
+
+func (s *trackingAccountState) DeleteValue(k []byte) ([]byte, error) {
+	s.ws.writeStorage(s.id, k)
+	return s.AccountState.DeleteValue(k)
+}
+
+func (s *trackingAccountState) GetSnapshot() AccountSnapshot {
+	s.ws.readWhole(s.id)
+	return s.AccountState.GetSnapshot()
+}
+
+func (s *trackingAccountState) Reset(snapshot AccountSnapshot) error {
+	s.ws.writeWhole(s.id)
+	return s.AccountState.Reset(snapshot)
+}
+
+func (s *trackingAccountState) Clear() {
+	s.ws.writeWhole(s.id)
+	s.AccountState.Clear()
+}
+
+func (s *trackingAccountState) IsContractOwner(owner module.Address) bool {
+	s.ws.readMeta(s.id)
+	return s.AccountState.IsContractOwner(owner)
+}
+
+func (s *trackingAccountState) SetContractOwner(owner module.Address) error {
+	s.ws.writeMeta(s.id)
+	return s.AccountState.SetContractOwner(owner)
+}
+
+func (s *trackingAccountState) InitContractAccount(address module.Address) bool {
+	s.ws.writeMeta(s.id)
+	return s.AccountState.InitContractAccount(address)
+}
+
+func (s *trackingAccountState) DeployContract(code []byte, eeType EEType, contentType string, params []byte, txHash []byte) ([]byte, error) {
+	s.ws.writeMeta(s.id)
+	return s.AccountState.DeployContract(code, eeType, contentType, params, txHash)
+}
+
+func (s *trackingAccountState) APIInfo() (*scoreapi.Info, error) {
+	s.ws.readMeta(s.id)
+	return s.AccountState.APIInfo()
+}
+
+func (s *trackingAccountState) SetAPIInfo(info *scoreapi.Info) {
+	s.ws.writeMeta(s.id)
+	s.AccountState.SetAPIInfo(info)
+}
+
+func (s *trackingAccountState) ActivateNextContract() error {
+	s.ws.writeMeta(s.id)
+	return s.AccountState.ActivateNextContract()
+}
+
+func (s *trackingAccountState) AcceptContract(txHash []byte, auditTxHash []byte) error {
+	s.ws.writeMeta(s.id)
+	return s.AccountState.AcceptContract(txHash, auditTxHash)
+}
+
+func (s *trackingAccountState) RejectContract(txHash []byte, auditTxHash []byte) error {
+	s.ws.writeMeta(s.id)
+	return s.AccountState.RejectContract(txHash, auditTxHash)
+}
+
+func (s *trackingAccountState) Contract() Contract {
+	s.ws.readContract(s.id, s.AccountState)
+	return s.AccountState.Contract()
+}
+
+func (s *trackingAccountState) ActiveContract() Contract {
+	s.ws.readContract(s.id, s.AccountState)
+	return s.AccountState.ActiveContract()
+}
+
+func (s *trackingAccountState) NextContract() Contract {
+	s.ws.readContract(s.id, s.AccountState)
+	return s.AccountState.NextContract()
+}
+
+func (s *trackingAccountState) SetDisable(b bool) {
+	s.ws.writeMeta(s.id)
+	s.AccountState.SetDisable(b)
+}
+
+func (s *trackingAccountState) IsDisabled() bool {
+	s.ws.readMeta(s.id)
+	return s.AccountState.IsDisabled()
+}
+
+func (s *trackingAccountState) SetBlock(b bool) {
+	s.ws.writeMeta(s.id)
+	s.AccountState.SetBlock(b)
+}
+
+func (s *trackingAccountState) IsBlocked() bool {
+	s.ws.readMeta(s.id)
+	return s.AccountState.IsBlocked()
+}
+
+func (s *trackingAccountState) ContractOwner() module.Address {
+	s.ws.readMeta(s.id)
+	return s.AccountState.ContractOwner()
+}
+
+func (s *trackingAccountState) GetObjGraph(id []byte, flags bool) (int, []byte, []byte, error) {
+	s.ws.readMeta(s.id)
+	return s.AccountState.GetObjGraph(id, flags)
+}
+
+func (s *trackingAccountState) SetObjGraph(id []byte, flags bool, nextHash int, objGraph []byte) error {
+	s.ws.writeMeta(s.id)
+	return s.AccountState.SetObjGraph(id, flags, nextHash, objGraph)
+}
+
+func (s *trackingAccountState) AddDeposit(dc DepositContext, value *big.Int) error {
+	s.ws.writeMeta(s.id)
+	return s.AccountState.AddDeposit(dc, value)
+}
+
+func (s *trackingAccountState) WithdrawDeposit(dc DepositContext, id []byte, value *big.Int) (*big.Int, *big.Int, error) {
+	s.ws.writeMeta(s.id)
+	return s.AccountState.WithdrawDeposit(dc, id, value)
+}
+
+func (s *trackingAccountState) PaySteps(pc PayContext, steps *big.Int) (*big.Int, error) {
+	s.ws.writeMeta(s.id)
+	return s.AccountState.PaySteps(pc, steps)
+}
+
+func (s *trackingAccountState) CanAcceptTx(pc PayContext) bool {
+	s.ws.readMeta(s.id)
+	return s.AccountState.CanAcceptTx(pc)
+}
+
+func (s *trackingAccountState) CheckDeposit(pc PayContext) bool {
+	s.ws.readMeta(s.id)
+	return s.AccountState.CheckDeposit(pc)
+}
+
+func (s *trackingAccountState) GetDepositInfo(dc DepositContext, v module.JSONVersion) (map[string]interface{}, error) {
+	s.ws.readMeta(s.id)
+	return s.AccountState.GetDepositInfo(dc, v)
+}
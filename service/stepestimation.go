@@ -0,0 +1,44 @@
+package service
+
+import (
+	"bytes"
+	"math/big"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/contract"
+	"github.com/icon-project/goloop/service/state"
+)
+
+type stepEstimation struct {
+	receipt module.Receipt
+	ws      state.TrackingWorldState
+	tracker *contract.StepTracker
+}
+
+func (e *stepEstimation) Receipt() module.Receipt {
+	return e.receipt
+}
+
+func (e *stepEstimation) StepsByType() map[string]*big.Int {
+	return e.tracker.StepsByType(e.receipt.StepUsed())
+}
+
+func (e *stepEstimation) AccessList() []module.AccountAccess {
+	reads := e.ws.Reads()
+	ids := reads.Accounts()
+	accesses := make([]module.AccountAccess, 0, len(ids))
+	for _, id := range ids {
+		accesses = append(accesses, module.AccountAccess{
+			Storage: reads.StorageKeys(id),
+		})
+	}
+	// it checks types of accounts after collecting IDs, because the
+	// check is also tracked as an access.
+	for i, id := range ids {
+		isContract := bytes.Equal(id, state.SystemID) ||
+			e.ws.GetAccountState(id).IsContract()
+		accesses[i].Address = common.NewAddressWithTypeAndID(isContract, id)
+	}
+	return accesses
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/common/wallet"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/eeproxy"
+	"github.com/icon-project/goloop/service/platform/basic"
+	"github.com/icon-project/goloop/service/state"
+)
+
+// newTestEstimateManager returns the manager executing transactions on
+// the last state of the chain.
+func newTestEstimateManager(t *testing.T, chain *testSystemChain) *manager {
+	_, err := chain.execute(t, 1, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, chain.parent.worldSnapshot.Flush())
+
+	return &manager{
+		plt:   basic.Platform,
+		db:    chain.mdb,
+		chain: &testOEChain{level: 1},
+		cm:    chain.cm,
+		eem:   chain.em,
+		trc:   newTransitionResultCache(chain.mdb, basic.Platform, 2, 2, log.GlobalLogger()),
+		log:   log.GlobalLogger(),
+	}
+}
+
+func testEstimateTxJSON(t *testing.T, w module.Wallet, to module.Address, method string, params map[string]interface{}, overrides interface{}) []byte {
+	tx := newTestOETx(t, w, 1, to, 0, map[string]interface{}{
+		"method": method,
+		"params": params,
+	})
+	jso, err := tx.ToJSON(module.JSONVersionLast)
+	assert.NoError(t, err)
+	if overrides != nil {
+		jso.(map[string]interface{})["overrides"] = overrides
+	}
+	js, err := json.Marshal(jso)
+	assert.NoError(t, err)
+	return js
+}
+
+func TestManager_EstimateTransaction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "estimate")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	wallets := []module.Wallet{wallet.New()}
+	chain := newTestSystemChain(t, dir, wallets, eeproxy.NewGoContracts())
+	defer chain.close()
+
+	m := newTestEstimateManager(t, chain)
+	js := testEstimateTxJSON(t, wallets[0], testOEScore, "add",
+		map[string]interface{}{"key": "e", "delta": "0x3"}, nil)
+
+	est, err := m.EstimateTransaction(chain.parent.Result(), nil, js, common.NewBlockInfo(2, 2000000))
+	assert.NoError(t, err)
+	assert.Equal(t, module.StatusSuccess, est.Receipt().Status())
+
+	// steps for each type are summed up to the steps used
+	steps := est.StepsByType()
+	sum := new(big.Int)
+	for _, v := range steps {
+		sum.Add(sum, v)
+	}
+	assert.Equal(t, est.Receipt().StepUsed(), sum)
+	for _, st := range []string{
+		state.StepTypeDefault, state.StepTypeInput, state.StepTypeContractCall,
+		state.StepTypeGet, state.StepTypeSet, state.StepTypeEventLog,
+	} {
+		assert.Contains(t, steps, st)
+	}
+
+	// accessed accounts and storage keys
+	accesses := make(map[string]module.AccountAccess)
+	for _, a := range est.AccessList() {
+		accesses[a.Address.String()] = a
+	}
+	assert.Contains(t, accesses, wallets[0].Address().String())
+	if a, ok := accesses[testOEScore.String()]; assert.True(t, ok) {
+		assert.Contains(t, a.Storage, []byte("e"))
+	}
+
+	// nothing is changed by the estimation
+	assert.Equal(t, int64(0), chain.counter("e"))
+}
+
+func TestManager_ExecuteTransactionWithOverrides(t *testing.T) {
+	dir, err := ioutil.TempDir("", "overrides")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	wallets := []module.Wallet{wallet.New()}
+	chain := newTestSystemChain(t, dir, wallets, eeproxy.NewGoContracts())
+	defer chain.close()
+
+	m := newTestEstimateManager(t, chain)
+	bi := common.NewBlockInfo(2, 2000000)
+	move := map[string]interface{}{"from": "x", "to": "y"}
+
+	// it fails without the value to move
+	rct, err := m.ExecuteTransaction(chain.parent.Result(), nil,
+		testEstimateTxJSON(t, wallets[0], testOEScore, "move", move, nil), bi)
+	assert.NoError(t, err)
+	assert.NotEqual(t, module.StatusSuccess, rct.Status())
+
+	// storage of the contract is overridden
+	overrides := map[string]interface{}{
+		testOEScore.String(): map[string]interface{}{
+			"storage": []interface{}{
+				map[string]interface{}{"key": "0x78", "value": "0x01"},
+			},
+		},
+	}
+	rct, err = m.ExecuteTransaction(chain.parent.Result(), nil,
+		testEstimateTxJSON(t, wallets[0], testOEScore, "move", move, overrides), bi)
+	assert.NoError(t, err)
+	assert.Equal(t, module.StatusSuccess, rct.Status())
+
+	// a contract running the code of the other contract
+	clone := common.MustNewAddressFromString("cx00000000000000000000000000000000000000ff")
+	overrides = map[string]interface{}{
+		clone.String(): map[string]interface{}{
+			"codeFrom": testOEScore.String(),
+		},
+	}
+	rct, err = m.ExecuteTransaction(chain.parent.Result(), nil,
+		testEstimateTxJSON(t, wallets[0], clone, "add",
+			map[string]interface{}{"key": "z", "delta": "0x1"}, overrides), bi)
+	assert.NoError(t, err)
+	assert.Equal(t, module.StatusSuccess, rct.Status())
+
+	// the real state is not changed
+	assert.Equal(t, int64(0), chain.counter("x"))
+	assert.Nil(t, chain.parent.worldSnapshot.GetAccountSnapshot(clone.ID()))
+}
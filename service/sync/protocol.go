@@ -3,7 +3,9 @@ package sync
 import (
 	"fmt"
 
+	"github.com/icon-project/goloop/common/errors"
 	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/network"
 )
 
 // protocol message codes
@@ -83,3 +85,27 @@ func (r *nodeData) String() string {
 	return fmt.Sprintf("ReqID(%d), Status(%d), Data(%#x)",
 		r.ReqID, r.Status, r.Data)
 }
+
+func init() {
+	network.RegisterPayloadDecoder(module.ProtoStateSync, "statesync", true, decodeMessage)
+}
+
+func decodeMessage(pi module.ProtocolInfo, b []byte) (interface{}, error) {
+	var msg interface{}
+	switch pi {
+	case protoHasNode:
+		msg = new(hasNode)
+	case protoResult:
+		msg = new(result)
+	case protoRequestNodeData:
+		msg = new(requestNodeData)
+	case protoNodeData:
+		msg = new(nodeData)
+	default:
+		return nil, errors.NotFoundError.Errorf("UnknownProtocol(%s)", pi)
+	}
+	if _, err := c.UnmarshalFromBytes(b, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
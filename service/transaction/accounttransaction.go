@@ -0,0 +1,480 @@
+package transaction
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/crypto"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/contract"
+	"github.com/icon-project/goloop/service/scoreresult"
+	"github.com/icon-project/goloop/service/state"
+	"github.com/icon-project/goloop/service/txresult"
+)
+
+const (
+	// AccountValidateMethod is the method of contract accounts deciding
+	// validity of transactions sent by them. It should be read-only, and
+	// it receives the hash of the transaction as txHash and the
+	// authorization data as authData. It returns true for valid one.
+	AccountValidateMethod = "validateTransaction"
+
+	txMaxAuthDataSize = 8 * 1024 // 8kB
+)
+
+var accountV3HashExclusion = map[string]bool{
+	"signature": true,
+	"authData":  true,
+	"txHash":    true,
+}
+
+type accountV3Data struct {
+	Version   common.HexUint16 `json:"version"`
+	From      common.Address   `json:"from"`
+	To        common.Address   `json:"to"`
+	Value     *common.HexInt   `json:"value"`
+	StepLimit common.HexInt    `json:"stepLimit"`
+	TimeStamp common.HexInt64  `json:"timestamp"`
+	NID       *common.HexInt64 `json:"nid,omitempty"`
+	Nonce     *common.HexInt   `json:"nonce,omitempty"`
+	AuthData  common.HexBytes  `json:"authData"`
+	DataType  *string          `json:"dataType,omitempty"`
+	Data      json.RawMessage  `json:"data,omitempty"`
+}
+
+// v3Data returns the data in the form of transaction V3 without signature.
+// It shares the hash and the checks on data with transaction V3.
+func (tx *accountV3Data) v3Data() *transactionV3Data {
+	return &transactionV3Data{
+		Version:   tx.Version,
+		From:      tx.From,
+		To:        tx.To,
+		Value:     tx.Value,
+		StepLimit: tx.StepLimit,
+		TimeStamp: tx.TimeStamp,
+		NID:       tx.NID,
+		Nonce:     tx.Nonce,
+		DataType:  tx.DataType,
+		Data:      tx.Data,
+	}
+}
+
+// accountV3 is the transaction sent by a contract account. Instead of the
+// signature, it has authorization data, and the contract account decides
+// its validity with AccountValidateMethod.
+type accountV3 struct {
+	accountV3Data
+	txHash []byte
+	bytes  []byte
+}
+
+func (tx *accountV3) Timestamp() int64 {
+	return tx.TimeStamp.Value
+}
+
+func (tx *accountV3) TxHash() []byte {
+	if tx.txHash == nil {
+		h, err := tx.v3Data().calcHash()
+		if err != nil {
+			tx.txHash = []byte{}
+		} else {
+			tx.txHash = h
+		}
+	}
+	return tx.txHash
+}
+
+func (tx *accountV3) ID() []byte {
+	return tx.TxHash()
+}
+
+func (tx *accountV3) From() module.Address {
+	return &tx.accountV3Data.From
+}
+
+func (tx *accountV3) To() module.Address {
+	return &tx.accountV3Data.To
+}
+
+func (tx *accountV3) Version() int {
+	return module.TransactionVersion3
+}
+
+func (tx *accountV3) Group() module.TransactionGroup {
+	return module.TransactionGroupNormal
+}
+
+func (tx *accountV3) Nonce() *big.Int {
+	if nonce := tx.accountV3Data.Nonce; nonce != nil {
+		return &nonce.Int
+	}
+	return nil
+}
+
+func (tx *accountV3) IsSkippable() bool {
+	return true
+}
+
+func (tx *accountV3) Verify() error {
+	if !tx.From().IsContract() {
+		return InvalidTxValue.Errorf("InvalidFrom(%s)", tx.From())
+	}
+	if tx.DataType != nil && *tx.DataType == contract.DataTypePatch {
+		return InvalidTxValue.New("PatchNotAllowed")
+	}
+	if len(tx.AuthData) > txMaxAuthDataSize {
+		return InvalidTxValue.Errorf("InvalidAuthDataSize(%d)", len(tx.AuthData))
+	}
+	return tx.v3Data().verifyData()
+}
+
+func (tx *accountV3) ValidateNetwork(nid int) bool {
+	if tx.NID == nil {
+		return true
+	}
+	return int(tx.NID.Value) == nid
+}
+
+func (tx *accountV3) PreValidate(wc state.WorldContext, update bool) error {
+	if !wc.Revision().UseAccountTransaction() {
+		return InvalidTxValue.New("AccountTransactionNotAllowed")
+	}
+
+	// stepLimit >= default step + input steps
+	cnt, err := MeasureBytesOfData(wc.Revision(), tx.Data)
+	if err != nil {
+		return err
+	}
+	cnt += len(tx.AuthData)
+	minStep := big.NewInt(wc.StepsFor(state.StepTypeDefault, 1) + wc.StepsFor(state.StepTypeInput, cnt))
+	if tx.StepLimit.Cmp(minStep) < 0 {
+		return NotEnoughStepError.Errorf("NotEnoughStep(txStepLimit:%s, minStep:%s)", tx.StepLimit, minStep)
+	}
+
+	// balance >= (fee + value), fee can be payed by the deposit.
+	as1 := wc.GetAccountState(tx.From().ID())
+	if !as1.IsContract() {
+		return ContractNotUsable.New("NotContractAccount")
+	}
+	trans := new(big.Int)
+	if !canPayByDeposit(wc, as1) {
+		trans.Mul(&tx.StepLimit.Int, wc.StepPrice())
+	}
+	if tx.Value != nil {
+		trans.Add(trans, &tx.Value.Int)
+	}
+	balance1 := as1.GetBalance()
+	if balance1.Cmp(trans) < 0 {
+		return NotEnoughBalanceError.Errorf("OutOfBalance(balance:%s, value:%s)", balance1, trans)
+	}
+
+	if as1.IsBlocked() {
+		return AccessDeniedError.New("BlockedAccount")
+	}
+
+	as2 := wc.GetAccountState(tx.To().ID())
+	if contract.IsCallableDataType(tx.DataType) {
+		if !as2.CanAcceptTx(wc) {
+			return ContractNotUsable.New("NotAcceptable")
+		}
+	}
+
+	// It can be validated by the account only with the context for
+	// execution, which is given on admission to the pool and on selecting
+	// candidates from the pool.
+	if ctx, ok := wc.(contract.Context); ok {
+		limit := validationStepLimit(wc, &tx.StepLimit.Int)
+		if _, err := validateByAccount(ctx, tx, limit); err != nil {
+			return InvalidSignatureError.Wrap(err, "InvalidAuthorization")
+		}
+	}
+
+	// for cumulative balance check
+	if update {
+		as1.SetBalance(new(big.Int).Sub(balance1, trans))
+		if tx.Value != nil {
+			balance2 := as2.GetBalance()
+			as2.SetBalance(new(big.Int).Add(balance2, &tx.Value.Int))
+		}
+	}
+	return nil
+}
+
+func (tx *accountV3) GetHandler(cm contract.ContractManager) (Handler, error) {
+	value := big.NewInt(0)
+	if tx.Value != nil {
+		value = &tx.Value.Int
+	}
+	th, err := NewHandler(cm,
+		tx.Group(),
+		tx.From(),
+		tx.To(),
+		value,
+		&tx.StepLimit.Int,
+		tx.DataType,
+		tx.Data)
+	if err != nil {
+		return nil, err
+	}
+	h := th.(*transactionHandler)
+	h.payByDeposit = true
+	return &accountHandler{tx: tx, th: h}, nil
+}
+
+func (tx *accountV3) Bytes() []byte {
+	if tx.bytes == nil {
+		if bs, err := codec.MarshalToBytes(&tx.accountV3Data); err != nil {
+			log.Errorf("Fail to marshal transaction=%+v err=%+v", tx, err)
+			return nil
+		} else {
+			tx.bytes = bs
+		}
+	}
+	return tx.bytes
+}
+
+func (tx *accountV3) Hash() []byte {
+	return crypto.SHA3Sum256(tx.Bytes())
+}
+
+func (tx *accountV3) ToJSON(version module.JSONVersion) (interface{}, error) {
+	jso := map[string]interface{}{
+		"version":   &tx.accountV3Data.Version,
+		"from":      &tx.accountV3Data.From,
+		"to":        &tx.accountV3Data.To,
+		"stepLimit": &tx.accountV3Data.StepLimit,
+		"timestamp": &tx.accountV3Data.TimeStamp,
+		"authData":  tx.accountV3Data.AuthData,
+	}
+	if tx.accountV3Data.Value != nil {
+		jso["value"] = tx.accountV3Data.Value
+	}
+	if tx.accountV3Data.NID != nil {
+		jso["nid"] = tx.accountV3Data.NID
+	}
+	if tx.accountV3Data.Nonce != nil {
+		jso["nonce"] = tx.accountV3Data.Nonce
+	}
+	if tx.accountV3Data.DataType != nil {
+		jso["dataType"] = *tx.accountV3Data.DataType
+	}
+	if tx.accountV3Data.Data != nil {
+		jso["data"] = json.RawMessage(tx.accountV3Data.Data)
+	}
+	jso["txHash"] = common.HexBytes(tx.ID())
+	return jso, nil
+}
+
+func (tx *accountV3) MarshalJSON() ([]byte, error) {
+	if obj, err := tx.ToJSON(module.JSONVersionLast); err != nil {
+		return nil, scoreresult.WithStatus(err, module.StatusIllegalFormat)
+	} else {
+		return json.Marshal(obj)
+	}
+}
+
+type accountHandler struct {
+	tx *accountV3
+	th *transactionHandler
+}
+
+func (h *accountHandler) Prepare(ctx contract.Context) (state.WorldContext, error) {
+	lq := []state.LockRequest{
+		{state.WorldIDStr, state.AccountWriteLock},
+	}
+	return ctx.GetFuture(lq), nil
+}
+
+// Execute validates the transaction with the contract account sending it,
+// then executes it. Steps for the validation are charged as a part of the
+// transaction. If the account doesn't authorize it, then it fails, and the
+// account pays the fee for the validation, because it's already checked on
+// admission to the pool.
+func (h *accountHandler) Execute(ctx contract.Context, estimate bool) (txresult.Receipt, error) {
+	if !ctx.Revision().UseAccountTransaction() {
+		return nil, errors.CriticalFormatError.New("AccountTransactionNotAllowed")
+	}
+	limit := validationStepLimit(ctx, &h.tx.StepLimit.Int)
+	used, err := validateByAccount(ctx, h.tx, limit)
+	if err != nil {
+		if code := errors.CodeOf(err); code == errors.ExecutionFailError ||
+			errors.IsCriticalCode(code) {
+			return nil, err
+		}
+		if !estimate {
+			h.th.preStatus = scoreresult.AccessDeniedError.Wrap(err, "InvalidAuthorization")
+		}
+	}
+	steps := big.NewInt(ctx.StepsFor(state.StepTypeInput, len(h.tx.AuthData)))
+	h.th.preSteps = steps.Add(steps, used)
+	return h.th.Execute(ctx, estimate)
+}
+
+func (h *accountHandler) Dispose() {
+	h.th.Dispose()
+}
+
+// validationStepLimit returns the step limit for validating the transaction
+// by the contract account. It's limited by the step limit of the transaction.
+func validationStepLimit(wc state.WorldContext, stepLimit *big.Int) *big.Int {
+	limit := wc.GetStepLimit(state.StepLimitTypeValidate)
+	if limit.Sign() == 0 {
+		limit = wc.GetStepLimit(state.StepLimitTypeQuery)
+	}
+	if limit.Sign() == 0 || limit.Cmp(stepLimit) > 0 {
+		limit = new(big.Int).Set(stepLimit)
+	}
+	return limit
+}
+
+// validateByAccount calls AccountValidateMethod of the contract account
+// sending the transaction in query mode. It returns steps used for it.
+func validateByAccount(ctx contract.Context, tx *accountV3, limit *big.Int) (*big.Int, error) {
+	from := tx.From()
+	as := ctx.GetAccountSnapshot(from.ID())
+	if as == nil || !as.IsContract() {
+		return new(big.Int), scoreresult.ErrContractNotFound
+	}
+	info, err := as.APIInfo()
+	if err != nil {
+		return new(big.Int), err
+	}
+	if info == nil {
+		return new(big.Int), scoreresult.ErrContractNotFound
+	}
+	if m := info.GetMethod(AccountValidateMethod); m == nil || !m.IsReadOnly() {
+		return new(big.Int), scoreresult.MethodNotFoundError.Errorf(
+			"NoValidateMethod(addr=%s)", from)
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"method": AccountValidateMethod,
+		"params": map[string]interface{}{
+			"txHash":   common.HexBytes(tx.ID()),
+			"authData": tx.AuthData,
+		},
+	})
+	if err != nil {
+		return new(big.Int), err
+	}
+	handler, err := ctx.ContractManager().GetHandler(nil, from, new(big.Int), contract.CTypeCall, data)
+	if err != nil {
+		return new(big.Int), errors.InvalidStateError.Wrap(err, "NoSuitableHandler")
+	}
+
+	cc := contract.NewCallContext(ctx, limit, true)
+	defer cc.Dispose()
+	status, used, result, _ := cc.Call(handler, limit)
+	if status != nil {
+		return used, status
+	}
+	if !isTrueValue(result) {
+		return used, scoreresult.AccessDeniedError.New("RejectedByAccount")
+	}
+	return used, nil
+}
+
+// IsAccountTransaction returns whether the transaction is sent by a contract
+// account.
+func IsAccountTransaction(tx module.Transaction) bool {
+	_, ok := Unwrap(tx).(*accountV3)
+	return ok
+}
+
+func isTrueValue(o *codec.TypedObj) bool {
+	value, err := common.DecodeAny(o)
+	if err != nil {
+		return false
+	}
+	switch v := value.(type) {
+	case bool:
+		return v
+	case *common.HexInt:
+		return v.Sign() != 0
+	default:
+		return false
+	}
+}
+
+// canPayByDeposit returns whether the account has the deposit, and it
+// can pay the fee.
+func canPayByDeposit(wc state.WorldContext, as interface {
+	CheckDeposit(pc state.PayContext) bool
+	GetDepositInfo(dc state.DepositContext, v module.JSONVersion) (map[string]interface{}, error)
+}) bool {
+	if !wc.FeeSharingEnabled() {
+		return false
+	}
+	if info, err := as.GetDepositInfo(wc, module.JSONVersionLast); err != nil || info == nil {
+		return false
+	}
+	return as.CheckDeposit(wc)
+}
+
+func checkAccountV3JSON(jso map[string]interface{}) bool {
+	if version, ok := jso["version"]; !ok || version != "0x3" {
+		return false
+	}
+	if _, ok := jso["authData"]; !ok {
+		return false
+	}
+	return true
+}
+
+func parseAccountV3JSON(js []byte, raw bool) (Transaction, error) {
+	tx := new(accountV3)
+	if err := json.Unmarshal(js, &tx.accountV3Data); err != nil {
+		return nil, InvalidFormat.Wrapf(err, "Invalid json for accountV3(%s)", string(js))
+	}
+	if tx.accountV3Data.Version.Value != module.TransactionVersion3 {
+		return nil, InvalidVersion.Errorf("NotTxVersion3(%d)", tx.accountV3Data.Version.Value)
+	}
+	if !raw {
+		// other fields can't be kept in binary form
+		var jso map[string]interface{}
+		if err := json.Unmarshal(js, &jso); err != nil {
+			return nil, InvalidFormat.Wrap(err, "InvalidJSON")
+		}
+		bs, err := SerializeMap(jso, nil, accountV3HashExclusion)
+		if err != nil {
+			return nil, InvalidFormat.Wrap(err, "InvalidJSON")
+		}
+		id := crypto.SHA3Sum256(append(transactionSaltBytes, bs...))
+		if !bytes.Equal(id, tx.ID()) {
+			return nil, InvalidFormat.New("UnknownFields")
+		}
+	}
+	return tx, nil
+}
+
+func checkAccountV3Binary(bs []byte) bool {
+	var d accountV3Data
+	if _, err := codec.UnmarshalFromBytes(bs, &d); err != nil {
+		return false
+	}
+	return d.Version.Value == module.TransactionVersion3 && d.From.IsContract()
+}
+
+func parseAccountV3Binary(bs []byte) (Transaction, error) {
+	tx := new(accountV3)
+	if _, err := codec.UnmarshalFromBytes(bs, &tx.accountV3Data); err != nil {
+		return nil, InvalidFormat.Wrap(err, "fail to parse transaction bytes")
+	}
+	tx.bytes = append([]byte{}, bs...)
+	return tx, nil
+}
+
+func init() {
+	RegisterFactory(&Factory{
+		Priority:    19,
+		CheckJSON:   checkAccountV3JSON,
+		ParseJSON:   parseAccountV3JSON,
+		CheckBinary: checkAccountV3Binary,
+		ParseBinary: parseAccountV3Binary,
+	})
+}
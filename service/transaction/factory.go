@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"sort"
 
+	"github.com/icon-project/goloop/common/codec"
 	"github.com/icon-project/goloop/common/errors"
 )
 
@@ -76,3 +77,26 @@ func newTransaction(b []byte) (Transaction, error) {
 	}
 	return nil, InvalidFormat.New("UnknownBinary")
 }
+
+type rawItem []byte
+
+func (r *rawItem) UnmarshalRLP(b []byte) error {
+	*r = b
+	return nil
+}
+
+// IsMalformed returns whether the bytes are broken at the level of JSON or
+// the codec, so that they can't be a transaction of any format. Other parse
+// failures may come from a transaction format unknown to this node.
+func IsMalformed(b []byte) bool {
+	if len(b) < 1 {
+		return true
+	}
+	if b[0] == '{' {
+		var jso map[string]json.RawMessage
+		return json.Unmarshal(b, &jso) != nil
+	}
+	var items []rawItem
+	remain, err := codec.BC.UnmarshalFromBytes(b, &items)
+	return err != nil || len(remain) != 0
+}
@@ -0,0 +1,47 @@
+package transaction
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common/codec"
+)
+
+const testTxJSON = `{
+	"version": "0x3",
+	"from": "hx736846756bcdea54366decfdbdae354789815103",
+	"to": "hx0000000000000000000000000000000000000001",
+	"value": "0x1",
+	"stepLimit": "0x100000",
+	"timestamp": "0x5a7f3a7c5d2b0",
+	"nid": "0x1",
+	"signature": "VAia7YZ2Ji6igKWzjR2YsGa2m53nKPrfK7uXYW78QLE+ATehAVZPC40szvAiA6NEU5gCYB4c4qaQzqDh2ugcHgA="
+}`
+
+func TestIsMalformed(t *testing.T) {
+	tx, err := NewTransactionFromJSON([]byte(testTxJSON))
+	assert.NoError(t, err)
+	bs := tx.Bytes()
+	_, err = NewTransaction(bs)
+	assert.NoError(t, err)
+	assert.False(t, IsMalformed(bs))
+
+	// broken bytes
+	assert.True(t, IsMalformed(nil))
+	assert.True(t, IsMalformed(bs[:len(bs)-1]))
+	assert.True(t, IsMalformed(append(append([]byte{}, bs...), 0x01)))
+	assert.True(t, IsMalformed([]byte(`{"version":"0x3"`)))
+	assert.True(t, IsMalformed([]byte(`{"version"}`)))
+
+	// valid envelopes of unknown formats
+	for _, b := range [][]byte{
+		[]byte(`{"version":"0x4","from":"hx736846756bcdea54366decfdbdae354789815103"}`),
+		[]byte(`{"version":"0x3","dataType":"unknown","data":{}}`),
+		codec.BC.MustMarshalToBytes([]interface{}{4, "unknown", []byte{1, 2}}),
+	} {
+		_, err = NewTransaction(b)
+		assert.Error(t, err)
+		assert.False(t, IsMalformed(b), string(b))
+	}
+}
@@ -0,0 +1,325 @@
+package transaction
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/crypto"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/contract"
+	"github.com/icon-project/goloop/service/scoreresult"
+	"github.com/icon-project/goloop/service/state"
+	"github.com/icon-project/goloop/service/txresult"
+)
+
+var multiSigV3HashExclusion = map[string]bool{
+	"signature": true,
+	"multiSig":  true,
+	"txHash":    true,
+}
+
+// MultiSig is the envelope of signatures for the account controlled by
+// the multi-signature key. The address of the account is derived from
+// the threshold and the public keys. Signatures are made on the hash of
+// the transaction, which doesn't include the envelope, so that holders
+// of the keys may sign the transaction independently.
+type MultiSig struct {
+	Threshold  common.HexUint16   `json:"threshold"`
+	PublicKeys []common.HexBytes  `json:"publicKeys"`
+	Signatures []common.Signature `json:"signatures"`
+}
+
+// Key returns the multi-signature key of the envelope.
+func (ms *MultiSig) Key() (*crypto.MultiSigKey, error) {
+	keys := make([]*crypto.PublicKey, len(ms.PublicKeys))
+	for i, bs := range ms.PublicKeys {
+		key, err := crypto.ParsePublicKey(bs)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = key
+	}
+	return crypto.NewMultiSigKey(int(ms.Threshold.Value), keys)
+}
+
+// Verify verifies signatures of the hash with the key of the envelope.
+func (ms *MultiSig) Verify(hash []byte) error {
+	key, err := ms.Key()
+	if err != nil {
+		return err
+	}
+	sigs := make([]*crypto.Signature, len(ms.Signatures))
+	for i, sig := range ms.Signatures {
+		sigs[i] = sig.Signature
+	}
+	return key.Verify(hash, sigs)
+}
+
+type multiSigV3Data struct {
+	Version   common.HexUint16 `json:"version"`
+	From      common.Address   `json:"from"`
+	To        common.Address   `json:"to"`
+	Value     *common.HexInt   `json:"value"`
+	StepLimit common.HexInt    `json:"stepLimit"`
+	TimeStamp common.HexInt64  `json:"timestamp"`
+	NID       *common.HexInt64 `json:"nid,omitempty"`
+	Nonce     *common.HexInt   `json:"nonce,omitempty"`
+	MultiSig  MultiSig         `json:"multiSig"`
+	DataType  *string          `json:"dataType,omitempty"`
+	Data      json.RawMessage  `json:"data,omitempty"`
+}
+
+// v3 returns the transaction V3 without signature. It shares the hash,
+// the checks on data and the execution with transaction V3.
+func (tx *multiSigV3Data) v3() *transactionV3 {
+	return &transactionV3{
+		transactionV3Data: transactionV3Data{
+			Version:   tx.Version,
+			From:      tx.From,
+			To:        tx.To,
+			Value:     tx.Value,
+			StepLimit: tx.StepLimit,
+			TimeStamp: tx.TimeStamp,
+			NID:       tx.NID,
+			Nonce:     tx.Nonce,
+			DataType:  tx.DataType,
+			Data:      tx.Data,
+		},
+	}
+}
+
+// multiSigV3 is the transaction sent by the account controlled by the
+// multi-signature key. Instead of the signature, it has the envelope of
+// signatures.
+type multiSigV3 struct {
+	multiSigV3Data
+	txHash []byte
+	bytes  []byte
+}
+
+func (tx *multiSigV3) Timestamp() int64 {
+	return tx.TimeStamp.Value
+}
+
+func (tx *multiSigV3) TxHash() []byte {
+	if tx.txHash == nil {
+		h, err := tx.v3().calcHash()
+		if err != nil {
+			tx.txHash = []byte{}
+		} else {
+			tx.txHash = h
+		}
+	}
+	return tx.txHash
+}
+
+func (tx *multiSigV3) ID() []byte {
+	return tx.TxHash()
+}
+
+func (tx *multiSigV3) From() module.Address {
+	return &tx.multiSigV3Data.From
+}
+
+func (tx *multiSigV3) To() module.Address {
+	return &tx.multiSigV3Data.To
+}
+
+func (tx *multiSigV3) Version() int {
+	return module.TransactionVersion3
+}
+
+func (tx *multiSigV3) Group() module.TransactionGroup {
+	return module.TransactionGroupNormal
+}
+
+func (tx *multiSigV3) Nonce() *big.Int {
+	if nonce := tx.multiSigV3Data.Nonce; nonce != nil {
+		return &nonce.Int
+	}
+	return nil
+}
+
+func (tx *multiSigV3) IsSkippable() bool {
+	return true
+}
+
+func (tx *multiSigV3) verifySignature() error {
+	key, err := tx.MultiSig.Key()
+	if err != nil {
+		return InvalidSignatureError.Wrap(err, "InvalidMultiSigKey")
+	}
+	if !common.NewAccountAddressFromMultiSigKey(key).Equal(tx.From()) {
+		return InvalidSignatureError.Errorf("InvalidFrom(%s)", tx.From())
+	}
+	if err := tx.MultiSig.Verify(tx.TxHash()); err != nil {
+		return InvalidSignatureError.Wrap(err, "fail to verify signatures")
+	}
+	return nil
+}
+
+func (tx *multiSigV3) Verify() error {
+	if tx.DataType != nil && *tx.DataType == contract.DataTypePatch {
+		return InvalidTxValue.New("PatchNotAllowed")
+	}
+	if err := tx.v3().verifyData(); err != nil {
+		return err
+	}
+	return tx.verifySignature()
+}
+
+func (tx *multiSigV3) ValidateNetwork(nid int) bool {
+	if tx.NID == nil {
+		return true
+	}
+	return int(tx.NID.Value) == nid
+}
+
+func (tx *multiSigV3) PreValidate(wc state.WorldContext, update bool) error {
+	if !wc.Revision().UseMultiSigTransaction() {
+		return InvalidTxValue.New("MultiSigTransactionNotAllowed")
+	}
+	return tx.v3().PreValidate(wc, update)
+}
+
+func (tx *multiSigV3) GetHandler(cm contract.ContractManager) (Handler, error) {
+	th, err := tx.v3().GetHandler(cm)
+	if err != nil {
+		return nil, err
+	}
+	return &multiSigHandler{th}, nil
+}
+
+// multiSigHandler executes the transaction as a normal transaction of the
+// account only if the revision allows it.
+type multiSigHandler struct {
+	Handler
+}
+
+func (h *multiSigHandler) Execute(ctx contract.Context, estimate bool) (txresult.Receipt, error) {
+	if !ctx.Revision().UseMultiSigTransaction() {
+		return nil, errors.CriticalFormatError.New("MultiSigTransactionNotAllowed")
+	}
+	return h.Handler.Execute(ctx, estimate)
+}
+
+func (tx *multiSigV3) Bytes() []byte {
+	if tx.bytes == nil {
+		if bs, err := codec.MarshalToBytes(&tx.multiSigV3Data); err != nil {
+			log.Errorf("Fail to marshal transaction=%+v err=%+v", tx, err)
+			return nil
+		} else {
+			tx.bytes = bs
+		}
+	}
+	return tx.bytes
+}
+
+func (tx *multiSigV3) Hash() []byte {
+	return crypto.SHA3Sum256(tx.Bytes())
+}
+
+func (tx *multiSigV3) ToJSON(version module.JSONVersion) (interface{}, error) {
+	jso := map[string]interface{}{
+		"version":   &tx.multiSigV3Data.Version,
+		"from":      &tx.multiSigV3Data.From,
+		"to":        &tx.multiSigV3Data.To,
+		"stepLimit": &tx.multiSigV3Data.StepLimit,
+		"timestamp": &tx.multiSigV3Data.TimeStamp,
+		"multiSig":  &tx.multiSigV3Data.MultiSig,
+	}
+	if tx.multiSigV3Data.Value != nil {
+		jso["value"] = tx.multiSigV3Data.Value
+	}
+	if tx.multiSigV3Data.NID != nil {
+		jso["nid"] = tx.multiSigV3Data.NID
+	}
+	if tx.multiSigV3Data.Nonce != nil {
+		jso["nonce"] = tx.multiSigV3Data.Nonce
+	}
+	if tx.multiSigV3Data.DataType != nil {
+		jso["dataType"] = *tx.multiSigV3Data.DataType
+	}
+	if tx.multiSigV3Data.Data != nil {
+		jso["data"] = json.RawMessage(tx.multiSigV3Data.Data)
+	}
+	jso["txHash"] = common.HexBytes(tx.ID())
+	return jso, nil
+}
+
+func (tx *multiSigV3) MarshalJSON() ([]byte, error) {
+	if obj, err := tx.ToJSON(module.JSONVersionLast); err != nil {
+		return nil, scoreresult.WithStatus(err, module.StatusIllegalFormat)
+	} else {
+		return json.Marshal(obj)
+	}
+}
+
+func checkMultiSigV3JSON(jso map[string]interface{}) bool {
+	if version, ok := jso["version"]; !ok || version != "0x3" {
+		return false
+	}
+	if _, ok := jso["multiSig"]; !ok {
+		return false
+	}
+	return true
+}
+
+func parseMultiSigV3JSON(js []byte, raw bool) (Transaction, error) {
+	tx := new(multiSigV3)
+	if err := json.Unmarshal(js, &tx.multiSigV3Data); err != nil {
+		return nil, InvalidFormat.Wrapf(err, "Invalid json for multiSigV3(%s)", string(js))
+	}
+	if tx.multiSigV3Data.Version.Value != module.TransactionVersion3 {
+		return nil, InvalidVersion.Errorf("NotTxVersion3(%d)", tx.multiSigV3Data.Version.Value)
+	}
+	if !raw {
+		// other fields can't be kept in binary form
+		var jso map[string]interface{}
+		if err := json.Unmarshal(js, &jso); err != nil {
+			return nil, InvalidFormat.Wrap(err, "InvalidJSON")
+		}
+		bs, err := SerializeMap(jso, nil, multiSigV3HashExclusion)
+		if err != nil {
+			return nil, InvalidFormat.Wrap(err, "InvalidJSON")
+		}
+		id := crypto.SHA3Sum256(append(transactionSaltBytes, bs...))
+		if !bytes.Equal(id, tx.ID()) {
+			return nil, InvalidFormat.New("UnknownFields")
+		}
+	}
+	return tx, nil
+}
+
+func checkMultiSigV3Binary(bs []byte) bool {
+	var d multiSigV3Data
+	if _, err := codec.UnmarshalFromBytes(bs, &d); err != nil {
+		return false
+	}
+	return d.Version.Value == module.TransactionVersion3 &&
+		len(d.MultiSig.PublicKeys) > 0
+}
+
+func parseMultiSigV3Binary(bs []byte) (Transaction, error) {
+	tx := new(multiSigV3)
+	if _, err := codec.UnmarshalFromBytes(bs, &tx.multiSigV3Data); err != nil {
+		return nil, InvalidFormat.Wrap(err, "fail to parse transaction bytes")
+	}
+	tx.bytes = append([]byte{}, bs...)
+	return tx, nil
+}
+
+func init() {
+	RegisterFactory(&Factory{
+		Priority:    18,
+		CheckJSON:   checkMultiSigV3JSON,
+		ParseJSON:   parseMultiSigV3JSON,
+		CheckBinary: checkMultiSigV3Binary,
+		ParseBinary: parseMultiSigV3Binary,
+	})
+}
@@ -0,0 +1,348 @@
+package transaction
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/crypto"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/common/intconv"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/contract"
+	"github.com/icon-project/goloop/service/scoredb"
+	"github.com/icon-project/goloop/service/state"
+	"github.com/icon-project/goloop/service/txresult"
+)
+
+const DataTypeScheduled = "scheduled"
+
+type scheduledData struct {
+	ID     common.HexInt64 `json:"id"`
+	Height common.HexInt64 `json:"height"`
+}
+
+type scheduledV3Data struct {
+	Version   common.HexUint16 `json:"version"`
+	From      *common.Address  `json:"from,omitempty"` // it should be nil
+	TimeStamp common.HexInt64  `json:"timestamp"`
+	DataType  string           `json:"dataType"`
+	Data      json.RawMessage  `json:"data"`
+}
+
+// scheduledV3 is the transaction generated by the chain to execute the
+// call scheduled at the height. It's placed at the start of the block, and
+// the fee is charged to the account registered the call. The call may be
+// executed in a later block if there are more calls than the block allows.
+type scheduledV3 struct {
+	scheduledV3Data
+
+	data  scheduledData
+	id    []byte
+	hash  []byte
+	bytes []byte
+}
+
+func (tx *scheduledV3) Version() int {
+	return module.TransactionVersion3
+}
+
+func (tx *scheduledV3) Group() module.TransactionGroup {
+	return module.TransactionGroupNormal
+}
+
+func (tx *scheduledV3) calcHash() ([]byte, error) {
+	var data interface{}
+	if err := json.Unmarshal(tx.Data, &data); err != nil {
+		return nil, err
+	}
+	bs, err := SerializeMap(map[string]interface{}{
+		"version":   tx.scheduledV3Data.Version.String(),
+		"timestamp": tx.TimeStamp.String(),
+		"dataType":  tx.DataType,
+		"data":      data,
+	}, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	bs = append([]byte("icx_sendTransaction."), bs...)
+	return crypto.SHA3Sum256(bs), nil
+}
+
+func (tx *scheduledV3) ID() []byte {
+	if tx.id == nil {
+		if bs, err := tx.calcHash(); err != nil {
+			panic(err)
+		} else {
+			tx.id = bs
+		}
+	}
+	return tx.id
+}
+
+func (tx *scheduledV3) From() module.Address {
+	return state.SystemAddress
+}
+
+func (tx *scheduledV3) Bytes() []byte {
+	if tx.bytes == nil {
+		tx.bytes = codec.BC.MustMarshalToBytes(&tx.scheduledV3Data)
+	}
+	return tx.bytes
+}
+
+func (tx *scheduledV3) Hash() []byte {
+	if tx.hash == nil {
+		tx.hash = crypto.SHA3Sum256(tx.Bytes())
+	}
+	return tx.hash
+}
+
+func (tx *scheduledV3) Verify() error {
+	return nil
+}
+
+func (tx *scheduledV3) ToJSON(version module.JSONVersion) (interface{}, error) {
+	jso := map[string]interface{}{
+		"version":   &tx.scheduledV3Data.Version,
+		"timestamp": &tx.TimeStamp,
+		"dataType":  tx.DataType,
+		"data":      tx.Data,
+	}
+	jso["txHash"] = common.HexBytes(tx.ID())
+	return jso, nil
+}
+
+func (tx *scheduledV3) ValidateNetwork(nid int) bool {
+	return true
+}
+
+func (tx *scheduledV3) PreValidate(wc state.WorldContext, update bool) error {
+	if !wc.Revision().UseScheduledCall() {
+		return InvalidTxValue.Errorf("UnsupportedDataType(%s)", tx.DataType)
+	}
+	return nil
+}
+
+func (tx *scheduledV3) GetHandler(cm contract.ContractManager) (Handler, error) {
+	return &scheduledHandler{tx: tx, cm: cm}, nil
+}
+
+func (tx *scheduledV3) Timestamp() int64 {
+	return tx.TimeStamp.Value
+}
+
+func (tx *scheduledV3) Nonce() *big.Int {
+	return nil
+}
+
+func (tx *scheduledV3) To() module.Address {
+	return state.SystemAddress
+}
+
+func (tx *scheduledV3) IsSkippable() bool {
+	return false
+}
+
+func (tx *scheduledV3) IsSystem() bool {
+	return true
+}
+
+// ScheduledCallExecutedSignature is the signature of the event log added
+// to the receipt of a scheduled call. Its indexed value is the ID of the
+// call, and data are the scheduled height and the executed height.
+const ScheduledCallExecutedSignature = "ScheduledCallExecuted(int,int,int)"
+
+type scheduledHandler struct {
+	tx *scheduledV3
+	cm contract.ContractManager
+	th Handler
+}
+
+func (h *scheduledHandler) Prepare(ctx contract.Context) (state.WorldContext, error) {
+	lq := []state.LockRequest{
+		{state.WorldIDStr, state.AccountWriteLock},
+	}
+	return ctx.GetFuture(lq), nil
+}
+
+// Execute releases the deposit of the call to the account registered it,
+// then executes the call as a transaction of the account.
+func (h *scheduledHandler) Execute(ctx contract.Context, estimate bool) (txresult.Receipt, error) {
+	if estimate {
+		return nil, errors.InvalidStateError.New("EstimationNotAllowed")
+	}
+	if !ctx.Revision().UseScheduledCall() {
+		return nil, errors.CriticalFormatError.New("ScheduledCallNotAllowed")
+	}
+	if h.tx.data.Height.Value > ctx.BlockHeight() {
+		return nil, errors.CriticalFormatError.Errorf(
+			"InvalidScheduledHeight(height=%d,block=%d)", h.tx.data.Height.Value, ctx.BlockHeight())
+	}
+	sys := ctx.GetAccountState(state.SystemID)
+	sc, err := contract.RemoveScheduledCall(sys, h.tx.data.ID.Value)
+	if err != nil {
+		return nil, err
+	}
+	if sc == nil || sc.Height != h.tx.data.Height.Value {
+		return nil, errors.CriticalFormatError.Errorf(
+			"InvalidScheduledCall(id=%d)", h.tx.data.ID.Value)
+	}
+
+	sys.SetBalance(new(big.Int).Sub(sys.GetBalance(), sc.Deposit))
+	as := ctx.GetAccountState(sc.From.ID())
+	as.SetBalance(new(big.Int).Add(as.GetBalance(), sc.Deposit))
+
+	dt := contract.DataTypeCall
+	h.th, err = NewHandler(h.cm, module.TransactionGroupNormal, sc.From, sc.To,
+		new(big.Int), sc.StepLimit, &dt, sc.Data())
+	if err != nil {
+		return nil, err
+	}
+	// Calls beyond contract.MaxScheduledCallsPerBlock are carried over to
+	// following blocks, so the receipt shows when it was actually executed.
+	h.th.(*transactionHandler).sysLogs = []sysEventLog{{
+		indexed: [][]byte{
+			[]byte(ScheduledCallExecutedSignature),
+			intconv.Int64ToBytes(sc.ID),
+		},
+		data: [][]byte{
+			intconv.Int64ToBytes(sc.Height),
+			intconv.Int64ToBytes(ctx.BlockHeight()),
+		},
+	}}
+	return h.th.Execute(ctx, false)
+}
+
+func (h *scheduledHandler) Dispose() {
+	if h.th != nil {
+		h.th.Dispose()
+	}
+}
+
+// NewScheduledTransaction returns the transaction executing the call
+// scheduled at the height.
+func NewScheduledTransaction(id, height, ts int64) (Transaction, error) {
+	tx := new(scheduledV3)
+	tx.scheduledV3Data.Version.Value = module.TransactionVersion3
+	tx.TimeStamp.Value = ts
+	tx.DataType = DataTypeScheduled
+	tx.data.ID.Value = id
+	tx.data.Height.Value = height
+	bs, err := json.Marshal(&tx.data)
+	if err != nil {
+		return nil, err
+	}
+	tx.Data = bs
+	return &transaction{tx}, nil
+}
+
+// NewScheduledTransactions returns transactions for calls to be executed
+// in the block of the world context. It includes calls carried over from
+// previous blocks, up to contract.MaxScheduledCallsPerBlock.
+func NewScheduledTransactions(wc state.WorldContext) ([]module.Transaction, error) {
+	if !wc.Revision().UseScheduledCall() {
+		return nil, nil
+	}
+	as := scoredb.NewStateStoreWith(wc.GetAccountSnapshot(state.SystemID))
+	ids := contract.DueScheduledCalls(as, wc.BlockHeight(), contract.MaxScheduledCallsPerBlock)
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	txs := make([]module.Transaction, 0, len(ids))
+	for _, id := range ids {
+		sc, err := contract.GetScheduledCall(as, id)
+		if err != nil {
+			return nil, err
+		}
+		if sc == nil {
+			return nil, errors.InvalidStateError.Errorf("NoScheduledCall(id=%d)", id)
+		}
+		tx, err := NewScheduledTransaction(id, sc.Height, wc.BlockTimeStamp())
+		if err != nil {
+			return nil, err
+		}
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
+
+// ScheduledCallID returns ID of the scheduled call executed by the
+// transaction. It returns false if it's not a transaction for a scheduled
+// call.
+func ScheduledCallID(tx module.Transaction) (int64, bool) {
+	if stx, ok := Unwrap(tx).(*scheduledV3); ok {
+		return stx.data.ID.Value, true
+	}
+	return 0, false
+}
+
+// IsSystemTransaction returns whether the transaction is generated by the
+// chain. Those are not allowed to be submitted by users.
+func IsSystemTransaction(tx module.Transaction) bool {
+	if stx, ok := Unwrap(tx).(interface{ IsSystem() bool }); ok {
+		return stx.IsSystem()
+	}
+	return false
+}
+
+func (tx *scheduledV3) parseData() error {
+	if err := json.Unmarshal(tx.Data, &tx.data); err != nil {
+		return InvalidFormat.Wrap(err, "InvalidData")
+	}
+	return nil
+}
+
+func checkScheduledV3JSON(jso map[string]interface{}) bool {
+	if d, ok := jso["dataType"]; !ok || d != DataTypeScheduled {
+		return false
+	}
+	if v, ok := jso["version"]; !ok || v != "0x3" {
+		return false
+	}
+	return true
+}
+
+func parseScheduledV3JSON(bs []byte, raw bool) (Transaction, error) {
+	tx := new(scheduledV3)
+	if err := json.Unmarshal(bs, &tx.scheduledV3Data); err != nil {
+		return nil, InvalidFormat.Wrap(err, "InvalidJSON")
+	}
+	if tx.scheduledV3Data.From != nil {
+		return nil, InvalidFormat.New("InvalidFromValue(NonNil)")
+	}
+	if err := tx.parseData(); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+func checkScheduledV3Bytes(bs []byte) bool {
+	var d scheduledV3Data
+	if _, err := codec.BC.UnmarshalFromBytes(bs, &d); err != nil {
+		return false
+	}
+	return d.From == nil && d.DataType == DataTypeScheduled
+}
+
+func parseScheduledV3Bytes(bs []byte) (Transaction, error) {
+	tx := new(scheduledV3)
+	if _, err := codec.BC.UnmarshalFromBytes(bs, &tx.scheduledV3Data); err != nil {
+		return nil, err
+	}
+	if err := tx.parseData(); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+func init() {
+	RegisterFactory(&Factory{
+		Priority:    14,
+		CheckJSON:   checkScheduledV3JSON,
+		ParseJSON:   parseScheduledV3JSON,
+		CheckBinary: checkScheduledV3Bytes,
+		ParseBinary: parseScheduledV3Bytes,
+	})
+}
@@ -157,13 +157,27 @@ func (tx *transactionV3) Version() int {
 }
 
 func (tx *transactionV3) isDeployType(cType string) bool {
-	if cType == state.CTAppZip || cType == state.CTAppJava {
+	if cType == state.CTAppZip || cType == state.CTAppJava || cType == state.CTAppWasm {
 		return true
 	}
 	return false
 }
 
 func (tx *transactionV3) Verify() error {
+	if err := tx.transactionV3Data.verifyData(); err != nil {
+		return err
+	}
+
+	// signature verification
+	if err := tx.verifySignature(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// verifyData checks value and data of the transaction.
+func (tx *transactionV3Data) verifyData() error {
 	// value >= 0
 	if tx.Value != nil && tx.Value.Sign() < 0 {
 		return InvalidTxValue.Errorf("InvalidTxValue(%s)", tx.Value.String())
@@ -210,18 +224,25 @@ func (tx *transactionV3) Verify() error {
 			if tx.Data == nil {
 				return InvalidTxValue.New("TxData for deposit is NIL")
 			}
+		case contract.DataTypeBatch:
+			if tx.Data == nil {
+				return InvalidTxValue.New("TxData for batch is NIL")
+			}
+			if !tx.To.Equal(&tx.From) {
+				return InvalidTxValue.Errorf("InvalidBatchTarget(%s)", tx.To.String())
+			}
+			if tx.Value != nil && tx.Value.Sign() != 0 {
+				return InvalidTxValue.Errorf("InvalidTxValue(%s)", tx.Value.String())
+			}
+			if _, err := contract.ParseBatchData(tx.Data); err != nil {
+				return InvalidTxValue.Wrap(err, "TxData is invalid")
+			}
 			// Remove verification for IC2-315
 			// if _, err := contract.ParseDepositData(tx.Data); err != nil {
 			// 	return InvalidTxValue.Wrap(err, "TxData is invalid")
 			// }
 		}
 	}
-
-	// signature verification
-	if err := tx.verifySignature(); err != nil {
-		return err
-	}
-
 	return nil
 }
 
@@ -245,6 +266,15 @@ func (tx *transactionV3) PreValidate(wc state.WorldContext, update bool) error {
 		}
 	}
 
+	if tx.DataType != nil && *tx.DataType == contract.DataTypeDeploy && !wc.Revision().UseWasmEE() {
+		if deploy, err := contract.ParseDeployData(tx.Data); err == nil && deploy.ContentType == state.CTAppWasm {
+			return InvalidTxValue.Errorf("UnsupportedContentType(%s)", deploy.ContentType)
+		}
+	}
+	if tx.DataType != nil && *tx.DataType == contract.DataTypeBatch && !wc.Revision().UseBatchTransaction() {
+		return InvalidTxValue.Errorf("UnsupportedDataType(%s)", *tx.DataType)
+	}
+
 	// balance >= (fee + value)
 	stepPrice := wc.StepPrice()
 
@@ -32,10 +32,27 @@ type transactionHandler struct {
 
 	chandler contract.ContractHandler
 
+	// For transactions sent by contract accounts. preSteps is steps used
+	// for validating the transaction, preStatus is the failure of the
+	// validation, and payByDeposit makes the sender pay the fee with its
+	// deposit instead of fee sharing of the receiver.
+	preSteps     *big.Int
+	preStatus    error
+	payByDeposit bool
+
+	// For transactions generated by the chain. sysLogs are event logs of
+	// the system added to the receipt regardless of the result.
+	sysLogs []sysEventLog
+
 	// Assigned at Execute()
 	cc contract.CallContext
 }
 
+type sysEventLog struct {
+	indexed [][]byte
+	data    [][]byte
+}
+
 func NewHandler(cm contract.ContractManager, group module.TransactionGroup, from, to module.Address, value, stepLimit *big.Int, dataType *string, data []byte) (Handler, error) {
 	th := &transactionHandler{
 		group:     group,
@@ -61,6 +78,8 @@ func NewHandler(cm contract.ContractManager, group module.TransactionGroup, from
 			ctype = contract.CTypePatch
 		case contract.DataTypeDeposit:
 			ctype = contract.CTypeDeposit
+		case contract.DataTypeBatch:
+			ctype = contract.CTypeBatch
 		default:
 			return nil, InvalidFormat.Errorf("IllegalDataType(type=%s)", *dataType)
 		}
@@ -79,7 +98,10 @@ func (th *transactionHandler) Prepare(ctx contract.Context) (state.WorldContext,
 }
 
 func (th *transactionHandler) checkBalance(cc contract.CallContext) error {
-	value := new(big.Int).Mul(cc.StepPrice(), th.stepLimit)
+	value := new(big.Int)
+	if !th.payByDeposit || !canPayByDeposit(cc, cc.GetAccountState(th.from.ID())) {
+		value.Mul(cc.StepPrice(), th.stepLimit)
+	}
 	if th.value != nil {
 		value.Add(value, th.value)
 	}
@@ -155,6 +177,9 @@ func (th *transactionHandler) DoExecute(cc contract.CallContext, estimate, isPat
 			return err, nil, nil
 		}
 	}
+	if th.preSteps != nil && !cc.DeductSteps(th.preSteps) {
+		return scoreresult.ErrOutOfStep, nil, nil
+	}
 	if !cc.ApplySteps(state.StepTypeDefault, 1) {
 		return scoreresult.ErrOutOfStep, nil, nil
 	}
@@ -165,6 +190,9 @@ func (th *transactionHandler) DoExecute(cc contract.CallContext, estimate, isPat
 			return scoreresult.ErrOutOfStep, nil, nil
 		}
 	}
+	if th.preStatus != nil {
+		return th.preStatus, nil, nil
+	}
 	if !isPatch {
 		if err := th.checkBlocked(cc); err != nil {
 			return err, nil, nil
@@ -225,7 +253,15 @@ func (th *transactionHandler) Execute(ctx contract.Context, estimate bool) (txre
 
 	stepAll := stepUsed
 	var redeemed *big.Int
-	if cc.FeeSharingEnabled() && stepPrice.Sign() > 0 {
+	if th.payByDeposit {
+		if cc.FeeSharingEnabled() && stepPrice.Sign() > 0 {
+			redeemed = th.payStepsByDeposit(ctx, cc, stepUsed)
+			if redeemed != nil {
+				stepUsed = new(big.Int)
+				logger.TSystemf("FRAME[%d] STEP payed by deposit value=%d", fid, redeemed)
+			}
+		}
+	} else if cc.FeeSharingEnabled() && stepPrice.Sign() > 0 {
 		var err error
 		redeemed, err = cc.RedeemSteps(stepUsed)
 		if err != nil {
@@ -288,9 +324,21 @@ func (th *transactionHandler) Execute(ctx contract.Context, estimate bool) (txre
 	if status == nil {
 		cc.GetEventLogs(receipt)
 	}
-	if redeemed := cc.GetRedeemLogs(receipt); redeemed && stepUsed.Sign() != 0 {
+	if th.payByDeposit {
+		if redeemed != nil {
+			receipt.AddPayment(th.from, redeemed)
+		}
+	} else if redeemed := cc.GetRedeemLogs(receipt); redeemed && stepUsed.Sign() != 0 {
 		receipt.AddPayment(th.from, stepUsed)
 	}
+	for _, e := range th.sysLogs {
+		receipt.AddLog(state.SystemAddress, e.indexed, e.data)
+	}
+	if bh, ok := th.chandler.(*contract.BatchHandler); ok && cc.Revision().UseBatchTransaction() {
+		for _, r := range bh.Results() {
+			receipt.AddBatchResult(r.Status, r.StepUsed)
+		}
+	}
 	receipt.SetResult(s, stepAll, stepPrice, addr)
 	receipt.SetReason(status)
 
@@ -299,6 +347,22 @@ func (th *transactionHandler) Execute(ctx contract.Context, estimate bool) (txre
 	return receipt, nil
 }
 
+// payStepsByDeposit pays all the steps with the deposit of the sender. It
+// returns nil without any change if the deposit can't pay all of them.
+func (th *transactionHandler) payStepsByDeposit(ctx contract.Context, cc contract.CallContext, steps *big.Int) *big.Int {
+	as := ctx.GetAccountState(th.from.ID())
+	if !canPayByDeposit(cc, as) {
+		return nil
+	}
+	snapshot := as.GetSnapshot()
+	payed, err := as.PaySteps(cc, steps)
+	if err != nil || payed == nil || payed.Cmp(steps) < 0 {
+		as.Reset(snapshot)
+		return nil
+	}
+	return payed
+}
+
 func (th *transactionHandler) Dispose() {
 	// Actually it is called after calling Execute(), so cc can't be nil.
 	if th.cc != nil {
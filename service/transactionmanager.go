@@ -31,6 +31,9 @@ type TransactionManager struct {
 
 	callback func()
 
+	// validator validates transactions with the state on admission.
+	validator func(tx transaction.Transaction) error
+
 	txWaiters map[hashValue][]chan<- interface{}
 }
 
@@ -203,6 +206,9 @@ func (m *TransactionManager) verifyTx(tx transaction.Transaction) error {
 		return errors.InvalidNetworkError.Errorf(
 			"ValidateNetwork(nid=%#x) fail", m.nid)
 	}
+	if transaction.IsSystemTransaction(tx) {
+		return InvalidTransactionError.New("SystemTransaction")
+	}
 	lastTS := atomic.LoadInt64(&m.lastTS[tx.Group()])
 	if err := m.tsc.CheckWithCurrent(lastTS, tx); err != nil {
 		return err
@@ -211,8 +217,20 @@ func (m *TransactionManager) verifyTx(tx transaction.Transaction) error {
 		return InvalidTransactionError.Wrap(err,
 			"Failed to verify transaction")
 	}
+	if m.validator != nil {
+		if err := m.validator(tx); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+// SetValidator sets the function validating transactions with the state
+// before adding them to the pool.
+func (m *TransactionManager) SetValidator(v func(tx transaction.Transaction) error) {
+	m.validator = v
+}
+
 func (m *TransactionManager) addInLock(tx transaction.Transaction, direct bool) error {
 	if m.txBucket.Has(tx.ID()) {
 		return ErrCommittedTransaction
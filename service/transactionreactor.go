@@ -1,6 +1,7 @@
 package service
 
 import (
+	"github.com/icon-project/goloop/common/errors"
 	"github.com/icon-project/goloop/common/log"
 	"github.com/icon-project/goloop/module"
 	"github.com/icon-project/goloop/network"
@@ -26,6 +27,29 @@ var (
 	}
 )
 
+func init() {
+	network.RegisterPayloadDecoder(module.ProtoTransaction, ReactorName, false, decodeMessage)
+}
+
+func decodeMessage(spi module.ProtocolInfo, b []byte) (interface{}, error) {
+	switch spi {
+	case protoPropagateTransaction, protoResponseTransaction:
+		tx, err := transaction.NewTransaction(b)
+		if err != nil {
+			return nil, err
+		}
+		return tx.ToJSON(module.JSONVersionLast)
+	case protoRequestTransaction:
+		msg := new(msgTransactionRequest)
+		if err := msg.SetBytes(b); err != nil {
+			return nil, err
+		}
+		return msg, nil
+	default:
+		return nil, errors.NotFoundError.Errorf("UnknownProtocol(%s)", spi)
+	}
+}
+
 type TransactionReactor struct {
 	nm         module.NetworkManager
 	membership module.ProtocolHandler
@@ -40,9 +64,7 @@ func (r *TransactionReactor) OnReceive(subProtocol module.ProtocolInfo, buf []by
 	case protoPropagateTransaction:
 		tx, err := transaction.NewTransaction(buf)
 		if err != nil {
-			r.log.Warnf("InvalidPacket(PropagateTransaction) from=%s", peerId.String())
-			r.log.Debugf("Failed to unmarshal transaction. buf=%x, err=%+v", buf, err)
-			return false, err
+			return false, r.invalidTransaction("PropagateTransaction", buf, err, peerId)
 		}
 
 		if err := r.tm.Add(tx, false); err != nil {
@@ -52,9 +74,7 @@ func (r *TransactionReactor) OnReceive(subProtocol module.ProtocolInfo, buf []by
 	case protoResponseTransaction:
 		tx, err := transaction.NewTransaction(buf)
 		if err != nil {
-			r.log.Warnf("InvalidPacket(ResponseTransaction) from=%s", peerId.String())
-			r.log.Debugf("Failed to unmarshal transaction. buf=%x, err=%+v", buf, err)
-			return false, err
+			return false, r.invalidTransaction("ResponseTransaction", buf, err, peerId)
 		}
 
 		if err := r.tm.Add(tx, false); err != nil {
@@ -74,6 +94,22 @@ func (r *TransactionReactor) OnReceive(subProtocol module.ProtocolInfo, buf []by
 	return false, nil
 }
 
+// invalidTransaction returns the error for the transaction failed to parse.
+// Only broken bytes are reported as misbehavior of the peer, because a valid
+// transaction of a format unknown to this node may be relayed by honest
+// peers of newer versions.
+func (r *TransactionReactor) invalidTransaction(
+	name string, buf []byte, err error, peerId module.PeerID,
+) error {
+	r.log.Debugf("Failed to unmarshal transaction. buf=%x, err=%+v", buf, err)
+	if transaction.IsMalformed(buf) {
+		r.log.Warnf("InvalidPacket(%s) from=%s", name, peerId.String())
+		return network.DecodeMessageError.Wrap(err, "InvalidTransaction")
+	}
+	r.log.Infof("UnknownTransaction(%s) from=%s", name, peerId.String())
+	return err
+}
+
 func (r *TransactionReactor) PropagateTransaction(tx transaction.Transaction) error {
 	if r != nil && r.membership != nil {
 		return r.membership.Multicast(protoPropagateTransaction, tx.Bytes(), module.ROLE_VALIDATOR)
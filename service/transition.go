@@ -540,11 +540,22 @@ func (t *transition) doExecute(alreadyValidated bool) {
 		t.reportExecution(err)
 		return
 	}
+	if err := t.checkScheduledCalls(ctx); err != nil {
+		t.reportExecution(err)
+		return
+	}
 	normalReceipts := make([]txresult.Receipt, normalCount)
 	if err := t.executeTxs(t.normalTransactions, ctx, normalReceipts); err != nil {
 		t.reportExecution(err)
 		return
 	}
+	if ctx.Revision().UseScheduledCall() {
+		sys := ctx.GetAccountState(state.SystemID)
+		if err := contract.UpdateScheduleBacklog(sys, ctx.BlockHeight()); err != nil {
+			t.reportExecution(err)
+			return
+		}
+	}
 	cumulativeSteps := big.NewInt(0)
 	gatheredFee := big.NewInt(0)
 	virtualFee := new(big.Int)
@@ -642,11 +653,45 @@ func (t *transition) executeTxs(l module.TransactionList, ctx contract.Context,
 		return t.executeTxsSequential(l, ctx, rctBuf)
 	}
 	if cc := t.chain.ConcurrencyLevel(); cc > 1 {
+		if useOptimisticExecution(t.chain) {
+			return t.executeTxsOptimistic(cc, l, ctx, rctBuf)
+		}
 		return t.executeTxsConcurrent(cc, l, ctx, rctBuf)
 	}
 	return t.executeTxsSequential(l, ctx, rctBuf)
 }
 
+// checkScheduledCalls checks whether the transactions for the calls to be
+// executed in the block are placed at the start of the block in order.
+// Only transactions from the system, like the base transaction, may precede
+// them.
+func (t *transition) checkScheduledCalls(ctx contract.Context) error {
+	if !ctx.Revision().UseScheduledCall() {
+		return nil
+	}
+	sys := ctx.GetAccountState(state.SystemID)
+	ids := contract.DueScheduledCalls(sys, ctx.BlockHeight(), contract.MaxScheduledCallsPerBlock)
+	idx, leading := 0, true
+	for i := t.normalTransactions.Iterator(); i.Has(); i.Next() {
+		tx, _, err := i.Get()
+		if err != nil {
+			return errors.Wrap(err, "checkScheduledCalls: fail to get transaction")
+		}
+		if id, ok := transaction.ScheduledCallID(tx); ok {
+			if !leading || idx >= len(ids) || ids[idx] != id {
+				return errors.CriticalFormatError.Errorf("InvalidScheduledCall(id=%d)", id)
+			}
+			idx++
+		} else if from := tx.From(); idx > 0 || from == nil || !from.Equal(state.SystemAddress) {
+			leading = false
+		}
+	}
+	if idx < len(ids) {
+		return errors.InvalidStateError.Errorf("MissingScheduledCalls(ids=%v)", ids[idx:])
+	}
+	return nil
+}
+
 func (t *transition) finalizeNormalTransaction() error {
 	return t.normalTransactions.Flush()
 }
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/crypto"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/common/wallet"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/contract"
+	"github.com/icon-project/goloop/service/eeproxy"
+	"github.com/icon-project/goloop/service/platform/basic"
+	"github.com/icon-project/goloop/service/scoreapi"
+	"github.com/icon-project/goloop/service/state"
+	"github.com/icon-project/goloop/service/transaction"
+)
+
+var testWalletScore = common.MustNewAddressFromString("cx0000000000000000000000000000000000000200")
+
+// newTestWalletContract returns the wallet accepting transactions with
+// authorization data of the hash of the transaction hash.
+func newTestWalletContract(t *testing.T) *eeproxy.GoContract {
+	c, err := eeproxy.NewGoContract(
+		&eeproxy.GoMethod{
+			Method: &scoreapi.Method{
+				Type:  scoreapi.Function,
+				Name:  transaction.AccountValidateMethod,
+				Flags: scoreapi.FlagExternal | scoreapi.FlagReadOnly,
+				Inputs: []scoreapi.Parameter{
+					{Name: "txHash", Type: scoreapi.Bytes},
+					{Name: "authData", Type: scoreapi.Bytes},
+				},
+				Outputs: []scoreapi.DataType{scoreapi.Bool},
+			},
+			Handler: func(ctx eeproxy.GoContext, params []interface{}) (interface{}, error) {
+				return bytes.Equal(crypto.SHA3Sum256(params[0].([]byte)), params[1].([]byte)), nil
+			},
+		},
+	)
+	assert.NoError(t, err)
+	return c
+}
+
+func newTestAccountTx(t *testing.T, from module.Address, ts int64, data interface{}, valid bool) transaction.Transaction {
+	param := map[string]interface{}{
+		"version":   "0x3",
+		"from":      from.String(),
+		"to":        testOEScore.String(),
+		"nid":       "0x1",
+		"stepLimit": "0x100000",
+		"timestamp": fmt.Sprintf("%#x", ts),
+		"dataType":  "call",
+		"data":      data,
+		"authData":  "0x",
+	}
+	js, err := json.Marshal(param)
+	assert.NoError(t, err)
+	tx, err := transaction.NewTransactionFromJSON(js)
+	assert.NoError(t, err)
+
+	auth := crypto.SHA3Sum256(tx.ID())
+	if !valid {
+		auth = tx.ID()
+	}
+	param["authData"] = common.HexBytes(auth).String()
+	js, err = json.Marshal(param)
+	assert.NoError(t, err)
+	tx, err = transaction.NewTransactionFromJSON(js)
+	assert.NoError(t, err)
+	return tx
+}
+
+func TestTransition_AccountTransaction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "account")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	wallets := []module.Wallet{wallet.New()}
+	cs := eeproxy.NewGoContracts()
+	w := newTestWalletContract(t)
+	cs.Register("wallet", w)
+	chain := newTestSystemChain(t, dir, wallets, cs)
+	defer chain.close()
+
+	balance := new(big.Int).Exp(big.NewInt(10), big.NewInt(20), nil)
+	chain.update(t, func(ws state.WorldState) {
+		as := ws.GetAccountState(testWalletScore.ID())
+		assert.True(t, as.InitContractAccount(wallets[0].Address()))
+		txHash := crypto.SHA3Sum256([]byte("deploy wallet"))
+		_, err := as.DeployContract([]byte("wallet"), state.JavaEE, state.CTAppJava, nil, txHash)
+		assert.NoError(t, err)
+		assert.NoError(t, as.AcceptContract(txHash, nil))
+		as.SetAPIInfo(w.Info())
+		as.SetBalance(balance)
+	})
+
+	add := func(delta string) interface{} {
+		return map[string]interface{}{
+			"method": "add",
+			"params": map[string]interface{}{"key": "w", "delta": delta},
+		}
+	}
+	valid := newTestAccountTx(t, testWalletScore, 1, add("0x3"), true)
+	invalid := newTestAccountTx(t, testWalletScore, 2, add("0x5"), false)
+
+	// binary form keeps the transaction
+	tx2, err := transaction.NewTransaction(valid.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, valid.ID(), tx2.ID())
+	assert.NoError(t, tx2.Verify())
+	assert.Equal(t, valid.From().String(), tx2.From().String())
+
+	// only authorized one is selected on the proposal
+	ws, err := state.WorldStateFromSnapshot(chain.parent.worldSnapshot)
+	assert.NoError(t, err)
+	wc := state.NewWorldContext(ws, common.NewBlockInfo(1, 1000000), nil, chain.parent.plt)
+	ctx := contract.NewContext(wc, chain.cm, chain.em, &testOEChain{level: 1}, log.GlobalLogger(), nil)
+	assert.NoError(t, valid.PreValidate(ctx, false))
+	err = invalid.PreValidate(ctx, false)
+	assert.True(t, transaction.InvalidSignatureError.Equals(err), "err=%+v", err)
+
+	// the validation is done again on execution, and the account pays fee
+	// also for the failed validation.
+	rcts, err := chain.execute(t, 1, []module.Transaction{valid, invalid})
+	assert.NoError(t, err)
+	if assert.Len(t, rcts, 2) {
+		assert.Equal(t, module.StatusSuccess, rcts[0].Status())
+		assert.Equal(t, module.StatusAccessDenied, rcts[1].Status())
+		assert.Equal(t, 1, rcts[1].StepPrice().Sign())
+		assert.Equal(t, 1, rcts[1].StepUsed().Cmp(big.NewInt(1000)))
+		fee := new(big.Int).Mul(rcts[0].StepUsed(), rcts[0].StepPrice())
+		fee.Add(fee, new(big.Int).Mul(rcts[1].StepUsed(), rcts[1].StepPrice()))
+		assert.Equal(t, new(big.Int).Sub(balance, fee), chain.balance(testWalletScore))
+	}
+	assert.Equal(t, int64(3), chain.counter("w"))
+
+	// the pool validates them with the state of the last finalized block
+	m := &manager{
+		plt:   basic.Platform,
+		cm:    chain.cm,
+		eem:   chain.em,
+		chain: &testOEChain{level: 1},
+		log:   log.GlobalLogger(),
+	}
+	assert.NoError(t, m.validateTransaction(invalid))
+	m.finalized.Store(chain.parent)
+	assert.NoError(t, m.validateTransaction(newTestAccountTx(t, testWalletScore, 3, add("0x1"), true)))
+	err = m.validateTransaction(newTestAccountTx(t, testWalletScore, 4, add("0x1"), false))
+	assert.True(t, transaction.InvalidSignatureError.Equals(err), "err=%+v", err)
+	assert.NoError(t, m.validateTransaction(newTestOETx(t, wallets[0], 5, testOEScore, 0, add("0x1")).(transaction.Transaction)))
+}
+
+func TestTransition_AccountTransactionRevision(t *testing.T) {
+	dir, err := ioutil.TempDir("", "account")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	wallets := []module.Wallet{wallet.New()}
+	chain := newTestSystemChainWithRevision(t, dir, wallets, eeproxy.NewGoContracts(), basic.Revision8)
+	defer chain.close()
+
+	tx := newTestAccountTx(t, testWalletScore, 1, map[string]interface{}{"method": "add"}, true)
+	err = tx.PreValidate(chain.worldContext(1), false)
+	assert.True(t, transaction.InvalidTxValue.Equals(err), "err=%+v", err)
+	_, err = chain.execute(t, 1, []module.Transaction{tx})
+	assert.Error(t, err)
+}
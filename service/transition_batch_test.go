@@ -0,0 +1,144 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common/crypto"
+	"github.com/icon-project/goloop/common/wallet"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/eeproxy"
+	"github.com/icon-project/goloop/service/platform/basic"
+	"github.com/icon-project/goloop/service/transaction"
+)
+
+func newTestBatchTx(t *testing.T, w module.Wallet, ts int64, items []map[string]interface{}) module.Transaction {
+	param := map[string]interface{}{
+		"version":   "0x3",
+		"from":      w.Address().String(),
+		"to":        w.Address().String(),
+		"nid":       "0x1",
+		"stepLimit": "0x100000",
+		"timestamp": fmt.Sprintf("%#x", ts),
+		"dataType":  "batch",
+		"data":      items,
+	}
+	js, err := json.Marshal(param)
+	assert.NoError(t, err)
+	bs, err := transaction.SerializeJSON(js, nil, map[string]bool{"signature": true})
+	assert.NoError(t, err)
+	sig, err := w.Sign(crypto.SHA3Sum256(append([]byte("icx_sendTransaction."), bs...)))
+	assert.NoError(t, err)
+	param["signature"] = base64.StdEncoding.EncodeToString(sig)
+	js, err = json.Marshal(param)
+	assert.NoError(t, err)
+	tx, err := transaction.NewTransactionFromJSON(js)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Verify())
+	return tx
+}
+
+func batchResultsOf(t *testing.T, rct module.Receipt) []map[string]interface{} {
+	jso, err := rct.ToJSON(module.JSONVersionLast)
+	assert.NoError(t, err)
+	js, err := json.Marshal(jso)
+	assert.NoError(t, err)
+	var result struct {
+		BatchResults []map[string]interface{} `json:"batchResults"`
+	}
+	assert.NoError(t, json.Unmarshal(js, &result))
+	return result.BatchResults
+}
+
+func TestTransition_BatchTransaction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "batch")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	wallets := []module.Wallet{wallet.New(), wallet.New()}
+	chain := newTestSystemChain(t, dir, wallets, eeproxy.NewGoContracts())
+	defer chain.close()
+
+	add := func(delta string) map[string]interface{} {
+		return map[string]interface{}{
+			"to":       testOEScore.String(),
+			"dataType": "call",
+			"data": map[string]interface{}{
+				"method": "add",
+				"params": map[string]interface{}{"key": "b", "delta": delta},
+			},
+		}
+	}
+	to := wallet.New().Address()
+	transfer := map[string]interface{}{
+		"to":    to.String(),
+		"value": "0x5",
+	}
+	fail := map[string]interface{}{
+		"to":       testOEScore.String(),
+		"dataType": "call",
+		"data":     map[string]interface{}{"method": "unknown"},
+	}
+
+	rcts, err := chain.execute(t, 1, []module.Transaction{
+		newTestBatchTx(t, wallets[0], 1, []map[string]interface{}{add("0x2"), transfer, add("0x3")}),
+		newTestBatchTx(t, wallets[1], 2, []map[string]interface{}{transfer, add("0x7"), fail, add("0x1")}),
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, rcts, 2) {
+		// all sub operations are applied in order
+		assert.Equal(t, module.StatusSuccess, rcts[0].Status())
+		results := batchResultsOf(t, rcts[0])
+		if assert.Len(t, results, 3) {
+			for _, r := range results {
+				assert.Equal(t, "0x1", r["status"])
+			}
+		}
+
+		// failure of one sub operation reverts all of them
+		assert.NotEqual(t, module.StatusSuccess, rcts[1].Status())
+		results = batchResultsOf(t, rcts[1])
+		if assert.Len(t, results, 3) {
+			assert.Equal(t, "0x1", results[1]["status"])
+			assert.Equal(t, "0x0", results[2]["status"])
+			assert.NotNil(t, results[2]["failure"])
+		}
+	}
+	assert.Equal(t, int64(5), chain.counter("b"))
+	assert.Equal(t, big.NewInt(5), chain.balance(to))
+}
+
+func TestTransition_BatchTransactionRevision(t *testing.T) {
+	dir, err := ioutil.TempDir("", "batch")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	wallets := []module.Wallet{wallet.New()}
+	chain := newTestSystemChainWithRevision(t, dir, wallets, eeproxy.NewGoContracts(), basic.Revision8)
+	defer chain.close()
+
+	to := wallet.New().Address()
+	tx := newTestBatchTx(t, wallets[0], 1, []map[string]interface{}{
+		{"to": to.String(), "value": "0x5"},
+	})
+
+	// rejected by the pool below the revision
+	err = tx.(transaction.Transaction).PreValidate(chain.worldContext(1), false)
+	assert.True(t, transaction.InvalidTxValue.Equals(err), "err=%+v", err)
+
+	// it fails without batch results on execution
+	rcts, err := chain.execute(t, 1, []module.Transaction{tx})
+	assert.NoError(t, err)
+	if assert.Len(t, rcts, 1) {
+		assert.Equal(t, module.StatusInvalidParameter, rcts[0].Status())
+		assert.Len(t, batchResultsOf(t, rcts[0]), 0)
+	}
+	assert.Nil(t, chain.parent.worldSnapshot.GetAccountSnapshot(to.ID()))
+}
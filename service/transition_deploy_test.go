@@ -0,0 +1,228 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/crypto"
+	"github.com/icon-project/goloop/common/wallet"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/contract"
+	"github.com/icon-project/goloop/service/eeproxy"
+	"github.com/icon-project/goloop/service/platform/basic"
+	"github.com/icon-project/goloop/service/scoreapi"
+	"github.com/icon-project/goloop/service/scoredb"
+	"github.com/icon-project/goloop/service/state"
+	"github.com/icon-project/goloop/service/transaction"
+)
+
+func newTestDeployTx(t *testing.T, w module.Wallet, ts int64, contentType string, content []byte) module.Transaction {
+	param := map[string]interface{}{
+		"version":   "0x3",
+		"from":      w.Address().String(),
+		"to":        "cx0000000000000000000000000000000000000000",
+		"nid":       "0x1",
+		"stepLimit": "0x1000000",
+		"timestamp": fmt.Sprintf("%#x", ts),
+		"dataType":  "deploy",
+		"data": map[string]interface{}{
+			"contentType": contentType,
+			"content":     common.HexBytes(content).String(),
+		},
+	}
+	bs, err := transaction.SerializeMap(param, nil, map[string]bool{"signature": true})
+	assert.NoError(t, err)
+	sig, err := w.Sign(crypto.SHA3Sum256(append([]byte("icx_sendTransaction."), bs...)))
+	assert.NoError(t, err)
+	param["signature"] = base64.StdEncoding.EncodeToString(sig)
+	js, err := json.Marshal(param)
+	assert.NoError(t, err)
+	tx, err := transaction.NewTransactionFromJSON(js)
+	assert.NoError(t, err)
+	return tx
+}
+
+func TestTransition_WasmDeployRevision(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wasm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	wallets := []module.Wallet{wallet.New()}
+	chain := newTestSystemChain(t, dir, wallets, eeproxy.NewGoContracts())
+	defer chain.close()
+
+	chain.update(t, func(ws state.WorldState) {
+		sys := ws.GetAccountState(state.SystemID)
+		assert.NoError(t, scoredb.NewVarDB(sys, state.VarRevision).Set(basic.Revision8))
+	})
+	content := []byte("\x00asm\x01\x00\x00\x00")
+	tx := newTestDeployTx(t, wallets[0], 1, state.CTAppWasm, content)
+	assert.NoError(t, tx.Verify())
+
+	// rejected by the pool below the revision
+	err = tx.(transaction.Transaction).PreValidate(chain.worldContext(1), false)
+	assert.True(t, transaction.InvalidTxValue.Equals(err), "err=%+v", err)
+
+	// and fails on execution if it's included anyway
+	rcts, err := chain.execute(t, 1, []module.Transaction{tx})
+	assert.NoError(t, err)
+	if assert.Len(t, rcts, 1) {
+		assert.Equal(t, module.StatusInvalidParameter, rcts[0].Status())
+	}
+
+	// other content types are not affected
+	java := newTestDeployTx(t, wallets[0], 2, state.CTAppJava, content)
+	assert.NoError(t, java.(transaction.Transaction).PreValidate(chain.worldContext(2), false))
+
+	chain.update(t, func(ws state.WorldState) {
+		sys := ws.GetAccountState(state.SystemID)
+		assert.NoError(t, scoredb.NewVarDB(sys, state.VarRevision).Set(basic.Revision9))
+	})
+	assert.NoError(t, tx.(transaction.Transaction).PreValidate(chain.worldContext(2), false))
+}
+
+// newTestPythonContent returns the python package having the name of the
+// Go contract as the code.
+func newTestPythonContent(t *testing.T, name string, extra int) []byte {
+	buf := bytes.NewBuffer(nil)
+	zw := zip.NewWriter(buf)
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{"package.json", []byte(`{"main_module":"token","main_score":"Token"}`)},
+		{"code.jar", []byte(name)},
+	}
+	if extra > 0 {
+		data := make([]byte, extra)
+		_, err := rand.Read(data)
+		assert.NoError(t, err)
+		files = append(files, struct {
+			name string
+			data []byte
+		}{"extra.bin", data})
+	}
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		assert.NoError(t, err)
+		_, err = w.Write(f.data)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func newTestTokenContract(t *testing.T) *eeproxy.GoContract {
+	c, err := eeproxy.NewGoContract(
+		&eeproxy.GoMethod{
+			Method: &scoreapi.Method{
+				Type:  scoreapi.Function,
+				Name:  "on_install",
+				Flags: scoreapi.FlagExternal,
+			},
+			Handler: func(ctx eeproxy.GoContext, params []interface{}) (interface{}, error) {
+				return nil, nil
+			},
+		},
+	)
+	assert.NoError(t, err)
+	return c
+}
+
+func TestTransition_DeployValidators(t *testing.T) {
+	dir, err := ioutil.TempDir("", "deploy")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	wallets := []module.Wallet{wallet.New(), wallet.New()}
+	cs := eeproxy.NewGoContracts()
+	cs.Register("token", newTestTokenContract(t))
+	chain := newTestSystemChainWithRevision(t, dir, wallets, cs, basic.Revision8)
+	defer chain.close()
+
+	chain.update(t, func(ws state.WorldState) {
+		sys := ws.GetAccountState(state.SystemID)
+		assert.NoError(t, scoredb.NewVarDB(sys, state.VarGovernance).Set(wallets[0].Address()))
+		assert.NoError(t, scoredb.NewVarDB(sys, state.VarServiceConfig).Set(state.SysConfigAudit))
+	})
+	ts := int64(0)
+	call := func(w module.Wallet, method string, params map[string]interface{}) module.Transaction {
+		ts++
+		return newTestScheduleTx(t, w, ts, method, params)
+	}
+	deploy := func(w module.Wallet, content []byte) module.Transaction {
+		ts++
+		return newTestDeployTx(t, w, ts, state.CTAppZip, content)
+	}
+	status := func(rcts []module.Receipt) []module.Status {
+		var ss []module.Status
+		for _, rct := range rcts {
+			ss = append(ss, rct.Status())
+		}
+		return ss
+	}
+
+	// not available below the revision
+	rcts, err := chain.execute(t, 1, []module.Transaction{
+		call(wallets[0], "setMaxCodeSize", map[string]interface{}{"type": "java", "size": "0x10"}),
+		call(wallets[0], "setRevision", map[string]interface{}{"code": fmt.Sprintf("%#x", basic.Revision9)}),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []module.Status{module.StatusMethodNotFound, module.StatusSuccess}, status(rcts))
+
+	rcts, err = chain.execute(t, 2, []module.Transaction{
+		call(wallets[0], "setDeployValidatorEnabled", map[string]interface{}{"name": "codeSize", "yn": "0x1"}),
+		call(wallets[0], "setDeployValidatorEnabled", map[string]interface{}{"name": "duplicate", "yn": "0x1"}),
+		call(wallets[0], "setMaxCodeSize", map[string]interface{}{"type": "python", "size": "0x1000"}),
+		call(wallets[0], "getMaxCodeSize", map[string]interface{}{"type": "java"}),
+		call(wallets[1], "setMaxCodeSize", map[string]interface{}{"type": "python", "size": "0x2000"}),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []module.Status{
+		module.StatusSuccess,
+		module.StatusSuccess,
+		module.StatusSuccess,
+		module.StatusSuccess,
+		module.StatusAccessDenied,
+	}, status(rcts))
+	sys := chain.worldContext(3).GetAccountState(state.SystemID)
+	assert.Equal(t, int64(0x1000), contract.GetMaxCodeSize(sys, "python"))
+	assert.Equal(t, int64(0), contract.GetMaxCodeSize(sys, "java"))
+
+	// pending contracts with the same content are allowed
+	content := newTestPythonContent(t, "token", 0)
+	d1 := deploy(wallets[0], content)
+	d2 := deploy(wallets[1], content)
+	rcts, err = chain.execute(t, 3, []module.Transaction{
+		d1, d2, deploy(wallets[0], newTestPythonContent(t, "token", 0x1000)),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []module.Status{
+		module.StatusSuccess,
+		module.StatusSuccess,
+		module.StatusInvalidParameter,
+	}, status(rcts))
+
+	// only one of them is accepted
+	rcts, err = chain.execute(t, 4, []module.Transaction{
+		call(wallets[0], "acceptScore", map[string]interface{}{"txHash": common.HexBytes(d2.ID()).String()}),
+		call(wallets[0], "acceptScore", map[string]interface{}{"txHash": common.HexBytes(d1.ID()).String()}),
+		deploy(wallets[0], content),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []module.Status{
+		module.StatusSuccess,
+		module.StatusInvalidParameter,
+		module.StatusInvalidParameter,
+	}, status(rcts))
+}
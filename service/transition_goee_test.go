@@ -0,0 +1,123 @@
+package service
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/crypto"
+	"github.com/icon-project/goloop/common/wallet"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/eeproxy"
+	"github.com/icon-project/goloop/service/scoreapi"
+	"github.com/icon-project/goloop/service/state"
+)
+
+var testRelayScore = common.MustNewAddressFromString("cx0000000000000000000000000000000000000300")
+
+// newTestRelayContract returns the contract counting relayed calls and
+// forwarding them to the counter contract.
+func newTestRelayContract(t *testing.T) *eeproxy.GoContract {
+	c, err := eeproxy.NewGoContract(
+		&eeproxy.GoMethod{
+			Method: &scoreapi.Method{
+				Type:  scoreapi.Function,
+				Name:  "relay",
+				Flags: scoreapi.FlagExternal,
+				Inputs: []scoreapi.Parameter{
+					{Name: "key", Type: scoreapi.String},
+					{Name: "delta", Type: scoreapi.Integer},
+				},
+			},
+			Handler: func(ctx eeproxy.GoContext, params []interface{}) (interface{}, error) {
+				bs, err := ctx.GetValue([]byte("relayed"))
+				if err != nil {
+					return nil, err
+				}
+				cnt := new(big.Int).SetBytes(bs)
+				if err := ctx.SetValue([]byte("relayed"), cnt.Add(cnt, big.NewInt(1)).Bytes()); err != nil {
+					return nil, err
+				}
+				return ctx.Call(testOEScore, nil, "add", params[0], params[1])
+			},
+		},
+	)
+	assert.NoError(t, err)
+	return c
+}
+
+func TestTransition_GoEE(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goee")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	wallets := []module.Wallet{wallet.New()}
+	cs := eeproxy.NewGoContracts()
+	relay := newTestRelayContract(t)
+	cs.Register("relay", relay)
+	chain := newTestSystemChain(t, dir, wallets, cs)
+	defer chain.close()
+
+	chain.update(t, func(ws state.WorldState) {
+		as := ws.GetAccountState(testRelayScore.ID())
+		assert.True(t, as.InitContractAccount(wallets[0].Address()))
+		txHash := crypto.SHA3Sum256([]byte("deploy relay"))
+		_, err := as.DeployContract([]byte("relay"), state.JavaEE, state.CTAppJava, nil, txHash)
+		assert.NoError(t, err)
+		assert.NoError(t, as.AcceptContract(txHash, nil))
+		as.SetAPIInfo(relay.Info())
+	})
+	relayed := func() int64 {
+		ass := chain.parent.worldSnapshot.GetAccountSnapshot(testRelayScore.ID())
+		bs, _ := ass.GetValue([]byte("relayed"))
+		return new(big.Int).SetBytes(bs).Int64()
+	}
+	call := func(ts int64, delta string) module.Transaction {
+		return newTestOETx(t, wallets[0], ts, testRelayScore, 0, map[string]interface{}{
+			"method": "relay",
+			"params": map[string]interface{}{"key": "r", "delta": delta},
+		})
+	}
+	txs := []module.Transaction{call(1, "0x2"), call(2, "-0x5"), call(3, "0x3")}
+
+	parent := chain.parent
+	rcts, err := chain.execute(t, 1, txs)
+	assert.NoError(t, err)
+	if assert.Len(t, rcts, 3) {
+		assert.Equal(t, module.StatusSuccess, rcts[0].Status())
+		assert.Equal(t, module.StatusUnknownFailure, rcts[1].Status())
+		assert.Equal(t, module.StatusSuccess, rcts[2].Status())
+
+		// the event is logged by the callee
+		cnt := 0
+		for itr := rcts[0].EventLogIterator(); itr.Has(); itr.Next() {
+			ev, err := itr.Get()
+			assert.NoError(t, err)
+			assert.True(t, testOEScore.Equal(ev.Address()))
+			cnt++
+		}
+		assert.Equal(t, 1, cnt)
+
+		// the caller pays steps used by the callee as well
+		assert.True(t, rcts[0].StepUsed().Cmp(big.NewInt(1000+1500*2)) > 0)
+	}
+	// the failed call is reverted including changes of the caller
+	assert.Equal(t, int64(5), chain.counter("r"))
+	assert.Equal(t, int64(2), relayed())
+
+	// the same block produces the same receipts
+	result := chain.parent.Result()
+	chain.parent = parent
+	rcts2, err := chain.execute(t, 1, txs)
+	assert.NoError(t, err)
+	if assert.Len(t, rcts2, len(rcts)) {
+		for i := range rcts {
+			assert.Equal(t, rcts[i].Bytes(), rcts2[i].Bytes(), "tx=%d", i)
+		}
+	}
+	assert.Equal(t, result, chain.parent.Result())
+}
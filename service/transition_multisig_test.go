@@ -0,0 +1,148 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/crypto"
+	"github.com/icon-project/goloop/common/wallet"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/eeproxy"
+	"github.com/icon-project/goloop/service/platform/basic"
+	"github.com/icon-project/goloop/service/state"
+	"github.com/icon-project/goloop/service/transaction"
+)
+
+func newTestMultiSigTx(t *testing.T, key *crypto.MultiSigKey, signers []module.Wallet, ts int64, data interface{}) transaction.Transaction {
+	var pubKeys []string
+	for _, pk := range key.PublicKeys() {
+		pubKeys = append(pubKeys, pk.String())
+	}
+	param := map[string]interface{}{
+		"version":   "0x3",
+		"from":      common.NewAccountAddressFromMultiSigKey(key).String(),
+		"to":        testOEScore.String(),
+		"nid":       "0x1",
+		"stepLimit": "0x100000",
+		"timestamp": fmt.Sprintf("%#x", ts),
+		"dataType":  "call",
+		"data":      data,
+	}
+	bs, err := transaction.SerializeMap(param, nil, map[string]bool{"multiSig": true})
+	assert.NoError(t, err)
+	hash := crypto.SHA3Sum256(append([]byte("icx_sendTransaction."), bs...))
+	var sigs []string
+	for _, w := range signers {
+		sig, err := w.Sign(hash)
+		assert.NoError(t, err)
+		sigs = append(sigs, base64.StdEncoding.EncodeToString(sig))
+	}
+	param["multiSig"] = map[string]interface{}{
+		"threshold":  fmt.Sprintf("%#x", key.Threshold()),
+		"publicKeys": pubKeys,
+		"signatures": sigs,
+	}
+	js, err := json.Marshal(param)
+	assert.NoError(t, err)
+	tx, err := transaction.NewTransactionFromJSON(js)
+	assert.NoError(t, err)
+	return tx
+}
+
+func TestTransition_MultiSigTransaction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "multisig")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	wallets := []module.Wallet{wallet.New()}
+	chain := newTestSystemChain(t, dir, wallets, eeproxy.NewGoContracts())
+	defer chain.close()
+
+	holders := []module.Wallet{wallet.New(), wallet.New(), wallet.New()}
+	var pubKeys []*crypto.PublicKey
+	for _, w := range holders {
+		pk, err := crypto.ParsePublicKey(w.PublicKey())
+		assert.NoError(t, err)
+		pubKeys = append(pubKeys, pk)
+	}
+	key, err := crypto.NewMultiSigKey(2, pubKeys)
+	assert.NoError(t, err)
+	addr := common.NewAccountAddressFromMultiSigKey(key)
+
+	balance := new(big.Int).Exp(big.NewInt(10), big.NewInt(20), nil)
+	chain.update(t, func(ws state.WorldState) {
+		ws.GetAccountState(addr.ID()).SetBalance(balance)
+	})
+
+	add := func(delta string) interface{} {
+		return map[string]interface{}{
+			"method": "add",
+			"params": map[string]interface{}{"key": "m", "delta": delta},
+		}
+	}
+
+	// signatures of enough holders are required
+	tx := newTestMultiSigTx(t, key, []module.Wallet{holders[2], holders[0]}, 1, add("0x3"))
+	assert.NoError(t, tx.Verify())
+	assert.True(t, tx.From().Equal(addr))
+	for _, signers := range [][]module.Wallet{
+		{holders[1]},
+		{holders[1], holders[1]},
+		{holders[1], wallet.New()},
+	} {
+		err := newTestMultiSigTx(t, key, signers, 2, add("0x5")).Verify()
+		assert.True(t, transaction.InvalidSignatureError.Equals(err), "err=%+v", err)
+	}
+
+	// binary form keeps the transaction, and others are not confused with it
+	tx2, err := transaction.NewTransaction(tx.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, tx.ID(), tx2.ID())
+	assert.NoError(t, tx2.Verify())
+	tx3 := newTestOETx(t, wallets[0], 3, testOEScore, 0, add("0x1"))
+	tx4, err := transaction.NewTransaction(tx3.Bytes())
+	assert.NoError(t, err)
+	assert.NoError(t, tx4.Verify())
+
+	// it's executed as a normal transaction of the account
+	rcts, err := chain.execute(t, 1, []module.Transaction{tx2})
+	assert.NoError(t, err)
+	if assert.Len(t, rcts, 1) {
+		assert.Equal(t, module.StatusSuccess, rcts[0].Status())
+		fee := new(big.Int).Mul(rcts[0].StepUsed(), rcts[0].StepPrice())
+		assert.Equal(t, new(big.Int).Sub(balance, fee), chain.balance(addr))
+	}
+	assert.Equal(t, int64(3), chain.counter("m"))
+}
+
+func TestTransition_MultiSigTransactionRevision(t *testing.T) {
+	dir, err := ioutil.TempDir("", "multisig")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	wallets := []module.Wallet{wallet.New()}
+	chain := newTestSystemChainWithRevision(t, dir, wallets, eeproxy.NewGoContracts(), basic.Revision8)
+	defer chain.close()
+
+	holder := wallet.New()
+	pk, err := crypto.ParsePublicKey(holder.PublicKey())
+	assert.NoError(t, err)
+	key, err := crypto.NewMultiSigKey(1, []*crypto.PublicKey{pk})
+	assert.NoError(t, err)
+	tx := newTestMultiSigTx(t, key, []module.Wallet{holder}, 1, map[string]interface{}{"method": "add"})
+	assert.NoError(t, tx.Verify())
+
+	// rejected below the revision
+	err = tx.PreValidate(chain.worldContext(1), false)
+	assert.True(t, transaction.InvalidTxValue.Equals(err), "err=%+v", err)
+	_, err = chain.execute(t, 1, []module.Transaction{tx})
+	assert.Error(t, err)
+}
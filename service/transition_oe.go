@@ -0,0 +1,290 @@
+package service
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/contract"
+	"github.com/icon-project/goloop/service/state"
+	"github.com/icon-project/goloop/service/transaction"
+	"github.com/icon-project/goloop/service/txresult"
+)
+
+// optimisticChain is implemented by the chain supporting optimistic
+// execution of transactions.
+type optimisticChain interface {
+	OptimisticExecution() bool
+}
+
+func useOptimisticExecution(c module.Chain) bool {
+	if oc, ok := c.(optimisticChain); ok {
+		return oc.OptimisticExecution()
+	}
+	return false
+}
+
+type speculation struct {
+	txo  transaction.Transaction
+	ctx  *trackingContext
+	ws   state.TrackingWorldState
+	rct  txresult.Receipt
+	err  error
+	done chan struct{}
+
+	// from is the number of transactions applied to the state where the
+	// speculation started, and deps are indexes of following transactions
+	// whose speculative writes are applied to the state.
+	from int
+	deps []int
+
+	// writes is the changes of the transaction applied to the block. If
+	// the transaction is executed again, spec keeps the changes of the
+	// speculation, which might be used by following speculations.
+	writes *state.AccessSet
+	spec   *state.AccessSet
+}
+
+// trackingContext records accesses to properties of the context of the
+// block, which are not copied to the context of the speculation. If it's
+// shared, properties of the block are used instead.
+type trackingContext struct {
+	contract.Context
+	parent contract.Context
+	shared bool
+	props  int32
+}
+
+func (c *trackingContext) GetProperty(name string) interface{} {
+	if c.shared {
+		return c.parent.GetProperty(name)
+	}
+	v := c.Context.GetProperty(name)
+	if v == nil && c.parent.GetProperty(name) != nil {
+		atomic.StoreInt32(&c.props, 1)
+	}
+	return v
+}
+
+func (c *trackingContext) SetProperty(name string, value interface{}) {
+	if c.shared {
+		c.parent.SetProperty(name, value)
+		return
+	}
+	atomic.StoreInt32(&c.props, 1)
+	c.Context.SetProperty(name, value)
+}
+
+func (c *trackingContext) UsesProperty() bool {
+	return atomic.LoadInt32(&c.props) != 0
+}
+
+func (t *transition) newTrackingContext(ctx contract.Context, ws state.WorldState, shared bool) (*trackingContext, state.TrackingWorldState) {
+	tws := state.NewTrackingWorldState(ws)
+	tctx := contract.NewContext(ctx.WorldStateChanged(tws), t.cm, t.eem, t.chain, t.log, t.ti)
+	tctx.SetProperty(contract.PropInitialSnapshot, ctx.GetProperty(contract.PropInitialSnapshot))
+	return &trackingContext{Context: tctx, parent: ctx, shared: shared}, tws
+}
+
+// speculative returns true if the result of the speculation may be
+// applied to the state. Platform may keep the data of the block out of the
+// world state (ex. logs of the extension, properties of the context), so
+// the transaction accessing the extension or properties of the block is
+// executed again on the state.
+func (sp *speculation) speculative() bool {
+	if sp.err != nil || sp.ctx.UsesProperty() {
+		return false
+	}
+	return !sp.ws.Reads().Extension()
+}
+
+// finished returns true if the speculation is done.
+func (sp *speculation) finished() bool {
+	select {
+	case <-sp.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// versionedStore keeps writes of transactions in a block by the index.
+// The state of a speculation is built from the state of the block with
+// the transactions validated, and speculative writes of the following
+// transactions done at the time. So a transaction needs to be executed
+// again only if it reads something written by a preceding transaction
+// which is not in the state or written differently.
+type versionedStore struct {
+	ctx       contract.Context
+	sps       []*speculation
+	validated int
+}
+
+// stateFor returns the state for the speculation of the transaction.
+func (vs *versionedStore) stateFor(idx int) (state.WorldState, error) {
+	ws, err := state.WorldStateFromSnapshot(vs.ctx.GetSnapshot())
+	if err != nil {
+		return nil, err
+	}
+	sp := vs.sps[idx]
+	sp.from = vs.validated
+	for j := vs.validated; j < idx; j++ {
+		dep := vs.sps[j]
+		if !dep.finished() || !dep.speculative() {
+			continue
+		}
+		if err := dep.ws.Apply(ws); err != nil {
+			return nil, err
+		}
+		sp.deps = append(sp.deps, j)
+	}
+	return ws, nil
+}
+
+// validate returns true if the speculation of the transaction read the
+// same state as the sequential execution. It's called after preceding
+// transactions are validated.
+func (vs *versionedStore) validate(idx int) bool {
+	sp := vs.sps[idx]
+	if !sp.speculative() {
+		return false
+	}
+	reads := sp.ws.Reads()
+	deps := sp.deps
+	for j := sp.from; j < idx; j++ {
+		prev := vs.sps[j]
+		if len(deps) > 0 && deps[0] == j {
+			deps = deps[1:]
+			if prev.spec == nil {
+				continue
+			}
+			// writes of the speculation were used, but they are replaced
+			if reads.Conflicts(prev.spec) {
+				return false
+			}
+		}
+		if reads.Conflicts(prev.writes) {
+			return false
+		}
+	}
+	return true
+}
+
+// commit records writes of the transaction applied to the block.
+func (vs *versionedStore) commit(idx int, writes *state.AccessSet) {
+	vs.sps[idx].writes = writes
+	vs.validated = idx + 1
+}
+
+// reexecuted records writes of the transaction executed again on the
+// block. Writes of the speculation are kept, because following speculations
+// may have used them.
+func (vs *versionedStore) reexecuted(idx int, writes *state.AccessSet) {
+	sp := vs.sps[idx]
+	if sp.speculative() {
+		sp.spec = sp.ws.Writes()
+	}
+	vs.commit(idx, writes)
+}
+
+func (t *transition) speculate(sp *speculation, idx int) {
+	defer close(sp.done)
+
+	txh, err := sp.txo.GetHandler(t.cm)
+	if err != nil {
+		sp.err = err
+		return
+	}
+	sp.ctx.SetTransactionInfo(transactionInfoOf(sp.txo, idx))
+	sp.ctx.UpdateSystemInfo()
+	sp.rct, sp.err = txh.Execute(sp.ctx, false)
+	txh.Dispose()
+}
+
+// executeTxsOptimistic executes transactions speculatively on the states
+// built by versionedStore. Then it validates reads of each transaction
+// against writes of preceding transactions in order. Changes of the valid
+// transaction are applied to the state, and others are executed again on
+// the state.
+func (t *transition) executeTxsOptimistic(level int, l module.TransactionList, ctx contract.Context, rctBuf []txresult.Receipt) error {
+	vs := &versionedStore{ctx: ctx}
+	for i := l.Iterator(); i.Has(); i.Next() {
+		txi, _, err := i.Get()
+		if err != nil {
+			t.log.Errorf("Fail to iterate transaction list err=%+v", err)
+			return err
+		}
+		vs.sps = append(vs.sps, &speculation{
+			txo:  txi.(transaction.Transaction),
+			done: make(chan struct{}),
+		})
+	}
+	sps := vs.sps
+
+	jobs := make(chan int, len(sps))
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < level; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				select {
+				case <-stop:
+					close(sps[idx].done)
+				default:
+					t.speculate(sps[idx], idx)
+				}
+			}
+		}()
+	}
+	defer func() {
+		close(stop)
+		close(jobs)
+		wg.Wait()
+	}()
+
+	// speculations are started as late as possible, so that they can use
+	// results of more transactions.
+	next := 0
+	reexecuted := 0
+	for idx, sp := range sps {
+		for ; next < len(sps) && next < idx+level; next++ {
+			ws, err := vs.stateFor(next)
+			if err != nil {
+				return err
+			}
+			sps[next].ctx, sps[next].ws = t.newTrackingContext(ctx, ws, false)
+			jobs <- next
+		}
+		<-sp.done
+		if t.step == stepCanceled {
+			return ErrTransitionInterrupted
+		}
+		ctx.SetTransactionInfo(transactionInfoOf(sp.txo, idx))
+		ctx.UpdateSystemInfo()
+		if vs.validate(idx) {
+			if err := sp.ws.Apply(ctx); err != nil {
+				return err
+			}
+			if err := t.plt.OnTransactionEnd(ctx, t.log, sp.rct); err != nil {
+				return err
+			}
+			vs.commit(idx, sp.ws.Writes())
+			rctBuf[idx] = sp.rct
+			continue
+		}
+
+		t.log.Tracef("REEXECUTE TX <0x%x> err=%v", sp.txo.ID(), sp.err)
+		reexecuted++
+		rctx, tws := t.newTrackingContext(ctx, ctx, true)
+		rct, err := t.executeTx(rctx, sp.txo, idx)
+		if err != nil {
+			return err
+		}
+		vs.reexecuted(idx, tws.Writes())
+		rctBuf[idx] = rct
+	}
+	t.log.Debugf("Optimistic execution txs=%d reexecuted=%d", len(sps), reexecuted)
+	return nil
+}
@@ -0,0 +1,767 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/chain/base"
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/crypto"
+	"github.com/icon-project/goloop/common/db"
+	"github.com/icon-project/goloop/common/intconv"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/common/merkle"
+	"github.com/icon-project/goloop/common/wallet"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/contract"
+	"github.com/icon-project/goloop/service/eeproxy"
+	"github.com/icon-project/goloop/service/platform/basic"
+	"github.com/icon-project/goloop/service/scoreapi"
+	"github.com/icon-project/goloop/service/scoredb"
+	"github.com/icon-project/goloop/service/state"
+	"github.com/icon-project/goloop/service/transaction"
+	"github.com/icon-project/goloop/service/txresult"
+)
+
+type testOEChain struct {
+	module.Chain
+	nid        int
+	level      int
+	optimistic bool
+}
+
+func (c *testOEChain) NID() int {
+	if c.nid == 0 {
+		return 1
+	}
+	return c.nid
+}
+
+func (c *testOEChain) CID() int                          { return c.NID() }
+func (c *testOEChain) ConcurrencyLevel() int             { return c.level }
+func (c *testOEChain) OptimisticExecution() bool         { return c.optimistic }
+func (c *testOEChain) TransactionTimeout() time.Duration { return 5 * time.Second }
+
+type testOECallback chan error
+
+func (cb testOECallback) OnValidate(tr module.Transition, err error) {
+	if err != nil {
+		cb <- err
+	}
+}
+
+func (cb testOECallback) OnExecute(tr module.Transition, err error) {
+	cb <- err
+}
+
+var testOEScore = common.MustNewAddressFromString("cx0000000000000000000000000000000000000100")
+
+func newTestOEContract(t *testing.T) *eeproxy.GoContract {
+	add := func(ctx eeproxy.GoContext, key string, delta *big.Int) error {
+		bs, err := ctx.GetValue([]byte(key))
+		if err != nil {
+			return err
+		}
+		value := new(big.Int).SetBytes(bs)
+		if value.Add(value, delta).Sign() < 0 {
+			return fmt.Errorf("NegativeValue(key=%s)", key)
+		}
+		if err := ctx.SetValue([]byte(key), value.Bytes()); err != nil {
+			return err
+		}
+		return ctx.Event([][]byte{[]byte("Added(str,int)"), []byte(key)}, [][]byte{value.Bytes()})
+	}
+	c, err := eeproxy.NewGoContract(
+		&eeproxy.GoMethod{
+			Method: &scoreapi.Method{
+				Type:  scoreapi.Function,
+				Name:  "add",
+				Flags: scoreapi.FlagExternal,
+				Inputs: []scoreapi.Parameter{
+					{Name: "key", Type: scoreapi.String},
+					{Name: "delta", Type: scoreapi.Integer},
+				},
+			},
+			Handler: func(ctx eeproxy.GoContext, params []interface{}) (interface{}, error) {
+				return nil, add(ctx, params[0].(string), &params[1].(*common.HexInt).Int)
+			},
+		},
+		&eeproxy.GoMethod{
+			Method: &scoreapi.Method{
+				Type:  scoreapi.Function,
+				Name:  "move",
+				Flags: scoreapi.FlagExternal,
+				Inputs: []scoreapi.Parameter{
+					{Name: "from", Type: scoreapi.String},
+					{Name: "to", Type: scoreapi.String},
+				},
+			},
+			Handler: func(ctx eeproxy.GoContext, params []interface{}) (interface{}, error) {
+				if err := add(ctx, params[0].(string), big.NewInt(-1)); err != nil {
+					return nil, err
+				}
+				return nil, add(ctx, params[1].(string), big.NewInt(1))
+			},
+		},
+		&eeproxy.GoMethod{
+			Method: &scoreapi.Method{
+				Type:    scoreapi.Event,
+				Name:    "Added",
+				Indexed: 1,
+				Inputs: []scoreapi.Parameter{
+					{Name: "key", Type: scoreapi.String},
+					{Name: "value", Type: scoreapi.Integer},
+				},
+			},
+		},
+	)
+	assert.NoError(t, err)
+	return c
+}
+
+func setupTestOEState(t *testing.T, ws state.WorldState, wallets []module.Wallet, c *eeproxy.GoContract) {
+	sys := ws.GetAccountState(state.SystemID)
+	assert.NoError(t, scoredb.NewVarDB(sys, state.VarRevision).Set(basic.LatestRevision))
+	assert.NoError(t, scoredb.NewVarDB(sys, state.VarStepPrice).Set(10))
+	costs := []struct {
+		name string
+		cost int
+	}{
+		{state.StepTypeDefault, 1000},
+		{state.StepTypeContractCall, 1500},
+		{state.StepTypeInput, 20},
+		{state.StepTypeDefaultGet, 25},
+		{state.StepTypeDefaultSet, 100},
+		{state.StepTypeEventLogBase, 100},
+	}
+	types := scoredb.NewArrayDB(sys, state.VarStepTypes)
+	dict := scoredb.NewDictDB(sys, state.VarStepCosts, 1)
+	for _, c := range costs {
+		assert.NoError(t, types.Put(c.name))
+		assert.NoError(t, dict.Set(c.name, c.cost))
+	}
+	assert.NoError(t, scoredb.NewArrayDB(sys, state.VarStepLimitTypes).Put(state.StepLimitTypeInvoke))
+	assert.NoError(t, scoredb.NewDictDB(sys, state.VarStepLimit, 1).Set(state.StepLimitTypeInvoke, 0x1000000))
+
+	balance := new(big.Int).Exp(big.NewInt(10), big.NewInt(20), nil)
+	for _, w := range wallets {
+		ws.GetAccountState(w.Address().ID()).SetBalance(balance)
+	}
+
+	as := ws.GetAccountState(testOEScore.ID())
+	owner := wallets[0].Address()
+	assert.True(t, as.InitContractAccount(owner))
+	txHash := crypto.SHA3Sum256([]byte("deploy"))
+	_, err := as.DeployContract([]byte("counter"), state.JavaEE, state.CTAppJava, nil, txHash)
+	assert.NoError(t, err)
+	assert.NoError(t, as.AcceptContract(txHash, nil))
+	as.SetAPIInfo(c.Info())
+}
+
+func newTestOETx(t *testing.T, w module.Wallet, ts int64, to module.Address, value int64, data interface{}) module.Transaction {
+	param := map[string]interface{}{
+		"version":   "0x3",
+		"from":      w.Address().String(),
+		"to":        to.String(),
+		"nid":       "0x1",
+		"stepLimit": "0x100000",
+		"timestamp": fmt.Sprintf("%#x", ts),
+	}
+	if value != 0 {
+		param["value"] = fmt.Sprintf("%#x", value)
+	}
+	if data != nil {
+		param["dataType"] = "call"
+		param["data"] = data
+	}
+	bs, err := transaction.SerializeMap(param, nil, map[string]bool{"signature": true})
+	assert.NoError(t, err)
+	sig, err := w.Sign(crypto.SHA3Sum256(append([]byte("icx_sendTransaction."), bs...)))
+	assert.NoError(t, err)
+	param["signature"] = base64.StdEncoding.EncodeToString(sig)
+	js, err := json.Marshal(param)
+	assert.NoError(t, err)
+	tx, err := transaction.NewTransactionFromJSON(js)
+	assert.NoError(t, err)
+	return tx
+}
+
+// newTestOEBlocks returns blocks of transactions mixing transfers and
+// calls for the contract. Some of them share accounts or storage keys.
+func newTestOEBlocks(t *testing.T, wallets []module.Wallet, blocks, size int) [][]module.Transaction {
+	keys := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	ts := int64(1000000)
+	var result [][]module.Transaction
+	for b := 0; b < blocks; b++ {
+		var txs []module.Transaction
+		for i := 0; i < size; i++ {
+			ts++
+			w := wallets[(b*size+i)%len(wallets)]
+			switch i % 4 {
+			case 0:
+				to := wallets[(b+i*7)%len(wallets)].Address()
+				txs = append(txs, newTestOETx(t, w, ts, to, int64(i+1), nil))
+			case 1:
+				txs = append(txs, newTestOETx(t, w, ts, testOEScore, 0, map[string]interface{}{
+					"method": "add",
+					"params": map[string]interface{}{
+						"key":   keys[(b+i)%len(keys)],
+						"delta": fmt.Sprintf("%#x", i+1),
+					},
+				}))
+			case 2:
+				txs = append(txs, newTestOETx(t, w, ts, testOEScore, 0, map[string]interface{}{
+					"method": "add",
+					"params": map[string]interface{}{
+						"key":   "shared",
+						"delta": "0x1",
+					},
+				}))
+			default:
+				txs = append(txs, newTestOETx(t, w, ts, testOEScore, 0, map[string]interface{}{
+					"method": "move",
+					"params": map[string]interface{}{
+						"from": keys[i%len(keys)],
+						"to":   keys[(i+3)%len(keys)],
+					},
+				}))
+			}
+		}
+		result = append(result, txs)
+	}
+	return result
+}
+
+// newTestOEManager returns the manager of execution environments running
+// the counter contract with enough instances for the concurrency level.
+func newTestOEManager(t *testing.T, dir string, level int) (eeproxy.Manager, *eeproxy.GoContract) {
+	logger := log.GlobalLogger()
+	c := newTestOEContract(t)
+	cs := eeproxy.NewGoContracts()
+	cs.Register("counter", c)
+	em, err := eeproxy.NewManager("unix", filepath.Join(dir, "ee.sock"), logger,
+		eeproxy.NewGoEE(logger, "java", cs))
+	assert.NoError(t, err)
+	go em.Loop()
+	assert.NoError(t, em.SetInstances(level+1, level+1, 1))
+	return em, c
+}
+
+type testOEResult struct {
+	result   []byte
+	receipts [][]byte
+}
+
+func executeTestOEBlocks(t *testing.T, dir string, chain *testOEChain, plt base.Platform, wallets []module.Wallet, blocks [][]module.Transaction) []testOEResult {
+	assert.NoError(t, os.MkdirAll(dir, 0700))
+	logger := log.GlobalLogger()
+	em, c := newTestOEManager(t, dir, chain.level)
+	defer em.Close()
+
+	mdb := db.NewMapDB()
+	cm, err := plt.NewContractManager(mdb, filepath.Join(dir, "contract"), logger)
+	assert.NoError(t, err)
+
+	parent, err := newInitTransition(mdb, nil, nil, cm, em, chain, logger, plt, nil)
+	assert.NoError(t, err)
+	ws, err := state.WorldStateFromSnapshot(parent.worldSnapshot)
+	assert.NoError(t, err)
+	setupTestOEState(t, ws, wallets, c)
+	parent.worldSnapshot = ws.GetSnapshot()
+
+	var results []testOEResult
+	for height, txs := range blocks {
+		bi := common.NewBlockInfo(int64(height+1), int64(height+1)*1000000)
+		tr := newTransition(parent, nil, transaction.NewTransactionListFromSlice(mdb, txs), bi, nil, true)
+		cb := make(testOECallback, 2)
+		_, err := tr.Execute(cb)
+		assert.NoError(t, err)
+		assert.NoError(t, <-cb)
+
+		r := testOEResult{result: tr.Result()}
+		for i := tr.NormalReceipts().Iterator(); i.Has(); i.Next() {
+			rct, err := i.Get()
+			assert.NoError(t, err)
+			r.receipts = append(r.receipts, rct.Bytes())
+		}
+		results = append(results, r)
+		parent = tr
+	}
+	return results
+}
+
+func TestTransition_OptimisticExecution(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oe")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	wallets := make([]module.Wallet, 12)
+	for i := range wallets {
+		wallets[i] = wallet.New()
+	}
+	blocks := newTestOEBlocks(t, wallets, 3, 40)
+
+	seq := executeTestOEBlocks(t, filepath.Join(dir, "seq"),
+		&testOEChain{level: 1}, basic.Platform, wallets, blocks)
+	opt := executeTestOEBlocks(t, filepath.Join(dir, "opt"),
+		&testOEChain{level: 4, optimistic: true}, basic.Platform, wallets, blocks)
+
+	assert.Len(t, seq, len(blocks))
+	assert.Len(t, opt, len(blocks))
+	for i := range seq {
+		assert.Equal(t, len(blocks[i]), len(seq[i].receipts))
+		assert.Equal(t, seq[i].receipts, opt[i].receipts, "block=%d", i)
+		assert.Equal(t, seq[i].result, opt[i].result, "block=%d", i)
+	}
+}
+
+var testOEExtScore = common.MustNewAddressFromString("cx0000000000000000000000000000000000000101")
+
+// testOEExtSnapshot is the extension counting logs of transactions. Like
+// the extension of ICON, logs are kept out of the snapshot until the end
+// of the transaction.
+type testOEExtSnapshot struct {
+	count int64
+}
+
+func (s *testOEExtSnapshot) Bytes() []byte {
+	return intconv.Int64ToBytes(s.count)
+}
+
+func (s *testOEExtSnapshot) Flush() error {
+	return nil
+}
+
+func (s *testOEExtSnapshot) NewState(readonly bool) state.ExtensionState {
+	return &testOEExtState{count: s.count}
+}
+
+type testOEExtState struct {
+	count int64
+	logs  int64
+}
+
+func (s *testOEExtState) GetSnapshot() state.ExtensionSnapshot {
+	return &testOEExtSnapshot{count: s.count}
+}
+
+func (s *testOEExtState) Reset(snapshot state.ExtensionSnapshot) {
+	s.count = snapshot.(*testOEExtSnapshot).count
+}
+
+func (s *testOEExtState) ClearCache() {
+	// do nothing
+}
+
+type testOEExtHandler struct {
+	*contract.CommonHandler
+}
+
+func (h *testOEExtHandler) ExecuteSync(cc contract.CallContext) (error, *codec.TypedObj, module.Address) {
+	cc.GetExtensionState().(*testOEExtState).logs++
+	return nil, nil, nil
+}
+
+type testOEExtContractManager struct {
+	contract.ContractManager
+}
+
+func (cm testOEExtContractManager) GetHandler(from, to module.Address, value *big.Int, ctype int, data []byte) (contract.ContractHandler, error) {
+	if ctype == contract.CTypeCall && to.Equal(testOEExtScore) {
+		ch := contract.NewCommonHandler(from, to, value, false, log.GlobalLogger())
+		return &testOEExtHandler{ch}, nil
+	}
+	return cm.ContractManager.GetHandler(from, to, value, ctype, data)
+}
+
+type testOEExtPlatform struct {
+	base.Platform
+}
+
+func (p *testOEExtPlatform) NewContractManager(dbase db.Database, dir string, logger log.Logger) (contract.ContractManager, error) {
+	cm, err := p.Platform.NewContractManager(dbase, dir, logger)
+	if err != nil {
+		return nil, err
+	}
+	return testOEExtContractManager{cm}, nil
+}
+
+func (p *testOEExtPlatform) NewExtensionSnapshot(dbase db.Database, raw []byte) state.ExtensionSnapshot {
+	return &testOEExtSnapshot{count: intconv.BytesToInt64(raw)}
+}
+
+func (p *testOEExtPlatform) NewExtensionWithBuilder(builder merkle.Builder, raw []byte) state.ExtensionSnapshot {
+	return &testOEExtSnapshot{count: intconv.BytesToInt64(raw)}
+}
+
+func (p *testOEExtPlatform) OnTransactionEnd(wc state.WorldContext, logger log.Logger, rct txresult.Receipt) error {
+	es := wc.GetExtensionState().(*testOEExtState)
+	es.count += es.logs
+	es.logs = 0
+	return nil
+}
+
+func TestTransition_OptimisticExecutionWithExtension(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oe")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	wallets := make([]module.Wallet, 12)
+	for i := range wallets {
+		wallets[i] = wallet.New()
+	}
+	blocks := newTestOEBlocks(t, wallets, 3, 40)
+	logs := 0
+	ts := int64(2000000)
+	for _, txs := range blocks {
+		for i := 0; i < len(txs); i += 5 {
+			ts++
+			txs[i] = newTestOETx(t, wallets[i%len(wallets)], ts, testOEExtScore, 0,
+				map[string]interface{}{"method": "log"})
+			logs++
+		}
+	}
+	plt := &testOEExtPlatform{basic.Platform}
+
+	seq := executeTestOEBlocks(t, filepath.Join(dir, "seq"),
+		&testOEChain{level: 1}, plt, wallets, blocks)
+	opt := executeTestOEBlocks(t, filepath.Join(dir, "opt"),
+		&testOEChain{level: 4, optimistic: true}, plt, wallets, blocks)
+
+	assert.Len(t, seq, len(blocks))
+	assert.Len(t, opt, len(blocks))
+	for i := range seq {
+		assert.Equal(t, seq[i].receipts, opt[i].receipts, "block=%d", i)
+		assert.Equal(t, seq[i].result, opt[i].result, "block=%d", i)
+	}
+
+	// logs of all transactions are applied to the extension
+	tr, err := newTransitionResultFromBytes(opt[len(opt)-1].result)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(logs), intconv.BytesToInt64(tr.ExtensionData))
+}
+
+type testOEContext struct {
+	contract.Context
+	ws state.WorldState
+}
+
+func (c *testOEContext) GetSnapshot() state.WorldSnapshot {
+	return c.ws.GetSnapshot()
+}
+
+func TestVersionedStore(t *testing.T) {
+	id := testOEScore.ID()
+	ws := state.NewWorldState(db.NewMapDB(), nil, nil, nil)
+	vs := &versionedStore{ctx: &testOEContext{ws: ws}}
+	for i := 0; i < 4; i++ {
+		vs.sps = append(vs.sps, &speculation{
+			ctx:  &trackingContext{},
+			done: make(chan struct{}),
+		})
+	}
+	start := func(idx int) state.AccountState {
+		ws, err := vs.stateFor(idx)
+		assert.NoError(t, err)
+		vs.sps[idx].ws = state.NewTrackingWorldState(ws)
+		return vs.sps[idx].ws.GetAccountState(id)
+	}
+
+	// tx0 writes "a"
+	as := start(0)
+	_, err := as.SetValue([]byte("a"), []byte("1"))
+	assert.NoError(t, err)
+	close(vs.sps[0].done)
+
+	// tx1 reads "a" written by tx0, and writes "b"
+	as = start(1)
+	assert.Equal(t, []int{0}, vs.sps[1].deps)
+	v, err := as.GetValue([]byte("a"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("1"), v)
+	_, err = as.SetValue([]byte("b"), []byte("2"))
+	assert.NoError(t, err)
+
+	// tx2 and tx3 start before tx1 is done
+	as = start(2)
+	assert.Equal(t, []int{0}, vs.sps[2].deps)
+	v, err = as.GetValue([]byte("c"))
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+	close(vs.sps[2].done)
+	as = start(3)
+	assert.Equal(t, []int{0, 2}, vs.sps[3].deps)
+	v, err = as.GetValue([]byte("b"))
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+	close(vs.sps[3].done)
+	close(vs.sps[1].done)
+
+	for idx := 0; idx < 3; idx++ {
+		assert.True(t, vs.validate(idx), "idx=%d", idx)
+		assert.NoError(t, vs.sps[idx].ws.Apply(ws))
+		vs.commit(idx, vs.sps[idx].ws.Writes())
+	}
+	// tx3 read "b" before tx1 wrote it
+	assert.False(t, vs.validate(3))
+
+	// tx1 used writes of tx0, which is executed again
+	vs.validated = 0
+	vs.reexecuted(0, state.NewAccessSet())
+	assert.False(t, vs.validate(1))
+	assert.True(t, vs.validate(2))
+}
+
+// testOEBlockHeader is the header of a block in the chain database, which
+// is stored by the block manager (blockV2HeaderFormat of the block package).
+// Result of the header is the result of transactions of the previous block.
+type testOEBlockHeader struct {
+	Version                int
+	Height                 int64
+	Timestamp              int64
+	Proposer               []byte
+	PrevID                 []byte
+	VotesHash              []byte
+	NextValidatorsHash     []byte
+	PatchTransactionsHash  []byte
+	NormalTransactionsHash []byte
+	LogsBloom              []byte
+	Result                 []byte
+}
+
+func storeTestOEBlockHeader(t *testing.T, dbase db.Database, header *testOEBlockHeader) {
+	bs := codec.BC.MustMarshalToBytes(header)
+	hb, err := db.NewCodedBucket(dbase, db.BytesByHash, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, hb.Put(header))
+	hh, err := db.NewCodedBucket(dbase, db.BlockHeaderHashByHeight, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, hh.Set(header.Height, db.Raw(crypto.SHA3Sum256(bs))))
+}
+
+func loadTestOEBlockHeader(t *testing.T, dbase db.Database, height int64) *testOEBlockHeader {
+	hh, err := db.NewCodedBucket(dbase, db.BlockHeaderHashByHeight, nil)
+	assert.NoError(t, err)
+	id, err := hh.GetBytes(height)
+	assert.NoError(t, err)
+	hb, err := db.NewCodedBucket(dbase, db.BytesByHash, nil)
+	assert.NoError(t, err)
+	header := new(testOEBlockHeader)
+	assert.NoError(t, hb.Get(db.Raw(id), header), "height=%d", height)
+	return header
+}
+
+func getTestOEReceipts(t *testing.T, rl module.ReceiptList) [][]byte {
+	var receipts [][]byte
+	for i := rl.Iterator(); i.Has(); i.Next() {
+		rct, err := i.Get()
+		assert.NoError(t, err)
+		receipts = append(receipts, rct.Bytes())
+	}
+	return receipts
+}
+
+// storeTestOEChainDB executes blocks sequentially, and stores headers,
+// transactions and receipts of them like the block manager does. Blocks
+// start from the height 1, and the block following the last one keeps the
+// result of it.
+func storeTestOEChainDB(t *testing.T, dir string, plt base.Platform, wallets []module.Wallet, blocks [][]module.Transaction) db.Database {
+	assert.NoError(t, os.MkdirAll(dir, 0700))
+	logger := log.GlobalLogger()
+	chain := &testOEChain{level: 1}
+	em, c := newTestOEManager(t, dir, chain.level)
+	defer em.Close()
+
+	dbase := db.NewMapDB()
+	cm, err := plt.NewContractManager(dbase, filepath.Join(dir, "contract"), logger)
+	assert.NoError(t, err)
+	wss, err := newWorldSnapshot(dbase, plt, nil, nil)
+	assert.NoError(t, err)
+	ws, err := state.WorldStateFromSnapshot(wss)
+	assert.NoError(t, err)
+	setupTestOEState(t, ws, wallets, c)
+	wss = ws.GetSnapshot()
+	assert.NoError(t, wss.Flush())
+	genesis := &transitionResult{
+		StateHash:     wss.StateHash(),
+		ExtensionData: wss.ExtensionData(),
+	}
+	parent, err := newInitTransition(dbase, genesis.Bytes(), nil, cm, em, chain, logger, plt, nil)
+	assert.NoError(t, err)
+
+	for i, txs := range blocks {
+		bi := common.NewBlockInfo(int64(i+1), int64(i+1)*1000000)
+		tr := newTransition(parent, nil, transaction.NewTransactionListFromSlice(dbase, txs), bi, nil, true)
+		cb := make(testOECallback, 2)
+		_, err := tr.Execute(cb)
+		assert.NoError(t, err)
+		assert.NoError(t, <-cb)
+		assert.NoError(t, tr.NormalTransactions().Flush())
+		assert.NoError(t, tr.worldSnapshot.Flush())
+		assert.NoError(t, tr.normalReceipts.Flush())
+		storeTestOEBlockHeader(t, dbase, &testOEBlockHeader{
+			Version:                module.BlockVersion2,
+			Height:                 bi.Height(),
+			Timestamp:              bi.Timestamp(),
+			PatchTransactionsHash:  tr.PatchTransactions().Hash(),
+			NormalTransactionsHash: tr.NormalTransactions().Hash(),
+			Result:                 parent.Result(),
+		})
+		parent = tr
+	}
+	storeTestOEBlockHeader(t, dbase, &testOEBlockHeader{
+		Version:   module.BlockVersion2,
+		Height:    int64(len(blocks) + 1),
+		Timestamp: int64(len(blocks)+1) * 1000000,
+		Result:    parent.Result(),
+	})
+	return dbase
+}
+
+// replayTestOEBlocks executes stored blocks of the chain database from the
+// height from to the height to, and returns receipts of them. Results and
+// receipts of each block are compared with the stored ones byte for byte.
+func replayTestOEBlocks(t *testing.T, dbase db.Database, dir string, chain *testOEChain, plt base.Platform, em eeproxy.Manager, from, to int64) [][][]byte {
+	logger := log.GlobalLogger()
+	cm, err := plt.NewContractManager(dbase, dir, logger)
+	assert.NoError(t, err)
+
+	header := loadTestOEBlockHeader(t, dbase, from)
+	var vl module.ValidatorList
+	if len(header.NextValidatorsHash) > 0 {
+		vs, err := state.ValidatorSnapshotFromHash(dbase, header.NextValidatorsHash)
+		assert.NoError(t, err)
+		vl = vs
+	}
+	parent, err := newInitTransition(dbase, header.Result, vl, cm, em, chain, logger, plt, nil)
+	if !assert.NoError(t, err) {
+		return nil
+	}
+
+	var receipts [][][]byte
+	for height := from; height <= to; height++ {
+		next := loadTestOEBlockHeader(t, dbase, height+1)
+		stored, err := newTransitionResultFromBytes(next.Result)
+		assert.NoError(t, err)
+
+		tr := newTransition(parent,
+			transaction.NewTransactionListFromHash(dbase, header.PatchTransactionsHash),
+			transaction.NewTransactionListFromHash(dbase, header.NormalTransactionsHash),
+			common.NewBlockInfo(header.Height, header.Timestamp), nil, true)
+		cb := make(testOECallback, 2)
+		_, err = tr.Execute(cb)
+		assert.NoError(t, err)
+		if !assert.NoError(t, <-cb, "height=%d", height) {
+			return receipts
+		}
+
+		patch := getTestOEReceipts(t, tr.PatchReceipts())
+		normal := getTestOEReceipts(t, tr.NormalReceipts())
+		assert.Equal(t, getTestOEReceipts(t, txresult.NewReceiptListFromHash(dbase, stored.PatchReceiptHash)),
+			patch, "height=%d", height)
+		assert.Equal(t, getTestOEReceipts(t, txresult.NewReceiptListFromHash(dbase, stored.NormalReceiptHash)),
+			normal, "height=%d", height)
+		assert.Equal(t, next.Result, tr.Result(), "height=%d", height)
+
+		receipts = append(receipts, append(patch, normal...))
+		parent, header = tr, next
+	}
+	return receipts
+}
+
+func TestTransition_OptimisticExecutionReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oe")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	wallets := make([]module.Wallet, 12)
+	for i := range wallets {
+		wallets[i] = wallet.New()
+	}
+	blocks := newTestOEBlocks(t, wallets, 5, 40)
+	dbase := storeTestOEChainDB(t, filepath.Join(dir, "chain"), basic.Platform, wallets, blocks)
+
+	replay := func(name string, chain *testOEChain, from, to int64) [][][]byte {
+		em, _ := newTestOEManager(t, filepath.Join(dir, name), chain.level)
+		defer em.Close()
+		return replayTestOEBlocks(t, dbase, filepath.Join(dir, name, "contract"),
+			chain, basic.Platform, em, from, to)
+	}
+	for _, r := range [][2]int64{{1, 5}, {3, 5}} {
+		seq := replay("seq", &testOEChain{level: 1}, r[0], r[1])
+		opt := replay("opt", &testOEChain{level: 4, optimistic: true}, r[0], r[1])
+		assert.Len(t, seq, int(r[1]-r[0]+1))
+		for i := range seq {
+			assert.Len(t, seq[i], len(blocks[int(r[0])-1+i]))
+		}
+		assert.Equal(t, seq, opt, "range=%d:%d", r[0], r[1])
+	}
+}
+
+// TestTransition_OptimisticExecutionReplayChainDB replays a range of blocks
+// of a chain database copied from a node of the basic platform in both ways
+// of execution. It's skipped unless the database is given.
+//
+//	GOLOOP_OE_CHAIN_DB=<dir>:<type>:<name>  database of the chain
+//	GOLOOP_OE_RANGE=<from>:<to>             heights of blocks to replay
+//	GOLOOP_OE_ENGINES=python,java           engines (default: python)
+//	GOLOOP_OE_NID=<nid>                     network ID (default: 1)
+func TestTransition_OptimisticExecutionReplayChainDB(t *testing.T) {
+	spec := strings.Split(os.Getenv("GOLOOP_OE_CHAIN_DB"), ":")
+	heights := strings.Split(os.Getenv("GOLOOP_OE_RANGE"), ":")
+	if len(spec) != 3 || len(heights) != 2 {
+		t.Skip("GOLOOP_OE_CHAIN_DB and GOLOOP_OE_RANGE are required")
+	}
+	from, err := strconv.ParseInt(heights[0], 0, 64)
+	assert.NoError(t, err)
+	to, err := strconv.ParseInt(heights[1], 0, 64)
+	assert.NoError(t, err)
+	engines := "python"
+	if v := os.Getenv("GOLOOP_OE_ENGINES"); v != "" {
+		engines = v
+	}
+	nid := int64(1)
+	if v := os.Getenv("GOLOOP_OE_NID"); v != "" {
+		nid, err = strconv.ParseInt(v, 0, 64)
+		assert.NoError(t, err)
+	}
+
+	dir, err := ioutil.TempDir("", "oe")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	dbase, err := db.Open(spec[0], spec[1], spec[2])
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer dbase.Close()
+
+	replay := func(name string, chain *testOEChain) [][][]byte {
+		logger := log.GlobalLogger()
+		ee, err := eeproxy.AllocEngines(logger, strings.Split(engines, ",")...)
+		assert.NoError(t, err)
+		em, err := eeproxy.NewManager("unix", filepath.Join(dir, name+".sock"), logger, ee...)
+		assert.NoError(t, err)
+		defer em.Close()
+		go em.Loop()
+		assert.NoError(t, em.SetInstances(chain.level+1, chain.level+1, chain.level+1))
+		// results are compared, but nothing is written to the database
+		return replayTestOEBlocks(t, db.NewLayerDB(dbase), filepath.Join(dir, name),
+			chain, basic.Platform, em, from, to)
+	}
+	seq := replay("seq", &testOEChain{nid: int(nid), level: 1})
+	opt := replay("opt", &testOEChain{nid: int(nid), level: 4, optimistic: true})
+	assert.Len(t, seq, int(to-from+1))
+	assert.Equal(t, seq, opt)
+}
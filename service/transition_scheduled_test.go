@@ -0,0 +1,338 @@
+package service
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/db"
+	"github.com/icon-project/goloop/common/intconv"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/common/wallet"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/contract"
+	"github.com/icon-project/goloop/service/eeproxy"
+	"github.com/icon-project/goloop/service/platform/basic"
+	"github.com/icon-project/goloop/service/scoredb"
+	"github.com/icon-project/goloop/service/state"
+	"github.com/icon-project/goloop/service/transaction"
+)
+
+// testSystemChain executes blocks on the state with the chain SCORE and
+// the counter contract of optimistic execution tests.
+type testSystemChain struct {
+	parent *transition
+	mdb    db.Database
+	cm     contract.ContractManager
+	em     eeproxy.Manager
+}
+
+func newTestSystemChain(t *testing.T, dir string, wallets []module.Wallet, cs *eeproxy.GoContracts) *testSystemChain {
+	return newTestSystemChainWithRevision(t, dir, wallets, cs, basic.LatestRevision)
+}
+
+// newTestSystemChainWithRevision installs the chain SCORE at the revision, so
+// the chain SCORE exposes only the methods available at the revision.
+func newTestSystemChainWithRevision(t *testing.T, dir string, wallets []module.Wallet, cs *eeproxy.GoContracts, rev int) *testSystemChain {
+	logger := log.GlobalLogger()
+	c := newTestOEContract(t)
+	cs.Register("counter", c)
+	em, err := eeproxy.NewManager("unix", filepath.Join(dir, "ee.sock"), logger,
+		eeproxy.NewGoEE(logger, "java", cs), eeproxy.NewGoEE(logger, "python", cs))
+	assert.NoError(t, err)
+	go em.Loop()
+	assert.NoError(t, em.SetInstances(1, 1, 1))
+
+	mdb := db.NewMapDB()
+	cm, err := basic.Platform.NewContractManager(mdb, filepath.Join(dir, "contract"), logger)
+	assert.NoError(t, err)
+
+	parent, err := newInitTransition(mdb, nil, nil, cm, em, &testOEChain{level: 1}, logger, basic.Platform, nil)
+	assert.NoError(t, err)
+	ws, err := state.WorldStateFromSnapshot(parent.worldSnapshot)
+	assert.NoError(t, err)
+	wc := state.NewWorldContext(ws, common.NewBlockInfo(0, 0), nil, basic.Platform)
+	cc := contract.NewCallContext(contract.NewContext(wc, cm, em, &testOEChain{level: 1}, logger, nil),
+		new(big.Int), false)
+	assert.NoError(t, contract.DeployAndInstallSystemSCORE(cc, contract.CID_CHAIN, nil, state.SystemAddress,
+		[]byte(fmt.Sprintf(`{"revision":"%#x"}`, rev)), nil))
+	setupTestOEState(t, ws, wallets, c)
+	sys := ws.GetAccountState(state.SystemID)
+	assert.NoError(t, scoredb.NewVarDB(sys, state.VarRevision).Set(rev))
+	parent.worldSnapshot = ws.GetSnapshot()
+	return &testSystemChain{parent: parent, mdb: mdb, cm: cm, em: em}
+}
+
+func (c *testSystemChain) close() {
+	c.em.Close()
+}
+
+// update applies changes on the state of the last block.
+func (c *testSystemChain) update(t *testing.T, f func(ws state.WorldState)) {
+	ws, err := state.WorldStateFromSnapshot(c.parent.worldSnapshot)
+	assert.NoError(t, err)
+	f(ws)
+	c.parent.worldSnapshot = ws.GetSnapshot()
+}
+
+func (c *testSystemChain) balance(addr module.Address) *big.Int {
+	return c.parent.worldSnapshot.GetAccountSnapshot(addr.ID()).GetBalance()
+}
+
+func (c *testSystemChain) worldContext(height int64) state.WorldContext {
+	ws, _ := state.WorldStateFromSnapshot(c.parent.worldSnapshot)
+	bi := common.NewBlockInfo(height, height*1000000)
+	return state.NewWorldContext(ws, bi, nil, basic.Platform)
+}
+
+func (c *testSystemChain) execute(t *testing.T, height int64, txs []module.Transaction) ([]module.Receipt, error) {
+	bi := common.NewBlockInfo(height, height*1000000)
+	tr := newTransition(c.parent, nil, transaction.NewTransactionListFromSlice(c.mdb, txs), bi, nil, true)
+	cb := make(testOECallback, 2)
+	if _, err := tr.Execute(cb); err != nil {
+		return nil, err
+	}
+	if err := <-cb; err != nil {
+		return nil, err
+	}
+	var rcts []module.Receipt
+	for i := tr.NormalReceipts().Iterator(); i.Has(); i.Next() {
+		rct, err := i.Get()
+		assert.NoError(t, err)
+		rcts = append(rcts, rct)
+	}
+	c.parent = tr
+	return rcts, nil
+}
+
+func (c *testSystemChain) counter(key string) int64 {
+	ws := c.parent.worldSnapshot
+	ass := ws.GetAccountSnapshot(testOEScore.ID())
+	bs, _ := ass.GetValue([]byte(key))
+	return new(big.Int).SetBytes(bs).Int64()
+}
+
+func newTestScheduleTx(t *testing.T, w module.Wallet, ts int64, method string, params map[string]interface{}) module.Transaction {
+	return newTestOETx(t, w, ts, state.SystemAddress, 0, map[string]interface{}{
+		"method": method,
+		"params": params,
+	})
+}
+
+func TestTransition_ScheduledCall(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scheduled")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	wallets := []module.Wallet{wallet.New(), wallet.New()}
+	chain := newTestSystemChain(t, dir, wallets, eeproxy.NewGoContracts())
+	defer chain.close()
+	balance := func(w module.Wallet) *big.Int {
+		return chain.balance(w.Address())
+	}
+
+	// register calls at height 3 and 4, then cancel the later one
+	stepLimit := "0x10000"
+	rcts, err := chain.execute(t, 1, []module.Transaction{
+		newTestScheduleTx(t, wallets[0], 1, "scheduleCall", map[string]interface{}{
+			"height":    "0x3",
+			"to":        testOEScore.String(),
+			"method":    "add",
+			"stepLimit": stepLimit,
+			"params":    `{"key":"s","delta":"0x5"}`,
+		}),
+		newTestScheduleTx(t, wallets[1], 2, "scheduleCall", map[string]interface{}{
+			"height":    "0x4",
+			"to":        testOEScore.String(),
+			"method":    "add",
+			"stepLimit": stepLimit,
+			"params":    `{"key":"s","delta":"0x7"}`,
+		}),
+	})
+	assert.NoError(t, err)
+	for _, rct := range rcts {
+		assert.Equal(t, module.StatusSuccess, rct.Status())
+	}
+
+	before := balance(wallets[1])
+	rcts, err = chain.execute(t, 2, []module.Transaction{
+		newTestScheduleTx(t, wallets[1], 3, "cancelScheduledCall", map[string]interface{}{
+			"id": "0x2",
+		}),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, module.StatusSuccess, rcts[0].Status())
+	fee := new(big.Int).Mul(rcts[0].StepUsed(), rcts[0].StepPrice())
+	deposit := new(big.Int).Mul(big.NewInt(0x10000), rcts[0].StepPrice())
+	assert.Equal(t, new(big.Int).Sub(new(big.Int).Add(before, deposit), fee), balance(wallets[1]))
+
+	// the block at the height should have transactions for due calls
+	txs, err := transaction.NewScheduledTransactions(chain.worldContext(3))
+	assert.NoError(t, err)
+	assert.Len(t, txs, 1)
+	saved := chain.parent
+	_, err = chain.execute(t, 3, nil)
+	assert.Error(t, err)
+	chain.parent = saved
+
+	rcts, err = chain.execute(t, 3, txs)
+	assert.NoError(t, err)
+	if assert.Len(t, rcts, 1) {
+		assert.Equal(t, module.StatusSuccess, rcts[0].Status())
+		assert.Equal(t, testOEScore.String(), rcts[0].To().String())
+	}
+	assert.Equal(t, int64(5), chain.counter("s"))
+
+	sys := scoredb.NewStateStoreWith(chain.worldContext(4).GetAccountSnapshot(state.SystemID))
+	sc, err := contract.GetScheduledCall(sys, 1)
+	assert.NoError(t, err)
+	assert.Nil(t, sc)
+	assert.Len(t, contract.DueScheduledCalls(sys, 4, contract.MaxScheduledCallsPerBlock), 0)
+
+	// canceled call is not executed
+	txs, err = transaction.NewScheduledTransactions(chain.worldContext(4))
+	assert.NoError(t, err)
+	assert.Len(t, txs, 0)
+
+	// users can't submit the transaction
+	stx, err := transaction.NewScheduledTransaction(1, 3, 1)
+	assert.NoError(t, err)
+	assert.True(t, transaction.IsSystemTransaction(stx))
+	stx2, err := transaction.NewTransaction(stx.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, stx.ID(), stx2.ID())
+	assert.True(t, transaction.IsSystemTransaction(stx2))
+	assert.False(t, transaction.IsSystemTransaction(
+		newTestScheduleTx(t, wallets[0], 4, "getScheduledCall", map[string]interface{}{
+			"id": fmt.Sprintf("%#x", 1),
+		})))
+}
+
+func TestTransition_ScheduledCallCarryOver(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scheduled")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	wallets := []module.Wallet{wallet.New()}
+	chain := newTestSystemChain(t, dir, wallets, eeproxy.NewGoContracts())
+	defer chain.close()
+
+	// register more calls than a block allows
+	count := contract.MaxScheduledCallsPerBlock + 2
+	var txs []module.Transaction
+	for i := 0; i < count; i++ {
+		txs = append(txs, newTestScheduleTx(t, wallets[0], int64(i+1), "scheduleCall", map[string]interface{}{
+			"height":    "0x2",
+			"to":        testOEScore.String(),
+			"method":    "add",
+			"stepLimit": "0x10000",
+			"params":    `{"key":"s","delta":"0x1"}`,
+		}))
+	}
+	rcts, err := chain.execute(t, 1, txs)
+	assert.NoError(t, err)
+	for _, rct := range rcts {
+		assert.Equal(t, module.StatusSuccess, rct.Status())
+	}
+
+	stxs, err := transaction.NewScheduledTransactions(chain.worldContext(2))
+	assert.NoError(t, err)
+	assert.Len(t, stxs, contract.MaxScheduledCallsPerBlock)
+
+	// calls should be at the start of the block in order
+	user := newTestScheduleTx(t, wallets[0], int64(count+1), "scheduleCall", map[string]interface{}{
+		"height":    "0x10",
+		"to":        testOEScore.String(),
+		"method":    "add",
+		"stepLimit": "0x10000",
+	})
+	saved := chain.parent
+	for _, invalid := range [][]module.Transaction{
+		append([]module.Transaction{user}, stxs...),
+		append([]module.Transaction{stxs[1], stxs[0]}, stxs[2:]...),
+		stxs[:len(stxs)-1],
+	} {
+		_, err = chain.execute(t, 2, invalid)
+		assert.Error(t, err)
+		chain.parent = saved
+	}
+
+	rcts, err = chain.execute(t, 2, append(stxs, user))
+	assert.NoError(t, err)
+	assert.Len(t, rcts, contract.MaxScheduledCallsPerBlock+1)
+	for _, rct := range rcts {
+		assert.Equal(t, module.StatusSuccess, rct.Status())
+	}
+	assert.Equal(t, int64(contract.MaxScheduledCallsPerBlock), chain.counter("s"))
+
+	// remaining calls are carried over to the next block
+	stxs, err = transaction.NewScheduledTransactions(chain.worldContext(3))
+	assert.NoError(t, err)
+	assert.Len(t, stxs, 2)
+	saved = chain.parent
+	_, err = chain.execute(t, 3, nil)
+	assert.Error(t, err)
+	chain.parent = saved
+
+	rcts, err = chain.execute(t, 3, stxs)
+	assert.NoError(t, err)
+	for _, rct := range rcts {
+		assert.Equal(t, module.StatusSuccess, rct.Status())
+
+		// receipts show the delay of carried over calls
+		var ev module.EventLog
+		for itr := rct.EventLogIterator(); itr.Has(); assert.NoError(t, itr.Next()) {
+			ev, err = itr.Get()
+			assert.NoError(t, err)
+		}
+		if assert.NotNil(t, ev) {
+			assert.Equal(t, []byte(transaction.ScheduledCallExecutedSignature), ev.Indexed()[0])
+			assert.Equal(t, [][]byte{intconv.Int64ToBytes(2), intconv.Int64ToBytes(3)}, ev.Data())
+		}
+	}
+	assert.Equal(t, int64(count), chain.counter("s"))
+
+	stxs, err = transaction.NewScheduledTransactions(chain.worldContext(4))
+	assert.NoError(t, err)
+	assert.Len(t, stxs, 0)
+	sys := scoredb.NewStateStoreWith(chain.worldContext(4).GetAccountSnapshot(state.SystemID))
+	assert.Nil(t, scoredb.NewVarDB(sys, state.VarScheduleBacklog).Bytes())
+}
+
+func TestTransition_ScheduledCallRevision(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scheduled")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	wallets := []module.Wallet{wallet.New()}
+	chain := newTestSystemChainWithRevision(t, dir, wallets, eeproxy.NewGoContracts(), basic.Revision8)
+	defer chain.close()
+
+	rcts, err := chain.execute(t, 1, []module.Transaction{
+		newTestScheduleTx(t, wallets[0], 1, "scheduleCall", map[string]interface{}{
+			"height":    "0x2",
+			"to":        testOEScore.String(),
+			"method":    "add",
+			"stepLimit": "0x10000",
+		}),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, module.StatusMethodNotFound, rcts[0].Status())
+
+	stxs, err := transaction.NewScheduledTransactions(chain.worldContext(2))
+	assert.NoError(t, err)
+	assert.Len(t, stxs, 0)
+
+	stx, err := transaction.NewScheduledTransaction(1, 2, 2000000)
+	assert.NoError(t, err)
+	err = stx.PreValidate(chain.worldContext(2), true)
+	assert.True(t, transaction.InvalidTxValue.Equals(err), "err=%+v", err)
+	_, err = chain.execute(t, 2, []module.Transaction{stx})
+	assert.Error(t, err)
+}
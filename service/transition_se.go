@@ -34,46 +34,57 @@ func (t *transition) executeTxsSequential(l module.TransactionList, ctx contract
 			cnt++
 			continue
 		}
-		t.log.Tracef("START TX <0x%x>", txo.ID())
-		ts := time.Now()
-		for trial := 0; ; trial++ {
-			txh, err := txo.GetHandler(t.cm)
-			if err != nil {
-				t.log.Errorf("Fail to GetHandler err=%+v", err)
-				return err
-			}
-			ctx.SetTransactionInfo(&state.TransactionInfo{
-				Group:     txo.Group(),
-				Index:     int32(cnt),
-				Timestamp: txo.Timestamp(),
-				Nonce:     txo.Nonce(),
-				Hash:      txo.ID(),
-				From:      txo.From(),
-			})
-			ctx.UpdateSystemInfo()
-			rct, err := txh.Execute(ctx, false)
-			txh.Dispose()
-			if err == nil {
-				err = t.plt.OnTransactionEnd(ctx, t.log, rct)
-			}
-			if err == nil {
-				rctBuf[cnt] = rct
-				break
-			}
-			if !errors.ExecutionFailError.Equals(err) {
-				t.log.Warnf("Fail to execute transaction err=%+v", err)
-				return err
-			}
-			if trial == RetryCount {
-				t.log.Warnf("Fail to execute transaction retry=%d err=%+v", trial, err)
-				return err
-			}
-			t.log.Warnf("RETRY TX <%#x> for err=%+v", txo.ID(), err)
-			ts = time.Now()
+		rct, err := t.executeTx(ctx, txo, cnt)
+		if err != nil {
+			return err
 		}
-		duration := time.Now().Sub(ts)
-		t.log.Tracef("END   TX <0x%x> duration=%s", txo.ID(), duration)
+		rctBuf[cnt] = rct
 		cnt++
 	}
 	return nil
 }
+
+func transactionInfoOf(txo transaction.Transaction, idx int) *state.TransactionInfo {
+	return &state.TransactionInfo{
+		Group:     txo.Group(),
+		Index:     int32(idx),
+		Timestamp: txo.Timestamp(),
+		Nonce:     txo.Nonce(),
+		Hash:      txo.ID(),
+		From:      txo.From(),
+	}
+}
+
+func (t *transition) executeTx(ctx contract.Context, txo transaction.Transaction, cnt int) (txresult.Receipt, error) {
+	t.log.Tracef("START TX <0x%x>", txo.ID())
+	ts := time.Now()
+	for trial := 0; ; trial++ {
+		txh, err := txo.GetHandler(t.cm)
+		if err != nil {
+			t.log.Errorf("Fail to GetHandler err=%+v", err)
+			return nil, err
+		}
+		ctx.SetTransactionInfo(transactionInfoOf(txo, cnt))
+		ctx.UpdateSystemInfo()
+		rct, err := txh.Execute(ctx, false)
+		txh.Dispose()
+		if err == nil {
+			err = t.plt.OnTransactionEnd(ctx, t.log, rct)
+		}
+		if err == nil {
+			duration := time.Now().Sub(ts)
+			t.log.Tracef("END   TX <0x%x> duration=%s", txo.ID(), duration)
+			return rct, nil
+		}
+		if !errors.ExecutionFailError.Equals(err) {
+			t.log.Warnf("Fail to execute transaction err=%+v", err)
+			return nil, err
+		}
+		if trial == RetryCount {
+			t.log.Warnf("Fail to execute transaction retry=%d err=%+v", trial, err)
+			return nil, err
+		}
+		t.log.Warnf("RETRY TX <%#x> for err=%+v", txo.ID(), err)
+		ts = time.Now()
+	}
+}
@@ -0,0 +1,83 @@
+package txresult
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/module"
+)
+
+type batchResult struct {
+	Status   module.Status
+	StepUsed common.HexInt
+}
+
+type batchResultJSON struct {
+	Status   common.HexUint16 `json:"status"`
+	StepUsed common.HexInt    `json:"stepUsed"`
+	Failure  *failureReason   `json:"failure,omitempty"`
+}
+
+// batchResults is results of sub operations of the batch transaction.
+type batchResults []*batchResult
+
+func (r *batchResults) Add(status module.Status, used *big.Int) {
+	br := &batchResult{Status: status}
+	br.StepUsed.Set(used)
+	*r = append(*r, br)
+}
+
+func (r batchResults) Has() bool {
+	return len(r) > 0
+}
+
+func (r batchResults) ToJSON(v module.JSONVersion) (interface{}, error) {
+	jso := make([]*batchResultJSON, len(r))
+	for i, br := range r {
+		item := &batchResultJSON{StepUsed: br.StepUsed}
+		if br.Status == module.StatusSuccess {
+			item.Status.Value = 1
+		} else {
+			item.Failure = failureReasonByCode(br.Status)
+		}
+		jso[i] = item
+	}
+	return jso, nil
+}
+
+func (r *batchResults) UnmarshalJSON(s []byte) error {
+	var jso []*batchResultJSON
+	if err := json.Unmarshal(s, &jso); err != nil {
+		return err
+	}
+	results := make(batchResults, len(jso))
+	for i, item := range jso {
+		br := &batchResult{StepUsed: item.StepUsed}
+		if item.Status.Value != 1 {
+			if item.Failure != nil {
+				br.Status = module.Status(item.Failure.CodeValue.Value)
+			} else {
+				br.Status = module.StatusUnknownFailure
+			}
+		}
+		results[i] = br
+	}
+	*r = results
+	return nil
+}
+
+func (r *batchResults) RLPEncodeSelf(e codec.Encoder) error {
+	rl := []*batchResult(*r)
+	return e.Encode(rl)
+}
+
+func (r *batchResults) RLPDecodeSelf(d codec.Decoder) error {
+	var rl []*batchResult
+	if err := d.Decode(&rl); err != nil {
+		return err
+	}
+	*r = rl
+	return nil
+}
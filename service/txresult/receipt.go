@@ -170,6 +170,7 @@ const (
 const (
 	ExtensionFeeDetail = 1 << iota
 	ExtensionDisableLogsBloom
+	ExtensionBatchResults
 )
 
 type receiptData struct {
@@ -183,6 +184,7 @@ type receiptData struct {
 	SCOREAddress       *common.Address
 	FeeDetail          feeDetail
 	DisableLogsBloom   bool
+	BatchResults       batchResults
 }
 
 func (r *receiptData) Equal(r2 *receiptData) bool {
@@ -194,7 +196,8 @@ func (r *receiptData) Equal(r2 *receiptData) bool {
 		r.LogsBloom.Equal(&r2.LogsBloom) &&
 		r.SCOREAddress.Equal(r2.SCOREAddress) &&
 		r.DisableLogsBloom == r2.DisableLogsBloom &&
-		reflect.DeepEqual(r.FeeDetail, r2.FeeDetail)
+		reflect.DeepEqual(r.FeeDetail, r2.FeeDetail) &&
+		reflect.DeepEqual(r.BatchResults, r2.BatchResults)
 }
 
 func (r *receiptData) Extension() int {
@@ -205,6 +208,9 @@ func (r *receiptData) Extension() int {
 	if r.DisableLogsBloom {
 		extension |= ExtensionDisableLogsBloom
 	}
+	if r.BatchResults.Has() {
+		extension |= ExtensionBatchResults
+	}
 	return extension
 }
 
@@ -313,6 +319,11 @@ func (r *receipt) RLPEncodeSelf(e codec.Encoder) error {
 				return err
 			}
 		}
+		if (extension & ExtensionBatchResults) != 0 {
+			if err = e2.Encode(&r.data.BatchResults); err != nil {
+				return err
+			}
+		}
 		return nil
 	}
 }
@@ -348,6 +359,11 @@ func (r *receipt) RLPDecodeSelf(d codec.Decoder) error {
 					return err
 				}
 			}
+			if (extension & ExtensionBatchResults) != 0 {
+				if err := d2.Decode(&r.data.BatchResults); err != nil {
+					return err
+				}
+			}
 		} else {
 			return codec.ErrInvalidFormat
 		}
@@ -409,6 +425,13 @@ func (r *receipt) AddPayment(addr module.Address, steps *big.Int) {
 	}
 }
 
+func (r *receipt) AddBatchResult(status module.Status, used *big.Int) {
+	r.data.BatchResults.Add(status, used)
+	if r.version < Version3 {
+		r.version = Version3
+	}
+}
+
 func (r *receipt) DisableLogsBloom() {
 	r.data.DisableLogsBloom = true
 	r.data.LogsBloom.SetBytes(nil)
@@ -484,6 +507,7 @@ type Receipt interface {
 	module.Receipt
 	AddLog(addr module.Address, indexed, data [][]byte)
 	AddPayment(addr module.Address, steps *big.Int)
+	AddBatchResult(status module.Status, used *big.Int)
 	DisableLogsBloom()
 	SetCumulativeStepUsed(cumulativeUsed *big.Int)
 	SetResult(status module.Status, used, price *big.Int, addr module.Address)
@@ -503,6 +527,7 @@ type receiptJSON struct {
 	LogsBloom          *LogsBloom       `json:"logsBloom"`
 	Status             common.HexUint16 `json:"status"`
 	FeeDetail          feeDetail        `json:"stepUsedDetails,omitempty"`
+	BatchResults       batchResults     `json:"batchResults,omitempty"`
 }
 
 func (r *receipt) ToJSON(version module.JSONVersion) (interface{}, error) {
@@ -535,6 +560,14 @@ func (r *receipt) ToJSON(version module.JSONVersion) (interface{}, error) {
 		jso["stepUsedDetails"] = details
 	}
 
+	if r.data.BatchResults.Has() {
+		results, err := r.data.BatchResults.ToJSON(version)
+		if err != nil {
+			return nil, err
+		}
+		jso["batchResults"] = results
+	}
+
 	if r.data.Status == module.StatusSuccess {
 		jso["status"] = "0x1"
 		if r.data.SCOREAddress != nil {
@@ -587,6 +620,7 @@ func (r *receipt) UnmarshalJSON(bs []byte) error {
 		data.DisableLogsBloom = true
 	}
 	data.FeeDetail = rjson.FeeDetail
+	data.BatchResults = rjson.BatchResults
 	if r.data.Extension() != 0 && r.version < Version3 {
 		r.version = Version3
 	}
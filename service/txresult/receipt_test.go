@@ -130,3 +130,34 @@ func TestReceipt_DisableLogBloom(t *testing.T) {
 		})
 	}
 }
+
+func TestReceipt_BatchResults(t *testing.T) {
+	dbase := db.NewMapDB()
+	to := common.MustNewAddressFromString("hx9834234")
+	for _, rev := range []module.Revision{module.NoRevision, module.LatestRevision} {
+		t.Run(fmt.Sprint("Rev", rev), func(t *testing.T) {
+			rct := NewReceipt(dbase, rev, to)
+			rct.AddBatchResult(module.StatusSuccess, big.NewInt(100))
+			rct.AddBatchResult(module.StatusMethodNotFound, big.NewInt(200))
+			rct.SetResult(module.StatusMethodNotFound, big.NewInt(1000), big.NewInt(10), nil)
+
+			// json marshalling test
+			jso, err := rct.ToJSON(module.JSONVersionLast)
+			assert.NoError(t, err)
+			jb, err := json.Marshal(jso)
+			assert.NoError(t, err)
+			rct2, err := NewReceiptFromJSON(dbase, rev, jb)
+			assert.NoError(t, err)
+			assert.NoError(t, rct.Check(rct2))
+
+			// binary marshalling test
+			bs := codec.BC.MustMarshalToBytes(rct)
+			rct3 := new(receipt)
+			assert.NoError(t, rct3.Reset(dbase, bs))
+			assert.NoError(t, rct.Check(rct3))
+			assert.Equal(t, bs, rct3.Bytes())
+			assert.Len(t, rct3.data.BatchResults, 2)
+			assert.Equal(t, module.StatusMethodNotFound, rct3.data.BatchResults[1].Status)
+		})
+	}
+}
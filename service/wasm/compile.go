@@ -0,0 +1,662 @@
+package wasm
+
+const (
+	opUnreachable  = 0x00
+	opNop          = 0x01
+	opBlock        = 0x02
+	opLoop         = 0x03
+	opIf           = 0x04
+	opElse         = 0x05
+	opEnd          = 0x0b
+	opBr           = 0x0c
+	opBrIf         = 0x0d
+	opBrTable      = 0x0e
+	opReturn       = 0x0f
+	opCall         = 0x10
+	opCallIndirect = 0x11
+	opDrop         = 0x1a
+	opSelect       = 0x1b
+	opSelectT      = 0x1c
+	opLocalGet     = 0x20
+	opLocalSet     = 0x21
+	opLocalTee     = 0x22
+	opGlobalGet    = 0x23
+	opGlobalSet    = 0x24
+)
+
+const (
+	opI32Load = iota + 0x28
+	opI64Load
+	opF32Load
+	opF64Load
+	opI32Load8S
+	opI32Load8U
+	opI32Load16S
+	opI32Load16U
+	opI64Load8S
+	opI64Load8U
+	opI64Load16S
+	opI64Load16U
+	opI64Load32S
+	opI64Load32U
+	opI32Store
+	opI64Store
+	opF32Store
+	opF64Store
+	opI32Store8
+	opI32Store16
+	opI64Store8
+	opI64Store16
+	opI64Store32
+	opMemorySize
+	opMemoryGrow
+	opI32Const
+	opI64Const
+)
+
+const (
+	opI32Eqz = iota + 0x45
+	opI32Eq
+	opI32Ne
+	opI32LtS
+	opI32LtU
+	opI32GtS
+	opI32GtU
+	opI32LeS
+	opI32LeU
+	opI32GeS
+	opI32GeU
+	opI64Eqz
+	opI64Eq
+	opI64Ne
+	opI64LtS
+	opI64LtU
+	opI64GtS
+	opI64GtU
+	opI64LeS
+	opI64LeU
+	opI64GeS
+	opI64GeU
+)
+
+const (
+	opI32Clz = iota + 0x67
+	opI32Ctz
+	opI32Popcnt
+	opI32Add
+	opI32Sub
+	opI32Mul
+	opI32DivS
+	opI32DivU
+	opI32RemS
+	opI32RemU
+	opI32And
+	opI32Or
+	opI32Xor
+	opI32Shl
+	opI32ShrS
+	opI32ShrU
+	opI32Rotl
+	opI32Rotr
+	opI64Clz
+	opI64Ctz
+	opI64Popcnt
+	opI64Add
+	opI64Sub
+	opI64Mul
+	opI64DivS
+	opI64DivU
+	opI64RemS
+	opI64RemU
+	opI64And
+	opI64Or
+	opI64Xor
+	opI64Shl
+	opI64ShrS
+	opI64ShrU
+	opI64Rotl
+	opI64Rotr
+)
+
+const (
+	opI32WrapI64    = 0xa7
+	opI64ExtendI32S = 0xac
+	opI64ExtendI32U = 0xad
+	opI32Extend8S   = 0xc0
+	opI32Extend16S  = 0xc1
+	opI64Extend8S   = 0xc2
+	opI64Extend16S  = 0xc3
+	opI64Extend32S  = 0xc4
+	opPrefixMisc    = 0xfc
+	opMemoryCopy    = opPrefixMisc<<8 | 0x0a
+	opMemoryFill    = opPrefixMisc<<8 | 0x0b
+)
+
+//unknownType is used for the values on the stack of unreachable code
+const unknownType ValueType = 0
+
+//instr is a compiled instruction. a and b are immediate values, branch
+//instructions keep the index of the branch table in a.
+type instr struct {
+	op uint16
+	a  uint32
+	b  uint64
+}
+
+//branch is a resolved target of br, br_if and br_table. On branching, the
+//top arity values are moved to height of the operand stack of the frame.
+type branch struct {
+	pc     uint32
+	height uint32
+	arity  uint32
+}
+
+type function struct {
+	typ       uint32
+	numLocals int
+	maxStack  int
+	code      []instr
+	branches  []branch
+}
+
+type ctrlFrame struct {
+	op          byte
+	params      []ValueType
+	results     []ValueType
+	height      int
+	unreachable bool
+	start       int
+	patch       int
+	fixups      []int
+}
+
+func (f *ctrlFrame) labelTypes() []ValueType {
+	if f.op == opLoop {
+		return f.params
+	}
+	return f.results
+}
+
+type compiler struct {
+	m        *Module
+	r        *reader
+	locals   []ValueType
+	stack    []ValueType
+	ctrls    []*ctrlFrame
+	code     []instr
+	branches []branch
+	maxStack int
+}
+
+func (m *Module) compileFunction(typ uint32, body []byte) (*function, error) {
+	ft := &m.types[typ]
+	c := &compiler{m: m, r: &reader{b: body}}
+	c.locals = append(c.locals, ft.Params...)
+	n := c.r.count(len(body))
+	for i := 0; i < n && c.r.err == nil; i++ {
+		cnt := c.r.u32()
+		t := c.r.valueType()
+		if int64(len(c.locals))+int64(cnt) > MaxFunctionLocals {
+			c.r.fail("TooManyLocals(n=%d)", int64(len(c.locals))+int64(cnt))
+			break
+		}
+		for j := uint32(0); j < cnt; j++ {
+			c.locals = append(c.locals, t)
+		}
+	}
+	c.pushCtrl(opBlock, nil, ft.Results)
+	for c.r.err == nil && len(c.ctrls) > 0 {
+		c.compile()
+	}
+	if c.r.err != nil {
+		return nil, c.r.err
+	}
+	if !c.r.eof() {
+		return nil, errorf("InvalidFunctionEnd(pos=%d)", c.r.pos)
+	}
+	return &function{
+		typ:       typ,
+		numLocals: len(c.locals),
+		maxStack:  c.maxStack,
+		code:      c.code,
+		branches:  c.branches,
+	}, nil
+}
+
+func (c *compiler) emit(op uint16, a uint32, b uint64) int {
+	c.code = append(c.code, instr{op: op, a: a, b: b})
+	return len(c.code) - 1
+}
+
+func (c *compiler) push(t ValueType) {
+	c.stack = append(c.stack, t)
+	if len(c.stack) > c.maxStack {
+		c.maxStack = len(c.stack)
+	}
+}
+
+func (c *compiler) pushVals(ts []ValueType) {
+	for _, t := range ts {
+		c.push(t)
+	}
+}
+
+func (c *compiler) pop() ValueType {
+	f := c.ctrls[len(c.ctrls)-1]
+	if len(c.stack) == f.height {
+		if !f.unreachable {
+			c.r.fail("StackUnderflow(pos=%d)", c.r.pos)
+		}
+		return unknownType
+	}
+	t := c.stack[len(c.stack)-1]
+	c.stack = c.stack[:len(c.stack)-1]
+	return t
+}
+
+func (c *compiler) popExpect(t ValueType) ValueType {
+	actual := c.pop()
+	if actual != t && actual != unknownType && t != unknownType {
+		c.r.fail("TypeMismatch(pos=%d,exp=%s,real=%s)", c.r.pos, t, actual)
+	}
+	if actual == unknownType {
+		return t
+	}
+	return actual
+}
+
+func (c *compiler) popVals(ts []ValueType) {
+	for i := len(ts) - 1; i >= 0; i-- {
+		c.popExpect(ts[i])
+	}
+}
+
+func (c *compiler) pushCtrl(op byte, params, results []ValueType) *ctrlFrame {
+	f := &ctrlFrame{
+		op:      op,
+		params:  params,
+		results: results,
+		height:  len(c.stack),
+		patch:   -1,
+	}
+	c.ctrls = append(c.ctrls, f)
+	c.pushVals(params)
+	return f
+}
+
+func (c *compiler) checkEnd(f *ctrlFrame) {
+	c.popVals(f.results)
+	if len(c.stack) != f.height {
+		c.r.fail("InvalidStackHeight(pos=%d,exp=%d,real=%d)",
+			c.r.pos, f.height, len(c.stack))
+	}
+}
+
+func (c *compiler) setUnreachable() {
+	f := c.ctrls[len(c.ctrls)-1]
+	c.stack = c.stack[:f.height]
+	f.unreachable = true
+}
+
+func (c *compiler) blockType() ([]ValueType, []ValueType) {
+	if c.r.eof() {
+		c.r.fail("UnexpectedEOF(pos=%d)", c.r.pos)
+		return nil, nil
+	}
+	switch b := c.r.b[c.r.pos]; ValueType(b) {
+	case 0x40:
+		c.r.pos++
+		return nil, nil
+	case I32, I64:
+		c.r.pos++
+		return nil, []ValueType{ValueType(b)}
+	}
+	idx := c.r.sleb(33)
+	if idx < 0 || idx >= int64(len(c.m.types)) {
+		c.r.fail("InvalidBlockType(pos=%d)", c.r.pos)
+		return nil, nil
+	}
+	ft := &c.m.types[idx]
+	return ft.Params, ft.Results
+}
+
+func (c *compiler) label() *ctrlFrame {
+	depth := c.r.u32()
+	if int64(depth) >= int64(len(c.ctrls)) {
+		c.r.fail("InvalidLabel(pos=%d,depth=%d)", c.r.pos, depth)
+		return c.ctrls[0]
+	}
+	return c.ctrls[len(c.ctrls)-1-int(depth)]
+}
+
+//branchTo adds the branch to the label, the target of non-loop label is
+//fixed on its end.
+func (c *compiler) branchTo(f *ctrlFrame) uint32 {
+	b := branch{
+		height: uint32(f.height),
+		arity:  uint32(len(f.labelTypes())),
+	}
+	idx := len(c.branches)
+	if f.op == opLoop {
+		b.pc = uint32(f.start)
+	} else {
+		f.fixups = append(f.fixups, idx)
+	}
+	c.branches = append(c.branches, b)
+	return uint32(idx)
+}
+
+func (c *compiler) needMemory() {
+	if c.m.memory == nil {
+		c.r.fail("NoMemory(pos=%d)", c.r.pos)
+	}
+}
+
+func (c *compiler) zeroByte() {
+	if b := c.r.byte(); b != 0 {
+		c.r.fail("InvalidReservedByte(pos=%d)", c.r.pos)
+	}
+}
+
+func sameTypes(a, b []ValueType) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	sigI32   = []ValueType{I32}
+	sigI32x2 = []ValueType{I32, I32}
+	sigI64   = []ValueType{I64}
+	sigI64x2 = []ValueType{I64, I64}
+)
+
+//numericType returns the signature of numeric instructions
+func numericType(op byte) ([]ValueType, ValueType, bool) {
+	switch {
+	case op == opI32Eqz, op >= opI32Clz && op <= opI32Popcnt,
+		op == opI32Extend8S, op == opI32Extend16S:
+		return sigI32, I32, true
+	case op >= opI32Eq && op <= opI32GeU, op >= opI32Add && op <= opI32Rotr:
+		return sigI32x2, I32, true
+	case op == opI64Eqz, op == opI32WrapI64:
+		return sigI64, I32, true
+	case op >= opI64Eq && op <= opI64GeU:
+		return sigI64x2, I32, true
+	case op >= opI64Clz && op <= opI64Popcnt,
+		op >= opI64Extend8S && op <= opI64Extend32S:
+		return sigI64, I64, true
+	case op >= opI64Add && op <= opI64Rotr:
+		return sigI64x2, I64, true
+	case op == opI64ExtendI32S, op == opI64ExtendI32U:
+		return sigI32, I64, true
+	default:
+		return nil, unknownType, false
+	}
+}
+
+//memoryAccess returns value type and access size of load and store
+func memoryAccess(op byte) (ValueType, uint32, bool) {
+	switch op {
+	case opI32Load, opI32Store:
+		return I32, 4, true
+	case opI64Load, opI64Store:
+		return I64, 8, true
+	case opI32Load8S, opI32Load8U, opI32Store8:
+		return I32, 1, true
+	case opI32Load16S, opI32Load16U, opI32Store16:
+		return I32, 2, true
+	case opI64Load8S, opI64Load8U, opI64Store8:
+		return I64, 1, true
+	case opI64Load16S, opI64Load16U, opI64Store16:
+		return I64, 2, true
+	case opI64Load32S, opI64Load32U, opI64Store32:
+		return I64, 4, true
+	default:
+		return unknownType, 0, false
+	}
+}
+
+func (c *compiler) compile() {
+	r := c.r
+	op := r.byte()
+	if r.err != nil {
+		return
+	}
+	switch op {
+	case opUnreachable:
+		c.emit(opUnreachable, 0, 0)
+		c.setUnreachable()
+	case opNop:
+	case opBlock, opLoop, opIf:
+		params, results := c.blockType()
+		if op == opIf {
+			c.popExpect(I32)
+		}
+		c.popVals(params)
+		f := c.pushCtrl(op, params, results)
+		switch op {
+		case opLoop:
+			f.start = len(c.code)
+		case opIf:
+			f.patch = c.emit(opIf, 0, 0)
+		}
+	case opElse:
+		f := c.ctrls[len(c.ctrls)-1]
+		if f.op != opIf {
+			r.fail("UnexpectedElse(pos=%d)", r.pos)
+			return
+		}
+		c.checkEnd(f)
+		idx := c.emit(opElse, 0, 0)
+		c.code[f.patch].a = uint32(len(c.code))
+		f.patch = idx
+		f.op = opElse
+		f.unreachable = false
+		c.pushVals(f.params)
+	case opEnd:
+		f := c.ctrls[len(c.ctrls)-1]
+		c.checkEnd(f)
+		if f.op == opIf && !sameTypes(f.params, f.results) {
+			r.fail("InvalidIfWithoutElse(pos=%d)", r.pos)
+		}
+		pc := uint32(len(c.code))
+		if f.patch >= 0 {
+			c.code[f.patch].a = pc
+		}
+		for _, idx := range f.fixups {
+			c.branches[idx].pc = pc
+		}
+		c.ctrls = c.ctrls[:len(c.ctrls)-1]
+		if len(c.ctrls) == 0 {
+			c.emit(opReturn, 0, 0)
+			return
+		}
+		c.pushVals(f.results)
+	case opBr:
+		f := c.label()
+		c.popVals(f.labelTypes())
+		c.emit(opBr, c.branchTo(f), 0)
+		c.setUnreachable()
+	case opBrIf:
+		f := c.label()
+		c.popExpect(I32)
+		ts := f.labelTypes()
+		c.popVals(ts)
+		c.pushVals(ts)
+		c.emit(opBrIf, c.branchTo(f), 0)
+	case opBrTable:
+		n := r.count(len(r.b))
+		labels := make([]*ctrlFrame, n+1)
+		for i := range labels {
+			labels[i] = c.label()
+		}
+		if r.err != nil {
+			return
+		}
+		c.popExpect(I32)
+		def := labels[n].labelTypes()
+		for _, f := range labels {
+			ts := f.labelTypes()
+			if len(ts) != len(def) {
+				r.fail("InvalidBrTableArity(pos=%d)", r.pos)
+				return
+			}
+			c.popVals(ts)
+			c.pushVals(ts)
+		}
+		start := uint32(len(c.branches))
+		for _, f := range labels {
+			c.branchTo(f)
+		}
+		c.popVals(def)
+		c.emit(opBrTable, start, uint64(len(labels)))
+		c.setUnreachable()
+	case opReturn:
+		c.popVals(c.ctrls[0].results)
+		c.emit(opReturn, 0, 0)
+		c.setUnreachable()
+	case opCall:
+		idx := r.u32()
+		if int64(idx) >= int64(c.m.numFuncs()) {
+			r.fail("InvalidFunctionIndex(idx=%d)", idx)
+			return
+		}
+		ft := c.m.funcType(idx)
+		c.popVals(ft.Params)
+		c.pushVals(ft.Results)
+		c.emit(opCall, idx, 0)
+	case opCallIndirect:
+		idx := r.u32()
+		c.zeroByte()
+		if int64(idx) >= int64(len(c.m.types)) || c.m.table == nil {
+			r.fail("InvalidCallIndirect(type=%d)", idx)
+			return
+		}
+		ft := &c.m.types[idx]
+		c.popExpect(I32)
+		c.popVals(ft.Params)
+		c.pushVals(ft.Results)
+		c.emit(opCallIndirect, idx, 0)
+	case opDrop:
+		c.pop()
+		c.emit(opDrop, 0, 0)
+	case opSelect, opSelectT:
+		var t ValueType
+		if op == opSelectT {
+			if n := r.count(1); n != 1 {
+				r.fail("InvalidSelectType(pos=%d)", r.pos)
+				return
+			}
+			t = r.valueType()
+		}
+		c.popExpect(I32)
+		t = c.popExpect(t)
+		t = c.popExpect(t)
+		c.push(t)
+		c.emit(opSelect, 0, 0)
+	case opLocalGet, opLocalSet, opLocalTee:
+		idx := r.u32()
+		if int64(idx) >= int64(len(c.locals)) {
+			r.fail("InvalidLocalIndex(idx=%d)", idx)
+			return
+		}
+		t := c.locals[idx]
+		switch op {
+		case opLocalGet:
+			c.push(t)
+		case opLocalSet:
+			c.popExpect(t)
+		case opLocalTee:
+			c.popExpect(t)
+			c.push(t)
+		}
+		c.emit(uint16(op), idx, 0)
+	case opGlobalGet, opGlobalSet:
+		idx := r.u32()
+		if int64(idx) >= int64(len(c.m.globals)) {
+			r.fail("InvalidGlobalIndex(idx=%d)", idx)
+			return
+		}
+		g := &c.m.globals[idx]
+		if op == opGlobalGet {
+			c.push(g.typ)
+		} else {
+			if !g.mutable {
+				r.fail("ImmutableGlobal(idx=%d)", idx)
+				return
+			}
+			c.popExpect(g.typ)
+		}
+		c.emit(uint16(op), idx, 0)
+	case opMemorySize:
+		c.zeroByte()
+		c.needMemory()
+		c.push(I32)
+		c.emit(opMemorySize, 0, 0)
+	case opMemoryGrow:
+		c.zeroByte()
+		c.needMemory()
+		c.popExpect(I32)
+		c.push(I32)
+		c.emit(opMemoryGrow, 0, 0)
+	case opI32Const:
+		v := r.sleb(32)
+		c.push(I32)
+		c.emit(opI32Const, 0, uint64(uint32(v)))
+	case opI64Const:
+		v := r.sleb(64)
+		c.push(I64)
+		c.emit(opI64Const, 0, uint64(v))
+	case opPrefixMisc:
+		switch sub := r.u32(); sub {
+		case 0x0a:
+			c.zeroByte()
+			c.zeroByte()
+			c.needMemory()
+			c.popVals(sigI32x2)
+			c.popExpect(I32)
+			c.emit(opMemoryCopy, 0, 0)
+		case 0x0b:
+			c.zeroByte()
+			c.needMemory()
+			c.popVals(sigI32x2)
+			c.popExpect(I32)
+			c.emit(opMemoryFill, 0, 0)
+		default:
+			r.fail("UnsupportedInstruction(op=0xfc %#x)", sub)
+		}
+	default:
+		if t, size, ok := memoryAccess(op); ok {
+			align := r.u32()
+			offset := r.u32()
+			if align >= 32 || uint32(1)<<align > size {
+				r.fail("InvalidAlignment(pos=%d)", r.pos)
+				return
+			}
+			c.needMemory()
+			if op >= opI32Store {
+				c.popExpect(t)
+				c.popExpect(I32)
+			} else {
+				c.popExpect(I32)
+				c.push(t)
+			}
+			c.emit(uint16(op), offset, 0)
+			return
+		}
+		if params, result, ok := numericType(op); ok {
+			c.popVals(params)
+			c.push(result)
+			c.emit(uint16(op), 0, 0)
+			return
+		}
+		r.fail("UnsupportedInstruction(op=%#x)", op)
+	}
+}
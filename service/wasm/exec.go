@@ -0,0 +1,654 @@
+package wasm
+
+import (
+	"encoding/binary"
+	"math"
+	"math/bits"
+	"sync/atomic"
+
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/service/scoreresult"
+)
+
+const (
+	MaxCallDepth  = 1024
+	MaxStackSize  = 1024 * 1024
+	abortInterval = 0x3ff
+)
+
+//HostFunc is a function provided by the host. The returned error stops the
+//execution, and it's returned by Instance.Call.
+type HostFunc struct {
+	Type FuncType
+	Call func(inst *Instance, args []uint64) ([]uint64, error)
+}
+
+//Imports is host functions by module and name
+type Imports map[string]map[string]*HostFunc
+
+//Instance is an instantiated module. It's not safe for concurrent use except
+//Abort.
+type Instance struct {
+	module   *Module
+	hosts    []*HostFunc
+	memory   []byte
+	maxPages uint32
+	table    []int64
+	globals  []uint64
+	stack    []uint64
+	sp       int
+	depth    int
+	steps    int64
+	limit    int64
+	aborted  int32
+}
+
+func trapf(format string, args ...interface{}) error {
+	return scoreresult.UnknownFailureError.Errorf(format, args...)
+}
+
+//NewInstance resolves imports and initializes memory, table and globals,
+//then it runs start function. Each instruction consumes one step and the
+//execution fails with OutOfStepError if it uses more than limit.
+func NewInstance(m *Module, imports Imports, limit int64) (*Instance, error) {
+	inst := &Instance{
+		module: m,
+		limit:  limit,
+	}
+	inst.hosts = make([]*HostFunc, len(m.imports))
+	for i, im := range m.imports {
+		h, ok := imports[im.module][im.name]
+		if !ok || h == nil {
+			return nil, errorf("UnknownImport(module=%s,name=%s)", im.module, im.name)
+		}
+		if !h.Type.Equal(&m.types[im.typ]) {
+			return nil, errorf("InvalidImportType(module=%s,name=%s)", im.module, im.name)
+		}
+		inst.hosts[i] = h
+	}
+	if m.memory != nil {
+		inst.memory = make([]byte, int(m.memory.min)*PageSize)
+		inst.maxPages = MaxMemoryPages
+		if m.memory.hasMax && m.memory.max < inst.maxPages {
+			inst.maxPages = m.memory.max
+		}
+	}
+	if m.table != nil {
+		inst.table = make([]int64, m.table.min)
+		for i := range inst.table {
+			inst.table[i] = -1
+		}
+	}
+	for _, e := range m.elements {
+		if uint64(e.offset)+uint64(len(e.funcs)) > uint64(len(inst.table)) {
+			return nil, errorf("ElementOutOfRange(offset=%d,size=%d)", e.offset, len(e.funcs))
+		}
+		for i, idx := range e.funcs {
+			inst.table[int(e.offset)+i] = int64(idx)
+		}
+	}
+	for _, d := range m.data {
+		if uint64(d.offset)+uint64(len(d.bytes)) > uint64(len(inst.memory)) {
+			return nil, errorf("DataOutOfRange(offset=%d,size=%d)", d.offset, len(d.bytes))
+		}
+		copy(inst.memory[d.offset:], d.bytes)
+	}
+	inst.globals = make([]uint64, len(m.globals))
+	for i, g := range m.globals {
+		inst.globals[i] = g.init
+	}
+	inst.stack = make([]uint64, 0, 1024)
+	if m.start >= 0 {
+		if err := inst.invoke(uint32(m.start)); err != nil {
+			return nil, err
+		}
+	}
+	return inst, nil
+}
+
+//Call invokes the exported function with the arguments
+func (inst *Instance) Call(name string, args ...uint64) ([]uint64, error) {
+	e, ok := inst.module.exports[name]
+	if !ok || e.kind != externFunc {
+		return nil, scoreresult.MethodNotFoundError.Errorf("FunctionNotFound(name=%s)", name)
+	}
+	ft := inst.module.funcType(e.index)
+	if len(args) != len(ft.Params) {
+		return nil, scoreresult.InvalidParameterError.Errorf(
+			"InvalidArguments(name=%s,exp=%d,real=%d)", name, len(ft.Params), len(args))
+	}
+	base := inst.sp
+	if err := inst.ensureStack(base + len(args)); err != nil {
+		return nil, err
+	}
+	copy(inst.stack[base:], args)
+	inst.sp = base + len(args)
+	if err := inst.invoke(e.index); err != nil {
+		inst.sp = base
+		return nil, err
+	}
+	results := make([]uint64, len(ft.Results))
+	copy(results, inst.stack[base:])
+	inst.sp = base
+	return results, nil
+}
+
+//UseSteps consumes steps for the host operations, negative steps are
+//refunded until it becomes zero.
+func (inst *Instance) UseSteps(n int64) error {
+	if n < 0 {
+		if inst.steps += n; inst.steps < 0 {
+			inst.steps = 0
+		}
+		return nil
+	}
+	if inst.steps+n > inst.limit || inst.steps+n < inst.steps {
+		inst.steps = inst.limit
+		return scoreresult.OutOfStepError.New("OutOfStep")
+	}
+	inst.steps += n
+	return nil
+}
+
+func (inst *Instance) StepUsed() int64 {
+	return inst.steps
+}
+
+func (inst *Instance) StepLimit() int64 {
+	return inst.limit
+}
+
+//Abort makes the execution fail, it can be called in another goroutine.
+func (inst *Instance) Abort() {
+	atomic.StoreInt32(&inst.aborted, 1)
+}
+
+func (inst *Instance) checkAbort() error {
+	if atomic.LoadInt32(&inst.aborted) != 0 {
+		return errors.ExecutionFailError.New("Aborted")
+	}
+	return nil
+}
+
+//ReadBytes returns copy of the memory
+func (inst *Instance) ReadBytes(ptr, size uint32) ([]byte, error) {
+	if uint64(ptr)+uint64(size) > uint64(len(inst.memory)) {
+		return nil, trapf("MemoryOutOfBounds(ptr=%d,size=%d)", ptr, size)
+	}
+	bs := make([]byte, size)
+	copy(bs, inst.memory[ptr:])
+	return bs, nil
+}
+
+func (inst *Instance) WriteBytes(ptr uint32, bs []byte) error {
+	if uint64(ptr)+uint64(len(bs)) > uint64(len(inst.memory)) {
+		return trapf("MemoryOutOfBounds(ptr=%d,size=%d)", ptr, len(bs))
+	}
+	copy(inst.memory[ptr:], bs)
+	return nil
+}
+
+func (inst *Instance) ensureStack(size int) error {
+	if size > MaxStackSize {
+		return scoreresult.StackOverflowError.Errorf("StackOverflow(size=%d)", size)
+	}
+	if size > len(inst.stack) {
+		if size > cap(inst.stack) {
+			c := cap(inst.stack)*2 + 1
+			for c < size {
+				c *= 2
+			}
+			if c > MaxStackSize {
+				c = MaxStackSize
+			}
+			stack := make([]uint64, size, c)
+			copy(stack, inst.stack)
+			inst.stack = stack
+		} else {
+			inst.stack = inst.stack[:size]
+		}
+	}
+	return nil
+}
+
+func (inst *Instance) invoke(idx uint32) error {
+	if int(idx) < len(inst.hosts) {
+		h := inst.hosts[idx]
+		base := inst.sp - len(h.Type.Params)
+		args := make([]uint64, len(h.Type.Params))
+		copy(args, inst.stack[base:inst.sp])
+		results, err := h.Call(inst, args)
+		if err != nil {
+			return err
+		}
+		if len(results) != len(h.Type.Results) {
+			return errors.InvalidStateError.Errorf(
+				"InvalidHostResults(exp=%d,real=%d)", len(h.Type.Results), len(results))
+		}
+		if err := inst.ensureStack(base + len(results)); err != nil {
+			return err
+		}
+		copy(inst.stack[base:], results)
+		inst.sp = base + len(results)
+		return nil
+	}
+	f := inst.module.funcs[int(idx)-len(inst.hosts)]
+	if inst.depth >= MaxCallDepth {
+		return scoreresult.StackOverflowError.Errorf("CallDepthExceeded(depth=%d)", inst.depth)
+	}
+	base := inst.sp - len(inst.module.types[f.typ].Params)
+	if err := inst.ensureStack(base + f.numLocals + f.maxStack); err != nil {
+		return err
+	}
+	for i := inst.sp; i < base+f.numLocals; i++ {
+		inst.stack[i] = 0
+	}
+	inst.depth++
+	err := inst.execute(f, base)
+	inst.depth--
+	return err
+}
+
+func b2u(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (inst *Instance) address(v uint64, offset uint32, size uint64) (uint64, error) {
+	addr := uint64(uint32(v)) + uint64(offset)
+	if addr+size > uint64(len(inst.memory)) {
+		return 0, trapf("MemoryOutOfBounds(addr=%d,size=%d)", addr, size)
+	}
+	return addr, nil
+}
+
+func (inst *Instance) execute(f *function, base int) error {
+	code := f.code
+	results := len(inst.module.types[f.typ].Results)
+	opBase := base + f.numLocals
+	sp := opBase
+	pc := 0
+	for {
+		if inst.steps >= inst.limit {
+			return scoreresult.OutOfStepError.New("OutOfStep")
+		}
+		inst.steps++
+		if inst.steps&abortInterval == 0 {
+			if err := inst.checkAbort(); err != nil {
+				return err
+			}
+		}
+		// stack may be reallocated by calls
+		stack := inst.stack
+		in := &code[pc]
+		pc++
+		switch in.op {
+		case opUnreachable:
+			return trapf("Unreachable")
+		case opBr, opBrIf, opBrTable:
+			var b *branch
+			switch in.op {
+			case opBr:
+				b = &f.branches[in.a]
+			case opBrIf:
+				sp--
+				if uint32(stack[sp]) == 0 {
+					continue
+				}
+				b = &f.branches[in.a]
+			case opBrTable:
+				sp--
+				i := uint64(uint32(stack[sp]))
+				if i >= in.b {
+					i = in.b - 1
+				}
+				b = &f.branches[uint64(in.a)+i]
+			}
+			to := opBase + int(b.height)
+			copy(stack[to:], stack[sp-int(b.arity):sp])
+			sp = to + int(b.arity)
+			pc = int(b.pc)
+		case opIf:
+			sp--
+			if uint32(stack[sp]) == 0 {
+				pc = int(in.a)
+			}
+		case opElse:
+			pc = int(in.a)
+		case opReturn:
+			copy(stack[base:], stack[sp-results:sp])
+			inst.sp = base + results
+			return nil
+		case opCall, opCallIndirect:
+			idx := in.a
+			if in.op == opCallIndirect {
+				sp--
+				i := uint32(stack[sp])
+				if int64(i) >= int64(len(inst.table)) {
+					return trapf("UndefinedElement(idx=%d)", i)
+				}
+				fi := inst.table[i]
+				if fi < 0 {
+					return trapf("UninitializedElement(idx=%d)", i)
+				}
+				if !inst.module.funcType(uint32(fi)).Equal(&inst.module.types[in.a]) {
+					return trapf("IndirectCallTypeMismatch(idx=%d)", i)
+				}
+				idx = uint32(fi)
+			}
+			inst.sp = sp
+			if err := inst.invoke(idx); err != nil {
+				return err
+			}
+			sp = inst.sp
+		case opDrop:
+			sp--
+		case opSelect:
+			sp -= 2
+			if uint32(stack[sp+1]) == 0 {
+				stack[sp-1] = stack[sp]
+			}
+		case opLocalGet:
+			stack[sp] = stack[base+int(in.a)]
+			sp++
+		case opLocalSet:
+			sp--
+			stack[base+int(in.a)] = stack[sp]
+		case opLocalTee:
+			stack[base+int(in.a)] = stack[sp-1]
+		case opGlobalGet:
+			stack[sp] = inst.globals[in.a]
+			sp++
+		case opGlobalSet:
+			sp--
+			inst.globals[in.a] = stack[sp]
+
+		case opI32Load, opI64Load, opI32Load8S, opI32Load8U, opI32Load16S,
+			opI32Load16U, opI64Load8S, opI64Load8U, opI64Load16S,
+			opI64Load16U, opI64Load32S, opI64Load32U:
+			_, size, _ := memoryAccess(byte(in.op))
+			addr, err := inst.address(stack[sp-1], in.a, uint64(size))
+			if err != nil {
+				return err
+			}
+			mem := inst.memory[addr:]
+			var v uint64
+			switch in.op {
+			case opI32Load, opI64Load32U:
+				v = uint64(binary.LittleEndian.Uint32(mem))
+			case opI64Load:
+				v = binary.LittleEndian.Uint64(mem)
+			case opI32Load8S:
+				v = uint64(uint32(int32(int8(mem[0]))))
+			case opI32Load8U, opI64Load8U:
+				v = uint64(mem[0])
+			case opI32Load16S:
+				v = uint64(uint32(int32(int16(binary.LittleEndian.Uint16(mem)))))
+			case opI32Load16U, opI64Load16U:
+				v = uint64(binary.LittleEndian.Uint16(mem))
+			case opI64Load8S:
+				v = uint64(int64(int8(mem[0])))
+			case opI64Load16S:
+				v = uint64(int64(int16(binary.LittleEndian.Uint16(mem))))
+			case opI64Load32S:
+				v = uint64(int64(int32(binary.LittleEndian.Uint32(mem))))
+			}
+			stack[sp-1] = v
+		case opI32Store, opI64Store, opI32Store8, opI32Store16,
+			opI64Store8, opI64Store16, opI64Store32:
+			_, size, _ := memoryAccess(byte(in.op))
+			sp -= 2
+			addr, err := inst.address(stack[sp], in.a, uint64(size))
+			if err != nil {
+				return err
+			}
+			mem := inst.memory[addr:]
+			v := stack[sp+1]
+			switch size {
+			case 1:
+				mem[0] = byte(v)
+			case 2:
+				binary.LittleEndian.PutUint16(mem, uint16(v))
+			case 4:
+				binary.LittleEndian.PutUint32(mem, uint32(v))
+			case 8:
+				binary.LittleEndian.PutUint64(mem, v)
+			}
+		case opMemorySize:
+			stack[sp] = uint64(len(inst.memory) / PageSize)
+			sp++
+		case opMemoryGrow:
+			pages := uint64(len(inst.memory) / PageSize)
+			n := uint64(uint32(stack[sp-1]))
+			if pages+n > uint64(inst.maxPages) {
+				stack[sp-1] = uint64(math.MaxUint32)
+			} else {
+				if n > 0 {
+					mem := make([]byte, int(pages+n)*PageSize)
+					copy(mem, inst.memory)
+					inst.memory = mem
+				}
+				stack[sp-1] = pages
+			}
+		case opMemoryCopy, opMemoryFill:
+			sp -= 3
+			dst := uint64(uint32(stack[sp]))
+			v := stack[sp+1]
+			n := uint64(uint32(stack[sp+2]))
+			if err := inst.UseSteps(int64(n / 8)); err != nil {
+				return err
+			}
+			if dst+n > uint64(len(inst.memory)) {
+				return trapf("MemoryOutOfBounds(addr=%d,size=%d)", dst, n)
+			}
+			if in.op == opMemoryCopy {
+				src := uint64(uint32(v))
+				if src+n > uint64(len(inst.memory)) {
+					return trapf("MemoryOutOfBounds(addr=%d,size=%d)", src, n)
+				}
+				copy(inst.memory[dst:dst+n], inst.memory[src:src+n])
+			} else {
+				mem := inst.memory[dst : dst+n]
+				for i := range mem {
+					mem[i] = byte(v)
+				}
+			}
+		case opI32Const, opI64Const:
+			stack[sp] = in.b
+			sp++
+
+		case opI32Eqz:
+			stack[sp-1] = b2u(uint32(stack[sp-1]) == 0)
+		case opI64Eqz:
+			stack[sp-1] = b2u(stack[sp-1] == 0)
+		case opI32Clz:
+			stack[sp-1] = uint64(bits.LeadingZeros32(uint32(stack[sp-1])))
+		case opI32Ctz:
+			stack[sp-1] = uint64(bits.TrailingZeros32(uint32(stack[sp-1])))
+		case opI32Popcnt:
+			stack[sp-1] = uint64(bits.OnesCount32(uint32(stack[sp-1])))
+		case opI64Clz:
+			stack[sp-1] = uint64(bits.LeadingZeros64(stack[sp-1]))
+		case opI64Ctz:
+			stack[sp-1] = uint64(bits.TrailingZeros64(stack[sp-1]))
+		case opI64Popcnt:
+			stack[sp-1] = uint64(bits.OnesCount64(stack[sp-1]))
+		case opI32WrapI64:
+			stack[sp-1] = uint64(uint32(stack[sp-1]))
+		case opI64ExtendI32S:
+			stack[sp-1] = uint64(int64(int32(stack[sp-1])))
+		case opI64ExtendI32U:
+			stack[sp-1] = uint64(uint32(stack[sp-1]))
+		case opI32Extend8S:
+			stack[sp-1] = uint64(uint32(int32(int8(stack[sp-1]))))
+		case opI32Extend16S:
+			stack[sp-1] = uint64(uint32(int32(int16(stack[sp-1]))))
+		case opI64Extend8S:
+			stack[sp-1] = uint64(int64(int8(stack[sp-1])))
+		case opI64Extend16S:
+			stack[sp-1] = uint64(int64(int16(stack[sp-1])))
+		case opI64Extend32S:
+			stack[sp-1] = uint64(int64(int32(stack[sp-1])))
+
+		default:
+			sp--
+			var err error
+			if in.op < opI64Eqz || (in.op >= opI32Add && in.op <= opI32Rotr) {
+				stack[sp-1], err = i32Binary(in.op, uint32(stack[sp-1]), uint32(stack[sp]))
+			} else {
+				stack[sp-1], err = i64Binary(in.op, stack[sp-1], stack[sp])
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func i32Binary(op uint16, x, y uint32) (uint64, error) {
+	switch op {
+	case opI32Eq:
+		return b2u(x == y), nil
+	case opI32Ne:
+		return b2u(x != y), nil
+	case opI32LtS:
+		return b2u(int32(x) < int32(y)), nil
+	case opI32LtU:
+		return b2u(x < y), nil
+	case opI32GtS:
+		return b2u(int32(x) > int32(y)), nil
+	case opI32GtU:
+		return b2u(x > y), nil
+	case opI32LeS:
+		return b2u(int32(x) <= int32(y)), nil
+	case opI32LeU:
+		return b2u(x <= y), nil
+	case opI32GeS:
+		return b2u(int32(x) >= int32(y)), nil
+	case opI32GeU:
+		return b2u(x >= y), nil
+	case opI32Add:
+		return uint64(x + y), nil
+	case opI32Sub:
+		return uint64(x - y), nil
+	case opI32Mul:
+		return uint64(x * y), nil
+	case opI32DivS, opI32RemS:
+		if y == 0 {
+			return 0, trapf("IntegerDivideByZero")
+		}
+		if op == opI32RemS {
+			if int32(y) == -1 {
+				return 0, nil
+			}
+			return uint64(uint32(int32(x) % int32(y))), nil
+		}
+		if int32(x) == math.MinInt32 && int32(y) == -1 {
+			return 0, trapf("IntegerOverflow")
+		}
+		return uint64(uint32(int32(x) / int32(y))), nil
+	case opI32DivU:
+		if y == 0 {
+			return 0, trapf("IntegerDivideByZero")
+		}
+		return uint64(x / y), nil
+	case opI32RemU:
+		if y == 0 {
+			return 0, trapf("IntegerDivideByZero")
+		}
+		return uint64(x % y), nil
+	case opI32And:
+		return uint64(x & y), nil
+	case opI32Or:
+		return uint64(x | y), nil
+	case opI32Xor:
+		return uint64(x ^ y), nil
+	case opI32Shl:
+		return uint64(x << (y & 31)), nil
+	case opI32ShrS:
+		return uint64(uint32(int32(x) >> (y & 31))), nil
+	case opI32ShrU:
+		return uint64(x >> (y & 31)), nil
+	case opI32Rotl:
+		return uint64(bits.RotateLeft32(x, int(y&31))), nil
+	case opI32Rotr:
+		return uint64(bits.RotateLeft32(x, -int(y&31))), nil
+	default:
+		return 0, errors.InvalidStateError.Errorf("UnknownInstruction(op=%#x)", op)
+	}
+}
+
+func i64Binary(op uint16, x, y uint64) (uint64, error) {
+	switch op {
+	case opI64Eq:
+		return b2u(x == y), nil
+	case opI64Ne:
+		return b2u(x != y), nil
+	case opI64LtS:
+		return b2u(int64(x) < int64(y)), nil
+	case opI64LtU:
+		return b2u(x < y), nil
+	case opI64GtS:
+		return b2u(int64(x) > int64(y)), nil
+	case opI64GtU:
+		return b2u(x > y), nil
+	case opI64LeS:
+		return b2u(int64(x) <= int64(y)), nil
+	case opI64LeU:
+		return b2u(x <= y), nil
+	case opI64GeS:
+		return b2u(int64(x) >= int64(y)), nil
+	case opI64GeU:
+		return b2u(x >= y), nil
+	case opI64Add:
+		return x + y, nil
+	case opI64Sub:
+		return x - y, nil
+	case opI64Mul:
+		return x * y, nil
+	case opI64DivS, opI64RemS:
+		if y == 0 {
+			return 0, trapf("IntegerDivideByZero")
+		}
+		if op == opI64RemS {
+			if int64(y) == -1 {
+				return 0, nil
+			}
+			return uint64(int64(x) % int64(y)), nil
+		}
+		if int64(x) == math.MinInt64 && int64(y) == -1 {
+			return 0, trapf("IntegerOverflow")
+		}
+		return uint64(int64(x) / int64(y)), nil
+	case opI64DivU:
+		if y == 0 {
+			return 0, trapf("IntegerDivideByZero")
+		}
+		return x / y, nil
+	case opI64RemU:
+		if y == 0 {
+			return 0, trapf("IntegerDivideByZero")
+		}
+		return x % y, nil
+	case opI64And:
+		return x & y, nil
+	case opI64Or:
+		return x | y, nil
+	case opI64Xor:
+		return x ^ y, nil
+	case opI64Shl:
+		return x << (y & 63), nil
+	case opI64ShrS:
+		return uint64(int64(x) >> (y & 63)), nil
+	case opI64ShrU:
+		return x >> (y & 63), nil
+	case opI64Rotl:
+		return bits.RotateLeft64(x, int(y&63)), nil
+	case opI64Rotr:
+		return bits.RotateLeft64(x, -int(y&63)), nil
+	default:
+		return 0, errors.InvalidStateError.Errorf("UnknownInstruction(op=%#x)", op)
+	}
+}
@@ -0,0 +1,549 @@
+package wasm
+
+import (
+	"encoding/binary"
+	"unicode/utf8"
+
+	"github.com/icon-project/goloop/service/scoreresult"
+)
+
+//ValueType is a type of the value, only integer types are supported for
+//deterministic execution.
+type ValueType byte
+
+const (
+	I32 ValueType = 0x7f
+	I64 ValueType = 0x7e
+)
+
+func (t ValueType) String() string {
+	switch t {
+	case I32:
+		return "i32"
+	case I64:
+		return "i64"
+	default:
+		return "unknown"
+	}
+}
+
+type FuncType struct {
+	Params  []ValueType
+	Results []ValueType
+}
+
+func (t *FuncType) Equal(t2 *FuncType) bool {
+	if len(t.Params) != len(t2.Params) || len(t.Results) != len(t2.Results) {
+		return false
+	}
+	for i, p := range t.Params {
+		if t2.Params[i] != p {
+			return false
+		}
+	}
+	for i, r := range t.Results {
+		if t2.Results[i] != r {
+			return false
+		}
+	}
+	return true
+}
+
+const (
+	magic   = 0x6d736100
+	version = 1
+)
+
+const (
+	secCustom byte = iota
+	secType
+	secImport
+	secFunction
+	secTable
+	secMemory
+	secGlobal
+	secExport
+	secStart
+	secElement
+	secCode
+	secData
+	secDataCount
+)
+
+const (
+	externFunc byte = iota
+	externTable
+	externMemory
+	externGlobal
+)
+
+const (
+	PageSize          = 64 * 1024
+	MaxMemoryPages    = 256
+	MaxTableSize      = 64 * 1024
+	MaxFunctionLocals = 50000
+)
+
+type limits struct {
+	min    uint32
+	max    uint32
+	hasMax bool
+}
+
+type importFunc struct {
+	module string
+	name   string
+	typ    uint32
+}
+
+type global struct {
+	typ     ValueType
+	mutable bool
+	init    uint64
+}
+
+type export struct {
+	kind  byte
+	index uint32
+}
+
+type element struct {
+	offset uint32
+	funcs  []uint32
+}
+
+type dataSegment struct {
+	offset uint32
+	bytes  []byte
+}
+
+//Module is a decoded and compiled WebAssembly module, it's immutable so it
+//can be shared by instances.
+type Module struct {
+	types    []FuncType
+	imports  []importFunc
+	typeOf   []uint32
+	funcs    []*function
+	table    *limits
+	memory   *limits
+	globals  []global
+	exports  map[string]export
+	start    int64
+	elements []element
+	data     []dataSegment
+	customs  map[string][]byte
+}
+
+func errorf(format string, args ...interface{}) error {
+	return scoreresult.IllegalFormatError.Errorf(format, args...)
+}
+
+type reader struct {
+	b   []byte
+	pos int
+	err error
+}
+
+func (r *reader) fail(format string, args ...interface{}) {
+	if r.err == nil {
+		r.err = errorf(format, args...)
+	}
+	r.pos = len(r.b)
+}
+
+func (r *reader) eof() bool {
+	return r.pos >= len(r.b)
+}
+
+func (r *reader) byte() byte {
+	if r.pos >= len(r.b) {
+		r.fail("UnexpectedEOF(pos=%d)", r.pos)
+		return 0
+	}
+	b := r.b[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *reader) bytes(n int) []byte {
+	if n < 0 || r.pos+n > len(r.b) {
+		r.fail("UnexpectedEOF(pos=%d,size=%d)", r.pos, n)
+		return nil
+	}
+	b := r.b[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *reader) uleb(bits uint) uint64 {
+	var v uint64
+	for shift := uint(0); ; shift += 7 {
+		b := r.byte()
+		if r.err != nil {
+			return 0
+		}
+		if shift >= bits || (shift+7 > bits && uint64(b&0x7f)>>(bits-shift) != 0) {
+			r.fail("InvalidLEB128(pos=%d)", r.pos)
+			return 0
+		}
+		v |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return v
+		}
+	}
+}
+
+func (r *reader) sleb(bits uint) int64 {
+	var v int64
+	var shift uint
+	var b byte
+	for {
+		b = r.byte()
+		if r.err != nil {
+			return 0
+		}
+		if shift >= bits {
+			r.fail("InvalidLEB128(pos=%d)", r.pos)
+			return 0
+		}
+		v |= int64(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	if shift < 64 && b&0x40 != 0 {
+		v |= -1 << shift
+	}
+	if bits < 64 && (v < -(1<<(bits-1)) || v >= 1<<(bits-1)) {
+		r.fail("InvalidLEB128(pos=%d)", r.pos)
+		return 0
+	}
+	return v
+}
+
+func (r *reader) u32() uint32 {
+	return uint32(r.uleb(32))
+}
+
+func (r *reader) count(max int) int {
+	n := r.u32()
+	if int64(n) > int64(max) || int(n) > len(r.b)-r.pos {
+		r.fail("TooManyItems(pos=%d,n=%d)", r.pos, n)
+		return 0
+	}
+	return int(n)
+}
+
+func (r *reader) name() string {
+	n := r.count(len(r.b))
+	b := r.bytes(n)
+	if !utf8.Valid(b) {
+		r.fail("InvalidName(pos=%d)", r.pos)
+	}
+	return string(b)
+}
+
+func (r *reader) valueType() ValueType {
+	t := ValueType(r.byte())
+	if t != I32 && t != I64 {
+		r.fail("UnsupportedValueType(type=%#x)", byte(t))
+	}
+	return t
+}
+
+func (r *reader) limits(max uint32) *limits {
+	l := &limits{}
+	switch flag := r.byte(); flag {
+	case 0:
+		l.min = r.u32()
+	case 1:
+		l.min = r.u32()
+		l.max = r.u32()
+		l.hasMax = true
+		if l.max < l.min {
+			r.fail("InvalidLimits(min=%d,max=%d)", l.min, l.max)
+		}
+	default:
+		r.fail("InvalidLimits(flag=%d)", flag)
+	}
+	if l.min > max {
+		r.fail("LimitsTooLarge(min=%d)", l.min)
+	}
+	return l
+}
+
+//constExpr evaluates constant expression of integer
+func (r *reader) constExpr(t ValueType) uint64 {
+	var v uint64
+	switch op := r.byte(); {
+	case op == opI32Const && t == I32:
+		v = uint64(uint32(r.sleb(32)))
+	case op == opI64Const && t == I64:
+		v = uint64(r.sleb(64))
+	default:
+		r.fail("UnsupportedConstExpr(op=%#x)", op)
+	}
+	if r.byte() != opEnd {
+		r.fail("InvalidConstExpr(pos=%d)", r.pos)
+	}
+	return v
+}
+
+//Compile decodes the binary and compiles function bodies. It accepts modules
+//using integer instructions only.
+func Compile(b []byte) (*Module, error) {
+	if len(b) < 8 || binary.LittleEndian.Uint32(b) != magic {
+		return nil, errorf("InvalidMagic")
+	}
+	if v := binary.LittleEndian.Uint32(b[4:]); v != version {
+		return nil, errorf("UnsupportedVersion(version=%d)", v)
+	}
+	m := &Module{
+		exports: make(map[string]export),
+		customs: make(map[string][]byte),
+		start:   -1,
+	}
+	r := &reader{b: b, pos: 8}
+	var last byte
+	for !r.eof() && r.err == nil {
+		id := r.byte()
+		size := r.count(len(b))
+		sr := &reader{b: r.bytes(size)}
+		if r.err != nil {
+			break
+		}
+		if id != secCustom {
+			if id > secDataCount || (id <= last && !(last == secDataCount && id > secElement)) ||
+				(id == secDataCount && last > secElement) {
+				return nil, errorf("InvalidSection(id=%d,prev=%d)", id, last)
+			}
+			last = id
+		}
+		switch id {
+		case secCustom:
+			name := sr.name()
+			if sr.err == nil {
+				m.customs[name] = sr.b[sr.pos:]
+				sr.pos = len(sr.b)
+			}
+		case secType:
+			n := sr.count(len(sr.b))
+			m.types = make([]FuncType, n)
+			for i := 0; i < n; i++ {
+				if f := sr.byte(); f != 0x60 {
+					sr.fail("InvalidFuncType(form=%#x)", f)
+				}
+				ft := &m.types[i]
+				ft.Params = make([]ValueType, sr.count(len(sr.b)))
+				for j := range ft.Params {
+					ft.Params[j] = sr.valueType()
+				}
+				ft.Results = make([]ValueType, sr.count(len(sr.b)))
+				for j := range ft.Results {
+					ft.Results[j] = sr.valueType()
+				}
+			}
+		case secImport:
+			n := sr.count(len(sr.b))
+			for i := 0; i < n; i++ {
+				im := importFunc{module: sr.name(), name: sr.name()}
+				if kind := sr.byte(); kind != externFunc {
+					sr.fail("UnsupportedImport(module=%s,name=%s,kind=%d)", im.module, im.name, kind)
+				}
+				im.typ = sr.u32()
+				if int(im.typ) >= len(m.types) {
+					sr.fail("InvalidTypeIndex(idx=%d)", im.typ)
+				}
+				m.imports = append(m.imports, im)
+			}
+		case secFunction:
+			n := sr.count(len(sr.b))
+			m.typeOf = make([]uint32, n)
+			for i := range m.typeOf {
+				m.typeOf[i] = sr.u32()
+				if int(m.typeOf[i]) >= len(m.types) {
+					sr.fail("InvalidTypeIndex(idx=%d)", m.typeOf[i])
+				}
+			}
+		case secTable:
+			if n := sr.count(1); n == 1 {
+				if et := sr.byte(); et != 0x70 {
+					sr.fail("UnsupportedTableType(type=%#x)", et)
+				}
+				m.table = sr.limits(MaxTableSize)
+			}
+		case secMemory:
+			if n := sr.count(1); n == 1 {
+				m.memory = sr.limits(MaxMemoryPages)
+			}
+		case secGlobal:
+			n := sr.count(len(sr.b))
+			m.globals = make([]global, n)
+			for i := range m.globals {
+				g := &m.globals[i]
+				g.typ = sr.valueType()
+				switch mut := sr.byte(); mut {
+				case 0:
+				case 1:
+					g.mutable = true
+				default:
+					sr.fail("InvalidMutability(mut=%d)", mut)
+				}
+				g.init = sr.constExpr(g.typ)
+			}
+		case secExport:
+			n := sr.count(len(sr.b))
+			for i := 0; i < n; i++ {
+				name := sr.name()
+				e := export{kind: sr.byte(), index: sr.u32()}
+				if _, ok := m.exports[name]; ok {
+					sr.fail("DuplicateExport(name=%s)", name)
+				}
+				m.exports[name] = e
+			}
+		case secStart:
+			m.start = int64(sr.u32())
+		case secElement:
+			n := sr.count(len(sr.b))
+			for i := 0; i < n; i++ {
+				if flag := sr.u32(); flag != 0 {
+					sr.fail("UnsupportedElement(flag=%d)", flag)
+				}
+				e := element{offset: uint32(sr.constExpr(I32))}
+				e.funcs = make([]uint32, sr.count(len(sr.b)))
+				for j := range e.funcs {
+					e.funcs[j] = sr.u32()
+				}
+				m.elements = append(m.elements, e)
+			}
+		case secCode:
+			n := sr.count(len(sr.b))
+			if n != len(m.typeOf) {
+				return nil, errorf("FunctionCountMismatch(funcs=%d,codes=%d)", len(m.typeOf), n)
+			}
+			m.funcs = make([]*function, n)
+			for i := 0; i < n; i++ {
+				body := sr.bytes(sr.count(len(sr.b)))
+				if sr.err != nil {
+					break
+				}
+				f, err := m.compileFunction(m.typeOf[i], body)
+				if err != nil {
+					return nil, err
+				}
+				m.funcs[i] = f
+			}
+		case secData:
+			n := sr.count(len(sr.b))
+			for i := 0; i < n; i++ {
+				if flag := sr.u32(); flag != 0 {
+					sr.fail("UnsupportedData(flag=%d)", flag)
+				}
+				d := dataSegment{offset: uint32(sr.constExpr(I32))}
+				d.bytes = sr.bytes(sr.count(len(sr.b)))
+				m.data = append(m.data, d)
+			}
+		case secDataCount:
+			sr.u32()
+		}
+		if sr.err != nil {
+			return nil, sr.err
+		}
+		if !sr.eof() {
+			return nil, errorf("InvalidSectionSize(id=%d)", id)
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if len(m.typeOf) != len(m.funcs) {
+		return nil, errorf("NoCodeSection")
+	}
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *Module) numFuncs() int {
+	return len(m.imports) + len(m.funcs)
+}
+
+func (m *Module) funcType(idx uint32) *FuncType {
+	if int(idx) < len(m.imports) {
+		return &m.types[m.imports[idx].typ]
+	}
+	return &m.types[m.typeOf[int(idx)-len(m.imports)]]
+}
+
+func (m *Module) validate() error {
+	for name, e := range m.exports {
+		var n int
+		switch e.kind {
+		case externFunc:
+			n = m.numFuncs()
+		case externTable:
+			if m.table != nil {
+				n = 1
+			}
+		case externMemory:
+			if m.memory != nil {
+				n = 1
+			}
+		case externGlobal:
+			n = len(m.globals)
+		default:
+			return errorf("InvalidExportKind(name=%s,kind=%d)", name, e.kind)
+		}
+		if int(e.index) >= n {
+			return errorf("InvalidExportIndex(name=%s,idx=%d)", name, e.index)
+		}
+	}
+	if m.start >= 0 {
+		if m.start >= int64(m.numFuncs()) {
+			return errorf("InvalidStartFunction(idx=%d)", m.start)
+		}
+		if ft := m.funcType(uint32(m.start)); len(ft.Params) != 0 || len(ft.Results) != 0 {
+			return errorf("InvalidStartFunctionType")
+		}
+	}
+	if len(m.elements) > 0 && m.table == nil {
+		return errorf("NoTableForElement")
+	}
+	for _, e := range m.elements {
+		for _, idx := range e.funcs {
+			if int(idx) >= m.numFuncs() {
+				return errorf("InvalidElementFunction(idx=%d)", idx)
+			}
+		}
+	}
+	if len(m.data) > 0 && m.memory == nil {
+		return errorf("NoMemoryForData")
+	}
+	return nil
+}
+
+//CustomSection returns the content of the custom section
+func (m *Module) CustomSection(name string) ([]byte, bool) {
+	b, ok := m.customs[name]
+	return b, ok
+}
+
+//ExportedFunction returns the type of the exported function
+func (m *Module) ExportedFunction(name string) (*FuncType, bool) {
+	e, ok := m.exports[name]
+	if !ok || e.kind != externFunc {
+		return nil, false
+	}
+	return m.funcType(e.index), true
+}
+
+//Imports returns names of imported functions in "module.name" form
+func (m *Module) Imports() []string {
+	names := make([]string, len(m.imports))
+	for i, im := range m.imports {
+		names[i] = im.module + "." + im.name
+	}
+	return names
+}
@@ -0,0 +1,275 @@
+package wasm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/service/scoreresult"
+)
+
+func uleb(v uint64) []byte {
+	var bs []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			bs = append(bs, b|0x80)
+		} else {
+			return append(bs, b)
+		}
+	}
+}
+
+func concat(items ...[]byte) []byte {
+	var bs []byte
+	for _, item := range items {
+		bs = append(bs, item...)
+	}
+	return bs
+}
+
+func vec(items ...[]byte) []byte {
+	return concat(uleb(uint64(len(items))), concat(items...))
+}
+
+func name(s string) []byte {
+	return concat(uleb(uint64(len(s))), []byte(s))
+}
+
+func section(id byte, content []byte) []byte {
+	return concat([]byte{id}, uleb(uint64(len(content))), content)
+}
+
+func funcType(params, results []byte) []byte {
+	return concat([]byte{0x60}, uleb(uint64(len(params))), params,
+		uleb(uint64(len(results))), results)
+}
+
+func body(locals []byte, code ...byte) []byte {
+	b := concat(locals, code)
+	return concat(uleb(uint64(len(b))), b)
+}
+
+func exportFunc(s string, idx byte) []byte {
+	return concat(name(s), []byte{0, idx})
+}
+
+func module(sections ...[]byte) []byte {
+	return concat([]byte{0, 'a', 's', 'm', 1, 0, 0, 0}, concat(sections...))
+}
+
+//testModule has functions without imports
+//  0: fact(i64) i64
+//  1: sel(i32) i32 with br_table
+//  2: abs(i32) i32 with if/else
+//  3: div(i32, i32) i32
+//  4: spin() with infinite loop
+//  5: recurse() calls itself
+//  6: load(i32) i32 loads a byte
+var testModule = module(
+	section(secType, vec(
+		funcType([]byte{0x7e}, []byte{0x7e}),
+		funcType([]byte{0x7f}, []byte{0x7f}),
+		funcType([]byte{0x7f, 0x7f}, []byte{0x7f}),
+		funcType(nil, nil),
+	)),
+	section(secFunction, vec([]byte{0}, []byte{1}, []byte{1}, []byte{2},
+		[]byte{3}, []byte{3}, []byte{1})),
+	section(secMemory, vec([]byte{0, 1})),
+	section(secExport, vec(
+		exportFunc("fact", 0),
+		exportFunc("sel", 1),
+		exportFunc("abs", 2),
+		exportFunc("div", 3),
+		exportFunc("spin", 4),
+		exportFunc("recurse", 5),
+		exportFunc("load", 6),
+	)),
+	section(secCode, vec(
+		body(vec([]byte{1, 0x7e}),
+			0x42, 0x01, 0x21, 0x01,
+			0x02, 0x40, 0x03, 0x40,
+			0x20, 0x00, 0x50, 0x0d, 0x01,
+			0x20, 0x01, 0x20, 0x00, 0x7e, 0x21, 0x01,
+			0x20, 0x00, 0x42, 0x01, 0x7d, 0x21, 0x00,
+			0x0c, 0x00, 0x0b, 0x0b,
+			0x20, 0x01, 0x0b),
+		body(vec(),
+			0x02, 0x40, 0x02, 0x40, 0x02, 0x40,
+			0x20, 0x00, 0x0e, 0x02, 0x00, 0x01, 0x02, 0x0b,
+			0x41, 0x0a, 0x0f, 0x0b,
+			0x41, 0x14, 0x0f, 0x0b,
+			0x41, 0x1e, 0x0b),
+		body(vec(),
+			0x20, 0x00, 0x41, 0x00, 0x48,
+			0x04, 0x7f, 0x41, 0x00, 0x20, 0x00, 0x6b,
+			0x05, 0x20, 0x00, 0x0b, 0x0b),
+		body(vec(), 0x20, 0x00, 0x20, 0x01, 0x6d, 0x0b),
+		body(vec(), 0x03, 0x40, 0x0c, 0x00, 0x0b, 0x0b),
+		body(vec(), 0x10, 0x05, 0x0b),
+		body(vec(), 0x20, 0x00, 0x2d, 0x00, 0x00, 0x0b),
+	)),
+	section(secData, vec(concat([]byte{0, 0x41, 0x00, 0x0b}, name("hello")))),
+	section(secCustom, concat(name("abi"), []byte("{}"))),
+)
+
+func TestCompile_Execute(t *testing.T) {
+	m, err := Compile(testModule)
+	assert.NoError(t, err)
+	abi, ok := m.CustomSection("abi")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("{}"), abi)
+	ft, ok := m.ExportedFunction("fact")
+	assert.True(t, ok)
+	assert.Equal(t, []ValueType{I64}, ft.Params)
+
+	inst, err := NewInstance(m, nil, 1000000)
+	assert.NoError(t, err)
+
+	r, err := inst.Call("fact", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{3628800}, r)
+	used := inst.StepUsed()
+	assert.True(t, used > 10)
+
+	for arg, exp := range map[uint64]uint64{0: 10, 1: 20, 2: 30, 9: 30} {
+		r, err = inst.Call("sel", arg)
+		assert.NoError(t, err)
+		assert.Equal(t, []uint64{exp}, r)
+	}
+
+	r, err = inst.Call("abs", uint64(uint32(0xfffffffb)))
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{5}, r)
+	r, err = inst.Call("abs", 7)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{7}, r)
+
+	r, err = inst.Call("div", uint64(uint32(0xfffffff6)), 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{uint64(uint32(0xfffffffd))}, r)
+	_, err = inst.Call("div", 1, 0)
+	assert.True(t, scoreresult.UnknownFailureError.Equals(err))
+	_, err = inst.Call("div", 0x80000000, uint64(uint32(0xffffffff)))
+	assert.True(t, scoreresult.UnknownFailureError.Equals(err))
+
+	r, err = inst.Call("load", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{'e'}, r)
+	_, err = inst.Call("load", PageSize)
+	assert.True(t, scoreresult.UnknownFailureError.Equals(err))
+	bs, err := inst.ReadBytes(0, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hello"), bs)
+
+	_, err = inst.Call("recurse")
+	assert.True(t, scoreresult.StackOverflowError.Equals(err))
+
+	_, err = inst.Call("spin")
+	assert.True(t, scoreresult.OutOfStepError.Equals(err))
+	assert.Equal(t, int64(1000000), inst.StepUsed())
+
+	_, err = inst.Call("unknown")
+	assert.True(t, scoreresult.MethodNotFoundError.Equals(err))
+}
+
+func TestInstance_Abort(t *testing.T) {
+	m, err := Compile(testModule)
+	assert.NoError(t, err)
+	inst, err := NewInstance(m, nil, 1<<40)
+	assert.NoError(t, err)
+	inst.Abort()
+	_, err = inst.Call("spin")
+	assert.True(t, errors.ExecutionFailError.Equals(err))
+}
+
+func TestInstance_Host(t *testing.T) {
+	bs := module(
+		section(secType, vec(
+			funcType([]byte{0x7f, 0x7f}, []byte{0x7f}),
+			funcType([]byte{0x7f}, []byte{0x7f}),
+		)),
+		section(secImport, vec(concat(name("env"), name("add"), []byte{0, 0}))),
+		section(secFunction, vec([]byte{1})),
+		section(secExport, vec(exportFunc("run", 1))),
+		section(secCode, vec(
+			body(vec(), 0x20, 0x00, 0x41, 0x0a, 0x10, 0x00, 0x0b),
+		)),
+	)
+	m, err := Compile(bs)
+	assert.NoError(t, err)
+
+	_, err = NewInstance(m, nil, 1000)
+	assert.True(t, scoreresult.IllegalFormatError.Equals(err))
+
+	var called int
+	imports := Imports{
+		"env": {
+			"add": &HostFunc{
+				Type: FuncType{Params: []ValueType{I32, I32}, Results: []ValueType{I32}},
+				Call: func(inst *Instance, args []uint64) ([]uint64, error) {
+					called++
+					if err := inst.UseSteps(100); err != nil {
+						return nil, err
+					}
+					return []uint64{uint64(uint32(args[0]) + uint32(args[1]))}, nil
+				},
+			},
+		},
+	}
+	inst, err := NewInstance(m, imports, 1000)
+	assert.NoError(t, err)
+	r, err := inst.Call("run", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{15}, r)
+	assert.Equal(t, 1, called)
+	assert.Equal(t, int64(104), inst.StepUsed())
+
+	inst, err = NewInstance(m, imports, 50)
+	assert.NoError(t, err)
+	_, err = inst.Call("run", 5)
+	assert.True(t, scoreresult.OutOfStepError.Equals(err))
+}
+
+func TestCompile_Invalid(t *testing.T) {
+	cases := map[string][]byte{
+		"magic": []byte("\x00wasm\x01\x00\x00\x00"),
+		"float": module(
+			section(secType, vec(funcType(nil, nil))),
+			section(secFunction, vec([]byte{0})),
+			section(secCode, vec(body(vec(), 0x43, 0, 0, 0, 0, 0x1a, 0x0b))),
+		),
+		"typeMismatch": module(
+			section(secType, vec(funcType(nil, []byte{0x7f}))),
+			section(secFunction, vec([]byte{0})),
+			section(secCode, vec(body(vec(), 0x42, 0x01, 0x0b))),
+		),
+		"underflow": module(
+			section(secType, vec(funcType(nil, nil))),
+			section(secFunction, vec([]byte{0})),
+			section(secCode, vec(body(vec(), 0x1a, 0x0b))),
+		),
+		"noEnd": module(
+			section(secType, vec(funcType(nil, nil))),
+			section(secFunction, vec([]byte{0})),
+			section(secCode, vec(body(vec(), 0x01))),
+		),
+		"noMemory": module(
+			section(secType, vec(funcType(nil, nil))),
+			section(secFunction, vec([]byte{0})),
+			section(secCode, vec(body(vec(), 0x41, 0x00, 0x28, 0x02, 0x00, 0x1a, 0x0b))),
+		),
+		"sectionOrder": module(
+			section(secFunction, vec()),
+			section(secType, vec()),
+		),
+	}
+	for n, bs := range cases {
+		t.Run(n, func(t *testing.T) {
+			_, err := Compile(bs)
+			assert.True(t, scoreresult.IllegalFormatError.Equals(err), "err=%+v", err)
+		})
+	}
+}